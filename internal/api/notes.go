@@ -0,0 +1,224 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/leog/RevEnGo/internal/models"
+)
+
+// listNotes handles GET /api/notes.
+// The optional project, tag and type query parameters filter the notes,
+// and q searches them.
+func (s *Server) listNotes(w http.ResponseWriter, r *http.Request) {
+	notes, err := s.Notes.ListNotes()
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	notes = models.FilterNotes(notes, models.NoteFilter{
+		ProjectID:      query.Get("project"),
+		Tag:            query.Get("tag"),
+		ReverseEngType: query.Get("type"),
+	})
+	if q := query.Get("q"); q != "" {
+		notes = models.SearchNotes(notes, q)
+	} else {
+		sort.SliceStable(notes, func(i, j int) bool {
+			return notes[i].Modified.After(notes[j].Modified)
+		})
+	}
+
+	if notes == nil {
+		notes = []*models.Note{}
+	}
+	writeJSON(w, http.StatusOK, notes)
+}
+
+// createNote handles POST /api/notes. The server assigns the ID.
+func (s *Server) createNote(w http.ResponseWriter, r *http.Request) {
+	var note models.Note
+	if !readJSON(w, r, &note) {
+		return
+	}
+	note.ID = ""
+	if !validateNote(w, &note, "", "") {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := models.SaveLinkedNote(s.Notes, &note, ""); err != nil {
+		storeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/api/notes/"+note.ID)
+	w.Header().Set("ETag", etag(note.ID, note.Modified))
+	writeJSON(w, http.StatusCreated, &note)
+}
+
+// getNote handles GET /api/notes/{id}
+func (s *Server) getNote(w http.ResponseWriter, r *http.Request) {
+	note, ok := s.loadNote(w, r)
+	if !ok {
+		return
+	}
+	tag := etag(note.ID, note.Modified)
+	if notModified(w, r, tag) {
+		return
+	}
+	w.Header().Set("ETag", tag)
+	writeJSON(w, http.StatusOK, note)
+}
+
+// replaceNote handles PUT /api/notes/{id}, replacing every field of the note
+func (s *Server) replaceNote(w http.ResponseWriter, r *http.Request) {
+	var note models.Note
+	if !readJSON(w, r, &note) {
+		return
+	}
+	s.updateNote(w, r, func(existing *models.Note) (*models.Note, error) {
+		return &note, nil
+	})
+}
+
+// patchNote handles PATCH /api/notes/{id}, changing only the fields present
+// in the request body
+func (s *Server) patchNote(w http.ResponseWriter, r *http.Request) {
+	body, ok := readPatch(w, r)
+	if !ok {
+		return
+	}
+	s.updateNote(w, r, func(existing *models.Note) (*models.Note, error) {
+		// Patch a deep copy: unmarshalling into a shallow copy would write
+		// into the details, slices and maps it shares with the existing note
+		data, err := json.Marshal(existing)
+		if err != nil {
+			return nil, err
+		}
+		var patched models.Note
+		if err := json.Unmarshal(data, &patched); err != nil {
+			return nil, err
+		}
+		return &patched, json.Unmarshal(body, &patched)
+	})
+}
+
+// updateNote loads a note, checks If-Match, and saves the version returned
+// by change. IDs and creation times cannot be changed by clients.
+func (s *Server) updateNote(w http.ResponseWriter, r *http.Request, change func(existing *models.Note) (*models.Note, error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.loadNote(w, r)
+	if !ok || !checkPrecondition(w, r, etag(existing.ID, existing.Modified)) {
+		return
+	}
+
+	previousStatus := ""
+	if existing.Vulnerability != nil {
+		previousStatus = existing.Vulnerability.Status
+	}
+	note, err := change(existing)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	note.ID = existing.ID
+	note.Created = existing.Created
+	if !validateNote(w, note, existing.ReverseEngType, previousStatus) {
+		return
+	}
+
+	if _, err := models.SaveLinkedNote(s.Notes, note, existing.Title); err != nil {
+		storeError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(note.ID, note.Modified))
+	writeJSON(w, http.StatusOK, note)
+}
+
+// deleteNote handles DELETE /api/notes/{id}
+func (s *Server) deleteNote(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	note, ok := s.loadNote(w, r)
+	if !ok || !checkPrecondition(w, r, etag(note.ID, note.Modified)) {
+		return
+	}
+	if err := s.Notes.DeleteNote(note.ID); err != nil {
+		storeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadNote loads the note named in the request path, writing a 404 if it
+// does not exist
+func (s *Server) loadNote(w http.ResponseWriter, r *http.Request) (*models.Note, bool) {
+	id := r.PathValue("id")
+	if !validID(id) {
+		writeError(w, http.StatusNotFound, "not found")
+		return nil, false
+	}
+	note, err := s.Notes.GetNote(id)
+	if err != nil {
+		storeError(w, err)
+		return nil, false
+	}
+	return note, true
+}
+
+// validateNote fills in defaults and rejects unknown note types, invalid
+// vulnerability fields and vulnerability statuses that cannot be reached
+// from the previous one, including no status. A status change is recorded
+// with today's date. A note may keep an unknown type it already had, such
+// as a type since removed from the configuration.
+func validateNote(w http.ResponseWriter, note *models.Note, previousType, previousStatus string) bool {
+	if note.Title == "" {
+		note.Title = "Untitled Note"
+	}
+	if note.ReverseEngType == "" {
+		note.ReverseEngType = models.RETypeGeneral
+	}
+	if err := note.Vulnerability.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	if err := setVulnStatus(note, previousStatus); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return false
+	}
+	if models.IsNoteType(note.ReverseEngType) || note.ReverseEngType == previousType {
+		return true
+	}
+	writeError(w, http.StatusBadRequest, "unknown note type "+note.ReverseEngType)
+	return false
+}
+
+// setVulnStatus moves a note from its previous vulnerability status to the
+// one it now holds, recording the day through SetStatus
+func setVulnStatus(note *models.Note, previousStatus string) error {
+	status := ""
+	if note.Vulnerability != nil {
+		status = note.Vulnerability.Status
+	}
+	if status == previousStatus {
+		return nil
+	}
+	if status == "" {
+		return fmt.Errorf("the status of a %s finding cannot be removed", previousStatus)
+	}
+	v := note.Vulnerability
+	v.Status = previousStatus
+	return v.SetStatus(status, time.Now())
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leog/RevEnGo/internal/models"
+)
+
+// testToken authorizes the test requests
+const testToken = "test-token"
+
+// newTestServer returns a server over an empty note store in a temporary
+// directory
+func newTestServer(t *testing.T) (*Server, models.NoteStore) {
+	t.Helper()
+	notes, err := models.NewFileNoteStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	projects, err := models.NewFileProjectStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewServer(notes, projects, nil, testToken), notes
+}
+
+// do sends an authorized request to the server and returns the response
+func do(s *Server, method, path, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer "+testToken)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, r)
+	return w
+}
+
+// fixedFinding stores a vulnerability note that reached the fixed status
+func fixedFinding(t *testing.T, notes models.NoteStore) *models.Note {
+	t.Helper()
+	note := &models.Note{
+		Title:          "Heap overflow",
+		ReverseEngType: models.RETypeVulnerability,
+		Vulnerability: &models.VulnerabilityDetails{
+			CWE:    "CWE-787",
+			Status: models.VulnStatusFixed,
+			StatusDates: map[string]string{
+				models.VulnStatusConfirmed: "2026-03-01",
+				models.VulnStatusFixed:     "2026-04-01",
+			},
+		},
+	}
+	if err := notes.SaveNote(note); err != nil {
+		t.Fatal(err)
+	}
+	return note
+}
+
+func TestUpdateNoteStatus(t *testing.T) {
+	today := time.Now().Format(models.VulnDateLayout)
+	tests := []struct {
+		name   string
+		method string
+		body   func(note *models.Note) string
+		code   int
+
+		// status and dates are the stored finding's afterwards
+		status string
+		dates  map[string]string
+	}{
+		{
+			name:   "patch back to suspected",
+			method: http.MethodPatch,
+			body:   func(*models.Note) string { return `{"vulnerability":{"status":"suspected"}}` },
+			code:   http.StatusConflict,
+			status: models.VulnStatusFixed,
+		},
+		{
+			name:   "patch to disclosed",
+			method: http.MethodPatch,
+			body:   func(*models.Note) string { return `{"vulnerability":{"status":"disclosed"}}` },
+			code:   http.StatusOK,
+			status: models.VulnStatusDisclosed,
+			dates:  map[string]string{models.VulnStatusDisclosed: today},
+		},
+		{
+			name:   "patch another field",
+			method: http.MethodPatch,
+			body:   func(*models.Note) string { return `{"title":"Heap overflow in parser"}` },
+			code:   http.StatusOK,
+			status: models.VulnStatusFixed,
+		},
+		{
+			name:   "put back to confirmed",
+			method: http.MethodPut,
+			body:   func(note *models.Note) string { return putBody(note, models.VulnStatusConfirmed) },
+			code:   http.StatusConflict,
+			status: models.VulnStatusFixed,
+		},
+		{
+			name:   "put without a status",
+			method: http.MethodPut,
+			body:   func(note *models.Note) string { return putBody(note, "") },
+			code:   http.StatusConflict,
+			status: models.VulnStatusFixed,
+		},
+		{
+			name:   "put without vulnerability details",
+			method: http.MethodPut,
+			body: func(note *models.Note) string {
+				return `{"title":"Heap overflow","reverse_eng_type":"vulnerability"}`
+			},
+			code:   http.StatusConflict,
+			status: models.VulnStatusFixed,
+		},
+		{
+			name:   "put to disclosed",
+			method: http.MethodPut,
+			body:   func(note *models.Note) string { return putBody(note, models.VulnStatusDisclosed) },
+			code:   http.StatusOK,
+			status: models.VulnStatusDisclosed,
+			dates:  map[string]string{models.VulnStatusDisclosed: today},
+		},
+	}
+	for _, tt := range tests {
+		s, notes := newTestServer(t)
+		note := fixedFinding(t, notes)
+		w := do(s, tt.method, "/api/notes/"+note.ID, tt.body(note))
+		if w.Code != tt.code {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.code, w.Body)
+		}
+
+		stored, err := notes.GetNote(note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := stored.Vulnerability; v == nil || v.Status != tt.status {
+			t.Errorf("%s: stored details %+v, want status %s", tt.name, v, tt.status)
+			continue
+		}
+		want := map[string]string{models.VulnStatusConfirmed: "2026-03-01", models.VulnStatusFixed: "2026-04-01"}
+		for status, date := range tt.dates {
+			want[status] = date
+		}
+		for status, date := range want {
+			if got := stored.Vulnerability.StatusDates[status]; got != date {
+				t.Errorf("%s: %s date %q, want %q", tt.name, status, got, date)
+			}
+		}
+	}
+}
+
+// putBody returns a finding with another status, as a PUT request body
+func putBody(note *models.Note, status string) string {
+	replaced := *note
+	details := *note.Vulnerability
+	details.Status = status
+	replaced.Vulnerability = &details
+	data, _ := json.Marshal(&replaced)
+	return string(data)
+}
+
+func TestCreateNoteStatus(t *testing.T) {
+	s, notes := newTestServer(t)
+	w := do(s, http.MethodPost, "/api/notes", `{"title":"Overflow","reverse_eng_type":"vulnerability","vulnerability":{"status":"confirmed"}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var created models.Note
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := notes.GetNote(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	today := time.Now().Format(models.VulnDateLayout)
+	if got := stored.Vulnerability.StatusDates[models.VulnStatusConfirmed]; got != today {
+		t.Errorf("confirmed date %q, want %q", got, today)
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/leog/RevEnGo/internal/models"
+)
+
+// listProjects handles GET /api/projects
+func (s *Server) listProjects(w http.ResponseWriter, r *http.Request) {
+	projects, err := s.Projects.ListProjects()
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+	sort.SliceStable(projects, func(i, j int) bool {
+		return strings.ToLower(projects[i].Name) < strings.ToLower(projects[j].Name)
+	})
+	writeJSON(w, http.StatusOK, projects)
+}
+
+// createProject handles POST /api/projects. The server assigns the ID.
+func (s *Server) createProject(w http.ResponseWriter, r *http.Request) {
+	var project models.Project
+	if !readJSON(w, r, &project) {
+		return
+	}
+	project.ID = ""
+	if strings.TrimSpace(project.Name) == "" {
+		writeError(w, http.StatusBadRequest, "a project name is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.Projects.SaveProject(&project); err != nil {
+		storeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/api/projects/"+project.ID)
+	w.Header().Set("ETag", etag(project.ID, project.Modified))
+	writeJSON(w, http.StatusCreated, &project)
+}
+
+// getProject handles GET /api/projects/{id}
+func (s *Server) getProject(w http.ResponseWriter, r *http.Request) {
+	project, ok := s.loadProject(w, r)
+	if !ok {
+		return
+	}
+	tag := etag(project.ID, project.Modified)
+	if notModified(w, r, tag) {
+		return
+	}
+	w.Header().Set("ETag", tag)
+	writeJSON(w, http.StatusOK, project)
+}
+
+// replaceProject handles PUT /api/projects/{id}
+func (s *Server) replaceProject(w http.ResponseWriter, r *http.Request) {
+	var project models.Project
+	if !readJSON(w, r, &project) {
+		return
+	}
+	s.updateProject(w, r, func(existing *models.Project) (*models.Project, error) {
+		return &project, nil
+	})
+}
+
+// patchProject handles PATCH /api/projects/{id}
+func (s *Server) patchProject(w http.ResponseWriter, r *http.Request) {
+	body, ok := readPatch(w, r)
+	if !ok {
+		return
+	}
+	s.updateProject(w, r, func(existing *models.Project) (*models.Project, error) {
+		patched := *existing
+		return &patched, json.Unmarshal(body, &patched)
+	})
+}
+
+// updateProject loads a project, checks If-Match, and saves the version
+// returned by change
+func (s *Server) updateProject(w http.ResponseWriter, r *http.Request, change func(existing *models.Project) (*models.Project, error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.loadProject(w, r)
+	if !ok || !checkPrecondition(w, r, etag(existing.ID, existing.Modified)) {
+		return
+	}
+
+	project, err := change(existing)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	project.ID = existing.ID
+	project.Created = existing.Created
+	if strings.TrimSpace(project.Name) == "" {
+		writeError(w, http.StatusBadRequest, "a project name is required")
+		return
+	}
+
+	if err := s.Projects.SaveProject(project); err != nil {
+		storeError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(project.ID, project.Modified))
+	writeJSON(w, http.StatusOK, project)
+}
+
+// deleteProject handles DELETE /api/projects/{id}.
+// Notes in the project are kept, as in the GUI.
+func (s *Server) deleteProject(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.loadProject(w, r)
+	if !ok || !checkPrecondition(w, r, etag(project.ID, project.Modified)) {
+		return
+	}
+	if err := s.Projects.DeleteProject(project.ID); err != nil {
+		storeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadProject loads the project named in the request path
+func (s *Server) loadProject(w http.ResponseWriter, r *http.Request) (*models.Project, bool) {
+	id := r.PathValue("id")
+	if !validID(id) {
+		writeError(w, http.StatusNotFound, "not found")
+		return nil, false
+	}
+	project, err := s.Projects.GetProject(id)
+	if err != nil {
+		storeError(w, err)
+		return nil, false
+	}
+	return project, true
+}
@@ -0,0 +1,272 @@
+// Package api implements RevEnGo's local HTTP/JSON API.
+// It exposes the note and project stores to tools such as disassembler
+// plugins and notebooks, with token authentication, ETag-based optimistic
+// concurrency and a Server-Sent Events stream of changes.
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leog/RevEnGo/internal/models"
+)
+
+// DefaultAddr is the address the server listens on by default.
+// Only the loopback interface is used so the API is not exposed to the network.
+const DefaultAddr = "127.0.0.1:8765"
+
+// TokenFile is the name of the file in the data directory holding the API token
+const TokenFile = "api-token"
+
+// Server serves the HTTP API over a note store and a project store
+type Server struct {
+	// Notes is the note store exposed by the API
+	Notes models.NoteStore
+
+	// Projects is the project store exposed by the API
+	Projects models.ProjectStore
+
+	// Changes reports store changes to event stream clients.
+	// The event stream is unavailable when it is nil.
+	Changes *models.ChangeFeed
+
+	// Token is the bearer token clients must present
+	Token string
+
+	// mu serializes writes so that ETag checks and saves happen atomically
+	mu sync.Mutex
+}
+
+// NewServer creates an API server.
+//
+// Parameters:
+//   - notes: The note store to expose
+//   - projects: The project store to expose
+//   - changes: The feed used for the event stream, or nil
+//   - token: The bearer token clients must present
+//
+// Returns:
+//   - A configured Server instance
+func NewServer(notes models.NoteStore, projects models.ProjectStore, changes *models.ChangeFeed, token string) *Server {
+	return &Server{
+		Notes:    notes,
+		Projects: projects,
+		Changes:  changes,
+		Token:    token,
+	}
+}
+
+// Handler returns the HTTP handler for the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	mux.Handle("GET /api/notes", s.auth(s.listNotes))
+	mux.Handle("POST /api/notes", s.auth(s.createNote))
+	mux.Handle("GET /api/notes/{id}", s.auth(s.getNote))
+	mux.Handle("PUT /api/notes/{id}", s.auth(s.replaceNote))
+	mux.Handle("PATCH /api/notes/{id}", s.auth(s.patchNote))
+	mux.Handle("DELETE /api/notes/{id}", s.auth(s.deleteNote))
+
+	mux.Handle("GET /api/projects", s.auth(s.listProjects))
+	mux.Handle("POST /api/projects", s.auth(s.createProject))
+	mux.Handle("GET /api/projects/{id}", s.auth(s.getProject))
+	mux.Handle("PUT /api/projects/{id}", s.auth(s.replaceProject))
+	mux.Handle("PATCH /api/projects/{id}", s.auth(s.patchProject))
+	mux.Handle("DELETE /api/projects/{id}", s.auth(s.deleteProject))
+
+	mux.Handle("GET /api/events", s.auth(s.events))
+
+	return mux
+}
+
+// Start listens on addr and serves the API in the background.
+//
+// Parameters:
+//   - addr: The address to listen on, e.g. DefaultAddr
+//
+// Returns:
+//   - The running HTTP server, to be stopped with Shutdown
+//   - The address actually listened on
+//   - An error if the address cannot be used
+func (s *Server) Start(addr string) (*http.Server, net.Addr, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Event streams never finish on their own, so their requests are
+	// cancelled when the server shuts down
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	srv.RegisterOnShutdown(cancel)
+	go srv.Serve(listener)
+	return srv, listener.Addr(), nil
+}
+
+// auth rejects requests without the server's bearer token.
+// The token may also be passed as the "token" query parameter, for clients
+// such as browser EventSource that cannot set headers.
+func (s *Server) auth(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if header := r.Header.Get("Authorization"); header != "" {
+			token, _ = strings.CutPrefix(header, "Bearer ")
+		}
+		if s.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="revengo"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		next(w, r)
+	})
+}
+
+// LoadToken reads the API token from the data directory, creating a new
+// random token the first time. The file is readable only by the owner.
+//
+// Parameters:
+//   - dataDir: The application data directory
+//
+// Returns:
+//   - The API token
+//   - An error if the token cannot be read or created
+func LoadToken(dataDir string) (string, error) {
+	path := filepath.Join(dataDir, TokenFile)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// validID reports whether id is a well-formed store ID. Store IDs name files,
+// so anything that could escape the storage directory is rejected.
+func validID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// etag builds an entity tag from an item's ID and modification time
+func etag(id string, modified time.Time) string {
+	return fmt.Sprintf(`"%s-%x"`, id, modified.UnixNano())
+}
+
+// checkPrecondition enforces If-Match on writes. It writes a 412 response
+// and returns false when the client's copy is out of date.
+func checkPrecondition(w http.ResponseWriter, r *http.Request, current string) bool {
+	match := r.Header.Get("If-Match")
+	if match == "" || match == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(match, ",") {
+		if strings.TrimSpace(candidate) == current {
+			return true
+		}
+	}
+	w.Header().Set("ETag", current)
+	writeError(w, http.StatusPreconditionFailed, "the item was modified since it was read")
+	return false
+}
+
+// notModified answers a conditional GET with 304 when the client's copy is current
+func notModified(w http.ResponseWriter, r *http.Request, current string) bool {
+	if r.Header.Get("If-None-Match") != current {
+		return false
+	}
+	w.Header().Set("ETag", current)
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// readJSON decodes a JSON request body into v
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, 16<<20)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// readPatch reads a PATCH request body, which must be a JSON object
+func readPatch(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 16<<20))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return nil, false
+	}
+	return body, true
+}
+
+// writeJSON writes v as a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// storeError writes the response for an error returned by a store
+func storeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
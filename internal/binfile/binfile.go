@@ -0,0 +1,370 @@
+// Package binfile reads the executables attached to projects: ELF, PE and
+// Mach-O files are parsed into sections with their file offsets and
+// virtual addresses and into the symbols of their symbol tables, and the
+// strings in them are extracted. Other files are read as raw bytes without
+// sections.
+package binfile
+
+import (
+	"bytes"
+	"cmp"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// File formats
+const (
+	FormatELF   = "elf"
+	FormatPE    = "pe"
+	FormatMachO = "macho"
+
+	// FormatRaw is any other file, read without sections
+	FormatRaw = "raw"
+)
+
+// Architectures named by Arch; other machines keep the name their format
+// gives them
+const (
+	ArchX86   = "x86"
+	ArchAMD64 = "x86-64"
+	ArchARM   = "arm"
+	ArchARM64 = "arm64"
+)
+
+// maxFileSize bounds the files that are read, as they are read whole
+const maxFileSize = 1 << 30
+
+// Section is a section of a file with data in the file
+type Section struct {
+	// Name is the section name; Mach-O sections are named
+	// "segment,section", as in "__TEXT,__cstring"
+	Name string
+
+	// Offset and Size locate the section's data in the file
+	Offset, Size uint64
+
+	// Addr is the virtual address of the section's first byte, if Mapped
+	Addr uint64
+
+	// Mapped reports a section loaded into memory, whose bytes have
+	// virtual addresses
+	Mapped bool
+
+	// Exec reports a section holding code
+	Exec bool
+}
+
+// Contains reports whether a file offset is in the section
+func (s *Section) Contains(offset uint64) bool {
+	return offset >= s.Offset && offset-s.Offset < s.Size
+}
+
+// File is a parsed executable
+type File struct {
+	Path string
+
+	// Format is one of FormatELF, FormatPE, FormatMachO or FormatRaw
+	Format string
+
+	// Arch is the machine the code is for, such as ArchAMD64; empty for
+	// raw files
+	Arch string
+
+	// Data is the whole file
+	Data []byte
+
+	// Sections are the sections with data in the file, in file order
+	Sections []Section
+
+	// Symbols are the functions and data named by the symbol tables, by
+	// address
+	Symbols []Symbol
+}
+
+// Open reads a file and parses its sections.
+//
+// Parameters:
+//   - path: The path of the file
+//
+// Returns:
+//   - The file; files that are not ELF, PE or Mach-O have the raw format
+//   - An error if the file cannot be read, or is an executable too damaged
+//     to parse
+func Open(path string) (*File, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size() > maxFileSize {
+		return nil, fmt.Errorf("%s is too large (%d bytes, at most %d)", path, info.Size(), maxFileSize)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{Path: path, Data: data}
+	switch {
+	case bytes.HasPrefix(data, []byte(elf.ELFMAG)):
+		err = f.parseELF()
+	case bytes.HasPrefix(data, []byte("MZ")):
+		err = f.parsePE()
+	case isMachO(data):
+		err = f.parseMachO()
+	default:
+		f.Format = FormatRaw
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// Sections without data in the file are of no use, and the rest must
+	// lie within it
+	f.Sections = slices.DeleteFunc(f.Sections, func(s Section) bool {
+		return s.Size == 0 || s.Offset >= uint64(len(data))
+	})
+	for i := range f.Sections {
+		f.Sections[i].Size = min(f.Sections[i].Size, uint64(len(data))-f.Sections[i].Offset)
+	}
+	slices.SortStableFunc(f.Sections, func(a, b Section) int {
+		return cmp.Compare(a.Offset, b.Offset)
+	})
+	f.sortSymbols()
+	return f, nil
+}
+
+// isMachO reports whether data starts with a Mach-O or universal header
+func isMachO(data []byte) bool {
+	if len(data) < 4 {
+		return false
+	}
+	switch magic := uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16 | uint32(data[3])<<24; magic {
+	case macho.Magic32, macho.Magic64, macho.MagicFat:
+		return true
+	case 0xcefaedfe, 0xcffaedfe, 0xbebafeca:
+		// Byte-swapped magics of big endian files
+		return true
+	}
+	return false
+}
+
+// parseELF reads the sections of an ELF file
+func (f *File) parseELF() error {
+	file, err := elf.NewFile(bytes.NewReader(f.Data))
+	if err != nil {
+		return err
+	}
+	f.Format = FormatELF
+	switch file.Machine {
+	case elf.EM_386:
+		f.Arch = ArchX86
+	case elf.EM_X86_64:
+		f.Arch = ArchAMD64
+	case elf.EM_ARM:
+		f.Arch = ArchARM
+	case elf.EM_AARCH64:
+		f.Arch = ArchARM64
+	default:
+		f.Arch = strings.ToLower(strings.TrimPrefix(file.Machine.String(), "EM_"))
+	}
+	for _, s := range file.Sections {
+		if s.Type == elf.SHT_NULL || s.Type == elf.SHT_NOBITS {
+			continue
+		}
+		f.Sections = append(f.Sections, Section{
+			Name:   s.Name,
+			Offset: s.Offset,
+			Size:   s.FileSize,
+			Addr:   s.Addr,
+			Mapped: s.Flags&elf.SHF_ALLOC != 0,
+			Exec:   s.Flags&elf.SHF_EXECINSTR != 0,
+		})
+	}
+	f.Symbols = elfSymbols(file)
+	return nil
+}
+
+// parsePE reads the sections of a PE file; addresses include the image
+// base
+func (f *File) parsePE() error {
+	file, err := pe.NewFile(bytes.NewReader(f.Data))
+	if err != nil {
+		return err
+	}
+	f.Format = FormatPE
+	switch file.Machine {
+	case pe.IMAGE_FILE_MACHINE_I386:
+		f.Arch = ArchX86
+	case pe.IMAGE_FILE_MACHINE_AMD64:
+		f.Arch = ArchAMD64
+	case pe.IMAGE_FILE_MACHINE_ARM, pe.IMAGE_FILE_MACHINE_ARMNT, pe.IMAGE_FILE_MACHINE_THUMB:
+		f.Arch = ArchARM
+	case pe.IMAGE_FILE_MACHINE_ARM64:
+		f.Arch = ArchARM64
+	default:
+		f.Arch = fmt.Sprintf("pe-machine-%#x", file.Machine)
+	}
+	var imageBase uint64
+	switch header := file.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		imageBase = uint64(header.ImageBase)
+	case *pe.OptionalHeader64:
+		imageBase = header.ImageBase
+	}
+	for _, s := range file.Sections {
+		// The raw data is padded to the file alignment; the virtual size
+		// is the part that is mapped
+		size := uint64(s.Size)
+		if s.VirtualSize != 0 {
+			size = min(size, uint64(s.VirtualSize))
+		}
+		f.Sections = append(f.Sections, Section{
+			Name:   s.Name,
+			Offset: uint64(s.Offset),
+			Size:   size,
+			Addr:   imageBase + uint64(s.VirtualAddress),
+			Mapped: true,
+			Exec:   s.Characteristics&(pe.IMAGE_SCN_CNT_CODE|pe.IMAGE_SCN_MEM_EXECUTE) != 0,
+		})
+	}
+	f.Symbols = peSymbols(file, imageBase)
+	return nil
+}
+
+// Mach-O section types and attributes
+const (
+	machoSectionType       = 0xff
+	machoZerofill          = 0x1
+	machoGBZerofill        = 0xc
+	machoThreadZerofill    = 0x12
+	machoPureInstructions  = 0x80000000
+	machoSomeInstructions  = 0x400
+	machoCodeAttributeMask = machoPureInstructions | machoSomeInstructions
+)
+
+// parseMachO reads the sections of a Mach-O file; of a universal file, the
+// first architecture is read
+func (f *File) parseMachO() error {
+	reader := bytes.NewReader(f.Data)
+	var base uint64
+	file, err := macho.NewFile(reader)
+	if err != nil {
+		fat, fatErr := macho.NewFatFile(reader)
+		if fatErr != nil {
+			// Java class files share the magic of universal files
+			if bytes.HasPrefix(f.Data, []byte{0xca, 0xfe, 0xba, 0xbe}) {
+				f.Format = FormatRaw
+				return nil
+			}
+			return err
+		}
+		if len(fat.Arches) == 0 {
+			return fmt.Errorf("universal file without architectures")
+		}
+		file, base = fat.Arches[0].File, uint64(fat.Arches[0].Offset)
+	}
+	f.Format = FormatMachO
+	switch file.Cpu {
+	case macho.Cpu386:
+		f.Arch = ArchX86
+	case macho.CpuAmd64:
+		f.Arch = ArchAMD64
+	case macho.CpuArm:
+		f.Arch = ArchARM
+	case macho.CpuArm64:
+		f.Arch = ArchARM64
+	default:
+		f.Arch = strings.ToLower(strings.TrimPrefix(file.Cpu.String(), "Cpu"))
+	}
+	for _, s := range file.Sections {
+		switch s.Flags & machoSectionType {
+		case machoZerofill, machoGBZerofill, machoThreadZerofill:
+			continue
+		}
+		if s.Offset == 0 {
+			continue
+		}
+		f.Sections = append(f.Sections, Section{
+			Name:   s.Seg + "," + s.Name,
+			Offset: base + uint64(s.Offset),
+			Size:   s.Size,
+			Addr:   s.Addr,
+			Mapped: true,
+			Exec:   s.Flags&machoCodeAttributeMask != 0,
+		})
+	}
+	f.Symbols = machoSymbols(file)
+	return nil
+}
+
+// Section returns the section with a name, or nil
+func (f *File) Section(name string) *Section {
+	for i := range f.Sections {
+		if f.Sections[i].Name == name {
+			return &f.Sections[i]
+		}
+	}
+	return nil
+}
+
+// SectionNames returns the names of the sections, in file order
+func (f *File) SectionNames() []string {
+	names := make([]string, len(f.Sections))
+	for i, s := range f.Sections {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// SectionAt returns the section holding a file offset, or nil
+func (f *File) SectionAt(offset uint64) *Section {
+	for i := range f.Sections {
+		if f.Sections[i].Contains(offset) {
+			return &f.Sections[i]
+		}
+	}
+	return nil
+}
+
+// VA returns the virtual address of the byte at a file offset.
+//
+// Parameters:
+//   - offset: The file offset
+//
+// Returns:
+//   - The virtual address
+//   - Whether the byte is in a mapped section and has an address
+func (f *File) VA(offset uint64) (uint64, bool) {
+	s := f.SectionAt(offset)
+	if s == nil || !s.Mapped {
+		return 0, false
+	}
+	return s.Addr + offset - s.Offset, true
+}
+
+// FileOffset returns the file offset of the byte at a virtual address.
+//
+// Parameters:
+//   - va: The virtual address
+//
+// Returns:
+//   - The file offset
+//   - The mapped section holding the address, or nil if the address has
+//     no data in the file
+func (f *File) FileOffset(va uint64) (uint64, *Section) {
+	for i := range f.Sections {
+		s := &f.Sections[i]
+		if s.Mapped && va >= s.Addr && va-s.Addr < s.Size {
+			return s.Offset + va - s.Addr, s
+		}
+	}
+	return 0, nil
+}
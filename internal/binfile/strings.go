@@ -0,0 +1,514 @@
+package binfile
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// String encodings
+const (
+	// EncodingASCII is printable ASCII
+	EncodingASCII = "ascii"
+
+	// EncodingUTF16LE is little endian UTF-16, the wide strings of Windows
+	EncodingUTF16LE = "utf-16le"
+
+	// EncodingUTF8 is UTF-8 text with characters beyond ASCII; text that
+	// is all ASCII is reported as EncodingASCII
+	EncodingUTF8 = "utf-8"
+)
+
+// Encodings lists the string encodings
+var Encodings = []string{EncodingASCII, EncodingUTF16LE, EncodingUTF8}
+
+// DefaultMinLength is the fewest characters a string has unless chosen
+// otherwise, as for the strings tool
+const DefaultMinLength = 4
+
+// ParseEncoding reads a string encoding, accepting "utf16", "utf16le",
+// "wide" and "utf8"
+func ParseEncoding(text string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case EncodingASCII:
+		return EncodingASCII, nil
+	case EncodingUTF16LE, "utf16le", "utf16", "utf-16", "wide":
+		return EncodingUTF16LE, nil
+	case EncodingUTF8, "utf8":
+		return EncodingUTF8, nil
+	}
+	return "", fmt.Errorf("unknown string encoding %q (supported: %s)", text, strings.Join(Encodings, ", "))
+}
+
+// StringOptions selects the strings to extract
+type StringOptions struct {
+	// MinLength is the fewest characters of a string; DefaultMinLength if
+	// zero
+	MinLength int
+
+	// Encodings are the encodings to look for; all if empty
+	Encodings []string
+
+	// Sections are the names of the sections to search; the whole file
+	// if empty
+	Sections []string
+}
+
+// String is a string found in a file
+type String struct {
+	// Offset and Size locate the string's bytes in the file
+	Offset uint64
+	Size   int
+
+	// VA is the virtual address of the first byte, if Mapped
+	VA     uint64
+	Mapped bool
+
+	// Section is the name of the section holding the string; empty if it
+	// lies outside sections
+	Section string
+
+	Encoding string
+	Text     string
+}
+
+// Location formats the address of the string: its virtual address, or its
+// file offset if it has none
+func (s String) Location() string {
+	if s.Mapped {
+		return fmt.Sprintf("0x%x", s.VA)
+	}
+	return fmt.Sprintf("offset 0x%x", s.Offset)
+}
+
+// AddressRange formats the bytes of the string as a note address range,
+// such as "0x402010-0x40201f". Strings without a virtual address give their
+// file offsets.
+func (s String) AddressRange() string {
+	start := s.Offset
+	if s.Mapped {
+		start = s.VA
+	}
+	return fmt.Sprintf("0x%x-0x%x", start, start+uint64(max(s.Size, 1))-1)
+}
+
+// maxTitleLength bounds the characters of a string quoted in a note title
+const maxTitleLength = 60
+
+// NoteTitle returns the title of a note about the string, quoting the
+// string's start
+func (s String) NoteTitle() string {
+	text := strings.Join(strings.Fields(s.Text), " ")
+	if runes := []rune(text); len(runes) > maxTitleLength {
+		text = string(runes[:maxTitleLength]) + "..."
+	}
+	return fmt.Sprintf("String %q", text)
+}
+
+// Strings extracts the strings of the file.
+//
+// Parameters:
+//   - opts: The encodings, sections and minimum length of the strings
+//
+// Returns:
+//   - The strings, in file order
+//   - An error if an encoding or section is unknown
+func (f *File) Strings(opts StringOptions) ([]String, error) {
+	minLength := opts.MinLength
+	if minLength <= 0 {
+		minLength = DefaultMinLength
+	}
+	var wantASCII, wantUTF16, wantUTF8 bool
+	if len(opts.Encodings) == 0 {
+		wantASCII, wantUTF16, wantUTF8 = true, true, true
+	}
+	for _, text := range opts.Encodings {
+		encoding, err := ParseEncoding(text)
+		if err != nil {
+			return nil, err
+		}
+		switch encoding {
+		case EncodingASCII:
+			wantASCII = true
+		case EncodingUTF16LE:
+			wantUTF16 = true
+		case EncodingUTF8:
+			wantUTF8 = true
+		}
+	}
+
+	// The regions searched, as file offsets
+	type region struct{ start, end uint64 }
+	regions := []region{{0, uint64(len(f.Data))}}
+	if len(opts.Sections) > 0 {
+		regions = nil
+		for _, name := range opts.Sections {
+			s := f.Section(name)
+			if s == nil {
+				return nil, fmt.Errorf("no section %q in %s (sections: %s)", name, f.Path, strings.Join(f.SectionNames(), ", "))
+			}
+			regions = append(regions, region{s.Offset, s.Offset + s.Size})
+		}
+	}
+
+	var found []String
+	emit := func(start, end uint64, encoding, text string) {
+		s := String{Offset: start, Size: int(end - start), Encoding: encoding, Text: text}
+		if section := f.SectionAt(start); section != nil {
+			s.Section = section.Name
+			if section.Mapped {
+				s.VA, s.Mapped = section.Addr+start-section.Offset, true
+			}
+		}
+		found = append(found, s)
+	}
+	for _, r := range regions {
+		data := f.Data[r.start:r.end]
+		if wantASCII || wantUTF8 {
+			scanBytes(data, minLength, wantASCII, wantUTF8, func(start, end int, encoding string) {
+				emit(r.start+uint64(start), r.start+uint64(end), encoding, string(data[start:end]))
+			})
+		}
+		if wantUTF16 {
+			scanUTF16(data, minLength, func(start, end int, text string) {
+				// Code passes for East Asian text far more often than
+				// it holds any
+				if s := f.SectionAt(r.start + uint64(start)); s != nil && s.Exec && eastAsian([]rune(text)) {
+					return
+				}
+				emit(r.start+uint64(start), r.start+uint64(end), EncodingUTF16LE, text)
+			})
+		}
+	}
+	slices.SortStableFunc(found, func(a, b String) int { return cmp.Compare(a.Offset, b.Offset) })
+	return found, nil
+}
+
+// printableASCII reports whether a byte is printable ASCII or a tab
+func printableASCII(b byte) bool {
+	return b >= 0x20 && b < 0x7f || b == '\t'
+}
+
+// scanBytes finds runs of printable ASCII and UTF-8 characters.
+//
+// Parameters:
+//   - data: The bytes to search
+//   - minLength: The fewest characters of a run
+//   - ascii: Report runs that are all ASCII
+//   - multibyte: Decode UTF-8 and report runs with characters beyond ASCII
+//   - emit: Receives the byte range and encoding of each run
+func scanBytes(data []byte, minLength int, ascii, multibyte bool, emit func(start, end int, encoding string)) {
+	start, length, beyondASCII := -1, 0, false
+	flush := func(end int) {
+		if start >= 0 && length >= minLength {
+			switch {
+			case beyondASCII && multibyte:
+				emit(start, end, EncodingUTF8)
+			case !beyondASCII && ascii:
+				emit(start, end, EncodingASCII)
+			}
+		}
+		start, length, beyondASCII = -1, 0, false
+	}
+	for i := 0; i < len(data); {
+		size := 1
+		switch b := data[i]; {
+		case printableASCII(b):
+		case multibyte && b >= utf8.RuneSelf:
+			r, n := utf8.DecodeRune(data[i:])
+			if r == utf8.RuneError || !unicode.IsPrint(r) {
+				flush(i)
+				i++
+				continue
+			}
+			size, beyondASCII = n, true
+		default:
+			flush(i)
+			i++
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		length++
+		i += size
+	}
+	flush(len(data))
+}
+
+// maxUTF16Unit bounds the UTF-16 code units taken for characters of any
+// kind, which covers Latin, Greek, Cyrillic, Hebrew and Arabic text. Above
+// it only the East Asian scripts and utf16Symbols are taken.
+const maxUTF16Unit = 0x800
+
+// utf16Scripts are the letters of the scripts that make up a UTF-16 string
+// beyond ASCII, each as ranges of code units: basic Greek, Cyrillic,
+// Hebrew and Arabic, then the East Asian scripts from eastAsianScript on.
+// Letters of the Latin blocks and the rarer Cyrillic capitals only count in
+// mostly ASCII strings, as the code units of tables of small numbers read
+// as them.
+var utf16Scripts = [][][2]rune{
+	{{0x386, 0x3ce}},
+	{{0x410, 0x45f}},
+	{{0x5d0, 0x5ea}},
+	{{0x620, 0x64a}},
+
+	// Chinese and Japanese: CJK punctuation, kana, ideographs and
+	// fullwidth forms
+	{{0x3000, 0x30ff}, {0x4e00, 0x9fff}, {0xff01, 0xffee}},
+
+	// Korean: jamo and syllables
+	{{0x3131, 0x318e}, {0xac00, 0xd7a3}},
+}
+
+// eastAsianScript is the index of the first East Asian script in
+// utf16Scripts. A third of all code units are East Asian characters, so
+// random bytes, and ASCII text read as UTF-16, easily pass for them.
+const eastAsianScript = 4
+
+// utf16Symbols are the code units beyond maxUTF16Unit of punctuation and
+// symbols common in version information and messages, such as "…", "€"
+// and "™": general punctuation, currency and letterlike symbols. They
+// count as ASCII, but make up at most a third of a run: pairs of ASCII
+// spaces and punctuation read as them.
+var utf16Symbols = [][2]rune{{0x2010, 0x2027}, {0x2030, 0x205e}, {0x20a0, 0x20c0}, {0x2100, 0x214f}}
+
+// minASCIIShare is the share of ASCII characters that makes a UTF-16 run
+// text even when its other characters are not letters of one script
+const minASCIIShare = 0.75
+
+// utf16Char reports whether a UTF-16 code unit may be a character of a
+// string. Combining marks are left out: on their own they are far more
+// often bytes of code or tables than accents.
+func utf16Char(unit rune) bool {
+	switch {
+	case unit < utf8.RuneSelf:
+		return printableASCII(byte(unit))
+	case unit >= maxUTF16Unit:
+		return inRanges(unit, utf16Symbols) || utf16Script(unit) >= eastAsianScript
+	}
+	return unicode.IsPrint(unit) && !unicode.In(unit, unicode.Mn, unicode.Me)
+}
+
+// inRanges reports whether a code unit lies in one of the ranges
+func inRanges(unit rune, ranges [][2]rune) bool {
+	return slices.ContainsFunc(ranges, func(r [2]rune) bool { return unit >= r[0] && unit <= r[1] })
+}
+
+// utf16Script returns the index in utf16Scripts of the script of a code
+// unit, or -1 if it is not a letter of one
+func utf16Script(unit rune) int {
+	return slices.IndexFunc(utf16Scripts, func(ranges [][2]rune) bool { return inRanges(unit, ranges) })
+}
+
+// plausibleText reports whether a run of UTF-16 characters reads as text:
+// mostly ASCII, or ASCII and the letters of a single script. Random code
+// units scatter over the Latin, Greek, Cyrillic and other blocks. Runs
+// read one byte off, or mostly of symbols, are left out.
+func plausibleText(text []rune) bool {
+	ascii, symbols, script := 0, 0, -1
+	oneScript := true
+	for _, r := range text {
+		if r < utf8.RuneSelf || inRanges(r, utf16Symbols) {
+			if r >= utf8.RuneSelf {
+				symbols++
+			}
+			ascii++
+			continue
+		}
+		i := utf16Script(r)
+		switch {
+		case i < 0:
+			oneScript = false
+		case script < 0:
+			script = i
+		case script != i:
+			oneScript = false
+		}
+	}
+	if misaligned(text) || 3*symbols > len(text) {
+		return false
+	}
+	return oneScript || float64(ascii) >= minASCIIShare*float64(len(text))
+}
+
+// misaligned reports whether a run of UTF-16 characters is other text read
+// one byte off: its code units hold printable ASCII or zero bytes, mostly
+// in both halves, as ASCII text does, or all end in the high byte of
+// characters below maxUTF16Unit, as UTF-16 text of those characters does
+func misaligned(text []rune) bool {
+	ascii, shadows, shifted := true, 0, true
+	for _, r := range text {
+		high, low := byte(r>>8), byte(r)
+		switch {
+		case r < utf8.RuneSelf:
+		case printableASCII(high) && (low == 0 || printableASCII(low)):
+			shadows++
+		default:
+			ascii = false
+		}
+		shifted = shifted && r >= maxUTF16Unit && low < maxUTF16Unit>>8
+	}
+	return ascii && 2*shadows > len(text) || shifted
+}
+
+// eastAsian reports whether a run of UTF-16 characters holds letters of
+// an East Asian script
+func eastAsian(text []rune) bool {
+	return slices.ContainsFunc(text, func(r rune) bool { return utf16Script(r) >= eastAsianScript })
+}
+
+// plausibleEastAsian reports whether a run of UTF-16 characters with East
+// Asian letters reads as text rather than a table: other characters mix in
+// as words and punctuation, with at most one stray character between East
+// Asian letters, and the ideographs and syllables seldom share their high
+// byte with one of the two before or hold a zero low byte, as "一" does,
+// nor all hold a small low byte.
+func plausibleEastAsian(text []rune) bool {
+	letter := func(i int) bool { return i < 0 || i >= len(text) || utf16Script(text[i]) >= eastAsianScript }
+	stray, pairs, sameHigh, zeroLow, smallLow := 0, 0, 0, 0, true
+	for i, r := range text {
+		if r != ' ' && !letter(i) && letter(i-1) && letter(i+1) {
+			stray++
+		}
+		if r >= utf8.RuneSelf {
+			smallLow = smallLow && r&0xff < 0x10
+		}
+		if !ideograph(r) {
+			continue
+		}
+		if r&0xff == 0 {
+			zeroLow++
+		}
+		var before []rune
+		for j := max(i-2, 0); j < i; j++ {
+			if ideograph(text[j]) {
+				before = append(before, text[j])
+			}
+		}
+		if len(before) > 0 {
+			pairs++
+			if slices.ContainsFunc(before, func(b rune) bool { return b>>8 == r>>8 }) {
+				sameHigh++
+			}
+		}
+	}
+	return stray <= 1 && 3*sameHigh <= pairs && 8*zeroLow <= len(text) && !smallLow
+}
+
+// ideograph reports whether a code unit is a CJK ideograph or a Hangul
+// syllable, whose high byte varies in text, unlike that of kana
+func ideograph(unit rune) bool {
+	return unit >= 0x4e00 && unit <= 0x9fff || unit >= 0xac00 && unit <= 0xd7a3
+}
+
+// scanUTF16 finds runs of printable little endian UTF-16 characters, at
+// even and odd offsets, that read as text. Runs with East Asian letters
+// must also start and end at a NUL or the edge of the data, as strings
+// do, and not read as a table.
+//
+// Parameters:
+//   - data: The bytes to search
+//   - minLength: The fewest characters of a run
+//   - emit: Receives the byte range and text of each run
+func scanUTF16(data []byte, minLength int, emit func(start, end int, text string)) {
+	for align := range 2 {
+		start := -1
+		var text []rune
+		unitAt := func(i int) rune {
+			return rune(data[i]) | rune(data[i+1])<<8
+		}
+		bounded := func(start, end int) bool {
+			return (start < 2 || unitAt(start-2) == 0) && (end+1 >= len(data) || unitAt(end) == 0)
+		}
+		report := func(start int, text []rune) bool {
+			end := start + 2*len(text)
+			if len(text) < minLength || !plausibleText(text) || eastAsian(text) && !(bounded(start, end) && plausibleEastAsian(text)) {
+				return false
+			}
+			emit(start, end, string(text))
+			return true
+		}
+		flush := func() {
+			// Runs of East Asian letters that do not read as text may
+			// still hold strings between them
+			if start >= 0 && !report(start, text) && eastAsian(text) {
+				from := 0
+				for j, r := range text {
+					if utf16Script(r) >= eastAsianScript {
+						report(start+2*from, text[from:j])
+						from = j + 1
+					}
+				}
+				report(start+2*from, text[from:])
+			}
+			start, text = -1, text[:0]
+		}
+		for i := align; i+1 < len(data); i += 2 {
+			unit := unitAt(i)
+			if !utf16Char(unit) && unit != '\t' {
+				flush()
+				continue
+			}
+			if start < 0 {
+				start = i
+			}
+			text = append(text, unit)
+		}
+		flush()
+	}
+}
+
+// FilterStrings returns the strings containing a query, ignoring case.
+//
+// Parameters:
+//   - found: The strings to filter
+//   - query: The text to look for; empty keeps every string
+//
+// Returns:
+//   - The matching strings, in their order
+func FilterStrings(found []String, query string) []String {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return found
+	}
+	var matched []String
+	for _, s := range found {
+		if strings.Contains(strings.ToLower(s.Text), query) {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
+
+// StringAt returns the string holding the byte at a file offset.
+//
+// Parameters:
+//   - offset: The file offset
+//   - encodings: The encodings the string may have; all if empty
+//
+// Returns:
+//   - The string, however short
+//   - An error if no printable string holds the byte
+func (f *File) StringAt(offset uint64, encodings []string) (String, error) {
+	if offset >= uint64(len(f.Data)) {
+		return String{}, fmt.Errorf("offset 0x%x is beyond the end of %s (%d bytes)", offset, f.Path, len(f.Data))
+	}
+	opts := StringOptions{MinLength: 1, Encodings: encodings}
+	if s := f.SectionAt(offset); s != nil {
+		opts.Sections = []string{s.Name}
+	}
+	found, err := f.Strings(opts)
+	if err != nil {
+		return String{}, err
+	}
+	for _, s := range found {
+		if offset >= s.Offset && offset-s.Offset < uint64(s.Size) {
+			return s, nil
+		}
+	}
+	return String{}, fmt.Errorf("no string at offset 0x%x of %s", offset, f.Path)
+}
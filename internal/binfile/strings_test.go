@@ -0,0 +1,220 @@
+package binfile
+
+import (
+	"slices"
+	"testing"
+	"unicode/utf16"
+)
+
+// wide encodes text as little endian UTF-16
+func wide(text string) []byte {
+	var data []byte
+	for _, unit := range utf16.Encode([]rune(text)) {
+		data = append(data, byte(unit), byte(unit>>8))
+	}
+	return data
+}
+
+// units encodes code units as little endian UTF-16, for runs that are not
+// valid text
+func units(codes ...rune) []byte {
+	var data []byte
+	for _, unit := range codes {
+		data = append(data, byte(unit), byte(unit>>8))
+	}
+	return data
+}
+
+// fixture returns a raw file holding the bytes, with a mapped .rodata
+// section from offset 0x40 to the end
+func fixture(parts ...[]byte) *File {
+	data := slices.Concat(parts...)
+	return &File{
+		Path:     "fixture",
+		Format:   FormatRaw,
+		Data:     data,
+		Sections: []Section{{Name: ".rodata", Offset: 0x40, Size: uint64(len(data)) - 0x40, Addr: 0x402000, Mapped: true}},
+	}
+}
+
+// pad returns n zero bytes
+func pad(n int) []byte {
+	return make([]byte, n)
+}
+
+func TestStrings(t *testing.T) {
+	f := fixture(
+		pad(2), []byte("usage: %s <file>"), pad(1), // 0x02, 16 bytes
+		[]byte{0xff, 0x01}, []byte("ab"), pad(7), // too short
+		wide("Access denied"), pad(2), // 0x1e, 26 bytes
+		pad(0x40-0x3a),
+		[]byte("naïve café"), pad(2), // 0x40, 12 bytes
+		wide("Привет мир"), pad(2), // 0x4e, 20 bytes
+	)
+	want := []String{
+		{Offset: 0x02, Size: 16, Encoding: EncodingASCII, Text: "usage: %s <file>"},
+		{Offset: 0x1e, Size: 26, Encoding: EncodingUTF16LE, Text: "Access denied"},
+		{Offset: 0x40, Size: 12, VA: 0x402000, Mapped: true, Section: ".rodata", Encoding: EncodingUTF8, Text: "naïve café"},
+		{Offset: 0x4e, Size: 20, VA: 0x40200e, Mapped: true, Section: ".rodata", Encoding: EncodingUTF16LE, Text: "Привет мир"},
+	}
+	got, err := f.Strings(StringOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Strings() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// Encodings and sections narrow the search; ASCII alone splits UTF-8
+	// text at its other characters
+	na := String{Offset: 0x40, Size: 2, VA: 0x402000, Mapped: true, Section: ".rodata", Encoding: EncodingASCII, Text: "na"}
+	veCaf := String{Offset: 0x44, Size: 6, VA: 0x402004, Mapped: true, Section: ".rodata", Encoding: EncodingASCII, Text: "ve caf"}
+	tests := []struct {
+		opts StringOptions
+		want []String
+	}{
+		{StringOptions{Encodings: []string{"wide"}}, []String{want[1], want[3]}},
+		{StringOptions{Encodings: []string{EncodingASCII}}, []String{want[0], veCaf}},
+		{StringOptions{Sections: []string{".rodata"}}, want[2:]},
+		{StringOptions{MinLength: 2, Encodings: []string{EncodingASCII}}, []String{want[0], {Offset: 0x15, Size: 2, Encoding: EncodingASCII, Text: "ab"}, na, veCaf}},
+		{StringOptions{MinLength: 14}, []String{want[0]}},
+	}
+	for _, tt := range tests {
+		got, err := f.Strings(tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Strings(%+v) =\n%+v\nwant\n%+v", tt.opts, got, tt.want)
+		}
+	}
+
+	for _, opts := range []StringOptions{{Encodings: []string{"ebcdic"}}, {Sections: []string{".data"}}} {
+		if _, err := f.Strings(opts); err == nil {
+			t.Errorf("Strings(%+v) succeeded", opts)
+		}
+	}
+}
+
+func TestScanUTF16(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []string
+	}{
+		{"ascii", wide("GetProcAddress"), []string{"GetProcAddress"}},
+		{"odd offset", slices.Concat(pad(1), wide("kernel32.dll")), []string{"kernel32.dll"}},
+		{"greek", wide("Καλημέρα"), []string{"Καλημέρα"}},
+		{"cyrillic", wide("Файл не найден"), []string{"Файл не найден"}},
+		{"hebrew", wide("שלום עולם"), []string{"שלום עולם"}},
+		{"arabic", wide("مرحبا بالعالم"), []string{"مرحبا بالعالم"}},
+		{"latin accents in ascii", wide("Résumé file"), []string{"Résumé file"}},
+		{"chinese", wide("文件不存在"), []string{"文件不存在"}},
+		{"chinese with one", wide("请再试一次，或联系管理员"), []string{"请再试一次，或联系管理员"}},
+		{"japanese", wide("ファイルを開けません。"), []string{"ファイルを開けません。"}},
+		{"korean", wide("파일을 찾을 수 없습니다"), []string{"파일을 찾을 수 없습니다"}},
+		{"japanese and ascii", slices.Concat(wide("設定: config.ini"), pad(2), wide("表示")), []string{"設定: config.ini"}},
+		{"symbols", slices.Concat(wide("Acme™ Tools"), pad(2), wide("Price: 5 €"), pad(2), wide("Loading…")), []string{"Acme™ Tools", "Price: 5 €", "Loading…"}},
+		{"too short", wide("abc"), nil},
+		{"tab", wide("a\tbcd"), []string{"a\tbcd"}},
+
+		// Combining marks break runs
+		{"combining marks", units(0x301, 0x302, 0x303, 0x304, 0x305, 0x306), nil},
+		{"combining mark between letters", units('a', 'b', 0x301, 'c', 'd'), nil},
+
+		// East Asian letters between other characters, as random data gives
+		{"cjk between characters", units(0xe000, 0x6587, 0x5b57, 0x5217, 0x8868, 0xe000), nil},
+		{"cjk between zeros", units(0, 0x6587, 0x5b57, 0x5217, 0x8868, 0), []string{"文字列表"}},
+		{"ascii after cjk", slices.Concat(units(0xffff, 0x6e6f, 0x6f6f), wide("Copyright")), []string{"Copyright"}},
+		{"cjk and stray ascii", units(0, 0x4e00, '<', 0x4e00, '<', 0x5100, '<', 0), nil},
+		{"cjk and a digit", wide("第3章を参照"), []string{"第3章を参照"}},
+		{"hangul table", units(0, 0xb2cf, 0xb2d6, 0xb2d3, 0xb2d9, 0xb2d8, 0), nil},
+		{"cjk and stray symbols", units(0, 0x7407, 0x205c, 0x9900, 0x205c, 0), nil},
+		{"cjk zero low bytes", units(0, 0x7000, 0x7c21, 0x7d00, 0x64e3, 0x9700, 0), nil},
+		{"cjk small low bytes", units(0, 0x900e, 0x8301, 0x8607, 0x8c06, 0x8d05, 0x3002, 0), nil},
+
+		// Text read one byte off
+		{"ascii", []byte("\x00hello world\x00"), nil},
+		{"wide ascii one byte off", slices.Concat(pad(1), wide("access denied"), pad(2)), []string{"access denied"}},
+
+		// Code units beyond the scripts, as random data gives
+		{"small numbers", units(0x100, 0x101, 0x102, 0x103, 0x104, 0x105), nil},
+		{"mixed scripts", units(0x3a3, 0x416, 0x5d0, 0x634, 0x3b1, 0x436), nil},
+		{"latin and greek", units(0x100, 0x3a3, 0x17f, 0x3b1, 0x1e9, 0x3c9), nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		scanUTF16(tt.data, DefaultMinLength, func(start, end int, text string) {
+			got = append(got, text)
+		})
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: scanUTF16(% x) = %q, want %q", tt.name, tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestPlausibleText(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"plain ascii", true},
+		{"Ошибка чтения", true},
+		{"Ошибка: file", true},
+		{"Déjà vu, mon ami", true},
+		{"ÀÉÎÕÜ", false},
+		{"ΣЖ", false},
+		{"ab ΣЖ", false},
+		{"abcdefgh ΣЖ", true},
+		{"ファイル: a.txt", true},
+		{"ファイル파일", false},
+		{"Price: 5 €…", true},
+		{"…—€™", false},
+		{"‐†††Р", false},
+		{"敨汬", false},
+		{"넃묃뜃밃", false},
+	}
+	for _, tt := range tests {
+		if got := plausibleText([]rune(tt.text)); got != tt.want {
+			t.Errorf("plausibleText(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestStringAt(t *testing.T) {
+	f := fixture(pad(4), []byte("config.ini"), pad(0x40-14), wide("Ключ"), pad(2))
+	tests := []struct {
+		offset uint64
+		want   string
+	}{
+		{4, "config.ini"},
+		{9, "config.ini"},
+		{0x40, "Ключ"},
+		{0x45, "Ключ"},
+	}
+	for _, tt := range tests {
+		s, err := f.StringAt(tt.offset, nil)
+		if err != nil {
+			t.Errorf("StringAt(0x%x): %v", tt.offset, err)
+			continue
+		}
+		if s.Text != tt.want {
+			t.Errorf("StringAt(0x%x) = %q, want %q", tt.offset, s.Text, tt.want)
+		}
+	}
+	for _, offset := range []uint64{0, uint64(len(f.Data))} {
+		if s, err := f.StringAt(offset, nil); err == nil {
+			t.Errorf("StringAt(0x%x) = %q, want an error", offset, s.Text)
+		}
+	}
+}
+
+func TestFilterStrings(t *testing.T) {
+	found := []String{{Text: "Access Denied"}, {Text: "usage"}, {Text: "access.log"}}
+	if got := FilterStrings(found, " ACCESS "); !slices.Equal(got, []String{found[0], found[2]}) {
+		t.Errorf("FilterStrings() = %+v", got)
+	}
+	if got := FilterStrings(found, ""); len(got) != len(found) {
+		t.Errorf("FilterStrings with no query = %+v", got)
+	}
+}
@@ -0,0 +1,136 @@
+package binfile
+
+import (
+	"cmp"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"slices"
+)
+
+// Symbol is a named address of a file, from its symbol tables
+type Symbol struct {
+	Name string
+	Addr uint64
+
+	// Size is the number of bytes the symbol covers; zero if unknown
+	Size uint64
+}
+
+// maxSymbolLookback bounds the symbols before an address searched for a
+// sized symbol covering it, past the labels inside a function
+const maxSymbolLookback = 64
+
+// SymbolAt names the address through the symbol covering it: a sized
+// symbol holding the address, or else the closest symbol of unknown size
+// before it in the same section.
+//
+// Parameters:
+//   - addr: The virtual address
+//
+// Returns:
+//   - The name of the symbol; empty if none covers the address
+//   - The address of the symbol, which addr is an offset from
+func (f *File) SymbolAt(addr uint64) (string, uint64) {
+	// Symbols before i start at or before the address
+	i, _ := slices.BinarySearchFunc(f.Symbols, addr, func(s Symbol, addr uint64) int {
+		return cmp.Compare(s.Addr, addr+1)
+	})
+	for j := i - 1; j >= 0 && j >= i-maxSymbolLookback; j-- {
+		if s := f.Symbols[j]; s.Size > 0 && addr-s.Addr < s.Size {
+			return s.Name, s.Addr
+		}
+	}
+	if i == 0 {
+		return "", 0
+	}
+	s := f.Symbols[i-1]
+	if s.Addr == addr {
+		return s.Name, s.Addr
+	}
+	_, section := f.FileOffset(addr)
+	if _, symbolSection := f.FileOffset(s.Addr); s.Size > 0 || section == nil || section != symbolSection {
+		return "", 0
+	}
+	return s.Name, s.Addr
+}
+
+// SymbolNamed returns the symbol with a name
+func (f *File) SymbolNamed(name string) (Symbol, bool) {
+	for _, s := range f.Symbols {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Symbol{}, false
+}
+
+// sortSymbols orders the symbols by address, sized ones first, and drops
+// duplicates
+func (f *File) sortSymbols() {
+	slices.SortStableFunc(f.Symbols, func(a, b Symbol) int {
+		if c := cmp.Compare(a.Addr, b.Addr); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Size, a.Size)
+	})
+	f.Symbols = slices.CompactFunc(f.Symbols, func(a, b Symbol) bool {
+		return a.Name == b.Name && a.Addr == b.Addr
+	})
+}
+
+// elfSymbols reads the static and dynamic symbols of functions and data;
+// either table may be missing
+func elfSymbols(file *elf.File) []Symbol {
+	var symbols []Symbol
+	static, _ := file.Symbols()
+	dynamic, _ := file.DynamicSymbols()
+	for _, s := range append(static, dynamic...) {
+		switch elf.ST_TYPE(s.Info) {
+		case elf.STT_FUNC, elf.STT_OBJECT, elf.STT_NOTYPE, elf.STT_GNU_IFUNC:
+		default:
+			continue
+		}
+		// ARM mapping symbols such as $x and $d mark code and data, not
+		// functions
+		if s.Name == "" || s.Name[0] == '$' || s.Value == 0 || s.Section == elf.SHN_UNDEF {
+			continue
+		}
+		symbols = append(symbols, Symbol{Name: s.Name, Addr: s.Value, Size: s.Size})
+	}
+	return symbols
+}
+
+// peSymbols reads the COFF symbols of a PE file, which linkers keep for
+// debug builds and MinGW binaries
+func peSymbols(file *pe.File, imageBase uint64) []Symbol {
+	var symbols []Symbol
+	for _, s := range file.Symbols {
+		// Section numbers start at 1; others are absolute or debug values.
+		// Section symbols such as .text only repeat section names.
+		if s.SectionNumber < 1 || int(s.SectionNumber) > len(file.Sections) || s.Name == "" || s.Name[0] == '.' {
+			continue
+		}
+		section := file.Sections[s.SectionNumber-1]
+		symbols = append(symbols, Symbol{Name: s.Name, Addr: imageBase + uint64(section.VirtualAddress) + uint64(s.Value)})
+	}
+	return symbols
+}
+
+// machoStab masks the type bits of debugger symbol table entries
+const machoStab = 0xe0
+
+// machoSymbols reads the defined symbols of a Mach-O file
+func machoSymbols(file *macho.File) []Symbol {
+	if file.Symtab == nil {
+		return nil
+	}
+	var symbols []Symbol
+	for _, s := range file.Symtab.Syms {
+		if s.Type&machoStab != 0 || s.Sect == 0 || s.Name == "" {
+			continue
+		}
+		symbols = append(symbols, Symbol{Name: s.Name, Addr: s.Value})
+	}
+	return symbols
+}
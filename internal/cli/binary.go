@@ -0,0 +1,375 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/leog/RevEnGo/internal/binfile"
+	"github.com/leog/RevEnGo/internal/disasm"
+	"github.com/leog/RevEnGo/internal/models"
+)
+
+// binarySubcommands lists the subcommands of "revengo binary"
+var binarySubcommands = []subcommand{
+	{"list", "[project]", "List the binaries attached to projects", (*CLI).binaryList},
+	{"attach", "<project> <file>...", "Attach executables, libraries or firmware images to a project", (*CLI).binaryAttach},
+	{"detach", "<project> <file>...", "Detach binaries from a project, keeping the files", (*CLI).binaryDetach},
+	{"sections", "<file>", "List the sections of a binary with their offsets and addresses", (*CLI).binarySections},
+	{"strings", "<file> [-min N] [-encoding E] [-section S] [-search Q]", "List the ASCII, UTF-16LE and UTF-8 strings of a binary", (*CLI).binaryStrings},
+	{"note", "<file> <address> [-offset] [fields]", "Create a note about the string at an address", (*CLI).binaryNote},
+	{"disasm", "<file> <range> [-bytes] [-fenced]", "Disassemble an address range of an x86, x86-64 or ARM64 binary", (*CLI).binaryDisasm},
+}
+
+// runBinary dispatches "revengo binary" subcommands
+func (c *CLI) runBinary(args []string) error {
+	return c.runSubcommand("binary", binarySubcommands, args)
+}
+
+// binaryList implements "revengo binary list"
+func (c *CLI) binaryList(args []string) error {
+	fs := c.newFlagSet("binary list", "[project] [-json]")
+	asJSON := fs.Bool("json", false, "print JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 0, 1); err != nil {
+		return err
+	}
+
+	var projects []*models.Project
+	if len(rest) == 1 {
+		project, err := c.findProject(rest[0])
+		if err != nil {
+			return err
+		}
+		projects = []*models.Project{project}
+	} else if projects, err = c.Projects.ListProjects(); err != nil {
+		return fmt.Errorf("listing projects: %w", err)
+	}
+
+	type attached struct {
+		Project string `json:"project"`
+		File    string `json:"file"`
+	}
+	var binaries []attached
+	for _, project := range projects {
+		for _, path := range project.Binaries {
+			binaries = append(binaries, attached{project.ID, path})
+		}
+	}
+	if *asJSON {
+		return c.writeJSON(nonNil(binaries))
+	}
+	w := tabwriter.NewWriter(c.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tFILE")
+	for _, binary := range binaries {
+		fmt.Fprintf(w, "%s\t%s\n", binary.Project, binary.File)
+	}
+	return w.Flush()
+}
+
+// binaryAttach implements "revengo binary attach"
+func (c *CLI) binaryAttach(args []string) error {
+	fs := c.newFlagSet("binary attach", "<project> <file>...")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 2, -1); err != nil {
+		return err
+	}
+
+	project, err := c.findProject(rest[0])
+	if err != nil {
+		return err
+	}
+	for _, file := range rest[1:] {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		// Only readable files are attached
+		if _, err := binfile.Open(path); err != nil {
+			return err
+		}
+		if !slices.Contains(project.Binaries, path) {
+			project.Binaries = append(project.Binaries, path)
+		}
+	}
+	if err := c.Projects.SaveProject(project); err != nil {
+		return fmt.Errorf("saving project: %w", err)
+	}
+	fmt.Fprintln(c.Stdout, project.ID)
+	return nil
+}
+
+// binaryDetach implements "revengo binary detach"
+func (c *CLI) binaryDetach(args []string) error {
+	fs := c.newFlagSet("binary detach", "<project> <file>...")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 2, -1); err != nil {
+		return err
+	}
+
+	project, err := c.findProject(rest[0])
+	if err != nil {
+		return err
+	}
+	for _, file := range rest[1:] {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		index := slices.Index(project.Binaries, path)
+		if index < 0 {
+			return fmt.Errorf("%s is not attached to project %s", file, project.ID)
+		}
+		project.Binaries = slices.Delete(project.Binaries, index, index+1)
+	}
+	if err := c.Projects.SaveProject(project); err != nil {
+		return fmt.Errorf("saving project: %w", err)
+	}
+	fmt.Fprintln(c.Stdout, project.ID)
+	return nil
+}
+
+// binarySections implements "revengo binary sections"
+func (c *CLI) binarySections(args []string) error {
+	fs := c.newFlagSet("binary sections", "<file> [-json]")
+	asJSON := fs.Bool("json", false, "print JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, 1); err != nil {
+		return err
+	}
+
+	file, err := binfile.Open(rest[0])
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		type section struct {
+			Name    string `json:"name"`
+			Offset  uint64 `json:"offset"`
+			Size    uint64 `json:"size"`
+			Address uint64 `json:"address,omitempty"`
+			Exec    bool   `json:"exec,omitempty"`
+		}
+		sections := []section{}
+		for _, s := range file.Sections {
+			entry := section{Name: s.Name, Offset: s.Offset, Size: s.Size, Exec: s.Exec}
+			if s.Mapped {
+				entry.Address = s.Addr
+			}
+			sections = append(sections, entry)
+		}
+		return c.writeJSON(sections)
+	}
+
+	fmt.Fprintf(c.Stdout, "%s: %s %s, %d sections\n\n", filepath.Base(file.Path), file.Format, file.Arch, len(file.Sections))
+	w := tabwriter.NewWriter(c.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SECTION\tOFFSET\tSIZE\tADDRESS\tFLAGS")
+	for _, s := range file.Sections {
+		address, flags := "-", ""
+		if s.Mapped {
+			address = fmt.Sprintf("0x%x", s.Addr)
+		}
+		if s.Exec {
+			flags = "code"
+		}
+		fmt.Fprintf(w, "%s\t0x%x\t0x%x\t%s\t%s\n", s.Name, s.Offset, s.Size, address, flags)
+	}
+	return w.Flush()
+}
+
+// binaryStrings implements "revengo binary strings"
+func (c *CLI) binaryStrings(args []string) error {
+	fs := c.newFlagSet("binary strings", "<file> [-min N] [-encoding E] [-section S] [-search Q] [-json]")
+	minLength := fs.Int("min", binfile.DefaultMinLength, "the fewest characters of a string")
+	encodings := fs.String("encoding", "", "comma separated encodings: "+strings.Join(binfile.Encodings, ", ")+"; all if empty")
+	sections := fs.String("section", "", "comma separated sections to search; the whole file if empty")
+	search := fs.String("search", "", "list only strings containing this text, ignoring case")
+	asJSON := fs.Bool("json", false, "print JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, 1); err != nil {
+		return err
+	}
+	if *minLength < 1 {
+		return fmt.Errorf("-min must be at least 1")
+	}
+
+	file, err := binfile.Open(rest[0])
+	if err != nil {
+		return err
+	}
+	found, err := file.Strings(binfile.StringOptions{
+		MinLength: *minLength,
+		Encodings: splitList(*encodings),
+		Sections:  splitList(*sections),
+	})
+	if err != nil {
+		return err
+	}
+	found = binfile.FilterStrings(found, *search)
+
+	if *asJSON {
+		type extracted struct {
+			Offset   uint64 `json:"offset"`
+			Address  uint64 `json:"address,omitempty"`
+			Section  string `json:"section,omitempty"`
+			Encoding string `json:"encoding"`
+			Text     string `json:"text"`
+		}
+		strs := []extracted{}
+		for _, s := range found {
+			entry := extracted{Offset: s.Offset, Section: s.Section, Encoding: s.Encoding, Text: s.Text}
+			if s.Mapped {
+				entry.Address = s.VA
+			}
+			strs = append(strs, entry)
+		}
+		return c.writeJSON(strs)
+	}
+	w := tabwriter.NewWriter(c.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "OFFSET\tVA\tSECTION\tENCODING\tSTRING")
+	for _, s := range found {
+		va, section := "-", s.Section
+		if s.Mapped {
+			va = fmt.Sprintf("0x%x", s.VA)
+		}
+		if section == "" {
+			section = "-"
+		}
+		text := strings.NewReplacer("\t", `\t`, "\r", `\r`, "\n", `\n`).Replace(s.Text)
+		fmt.Fprintf(w, "0x%x\t%s\t%s\t%s\t%s\n", s.Offset, va, section, s.Encoding, text)
+	}
+	return w.Flush()
+}
+
+// binaryNote implements "revengo binary note"
+func (c *CLI) binaryNote(args []string) error {
+	fs := c.newFlagSet("binary note", "<file> <address> [-offset] [-encoding E] [fields] [-json]")
+	isOffset := fs.Bool("offset", false, "the address is a file offset rather than a virtual address")
+	encodings := fs.String("encoding", "", "comma separated encodings the string may have; all if empty")
+	var fields noteFields
+	fields.register(fs)
+	asJSON := fs.Bool("json", false, "print the created note as JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 2, 2); err != nil {
+		return err
+	}
+
+	path, err := filepath.Abs(rest[0])
+	if err != nil {
+		return err
+	}
+	file, err := binfile.Open(path)
+	if err != nil {
+		return err
+	}
+	address, err := models.ParseAddress(rest[1])
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", rest[1], err)
+	}
+	offset := address
+	if !*isOffset {
+		var section *binfile.Section
+		if offset, section = file.FileOffset(address); section == nil {
+			return fmt.Errorf("0x%x is not a mapped address of %s; pass -offset for file offsets", address, filepath.Base(path))
+		}
+	}
+	s, err := file.StringAt(offset, splitList(*encodings))
+	if err != nil {
+		return err
+	}
+
+	// The string's note starts in the project the binary is attached to;
+	// the flags override any field
+	note := &models.Note{
+		Title:          s.NoteTitle(),
+		ReverseEngType: models.RETypeGeneral,
+		BinaryName:     filepath.Base(path),
+		AddressRange:   s.AddressRange(),
+	}
+	if projects, err := c.Projects.ListProjects(); err == nil {
+		for _, project := range projects {
+			if slices.Contains(project.Binaries, path) {
+				note.ProjectID = project.ID
+				break
+			}
+		}
+	}
+	if err := c.applyNoteFields(fs, &fields, note); err != nil {
+		return err
+	}
+	if err := c.saveNote(note, ""); err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return c.writeJSON(note)
+	}
+	fmt.Fprintln(c.Stdout, note.ID)
+	return nil
+}
+
+// binaryDisasm implements "revengo binary disasm"
+func (c *CLI) binaryDisasm(args []string) error {
+	fs := c.newFlagSet("binary disasm", "<file> <range> [-bytes] [-fenced]")
+	withBytes := fs.Bool("bytes", false, "show the bytes of each instruction")
+	fenced := fs.Bool("fenced", false, "print a fenced code block to paste into a note")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 2, 2); err != nil {
+		return err
+	}
+
+	listing, err := c.disassemble(rest[0], rest[1])
+	if err != nil {
+		return err
+	}
+	if *fenced {
+		fmt.Fprint(c.Stdout, listing.Fenced(filepath.Base(rest[0])))
+		return nil
+	}
+	fmt.Fprint(c.Stdout, listing.Format(*withBytes))
+	return nil
+}
+
+// disassemble decodes an address range of a binary, as in note address
+// ranges; a range cut short is reported on stderr
+func (c *CLI) disassemble(path, addressRange string) (*disasm.Listing, error) {
+	start, end, err := models.ParseAddressRange(addressRange)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address range %q: %w", addressRange, err)
+	}
+	file, err := binfile.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	listing, err := disasm.Disassemble(file, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if listing.Truncated {
+		fmt.Fprintf(c.Stderr, "warning: the range was cut short at 0x%x\n", listing.End)
+	}
+	return listing, nil
+}
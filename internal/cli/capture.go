@@ -0,0 +1,360 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/leog/RevEnGo/internal/dissect"
+	"github.com/leog/RevEnGo/internal/models"
+	"github.com/leog/RevEnGo/internal/pcap"
+)
+
+// captureSubcommands lists the subcommands of "revengo capture"
+var captureSubcommands = []subcommand{
+	{"list", "[project]", "List the captures attached to projects", (*CLI).captureList},
+	{"attach", "<project> <file>...", "Attach pcap or pcapng files to a project", (*CLI).captureAttach},
+	{"detach", "<project> <file>...", "Detach captures from a project, keeping the files", (*CLI).captureDetach},
+	{"flows", "<file>", "List the TCP and UDP flows of a capture", (*CLI).captureFlows},
+	{"packets", "<file> [-flow N]", "List the packets of a capture or flow", (*CLI).capturePackets},
+	{"stream", "<file> -flow N", "Show the reassembled stream of a flow", (*CLI).captureStream},
+	{"pin", "<file> <note> (-packet N | -flow N -dir D [-offset O] [-length L])", "Pin a packet payload or stream range to a note as a sample", (*CLI).capturePin},
+}
+
+// runCapture dispatches "revengo capture" subcommands
+func (c *CLI) runCapture(args []string) error {
+	return c.runSubcommand("capture", captureSubcommands, args)
+}
+
+// captureList implements "revengo capture list"
+func (c *CLI) captureList(args []string) error {
+	fs := c.newFlagSet("capture list", "[project] [-json]")
+	asJSON := fs.Bool("json", false, "print JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 0, 1); err != nil {
+		return err
+	}
+
+	var projects []*models.Project
+	if len(rest) == 1 {
+		project, err := c.findProject(rest[0])
+		if err != nil {
+			return err
+		}
+		projects = []*models.Project{project}
+	} else if projects, err = c.Projects.ListProjects(); err != nil {
+		return fmt.Errorf("listing projects: %w", err)
+	}
+
+	type attached struct {
+		Project string `json:"project"`
+		File    string `json:"file"`
+	}
+	var captures []attached
+	for _, project := range projects {
+		for _, path := range project.Captures {
+			captures = append(captures, attached{project.ID, path})
+		}
+	}
+	if *asJSON {
+		return c.writeJSON(nonNil(captures))
+	}
+	w := tabwriter.NewWriter(c.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tFILE")
+	for _, capture := range captures {
+		fmt.Fprintf(w, "%s\t%s\n", capture.Project, capture.File)
+	}
+	return w.Flush()
+}
+
+// captureAttach implements "revengo capture attach"
+func (c *CLI) captureAttach(args []string) error {
+	fs := c.newFlagSet("capture attach", "<project> <file>...")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 2, -1); err != nil {
+		return err
+	}
+
+	project, err := c.findProject(rest[0])
+	if err != nil {
+		return err
+	}
+	for _, file := range rest[1:] {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		// Only readable captures are attached
+		if _, err := pcap.Open(path); err != nil {
+			return err
+		}
+		if !slices.Contains(project.Captures, path) {
+			project.Captures = append(project.Captures, path)
+		}
+	}
+	if err := c.Projects.SaveProject(project); err != nil {
+		return fmt.Errorf("saving project: %w", err)
+	}
+	fmt.Fprintln(c.Stdout, project.ID)
+	return nil
+}
+
+// captureDetach implements "revengo capture detach"
+func (c *CLI) captureDetach(args []string) error {
+	fs := c.newFlagSet("capture detach", "<project> <file>...")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 2, -1); err != nil {
+		return err
+	}
+
+	project, err := c.findProject(rest[0])
+	if err != nil {
+		return err
+	}
+	for _, file := range rest[1:] {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		index := slices.Index(project.Captures, path)
+		if index < 0 {
+			return fmt.Errorf("%s is not attached to project %s", file, project.ID)
+		}
+		project.Captures = slices.Delete(project.Captures, index, index+1)
+	}
+	if err := c.Projects.SaveProject(project); err != nil {
+		return fmt.Errorf("saving project: %w", err)
+	}
+	fmt.Fprintln(c.Stdout, project.ID)
+	return nil
+}
+
+// captureFlows implements "revengo capture flows"
+func (c *CLI) captureFlows(args []string) error {
+	fs := c.newFlagSet("capture flows", "<file>")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, 1); err != nil {
+		return err
+	}
+
+	capture, flows, skipped, err := openFlows(rest[0])
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(c.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FLOW\tPROTO\tCLIENT\tSERVER\tPACKETS\tBYTES\tDURATION")
+	for _, f := range flows {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%d\t%.3fs\n", f.Number, pcap.ProtoName(f.Proto),
+			f.Client, f.Server, len(f.Packets), f.Bytes, f.Last.Sub(f.First).Seconds())
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	c.printCaptureNotes(capture, skipped)
+	return nil
+}
+
+// capturePackets implements "revengo capture packets"
+func (c *CLI) capturePackets(args []string) error {
+	fs := c.newFlagSet("capture packets", "<file> [-flow N]")
+	flowNumber := fs.Int("flow", 0, "list only the packets of flow N")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, 1); err != nil {
+		return err
+	}
+
+	capture, flows, skipped, err := openFlows(rest[0])
+	if err != nil {
+		return err
+	}
+	if *flowNumber != 0 {
+		f, err := findFlow(flows, *flowNumber)
+		if err != nil {
+			return err
+		}
+		flows = []*pcap.Flow{f}
+	}
+
+	// Packets are listed in capture order across the flows
+	var packets []pcap.FlowPacket
+	flowOf := make(map[int]*pcap.Flow)
+	for _, f := range flows {
+		for _, p := range f.Packets {
+			packets = append(packets, p)
+			flowOf[p.Number] = f
+		}
+	}
+	slices.SortFunc(packets, func(a, b pcap.FlowPacket) int { return a.Number - b.Number })
+
+	w := tabwriter.NewWriter(c.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PACKET\tTIME\tFLOW\tSOURCE\tDESTINATION\tFLAGS\tPAYLOAD")
+	for _, p := range packets {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s\t%d\n", p.Number, p.Time.Format("15:04:05.000000"),
+			flowOf[p.Number].Number, p.Src, p.Dst, p.Flags, len(p.Payload))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if *flowNumber == 0 {
+		c.printCaptureNotes(capture, skipped)
+	}
+	return nil
+}
+
+// captureStream implements "revengo capture stream"
+func (c *CLI) captureStream(args []string) error {
+	fs := c.newFlagSet("capture stream", "<file> -flow N")
+	flowNumber := fs.Int("flow", 0, "the flow to reassemble")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, 1); err != nil {
+		return err
+	}
+	if *flowNumber == 0 {
+		fs.Usage()
+		return ErrUsage
+	}
+
+	_, flows, _, err := openFlows(rest[0])
+	if err != nil {
+		return err
+	}
+	f, err := findFlow(flows, *flowNumber)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(c.Stdout, pcap.Reassemble(f).Format())
+	return nil
+}
+
+// capturePin implements "revengo capture pin"
+func (c *CLI) capturePin(args []string) error {
+	fs := c.newFlagSet("capture pin", "<file> <note> (-packet N | -flow N -dir client|server [-offset O] [-length L])")
+	packetNumber := fs.Int("packet", 0, "pin the payload of packet N")
+	flowNumber := fs.Int("flow", 0, "pin a range of the stream of flow N")
+	dir := fs.String("dir", "", "the side of the stream: client or server")
+	offset := fs.Int("offset", 0, "the offset of the range in the data the side sent")
+	length := fs.Int("length", -1, "the length of the range; -1 for the rest of the data")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 2, 2); err != nil {
+		return err
+	}
+	if (*packetNumber == 0) == (*flowNumber == 0) {
+		return fmt.Errorf("pass either -packet or -flow")
+	}
+	if *flowNumber != 0 && *dir != "client" && *dir != "server" {
+		return fmt.Errorf("-dir must be client or server")
+	}
+
+	note, err := c.findNote(rest[1])
+	if err != nil {
+		return err
+	}
+	if note.ReverseEngType != models.RETypeProtocolAnalysis {
+		return fmt.Errorf("note %s is not a protocol analysis note", note.ID)
+	}
+	_, flows, _, err := openFlows(rest[0])
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Base(rest[0])
+	var sample models.SampleMessage
+	if *packetNumber != 0 {
+		f, p := findPacket(flows, *packetNumber)
+		if f == nil {
+			return fmt.Errorf("packet %d is not part of a TCP or UDP flow in %s", *packetNumber, name)
+		}
+		if len(p.Payload) == 0 {
+			return fmt.Errorf("packet %d carries no data", *packetNumber)
+		}
+		sample = models.SampleMessage{Source: pcap.PacketSource(name, f, p.Number), Hex: dissect.FormatHex(p.Payload)}
+	} else {
+		f, err := findFlow(flows, *flowNumber)
+		if err != nil {
+			return err
+		}
+		fromClient := *dir == "client"
+		data, err := pcap.Reassemble(f).Range(fromClient, *offset, *length)
+		if err != nil {
+			return err
+		}
+		if len(data) == 0 {
+			return fmt.Errorf("the range is empty")
+		}
+		sample = models.SampleMessage{Source: pcap.RangeSource(name, f, fromClient, *offset, len(data)), Hex: dissect.FormatHex(data)}
+	}
+
+	// The pinned sample becomes the one the dissector decodes
+	if note.Protocol == nil {
+		note.Protocol = &models.ProtocolDetails{}
+	}
+	note.Protocol.Samples = append(note.Protocol.Samples, sample)
+	note.Protocol.Sample = sample.Hex
+	if err := c.saveNote(note, note.Title); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.Stdout, "pinned %s\n", sample.Source)
+	return nil
+}
+
+// printCaptureNotes reports the parts of a capture that flows leave out
+func (c *CLI) printCaptureNotes(capture *pcap.Capture, skipped int) {
+	if skipped > 0 {
+		fmt.Fprintf(c.Stdout, "\n%d of %d packets are not IP and are not shown\n", skipped, len(capture.Packets))
+	}
+	if capture.Truncated {
+		fmt.Fprintln(c.Stderr, "warning: the capture file is truncated")
+	}
+}
+
+// openFlows reads a capture and groups its packets into flows
+func openFlows(path string) (*pcap.Capture, []*pcap.Flow, int, error) {
+	capture, err := pcap.Open(path)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	flows, skipped := pcap.Flows(capture)
+	return capture, flows, skipped, nil
+}
+
+// findFlow returns the flow with the given number
+func findFlow(flows []*pcap.Flow, number int) (*pcap.Flow, error) {
+	if number < 1 || number > len(flows) {
+		return nil, fmt.Errorf("no flow %d: the capture has %d flows", number, len(flows))
+	}
+	return flows[number-1], nil
+}
+
+// findPacket returns the packet with the given number and its flow
+func findPacket(flows []*pcap.Flow, number int) (*pcap.Flow, *pcap.FlowPacket) {
+	for _, f := range flows {
+		for i := range f.Packets {
+			if f.Packets[i].Number == number {
+				return f, &f.Packets[i]
+			}
+		}
+	}
+	return nil, nil
+}
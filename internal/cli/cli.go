@@ -0,0 +1,302 @@
+// Package cli implements RevEnGo's headless command-line interface.
+// Commands operate directly on the note and project stores used by the GUI,
+// so notes can be scripted from a terminal or over SSH without starting Fyne.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/leog/RevEnGo/internal/config"
+	"github.com/leog/RevEnGo/internal/models"
+)
+
+// ErrUsage is returned when a command is invoked with invalid arguments.
+// The usage text has already been printed when it is returned.
+var ErrUsage = errors.New("invalid usage")
+
+// CLI runs commands against a note store and a project store
+type CLI struct {
+	// Notes is the store that note commands operate on
+	Notes models.NoteStore
+
+	// Projects is the store that project commands operate on
+	Projects models.ProjectStore
+
+	// DataDir is the application data directory, which holds the API token
+	DataDir string
+
+	// Settings is the persistent configuration managed by "revengo profile"
+	Settings *config.Config
+
+	// Profile is the name of the profile in use
+	Profile string
+
+	// Changes reports store changes to clients of "revengo serve"
+	Changes *models.ChangeFeed
+
+	// Stdin, Stdout and Stderr are the streams commands read from and write to
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Editor opens a file in the user's editor and waits for it to close.
+	// It defaults to running $VISUAL or $EDITOR.
+	Editor func(path string) error
+}
+
+// command is a top-level CLI command
+type command struct {
+	name    string
+	summary string
+	run     func(c *CLI, args []string) error
+}
+
+// commands lists the top-level commands in the order shown in the help text
+var commands = []command{
+	{"note", "Create, show, list, edit and remove notes", (*CLI).runNote},
+	{"project", "Create, show, list, edit and remove projects", (*CLI).runProject},
+	{"vuln", "Track vulnerability findings: CVSS scores, CWEs and status", (*CLI).runVuln},
+	{"capture", "Attach packet captures to projects and pin samples from them", (*CLI).runCapture},
+	{"binary", "Attach binaries to projects and extract their strings", (*CLI).runBinary},
+	{"search", "Search notes by text", (*CLI).runSearch},
+	{"export", "Export notes as JSON or Markdown", (*CLI).runExport},
+	{"serve", "Serve the HTTP/JSON API on localhost", (*CLI).runServe},
+	{"profile", "List, create, select and remove profiles", (*CLI).runProfile},
+}
+
+// New creates a CLI that uses the standard streams and the user's editor.
+//
+// Parameters:
+//   - notes: The note store to operate on
+//   - projects: The project store to operate on
+//
+// Returns:
+//   - A configured CLI instance
+func New(notes models.NoteStore, projects models.ProjectStore) *CLI {
+	return &CLI{
+		Notes:    notes,
+		Projects: projects,
+		Stdin:    os.Stdin,
+		Stdout:   os.Stdout,
+		Stderr:   os.Stderr,
+		Editor:   runEditor,
+	}
+}
+
+// Run executes the command named by the first argument.
+//
+// Parameters:
+//   - args: The command-line arguments, without the program name
+//
+// Returns:
+//   - An error if the command fails; ErrUsage for invalid arguments
+func (c *CLI) Run(args []string) error {
+	if len(args) == 0 {
+		c.usage()
+		return ErrUsage
+	}
+
+	if isHelp(args[0]) {
+		c.usage()
+		return nil
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(c, args[1:])
+		}
+	}
+
+	fmt.Fprintf(c.Stderr, "revengo: unknown command %q\n\n", args[0])
+	c.usage()
+	return ErrUsage
+}
+
+// usage prints the top-level help text
+func (c *CLI) usage() {
+	fmt.Fprintln(c.Stderr, "Usage: revengo [-data-dir DIR] [-profile NAME] [command] [arguments]")
+	fmt.Fprintln(c.Stderr)
+	fmt.Fprintln(c.Stderr, "Without a command, the graphical interface is started.")
+	fmt.Fprintln(c.Stderr)
+	fmt.Fprintln(c.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(c.Stderr, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(c.Stderr)
+	fmt.Fprintln(c.Stderr, `Run "revengo <command> -h" for help on a command.`)
+}
+
+// subcommand is a subcommand of note or project
+type subcommand struct {
+	name    string
+	args    string
+	summary string
+	run     func(c *CLI, args []string) error
+}
+
+// runSubcommand dispatches to a subcommand, printing help when none matches
+func (c *CLI) runSubcommand(group string, subs []subcommand, args []string) error {
+	if len(args) > 0 {
+		for _, sub := range subs {
+			if sub.name == args[0] {
+				return sub.run(c, args[1:])
+			}
+		}
+		if !isHelp(args[0]) {
+			fmt.Fprintf(c.Stderr, "revengo %s: unknown subcommand %q\n\n", group, args[0])
+		}
+	}
+
+	fmt.Fprintf(c.Stderr, "Usage: revengo %s <subcommand> [arguments]\n\n", group)
+	fmt.Fprintln(c.Stderr, "Subcommands:")
+	w := tabwriter.NewWriter(c.Stderr, 0, 4, 2, ' ', 0)
+	for _, sub := range subs {
+		fmt.Fprintf(w, "  %s %s\t%s\n", sub.name, sub.args, sub.summary)
+	}
+	w.Flush()
+	if len(args) > 0 && isHelp(args[0]) {
+		return nil
+	}
+	return ErrUsage
+}
+
+// isHelp reports whether arg asks for help
+func isHelp(arg string) bool {
+	switch arg {
+	case "help", "-h", "-help", "--help":
+		return true
+	}
+	return false
+}
+
+// newFlagSet creates a flag set whose errors and help go to stderr
+func (c *CLI) newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.Stderr, "Usage: revengo %s %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses flags that may appear before, after or between
+// positional arguments, and returns the positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, ErrUsage
+		}
+		// A "--" consumed by Parse ends flag parsing; the rest is positional
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// expectArgs checks the number of positional arguments, printing usage on mismatch
+func expectArgs(fs *flag.FlagSet, args []string, min, max int) error {
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		fs.Usage()
+		return ErrUsage
+	}
+	return nil
+}
+
+// writeJSON writes v to the CLI's stdout as indented JSON
+func (c *CLI) writeJSON(v interface{}) error {
+	return writeJSON(c.Stdout, v)
+}
+
+// writeJSON writes v to w as indented JSON
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// splitList splits a comma separated flag value, dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// listNotes loads all notes, sorted by most recently modified first
+func (c *CLI) listNotes() ([]*models.Note, error) {
+	notes, err := c.Notes.ListNotes()
+	if err != nil {
+		return nil, fmt.Errorf("listing notes: %w", err)
+	}
+	sort.SliceStable(notes, func(i, j int) bool {
+		return notes[i].Modified.After(notes[j].Modified)
+	})
+	return notes, nil
+}
+
+// findNote resolves a note by ID or title
+func (c *CLI) findNote(ref string) (*models.Note, error) {
+	notes, err := c.listNotes()
+	if err != nil {
+		return nil, err
+	}
+	note := models.FindNoteByLink(ref, notes)
+	if note == nil {
+		return nil, fmt.Errorf("no note with ID or title %q", ref)
+	}
+	return note, nil
+}
+
+// findProject resolves a project by ID or name, ignoring case in names
+func (c *CLI) findProject(ref string) (*models.Project, error) {
+	projects, err := c.Projects.ListProjects()
+	if err != nil {
+		return nil, fmt.Errorf("listing projects: %w", err)
+	}
+	for _, project := range projects {
+		if project.ID == ref {
+			return project, nil
+		}
+	}
+	for _, project := range projects {
+		if strings.EqualFold(project.Name, ref) {
+			return project, nil
+		}
+	}
+	return nil, fmt.Errorf("no project with ID or name %q", ref)
+}
+
+// noteFilter builds a note filter from command-line values, resolving the project
+func (c *CLI) noteFilter(project, tag, noteType string) (models.NoteFilter, error) {
+	filter := models.NoteFilter{Tag: tag, ReverseEngType: noteType}
+	if project != "" {
+		p, err := c.findProject(project)
+		if err != nil {
+			return filter, err
+		}
+		filter.ProjectID = p.ID
+	}
+	return filter, nil
+}
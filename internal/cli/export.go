@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/leog/RevEnGo/internal/models"
+)
+
+// runExport implements "revengo export"
+func (c *CLI) runExport(args []string) error {
+	fs := c.newFlagSet("export", "[-format json|markdown] [-o FILE | -dir DIR] [-project P] [-tag T] [-type T]")
+	format := fs.String("format", "json", "output format: json or markdown")
+	output := fs.String("o", "", "write to FILE instead of stdout")
+	dir := fs.String("dir", "", "write one Markdown file per note into DIR")
+	project := fs.String("project", "", "only export notes in this project (ID or name)")
+	tag := fs.String("tag", "", "only export notes with this tag")
+	noteType := fs.String("type", "", "only export notes of this type")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 0, 0); err != nil {
+		return err
+	}
+	if *format != "json" && *format != "markdown" && *format != "md" {
+		return fmt.Errorf("unknown export format %q", *format)
+	}
+	if *dir != "" && *output != "" {
+		return fmt.Errorf("-o and -dir cannot be used together")
+	}
+
+	filter, err := c.noteFilter(*project, *tag, *noteType)
+	if err != nil {
+		return err
+	}
+	notes, err := c.listNotes()
+	if err != nil {
+		return err
+	}
+	notes = models.FilterNotes(notes, filter)
+
+	if *dir != "" {
+		return c.exportMarkdownDir(notes, *dir)
+	}
+
+	var buf bytes.Buffer
+	if *format == "json" {
+		projects, err := c.Projects.ListProjects()
+		if err != nil {
+			return fmt.Errorf("listing projects: %w", err)
+		}
+		if filter.ProjectID != "" {
+			projects = projectsByID(projects, filter.ProjectID)
+		}
+		err = writeJSON(&buf, struct {
+			Projects []*models.Project `json:"projects"`
+			Notes    []*models.Note    `json:"notes"`
+		}{nonNil(projects), nonNil(notes)})
+		if err != nil {
+			return err
+		}
+	} else {
+		for i, note := range notes {
+			if i > 0 {
+				buf.WriteString("\n---\n\n")
+			}
+			c.writeMarkdown(&buf, note)
+		}
+	}
+
+	if *output == "" {
+		_, err = c.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(*output, buf.Bytes(), 0644)
+}
+
+// exportMarkdownDir writes each note to its own Markdown file in dir
+func (c *CLI) exportMarkdownDir(notes []*models.Note, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, note := range notes {
+		var buf bytes.Buffer
+		c.writeMarkdown(&buf, note)
+		path := filepath.Join(dir, exportFileName(note))
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+		fmt.Fprintln(c.Stdout, path)
+	}
+	return nil
+}
+
+// writeMarkdown writes a note as a Markdown document with a metadata list
+func (c *CLI) writeMarkdown(w io.Writer, note *models.Note) {
+	fmt.Fprintf(w, "# %s\n\n", note.Title)
+	fmt.Fprintf(w, "- **ID:** %s\n", note.ID)
+	fmt.Fprintf(w, "- **Type:** %s\n", note.ReverseEngType)
+	if note.ProjectID != "" {
+		fmt.Fprintf(w, "- **Project:** %s\n", c.projectName(note.ProjectID))
+	}
+	if len(note.Tags) > 0 {
+		fmt.Fprintf(w, "- **Tags:** %s\n", strings.Join(note.Tags, ", "))
+	}
+	if note.BinaryName != "" {
+		fmt.Fprintf(w, "- **Binary:** `%s`\n", note.BinaryName)
+	}
+	if note.AddressRange != "" {
+		fmt.Fprintf(w, "- **Address:** `%s`\n", note.AddressRange)
+	}
+	if len(note.FunctionRefs) > 0 {
+		fmt.Fprintf(w, "- **Functions:** `%s`\n", strings.Join(note.FunctionRefs, "`, `"))
+	}
+	for _, field := range note.DetailFields() {
+		fmt.Fprintf(w, "- **%s:** %s\n", field.Label, field.Value)
+	}
+	fmt.Fprintf(w, "- **Modified:** %s\n", note.Modified.Format("2006-01-02 15:04"))
+
+	if content := strings.TrimRight(note.Content, "\n"); content != "" {
+		fmt.Fprintf(w, "\n%s\n", content)
+	}
+}
+
+// unsafeFileChars matches runs of characters not wanted in file names
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// exportFileName builds a file name from a note's ID and title
+func exportFileName(note *models.Note) string {
+	slug := strings.Trim(unsafeFileChars.ReplaceAllString(strings.ToLower(note.Title), "-"), "-.")
+	if len(slug) > 60 {
+		slug = slug[:60]
+	}
+	if slug == "" {
+		return note.ID + ".md"
+	}
+	return note.ID + "-" + slug + ".md"
+}
+
+// projectsByID returns the projects with the given ID
+func projectsByID(projects []*models.Project, id string) []*models.Project {
+	var result []*models.Project
+	for _, project := range projects {
+		if project.ID == id {
+			result = append(result, project)
+		}
+	}
+	return result
+}
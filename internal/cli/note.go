@@ -0,0 +1,559 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/leog/RevEnGo/internal/cstruct"
+	"github.com/leog/RevEnGo/internal/dissect"
+	"github.com/leog/RevEnGo/internal/models"
+)
+
+// noteSubcommands lists the subcommands of "revengo note"
+var noteSubcommands = []subcommand{
+	{"list", "[-project P] [-tag T] [-type T]", "List notes", (*CLI).noteList},
+	{"show", "<note>", "Show a note", (*CLI).noteShow},
+	{"add", "[-title T] [fields] [-edit]", "Create a note", (*CLI).noteAdd},
+	{"edit", "<note> [fields]", "Change a note; opens $EDITOR without fields", (*CLI).noteEdit},
+	{"rm", "<note>...", "Delete notes", (*CLI).noteRemove},
+	{"struct", "<note> [-abi A] [-format F] [overlay flags]", "Show, export or overlay the structures of a note", (*CLI).noteStruct},
+	{"dissect", "<note> [-hex H | -data F] [-format F]", "Decode a message or export the dissector of a note", (*CLI).noteDissect},
+	{"disasm", "<note> [-bytes] [-append]", "Disassemble the address range of a note in its binary", (*CLI).noteDisasm},
+}
+
+// runNote dispatches "revengo note" subcommands
+func (c *CLI) runNote(args []string) error {
+	return c.runSubcommand("note", noteSubcommands, args)
+}
+
+// noteFields holds the flags shared by "note add" and "note edit"
+type noteFields struct {
+	title     string
+	content   string
+	file      string
+	tags      string
+	noteType  string
+	binary    string
+	address   string
+	functions string
+	project   string
+	edit      bool
+}
+
+// register adds the note field flags to fs
+func (f *noteFields) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.title, "title", "", "note title")
+	fs.StringVar(&f.content, "content", "", "note content")
+	fs.StringVar(&f.file, "file", "", `read content from a file ("-" for stdin)`)
+	fs.StringVar(&f.tags, "tags", "", "comma separated tags")
+	fs.StringVar(&f.noteType, "type", "", "note type: "+strings.Join(models.NoteTypeNames(), ", "))
+	fs.StringVar(&f.binary, "binary", "", "binary name")
+	fs.StringVar(&f.address, "address", "", "address range, e.g. 0x401000-0x401200")
+	fs.StringVar(&f.functions, "functions", "", "comma separated function references")
+	fs.StringVar(&f.project, "project", "", "project ID or name")
+	fs.BoolVar(&f.edit, "edit", false, "edit the content in $EDITOR")
+}
+
+// applyNoteFields copies the flags that were set on the command line into note
+func (c *CLI) applyNoteFields(fs *flag.FlagSet, f *noteFields, note *models.Note) error {
+	var err error
+	fs.Visit(func(fl *flag.Flag) {
+		if err != nil {
+			return
+		}
+		switch fl.Name {
+		case "title":
+			note.Title = f.title
+		case "content":
+			note.Content = f.content
+		case "file":
+			note.Content, err = c.readContent(f.file)
+		case "tags":
+			note.Tags = splitList(f.tags)
+		case "type":
+			if !models.IsNoteType(f.noteType) {
+				err = fmt.Errorf("unknown note type %q (valid types: %s)", f.noteType, strings.Join(models.NoteTypeNames(), ", "))
+			}
+			note.ReverseEngType = f.noteType
+		case "binary":
+			note.BinaryName = f.binary
+		case "address":
+			note.AddressRange = f.address
+		case "functions":
+			note.FunctionRefs = splitList(f.functions)
+		case "project":
+			if f.project == "" {
+				note.ProjectID = ""
+				return
+			}
+			var project *models.Project
+			if project, err = c.findProject(f.project); err == nil {
+				note.ProjectID = project.ID
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if f.edit {
+		note.Content, err = c.editText(note.Content)
+	}
+	return err
+}
+
+// readContent reads note content from a file, or from stdin when path is "-"
+func (c *CLI) readContent(path string) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(c.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading content: %w", err)
+	}
+	return string(data), nil
+}
+
+// noteList implements "revengo note list"
+func (c *CLI) noteList(args []string) error {
+	fs := c.newFlagSet("note list", "[-project P] [-tag T] [-type T] [-json]")
+	project := fs.String("project", "", "only notes in this project (ID or name)")
+	tag := fs.String("tag", "", "only notes with this tag")
+	noteType := fs.String("type", "", "only notes of this type")
+	asJSON := fs.Bool("json", false, "print JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 0, 0); err != nil {
+		return err
+	}
+
+	filter, err := c.noteFilter(*project, *tag, *noteType)
+	if err != nil {
+		return err
+	}
+	notes, err := c.listNotes()
+	if err != nil {
+		return err
+	}
+	notes = models.FilterNotes(notes, filter)
+
+	if *asJSON {
+		return c.writeJSON(nonNil(notes))
+	}
+	c.printNoteTable(notes)
+	return nil
+}
+
+// noteShow implements "revengo note show"
+func (c *CLI) noteShow(args []string) error {
+	fs := c.newFlagSet("note show", "<note> [-json]")
+	asJSON := fs.Bool("json", false, "print JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, 1); err != nil {
+		return err
+	}
+
+	note, err := c.findNote(rest[0])
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return c.writeJSON(note)
+	}
+
+	w := tabwriter.NewWriter(c.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%s\n", note.ID)
+	fmt.Fprintf(w, "Title:\t%s\n", note.Title)
+	fmt.Fprintf(w, "Type:\t%s\n", note.ReverseEngType)
+	if note.ProjectID != "" {
+		fmt.Fprintf(w, "Project:\t%s\n", c.projectName(note.ProjectID))
+	}
+	if len(note.Tags) > 0 {
+		fmt.Fprintf(w, "Tags:\t%s\n", strings.Join(note.Tags, ", "))
+	}
+	if note.BinaryName != "" {
+		fmt.Fprintf(w, "Binary:\t%s\n", note.BinaryName)
+	}
+	if note.AddressRange != "" {
+		fmt.Fprintf(w, "Address:\t%s\n", note.AddressRange)
+	}
+	if len(note.FunctionRefs) > 0 {
+		fmt.Fprintf(w, "Functions:\t%s\n", strings.Join(note.FunctionRefs, ", "))
+	}
+	for _, field := range note.DetailFields() {
+		fmt.Fprintf(w, "%s:\t%s\n", field.Label, field.Value)
+	}
+	fmt.Fprintf(w, "Created:\t%s\n", note.Created.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "Modified:\t%s\n", note.Modified.Format("2006-01-02 15:04:05"))
+	w.Flush()
+
+	if note.Content != "" {
+		fmt.Fprintln(c.Stdout)
+		fmt.Fprint(c.Stdout, note.Content)
+		if !strings.HasSuffix(note.Content, "\n") {
+			fmt.Fprintln(c.Stdout)
+		}
+	}
+	return nil
+}
+
+// noteAdd implements "revengo note add"
+func (c *CLI) noteAdd(args []string) error {
+	fs := c.newFlagSet("note add", "[-title T] [-content C | -file F] [fields] [-edit] [-json]")
+	var fields noteFields
+	fields.register(fs)
+	asJSON := fs.Bool("json", false, "print the created note as JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 0, 0); err != nil {
+		return err
+	}
+
+	note := &models.Note{ReverseEngType: models.RETypeGeneral}
+	if err := c.applyNoteFields(fs, &fields, note); err != nil {
+		return err
+	}
+	if note.Title == "" {
+		note.Title = "Untitled Note"
+	}
+	if err := c.saveNote(note, ""); err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return c.writeJSON(note)
+	}
+	fmt.Fprintln(c.Stdout, note.ID)
+	return nil
+}
+
+// noteEdit implements "revengo note edit"
+func (c *CLI) noteEdit(args []string) error {
+	fs := c.newFlagSet("note edit", "<note> [fields] [-edit] [-json]")
+	var fields noteFields
+	fields.register(fs)
+	asJSON := fs.Bool("json", false, "print the updated note as JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, 1); err != nil {
+		return err
+	}
+
+	note, err := c.findNote(rest[0])
+	if err != nil {
+		return err
+	}
+
+	// Without any field flags, edit the content interactively
+	changed := 0
+	fs.Visit(func(fl *flag.Flag) {
+		if fl.Name != "json" {
+			changed++
+		}
+	})
+	if changed == 0 {
+		fields.edit = true
+	}
+
+	before, oldTitle := note.Content, note.Title
+	if err := c.applyNoteFields(fs, &fields, note); err != nil {
+		return err
+	}
+	if changed == 0 && note.Content == before {
+		fmt.Fprintln(c.Stderr, "revengo: no changes")
+		return nil
+	}
+	if err := c.saveNote(note, oldTitle); err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return c.writeJSON(note)
+	}
+	fmt.Fprintln(c.Stdout, note.ID)
+	return nil
+}
+
+// noteRemove implements "revengo note rm"
+func (c *CLI) noteRemove(args []string) error {
+	fs := c.newFlagSet("note rm", "<note>...")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, -1); err != nil {
+		return err
+	}
+
+	for _, ref := range rest {
+		note, err := c.findNote(ref)
+		if err != nil {
+			return err
+		}
+		if err := c.Notes.DeleteNote(note.ID); err != nil {
+			return fmt.Errorf("deleting note %s: %w", note.ID, err)
+		}
+		fmt.Fprintf(c.Stdout, "deleted %s\n", note.ID)
+	}
+	return nil
+}
+
+// noteStruct implements "revengo note struct"
+func (c *CLI) noteStruct(args []string) error {
+	fs := c.newFlagSet("note struct", "<note> [-abi A] [-format F] [-binary B] [-offset N] [-record R] [-endian E]")
+	abiName := fs.String("abi", "", "ABI to lay out for: "+strings.Join(cstruct.ABINames(), ", ")+"; defaults to the note's")
+	format := fs.String("format", "layout", "output format: layout, overlay, "+strings.Join(cstruct.ExportFormats, ", "))
+	binary := fs.String("binary", "", "file to overlay the structure on; defaults to the note's")
+	offset := fs.String("offset", "", "file offset of the structure; defaults to the note's")
+	record := fs.String("record", "", "struct or union to overlay; defaults to the note's")
+	endian := fs.String("endian", "", "byte order of the overlaid bytes: little or big; defaults to the note's")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, 1); err != nil {
+		return err
+	}
+
+	note, err := c.findNote(rest[0])
+	if err != nil {
+		return err
+	}
+	if note.Structure == nil || strings.TrimSpace(note.Structure.Definition) == "" {
+		return fmt.Errorf("note %s has no structure definition", note.ID)
+	}
+	details := *note.Structure
+	if *abiName != "" {
+		details.ABI = *abiName
+	}
+	if *binary != "" {
+		details.Binary = *binary
+	}
+	if *offset != "" {
+		details.Offset = *offset
+	}
+	if *record != "" {
+		details.Record = *record
+	}
+	switch *endian {
+	case "":
+	case "little":
+		details.BigEndian = false
+	case "big":
+		details.BigEndian = true
+	default:
+		return fmt.Errorf("invalid byte order %q (use little or big)", *endian)
+	}
+
+	switch *format {
+	case "overlay":
+		layout, values, base, err := details.Overlay()
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(c.Stdout, cstruct.FormatValues(layout, values, base))
+		return nil
+	case "layout":
+		layouts, err := details.Layouts()
+		if err != nil {
+			return err
+		}
+		for i, l := range layouts {
+			if i > 0 {
+				fmt.Fprintln(c.Stdout)
+			}
+			fmt.Fprint(c.Stdout, l.Format())
+		}
+		return nil
+	}
+
+	abi, err := cstruct.LookupABI(details.ABI)
+	if err != nil {
+		return err
+	}
+	file, err := cstruct.Parse(details.Definition)
+	if err != nil {
+		return err
+	}
+	source, err := cstruct.Export(file, abi, *format)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(c.Stdout, source)
+	return nil
+}
+
+// noteDissect implements "revengo note dissect"
+func (c *CLI) noteDissect(args []string) error {
+	fs := c.newFlagSet("note dissect", "<note> [-hex H | -data F] [-format F]")
+	hexData := fs.String("hex", "", "message to decode, in hex; defaults to the note's sample")
+	dataFile := fs.String("data", "", `file holding the message to decode ("-" for stdin)`)
+	format := fs.String("format", "tree", "output format: tree, "+strings.Join(dissect.ExportFormats, ", "))
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, 1); err != nil {
+		return err
+	}
+	if *hexData != "" && *dataFile != "" {
+		return fmt.Errorf("-hex and -data cannot be combined")
+	}
+
+	note, err := c.findNote(rest[0])
+	if err != nil {
+		return err
+	}
+	if note.Protocol == nil || strings.TrimSpace(note.Protocol.Dissector) == "" {
+		return fmt.Errorf("note %s has no dissector", note.ID)
+	}
+	if *format != "tree" {
+		spec, err := dissect.Parse(note.Protocol.Dissector)
+		if err != nil {
+			return err
+		}
+		source, err := dissect.Export(spec, *format, note.Protocol.ExportOptions())
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(c.Stdout, source)
+		return nil
+	}
+
+	var data []byte
+	switch {
+	case *hexData != "":
+		if data, err = dissect.ParseHex(*hexData); err != nil {
+			return fmt.Errorf("invalid -hex: %w", err)
+		}
+	case *dataFile == "-":
+		if data, err = io.ReadAll(c.Stdin); err != nil {
+			return fmt.Errorf("reading stdin: %w", err)
+		}
+	case *dataFile != "":
+		if data, err = os.ReadFile(*dataFile); err != nil {
+			return err
+		}
+	case strings.TrimSpace(note.Protocol.Sample) == "":
+		return fmt.Errorf("note %s has no sample; pass -hex or -data", note.ID)
+	}
+	_, node, err := note.Protocol.Decode(data)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(c.Stdout, node.Format())
+	if problems := node.Problems(); len(problems) > 0 {
+		return fmt.Errorf("the message does not match the dissector: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// noteDisasm implements "revengo note disasm"
+func (c *CLI) noteDisasm(args []string) error {
+	fs := c.newFlagSet("note disasm", "<note> [-bytes] [-append]")
+	withBytes := fs.Bool("bytes", false, "show the bytes of each instruction")
+	appendBlock := fs.Bool("append", false, "append the listing to the note's content as a fenced code block")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, 1); err != nil {
+		return err
+	}
+
+	note, err := c.findNote(rest[0])
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(note.BinaryName) == "" || strings.TrimSpace(note.AddressRange) == "" {
+		return fmt.Errorf("note %s needs a binary name and an address range", note.ID)
+	}
+	projects, err := c.Projects.ListProjects()
+	if err != nil {
+		return fmt.Errorf("listing projects: %w", err)
+	}
+	path := models.FindBinary(projects, note.ProjectID, note.BinaryName)
+	if path == "" {
+		return fmt.Errorf("%s is not attached to a project; attach it with: revengo binary attach PROJECT FILE", note.BinaryName)
+	}
+	listing, err := c.disassemble(path, note.AddressRange)
+	if err != nil {
+		return err
+	}
+
+	if !*appendBlock {
+		fmt.Fprint(c.Stdout, listing.Format(*withBytes))
+		return nil
+	}
+	block := listing.Fenced(note.BinaryName)
+	if content := strings.TrimRight(note.Content, "\n"); content != "" {
+		block = content + "\n\n" + block
+	}
+	note.Content = block
+	if err := c.saveNote(note, note.Title); err != nil {
+		return err
+	}
+	fmt.Fprintln(c.Stdout, note.ID)
+	return nil
+}
+
+// saveNote saves a note the way the GUI does: related notes follow the
+// [[links]] in the content, and links in other notes follow a title change.
+func (c *CLI) saveNote(note *models.Note, oldTitle string) error {
+	if _, err := models.SaveLinkedNote(c.Notes, note, oldTitle); err != nil {
+		return fmt.Errorf("saving note: %w", err)
+	}
+	return nil
+}
+
+// printNoteTable prints notes as an aligned table
+func (c *CLI) printNoteTable(notes []*models.Note) {
+	w := tabwriter.NewWriter(c.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTYPE\tMODIFIED\tTITLE")
+	for _, note := range notes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", note.ID, note.ReverseEngType,
+			note.Modified.Format("2006-01-02 15:04"), note.Title)
+	}
+	w.Flush()
+}
+
+// projectName returns the name of a project, or its ID if it cannot be loaded
+func (c *CLI) projectName(id string) string {
+	if project, err := c.Projects.GetProject(id); err == nil {
+		return project.Name
+	}
+	return id
+}
+
+// helpOK turns a -h request into a successful exit
+func helpOK(err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
+
+// nonNil returns an empty slice instead of nil so JSON output is [] not null
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
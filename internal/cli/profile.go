@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/leog/RevEnGo/internal/config"
+)
+
+// profileSubcommands lists the subcommands of "revengo profile"
+var profileSubcommands = []subcommand{
+	{"list", "", "List profiles; * marks the one in use", (*CLI).profileList},
+	{"add", "<name> [-data-dir DIR]", "Create a profile", (*CLI).profileAdd},
+	{"use", "<name>", "Make a profile the default", (*CLI).profileUse},
+	{"rm", "<name>", "Remove a profile, keeping its data", (*CLI).profileRemove},
+}
+
+// runProfile dispatches "revengo profile" subcommands
+func (c *CLI) runProfile(args []string) error {
+	if c.Settings == nil {
+		return fmt.Errorf("profiles are not available")
+	}
+	return c.runSubcommand("profile", profileSubcommands, args)
+}
+
+// profileList implements "revengo profile list"
+func (c *CLI) profileList(args []string) error {
+	fs := c.newFlagSet("profile list", "[-json]")
+	asJSON := fs.Bool("json", false, "print JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 0, 0); err != nil {
+		return err
+	}
+
+	type profileInfo struct {
+		Name    string `json:"name"`
+		DataDir string `json:"data_dir"`
+		Current bool   `json:"current"`
+	}
+	var profiles []profileInfo
+	for _, name := range c.Settings.ProfileNames() {
+		dir, err := c.Settings.ProfileDataDir(name)
+		if err != nil {
+			return err
+		}
+		profiles = append(profiles, profileInfo{Name: name, DataDir: dir, Current: name == c.Profile})
+	}
+
+	if *asJSON {
+		return c.writeJSON(profiles)
+	}
+	w := tabwriter.NewWriter(c.Stdout, 0, 4, 2, ' ', 0)
+	for _, p := range profiles {
+		marker := " "
+		if p.Current {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s %s\t%s\n", marker, p.Name, p.DataDir)
+	}
+	return w.Flush()
+}
+
+// profileAdd implements "revengo profile add"
+func (c *CLI) profileAdd(args []string) error {
+	fs := c.newFlagSet("profile add", "<name> [-data-dir DIR]")
+	dataDir := fs.String("data-dir", "", "where the profile stores its data (default: inside the default data directory)")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, 1); err != nil {
+		return err
+	}
+
+	name := rest[0]
+	if c.Settings.HasProfile(name) {
+		return fmt.Errorf("a profile named %q already exists", name)
+	}
+	if err := c.Settings.AddProfile(name, *dataDir); err != nil {
+		return err
+	}
+	if err := c.Settings.Save(); err != nil {
+		return fmt.Errorf("saving configuration: %w", err)
+	}
+
+	dir, _ := c.Settings.ProfileDataDir(name)
+	fmt.Fprintf(c.Stdout, "created profile %s (%s)\n", name, dir)
+	return nil
+}
+
+// profileUse implements "revengo profile use"
+func (c *CLI) profileUse(args []string) error {
+	fs := c.newFlagSet("profile use", "<name>")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, 1); err != nil {
+		return err
+	}
+
+	name := rest[0]
+	if !c.Settings.HasProfile(name) {
+		return fmt.Errorf("no profile named %q", name)
+	}
+	c.Settings.DefaultProfile = name
+	if name == config.DefaultProfile {
+		c.Settings.DefaultProfile = ""
+	}
+	if err := c.Settings.Save(); err != nil {
+		return fmt.Errorf("saving configuration: %w", err)
+	}
+	fmt.Fprintf(c.Stdout, "using profile %s\n", name)
+	return nil
+}
+
+// profileRemove implements "revengo profile rm"
+func (c *CLI) profileRemove(args []string) error {
+	fs := c.newFlagSet("profile rm", "<name>")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, 1); err != nil {
+		return err
+	}
+
+	dir, _ := c.Settings.ProfileDataDir(rest[0])
+	if err := c.Settings.RemoveProfile(rest[0]); err != nil {
+		return err
+	}
+	if err := c.Settings.Save(); err != nil {
+		return fmt.Errorf("saving configuration: %w", err)
+	}
+	fmt.Fprintf(c.Stdout, "removed profile %s; its data remains in %s\n", rest[0], dir)
+	return nil
+}
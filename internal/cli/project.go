@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/leog/RevEnGo/internal/models"
+)
+
+// projectSubcommands lists the subcommands of "revengo project"
+var projectSubcommands = []subcommand{
+	{"list", "", "List projects", (*CLI).projectList},
+	{"show", "<project>", "Show a project and its notes", (*CLI).projectShow},
+	{"add", "-name N [-description D]", "Create a project", (*CLI).projectAdd},
+	{"edit", "<project> [-name N] [-description D]", "Change a project", (*CLI).projectEdit},
+	{"rm", "<project>...", "Delete projects, keeping their notes", (*CLI).projectRemove},
+}
+
+// runProject dispatches "revengo project" subcommands
+func (c *CLI) runProject(args []string) error {
+	return c.runSubcommand("project", projectSubcommands, args)
+}
+
+// projectList implements "revengo project list"
+func (c *CLI) projectList(args []string) error {
+	fs := c.newFlagSet("project list", "[-json]")
+	asJSON := fs.Bool("json", false, "print JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 0, 0); err != nil {
+		return err
+	}
+
+	projects, err := c.Projects.ListProjects()
+	if err != nil {
+		return fmt.Errorf("listing projects: %w", err)
+	}
+	sort.SliceStable(projects, func(i, j int) bool {
+		return strings.ToLower(projects[i].Name) < strings.ToLower(projects[j].Name)
+	})
+
+	if *asJSON {
+		return c.writeJSON(nonNil(projects))
+	}
+	w := tabwriter.NewWriter(c.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tDESCRIPTION")
+	for _, project := range projects {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", project.ID, project.Name, firstLine(project.Description))
+	}
+	return w.Flush()
+}
+
+// projectShow implements "revengo project show"
+func (c *CLI) projectShow(args []string) error {
+	fs := c.newFlagSet("project show", "<project> [-json]")
+	asJSON := fs.Bool("json", false, "print JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, 1); err != nil {
+		return err
+	}
+
+	project, err := c.findProject(rest[0])
+	if err != nil {
+		return err
+	}
+	notes, err := c.listNotes()
+	if err != nil {
+		return err
+	}
+	notes = models.FilterNotes(notes, models.NoteFilter{ProjectID: project.ID})
+
+	if *asJSON {
+		return c.writeJSON(struct {
+			*models.Project
+			Notes []*models.Note `json:"notes"`
+		}{project, nonNil(notes)})
+	}
+
+	fmt.Fprintf(c.Stdout, "ID:    %s\nName:  %s\n", project.ID, project.Name)
+	if project.Description != "" {
+		fmt.Fprintf(c.Stdout, "\n%s\n", project.Description)
+	}
+	fmt.Fprintln(c.Stdout)
+	c.printNoteTable(notes)
+	return nil
+}
+
+// projectAdd implements "revengo project add"
+func (c *CLI) projectAdd(args []string) error {
+	fs := c.newFlagSet("project add", "-name N [-description D] [-json]")
+	name := fs.String("name", "", "project name")
+	description := fs.String("description", "", "project description")
+	asJSON := fs.Bool("json", false, "print the created project as JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 0, 0); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*name) == "" {
+		fs.Usage()
+		return ErrUsage
+	}
+
+	project := &models.Project{Name: *name, Description: *description}
+	if err := c.Projects.SaveProject(project); err != nil {
+		return fmt.Errorf("saving project: %w", err)
+	}
+
+	if *asJSON {
+		return c.writeJSON(project)
+	}
+	fmt.Fprintln(c.Stdout, project.ID)
+	return nil
+}
+
+// projectEdit implements "revengo project edit"
+func (c *CLI) projectEdit(args []string) error {
+	fs := c.newFlagSet("project edit", "<project> [-name N] [-description D] [-json]")
+	name := fs.String("name", "", "new project name")
+	description := fs.String("description", "", "new project description")
+	asJSON := fs.Bool("json", false, "print the updated project as JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, 1); err != nil {
+		return err
+	}
+
+	project, err := c.findProject(rest[0])
+	if err != nil {
+		return err
+	}
+
+	// Without any field flags, edit the description interactively
+	edited := false
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "name":
+			project.Name = *name
+			edited = true
+		case "description":
+			project.Description = *description
+			edited = true
+		}
+	})
+	if !edited {
+		if project.Description, err = c.editText(project.Description); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Projects.SaveProject(project); err != nil {
+		return fmt.Errorf("saving project: %w", err)
+	}
+	if *asJSON {
+		return c.writeJSON(project)
+	}
+	fmt.Fprintln(c.Stdout, project.ID)
+	return nil
+}
+
+// projectRemove implements "revengo project rm"
+func (c *CLI) projectRemove(args []string) error {
+	fs := c.newFlagSet("project rm", "<project>...")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, -1); err != nil {
+		return err
+	}
+
+	for _, ref := range rest {
+		project, err := c.findProject(ref)
+		if err != nil {
+			return err
+		}
+		if err := c.Projects.DeleteProject(project.ID); err != nil {
+			return fmt.Errorf("deleting project %s: %w", project.ID, err)
+		}
+		fmt.Fprintf(c.Stdout, "deleted %s\n", project.ID)
+	}
+	return nil
+}
+
+// firstLine returns the first line of s
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leog/RevEnGo/internal/models"
+)
+
+// runSearch implements "revengo search"
+func (c *CLI) runSearch(args []string) error {
+	fs := c.newFlagSet("search", "<query>... [-project P] [-tag T] [-type T] [-json]")
+	project := fs.String("project", "", "only search notes in this project (ID or name)")
+	tag := fs.String("tag", "", "only search notes with this tag")
+	noteType := fs.String("type", "", "only search notes of this type")
+	asJSON := fs.Bool("json", false, "print JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, -1); err != nil {
+		return err
+	}
+
+	filter, err := c.noteFilter(*project, *tag, *noteType)
+	if err != nil {
+		return err
+	}
+	notes, err := c.listNotes()
+	if err != nil {
+		return err
+	}
+	results := models.SearchNotes(models.FilterNotes(notes, filter), strings.Join(rest, " "))
+
+	if *asJSON {
+		return c.writeJSON(nonNil(results))
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(c.Stderr, "no matching notes")
+		return nil
+	}
+	c.printNoteTable(results)
+	return nil
+}
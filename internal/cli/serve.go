@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/leog/RevEnGo/internal/api"
+)
+
+// runServe implements "revengo serve"
+func (c *CLI) runServe(args []string) error {
+	fs := c.newFlagSet("serve", "[-addr HOST:PORT] [-token TOKEN]")
+	addr := fs.String("addr", api.DefaultAddr, "address to listen on")
+	token := fs.String("token", "", "API token (default: the token stored in the data directory)")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 0, 0); err != nil {
+		return err
+	}
+
+	tokenSource := "-token flag"
+	if *token == "" {
+		if *token, err = api.LoadToken(c.DataDir); err != nil {
+			return fmt.Errorf("loading API token: %w", err)
+		}
+		tokenSource = filepath.Join(c.DataDir, api.TokenFile)
+	}
+
+	if host, _, err := net.SplitHostPort(*addr); err == nil {
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			fmt.Fprintf(c.Stderr, "revengo: warning: %s is reachable from other machines\n", *addr)
+		}
+	}
+
+	server := api.NewServer(c.Notes, c.Projects, c.Changes, *token)
+	srv, listening, err := server.Start(*addr)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.Stdout, "Serving the RevEnGo API on http://%s/api/\n", listening)
+	fmt.Fprintf(c.Stdout, "Bearer token: %s\n", tokenSource)
+
+	// Run until interrupted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdown)
+}
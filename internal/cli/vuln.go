@@ -0,0 +1,465 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/leog/RevEnGo/internal/models"
+	"github.com/leog/RevEnGo/internal/report"
+	"github.com/leog/RevEnGo/internal/vuln"
+)
+
+// vulnSubcommands lists the subcommands of "revengo vuln"
+var vulnSubcommands = []subcommand{
+	{"list", "[-project P] [-severity S] [-status S] [-open] [-binary B] [-version V]", "List findings, most severe first", (*CLI).vulnList},
+	{"dashboard", "[-project P]", "Count the findings of each project by severity and status", (*CLI).vulnDashboard},
+	{"set", "<note> [-cwe C] [-cvss V] [-binary B] [-affected R]... [-status S] [-date D]", "Change the fields of a vulnerability note", (*CLI).vulnSet},
+	{"score", "<vector>", "Score a CVSS v3.0, v3.1 or v4.0 vector", (*CLI).vulnScore},
+	{"cwe", "[query...]", "Search the bundled CWE catalog", (*CLI).vulnCWE},
+	{"report", "<note>... | -project P [-format F] [-o FILE] [-internal]", "Write a disclosure report in Markdown, HTML or PDF", (*CLI).vulnReport},
+	{"template", "[-format F]", "Print the built-in report template, to start a custom one", (*CLI).vulnTemplate},
+}
+
+// runVuln dispatches "revengo vuln" subcommands
+func (c *CLI) runVuln(args []string) error {
+	return c.runSubcommand("vuln", vulnSubcommands, args)
+}
+
+// vulnList implements "revengo vuln list"
+func (c *CLI) vulnList(args []string) error {
+	fs := c.newFlagSet("vuln list", "[-project P] [-severity S] [-status S] [-open] [-binary B] [-version V] [-json]")
+	project := fs.String("project", "", "only findings in this project (ID or name)")
+	severityName := fs.String("severity", "", "only findings of this severity: critical, high, medium, low, none or unscored")
+	status := fs.String("status", "", "only findings with this status: "+strings.Join(models.VulnStatuses, ", "))
+	open := fs.Bool("open", false, "only findings not fixed, disclosed or declined")
+	binary := fs.String("binary", "", "only findings in this binary")
+	version := fs.String("version", "", "only findings whose affected versions include this version")
+	asJSON := fs.Bool("json", false, "print JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 0, 0); err != nil {
+		return err
+	}
+	var severity vuln.Severity
+	if *severityName != "" {
+		if severity, err = vuln.ParseSeverity(*severityName); err != nil {
+			return err
+		}
+	}
+
+	filter, err := c.noteFilter(*project, "", models.RETypeVulnerability)
+	if err != nil {
+		return err
+	}
+	notes, err := c.listNotes()
+	if err != nil {
+		return err
+	}
+	var findings []models.Finding
+	for _, f := range models.Findings(models.FilterNotes(notes, filter)) {
+		switch {
+		case *severityName != "" && f.Severity() != severity,
+			*status != "" && f.Status() != *status,
+			*open && !models.IsOpenVulnStatus(f.Status()),
+			*binary != "" && !strings.EqualFold(f.Binary(), *binary),
+			*version != "" && !f.Note.Vulnerability.Affects(*version):
+			continue
+		}
+		findings = append(findings, f)
+	}
+
+	if *asJSON {
+		type finding struct {
+			ID       string  `json:"id"`
+			Title    string  `json:"title"`
+			Project  string  `json:"project,omitempty"`
+			Severity string  `json:"severity"`
+			Score    float64 `json:"score,omitempty"`
+			CVSS     string  `json:"cvss_version,omitempty"`
+			Status   string  `json:"status"`
+			CWE      string  `json:"cwe,omitempty"`
+			Binary   string  `json:"binary,omitempty"`
+		}
+		var out []finding
+		for _, f := range findings {
+			out = append(out, finding{
+				ID:       f.Note.ID,
+				Title:    f.Note.Title,
+				Project:  f.Note.ProjectID,
+				Severity: strings.ToLower(f.Severity().Label()),
+				Score:    f.Score.Value,
+				CVSS:     f.Score.Version,
+				Status:   f.Status(),
+				CWE:      f.Note.Vulnerability.CWE,
+				Binary:   f.Binary(),
+			})
+		}
+		return c.writeJSON(nonNil(out))
+	}
+
+	w := tabwriter.NewWriter(c.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSEVERITY\tSCORE\tSTATUS\tCWE\tBINARY\tTITLE")
+	for _, f := range findings {
+		score := "-"
+		if f.Score.Version != "" {
+			score = fmt.Sprintf("%.1f", f.Score.Value)
+		} else if f.Err != nil {
+			score = "invalid"
+		}
+		cwe, binary := "-", "-"
+		if v := f.Note.Vulnerability; v != nil && v.CWE != "" {
+			cwe = v.CWE
+		}
+		if f.Binary() != "" {
+			binary = f.Binary()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", f.Note.ID, f.Severity().Label(), score,
+			f.Status(), cwe, binary, f.Note.Title)
+	}
+	return w.Flush()
+}
+
+// vulnDashboard implements "revengo vuln dashboard"
+func (c *CLI) vulnDashboard(args []string) error {
+	fs := c.newFlagSet("vuln dashboard", "[-project P] [-json]")
+	project := fs.String("project", "", "only this project (ID or name)")
+	asJSON := fs.Bool("json", false, "print JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 0, 0); err != nil {
+		return err
+	}
+
+	filter, err := c.noteFilter(*project, "", models.RETypeVulnerability)
+	if err != nil {
+		return err
+	}
+	notes, err := c.listNotes()
+	if err != nil {
+		return err
+	}
+	summaries := models.SummarizeFindings(models.FilterNotes(notes, filter))
+
+	if *asJSON {
+		type summary struct {
+			Project    string         `json:"project"`
+			Name       string         `json:"name"`
+			Findings   int            `json:"findings"`
+			Open       int            `json:"open"`
+			BySeverity map[string]int `json:"by_severity"`
+			ByStatus   map[string]int `json:"by_status"`
+		}
+		var out []summary
+		for _, s := range summaries {
+			entry := summary{Project: s.ProjectID, Findings: len(s.Findings), BySeverity: make(map[string]int), ByStatus: s.ByStatus}
+			if s.ProjectID != "" {
+				entry.Name = c.projectName(s.ProjectID)
+			}
+			for severity, n := range s.BySeverity {
+				entry.BySeverity[strings.ToLower(severity.Label())] = n
+			}
+			for _, n := range s.Open {
+				entry.Open += n
+			}
+			out = append(out, entry)
+		}
+		return c.writeJSON(nonNil(out))
+	}
+
+	w := tabwriter.NewWriter(c.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprint(w, "PROJECT")
+	for _, severity := range vuln.Severities {
+		fmt.Fprintf(w, "\t%s", strings.ToUpper(severity.Label()))
+	}
+	fmt.Fprintln(w, "\tOPEN\tSTATUS")
+	for _, s := range summaries {
+		name := "(no project)"
+		if s.ProjectID != "" {
+			name = c.projectName(s.ProjectID)
+		}
+		fmt.Fprint(w, name)
+		open := 0
+		for _, severity := range vuln.Severities {
+			fmt.Fprintf(w, "\t%d", s.BySeverity[severity])
+			open += s.Open[severity]
+		}
+		var statuses []string
+		for _, status := range models.VulnStatuses {
+			if n := s.ByStatus[status]; n > 0 {
+				statuses = append(statuses, fmt.Sprintf("%d %s", n, status))
+			}
+		}
+		fmt.Fprintf(w, "\t%d\t%s\n", open, strings.Join(statuses, ", "))
+	}
+	return w.Flush()
+}
+
+// vulnSet implements "revengo vuln set"
+func (c *CLI) vulnSet(args []string) error {
+	fs := c.newFlagSet("vuln set", "<note> [-cwe C] [-cvss V] [-binary B] [-affected R]... [-status S] [-date D] [-json]")
+	cwe := fs.String("cwe", "", "weakness identifier, e.g. CWE-787")
+	cvss := fs.String("cvss", "", "CVSS v3.1 or v4.0 vector")
+	binary := fs.String("binary", "", "affected binary, if not the note's binary")
+	var affected []string
+	fs.Func("affected", "affected version range, e.g. \">= 2.0, < 2.4.1\"; repeat for several, or pass \"\" to clear", func(text string) error {
+		if text = strings.TrimSpace(text); text != "" {
+			affected = append(affected, text)
+		}
+		return nil
+	})
+	status := fs.String("status", "", "status: "+strings.Join(models.VulnStatuses, ", "))
+	date := fs.String("date", "", "day the status was reached, YYYY-MM-DD; defaults to today")
+	asJSON := fs.Bool("json", false, "print the updated note as JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, 1); err != nil {
+		return err
+	}
+
+	note, err := c.findNote(rest[0])
+	if err != nil {
+		return err
+	}
+	if note.ReverseEngType != models.RETypeVulnerability {
+		return fmt.Errorf("note %s is not a vulnerability note; change its type with: revengo note edit %s -type %s", note.ID, note.ID, models.RETypeVulnerability)
+	}
+	if note.Vulnerability == nil {
+		note.Vulnerability = &models.VulnerabilityDetails{}
+	}
+	v := note.Vulnerability
+
+	day := time.Now()
+	if *date != "" {
+		if day, err = time.Parse(models.VulnDateLayout, *date); err != nil {
+			return fmt.Errorf("invalid -date %q: expected YYYY-MM-DD", *date)
+		}
+	}
+	changed := false
+	fs.Visit(func(fl *flag.Flag) {
+		if err != nil {
+			return
+		}
+		switch fl.Name {
+		case "cwe":
+			v.CWE = ""
+			if strings.TrimSpace(*cwe) != "" {
+				var weakness vuln.CWE
+				if weakness, err = vuln.ParseCWE(*cwe); err == nil {
+					v.CWE = weakness.Ref()
+				}
+			}
+		case "cvss":
+			v.CVSSVector = strings.TrimSpace(*cvss)
+		case "binary":
+			v.AffectedBinary = strings.TrimSpace(*binary)
+		case "affected":
+			v.AffectedVersions = affected
+		case "status":
+			err = v.SetStatus(*status, day)
+		case "date":
+			if *status != "" {
+				return
+			}
+			// Correct the day of the current status
+			if v.Status == "" {
+				err = fmt.Errorf("-date needs -status, or a note with a status")
+				return
+			}
+			if v.StatusDates == nil {
+				v.StatusDates = make(map[string]string)
+			}
+			v.StatusDates[v.Status] = day.Format(models.VulnDateLayout)
+		case "json":
+			return
+		}
+		changed = true
+	})
+	if err != nil {
+		return err
+	}
+	if !changed {
+		fs.Usage()
+		return ErrUsage
+	}
+	if *status != "" && *date != "" {
+		// An explicit day replaces one recorded before
+		v.StatusDates[*status] = day.Format(models.VulnDateLayout)
+	}
+	if err := v.Validate(); err != nil {
+		return err
+	}
+	if err := c.saveNote(note, note.Title); err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return c.writeJSON(note)
+	}
+	fmt.Fprintln(c.Stdout, note.ID)
+	return nil
+}
+
+// vulnScore implements "revengo vuln score"
+func (c *CLI) vulnScore(args []string) error {
+	fs := c.newFlagSet("vuln score", "<vector>")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 1, 1); err != nil {
+		return err
+	}
+
+	score, err := vuln.ScoreVector(rest[0])
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(c.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Version:\tCVSS %s\n", score.Version)
+	fmt.Fprintf(w, "Metrics:\t%s\n", score.Nomenclature)
+	fmt.Fprintf(w, "Base score:\t%.1f\n", score.Base)
+	fmt.Fprintf(w, "Score:\t%.1f\n", score.Value)
+	fmt.Fprintf(w, "Severity:\t%s\n", score.Severity.Label())
+	return w.Flush()
+}
+
+// vulnCWE implements "revengo vuln cwe"
+func (c *CLI) vulnCWE(args []string) error {
+	fs := c.newFlagSet("vuln cwe", "[query...]")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+
+	found := vuln.SearchCWE(strings.Join(rest, " "))
+	if len(found) == 0 {
+		return fmt.Errorf("no weakness in the catalog matches %q", strings.Join(rest, " "))
+	}
+	w := tabwriter.NewWriter(c.Stdout, 0, 4, 2, ' ', 0)
+	for _, cwe := range found {
+		fmt.Fprintf(w, "%s\t%s\n", cwe.Ref(), cwe.Name)
+	}
+	return w.Flush()
+}
+
+// vulnReport implements "revengo vuln report"
+func (c *CLI) vulnReport(args []string) error {
+	fs := c.newFlagSet("vuln report", "<note>... | -project P [-open] [-format markdown|html|pdf] [-o FILE] [-title T] [-template NAME|FILE] [-redact F,...] [-internal]")
+	project := fs.String("project", "", "report the findings of this project (ID or name); with notes, only names the project")
+	open := fs.Bool("open", false, "with -project, only findings not fixed, disclosed or declined")
+	formatName := fs.String("format", report.FormatMarkdown, "report format: "+strings.Join(report.Formats, ", "))
+	output := fs.String("o", "", "write to FILE instead of stdout")
+	title := fs.String("title", "", "report title")
+	templateName := fs.String("template", "", "template name in the reports directory, or a template file")
+	redact := fs.String("redact", "", "comma-separated fields to redact besides those marked internal in the settings: "+strings.Join(report.RedactableFields, ", "))
+	internal := fs.Bool("internal", false, "keep internal content, for drafts shared within the team")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if len(rest) == 0 && *project == "" {
+		fs.Usage()
+		return ErrUsage
+	}
+	format, err := report.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	notes, err := c.listNotes()
+	if err != nil {
+		return err
+	}
+	opts := report.Options{Title: *title, Internal: *internal}
+	if c.Settings != nil {
+		opts.Redact = append(opts.Redact, c.Settings.Settings.ReportRedact...)
+	}
+	for _, field := range strings.Split(*redact, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			opts.Redact = append(opts.Redact, field)
+		}
+	}
+
+	var findings []*models.Note
+	if len(rest) > 0 {
+		for _, ref := range rest {
+			note, err := c.findNote(ref)
+			if err != nil {
+				return err
+			}
+			findings = append(findings, note)
+		}
+		if *project != "" {
+			p, err := c.findProject(*project)
+			if err != nil {
+				return err
+			}
+			opts.Project = p.Name
+		} else if id := findings[0].ProjectID; id != "" {
+			opts.Project = c.projectName(id)
+		}
+	} else {
+		filter, err := c.noteFilter(*project, "", models.RETypeVulnerability)
+		if err != nil {
+			return err
+		}
+		for _, note := range models.FilterNotes(notes, filter) {
+			if !*open || models.IsOpenVulnStatus(models.Finding{Note: note}.Status()) {
+				findings = append(findings, note)
+			}
+		}
+		if len(findings) == 0 {
+			return fmt.Errorf("project %s has no vulnerability notes to report", c.projectName(filter.ProjectID))
+		}
+		opts.Project = c.projectName(filter.ProjectID)
+	}
+
+	r, err := report.Build(findings, notes, opts)
+	if err != nil {
+		return err
+	}
+	text, err := report.LoadTemplate(filepath.Join(c.DataDir, report.TemplatesDir), *templateName, format)
+	if err != nil {
+		return err
+	}
+	data, err := report.Render(r, format, text)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = c.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*output, data, 0644)
+}
+
+// vulnTemplate implements "revengo vuln template"
+func (c *CLI) vulnTemplate(args []string) error {
+	fs := c.newFlagSet("vuln template", "[-format markdown|html]")
+	formatName := fs.String("format", report.FormatMarkdown, "template format: markdown (also used for PDF) or html")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return helpOK(err)
+	}
+	if err := expectArgs(fs, rest, 0, 0); err != nil {
+		return err
+	}
+	format, err := report.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(c.Stdout, report.BuiltinTemplate(format))
+	return err
+}
@@ -0,0 +1,320 @@
+// Package config manages RevEnGo's persistent configuration and profiles.
+// The configuration is a TOML file; each named profile selects a separate
+// data directory, so separate engagements keep separate notes and projects.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/leog/RevEnGo/internal/models"
+)
+
+// DefaultProfile is the name of the profile used when none is selected
+const DefaultProfile = "default"
+
+// Environment variables that override the configuration
+const (
+	// EnvConfig names the configuration file to use
+	EnvConfig = "REVENGO_CONFIG"
+
+	// EnvDataDir overrides the data directory of the selected profile
+	EnvDataDir = "REVENGO_DATA_DIR"
+
+	// EnvProfile selects a profile
+	EnvProfile = "REVENGO_PROFILE"
+)
+
+// Config is the contents of the configuration file
+type Config struct {
+	// DefaultProfile is the profile used when none is selected on the
+	// command line or in the environment. The GUI's profile switcher updates it.
+	DefaultProfile string `toml:"default_profile,omitempty"`
+
+	// Settings are the user's preferences
+	Settings Settings `toml:"settings"`
+
+	// Profiles maps profile names to their settings
+	Profiles map[string]Profile `toml:"profiles,omitempty"`
+
+	// NoteTypes are note types defined by the user in addition to the
+	// built-in ones, as [[note_types]] tables
+	NoteTypes []models.NoteType `toml:"note_types,omitempty"`
+
+	// path is the file the configuration was loaded from
+	path string
+}
+
+// Profile holds the settings of one named workspace
+type Profile struct {
+	// DataDir is where the profile's notes and projects are stored.
+	// A leading "~" is expanded to the home directory. If empty, the
+	// default profile uses the default data directory and other profiles
+	// use a "profiles/<name>" directory inside it.
+	DataDir string `toml:"data_dir,omitempty"`
+
+	// DefaultProject is the ID of the project new notes are filed under
+	DefaultProject string `toml:"default_project,omitempty"`
+}
+
+// Options are the data directory and profile chosen on the command line
+type Options struct {
+	// DataDir overrides the data directory
+	DataDir string
+
+	// Profile selects a profile by name
+	Profile string
+}
+
+// ParseArgs parses the global options that precede a command, such as
+// "revengo --profile client-a note list".
+//
+// Parameters:
+//   - args: The command-line arguments, without the program name
+//   - output: Where usage and errors are written
+//
+// Returns:
+//   - The parsed options
+//   - The remaining arguments
+//   - An error if the options are invalid
+func ParseArgs(args []string, output io.Writer) (Options, []string, error) {
+	var opts Options
+	fs := flag.NewFlagSet("revengo", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&opts.DataDir, "data-dir", "", "store notes and projects in `DIR` (env "+EnvDataDir+")")
+	fs.StringVar(&opts.Profile, "profile", "", "use the named profile (env "+EnvProfile+")")
+	fs.Usage = func() {
+		fmt.Fprintln(output, "Usage: revengo [-data-dir DIR] [-profile NAME] [command] [arguments]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return opts, nil, err
+	}
+	return opts, fs.Args(), nil
+}
+
+// DefaultDataDir returns the data directory used when nothing else is
+// configured: ~/.revengo, or a directory under the user configuration
+// directory if the home directory cannot be determined.
+func DefaultDataDir() (string, error) {
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".revengo"), nil
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "revengo"), nil
+	}
+	return "", fmt.Errorf("cannot determine a data directory; use -data-dir or set %s", EnvDataDir)
+}
+
+// Path returns the location of the configuration file: $REVENGO_CONFIG,
+// or config.toml in the default data directory.
+func Path() (string, error) {
+	if path := os.Getenv(EnvConfig); path != "" {
+		return expandHome(path), nil
+	}
+	dir, err := DefaultDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.toml"), nil
+}
+
+// Load reads a configuration file. A missing file yields an empty
+// configuration that will be created when saved.
+//
+// Parameters:
+//   - path: The configuration file to read
+//
+// Returns:
+//   - The configuration
+//   - An error if the file exists but cannot be read or parsed
+func Load(path string) (*Config, error) {
+	cfg := &Config{path: path}
+	if _, err := toml.DecodeFile(path, cfg); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]Profile)
+	}
+	return cfg, nil
+}
+
+// Save writes the configuration back to the file it was loaded from
+func (c *Config) Save() error {
+	if c.path == "" {
+		return errors.New("the configuration has no file to save to")
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a failed write cannot corrupt the config
+	tmp := c.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := toml.NewEncoder(f).Encode(c); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// Path returns the file the configuration is saved to
+func (c *Config) Path() string {
+	return c.path
+}
+
+// ThemesDir returns the directory holding user theme files, next to the
+// configuration file
+func (c *Config) ThemesDir() string {
+	return filepath.Join(filepath.Dir(c.path), "themes")
+}
+
+// ProfileNames returns the names of all profiles, including the default
+// profile, in alphabetical order
+func (c *Config) ProfileNames() []string {
+	names := []string{DefaultProfile}
+	for name := range c.Profiles {
+		if name != DefaultProfile {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// HasProfile reports whether a profile exists. The default profile always exists.
+func (c *Config) HasProfile(name string) bool {
+	if name == DefaultProfile {
+		return true
+	}
+	_, ok := c.Profiles[name]
+	return ok
+}
+
+// AddProfile creates a profile or changes its data directory.
+//
+// Parameters:
+//   - name: The profile name
+//   - dataDir: The profile's data directory, or "" for the default location
+//
+// Returns:
+//   - An error if the name is not valid
+func (c *Config) AddProfile(name, dataDir string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	p := c.Profiles[name]
+	p.DataDir = dataDir
+	c.Profiles[name] = p
+	return nil
+}
+
+// RemoveProfile deletes a profile from the configuration.
+// The profile's data directory is left untouched.
+func (c *Config) RemoveProfile(name string) error {
+	if name == DefaultProfile {
+		return errors.New("the default profile cannot be removed")
+	}
+	if _, ok := c.Profiles[name]; !ok {
+		return fmt.Errorf("no profile named %q", name)
+	}
+	delete(c.Profiles, name)
+	if c.DefaultProfile == name {
+		c.DefaultProfile = ""
+	}
+	return nil
+}
+
+// ProfileDataDir returns the data directory of a profile
+func (c *Config) ProfileDataDir(name string) (string, error) {
+	if p, ok := c.Profiles[name]; ok && p.DataDir != "" {
+		return expandHome(p.DataDir), nil
+	}
+	base, err := DefaultDataDir()
+	if err != nil {
+		return "", err
+	}
+	if name == DefaultProfile {
+		return base, nil
+	}
+	return filepath.Join(base, "profiles", name), nil
+}
+
+// Resolve picks the profile and data directory to use. Command-line options
+// take precedence over the environment, which takes precedence over the
+// configuration file.
+//
+// Parameters:
+//   - opts: The options given on the command line
+//
+// Returns:
+//   - The selected profile name
+//   - The data directory to use
+//   - An error if the profile does not exist or no directory can be found
+func (c *Config) Resolve(opts Options) (string, string, error) {
+	profile := firstNonEmpty(opts.Profile, os.Getenv(EnvProfile), c.DefaultProfile, DefaultProfile)
+	dataDir := firstNonEmpty(opts.DataDir, os.Getenv(EnvDataDir))
+
+	if dataDir != "" {
+		return profile, expandHome(dataDir), nil
+	}
+	if !c.HasProfile(profile) {
+		return "", "", fmt.Errorf("no profile named %q (available: %s)", profile, strings.Join(c.ProfileNames(), ", "))
+	}
+	dataDir, err := c.ProfileDataDir(profile)
+	return profile, dataDir, err
+}
+
+// ValidateProfileName checks that a profile name is usable as a directory name
+func ValidateProfileName(name string) error {
+	if name == "" {
+		return errors.New("a profile name is required")
+	}
+	for _, r := range name {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-' || r == '_' || r == '.') {
+			return fmt.Errorf("invalid profile name %q: use letters, digits, '-', '_' and '.'", name)
+		}
+	}
+	if name == "." || name == ".." {
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+	return nil
+}
+
+// expandHome replaces a leading "~" with the user's home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// firstNonEmpty returns the first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
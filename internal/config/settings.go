@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leog/RevEnGo/internal/report"
+)
+
+// Limits on the editor font size, in points
+const (
+	MinEditorFontSize = 8
+	MaxEditorFontSize = 32
+)
+
+// Settings are the user's preferences. They apply to every profile and are
+// edited in the GUI's settings dialog.
+type Settings struct {
+	// AutosaveSeconds is how often edited notes are saved automatically;
+	// 0 disables autosave
+	AutosaveSeconds int `toml:"autosave_seconds,omitempty"`
+
+	// DefaultNoteType is the type given to new notes; empty means general
+	DefaultNoteType string `toml:"default_note_type,omitempty"`
+
+	// EditorFontSize is the text size of the note editor; 0 uses the theme's size
+	EditorFontSize float32 `toml:"editor_font_size,omitempty"`
+
+	// Theme names the GUI theme; empty selects the default theme
+	Theme string `toml:"theme,omitempty"`
+
+	// Keybindings maps action names to shortcuts such as "CmdOrCtrl+S",
+	// replacing the default shortcut of each listed action. An empty
+	// shortcut removes the action's binding.
+	Keybindings map[string]string `toml:"keybindings,omitempty"`
+
+	// ReportRedact names the fields marked internal, which vulnerability
+	// reports redact; see report.RedactableFields
+	ReportRedact []string `toml:"report_redact,omitempty"`
+}
+
+// AutosaveInterval returns the autosave period, or 0 if autosave is off
+func (s Settings) AutosaveInterval() time.Duration {
+	if s.AutosaveSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(s.AutosaveSeconds) * time.Second
+}
+
+// Validate checks that the settings are within range
+func (s Settings) Validate() error {
+	if s.AutosaveSeconds < 0 {
+		return fmt.Errorf("invalid autosave interval %d: use 0 to disable autosave", s.AutosaveSeconds)
+	}
+	if s.EditorFontSize != 0 && (s.EditorFontSize < MinEditorFontSize || s.EditorFontSize > MaxEditorFontSize) {
+		return fmt.Errorf("invalid editor font size %g: use %d to %d", s.EditorFontSize, MinEditorFontSize, MaxEditorFontSize)
+	}
+	for _, field := range s.ReportRedact {
+		if !slices.Contains(report.RedactableFields, field) {
+			return fmt.Errorf("invalid report_redact field %q: use %s", field, strings.Join(report.RedactableFields, ", "))
+		}
+	}
+	return nil
+}
+
+// DefaultProject returns the ID of the project new notes of a profile are
+// filed under, or "" if there is none
+func (c *Config) DefaultProject(profile string) string {
+	return c.Profiles[profile].DefaultProject
+}
+
+// SetDefaultProject sets the project new notes of a profile are filed under.
+//
+// Parameters:
+//   - profile: The profile name
+//   - projectID: The project ID, or "" for none
+func (c *Config) SetDefaultProject(profile, projectID string) {
+	p := c.Profiles[profile]
+	p.DefaultProject = projectID
+	c.Profiles[profile] = p
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/leog/RevEnGo/internal/models"
+)
+
+// Workspace is an opened data directory: the stores of one profile
+type Workspace struct {
+	// Profile is the name of the profile the workspace belongs to
+	Profile string
+
+	// DataDir is the directory holding the workspace's data
+	DataDir string
+
+	// Notes and Projects are the workspace's stores
+	Notes    *models.FileNoteStore
+	Projects *models.FileProjectStore
+
+	// Changes reports edits to the stores made by any process
+	Changes *models.ChangeFeed
+}
+
+// Open opens the stores in a data directory, creating it if needed.
+//
+// Parameters:
+//   - profile: The name of the profile being opened
+//   - dataDir: The profile's data directory
+//
+// Returns:
+//   - The opened workspace
+//   - An error if the directories cannot be created
+func Open(profile, dataDir string) (*Workspace, error) {
+	notesDir := filepath.Join(dataDir, "notes")       // For storing note files
+	projectsDir := filepath.Join(dataDir, "projects") // For storing project files
+
+	notes, err := models.NewFileNoteStore(notesDir)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := models.NewFileProjectStore(projectsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Workspace{
+		Profile:  profile,
+		DataDir:  dataDir,
+		Notes:    notes,
+		Projects: projects,
+		Changes:  models.NewChangeFeed(notesDir, projectsDir),
+	}, nil
+}
+
+// ProgramFlowDir returns the directory for program flow diagrams
+func (w *Workspace) ProgramFlowDir() string {
+	return filepath.Join(w.DataDir, "program-flow")
+}
+
+// InDefaultDataDir reports whether the workspace is the default data
+// directory, which holds the data kept before profiles and -data-dir
+// existed
+func (w *Workspace) InDefaultDataDir() bool {
+	dir, err := DefaultDataDir()
+	return err == nil && filepath.Clean(w.DataDir) == filepath.Clean(dir)
+}
+
+// LegacyProgramFlowDir returns the directory program flow diagrams were
+// kept in before they moved into data directories
+func LegacyProgramFlowDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Program Flow"), nil
+}
+
+// MigrateProgramFlow moves the program flow diagrams of a directory into
+// the workspace's program flow directory, and removes the directory once
+// it is empty. Diagrams whose name is taken are left where they are.
+//
+// Parameters:
+//   - legacyDir: The directory the diagrams were kept in; missing is fine
+//
+// Returns:
+//   - The names of the diagrams moved
+//   - The names of the diagrams left in legacyDir
+//   - An error if the directories cannot be read or created
+func (w *Workspace) MigrateProgramFlow(legacyDir string) (moved, kept []string, err error) {
+	entries, err := os.ReadDir(legacyDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	dir := w.ProgramFlowDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		target := filepath.Join(dir, name)
+		if _, err := os.Lstat(target); err == nil {
+			kept = append(kept, name)
+			continue
+		}
+		// Renames fail across filesystems; those diagrams stay behind too
+		if err := os.Rename(filepath.Join(legacyDir, name), target); err != nil {
+			kept = append(kept, name)
+			continue
+		}
+		moved = append(moved, name)
+	}
+	if len(kept) == 0 {
+		os.Remove(legacyDir)
+	}
+	return moved, kept, nil
+}
+
+// Close stops watching the workspace for changes
+func (w *Workspace) Close() error {
+	return w.Changes.Close()
+}
@@ -0,0 +1,136 @@
+// Package cstruct parses C structure definitions and computes their memory
+// layout for a target ABI. It supports nested structs, unions, enums,
+// arrays, bitfields, typedefs, #define constants and #pragma pack, and
+// exports the definitions as C headers, C for Ghidra's C parser and
+// Python ctypes classes.
+package cstruct
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ABI describes the sizes and alignments of C types on a target
+type ABI struct {
+	// Name identifies the ABI, such as "x64"
+	Name string
+
+	// Description names the platform conventions the ABI follows
+	Description string
+
+	// PointerSize is the size of pointers and pointer-sized integers
+	PointerSize int
+
+	// LongSize is the size of long
+	LongSize int
+
+	// Int64Align is the alignment of long long, int64_t and double inside
+	// structures; i386 System V aligns them to 4 bytes
+	Int64Align int
+
+	// LongDoubleSize and LongDoubleAlign describe long double
+	LongDoubleSize  int
+	LongDoubleAlign int
+
+	// WCharSize is the size of wchar_t
+	WCharSize int
+
+	// CharUnsigned reports whether plain char is unsigned
+	CharUnsigned bool
+
+	// MSBitfields selects Microsoft's bitfield allocation, where adjacent
+	// bitfields share a storage unit only if their types have the same size
+	MSBitfields bool
+
+	// BigEndian reports whether multi-byte values are stored most
+	// significant byte first
+	BigEndian bool
+}
+
+// Names of the supported ABIs
+const (
+	ABIX86     = "x86"
+	ABIX86MSVC = "x86-msvc"
+	ABIX64     = "x64"
+	ABIX64MSVC = "x64-msvc"
+	ABIARM32   = "arm32"
+	ABIAArch64 = "aarch64"
+)
+
+// DefaultABI is the ABI used when none is chosen
+const DefaultABI = ABIX64
+
+// abis are the supported ABIs in display order
+var abis = []ABI{
+	{
+		Name: ABIX86, Description: "i386 System V (GCC, Clang)",
+		PointerSize: 4, LongSize: 4, Int64Align: 4,
+		LongDoubleSize: 12, LongDoubleAlign: 4, WCharSize: 4,
+	},
+	{
+		Name: ABIX86MSVC, Description: "32-bit Windows (MSVC)",
+		PointerSize: 4, LongSize: 4, Int64Align: 8,
+		LongDoubleSize: 8, LongDoubleAlign: 8, WCharSize: 2, MSBitfields: true,
+	},
+	{
+		Name: ABIX64, Description: "x86-64 System V (GCC, Clang)",
+		PointerSize: 8, LongSize: 8, Int64Align: 8,
+		LongDoubleSize: 16, LongDoubleAlign: 16, WCharSize: 4,
+	},
+	{
+		Name: ABIX64MSVC, Description: "64-bit Windows (MSVC)",
+		PointerSize: 8, LongSize: 4, Int64Align: 8,
+		LongDoubleSize: 8, LongDoubleAlign: 8, WCharSize: 2, MSBitfields: true,
+	},
+	{
+		Name: ABIARM32, Description: "ARM AAPCS, little endian",
+		PointerSize: 4, LongSize: 4, Int64Align: 8,
+		LongDoubleSize: 8, LongDoubleAlign: 8, WCharSize: 4, CharUnsigned: true,
+	},
+	{
+		Name: ABIAArch64, Description: "AArch64 AAPCS64 (Linux, macOS)",
+		PointerSize: 8, LongSize: 8, Int64Align: 8,
+		LongDoubleSize: 16, LongDoubleAlign: 16, WCharSize: 4, CharUnsigned: true,
+	},
+}
+
+// ABIs returns the supported ABIs in display order
+func ABIs() []ABI {
+	return append([]ABI(nil), abis...)
+}
+
+// ABINames returns the names of the supported ABIs in display order
+func ABINames() []string {
+	names := make([]string, len(abis))
+	for i, abi := range abis {
+		names[i] = abi.Name
+	}
+	return names
+}
+
+// LookupABI returns the ABI with a name; the empty name selects DefaultABI.
+//
+// Parameters:
+//   - name: The ABI name, ignoring case
+//
+// Returns:
+//   - The ABI
+//   - An error naming the supported ABIs if the name is unknown
+func LookupABI(name string) (ABI, error) {
+	if name == "" {
+		name = DefaultABI
+	}
+	for _, abi := range abis {
+		if strings.EqualFold(abi.Name, name) {
+			return abi, nil
+		}
+	}
+	return ABI{}, fmt.Errorf("unknown ABI %q (supported: %s)", name, strings.Join(ABINames(), ", "))
+}
+
+// WithByteOrder returns a copy of the ABI with the given byte order, for
+// targets such as big-endian ARM or data read in network byte order
+func (a ABI) WithByteOrder(bigEndian bool) ABI {
+	a.BigEndian = bigEndian
+	return a
+}
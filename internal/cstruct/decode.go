@@ -0,0 +1,326 @@
+package cstruct
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// maxListed is the number of array elements or bytes shown before a value
+// is cut short
+const maxListed = 16
+
+// Value is a member of a layout decoded from bytes
+type Value struct {
+	Row
+
+	// Bytes are the bytes holding the member; for bitfields, the bytes its
+	// bits touch. Shorter than the member when the data ends inside it.
+	Bytes []byte
+
+	// Missing reports whether the data ends before the member does
+	Missing bool
+
+	// Hex, Decimal, ASCII and Pointer show the member's value in each
+	// form; empty when the form does not apply
+	Hex     string
+	Decimal string
+	ASCII   string
+	Pointer string
+}
+
+// Decode overlays a layout on bytes and decodes every member, following
+// nested records like Rows.
+//
+// Parameters:
+//   - data: The bytes at the start of the record; may be shorter than it
+//   - abi: The ABI the layout was computed for, which gives the byte order,
+//     pointer size and signedness of char
+//
+// Returns:
+//   - The decoded members in offset order
+func (l *Layout) Decode(data []byte, abi ABI) []Value {
+	rows := l.Rows()
+	values := make([]Value, len(rows))
+	for i, row := range rows {
+		values[i] = decodeRow(row, data, abi)
+	}
+	return values
+}
+
+// decodeRow decodes one member
+func decodeRow(row Row, data []byte, abi ABI) Value {
+	v := Value{Row: row}
+	start, end := row.Offset, row.Offset+row.Size
+	if row.IsBitfield() {
+		start = row.Offset + row.BitOffset/8
+		end = row.Offset + (row.BitOffset+row.BitSize+7)/8
+	}
+	if end > len(data) {
+		v.Missing = true
+		end = len(data)
+	}
+	if start < end {
+		v.Bytes = data[start:end]
+	}
+	if v.Missing {
+		v.Hex = hexBytes(v.Bytes)
+		return v
+	}
+
+	order := byteOrder(abi)
+	switch {
+	case row.Padding:
+		v.Hex = hexBytes(v.Bytes)
+		v.ASCII = asciiBytes(v.Bytes)
+
+	case row.IsBitfield():
+		bits := readBits(data, row.Offset*8+row.BitOffset, row.BitSize, abi.BigEndian)
+		v.Hex = fmt.Sprintf("0x%x", bits)
+		if isSigned(row.Elem, abi) && bits&(1<<(row.BitSize-1)) != 0 {
+			bits |= ^uint64(0) << row.BitSize
+		}
+		v.Decimal = formatInteger(row.Elem, bits, abi)
+
+	case row.Dims != nil:
+		v.Hex = hexBytes(v.Bytes)
+		v.ASCII = asciiBytes(v.Bytes)
+		if row.Elem.Kind == Char {
+			v.ASCII = strconv.Quote(cString(v.Bytes))
+		} else if row.Record == nil && row.ElemSize > 0 {
+			v.Decimal = formatArray(row, v.Bytes, abi)
+		}
+
+	case row.Record != nil:
+		v.Hex = hexBytes(v.Bytes)
+		v.ASCII = asciiBytes(v.Bytes)
+
+	default:
+		raw, ok := readScalar(v.Bytes, order)
+		if !ok {
+			v.Hex = hexBytes(v.Bytes)
+			break
+		}
+		v.Hex = fmt.Sprintf("0x%0*x", 2*len(v.Bytes), raw)
+		v.Decimal = formatScalar(row.Elem, raw, len(v.Bytes), abi)
+		v.ASCII = asciiBytes(v.Bytes)
+		if len(v.Bytes) == abi.PointerSize {
+			v.Pointer = formatPointer(raw, abi)
+		}
+	}
+	return v
+}
+
+// byteOrder returns the byte order of an ABI
+func byteOrder(abi ABI) binary.ByteOrder {
+	if abi.BigEndian {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// readScalar reads an unsigned integer of 1 to 8 bytes
+func readScalar(b []byte, order binary.ByteOrder) (uint64, bool) {
+	switch len(b) {
+	case 1:
+		return uint64(b[0]), true
+	case 2:
+		return uint64(order.Uint16(b)), true
+	case 4:
+		return uint64(order.Uint32(b)), true
+	case 8:
+		return order.Uint64(b), true
+	}
+	return 0, false
+}
+
+// readBits reads a bitfield starting at a bit of the data. Little-endian
+// ABIs allocate bitfields from the least significant bit of each byte,
+// big-endian ones from the most significant.
+func readBits(data []byte, start, size int, bigEndian bool) uint64 {
+	var v uint64
+	for i := 0; i < size; i++ {
+		bit := start + i
+		if bigEndian {
+			v = v<<1 | uint64(data[bit/8]>>(7-bit%8)&1)
+		} else {
+			v |= uint64(data[bit/8]>>(bit%8)&1) << i
+		}
+	}
+	return v
+}
+
+// isSigned reports whether an integer type is signed on an ABI
+func isSigned(t *Type, abi ABI) bool {
+	switch t.Kind {
+	case Bool, Pointer:
+		return false
+	case Char:
+		return t.Signed || !t.Unsigned && !abi.CharUnsigned
+	case WChar:
+		return abi.WCharSize == 4
+	case Enum:
+		if t.Enum.Base != nil {
+			return isSigned(t.Enum.Base, abi)
+		}
+		return true
+	}
+	return !t.Unsigned
+}
+
+// formatScalar shows a scalar member's value in decimal: integers with
+// their sign, enums with the constant's name, and floating point values
+func formatScalar(t *Type, raw uint64, size int, abi ABI) string {
+	switch t.Kind {
+	case Float:
+		if size == 4 {
+			return strconv.FormatFloat(float64(math.Float32frombits(uint32(raw))), 'g', -1, 32)
+		}
+	case Double:
+		if size == 8 {
+			return strconv.FormatFloat(math.Float64frombits(raw), 'g', -1, 64)
+		}
+	case LongDouble:
+		if size == 8 {
+			return strconv.FormatFloat(math.Float64frombits(raw), 'g', -1, 64)
+		}
+	case Pointer:
+		return strconv.FormatUint(raw, 10)
+	default:
+		if isSigned(t, abi) && size < 8 && raw&(1<<(size*8-1)) != 0 {
+			raw |= ^uint64(0) << (size * 8)
+		}
+		return formatInteger(t, raw, abi)
+	}
+	return ""
+}
+
+// formatInteger shows an integer, sign extended to 64 bits, in decimal
+func formatInteger(t *Type, raw uint64, abi ABI) string {
+	var s string
+	if isSigned(t, abi) {
+		s = strconv.FormatInt(int64(raw), 10)
+	} else {
+		s = strconv.FormatUint(raw, 10)
+	}
+	switch t.Kind {
+	case Bool:
+		s += map[bool]string{false: " (false)", true: " (true)"}[raw != 0]
+	case Enum:
+		if name := t.Enum.ValueName(int64(raw)); name != "" {
+			s += " (" + name + ")"
+		}
+	}
+	return s
+}
+
+// formatArray lists the elements of an array of scalars
+func formatArray(row Row, b []byte, abi ABI) string {
+	order := byteOrder(abi)
+	var items []string
+	for i := 0; i+row.ElemSize <= len(b); i += row.ElemSize {
+		if len(items) == maxListed {
+			items = append(items, "...")
+			break
+		}
+		raw, ok := readScalar(b[i:i+row.ElemSize], order)
+		if !ok {
+			return ""
+		}
+		items = append(items, formatScalar(row.Elem, raw, row.ElemSize, abi))
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// formatPointer shows a pointer-sized value as an address
+func formatPointer(raw uint64, abi ABI) string {
+	if raw == 0 {
+		return "NULL"
+	}
+	return fmt.Sprintf("0x%0*x", 2*abi.PointerSize, raw)
+}
+
+// hexBytes shows bytes in hex, cut short after maxListed bytes
+func hexBytes(b []byte) string {
+	var parts []string
+	for i, c := range b {
+		if i == maxListed {
+			parts = append(parts, "...")
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%02x", c))
+	}
+	return strings.Join(parts, " ")
+}
+
+// asciiBytes shows printable bytes as characters and others as dots, cut
+// short after maxListed bytes
+func asciiBytes(b []byte) string {
+	var s strings.Builder
+	for i, c := range b {
+		if i == maxListed {
+			s.WriteString("...")
+			break
+		}
+		if c >= 0x20 && c < 0x7f {
+			s.WriteByte(c)
+		} else {
+			s.WriteByte('.')
+		}
+	}
+	return s.String()
+}
+
+// cString returns the bytes of a char array up to the first NUL
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+// FormatValues writes decoded members as a table of offsets, names and
+// their value in hex, decimal, ASCII and as a pointer.
+//
+// Parameters:
+//   - l: The layout the values were decoded with
+//   - values: The values from l.Decode
+//   - base: The offset of the record in its data source, added to the
+//     offsets shown
+//
+// Returns:
+//   - The table, starting with a line naming the record
+func FormatValues(l *Layout, values []Value, base int64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s at 0x%x: %d bytes\n", l.Record.keyword(), l.displayName(), base, l.Size)
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "OFFSET\tNAME\tHEX\tDECIMAL\tASCII\tPOINTER")
+	for _, v := range values {
+		offset := fmt.Sprintf("0x%04x", base+int64(v.Offset))
+		name := strings.Repeat("  ", v.Depth) + v.Label()
+		if v.IsBitfield() {
+			offset += fmt.Sprintf(":%d", v.BitOffset)
+			name += fmt.Sprintf(" : %d", v.BitSize)
+		}
+		hex := v.Hex
+		if v.Missing {
+			hex = strings.TrimSpace("(beyond end of data) " + hex)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", offset, name, dash(hex), dash(v.Decimal), dash(v.ASCII), dash(v.Pointer))
+	}
+	w.Flush()
+	return b.String()
+}
+
+// dash shows an empty cell as "-"
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
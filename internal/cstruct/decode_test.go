@@ -0,0 +1,193 @@
+package cstruct
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// decoded lays out the record s on an ABI and decodes bytes with it.
+//
+// Returns:
+//   - The layout
+//   - The decoded members, by path
+func decoded(t *testing.T, src string, abi ABI, data []byte) (*Layout, map[string]Value) {
+	t.Helper()
+	f, err := Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := f.Layout("s", abi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]Value)
+	for _, v := range l.Decode(data, abi) {
+		if !v.Padding {
+			values[v.Path] = v
+		}
+	}
+	return l, values
+}
+
+// lookupABI returns a built-in ABI, failing the test if it is unknown
+func lookupABI(t *testing.T, name string) ABI {
+	t.Helper()
+	abi, err := LookupABI(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return abi
+}
+
+func TestDecodeByteOrders(t *testing.T) {
+	src := "struct s { unsigned short a; int b; unsigned int c; };"
+	data := []byte{0x12, 0x34, 0, 0, 0xff, 0xff, 0xff, 0xfe, 0x01, 0x02, 0x03, 0x04}
+	tests := []struct {
+		bigEndian bool
+
+		// want are the hex and decimal forms of a, b and c
+		want [3][2]string
+	}{
+		{false, [3][2]string{{"0x3412", "13330"}, {"0xfeffffff", "-16777217"}, {"0x04030201", "67305985"}}},
+		{true, [3][2]string{{"0x1234", "4660"}, {"0xfffffffe", "-2"}, {"0x01020304", "16909060"}}},
+	}
+	for _, tt := range tests {
+		_, values := decoded(t, src, lookupABI(t, ABIX64).WithByteOrder(tt.bigEndian), data)
+		for i, path := range []string{"a", "b", "c"} {
+			v := values[path]
+			if v.Hex != tt.want[i][0] || v.Decimal != tt.want[i][1] {
+				t.Errorf("big endian %v: %s = %s %s, want %s %s", tt.bigEndian, path, v.Hex, v.Decimal, tt.want[i][0], tt.want[i][1])
+			}
+		}
+	}
+}
+
+func TestDecodeBitfields(t *testing.T) {
+	src := "struct s { unsigned int lo : 3; int mid : 5; unsigned int hi : 8; int neg : 4; };"
+	data := []byte{0xb5, 0xa5, 0x0f, 0}
+	tests := []struct {
+		bigEndian bool
+		want      map[string]string
+	}{
+		// Little-endian ABIs take bits from the least significant end:
+		// lo is 101, mid 10110, hi 0xa5 and neg 1111
+		{false, map[string]string{"lo": "0x5 5", "mid": "0x16 -10", "hi": "0xa5 165", "neg": "0xf -1"}},
+
+		// Big-endian ABIs take them from the most significant end: lo is
+		// 101, mid 10101, hi 0xa5 and neg 0000
+		{true, map[string]string{"lo": "0x5 5", "mid": "0x15 -11", "hi": "0xa5 165", "neg": "0x0 0"}},
+	}
+	for _, tt := range tests {
+		_, values := decoded(t, src, lookupABI(t, ABIX64).WithByteOrder(tt.bigEndian), data)
+		for path, want := range tt.want {
+			v := values[path]
+			if got := v.Hex + " " + v.Decimal; got != want {
+				t.Errorf("big endian %v: %s = %s, want %s", tt.bigEndian, path, got, want)
+			}
+		}
+	}
+}
+
+func TestDecodeFormats(t *testing.T) {
+	src := `
+enum color { RED, GREEN = 5 };
+struct s { float f; double d; void *p; enum color c; char *q; _Bool ok; char name[6]; };`
+	tests := []struct {
+		abi     string
+		pointer uint64
+		want    string
+	}{
+		{ABIX86, 0x08049000, "0x08049000"},
+		{ABIX64, 0x7fff12345678, "0x00007fff12345678"},
+	}
+	for _, tt := range tests {
+		abi := lookupABI(t, tt.abi)
+		l, _ := decoded(t, src, abi, nil)
+
+		// Fill each member at its offset on the ABI
+		data := make([]byte, l.Size)
+		for _, row := range l.Rows() {
+			b := data[row.Offset : row.Offset+row.Size]
+			switch row.Path {
+			case "f":
+				binary.LittleEndian.PutUint32(b, math.Float32bits(1.5))
+			case "d":
+				binary.LittleEndian.PutUint64(b, math.Float64bits(-2.25))
+			case "p":
+				if abi.PointerSize == 4 {
+					binary.LittleEndian.PutUint32(b, uint32(tt.pointer))
+				} else {
+					binary.LittleEndian.PutUint64(b, tt.pointer)
+				}
+			case "c":
+				binary.LittleEndian.PutUint32(b, 5)
+			case "ok":
+				b[0] = 1
+			case "name":
+				copy(b, "ab\x00cd")
+			}
+		}
+		_, values := decoded(t, src, abi, data)
+
+		checks := []struct {
+			path, got, want string
+		}{
+			{"f", values["f"].Decimal, "1.5"},
+			{"d", values["d"].Decimal, "-2.25"},
+			{"p", values["p"].Pointer, tt.want},
+			{"c", values["c"].Decimal, "5 (GREEN)"},
+			{"q", values["q"].Pointer, "NULL"},
+			{"ok", values["ok"].Decimal, "1 (true)"},
+			{"name", values["name"].ASCII, `"ab"`},
+		}
+		for _, c := range checks {
+			if c.got != c.want {
+				t.Errorf("%s: %s = %q, want %q", tt.abi, c.path, c.got, c.want)
+			}
+		}
+		if size := values["p"].Size; size != abi.PointerSize {
+			t.Errorf("%s: pointer of %d bytes, want %d", tt.abi, size, abi.PointerSize)
+		}
+	}
+}
+
+func TestDecodeShortData(t *testing.T) {
+	src := "struct s { int a; unsigned int b : 4; unsigned int c : 12; char name[8]; struct { short x; long long y; } in; };"
+	abi := lookupABI(t, ABIX64)
+	l, _ := decoded(t, src, abi, nil)
+	full := make([]byte, l.Size)
+	for i := range full {
+		full[i] = byte(i + 1)
+	}
+
+	// Every length up to the record's size decodes without panicking, and
+	// the members the data ends inside are missing
+	for n := 0; n <= l.Size; n++ {
+		for _, v := range l.Decode(full[:n], abi) {
+			end := v.Offset + v.Size
+			if v.IsBitfield() {
+				end = v.Offset + (v.BitOffset+v.BitSize+7)/8
+			}
+			if v.Missing != (end > n) {
+				t.Errorf("%d bytes: %s missing %v", n, v.Path, v.Missing)
+			}
+			if v.Missing && v.Decimal != "" {
+				t.Errorf("%d bytes: missing %s decoded as %s", n, v.Path, v.Decimal)
+			}
+		}
+	}
+
+	// Members before the end of the data are still decoded: b's bits lie
+	// in the fifth byte, c's reach into the sixth
+	_, values := decoded(t, src, abi, full[:5])
+	if v := values["a"]; v.Missing || v.Decimal != "67305985" {
+		t.Errorf("a = %+v, want 67305985", v)
+	}
+	if v := values["b"]; v.Missing || v.Decimal != "5" {
+		t.Errorf("b = %+v, want 5", v)
+	}
+	if v := values["c"]; !v.Missing || v.Hex != "05" {
+		t.Errorf("c = %+v, want missing with the byte 05", v)
+	}
+}
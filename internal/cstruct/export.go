@@ -0,0 +1,843 @@
+package cstruct
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Export formats
+const (
+	// FormatC is a C header following the definitions as written, with
+	// offsets in comments
+	FormatC = "c"
+
+	// FormatGhidra is C for Ghidra's C parser with explicit padding, so
+	// the imported data types have the ABI's offsets
+	FormatGhidra = "ghidra"
+
+	// FormatCtypes is Python ctypes classes with explicit padding
+	FormatCtypes = "ctypes"
+)
+
+// ExportFormats lists the export formats
+var ExportFormats = []string{FormatC, FormatGhidra, FormatCtypes}
+
+// FormatExtension returns the file extension for an export format
+func FormatExtension(format string) string {
+	if format == FormatCtypes {
+		return ".py"
+	}
+	return ".h"
+}
+
+// standardNames are the predefined typedef names declared by the standard
+// headers the C export includes
+var standardNames = map[string]bool{
+	"bool": true, "wchar_t": true, "size_t": true, "ptrdiff_t": true,
+	"int8_t": true, "uint8_t": true, "int16_t": true, "uint16_t": true,
+	"int32_t": true, "uint32_t": true, "int64_t": true, "uint64_t": true,
+	"intptr_t": true, "uintptr_t": true,
+}
+
+// Export writes the definitions in an export format.
+//
+// Parameters:
+//   - f: The parsed definitions
+//   - abi: The ABI whose layout is written
+//   - format: One of ExportFormats
+//
+// Returns:
+//   - The exported source
+//   - An error if the format is unknown or a record cannot be laid out
+func Export(f *File, abi ABI, format string) (string, error) {
+	layouts, err := f.Layouts(abi)
+	if err != nil {
+		return "", err
+	}
+	layouts = dependencyOrder(layouts)
+	switch format {
+	case FormatC:
+		return exportC(f, abi, layouts), nil
+	case FormatGhidra:
+		return exportGhidra(f, abi, layouts), nil
+	case FormatCtypes:
+		return exportCtypes(f, abi, layouts), nil
+	}
+	return "", fmt.Errorf("unknown export format %q (supported: %s)", format, strings.Join(ExportFormats, ", "))
+}
+
+// dependencyOrder orders layouts so every record comes after the named
+// records it holds by value
+func dependencyOrder(layouts []*Layout) []*Layout {
+	var ordered []*Layout
+	seen := make(map[*Record]bool)
+	var visit func(l *Layout)
+	visit = func(l *Layout) {
+		named := l.Record.Name() != ""
+		if named {
+			if seen[l.Record] {
+				return
+			}
+			seen[l.Record] = true
+		}
+		for _, field := range l.Fields {
+			if field.Record != nil {
+				visit(field.Record)
+			}
+		}
+		if named {
+			ordered = append(ordered, l)
+		}
+	}
+	for _, l := range layouts {
+		visit(l)
+	}
+	return ordered
+}
+
+// isAnonymous reports whether a member's record type has no name, so it is
+// written inline
+func isAnonymous(field FieldLayout) bool {
+	return field.Record != nil && field.Record.Record.Name() == ""
+}
+
+// writer accumulates lines of generated source, aligning trailing comments
+type writer struct {
+	b     strings.Builder
+	lines [][2]string
+}
+
+// line queues a line with an optional trailing comment
+func (w *writer) line(text, comment string) {
+	w.lines = append(w.lines, [2]string{text, comment})
+}
+
+// flush writes the queued lines, aligning their comments in a column
+func (w *writer) flush(commentFormat string) {
+	width := 0
+	for _, l := range w.lines {
+		if l[1] != "" {
+			width = max(width, len(l[0]))
+		}
+	}
+	for _, l := range w.lines {
+		if l[1] == "" {
+			w.b.WriteString(l[0] + "\n")
+			continue
+		}
+		fmt.Fprintf(&w.b, "%-*s  "+commentFormat+"\n", width, l[0], l[1])
+	}
+	w.lines = w.lines[:0]
+}
+
+// offsetComment describes where a member is, such as "0x0010" or
+// "0x0028:3" for a bitfield starting at bit 3
+func offsetComment(field FieldLayout, base int) string {
+	comment := fmt.Sprintf("0x%04x", base+field.Offset)
+	if field.IsBitfield() {
+		comment += fmt.Sprintf(":%d", field.BitOffset)
+	}
+	return comment
+}
+
+// exportC writes a C header following the definitions as written
+func exportC(f *File, abi ABI, layouts []*Layout) string {
+	w := &writer{}
+	fmt.Fprintf(&w.b, "/* Generated by RevEnGo. Offsets are for the %s ABI: %s. */\n\n", abi.Name, abi.Description)
+	w.b.WriteString("#include <stdbool.h>\n#include <stddef.h>\n#include <stdint.h>\n")
+
+	if len(f.Defines) > 0 {
+		w.b.WriteString("\n")
+		for _, d := range f.Defines {
+			fmt.Fprintf(&w.b, "#define %s %d\n", d.Name, d.Value)
+		}
+	}
+
+	if names := predefinedNames(f); len(names) > 0 {
+		w.b.WriteString("\n")
+		for _, name := range names {
+			if standardNames[name] {
+				continue
+			}
+			underlying := *predefinedTypes[name]
+			fmt.Fprintf(&w.b, "typedef %s;\n", declare(&underlying, name))
+		}
+	}
+
+	var forward []string
+	for _, r := range f.Records {
+		if r.Tag != "" {
+			forward = append(forward, fmt.Sprintf("%s %s;\n", r.keyword(), r.Tag))
+		}
+	}
+	if len(forward) > 0 {
+		w.b.WriteString("\n" + strings.Join(forward, ""))
+	}
+
+	for _, e := range f.Enums {
+		w.b.WriteString("\n" + cEnum(e))
+	}
+	if len(f.Typedefs) > 0 {
+		w.b.WriteString("\n")
+		for _, t := range f.Typedefs {
+			fmt.Fprintf(&w.b, "typedef %s;\n", declare(t.Type, t.Name))
+		}
+	}
+
+	for _, l := range layouts {
+		r := l.Record
+		w.b.WriteString("\n")
+		fmt.Fprintf(&w.b, "/* size 0x%x (%d), align %d */\n", l.Size, l.Size, l.Align)
+		if r.Pack > 0 {
+			fmt.Fprintf(&w.b, "#pragma pack(push, %d)\n", r.Pack)
+		}
+		head := r.keyword()
+		if r.Tag != "" {
+			head += " " + r.Tag
+		}
+		if r.Typedef != "" {
+			head = "typedef " + head
+		}
+		w.line(head+" {", "")
+		cBody(w, l, "    ", 0)
+		w.line("}"+cAttributes(r)+spaced(r.Typedef)+";", "")
+		w.flush("/* %s */")
+		if r.Pack > 0 {
+			w.b.WriteString("#pragma pack(pop)\n")
+		}
+	}
+	return w.b.String()
+}
+
+// cEnum writes an enum definition
+func cEnum(e *EnumType) string {
+	var b strings.Builder
+	head := "enum"
+	if e.Tag != "" {
+		head += " " + e.Tag
+	}
+	if e.Typedef != "" {
+		head = "typedef " + head
+	}
+	if e.Base != nil {
+		head += " : " + e.Base.String()
+	}
+	b.WriteString(head + " {\n")
+	for i, v := range e.Values {
+		sep := ","
+		if i == len(e.Values)-1 {
+			sep = ""
+		}
+		fmt.Fprintf(&b, "    %s = %d%s\n", v.Name, v.Value, sep)
+	}
+	b.WriteString("}" + strings.TrimSpace(" "+e.Typedef) + ";\n")
+	return b.String()
+}
+
+// spaced returns s with a leading space, or "" if s is empty
+func spaced(s string) string {
+	if s == "" {
+		return ""
+	}
+	return " " + s
+}
+
+// plural writes a count with a noun, adding "s" unless the count is 1
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// cAttributes writes the attributes of a record after its closing brace
+func cAttributes(r *Record) string {
+	var attrs []string
+	if r.Packed {
+		attrs = append(attrs, "packed")
+	}
+	if r.Align > 0 {
+		attrs = append(attrs, fmt.Sprintf("aligned(%d)", r.Align))
+	}
+	if len(attrs) == 0 {
+		return ""
+	}
+	return " __attribute__((" + strings.Join(attrs, ", ") + "))"
+}
+
+// cBody writes the members of a record as declared, with padding noted in
+// comments and anonymous records written inline
+func cBody(w *writer, l *Layout, indent string, base int) {
+	index := make(map[*Field]int)
+	for i, field := range l.Fields {
+		if field.Field != nil {
+			index[field.Field] = i
+		}
+	}
+	padding := func(field FieldLayout) {
+		w.line(indent+fmt.Sprintf("/* %s of padding */", plural(field.Size, "byte")), offsetComment(field, base))
+	}
+
+	next := 0
+	for _, f := range l.Record.Fields {
+		i, ok := index[f]
+		if !ok {
+			// Zero-width bitfields take no space but move the next member
+			w.line(indent+declare(f.Type, "")+" : 0;", "")
+			continue
+		}
+		for ; next < i; next++ {
+			padding(l.Fields[next])
+		}
+		next = i + 1
+
+		field := l.Fields[i]
+		if isAnonymous(field) {
+			r := field.Record.Record
+			w.line(indent+r.keyword()+" {", offsetComment(field, base))
+			cBody(w, field.Record, indent+"    ", base+field.Offset)
+			w.line(indent+"}"+cAttributes(r)+spaced(declarator(f.Type, f.Name))+";", "")
+			continue
+		}
+		decl := declare(f.Type, f.Name)
+		if f.IsBitfield() {
+			decl += fmt.Sprintf(" : %d", f.Bits)
+		}
+		w.line(indent+decl+";", offsetComment(field, base))
+	}
+	for ; next < len(l.Fields); next++ {
+		padding(l.Fields[next])
+	}
+}
+
+// declarator returns the declarator part of a declaration of an anonymous
+// record type, such as "pos[2]", for writing after the record's body
+func declarator(t *Type, name string) string {
+	return strings.TrimSpace(declareWith(t, name, func(*Type) string { return "" }))
+}
+
+// predefinedNames returns the predefined typedef names the definitions
+// use and do not redefine, in an order where names come after those their
+// definitions use
+func predefinedNames(f *File) []string {
+	redefined := make(map[string]bool)
+	for _, t := range f.Typedefs {
+		redefined[t.Name] = true
+	}
+	used := make(map[string]bool)
+	var walk func(t *Type)
+	walk = func(t *Type) {
+		for ; t != nil; t = t.Elem {
+			if t.Name != "" && isPredefined(t) && !redefined[t.Name] && !used[t.Name] {
+				used[t.Name] = true
+				walk(predefinedTypes[t.Name].Elem)
+			}
+		}
+	}
+	var walkRecord func(r *Record)
+	walkRecord = func(r *Record) {
+		for _, field := range r.Fields {
+			walk(field.Type)
+			if inner := field.Type.resolve(); inner.Record != nil && inner.Record.Name() == "" {
+				walkRecord(inner.Record)
+			}
+		}
+	}
+	for _, r := range f.Records {
+		walkRecord(r)
+	}
+	for _, t := range f.Typedefs {
+		walk(t.Type)
+	}
+	for _, e := range f.Enums {
+		if e.Base != nil {
+			walk(e.Base)
+		}
+	}
+
+	names := make([]string, 0, len(used))
+	for name := range used {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		pi, pj := predefinedTypes[names[i]].Elem != nil, predefinedTypes[names[j]].Elem != nil
+		if pi != pj {
+			return pj
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// explicitMember is a member of a record whose padding is written out:
+// an ordinary member, a padding gap, or bitfields sharing a storage unit
+type explicitMember struct {
+	offset int
+	size   int
+
+	// field is an ordinary member
+	field *FieldLayout
+
+	// bits are bitfields stored together, with BitOffset counted from the
+	// start of the member
+	bits []FieldLayout
+
+	// unit is the integer size holding bits; 0 if they span a number of
+	// bytes no integer type has and are written as a byte array
+	unit int
+
+	padding bool
+}
+
+// explicitMembers lists the members of a layout with its padding and
+// bitfield storage made explicit, so packed output reproduces the offsets
+func explicitMembers(l *Layout) []explicitMember {
+	var solid []explicitMember
+	var fields []FieldLayout
+	for _, field := range l.Fields {
+		if !field.Padding {
+			fields = append(fields, field)
+		}
+	}
+
+	if l.Union {
+		end := 0
+		for i := range fields {
+			field := fields[i]
+			if field.IsBitfield() {
+				solid = append(solid, explicitMember{size: field.Size, bits: []FieldLayout{field}, unit: field.Size})
+			} else {
+				solid = append(solid, explicitMember{size: field.Size, field: &fields[i]})
+			}
+			end = max(end, field.Size)
+		}
+		if l.Size > end {
+			solid = append(solid, explicitMember{size: l.Size, padding: true})
+		}
+		return solid
+	}
+
+	for i := 0; i < len(fields); {
+		if !fields[i].IsBitfield() {
+			solid = append(solid, explicitMember{offset: fields[i].Offset, size: fields[i].Size, field: &fields[i]})
+			i++
+			continue
+		}
+		// Bitfields share a storage unit while their bits touch the same
+		// or adjacent bytes; a zero-width bitfield leaves a gap
+		j := i + 1
+		lo := fields[i].Offset*8 + fields[i].BitOffset
+		hi := lo + fields[i].BitSize
+		for ; j < len(fields) && fields[j].IsBitfield(); j++ {
+			start := fields[j].Offset*8 + fields[j].BitOffset
+			if start/8 > (hi+7)/8 {
+				break
+			}
+			hi = max(hi, start+fields[j].BitSize)
+		}
+		limit := l.Size
+		if j < len(fields) {
+			limit = fields[j].Offset + fields[j].BitOffset/8
+		}
+
+		m := explicitMember{offset: lo / 8, size: (hi+7)/8 - lo/8}
+		for _, unit := range []int{1, 2, 4, 8} {
+			if unit >= m.size && m.offset+unit <= limit {
+				m.unit, m.size = unit, unit
+				break
+			}
+		}
+		for _, field := range fields[i:j] {
+			field.BitOffset = field.Offset*8 + field.BitOffset - m.offset*8
+			m.bits = append(m.bits, field)
+		}
+		solid = append(solid, m)
+		i = j
+	}
+
+	var members []explicitMember
+	end := 0
+	for _, m := range solid {
+		if m.offset > end {
+			members = append(members, explicitMember{offset: end, size: m.offset - end, padding: true})
+		}
+		members = append(members, m)
+		end = max(end, m.offset+m.size)
+	}
+	if l.Size > end {
+		members = append(members, explicitMember{offset: end, size: l.Size - end, padding: true})
+	}
+	return members
+}
+
+// bitSlot is a bitfield or an unnamed filler in a storage unit
+type bitSlot struct {
+	field *FieldLayout
+	width int
+}
+
+// slots lists the bitfields of a member with fillers for the unused bits,
+// so the bits fill the whole storage unit
+func (m explicitMember) slots() []bitSlot {
+	var slots []bitSlot
+	cursor := 0
+	for i := range m.bits {
+		field := &m.bits[i]
+		if field.BitOffset > cursor {
+			slots = append(slots, bitSlot{width: field.BitOffset - cursor})
+		}
+		slots = append(slots, bitSlot{field: field, width: field.BitSize})
+		cursor = field.BitOffset + field.BitSize
+	}
+	if m.unit*8 > cursor {
+		slots = append(slots, bitSlot{width: m.unit*8 - cursor})
+	}
+	return slots
+}
+
+// describeBits lists the bitfields of a member for a comment
+func (m explicitMember) describeBits() string {
+	var parts []string
+	for _, field := range m.bits {
+		parts = append(parts, fmt.Sprintf("%s : %d at bit %d", field.Label(), field.BitSize, field.BitOffset))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sizedInt spells an integer type of a size with C keywords whose sizes
+// Ghidra agrees on for every data organization
+func sizedInt(size int, unsigned bool) string {
+	names := map[int]string{1: "char", 2: "short", 4: "int", 8: "long long"}
+	name := names[size]
+	if unsigned {
+		return "unsigned " + name
+	}
+	if size == 1 {
+		return "signed char"
+	}
+	return name
+}
+
+// ghidraWriter writes C for Ghidra's C parser
+type ghidraWriter struct {
+	writer
+	l *layouter
+}
+
+// exportGhidra writes C for Ghidra's C parser. Everything is packed and
+// padding is explicit, so the imported types keep the ABI's offsets
+// whatever data organization the program in Ghidra uses.
+func exportGhidra(f *File, abi ABI, layouts []*Layout) string {
+	g := &ghidraWriter{l: newLayouter(abi)}
+
+	// Write the records first to learn which typedef names they use
+	for _, l := range layouts {
+		r := l.Record
+		g.line("", "")
+		g.line(fmt.Sprintf("/* size 0x%x (%d), align %d */", l.Size, l.Size, l.Align), "")
+		g.line(r.keyword()+" "+ghidraTag(r)+" {", "")
+		g.body(l, "    ", 0)
+		g.line("};", "")
+	}
+	g.flush("/* %s */")
+	records := g.b.String()
+	g.b.Reset()
+
+	fmt.Fprintf(&g.b, "/* Generated by RevEnGo for Ghidra's C parser (Data Type Manager > Parse C Source).\n")
+	fmt.Fprintf(&g.b, " * Layout of the %s ABI: %s. Structures are packed with explicit\n", abi.Name, abi.Description)
+	fmt.Fprintf(&g.b, " * padding, so the offsets hold in any program; pointers take %d bytes. */\n\n", abi.PointerSize)
+	g.b.WriteString("#pragma pack(push, 1)\n")
+
+	var typedefs []string
+	for _, name := range predefinedNames(f) {
+		underlying := *predefinedTypes[name]
+		typedefs = append(typedefs, fmt.Sprintf("typedef %s;\n", declareWith(&underlying, name, g.spell)))
+	}
+	if len(typedefs) > 0 {
+		g.b.WriteString("\n" + strings.Join(typedefs, ""))
+	}
+
+	if len(layouts) > 0 {
+		g.b.WriteString("\n")
+		for _, l := range layouts {
+			r := l.Record
+			fmt.Fprintf(&g.b, "%s %s;\n", r.keyword(), ghidraTag(r))
+			if r.Typedef != "" {
+				fmt.Fprintf(&g.b, "typedef %s %s %s;\n", r.keyword(), ghidraTag(r), r.Typedef)
+			}
+		}
+	}
+
+	for _, e := range f.Enums {
+		size, _, _, _ := g.l.sizeAlign(&Type{Kind: Enum, Enum: e}, 0)
+		if size != 4 {
+			// Ghidra's parser makes every enum 4 bytes; members of other
+			// sizes are written as integers
+			if e.Typedef != "" {
+				fmt.Fprintf(&g.b, "\ntypedef %s %s;\n", sizedInt(size, true), e.Typedef)
+			}
+			continue
+		}
+		g.b.WriteString("\n" + cEnum(&EnumType{Tag: e.Tag, Typedef: e.Typedef, Values: e.Values}))
+	}
+	if len(f.Typedefs) > 0 {
+		g.b.WriteString("\n")
+		for _, t := range f.Typedefs {
+			fmt.Fprintf(&g.b, "typedef %s;\n", declareWith(t.Type, t.Name, g.spell))
+		}
+	}
+
+	g.b.WriteString(records)
+	g.b.WriteString("\n#pragma pack(pop)\n")
+	return g.b.String()
+}
+
+// ghidraTag returns the tag a record is written with; records known only
+// by a typedef name use that name as their tag
+func ghidraTag(r *Record) string {
+	if r.Tag != "" {
+		return r.Tag
+	}
+	return r.Typedef
+}
+
+// spell names a type for Ghidra, replacing types whose size depends on
+// the data organization with integers of the ABI's size
+func (g *ghidraWriter) spell(t *Type) string {
+	if t.Name != "" {
+		return t.Name
+	}
+	switch t.Kind {
+	case Struct, Union:
+		return t.Record.keyword() + " " + ghidraTag(t.Record)
+	case Void, Bool, Float, Double:
+		return basicName(t)
+	case Char:
+		if t.Unsigned {
+			return "unsigned char"
+		}
+		if t.Signed {
+			return "signed char"
+		}
+		return "char"
+	case Enum:
+		size, _, _, _ := g.l.sizeAlign(t, 0)
+		switch {
+		case size == 4 && t.Enum.Tag != "":
+			return "enum " + t.Enum.Tag
+		case size == 4 && t.Enum.Typedef != "":
+			return t.Enum.Typedef
+		}
+		return sizedInt(size, true)
+	}
+	size, _, _, _ := g.l.sizeAlign(t, 0)
+	return sizedInt(size, t.Unsigned)
+}
+
+// body writes the members of a record with explicit padding
+func (g *ghidraWriter) body(l *Layout, indent string, base int) {
+	for _, m := range explicitMembers(l) {
+		offset := fmt.Sprintf("0x%04x", base+m.offset)
+		switch {
+		case m.padding:
+			g.line(fmt.Sprintf("%sunsigned char _padding_%x[%d];", indent, base+m.offset, m.size), offset)
+		case m.bits != nil && m.unit == 0:
+			g.line(fmt.Sprintf("%sunsigned char _bits_%x[%d];", indent, base+m.offset, m.size), offset+" "+m.describeBits())
+		case m.bits != nil:
+			unit := sizedInt(m.unit, true)
+			for _, slot := range m.slots() {
+				if slot.field == nil {
+					g.line(fmt.Sprintf("%s%s : %d;", indent, unit, slot.width), "")
+					continue
+				}
+				g.line(fmt.Sprintf("%s%s %s : %d;", indent, unit, slot.field.Name, slot.width),
+					fmt.Sprintf("%s:%d %s", offset, slot.field.BitOffset, slot.field.Type))
+			}
+		case isAnonymous(*m.field):
+			field := m.field
+			g.line(indent+field.Record.Record.keyword()+" {", offset)
+			g.body(field.Record, indent+"    ", base+field.Offset)
+			g.line(indent+"}"+spaced(declarator(field.Field.Type, field.Name))+";", "")
+		case m.field.Elem.Kind == LongDouble && m.field.Elem.Name == "":
+			g.line(fmt.Sprintf("%sunsigned char %s[%d];", indent, m.field.Name, m.size), offset+" "+m.field.Type)
+		default:
+			g.line(indent+declareWith(m.field.Field.Type, m.field.Name, g.spell)+";", offset)
+		}
+	}
+}
+
+// pyWriter writes Python ctypes classes
+type pyWriter struct {
+	writer
+	abi     ABI
+	l       *layouter
+	classes map[*Record]string
+}
+
+// exportCtypes writes Python ctypes classes. Every class is packed with
+// explicit padding and pointers are integers of the target's size, so the
+// classes parse target memory on any host.
+func exportCtypes(f *File, abi ABI, layouts []*Layout) string {
+	p := &pyWriter{abi: abi, l: newLayouter(abi), classes: make(map[*Record]string)}
+	fmt.Fprintf(&p.b, "# Generated by RevEnGo for the %s ABI: %s.\n", abi.Name, abi.Description)
+	p.b.WriteString("# Structures are packed with explicit padding and pointers are plain\n")
+	fmt.Fprintf(&p.b, "# %d-byte integers, so the classes parse target memory on any host.\n\n", abi.PointerSize)
+	p.b.WriteString("import ctypes\nimport enum\n")
+
+	for _, e := range f.Enums {
+		if e.Name() == "" {
+			continue
+		}
+		fmt.Fprintf(&p.b, "\n\nclass %s(enum.IntEnum):\n", e.Name())
+		if len(e.Values) == 0 {
+			p.b.WriteString("    pass\n")
+		}
+		for _, v := range e.Values {
+			fmt.Fprintf(&p.b, "    %s = %d\n", v.Name, v.Value)
+		}
+	}
+	var constants []string
+	for _, e := range f.Enums {
+		if e.Name() == "" {
+			for _, v := range e.Values {
+				constants = append(constants, fmt.Sprintf("%s = %d\n", v.Name, v.Value))
+			}
+		}
+	}
+	for _, d := range f.Defines {
+		constants = append(constants, fmt.Sprintf("%s = %d\n", d.Name, d.Value))
+	}
+	if len(constants) > 0 {
+		p.b.WriteString("\n" + strings.Join(constants, ""))
+	}
+
+	for _, l := range layouts {
+		p.class(l, l.Record.Name())
+	}
+
+	var aliases []string
+	for _, l := range layouts {
+		if r := l.Record; r.Tag != "" && r.Typedef != "" && r.Tag != r.Typedef {
+			aliases = append(aliases, fmt.Sprintf("%s = %s\n", r.Tag, r.Typedef))
+		}
+	}
+	for _, t := range f.Typedefs {
+		if t.Type.resolve().Kind != Func {
+			aliases = append(aliases, fmt.Sprintf("%s = %s\n", t.Name, p.ctype(t.Type)))
+		}
+	}
+	if len(aliases) > 0 {
+		p.b.WriteString("\n\n" + strings.Join(aliases, ""))
+	}
+	return p.b.String()
+}
+
+// class writes the class of a record, after the classes of its anonymous
+// members
+func (p *pyWriter) class(l *Layout, name string) {
+	p.classes[l.Record] = name
+	members := explicitMembers(l)
+	var anonymous []string
+	for _, m := range members {
+		if m.field == nil || !isAnonymous(*m.field) {
+			continue
+		}
+		inner := fmt.Sprintf("%s_%s", name, m.field.Name)
+		if m.field.Name == "" {
+			inner = fmt.Sprintf("%s_anon%d", name, len(anonymous))
+			anonymous = append(anonymous, fmt.Sprintf("%q", fmt.Sprintf("_anon%d", len(anonymous))))
+		}
+		p.class(m.field.Record, inner)
+	}
+
+	base := "ctypes.LittleEndianStructure"
+	if p.abi.BigEndian {
+		base = "ctypes.BigEndianStructure"
+	}
+	if l.Union {
+		base = "ctypes.Union"
+	}
+	fmt.Fprintf(&p.b, "\n\nclass %s(%s):\n", name, base)
+	p.b.WriteString("    _pack_ = 1\n    _layout_ = \"ms\"\n")
+	if len(anonymous) > 0 {
+		fmt.Fprintf(&p.b, "    _anonymous_ = (%s,)\n", strings.Join(anonymous, ", "))
+	}
+	if len(members) == 0 {
+		p.b.WriteString("    _fields_ = []\n")
+		return
+	}
+	p.line("    _fields_ = [", "")
+	anon := 0
+	for _, m := range members {
+		offset := fmt.Sprintf("0x%04x", m.offset)
+		switch {
+		case m.padding:
+			p.line(fmt.Sprintf("        (\"_padding_%x\", ctypes.c_uint8 * %d),", m.offset, m.size), offset)
+		case m.bits != nil && m.unit == 0:
+			p.line(fmt.Sprintf("        (\"_bits_%x\", ctypes.c_uint8 * %d),", m.offset, m.size), offset+" "+m.describeBits())
+		case m.bits != nil:
+			unit := p.intType(m.unit, true)
+			for i, slot := range m.slots() {
+				if slot.field == nil {
+					p.line(fmt.Sprintf("        (\"_bits_%x_%d\", %s, %d),", m.offset, i, unit, slot.width), "")
+					continue
+				}
+				p.line(fmt.Sprintf("        (%q, %s, %d),", slot.field.Name, unit, slot.width),
+					fmt.Sprintf("%s:%d %s", offset, slot.field.BitOffset, slot.field.Type))
+			}
+		case isAnonymous(*m.field) && m.field.Name == "":
+			p.line(fmt.Sprintf("        (\"_anon%d\", %s),", anon, p.classes[m.field.Record.Record]), offset)
+			anon++
+		default:
+			p.line(fmt.Sprintf("        (%q, %s),", m.field.Name, p.ctype(m.field.Field.Type)), offset+" "+m.field.Type)
+		}
+	}
+	p.line("    ]", "")
+	p.flush("# %s")
+}
+
+// ctype returns the ctypes expression for a type
+func (p *pyWriter) ctype(t *Type) string {
+	switch t.Kind {
+	case Array:
+		return fmt.Sprintf("%s * %d", p.ctype(t.Elem), max(t.Len, 0))
+	case Struct, Union:
+		if name, ok := p.classes[t.Record]; ok {
+			return name
+		}
+		return t.Record.Name()
+	case Bool:
+		return "ctypes.c_bool"
+	case Float:
+		return "ctypes.c_float"
+	case Double:
+		return "ctypes.c_double"
+	case LongDouble:
+		return fmt.Sprintf("(ctypes.c_uint8 * %d)", p.abi.LongDoubleSize)
+	case Char:
+		switch {
+		case t.Unsigned:
+			return "ctypes.c_ubyte"
+		case t.Signed:
+			return "ctypes.c_byte"
+		}
+		return "ctypes.c_char"
+	case Enum:
+		if t.Enum.Base != nil {
+			return p.ctype(t.Enum.Base)
+		}
+	case Void, Func:
+		return "None"
+	}
+	size, _, _, _ := p.l.sizeAlign(t, 0)
+	return p.intType(size, t.Unsigned || t.Kind == Pointer)
+}
+
+// intType returns the ctypes integer type of a size
+func (p *pyWriter) intType(size int, unsigned bool) string {
+	if unsigned {
+		return fmt.Sprintf("ctypes.c_uint%d", size*8)
+	}
+	return fmt.Sprintf("ctypes.c_int%d", size*8)
+}
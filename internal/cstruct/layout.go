@@ -0,0 +1,458 @@
+package cstruct
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// Layout is the memory layout of a struct or union on an ABI
+type Layout struct {
+	// Name is the record's name; empty for anonymous records
+	Name string
+
+	Union bool
+	Size  int
+	Align int
+
+	// Fields are the members and the padding between them, in offset order
+	Fields []FieldLayout
+
+	// Record is the definition the layout was computed from
+	Record *Record
+}
+
+// FieldLayout places a member of a struct or union, or a padding gap
+type FieldLayout struct {
+	// Name is the member name; empty for padding, unnamed bitfields and
+	// anonymous struct and union members
+	Name string
+
+	// Type is the member's type as written in C, such as "uint8_t[16]"
+	Type string
+
+	// Offset is the byte offset from the start of the enclosing record.
+	// For bitfields it is the offset of the storage unit holding the bits.
+	Offset int
+
+	// Size is the size in bytes; for bitfields, the size of the declared type
+	Size int
+
+	Align int
+
+	// BitOffset and BitSize place a bitfield within its storage unit,
+	// counting from the least significant bit; BitSize is 0 for other members
+	BitOffset int
+	BitSize   int
+
+	// Padding reports whether the entry is a gap inserted for alignment
+	Padding bool
+
+	// Elem is the type of the member, or of its elements for arrays; nil
+	// for padding
+	Elem *Type
+
+	// ElemSize is the size of Elem
+	ElemSize int
+
+	// Dims are the array dimensions, outermost first; nil for scalars
+	Dims []int
+
+	// Record is the layout of a struct or union member, or of the
+	// elements of an array of structs or unions
+	Record *Layout
+
+	// Line is the line the member is declared on
+	Line int
+
+	// Field is the member's definition; nil for padding
+	Field *Field
+}
+
+// IsBitfield reports whether the entry is a bitfield
+func (f *FieldLayout) IsBitfield() bool {
+	return f.BitSize > 0
+}
+
+// Count returns the number of elements of an array member, 1 for scalars
+func (f *FieldLayout) Count() int {
+	n := 1
+	for _, d := range f.Dims {
+		n *= max(d, 0)
+	}
+	return n
+}
+
+// Label names the entry for display: its name, or a description of
+// padding and anonymous members
+func (f *FieldLayout) Label() string {
+	switch {
+	case f.Padding:
+		return "(padding)"
+	case f.Name != "":
+		return f.Name
+	case f.Record != nil && f.Record.Union:
+		return "(anonymous union)"
+	case f.Record != nil:
+		return "(anonymous struct)"
+	}
+	return "(unnamed)"
+}
+
+// Layout computes the layout of a struct or union.
+//
+// Parameters:
+//   - name: The typedef name or tag of the record
+//   - abi: The target ABI
+//
+// Returns:
+//   - The layout
+//   - An *Error if the record is unknown or cannot be laid out
+func (f *File) Layout(name string, abi ABI) (*Layout, error) {
+	r, ok := f.Record(name)
+	if !ok {
+		return nil, &Error{Msg: fmt.Sprintf("no struct or union named %q", name)}
+	}
+	return newLayouter(abi).record(r, r.Line)
+}
+
+// Layouts computes the layouts of every defined struct and union in
+// definition order.
+//
+// Parameters:
+//   - abi: The target ABI
+//
+// Returns:
+//   - The layouts
+//   - An *Error for the first record that cannot be laid out
+func (f *File) Layouts(abi ABI) ([]*Layout, error) {
+	l := newLayouter(abi)
+	var layouts []*Layout
+	for _, r := range f.Records {
+		if !r.Defined {
+			continue
+		}
+		layout, err := l.record(r, r.Line)
+		if err != nil {
+			return nil, err
+		}
+		layouts = append(layouts, layout)
+	}
+	return layouts, nil
+}
+
+// layouter computes layouts, remembering those already computed
+type layouter struct {
+	abi    ABI
+	done   map[*Record]*Layout
+	active map[*Record]bool
+}
+
+func newLayouter(abi ABI) *layouter {
+	return &layouter{abi: abi, done: make(map[*Record]*Layout), active: make(map[*Record]bool)}
+}
+
+// describeRecord names a record for error messages
+func describeRecord(r *Record) string {
+	if r.Name() == "" {
+		return "anonymous " + r.keyword()
+	}
+	return r.keyword() + " " + r.Name()
+}
+
+// sizeAlign returns the size and natural alignment of a type, and the
+// layout of the record it is or holds
+func (l *layouter) sizeAlign(t *Type, line int) (int, int, *Layout, error) {
+	abi := l.abi
+	switch t.Kind {
+	case Void:
+		return 0, 0, nil, errorf(line, "void cannot be a member type")
+	case Bool, Char, Int8:
+		return 1, 1, nil, nil
+	case Short, Int16:
+		return 2, 2, nil, nil
+	case Int, Int32, Float:
+		return 4, 4, nil, nil
+	case Long:
+		return abi.LongSize, l.int64Align(abi.LongSize), nil, nil
+	case LongLong, Int64, Double:
+		return 8, abi.Int64Align, nil, nil
+	case IntPtr, Pointer:
+		return abi.PointerSize, abi.PointerSize, nil, nil
+	case WChar:
+		return abi.WCharSize, abi.WCharSize, nil, nil
+	case LongDouble:
+		return abi.LongDoubleSize, abi.LongDoubleAlign, nil, nil
+	case Enum:
+		if t.Enum.Base != nil {
+			return l.sizeAlign(t.Enum.Base, line)
+		}
+		return 4, 4, nil, nil
+	case Array:
+		size, align, layout, err := l.sizeAlign(t.Elem, line)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		return size * max(t.Len, 0), align, layout, nil
+	case Struct, Union:
+		layout, err := l.record(t.Record, line)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		return layout.Size, layout.Align, layout, nil
+	case Func:
+		return 0, 0, nil, errorf(line, "a function cannot be a member; use a function pointer")
+	}
+	return 0, 0, nil, errorf(line, "unsupported type %s", t)
+}
+
+// int64Align returns the alignment of an integer of a size, following the
+// ABI's alignment of 8-byte values
+func (l *layouter) int64Align(size int) int {
+	if size == 8 {
+		return l.abi.Int64Align
+	}
+	return size
+}
+
+// record computes the layout of a struct or union
+func (l *layouter) record(r *Record, line int) (*Layout, error) {
+	if layout, ok := l.done[r]; ok {
+		return layout, nil
+	}
+	if !r.Defined {
+		return nil, errorf(line, "%s is declared but not defined", describeRecord(r))
+	}
+	if l.active[r] {
+		return nil, errorf(line, "%s contains itself", describeRecord(r))
+	}
+	l.active[r] = true
+	defer delete(l.active, r)
+
+	// effective caps an alignment by the record's packing
+	effective := func(align int) int {
+		if r.Packed {
+			return 1
+		}
+		if r.Pack > 0 && align > r.Pack {
+			return r.Pack
+		}
+		return align
+	}
+
+	layout := &Layout{Name: r.Name(), Union: r.Union, Align: 1, Record: r}
+	var fields []FieldLayout
+	bit := 0 // next free bit of a struct
+	size := 0
+
+	// The storage unit of the Microsoft bitfield run in progress
+	unitOffset, unitSize, unitUsed := 0, 0, -1
+
+	for i, f := range r.Fields {
+		fieldSize, natural, nested, err := l.sizeAlign(f.Type, f.Line)
+		if err != nil {
+			return nil, err
+		}
+		if f.Type.Kind == Array && f.Type.Len < 0 && (r.Union || i != len(r.Fields)-1) {
+			return nil, errorf(f.Line, "flexible array member %q must be the last member of a struct", f.Name)
+		}
+		align := effective(natural)
+		elem := f.Type.resolve()
+		elemSize, _, _, _ := l.sizeAlign(elem, f.Line)
+		entry := FieldLayout{
+			Name:     f.Name,
+			Type:     declare(f.Type, ""),
+			Size:     fieldSize,
+			Align:    align,
+			Elem:     elem,
+			ElemSize: elemSize,
+			Record:   nested,
+			Line:     f.Line,
+			Field:    f,
+		}
+		for t := f.Type; t.Kind == Array; t = t.Elem {
+			entry.Dims = append(entry.Dims, t.Len)
+		}
+
+		if f.IsBitfield() {
+			if !f.Type.isInteger() {
+				return nil, errorf(f.Line, "bitfield %q must have an integer type", f.Name)
+			}
+			if f.Bits > fieldSize*8 {
+				return nil, errorf(f.Line, "bitfield %q is wider than its type", f.Name)
+			}
+		}
+
+		switch {
+		case r.Union:
+			if f.IsBitfield() && f.Bits == 0 {
+				continue
+			}
+			entry.BitSize = max(f.Bits, 0)
+			size = max(size, fieldSize)
+
+		case f.IsBitfield() && l.abi.MSBitfields:
+			if f.Bits == 0 {
+				// A zero-width bitfield ends the run in progress
+				if unitUsed >= 0 {
+					bit = (unitOffset + unitSize) * 8
+					unitUsed = -1
+				}
+				continue
+			}
+			if unitUsed < 0 || fieldSize != unitSize || unitUsed+f.Bits > unitSize*8 {
+				if unitUsed >= 0 {
+					bit = (unitOffset + unitSize) * 8
+				}
+				unitOffset = roundUp((bit+7)/8, align)
+				unitSize, unitUsed = fieldSize, 0
+			}
+			entry.Offset, entry.BitOffset, entry.BitSize = unitOffset, unitUsed, f.Bits
+			unitUsed += f.Bits
+			bit = (unitOffset + unitSize) * 8
+
+		case f.IsBitfield():
+			if f.Bits == 0 {
+				bit = roundUp(bit, natural*8)
+				continue
+			}
+			// A bitfield must fit in a storage unit of its type starting at
+			// an aligned offset, unless the record is packed
+			if !r.Packed && (align > 1 || natural == 1) {
+				unitStart := bit / (align * 8) * (align * 8)
+				if bit-unitStart+f.Bits > fieldSize*8 {
+					bit = roundUp(bit, align*8)
+				}
+			}
+			entry.Offset = bit / 8 / align * align
+			entry.BitOffset, entry.BitSize = bit-entry.Offset*8, f.Bits
+			bit += f.Bits
+
+		default:
+			if unitUsed >= 0 {
+				bit = (unitOffset + unitSize) * 8
+				unitUsed = -1
+			}
+			entry.Offset = roundUp((bit+7)/8, align)
+			bit = (entry.Offset + fieldSize) * 8
+		}
+
+		// Unnamed bitfields do not affect the alignment of System V records
+		if f.Name != "" || l.abi.MSBitfields || !f.IsBitfield() {
+			layout.Align = max(layout.Align, align)
+		}
+		fields = append(fields, entry)
+	}
+
+	if !r.Union {
+		size = (bit + 7) / 8
+	}
+	layout.Align = max(layout.Align, r.Align)
+	layout.Size = roundUp(size, layout.Align)
+	layout.Fields = addPadding(fields, layout.Size, r.Union)
+	l.done[r] = layout
+	return layout, nil
+}
+
+// addPadding inserts padding entries for the bytes of a record that no
+// member covers
+func addPadding(fields []FieldLayout, size int, union bool) []FieldLayout {
+	out := make([]FieldLayout, 0, len(fields))
+	end := 0
+	for _, f := range fields {
+		start, stop := f.Offset, f.Offset+f.Size
+		if f.IsBitfield() {
+			start = f.Offset + f.BitOffset/8
+			stop = f.Offset + (f.BitOffset+f.BitSize+7)/8
+		}
+		if !union && start > end {
+			out = append(out, FieldLayout{Offset: end, Size: start - end, Align: 1, Padding: true})
+		}
+		out = append(out, f)
+		end = max(end, stop)
+	}
+	if size > end {
+		out = append(out, FieldLayout{Offset: end, Size: size - end, Align: 1, Padding: true})
+	}
+	return out
+}
+
+// roundUp rounds n up to a multiple of align
+func roundUp(n, align int) int {
+	if align <= 1 {
+		return n
+	}
+	return (n + align - 1) / align * align
+}
+
+// Row is a line of a flattened layout: a member with its offset from the
+// start of the outermost record
+type Row struct {
+	FieldLayout
+
+	// Depth is the nesting depth; members of the outermost record are at 0
+	Depth int
+
+	// Path is the dotted member path, such as "hdr.flags"
+	Path string
+}
+
+// Rows flattens a layout, following nested struct and union members (but
+// not arrays of them) so every member has its absolute offset.
+//
+// Returns:
+//   - The rows in offset order, each nested record's members after it
+func (l *Layout) Rows() []Row {
+	var rows []Row
+	var walk func(layout *Layout, base, depth int, prefix string)
+	walk = func(layout *Layout, base, depth int, prefix string) {
+		for _, f := range layout.Fields {
+			row := Row{FieldLayout: f, Depth: depth, Path: prefix + f.Name}
+			row.Offset += base
+			rows = append(rows, row)
+			if f.Record != nil && f.Dims == nil {
+				next := prefix
+				if f.Name != "" {
+					next = prefix + f.Name + "."
+				}
+				walk(f.Record, base+f.Offset, depth+1, next)
+			}
+		}
+	}
+	walk(l, 0, 0, "")
+	return rows
+}
+
+// Format writes a layout as a table of offsets, sizes, types and names.
+//
+// Returns:
+//   - The table, starting with a line giving the record's size and alignment
+func (l *Layout) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s: size %d (0x%x), align %d\n", l.Record.keyword(), l.displayName(), l.Size, l.Size, l.Align)
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "OFFSET\tSIZE\tTYPE\tNAME")
+	for _, row := range l.Rows() {
+		offset := fmt.Sprintf("0x%04x", row.Offset)
+		name := strings.Repeat("  ", row.Depth) + row.Label()
+		if row.IsBitfield() {
+			offset += fmt.Sprintf(":%d", row.BitOffset)
+			name += fmt.Sprintf(" : %d", row.BitSize)
+		}
+		typ := row.Type
+		if row.Padding {
+			typ = "-"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", offset, row.Size, typ, name)
+	}
+	w.Flush()
+	return b.String()
+}
+
+// displayName names the layout's record for headings
+func (l *Layout) displayName() string {
+	if l.Name == "" {
+		return "(anonymous)"
+	}
+	return l.Name
+}
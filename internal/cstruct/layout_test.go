@@ -0,0 +1,279 @@
+package cstruct
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// shape summarizes a layout as its size, alignment and fields, each
+// written "name@offset+size" with ".bit:bits" for bitfields and "pad" for
+// padding
+func shape(l *Layout) string {
+	fields := make([]string, len(l.Fields))
+	for i, f := range l.Fields {
+		name := f.Label()
+		if f.Padding {
+			name = "pad"
+		}
+		fields[i] = fmt.Sprintf("%s@%d+%d", name, f.Offset, f.Size)
+		if f.IsBitfield() {
+			fields[i] += fmt.Sprintf(".%d:%d", f.BitOffset, f.BitSize)
+		}
+	}
+	return fmt.Sprintf("size %d align %d: %s", l.Size, l.Align, strings.Join(fields, " "))
+}
+
+// everyABI expects the same shape on every ABI
+func everyABI(want string) map[string]string {
+	shapes := make(map[string]string)
+	for _, name := range ABINames() {
+		shapes[name] = want
+	}
+	return shapes
+}
+
+func TestLayout(t *testing.T) {
+	tests := []struct {
+		name string
+
+		// src defines the record s
+		src string
+
+		// shapes are the expected shapes by ABI name
+		shapes map[string]string
+	}{
+		{
+			name:   "padding",
+			src:    "struct s { char c; int i; short v; };",
+			shapes: everyABI("size 12 align 4: c@0+1 pad@1+3 i@4+4 v@8+2 pad@10+2"),
+		},
+		{
+			name: "64-bit integers",
+			src:  "struct s { char c; long long v; };",
+			shapes: map[string]string{
+				ABIX86:     "size 12 align 4: c@0+1 pad@1+3 v@4+8",
+				ABIX86MSVC: "size 16 align 8: c@0+1 pad@1+7 v@8+8",
+				ABIX64:     "size 16 align 8: c@0+1 pad@1+7 v@8+8",
+				ABIX64MSVC: "size 16 align 8: c@0+1 pad@1+7 v@8+8",
+				ABIARM32:   "size 16 align 8: c@0+1 pad@1+7 v@8+8",
+				ABIAArch64: "size 16 align 8: c@0+1 pad@1+7 v@8+8",
+			},
+		},
+		{
+			name: "longs and pointers",
+			src:  "struct s { char c; long l; void *p; };",
+			shapes: map[string]string{
+				ABIX86:     "size 12 align 4: c@0+1 pad@1+3 l@4+4 p@8+4",
+				ABIX86MSVC: "size 12 align 4: c@0+1 pad@1+3 l@4+4 p@8+4",
+				ABIX64:     "size 24 align 8: c@0+1 pad@1+7 l@8+8 p@16+8",
+				ABIX64MSVC: "size 16 align 8: c@0+1 pad@1+3 l@4+4 p@8+8",
+				ABIARM32:   "size 12 align 4: c@0+1 pad@1+3 l@4+4 p@8+4",
+				ABIAArch64: "size 24 align 8: c@0+1 pad@1+7 l@8+8 p@16+8",
+			},
+		},
+		{
+			name: "long double and wchar_t",
+			src:  "struct s { char c; long double d; wchar_t w; };",
+			shapes: map[string]string{
+				ABIX86:     "size 20 align 4: c@0+1 pad@1+3 d@4+12 w@16+4",
+				ABIX86MSVC: "size 24 align 8: c@0+1 pad@1+7 d@8+8 w@16+2 pad@18+6",
+				ABIX64:     "size 48 align 16: c@0+1 pad@1+15 d@16+16 w@32+4 pad@36+12",
+				ABIX64MSVC: "size 24 align 8: c@0+1 pad@1+7 d@8+8 w@16+2 pad@18+6",
+				ABIARM32:   "size 24 align 8: c@0+1 pad@1+7 d@8+8 w@16+4 pad@20+4",
+				ABIAArch64: "size 48 align 16: c@0+1 pad@1+15 d@16+16 w@32+4 pad@36+12",
+			},
+		},
+		{
+			name:   "bitfields of one type",
+			src:    "struct s { unsigned a:3; unsigned b:5; unsigned c:30; };",
+			shapes: everyABI("size 8 align 4: a@0+4.0:3 b@0+4.3:5 pad@1+3 c@4+4.0:30"),
+		},
+		{
+			name: "bitfields of different sizes",
+			src:  "struct s { char a:3; int b:4; };",
+			shapes: map[string]string{
+				ABIX86:     "size 4 align 4: a@0+1.0:3 b@0+4.3:4 pad@1+3",
+				ABIX86MSVC: "size 8 align 4: a@0+1.0:3 pad@1+3 b@4+4.0:4 pad@5+3",
+				ABIX64:     "size 4 align 4: a@0+1.0:3 b@0+4.3:4 pad@1+3",
+				ABIX64MSVC: "size 8 align 4: a@0+1.0:3 pad@1+3 b@4+4.0:4 pad@5+3",
+				ABIARM32:   "size 4 align 4: a@0+1.0:3 b@0+4.3:4 pad@1+3",
+				ABIAArch64: "size 4 align 4: a@0+1.0:3 b@0+4.3:4 pad@1+3",
+			},
+		},
+		{
+			name:   "zero-width bitfield",
+			src:    "struct s { int a:4; int :0; int b:4; };",
+			shapes: everyABI("size 8 align 4: a@0+4.0:4 pad@1+3 b@4+4.0:4 pad@5+3"),
+		},
+		{
+			name: "unnamed bitfield",
+			src:  "struct s { char c; long long :3; };",
+			shapes: map[string]string{
+				ABIX86:     "size 2 align 1: c@0+1 (unnamed)@0+8.8:3",
+				ABIX86MSVC: "size 16 align 8: c@0+1 pad@1+7 (unnamed)@8+8.0:3 pad@9+7",
+				ABIX64:     "size 2 align 1: c@0+1 (unnamed)@0+8.8:3",
+				ABIX64MSVC: "size 16 align 8: c@0+1 pad@1+7 (unnamed)@8+8.0:3 pad@9+7",
+				ABIARM32:   "size 2 align 1: c@0+1 (unnamed)@0+8.8:3",
+				ABIAArch64: "size 2 align 1: c@0+1 (unnamed)@0+8.8:3",
+			},
+		},
+		{
+			name:   "packed",
+			src:    "struct __attribute__((packed)) s { char c; int i; short v; };",
+			shapes: everyABI("size 7 align 1: c@0+1 i@1+4 v@5+2"),
+		},
+		{
+			name: "packed bitfields",
+			src:  "struct __attribute__((packed)) s { unsigned char a:3; unsigned int b:7; };",
+			shapes: map[string]string{
+				ABIX86:     "size 2 align 1: a@0+1.0:3 b@0+4.3:7",
+				ABIX86MSVC: "size 5 align 1: a@0+1.0:3 b@1+4.0:7 pad@2+3",
+				ABIX64:     "size 2 align 1: a@0+1.0:3 b@0+4.3:7",
+				ABIX64MSVC: "size 5 align 1: a@0+1.0:3 b@1+4.0:7 pad@2+3",
+				ABIARM32:   "size 2 align 1: a@0+1.0:3 b@0+4.3:7",
+				ABIAArch64: "size 2 align 1: a@0+1.0:3 b@0+4.3:7",
+			},
+		},
+		{
+			name:   "pragma pack",
+			src:    "#pragma pack(push, 2)\nstruct s { char c; int i; double d; };\n#pragma pack(pop)\n",
+			shapes: everyABI("size 14 align 2: c@0+1 pad@1+1 i@2+4 d@6+8"),
+		},
+		{
+			name:   "after pragma pack pop",
+			src:    "#pragma pack(push, 1)\nstruct t { char c; int i; };\n#pragma pack(pop)\nstruct s { char c; int i; };",
+			shapes: everyABI("size 8 align 4: c@0+1 pad@1+3 i@4+4"),
+		},
+		{
+			name:   "nested arrays",
+			src:    "struct s { char name[3]; short v[2][3]; struct { char a; int b; } p[2]; };",
+			shapes: everyABI("size 32 align 4: name@0+3 pad@3+1 v@4+12 p@16+16"),
+		},
+		{
+			name:   "flexible array member",
+			src:    "struct s { short n; int data[]; };",
+			shapes: everyABI("size 4 align 4: n@0+2 pad@2+2 data@4+0"),
+		},
+		{
+			name: "union",
+			src:  "union s { char c; int i; double d[2]; };",
+			shapes: map[string]string{
+				ABIX86:     "size 16 align 4: c@0+1 i@0+4 d@0+16",
+				ABIX86MSVC: "size 16 align 8: c@0+1 i@0+4 d@0+16",
+				ABIX64:     "size 16 align 8: c@0+1 i@0+4 d@0+16",
+				ABIX64MSVC: "size 16 align 8: c@0+1 i@0+4 d@0+16",
+				ABIARM32:   "size 16 align 8: c@0+1 i@0+4 d@0+16",
+				ABIAArch64: "size 16 align 8: c@0+1 i@0+4 d@0+16",
+			},
+		},
+		{
+			name:   "aligned",
+			src:    "struct __attribute__((aligned(16))) s { char c; int i; };",
+			shapes: everyABI("size 16 align 16: c@0+1 pad@1+3 i@4+4 pad@8+8"),
+		},
+	}
+	for _, tt := range tests {
+		f, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		for _, abi := range ABIs() {
+			want, ok := tt.shapes[abi.Name]
+			if !ok {
+				t.Fatalf("%s: no shape for %s", tt.name, abi.Name)
+			}
+			l, err := f.Layout("s", abi)
+			if err != nil {
+				t.Errorf("%s on %s: %v", tt.name, abi.Name, err)
+				continue
+			}
+			if got := shape(l); got != want {
+				t.Errorf("%s on %s:\n got %s\nwant %s", tt.name, abi.Name, got, want)
+			}
+		}
+	}
+}
+
+func TestLayoutNestedArrays(t *testing.T) {
+	f, err := Parse("struct s { char name[3]; short v[2][3]; struct { char a; int b; } p[2]; };")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := f.Layout("s", ABIs()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := make(map[string]FieldLayout)
+	for _, field := range l.Fields {
+		fields[field.Name] = field
+	}
+	if v := fields["v"]; !slices.Equal(v.Dims, []int{2, 3}) || v.Count() != 6 || v.ElemSize != 2 {
+		t.Errorf("v: dims %v, count %d, element size %d; want [2 3], 6, 2", v.Dims, v.Count(), v.ElemSize)
+	}
+	p := fields["p"]
+	if !slices.Equal(p.Dims, []int{2}) || p.Record == nil || p.Record.Size != 8 || p.ElemSize != 8 {
+		t.Fatalf("p: dims %v, record %v, element size %d; want [2] of 8-byte structs", p.Dims, p.Record, p.ElemSize)
+	}
+	if got, want := shape(p.Record), "size 8 align 4: a@0+1 pad@1+3 b@4+4"; got != want {
+		t.Errorf("p's elements: %s; want %s", got, want)
+	}
+}
+
+func TestRows(t *testing.T) {
+	src := `
+struct inner { char a; int b; };
+struct s {
+	char x;
+	struct inner y;
+	union { short u; char w[3]; };
+	struct inner z[2];
+};`
+	f, err := Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := f.Layout("s", ABIs()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []string
+	for _, row := range l.Rows() {
+		if !row.Padding {
+			rows = append(rows, fmt.Sprintf("%d:%s@%d", row.Depth, row.Path, row.Offset))
+		}
+	}
+	// Members of anonymous records keep the enclosing path, and arrays of
+	// records are not followed
+	want := []string{
+		"0:x@0", "0:y@4", "1:y.a@4", "1:y.b@8",
+		"0:@12", "1:u@12", "1:w@12", "0:z@16",
+	}
+	if !slices.Equal(rows, want) {
+		t.Errorf("rows\n got %v\nwant %v", rows, want)
+	}
+}
+
+func TestLayoutErrors(t *testing.T) {
+	tests := []struct {
+		src, err string
+	}{
+		{"struct s { struct s inner; };", "contains itself"},
+		{"struct t; struct s { struct t inner; };", "declared but not defined"},
+		{"struct s { char data[]; int n; };", "must be the last member"},
+		{"struct s { char a:9; };", "wider than its type"},
+		{"struct s { double d:3; };", "must have an integer type"},
+		{"struct s { void v; };", "void cannot be a member"},
+	}
+	for _, tt := range tests {
+		f, err := Parse(tt.src)
+		if err == nil {
+			_, err = f.Layout("s", ABIs()[0])
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error %v; want one containing %q", tt.src, err, tt.err)
+		}
+	}
+}
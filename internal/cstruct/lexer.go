@@ -0,0 +1,224 @@
+package cstruct
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// tokenKind classifies a token
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokPunct
+	tokString
+
+	// tokPragma is a #pragma line; args holds its tokens
+	tokPragma
+
+	// tokDefine is a #define of an object-like macro; text is the macro
+	// name and args its replacement tokens
+	tokDefine
+)
+
+// token is a lexical token of C source
+type token struct {
+	kind  tokenKind
+	text  string
+	value int64
+	line  int
+	args  []token
+}
+
+// Error is a syntax or layout error in C definitions
+type Error struct {
+	// Line is the line of the definitions the error is on; 0 if unknown
+	Line int
+
+	Msg string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+	}
+	return e.Msg
+}
+
+// errorf returns an *Error for a line
+func errorf(line int, format string, args ...interface{}) *Error {
+	return &Error{Line: line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// punctuators are the multi-character punctuators the lexer recognizes;
+// every other punctuation character is a token of its own
+var punctuators = []string{"...", "<<", ">>", "::"}
+
+// lex splits C source into tokens. Comments are dropped, #pragma and
+// #define lines become single tokens and other preprocessor lines such as
+// #include are ignored.
+func lex(src string) ([]token, error) {
+	var toks []token
+	line := 1
+	lineStart := true
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			lineStart = true
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+			continue
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, errorf(line, "unterminated comment")
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+			continue
+		case c == '#' && lineStart:
+			start, startLine := i, line
+			for i < len(src) && src[i] != '\n' {
+				if src[i] == '\\' && i+1 < len(src) && src[i+1] == '\n' {
+					i++
+					line++
+				}
+				i++
+			}
+			tok, err := lexDirective(src[start+1:i], startLine)
+			if err != nil {
+				return nil, err
+			}
+			if tok != nil {
+				toks = append(toks, *tok)
+			}
+			continue
+		}
+		lineStart = false
+
+		tok, n, err := lexToken(src[i:], line)
+		if err != nil {
+			return nil, err
+		}
+		toks = append(toks, tok)
+		i += n
+	}
+	return append(toks, token{kind: tokEOF, line: line}), nil
+}
+
+// lexToken reads the token at the start of s
+func lexToken(s string, line int) (token, int, error) {
+	c := rune(s[0])
+	switch {
+	case c == '_' || unicode.IsLetter(c):
+		n := 1
+		for n < len(s) && (s[n] == '_' || isAlnum(s[n])) {
+			n++
+		}
+		return token{kind: tokIdent, text: s[:n], line: line}, n, nil
+	case c >= '0' && c <= '9':
+		n := 1
+		for n < len(s) && (isAlnum(s[n]) || s[n] == '_') {
+			n++
+		}
+		text := strings.TrimRight(strings.ToLower(s[:n]), "ul")
+		v, err := strconv.ParseInt(text, 0, 64)
+		if err != nil {
+			u, uerr := strconv.ParseUint(text, 0, 64)
+			if uerr != nil {
+				return token{}, 0, errorf(line, "invalid number %q", s[:n])
+			}
+			v = int64(u)
+		}
+		return token{kind: tokNumber, text: s[:n], value: v, line: line}, n, nil
+	case c == '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return token{}, 0, errorf(line, "unterminated character constant")
+		}
+		unquoted, _, _, err := strconv.UnquoteChar(s[1:end+1], '\'')
+		if err != nil {
+			return token{}, 0, errorf(line, "invalid character constant %s", s[:end+2])
+		}
+		return token{kind: tokNumber, text: s[:end+2], value: int64(unquoted), line: line}, end + 2, nil
+	case c == '"':
+		n := 1
+		for n < len(s) && s[n] != '"' && s[n] != '\n' {
+			if s[n] == '\\' {
+				n++
+			}
+			n++
+		}
+		if n >= len(s) || s[n] != '"' {
+			return token{}, 0, errorf(line, "unterminated string")
+		}
+		return token{kind: tokString, text: s[:n+1], line: line}, n + 1, nil
+	}
+	for _, p := range punctuators {
+		if strings.HasPrefix(s, p) {
+			return token{kind: tokPunct, text: p, line: line}, len(p), nil
+		}
+	}
+	if c > unicode.MaxASCII {
+		return token{}, 0, errorf(line, "unexpected character %q", []rune(s)[0])
+	}
+	return token{kind: tokPunct, text: s[:1], line: line}, 1, nil
+}
+
+// lexDirective turns the text of a preprocessor line after "#" into a
+// token, or nil for directives that do not affect layouts
+func lexDirective(text string, line int) (*token, error) {
+	text = strings.ReplaceAll(text, "\\\n", " ")
+	if i := strings.Index(text, "//"); i >= 0 {
+		text = text[:i]
+	}
+	text = strings.TrimSpace(text)
+	name, rest, _ := strings.Cut(text, " ")
+	rest = strings.TrimSpace(rest)
+	switch name {
+	case "pragma":
+		toks, err := lex(rest)
+		if err != nil {
+			return nil, errorf(line, "%s", err.(*Error).Msg)
+		}
+		return &token{kind: tokPragma, line: line, args: toks[:len(toks)-1]}, nil
+	case "define":
+		macro := rest
+		for i, c := range rest {
+			if c != '_' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+				macro = rest[:i]
+				break
+			}
+		}
+		body := strings.TrimPrefix(rest, macro)
+		// Function-like macros and empty macros cannot be array sizes
+		if macro == "" || strings.HasPrefix(body, "(") || strings.TrimSpace(body) == "" {
+			return nil, nil
+		}
+		toks, err := lex(body)
+		if err != nil {
+			return nil, nil
+		}
+		return &token{kind: tokDefine, text: macro, line: line, args: toks[:len(toks)-1]}, nil
+	}
+	return nil, nil
+}
+
+// isAlnum reports whether c is an ASCII letter or digit
+func isAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,914 @@
+package cstruct
+
+import (
+	"fmt"
+	"strings"
+)
+
+// predefinedTypes are the typedef names known without a definition: the
+// fixed-width types of <stdint.h>, common Windows and Linux kernel names and
+// the names Ghidra uses in its decompiler output
+var predefinedTypes = map[string]*Type{
+	"bool":    {Kind: Bool},
+	"wchar_t": {Kind: WChar},
+
+	"int8_t": {Kind: Int8}, "uint8_t": {Kind: Int8, Unsigned: true},
+	"int16_t": {Kind: Int16}, "uint16_t": {Kind: Int16, Unsigned: true},
+	"int32_t": {Kind: Int32}, "uint32_t": {Kind: Int32, Unsigned: true},
+	"int64_t": {Kind: Int64}, "uint64_t": {Kind: Int64, Unsigned: true},
+	"intptr_t": {Kind: IntPtr}, "uintptr_t": {Kind: IntPtr, Unsigned: true},
+	"size_t": {Kind: IntPtr, Unsigned: true}, "ssize_t": {Kind: IntPtr},
+	"ptrdiff_t": {Kind: IntPtr},
+	"char16_t":  {Kind: Int16, Unsigned: true}, "char32_t": {Kind: Int32, Unsigned: true},
+
+	"u8": {Kind: Int8, Unsigned: true}, "s8": {Kind: Int8},
+	"u16": {Kind: Int16, Unsigned: true}, "s16": {Kind: Int16},
+	"u32": {Kind: Int32, Unsigned: true}, "s32": {Kind: Int32},
+	"u64": {Kind: Int64, Unsigned: true}, "s64": {Kind: Int64},
+	"__u8": {Kind: Int8, Unsigned: true}, "__s8": {Kind: Int8},
+	"__u16": {Kind: Int16, Unsigned: true}, "__s16": {Kind: Int16},
+	"__u32": {Kind: Int32, Unsigned: true}, "__s32": {Kind: Int32},
+	"__u64": {Kind: Int64, Unsigned: true}, "__s64": {Kind: Int64},
+
+	"BYTE": {Kind: Int8, Unsigned: true}, "UCHAR": {Kind: Int8, Unsigned: true},
+	"BOOLEAN": {Kind: Int8, Unsigned: true}, "CHAR": {Kind: Char},
+	"WORD": {Kind: Int16, Unsigned: true}, "USHORT": {Kind: Int16, Unsigned: true},
+	"SHORT": {Kind: Int16}, "WCHAR": {Kind: Int16, Unsigned: true},
+	"DWORD": {Kind: Int32, Unsigned: true}, "ULONG": {Kind: Int32, Unsigned: true},
+	"UINT": {Kind: Int32, Unsigned: true}, "LONG": {Kind: Int32},
+	"INT": {Kind: Int32}, "BOOL": {Kind: Int32},
+	"QWORD": {Kind: Int64, Unsigned: true}, "ULONGLONG": {Kind: Int64, Unsigned: true},
+	"DWORD64": {Kind: Int64, Unsigned: true}, "ULONG64": {Kind: Int64, Unsigned: true},
+	"UINT64": {Kind: Int64, Unsigned: true}, "LONGLONG": {Kind: Int64},
+	"LONG64": {Kind: Int64}, "INT64": {Kind: Int64},
+	"SIZE_T": {Kind: IntPtr, Unsigned: true}, "ULONG_PTR": {Kind: IntPtr, Unsigned: true},
+	"UINT_PTR": {Kind: IntPtr, Unsigned: true}, "DWORD_PTR": {Kind: IntPtr, Unsigned: true},
+	"LONG_PTR": {Kind: IntPtr}, "INT_PTR": {Kind: IntPtr}, "SSIZE_T": {Kind: IntPtr},
+	"FLOAT":  {Kind: Float},
+	"HANDLE": {Kind: Pointer, Elem: &Type{Kind: Void}}, "PVOID": {Kind: Pointer, Elem: &Type{Kind: Void}},
+	"LPVOID": {Kind: Pointer, Elem: &Type{Kind: Void}}, "HMODULE": {Kind: Pointer, Elem: &Type{Kind: Void}},
+	"HINSTANCE": {Kind: Pointer, Elem: &Type{Kind: Void}}, "HWND": {Kind: Pointer, Elem: &Type{Kind: Void}},
+	"LPSTR": {Kind: Pointer, Elem: &Type{Kind: Char}}, "LPCSTR": {Kind: Pointer, Elem: &Type{Kind: Char}},
+	"LPWSTR":  {Kind: Pointer, Elem: &Type{Kind: Int16, Unsigned: true, Name: "WCHAR"}},
+	"LPCWSTR": {Kind: Pointer, Elem: &Type{Kind: Int16, Unsigned: true, Name: "WCHAR"}},
+
+	"byte": {Kind: Int8, Unsigned: true}, "sbyte": {Kind: Int8},
+	"uchar": {Kind: Char, Unsigned: true}, "ushort": {Kind: Short, Unsigned: true},
+	"uint": {Kind: Int, Unsigned: true}, "ulong": {Kind: Long, Unsigned: true},
+	"longlong": {Kind: LongLong}, "ulonglong": {Kind: LongLong, Unsigned: true},
+	"word": {Kind: Int16, Unsigned: true}, "dword": {Kind: Int32, Unsigned: true},
+	"qword": {Kind: Int64, Unsigned: true}, "undefined": {Kind: Int8, Unsigned: true},
+	"undefined1": {Kind: Int8, Unsigned: true}, "undefined2": {Kind: Int16, Unsigned: true},
+	"undefined4": {Kind: Int32, Unsigned: true}, "undefined8": {Kind: Int64, Unsigned: true},
+	"pointer": {Kind: Pointer, Elem: &Type{Kind: Void}},
+}
+
+// isPredefined reports whether a type is one of the predefined typedef names
+func isPredefined(t *Type) bool {
+	_, ok := predefinedTypes[t.Name]
+	return ok
+}
+
+// ignoredKeywords are qualifiers and storage classes that do not affect layout
+var ignoredKeywords = map[string]bool{
+	"const": true, "volatile": true, "restrict": true, "__restrict": true,
+	"register": true, "static": true, "extern": true, "inline": true,
+	"__unaligned": true, "__ptr32": true, "__ptr64": true, "__far": true,
+	"__near": true, "__cdecl": true, "__stdcall": true, "__fastcall": true,
+	"__thiscall": true, "WINAPI": true, "CALLBACK": true,
+}
+
+// attributeKeywords introduce attribute lists
+var attributeKeywords = map[string]bool{
+	"__attribute__": true, "__attribute": true, "__declspec": true,
+	"alignas": true, "_Alignas": true, "__packed": true,
+}
+
+// parser is a recursive descent parser for C type definitions
+type parser struct {
+	toks []token
+	pos  int
+	file *File
+
+	// pack is the #pragma pack value in effect and packStack the values
+	// saved by push
+	pack      int
+	packStack []int
+}
+
+// Parse parses C definitions of structs, unions, enums and typedefs.
+// Function prototypes and variable declarations are skipped.
+//
+// Parameters:
+//   - src: The C source
+//
+// Returns:
+//   - The parsed definitions
+//   - An *Error locating the first problem
+func Parse(src string) (*File, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{
+		toks: toks,
+		file: &File{
+			tags:      make(map[string]interface{}),
+			types:     make(map[string]*Type),
+			constants: make(map[string]int64),
+		},
+	}
+	for name, t := range predefinedTypes {
+		typ := *t
+		typ.Name = name
+		p.file.types[name] = &typ
+	}
+	if err := p.parseFile(); err != nil {
+		return nil, err
+	}
+	return p.file, nil
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// isPunct reports whether the next token is the punctuator s
+func (p *parser) isPunct(s string) bool {
+	t := p.peek()
+	return t.kind == tokPunct && t.text == s
+}
+
+// expect consumes the punctuator s or fails
+func (p *parser) expect(s string) error {
+	if !p.isPunct(s) {
+		return errorf(p.peek().line, "expected %q, found %s", s, describe(p.peek()))
+	}
+	p.next()
+	return nil
+}
+
+// describe names a token for error messages
+func describe(t token) string {
+	if t.kind == tokEOF {
+		return "end of input"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// parseFile parses top-level declarations until the end of input
+func (p *parser) parseFile() error {
+	for p.peek().kind != tokEOF {
+		t := p.peek()
+		switch {
+		case t.kind == tokPragma || t.kind == tokDefine:
+			if err := p.directive(); err != nil {
+				return err
+			}
+		case p.isPunct(";"):
+			p.next()
+		case t.kind == tokIdent && t.text == "typedef":
+			if err := p.parseTypedef(); err != nil {
+				return err
+			}
+		default:
+			if _, err := p.parseType(); err != nil {
+				return err
+			}
+			// Variables and function prototypes have no layout of their own
+			p.skipDeclaration()
+		}
+	}
+	return nil
+}
+
+// skipDeclaration skips to the end of a declaration: the next ";" outside
+// brackets, or the end of a function body
+func (p *parser) skipDeclaration() {
+	depth := 0
+	for p.peek().kind != tokEOF {
+		t := p.next()
+		if t.kind != tokPunct {
+			continue
+		}
+		switch t.text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]":
+			depth--
+		case "}":
+			if depth--; depth == 0 {
+				return
+			}
+		case ";":
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+// directive applies a #pragma or #define token
+func (p *parser) directive() error {
+	t := p.next()
+	if t.kind == tokDefine {
+		sub := &parser{toks: append(append([]token(nil), t.args...), token{kind: tokEOF, line: t.line}), file: p.file}
+		// Macros that are not integer constants are ignored
+		if v, err := sub.parseExpr(); err == nil && sub.peek().kind == tokEOF {
+			p.file.constants[t.text] = v
+			p.file.Defines = append(p.file.Defines, Define{Name: t.text, Value: v})
+		}
+		return nil
+	}
+
+	if len(t.args) == 0 || t.args[0].text != "pack" {
+		return nil
+	}
+	var args []token
+	for _, a := range t.args[1:] {
+		if a.kind != tokPunct {
+			args = append(args, a)
+		}
+	}
+	value := func(a token) (int, error) {
+		v := int(a.value)
+		if a.kind != tokNumber || v&(v-1) != 0 || v < 1 || v > 16 {
+			return 0, errorf(t.line, "invalid #pragma pack value %s", describe(a))
+		}
+		return v, nil
+	}
+
+	switch {
+	case len(args) == 0:
+		p.pack = 0
+	case args[0].text == "push":
+		p.packStack = append(p.packStack, p.pack)
+		if last := args[len(args)-1]; len(args) > 1 && last.kind == tokNumber {
+			v, err := value(last)
+			if err != nil {
+				return err
+			}
+			p.pack = v
+		}
+	case args[0].text == "pop":
+		p.pack = 0
+		if n := len(p.packStack); n > 0 {
+			p.pack = p.packStack[n-1]
+			p.packStack = p.packStack[:n-1]
+		}
+	default:
+		v, err := value(args[0])
+		if err != nil {
+			return err
+		}
+		p.pack = v
+	}
+	return nil
+}
+
+// attributes parses attribute lists such as __attribute__((packed)),
+// __declspec(align(8)) and alignas(8)
+//
+// Returns:
+//   - Whether the attributes pack the type
+//   - The alignment they request, or 0
+func (p *parser) attributes() (packed bool, align int, err error) {
+	for p.peek().kind == tokIdent && attributeKeywords[p.peek().text] {
+		keyword := p.next().text
+		if keyword == "__packed" {
+			packed = true
+			continue
+		}
+		if !p.isPunct("(") {
+			return false, 0, errorf(p.peek().line, "expected \"(\" after %s", keyword)
+		}
+		toks := p.balanced()
+		if keyword == "alignas" || keyword == "_Alignas" {
+			if len(toks) == 3 && toks[1].kind == tokNumber {
+				align = int(toks[1].value)
+			}
+			continue
+		}
+		for i, t := range toks {
+			switch t.text {
+			case "packed", "__packed__":
+				packed = true
+			case "aligned", "__aligned__", "align":
+				if i+2 < len(toks) && toks[i+1].text == "(" && toks[i+2].kind == tokNumber {
+					align = int(toks[i+2].value)
+				}
+			}
+		}
+	}
+	return packed, align, nil
+}
+
+// balanced consumes a parenthesized token sequence and returns it,
+// parentheses included
+func (p *parser) balanced() []token {
+	var toks []token
+	depth := 0
+	for p.peek().kind != tokEOF {
+		t := p.next()
+		toks = append(toks, t)
+		if t.kind == tokPunct && t.text == "(" {
+			depth++
+		} else if t.kind == tokPunct && t.text == ")" {
+			if depth--; depth == 0 {
+				break
+			}
+		}
+	}
+	return toks
+}
+
+// parseType parses a type specifier: basic types spelled with keywords,
+// typedef names, and struct, union and enum references or definitions
+func (p *parser) parseType() (*Type, error) {
+	var signed, unsigned, short, char, float, double, void, boolean bool
+	long := 0
+	found := false
+	start := p.peek()
+
+loop:
+	for {
+		t := p.peek()
+		if t.kind != tokIdent {
+			break
+		}
+		switch {
+		case ignoredKeywords[t.text]:
+		case attributeKeywords[t.text]:
+			if _, _, err := p.attributes(); err != nil {
+				return nil, err
+			}
+			continue
+		case t.text == "signed" || t.text == "__signed__":
+			signed = true
+		case t.text == "unsigned":
+			unsigned = true
+		case t.text == "short":
+			short = true
+		case t.text == "long":
+			long++
+		case t.text == "int":
+		case t.text == "char":
+			char = true
+		case t.text == "float":
+			float = true
+		case t.text == "double":
+			double = true
+		case t.text == "void":
+			void = true
+		case t.text == "_Bool":
+			boolean = true
+		case t.text == "struct" || t.text == "union" || t.text == "enum":
+			if found {
+				return nil, errorf(t.line, "unexpected %q after a type", t.text)
+			}
+			var typ *Type
+			var err error
+			if t.text == "enum" {
+				typ, err = p.parseEnum()
+			} else {
+				typ, err = p.parseRecord()
+			}
+			if err != nil {
+				return nil, err
+			}
+			p.skipQualifiers()
+			return typ, nil
+		default:
+			if found {
+				break loop
+			}
+			typ, ok := p.file.types[t.text]
+			if !ok {
+				return nil, errorf(t.line, "unknown type %q", t.text)
+			}
+			p.next()
+			p.skipQualifiers()
+			return typ, nil
+		}
+		if !ignoredKeywords[t.text] {
+			found = true
+		}
+		p.next()
+	}
+
+	if !found {
+		return nil, errorf(start.line, "expected a type, found %s", describe(p.peek()))
+	}
+	switch {
+	case void:
+		return &Type{Kind: Void}, nil
+	case boolean:
+		return &Type{Kind: Bool}, nil
+	case float:
+		return &Type{Kind: Float}, nil
+	case double && long > 0:
+		return &Type{Kind: LongDouble}, nil
+	case double:
+		return &Type{Kind: Double}, nil
+	case char:
+		return &Type{Kind: Char, Unsigned: unsigned, Signed: signed}, nil
+	case short:
+		return &Type{Kind: Short, Unsigned: unsigned}, nil
+	case long == 1:
+		return &Type{Kind: Long, Unsigned: unsigned}, nil
+	case long > 1:
+		return &Type{Kind: LongLong, Unsigned: unsigned}, nil
+	}
+	return &Type{Kind: Int, Unsigned: unsigned}, nil
+}
+
+// skipQualifiers skips qualifiers and attributes that follow a type name
+func (p *parser) skipQualifiers() {
+	for p.peek().kind == tokIdent && ignoredKeywords[p.peek().text] {
+		p.next()
+	}
+}
+
+// parseRecord parses a struct or union reference or definition
+func (p *parser) parseRecord() (*Type, error) {
+	keyword := p.next()
+	union := keyword.text == "union"
+	kind := Struct
+	if union {
+		kind = Union
+	}
+
+	packed, align, err := p.attributes()
+	if err != nil {
+		return nil, err
+	}
+	tag := ""
+	if p.peek().kind == tokIdent {
+		tag = p.next().text
+	}
+
+	var r *Record
+	if tag != "" {
+		if existing, ok := p.file.tags[keyword.text+" "+tag].(*Record); ok {
+			r = existing
+		}
+	}
+	if !p.isPunct("{") {
+		if tag == "" {
+			return nil, errorf(keyword.line, "expected a %s tag or body", keyword.text)
+		}
+		if r == nil {
+			r = &Record{Tag: tag, Union: union, Line: keyword.line}
+			p.file.tags[keyword.text+" "+tag] = r
+		}
+		return &Type{Kind: kind, Record: r}, nil
+	}
+
+	if r != nil && r.Defined {
+		return nil, errorf(keyword.line, "redefinition of %s %s", keyword.text, tag)
+	}
+	if r == nil {
+		r = &Record{Tag: tag, Union: union}
+		if tag != "" {
+			p.file.tags[keyword.text+" "+tag] = r
+		}
+	}
+	r.Line = keyword.line
+	r.Pack = p.pack
+	p.next()
+	if err := p.parseFields(r); err != nil {
+		return nil, err
+	}
+	trailingPacked, trailingAlign, err := p.attributes()
+	if err != nil {
+		return nil, err
+	}
+	r.Packed = packed || trailingPacked
+	r.Align = max(align, trailingAlign)
+	r.Defined = true
+	if tag != "" {
+		p.file.Records = append(p.file.Records, r)
+	}
+	return &Type{Kind: kind, Record: r}, nil
+}
+
+// parseFields parses the members of a record up to and including "}"
+func (p *parser) parseFields(r *Record) error {
+	names := make(map[string]bool)
+	for !p.isPunct("}") {
+		t := p.peek()
+		switch {
+		case t.kind == tokEOF:
+			return errorf(t.line, "expected \"}\" to close %s %s", r.keyword(), r.Tag)
+		case t.kind == tokPragma || t.kind == tokDefine:
+			if err := p.directive(); err != nil {
+				return err
+			}
+			continue
+		case p.isPunct(";"):
+			p.next()
+			continue
+		}
+
+		base, err := p.parseType()
+		if err != nil {
+			return err
+		}
+		if p.isPunct(";") {
+			p.next()
+			// An anonymous struct or union member makes its members
+			// members of the enclosing record
+			if (base.Kind == Struct || base.Kind == Union) && base.Record.Tag == "" && base.Name == "" {
+				r.Fields = append(r.Fields, &Field{Type: base, Bits: -1, Line: t.line})
+			}
+			continue
+		}
+
+		for {
+			line := p.peek().line
+			name, typ, err := p.parseDeclarator(base, true)
+			if err != nil {
+				return err
+			}
+			bits := -1
+			if p.isPunct(":") {
+				p.next()
+				v, err := p.parseExpr()
+				if err != nil {
+					return err
+				}
+				if v < 0 || v > 64 {
+					return errorf(line, "invalid bitfield width %d", v)
+				}
+				bits = int(v)
+				if bits == 0 && name != "" {
+					return errorf(line, "named bitfield %q has zero width", name)
+				}
+			} else if name == "" {
+				return errorf(line, "expected a member name, found %s", describe(p.peek()))
+			}
+			if _, _, err := p.attributes(); err != nil {
+				return err
+			}
+			if name != "" {
+				if names[name] {
+					return errorf(line, "duplicate member %q", name)
+				}
+				names[name] = true
+			}
+			r.Fields = append(r.Fields, &Field{Name: name, Type: typ, Bits: bits, Line: line})
+			if !p.isPunct(",") {
+				break
+			}
+			p.next()
+		}
+		if err := p.expect(";"); err != nil {
+			return err
+		}
+	}
+	p.next()
+	return nil
+}
+
+// parseDeclarator parses the declarator of a member or typedef: pointers,
+// a name, array dimensions and function pointer syntax such as
+// "(*handlers[4])(int)".
+//
+// Parameters:
+//   - base: The type from the type specifier
+//   - allowUnnamed: Whether the name may be missing, as for unnamed bitfields
+//
+// Returns:
+//   - The declared name, or "" if unnamed
+//   - The declared type
+//   - An error if the declarator is malformed
+func (p *parser) parseDeclarator(base *Type, allowUnnamed bool) (string, *Type, error) {
+	typ := base
+	for p.isPunct("*") || p.peek().kind == tokIdent && ignoredKeywords[p.peek().text] {
+		if p.next().text == "*" {
+			typ = &Type{Kind: Pointer, Elem: typ}
+		}
+	}
+	if _, _, err := p.attributes(); err != nil {
+		return "", nil, err
+	}
+
+	if p.isPunct("(") {
+		line := p.next().line
+		pointers := 0
+		for p.isPunct("*") || p.peek().kind == tokIdent && ignoredKeywords[p.peek().text] {
+			if p.next().text == "*" {
+				pointers++
+			}
+		}
+		if pointers == 0 {
+			return "", nil, errorf(line, "expected \"*\" in function pointer declarator")
+		}
+		name := ""
+		if p.peek().kind == tokIdent {
+			name = p.next().text
+		}
+		dims, err := p.parseDims()
+		if err != nil {
+			return "", nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return "", nil, err
+		}
+		if p.isPunct("(") {
+			typ = &Type{Kind: Func, Elem: typ, Params: joinTokens(p.balanced())}
+		}
+		for i := 0; i < pointers; i++ {
+			typ = &Type{Kind: Pointer, Elem: typ}
+		}
+		return name, arrayOf(typ, dims), nil
+	}
+
+	name := ""
+	if t := p.peek(); t.kind == tokIdent {
+		name = p.next().text
+	} else if !allowUnnamed || !p.isPunct(":") {
+		return "", nil, errorf(t.line, "expected a name, found %s", describe(t))
+	}
+	dims, err := p.parseDims()
+	if err != nil {
+		return "", nil, err
+	}
+	return name, arrayOf(typ, dims), nil
+}
+
+// parseDims parses array dimensions; an empty dimension is returned as -1
+func (p *parser) parseDims() ([]int, error) {
+	var dims []int
+	for p.isPunct("[") {
+		p.next()
+		if p.isPunct("]") {
+			p.next()
+			dims = append(dims, -1)
+			continue
+		}
+		line := p.peek().line
+		v, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if v < 0 {
+			return nil, errorf(line, "negative array size %d", v)
+		}
+		dims = append(dims, int(v))
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	}
+	return dims, nil
+}
+
+// arrayOf wraps a type in array dimensions, outermost first
+func arrayOf(t *Type, dims []int) *Type {
+	for i := len(dims) - 1; i >= 0; i-- {
+		t = &Type{Kind: Array, Elem: t, Len: dims[i]}
+	}
+	return t
+}
+
+// joinTokens writes tokens back as C source with conventional spacing
+func joinTokens(toks []token) string {
+	var b strings.Builder
+	for i, t := range toks {
+		if i > 0 {
+			prev := toks[i-1].text
+			if prev != "(" && prev != "[" && t.text != ")" && t.text != "," && t.text != "[" && t.text != "]" {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(t.text)
+	}
+	return b.String()
+}
+
+// parseEnum parses an enum reference or definition
+func (p *parser) parseEnum() (*Type, error) {
+	keyword := p.next()
+	if _, _, err := p.attributes(); err != nil {
+		return nil, err
+	}
+	tag := ""
+	if p.peek().kind == tokIdent {
+		tag = p.next().text
+	}
+
+	var e *EnumType
+	if tag != "" {
+		e, _ = p.file.tags["enum "+tag].(*EnumType)
+	}
+	var base *Type
+	if p.isPunct(":") {
+		p.next()
+		var err error
+		if base, err = p.parseType(); err != nil {
+			return nil, err
+		}
+		if !base.isInteger() {
+			return nil, errorf(keyword.line, "enum %s has a non-integer base type", tag)
+		}
+	}
+
+	if !p.isPunct("{") {
+		if tag == "" {
+			return nil, errorf(keyword.line, "expected an enum tag or body")
+		}
+		if e == nil {
+			e = &EnumType{Tag: tag, Base: base}
+			p.file.tags["enum "+tag] = e
+		}
+		return &Type{Kind: Enum, Enum: e}, nil
+	}
+
+	if e != nil && len(e.Values) > 0 {
+		return nil, errorf(keyword.line, "redefinition of enum %s", tag)
+	}
+	if e == nil {
+		e = &EnumType{Tag: tag}
+		if tag != "" {
+			p.file.tags["enum "+tag] = e
+		}
+	}
+	if base != nil {
+		e.Base = base
+	}
+	p.next()
+	next := int64(0)
+	for !p.isPunct("}") {
+		t := p.next()
+		if t.kind != tokIdent {
+			return nil, errorf(t.line, "expected an enum constant, found %s", describe(t))
+		}
+		if p.isPunct("=") {
+			p.next()
+			v, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			next = v
+		}
+		e.Values = append(e.Values, EnumValue{Name: t.text, Value: next})
+		p.file.constants[t.text] = next
+		next++
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	if err := p.expect("}"); err != nil {
+		return nil, err
+	}
+	if _, _, err := p.attributes(); err != nil {
+		return nil, err
+	}
+	if tag != "" {
+		p.file.Enums = append(p.file.Enums, e)
+	}
+	return &Type{Kind: Enum, Enum: e}, nil
+}
+
+// parseTypedef parses a typedef declaration
+func (p *parser) parseTypedef() error {
+	p.next()
+	base, err := p.parseType()
+	if err != nil {
+		return err
+	}
+	for {
+		name, typ, err := p.parseDeclarator(base, false)
+		if err != nil {
+			return err
+		}
+		p.define(name, typ, typ == base)
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	return p.expect(";")
+}
+
+// define records a typedef name. A typedef naming a struct, union or enum
+// directly becomes the name of that definition if it has none yet.
+func (p *parser) define(name string, typ *Type, direct bool) {
+	named := *typ
+	named.Name = name
+	p.file.types[name] = &named
+
+	switch {
+	case direct && typ.Name == "" && typ.Record != nil && typ.Record.Typedef == "":
+		typ.Record.Typedef = name
+		if typ.Record.Tag == "" && typ.Record.Defined {
+			p.file.Records = append(p.file.Records, typ.Record)
+		}
+	case direct && typ.Name == "" && typ.Enum != nil && typ.Enum.Typedef == "":
+		typ.Enum.Typedef = name
+		if typ.Enum.Tag == "" {
+			p.file.Enums = append(p.file.Enums, typ.Enum)
+		}
+	default:
+		p.file.Typedefs = append(p.file.Typedefs, Typedef{Name: name, Type: typ})
+	}
+}
+
+// binaryPrecedence is the precedence of the binary operators allowed in
+// constant expressions
+var binaryPrecedence = map[string]int{
+	"|": 1, "^": 2, "&": 3, "<<": 4, ">>": 4, "+": 5, "-": 5, "*": 6, "/": 6, "%": 6,
+}
+
+// parseExpr parses an integer constant expression made of numbers, enum
+// constants, defines and arithmetic operators
+func (p *parser) parseExpr() (int64, error) {
+	return p.parseBinary(1)
+}
+
+// parseBinary parses operators of at least a precedence
+func (p *parser) parseBinary(minPrec int) (int64, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		t := p.peek()
+		prec, ok := binaryPrecedence[t.text]
+		if t.kind != tokPunct || !ok || prec < minPrec {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseBinary(prec + 1)
+		if err != nil {
+			return 0, err
+		}
+		switch t.text {
+		case "|":
+			left |= right
+		case "^":
+			left ^= right
+		case "&":
+			left &= right
+		case "<<":
+			left <<= uint(right)
+		case ">>":
+			left >>= uint(right)
+		case "+":
+			left += right
+		case "-":
+			left -= right
+		case "*":
+			left *= right
+		case "/", "%":
+			if right == 0 {
+				return 0, errorf(t.line, "division by zero")
+			}
+			if t.text == "/" {
+				left /= right
+			} else {
+				left %= right
+			}
+		}
+	}
+}
+
+// parseUnary parses a unary expression or operand
+func (p *parser) parseUnary() (int64, error) {
+	t := p.next()
+	switch {
+	case t.kind == tokNumber:
+		return t.value, nil
+	case t.kind == tokIdent:
+		v, ok := p.file.constants[t.text]
+		if !ok {
+			return 0, errorf(t.line, "unknown constant %q", t.text)
+		}
+		return v, nil
+	case t.kind == tokPunct && t.text == "(":
+		v, err := p.parseExpr()
+		if err != nil {
+			return 0, err
+		}
+		return v, p.expect(")")
+	case t.kind == tokPunct && (t.text == "-" || t.text == "+" || t.text == "~"):
+		v, err := p.parseUnary()
+		switch t.text {
+		case "-":
+			v = -v
+		case "~":
+			v = ^v
+		}
+		return v, err
+	}
+	return 0, errorf(t.line, "expected a constant, found %s", describe(t))
+}
@@ -0,0 +1,329 @@
+package cstruct
+
+import "fmt"
+
+// Kind classifies a C type
+type Kind int
+
+// Kinds of C types
+const (
+	Void Kind = iota
+	Bool
+	Char
+	Short
+	Int
+	Long
+	LongLong
+	Int8
+	Int16
+	Int32
+	Int64
+	IntPtr
+	WChar
+	Float
+	Double
+	LongDouble
+	Pointer
+	Array
+	Struct
+	Union
+	Enum
+	Func
+)
+
+// Type is a C type
+type Type struct {
+	Kind Kind
+
+	// Unsigned reports whether an integer type is unsigned. Plain char
+	// follows the ABI.
+	Unsigned bool
+
+	// Signed reports whether an integer type was declared signed, which
+	// matters only for char
+	Signed bool
+
+	// Name is the name the type was written with, such as a typedef name
+	// like "DWORD"; empty for types spelled out with keywords
+	Name string
+
+	// Elem is the target of a pointer, the element of an array or the
+	// return type of a function
+	Elem *Type
+
+	// Len is the number of elements of an array; -1 for a flexible array
+	Len int
+
+	// Record is the definition of a struct or union
+	Record *Record
+
+	// Enum is the definition of an enum
+	Enum *EnumType
+
+	// Params is the parameter list of a function type as written
+	Params string
+}
+
+// Field is a member of a struct or union
+type Field struct {
+	// Name is the member name; empty for anonymous struct and union
+	// members and for unnamed bitfields
+	Name string
+
+	Type *Type
+
+	// Bits is the width of a bitfield; -1 for ordinary members
+	Bits int
+
+	// Line is the line the member is declared on
+	Line int
+}
+
+// IsBitfield reports whether the field is a bitfield
+func (f *Field) IsBitfield() bool {
+	return f.Bits >= 0
+}
+
+// Record is a struct or union definition
+type Record struct {
+	// Tag is the struct or union tag; empty for anonymous records
+	Tag string
+
+	// Typedef is the typedef name given to the record, if any
+	Typedef string
+
+	// Union reports whether the record is a union
+	Union bool
+
+	Fields []*Field
+
+	// Defined reports whether the record has a body; records that are
+	// only declared cannot be used by value
+	Defined bool
+
+	// Pack is the #pragma pack value in effect at the definition; 0 for
+	// natural alignment
+	Pack int
+
+	// Packed reports whether the record has __attribute__((packed))
+	Packed bool
+
+	// Align is a minimum alignment from __attribute__((aligned(n)))
+	Align int
+
+	// Line is the line the definition starts on
+	Line int
+}
+
+// Name returns the name the record is known by: its typedef name if it
+// has one, otherwise its tag
+func (r *Record) Name() string {
+	if r.Typedef != "" {
+		return r.Typedef
+	}
+	return r.Tag
+}
+
+// keyword returns "struct" or "union"
+func (r *Record) keyword() string {
+	if r.Union {
+		return "union"
+	}
+	return "struct"
+}
+
+// EnumType is an enum definition
+type EnumType struct {
+	Tag     string
+	Typedef string
+
+	// Base is the underlying type given with "enum E : uint8_t"; nil for int
+	Base *Type
+
+	Values []EnumValue
+}
+
+// Name returns the name the enum is known by
+func (e *EnumType) Name() string {
+	if e.Typedef != "" {
+		return e.Typedef
+	}
+	return e.Tag
+}
+
+// EnumValue is a named enum constant
+type EnumValue struct {
+	Name  string
+	Value int64
+}
+
+// ValueName returns the name of the constant with a value, or "" if none
+func (e *EnumType) ValueName(value int64) string {
+	for _, v := range e.Values {
+		if v.Value == value {
+			return v.Name
+		}
+	}
+	return ""
+}
+
+// Typedef is a typedef of a type other than a record or enum defined with it
+type Typedef struct {
+	Name string
+	Type *Type
+}
+
+// Define is a #define of a constant
+type Define struct {
+	Name  string
+	Value int64
+}
+
+// File is a parsed set of C definitions
+type File struct {
+	// Records are the named structs and unions in definition order
+	Records []*Record
+
+	// Enums are the named enums in definition order
+	Enums []*EnumType
+
+	// Typedefs are the typedefs of other types in definition order
+	Typedefs []Typedef
+
+	// Defines are the #define constants in definition order
+	Defines []Define
+
+	// tags maps "struct x", "union x" and "enum x" to their definitions
+	tags map[string]interface{}
+
+	// types maps typedef names, including the predefined ones, to types
+	types map[string]*Type
+
+	// constants maps enum constants and defines to their values
+	constants map[string]int64
+}
+
+// Record returns the struct or union with a typedef name or tag
+func (f *File) Record(name string) (*Record, bool) {
+	for _, r := range f.Records {
+		if r.Typedef == name {
+			return r, true
+		}
+	}
+	for _, r := range f.Records {
+		if r.Tag == name {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// RecordNames returns the names of the defined structs and unions in
+// definition order
+func (f *File) RecordNames() []string {
+	var names []string
+	for _, r := range f.Records {
+		if r.Defined {
+			names = append(names, r.Name())
+		}
+	}
+	return names
+}
+
+// String returns the type as it would be written in C, without a declarator
+func (t *Type) String() string {
+	return declare(t, "")
+}
+
+// declare writes a C declaration of a name with a type, such as
+// "char *argv[4]" or "void (*handler)(int)"
+func declare(t *Type, name string) string {
+	return declareWith(t, name, spell)
+}
+
+// declareWith writes a C declaration, spelling named, basic, record and
+// enum types with a function so exports can substitute their own names
+func declareWith(t *Type, name string, spellType func(*Type) string) string {
+	if t.Name == "" {
+		switch t.Kind {
+		case Pointer:
+			if t.Elem.Name == "" && (t.Elem.Kind == Func || t.Elem.Kind == Array) {
+				return declareWith(t.Elem, "(*"+name+")", spellType)
+			}
+			return declareWith(t.Elem, "*"+name, spellType)
+		case Array:
+			if t.Len < 0 {
+				return declareWith(t.Elem, name+"[]", spellType)
+			}
+			return declareWith(t.Elem, fmt.Sprintf("%s[%d]", name, t.Len), spellType)
+		case Func:
+			return declareWith(t.Elem, name+t.Params, spellType)
+		}
+	}
+	return joinDecl(spellType(t), name)
+}
+
+// spell names a type that declareWith does not build from its element:
+// a typedef name, a basic type, or a struct, union or enum
+func spell(t *Type) string {
+	if t.Name != "" {
+		return t.Name
+	}
+	switch t.Kind {
+	case Struct, Union:
+		if t.Record.Tag == "" {
+			return t.Record.keyword() + " {...}"
+		}
+		return t.Record.keyword() + " " + t.Record.Tag
+	case Enum:
+		if t.Enum.Tag == "" {
+			return "enum {...}"
+		}
+		return "enum " + t.Enum.Tag
+	}
+	return basicName(t)
+}
+
+// joinDecl joins a type name and a declarator
+func joinDecl(typeName, declarator string) string {
+	if declarator == "" {
+		return typeName
+	}
+	if declarator[0] == '[' {
+		return typeName + declarator
+	}
+	return typeName + " " + declarator
+}
+
+// basicName spells out a basic type with C keywords
+func basicName(t *Type) string {
+	names := map[Kind]string{
+		Void: "void", Bool: "_Bool", Char: "char", Short: "short", Int: "int",
+		Long: "long", LongLong: "long long", Int8: "int8_t", Int16: "int16_t",
+		Int32: "int32_t", Int64: "int64_t", IntPtr: "intptr_t", WChar: "wchar_t",
+		Float: "float", Double: "double", LongDouble: "long double",
+	}
+	name := names[t.Kind]
+	switch {
+	case t.Kind >= Int8 && t.Kind <= IntPtr && t.Unsigned:
+		return "u" + name
+	case t.Kind == Char && t.Signed:
+		return "signed char"
+	case t.Unsigned && t.Kind >= Char && t.Kind <= LongLong:
+		return "unsigned " + name
+	}
+	return name
+}
+
+// isInteger reports whether a type can hold a bitfield
+func (t *Type) isInteger() bool {
+	return t.Kind >= Bool && t.Kind <= WChar || t.Kind == Enum
+}
+
+// resolve follows arrays to their innermost element type
+func (t *Type) resolve() *Type {
+	for t.Kind == Array {
+		t = t.Elem
+	}
+	return t
+}
@@ -0,0 +1,212 @@
+// Package disasm decodes the machine code of the binaries attached to
+// projects for the address ranges of notes: x86, x86-64 and ARM64
+// instructions, annotated with the names of the binary's symbols.
+package disasm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"golang.org/x/arch/arm64/arm64asm"
+	"golang.org/x/arch/x86/x86asm"
+
+	"github.com/leog/RevEnGo/internal/binfile"
+)
+
+// MaxBytes bounds the bytes decoded at once, so a mistyped range does not
+// produce a listing of the whole binary
+const MaxBytes = 64 << 10
+
+// maxInstructionLen is the length of the longest x86 instruction
+const maxInstructionLen = 15
+
+// SingleAddressBytes is the number of bytes decoded for an address range
+// that is a single address
+const SingleAddressBytes = 64
+
+// Instruction is a decoded instruction
+type Instruction struct {
+	Addr  uint64
+	Bytes []byte
+
+	// Text is the instruction in Intel syntax for x86 and GNU syntax for
+	// ARM64, with branch targets as absolute addresses
+	Text string
+
+	// Symbol is the name of the symbol starting at the instruction
+	Symbol string
+
+	// Refs name the symbols of the addresses the instruction refers to,
+	// as in "main.parse" or "buf+0x10"
+	Refs []string
+}
+
+// Listing is the disassembly of a range of a binary
+type Listing struct {
+	// Arch is the architecture decoded, one of the binfile architectures
+	Arch string
+
+	// Section is the section holding the range
+	Section string
+
+	// Start is the address of the first instruction and End the address
+	// after the last
+	Start, End uint64
+
+	// Truncated reports a range cut short at the end of its section or at
+	// MaxBytes
+	Truncated bool
+
+	Instructions []Instruction
+}
+
+// Supported reports whether an architecture can be disassembled
+func Supported(arch string) bool {
+	switch arch {
+	case binfile.ArchX86, binfile.ArchAMD64, binfile.ArchARM64:
+		return true
+	}
+	return false
+}
+
+// Disassemble decodes the instructions of a range of a binary.
+//
+// Parameters:
+//   - f: The binary
+//   - start: The virtual address of the first instruction
+//   - end: The address of the last byte of the range, inclusive as in note
+//     address ranges; the last instruction may run past it. A range of one
+//     address decodes SingleAddressBytes bytes.
+//
+// Returns:
+//   - The listing
+//   - An error if the architecture is not supported or the start address
+//     has no data in the file
+func Disassemble(f *binfile.File, start, end uint64) (*Listing, error) {
+	if !Supported(f.Arch) {
+		if f.Arch == "" {
+			return nil, fmt.Errorf("%s is not an ELF, PE or Mach-O executable", f.Path)
+		}
+		return nil, fmt.Errorf("cannot disassemble %s code (supported: x86, x86-64, arm64)", f.Arch)
+	}
+	if end < start {
+		return nil, fmt.Errorf("the range ends at 0x%x before it starts at 0x%x", end, start)
+	}
+	offset, section := f.FileOffset(start)
+	if section == nil {
+		return nil, fmt.Errorf("0x%x is not in a section of %s with data in the file", start, f.Path)
+	}
+
+	size := end - start + 1
+	if start == end {
+		size = SingleAddressBytes
+	}
+	l := &Listing{Arch: f.Arch, Section: section.Name, Start: start}
+	if available := section.Offset + section.Size - offset; size > available {
+		size, l.Truncated = available, start != end
+	}
+	if size > MaxBytes {
+		size, l.Truncated = MaxBytes, true
+	}
+
+	// The last instruction may need bytes after the range
+	code := f.Data[offset : offset+min(size+maxInstructionLen, section.Offset+section.Size-offset)]
+	for pos := 0; pos < int(size); {
+		pc := start + uint64(pos)
+		var inst Instruction
+		if f.Arch == binfile.ArchARM64 {
+			inst = decodeARM64(f, code[pos:], pc)
+		} else {
+			inst = decodeX86(f, code[pos:], pc)
+		}
+		if name, base := f.SymbolAt(pc); name != "" && base == pc {
+			inst.Symbol = name
+		}
+		l.Instructions = append(l.Instructions, inst)
+		pos += len(inst.Bytes)
+	}
+	l.End = start
+	if n := len(l.Instructions); n > 0 {
+		last := l.Instructions[n-1]
+		l.End = last.Addr + uint64(len(last.Bytes))
+	}
+	return l, nil
+}
+
+// decodeX86 decodes the x86 instruction at the start of code
+func decodeX86(f *binfile.File, code []byte, pc uint64) Instruction {
+	mode := 64
+	if f.Arch == binfile.ArchX86 {
+		mode = 32
+	}
+	inst, err := x86asm.Decode(code, mode)
+	if err != nil || inst.Len == 0 {
+		return Instruction{Addr: pc, Bytes: code[:1], Text: fmt.Sprintf("(bad) .byte 0x%02x", code[0])}
+	}
+
+	decoded := Instruction{Addr: pc, Bytes: code[:inst.Len], Text: x86asm.IntelSyntax(inst, pc, nil)}
+	next := pc + uint64(inst.Len)
+	for _, arg := range inst.Args {
+		switch arg := arg.(type) {
+		case x86asm.Rel:
+			decoded.refer(f, next+uint64(int64(arg)))
+		case x86asm.Mem:
+			switch {
+			case arg.Base == x86asm.RIP:
+				decoded.refer(f, next+uint64(arg.Disp))
+			case arg.Base == 0 && arg.Index == 0 && arg.Disp > 0:
+				decoded.refer(f, uint64(arg.Disp))
+			}
+		case x86asm.Imm:
+			// Absolute addresses of 32-bit code are immediates
+			if mode == 32 && arg > 0 {
+				decoded.refer(f, uint64(arg))
+			}
+		}
+	}
+	return decoded
+}
+
+// decodeARM64 decodes the ARM64 instruction at the start of code
+func decodeARM64(f *binfile.File, code []byte, pc uint64) Instruction {
+	if len(code) < 4 {
+		return Instruction{Addr: pc, Bytes: code, Text: fmt.Sprintf("(bad) .byte % #x", code)}
+	}
+	word := code[:4]
+	inst, err := arm64asm.Decode(word)
+	if err != nil {
+		return Instruction{Addr: pc, Bytes: word, Text: fmt.Sprintf(".inst 0x%08x", binary.LittleEndian.Uint32(word))}
+	}
+
+	decoded := Instruction{Addr: pc, Bytes: word, Text: arm64asm.GNUSyntax(inst)}
+	for _, arg := range inst.Args {
+		rel, ok := arg.(arm64asm.PCRel)
+		if !ok {
+			continue
+		}
+		// ADRP addresses the 4 KiB page of its target
+		target := pc + uint64(int64(rel))
+		if inst.Op == arm64asm.ADRP {
+			target = pc&^0xfff + uint64(int64(rel))
+		}
+		decoded.Text = strings.Replace(decoded.Text, rel.String(), fmt.Sprintf("0x%x", target), 1)
+		if inst.Op != arm64asm.ADRP {
+			decoded.refer(f, target)
+		}
+	}
+	return decoded
+}
+
+// refer records the symbol naming an address the instruction refers to,
+// as objdump does for branch targets and data
+func (inst *Instruction) refer(f *binfile.File, addr uint64) {
+	name, base := f.SymbolAt(addr)
+	switch {
+	case name == "":
+	case base == addr:
+		inst.Refs = append(inst.Refs, name)
+	default:
+		inst.Refs = append(inst.Refs, fmt.Sprintf("%s+0x%x", name, addr-base))
+	}
+}
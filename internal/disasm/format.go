@@ -0,0 +1,79 @@
+package disasm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leog/RevEnGo/internal/binfile"
+)
+
+// maxBytesShown bounds the instruction bytes shown on a line; longer x86
+// instructions end in ".."
+const maxBytesShown = 8
+
+// FenceTag returns the info string of the fenced code blocks holding code
+// of an architecture, one the note highlighter knows
+func FenceTag(arch string) string {
+	switch arch {
+	case binfile.ArchX86:
+		return "x86"
+	case binfile.ArchAMD64:
+		return "x64"
+	case binfile.ArchARM64:
+		return "aarch64"
+	}
+	return "asm"
+}
+
+// Format writes the listing as text, one instruction per line with its
+// address, and symbols as labels before the instructions they start at.
+//
+// Parameters:
+//   - withBytes: Show the bytes of each instruction after its address
+//
+// Returns:
+//   - The listing, each line ending in a newline
+func (l *Listing) Format(withBytes bool) string {
+	var b strings.Builder
+	for i, inst := range l.Instructions {
+		if inst.Symbol != "" {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "%s:\n", inst.Symbol)
+		}
+		fmt.Fprintf(&b, "  0x%x  ", inst.Addr)
+		if withBytes {
+			fmt.Fprintf(&b, "%-*s  ", 3*maxBytesShown-1, formatBytes(inst.Bytes))
+		}
+		b.WriteString(inst.Text)
+		if len(inst.Refs) > 0 {
+			fmt.Fprintf(&b, "  ; %s", strings.Join(inst.Refs, ", "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// formatBytes writes instruction bytes in hex
+func formatBytes(data []byte) string {
+	if len(data) > maxBytesShown {
+		return fmt.Sprintf("% x ..", data[:maxBytesShown-1])
+	}
+	return fmt.Sprintf("% x", data)
+}
+
+// Fenced returns the listing as a fenced code block for a note, without
+// the instruction bytes.
+//
+// Parameters:
+//   - binaryName: The name of the binary, written in a comment before the
+//     code with the range and section
+func (l *Listing) Fenced(binaryName string) string {
+	tag := FenceTag(l.Arch)
+	var b strings.Builder
+	fmt.Fprintf(&b, "```%s\n; %s 0x%x-0x%x (%s)\n", tag, binaryName, l.Start, l.End-1, l.Section)
+	b.WriteString(l.Format(false))
+	b.WriteString("```\n")
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package dissect
+
+import (
+	"hash/crc32"
+	"sort"
+)
+
+// checksums are the supported checksum algorithms by name
+var checksums = map[string]func([]byte) uint64{
+	// sum8 and sum16 add the bytes, keeping the low 8 or 16 bits
+	"sum8": func(b []byte) uint64 {
+		var sum uint8
+		for _, c := range b {
+			sum += c
+		}
+		return uint64(sum)
+	},
+	"sum16": func(b []byte) uint64 {
+		var sum uint16
+		for _, c := range b {
+			sum += uint16(c)
+		}
+		return uint64(sum)
+	},
+
+	// xor8 XORs the bytes
+	"xor8": func(b []byte) uint64 {
+		var x uint8
+		for _, c := range b {
+			x ^= c
+		}
+		return uint64(x)
+	},
+
+	// internet is the ones' complement sum of big-endian 16-bit words of
+	// RFC 1071, used by IP, TCP and UDP
+	"internet": func(b []byte) uint64 {
+		var sum uint32
+		for i := 0; i < len(b); i += 2 {
+			word := uint32(b[i]) << 8
+			if i+1 < len(b) {
+				word |= uint32(b[i+1])
+			}
+			sum += word
+		}
+		for sum > 0xffff {
+			sum = sum&0xffff + sum>>16
+		}
+		return uint64(^uint16(sum))
+	},
+
+	// crc16 is CRC-16/CCITT-FALSE: polynomial 0x1021, initial value 0xffff
+	"crc16": func(b []byte) uint64 {
+		crc := uint16(0xffff)
+		for _, c := range b {
+			crc ^= uint16(c) << 8
+			for i := 0; i < 8; i++ {
+				if crc&0x8000 != 0 {
+					crc = crc<<1 ^ 0x1021
+				} else {
+					crc <<= 1
+				}
+			}
+		}
+		return uint64(crc)
+	},
+
+	// crc16_modbus is CRC-16/MODBUS: reflected polynomial 0xa001, initial
+	// value 0xffff
+	"crc16_modbus": func(b []byte) uint64 {
+		crc := uint16(0xffff)
+		for _, c := range b {
+			crc ^= uint16(c)
+			for i := 0; i < 8; i++ {
+				if crc&1 != 0 {
+					crc = crc>>1 ^ 0xa001
+				} else {
+					crc >>= 1
+				}
+			}
+		}
+		return uint64(crc)
+	},
+
+	// crc32 is the CRC-32 of Ethernet, zlib and PNG
+	"crc32": func(b []byte) uint64 {
+		return uint64(crc32.ChecksumIEEE(b))
+	},
+}
+
+// Checksums returns the names of the supported checksum algorithms
+func Checksums() []string {
+	names := make([]string, 0, len(checksums))
+	for name := range checksums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,544 @@
+package dissect
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxShown is the number of bytes of a bytes field shown in its value
+const maxShown = 32
+
+// Node is a decoded field, or a message or TLV record holding fields
+type Node struct {
+	Name string
+
+	// Type is the field's type as written in the definition, such as
+	// "u16" or "bytes[length]"
+	Type string
+
+	// Offset and Length locate the field's bytes in the sample
+	Offset int
+	Length int
+
+	// Value shows the decoded value; empty for messages and records
+	Value string
+
+	// Error reports a problem found while decoding the field, such as a
+	// truncated sample, an unexpected value or a wrong checksum
+	Error string
+
+	Children []*Node
+}
+
+// Decode decodes a sample message with the root message of a definition.
+// Problems with the sample, such as truncation or a wrong magic number,
+// are reported on the nodes where they occur rather than stopping the
+// decode, so the fields before them are still shown.
+//
+// Parameters:
+//   - spec: The protocol definition
+//   - data: The sample message
+//
+// Returns:
+//   - The root node of the field tree
+//   - An error if the definition has no message
+func Decode(spec *Spec, data []byte) (*Node, error) {
+	root := spec.Root()
+	if root == nil {
+		return nil, fmt.Errorf("the definition has no message")
+	}
+	d := &decoder{spec: spec, data: data}
+	node := d.message(root, 0, len(data))
+	if node.Error == "" && node.Length < len(data) {
+		node.Error = fmt.Sprintf("%d bytes after the end of the message", len(data)-node.Length)
+	}
+	return node, nil
+}
+
+// decoder decodes a sample
+type decoder struct {
+	spec *Spec
+	data []byte
+}
+
+// errTruncated is the error of fields that end past their limit
+const errTruncated = "truncated: the sample ends inside this field"
+
+// message decodes a message starting at an offset, reading no further
+// than limit
+func (d *decoder) message(m *Message, offset, limit int) *Node {
+	node := &Node{Name: m.Name, Type: m.Name, Offset: offset}
+	values := make(map[string]int64)
+	starts := make(map[string]int)
+	pos := offset
+	for _, f := range m.Fields {
+		starts[f.Name] = pos
+		children, next, stop := d.field(f, pos, limit, values)
+		if f.Checksum != "" && len(children) == 1 && children[0].Error == "" {
+			from := offset
+			if f.ChecksumFrom != "" {
+				from = starts[f.ChecksumFrom]
+			}
+			checkChecksum(children[0], f, d.data[from:pos], values[f.Name])
+		}
+		node.Children = append(node.Children, children...)
+		pos = next
+		if stop {
+			node.Error = "decoding stopped at " + f.Name
+			break
+		}
+	}
+	node.Length = pos - offset
+	return node
+}
+
+// field decodes a field.
+//
+// Returns:
+//   - The nodes of the field: one, or one per element of a repeated
+//     message or TLV field
+//   - The offset after the field
+//   - Whether decoding the message must stop because the field could not
+//     be read
+func (d *decoder) field(f *Field, pos, limit int, values map[string]int64) ([]*Node, int, bool) {
+	typeName := fieldType(f)
+	switch f.Kind {
+	case KindInt:
+		node, ok := d.integer(f.Name, typeName, f.Int, pos, limit)
+		if !ok {
+			return []*Node{node}, limit, true
+		}
+		raw := d.readInt(f.Int, pos)
+		values[f.Name] = raw
+		node.Value = formatInt(raw, f.Int, f.Enum)
+		if f.Expect != nil && raw != *f.Expect {
+			node.Error = fmt.Sprintf("expected %s", formatHex(*f.Expect, f.Int))
+		}
+		return []*Node{node}, pos + f.Int.Size, false
+
+	case KindBytes, KindString:
+		var prefix *Node
+		length, start, err := d.size(f.Size, pos, limit, values)
+		if f.Size != nil && f.Size.Prefix != nil {
+			prefix, _ = d.integer(f.Name+"_length", f.Size.Prefix.String(), *f.Size.Prefix, pos, limit)
+			if err == "" {
+				prefix.Value = strconv.FormatInt(int64(length), 10)
+			}
+		}
+		node := &Node{Name: f.Name, Type: typeName, Offset: start, Length: max(length, 0)}
+		if err == "" && start+length > limit {
+			err = errTruncated
+			node.Length = limit - start
+		}
+		if err != "" {
+			node.Error = err
+			return withPrefix(prefix, node), limit, true
+		}
+		node.Value = formatBytes(d.data[start:start+length], f.Kind == KindString)
+		return withPrefix(prefix, node), start + length, false
+
+	case KindCString:
+		node := &Node{Name: f.Name, Type: typeName, Offset: pos}
+		end := pos
+		for end < limit && d.data[end] != 0 {
+			end++
+		}
+		if end >= limit {
+			node.Length, node.Error = limit-pos, "truncated: no terminating NUL"
+			return []*Node{node}, limit, true
+		}
+		node.Length = end + 1 - pos
+		node.Value = strconv.Quote(string(d.data[pos:end]))
+		return []*Node{node}, end + 1, false
+
+	case KindMessage, KindTLV:
+		// A field with a length in bytes is read from a region ending
+		// there, and the message continues after it
+		var nodes []*Node
+		region := -1
+		if f.Length != nil {
+			length, start, err := d.size(f.Length, pos, limit, values)
+			if f.Length.Prefix != nil {
+				prefix, _ := d.integer(f.Name+"_length", f.Length.Prefix.String(), *f.Length.Prefix, pos, limit)
+				if err == "" {
+					prefix.Value = strconv.Itoa(length)
+				}
+				nodes = append(nodes, prefix)
+			}
+			if err == "" && start+length > limit {
+				err = errTruncated
+			}
+			if err != "" {
+				nodes = append(nodes, &Node{Name: f.Name, Type: typeName, Offset: start, Error: err})
+				return nodes, limit, true
+			}
+			pos, limit, region = start, start+length, start+length
+		}
+
+		count, start, err := d.size(f.Size, pos, limit, values)
+		if f.Size != nil && f.Size.Prefix != nil {
+			prefix, _ := d.integer(f.Name+"_count", f.Size.Prefix.String(), *f.Size.Prefix, pos, limit)
+			if err == "" {
+				prefix.Value = strconv.Itoa(count)
+			}
+			nodes = append(nodes, prefix)
+		}
+		if err != "" {
+			nodes = append(nodes, &Node{Name: f.Name, Type: typeName, Offset: start, Error: err})
+			return nodes, limit, true
+		}
+		if f.Size == nil {
+			count = 1
+		}
+		rest := f.Size != nil && f.Size.Rest
+		at := start
+		for i := 0; rest && at < limit || !rest && i < count; i++ {
+			var node *Node
+			if f.Kind == KindMessage {
+				node = d.message(f.Message, at, limit)
+			} else {
+				node = d.record(f.TLV, at, limit)
+			}
+			node.Name = f.Name
+			if f.Size != nil {
+				node.Name = fmt.Sprintf("%s[%d]", f.Name, i)
+			}
+			nodes = append(nodes, node)
+			if node.Error != "" {
+				return nodes, limit, true
+			}
+			if node.Length == 0 {
+				// An empty element would repeat forever
+				break
+			}
+			at += node.Length
+		}
+		if region >= 0 {
+			return nodes, region, false
+		}
+		return nodes, at, false
+	}
+	return nil, pos, true
+}
+
+// withPrefix returns a field's nodes, led by its length prefix if any
+func withPrefix(prefix, node *Node) []*Node {
+	if prefix == nil {
+		return []*Node{node}
+	}
+	return []*Node{prefix, node}
+}
+
+// integer makes the node of an integer, reporting truncation
+func (d *decoder) integer(name, typeName string, t IntType, pos, limit int) (*Node, bool) {
+	node := &Node{Name: name, Type: typeName, Offset: pos, Length: t.Size}
+	if pos+t.Size > limit {
+		node.Length = limit - pos
+		node.Error = errTruncated
+		return node, false
+	}
+	return node, true
+}
+
+// size computes the length or count of a field.
+//
+// Returns:
+//   - The length or count; for fields taking the rest of the message, the
+//     number of bytes left
+//   - The offset the field's data starts at, after any prefix
+//   - An error message, or ""
+func (d *decoder) size(s *Size, pos, limit int, values map[string]int64) (int, int, string) {
+	switch {
+	case s == nil:
+		return limit - pos, pos, ""
+	case s.Rest:
+		return limit - pos, pos, ""
+	case s.Prefix != nil:
+		if pos+s.Prefix.Size > limit {
+			return 0, pos, errTruncated
+		}
+		n := d.readInt(*s.Prefix, pos)
+		if n < 0 {
+			return 0, pos + s.Prefix.Size, fmt.Sprintf("negative size %d", n)
+		}
+		return int(n), pos + s.Prefix.Size, ""
+	}
+	n := eval(s.Expr, values)
+	if n < 0 || n > int64(len(d.data)) {
+		return 0, pos, fmt.Sprintf("invalid size %s = %d", s.Expr, n)
+	}
+	return int(n), pos, ""
+}
+
+// eval computes an expression from the values of earlier fields
+func eval(e *Expr, values map[string]int64) int64 {
+	switch e.Op {
+	case '+':
+		return eval(e.Left, values) + eval(e.Right, values)
+	case '-':
+		return eval(e.Left, values) - eval(e.Right, values)
+	case '*':
+		return eval(e.Left, values) * eval(e.Right, values)
+	}
+	if e.Field != "" {
+		return values[e.Field]
+	}
+	return e.Value
+}
+
+// record decodes a TLV record
+func (d *decoder) record(tlv *TLV, pos, limit int) *Node {
+	node := &Node{Type: "tlv", Offset: pos}
+	tagNode, ok := d.integer("tag", tlv.Tag.String(), tlv.Tag, pos, limit)
+	node.Children = append(node.Children, tagNode)
+	if !ok {
+		node.Length, node.Error = limit-pos, errTruncated
+		return node
+	}
+	tag := d.readInt(tlv.Tag, pos)
+	tagNode.Value = formatInt(tag, tlv.Tag, tlv.TagEnum)
+
+	lengthAt := pos + tlv.Tag.Size
+	lengthNode, ok := d.integer("length", tlv.Length.String(), tlv.Length, lengthAt, limit)
+	node.Children = append(node.Children, lengthNode)
+	if !ok {
+		node.Length, node.Error = limit-pos, errTruncated
+		return node
+	}
+	length := d.readInt(tlv.Length, lengthAt)
+	lengthNode.Value = strconv.FormatInt(length, 10)
+
+	valueAt := lengthAt + tlv.Length.Size
+	node.Length = valueAt - pos + int(max(length, 0))
+	if length < 0 || valueAt+int(length) > limit {
+		node.Length, node.Error = limit-pos, errTruncated
+		return node
+	}
+	end := valueAt + int(length)
+
+	var value *Field
+	for _, c := range tlv.Cases {
+		if c.Tag == tag {
+			value = c.Field
+		}
+	}
+	if value == nil {
+		value = &Field{Name: "value", Kind: KindBytes}
+	}
+	children, next, _ := d.field(value, valueAt, end, make(map[string]int64))
+	node.Children = append(node.Children, children...)
+	for _, child := range children {
+		if child.Error != "" {
+			node.Error = "invalid value"
+		}
+	}
+	if node.Error == "" && next < end {
+		node.Error = fmt.Sprintf("%d bytes after the value", end-next)
+	}
+	if name := tlv.tagName(tag); name != "" {
+		node.Value = name
+	}
+	return node
+}
+
+// tagName names a TLV tag after its case or enum constant
+func (tlv *TLV) tagName(tag int64) string {
+	for _, c := range tlv.Cases {
+		if c.Tag == tag {
+			return c.Field.Name
+		}
+	}
+	if tlv.TagEnum != nil {
+		return tlv.TagEnum.ValueName(tag)
+	}
+	return ""
+}
+
+// readInt reads an integer, sign extending signed types
+func (d *decoder) readInt(t IntType, pos int) int64 {
+	var v uint64
+	b := d.data[pos : pos+t.Size]
+	for i := range b {
+		if t.bigEndian(d.spec) {
+			v = v<<8 | uint64(b[i])
+		} else {
+			v |= uint64(b[i]) << (8 * i)
+		}
+	}
+	if t.Signed && t.Size < 8 && v&(1<<(8*t.Size-1)) != 0 {
+		v |= ^uint64(0) << (8 * t.Size)
+	}
+	return int64(v)
+}
+
+// checkChecksum compares a checksum field with the checksum of its bytes
+func checkChecksum(node *Node, f *Field, covered []byte, value int64) {
+	mask := ^uint64(0)
+	if f.Int.Size < 8 {
+		mask = 1<<(8*f.Int.Size) - 1
+	}
+	want := checksums[f.Checksum](covered) & mask
+	if uint64(value)&mask == want {
+		node.Value += " [" + f.Checksum + " correct]"
+		return
+	}
+	node.Error = fmt.Sprintf("%s incorrect, should be %s", f.Checksum, formatHex(int64(want), f.Int))
+}
+
+// fieldType writes the type of a field as in the definition
+func fieldType(f *Field) string {
+	var name string
+	switch f.Kind {
+	case KindInt:
+		name = f.Int.String()
+	case KindBytes:
+		name = "bytes"
+	case KindString:
+		name = "string"
+	case KindCString:
+		name = "cstring"
+	case KindMessage:
+		name = f.Message.Name
+	case KindTLV:
+		name = fmt.Sprintf("tlv(%s, %s)", f.TLV.Tag, f.TLV.Length)
+	}
+	if f.Size != nil {
+		name += "[" + f.Size.String() + "]"
+	}
+	if f.Length != nil {
+		name += " size " + f.Length.String()
+	}
+	return name
+}
+
+// formatInt shows an integer in decimal and hex, with its enum name
+func formatInt(v int64, t IntType, e *Enum) string {
+	s := strconv.FormatInt(v, 10)
+	if !t.Signed && v < 0 {
+		s = strconv.FormatUint(uint64(v), 10)
+	}
+	s += " (" + formatHex(v, t) + ")"
+	if e != nil {
+		if name := e.ValueName(v); name != "" {
+			s = name + " = " + s
+		} else {
+			s += " [unknown " + e.Name + "]"
+		}
+	}
+	return s
+}
+
+// formatHex shows an integer in hex with the digits of its type's size
+func formatHex(v int64, t IntType) string {
+	u := uint64(v)
+	if t.Size < 8 {
+		u &= 1<<(8*t.Size) - 1
+	}
+	return fmt.Sprintf("0x%0*x", 2*t.Size, u)
+}
+
+// formatBytes shows bytes in hex, or as a quoted string for string fields
+// holding valid UTF-8
+func formatBytes(b []byte, text bool) string {
+	if text && utf8.Valid(b) {
+		return strconv.Quote(string(b))
+	}
+	var parts []string
+	for i, c := range b {
+		if i == maxShown {
+			parts = append(parts, fmt.Sprintf("... (%d bytes)", len(b)))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%02x", c))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Format writes a field tree as indented text.
+//
+// Returns:
+//   - One line per node with its offset, length, name, type and value
+func (n *Node) Format() string {
+	var b strings.Builder
+	var walk func(n *Node, depth int)
+	walk = func(n *Node, depth int) {
+		fmt.Fprintf(&b, "%04x  %4d  %s%s: %s", n.Offset, n.Length, strings.Repeat("  ", depth), n.Name, n.Type)
+		if n.Value != "" {
+			b.WriteString(" = " + n.Value)
+		}
+		if n.Error != "" {
+			b.WriteString("  [!] " + n.Error)
+		}
+		b.WriteByte('\n')
+		for _, child := range n.Children {
+			walk(child, depth+1)
+		}
+	}
+	walk(n, 0)
+	return b.String()
+}
+
+// ParseHex parses a sample message written in hex. Bytes may be separated
+// by spaces, colons, commas or dashes, and written with 0x or \x prefixes,
+// as in "de ad be ef", "de:ad:be:ef", "0xde, 0xad" or "\xde\xad".
+//
+// Returns:
+//   - The bytes
+//   - An error naming the first character that is not hex
+func ParseHex(text string) ([]byte, error) {
+	replacer := strings.NewReplacer("0x", " ", "0X", " ", "\\x", " ", ":", " ", ",", " ", "-", " ", "\"", " ")
+	var out []byte
+	for _, group := range strings.Fields(replacer.Replace(text)) {
+		if len(group)%2 != 0 {
+			if len(group) != 1 {
+				return nil, fmt.Errorf("odd number of hex digits in %q", group)
+			}
+			group = "0" + group
+		}
+		for i := 0; i < len(group); i += 2 {
+			v, err := strconv.ParseUint(group[i:i+2], 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not hex", group[i:i+2])
+			}
+			out = append(out, byte(v))
+		}
+	}
+	return out, nil
+}
+
+// Problems lists the errors found in a field tree. Errors of messages and
+// records that only report a problem of their fields are left out.
+//
+// Returns:
+//   - One "path: error" line per problem, such as
+//     "packet.crc: crc16 incorrect, should be 0x1d0f", in tree order
+func (n *Node) Problems() []string {
+	var problems []string
+	var walk func(n *Node, path string)
+	walk = func(n *Node, path string) {
+		before := len(problems)
+		for _, child := range n.Children {
+			walk(child, path+"."+child.Name)
+		}
+		if n.Error != "" && len(problems) == before {
+			problems = append(problems, path+": "+n.Error)
+		}
+	}
+	walk(n, n.Name)
+	return problems
+}
+
+// FormatHex writes bytes in hex as ParseHex reads them, 16 to a line
+func FormatHex(data []byte) string {
+	var lines []string
+	for start := 0; start < len(data); start += 16 {
+		var parts []string
+		for _, b := range data[start:min(start+16, len(data))] {
+			parts = append(parts, fmt.Sprintf("%02x", b))
+		}
+		lines = append(lines, strings.Join(parts, " "))
+	}
+	return strings.Join(lines, "\n")
+}
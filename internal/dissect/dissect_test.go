@@ -0,0 +1,377 @@
+package dissect
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// acme is the definition of the package documentation
+const acme = `protocol acme "ACME telemetry"
+endian big
+
+enum msg_type u8 {
+    HELLO = 1
+    DATA = 2
+}
+
+message packet {
+    magic   u32 = 0xa5a5a5a5
+    type    u8 enum msg_type
+    length  u16
+    payload bytes[length - 2]
+    olen    u8
+    options tlv(u8, u8)[*] size olen {
+        1: mss u16
+        2: host string
+    }
+    crc     u16 checksum crc16 from magic
+}
+`
+
+// frame exercises little-endian integers, byte order overrides, prefixed
+// counts, nested messages and C strings
+const frame = `protocol tiny
+endian little
+
+message frame {
+    id     u16
+    seq    u32be
+    name   cstring
+    items  item[u8]
+    rest   bytes[*]
+}
+
+message item {
+    kind  u8
+    value i16
+}
+`
+
+// mustParse parses a definition, failing the test on errors
+func mustParse(t *testing.T, src string) *Spec {
+	t.Helper()
+	spec, err := Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+// mustHex parses a hex sample, failing the test on errors
+func mustHex(t *testing.T, text string) []byte {
+	t.Helper()
+	data, err := ParseHex(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// withCRC appends the big-endian crc16 of a sample
+func withCRC(data []byte) []byte {
+	crc := checksums["crc16"](data)
+	return append(data, byte(crc>>8), byte(crc))
+}
+
+// values maps the dotted paths of a field tree to their values
+func values(n *Node) map[string]string {
+	out := make(map[string]string)
+	var walk func(n *Node, path string)
+	walk = func(n *Node, path string) {
+		out[path] = n.Value
+		for _, child := range n.Children {
+			walk(child, path+"."+child.Name)
+		}
+	}
+	walk(n, n.Name)
+	return out
+}
+
+func TestDecode(t *testing.T) {
+	spec := mustParse(t, acme)
+	message := "a5a5a5a5 02 0005 aabbcc 09 0102 05dc 0203 616263"
+	valid := withCRC(mustHex(t, message))
+
+	tests := []struct {
+		name     string
+		data     []byte
+		values   map[string]string
+		problems []string
+	}{
+		{
+			name: "valid",
+			data: valid,
+			values: map[string]string{
+				"packet.magic":             "2779096485 (0xa5a5a5a5)",
+				"packet.type":              "DATA = 2 (0x02)",
+				"packet.payload":           "aa bb cc",
+				"packet.options[0].mss":    "1500 (0x05dc)",
+				"packet.options[1]":        "host",
+				"packet.options[1].host":   `"abc"`,
+				"packet.options[1].length": "3",
+				"packet.crc":               fmt.Sprintf("%d (0x%02x%02x) [crc16 correct]", int(valid[20])<<8|int(valid[21]), valid[20], valid[21]),
+				"packet.options[0].tag":    "1 (0x01)",
+				"packet.options[1].tag":    "2 (0x02)",
+				"packet.options[0].length": "2",
+				"packet.olen":              "9 (0x09)",
+				"packet.length":            "5 (0x0005)",
+			},
+		},
+		{
+			name:     "wrong checksum",
+			data:     append(mustHex(t, message), 0, 0),
+			problems: []string{fmt.Sprintf("packet.crc: crc16 incorrect, should be 0x%02x%02x", valid[20], valid[21])},
+		},
+		{
+			name:     "wrong magic",
+			data:     withCRC(mustHex(t, "a5a5a5a6 02 0005 aabbcc 09 0102 05dc 0203 616263")),
+			problems: []string{"packet.magic: expected 0xa5a5a5a5"},
+		},
+		{
+			name:     "truncated",
+			data:     valid[:9],
+			problems: []string{"packet.payload: " + errTruncated},
+		},
+		{
+			name:     "trailing bytes",
+			data:     append(slices.Clone(valid), 0xee, 0xff),
+			problems: []string{"packet: 2 bytes after the end of the message"},
+		},
+		{
+			name:   "unknown tag",
+			data:   withCRC(mustHex(t, "a5a5a5a5 01 0002 05 07 03 070707")),
+			values: map[string]string{"packet.type": "HELLO = 1 (0x01)", "packet.options[0].value": "07 07 07"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := Decode(spec, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := n.Problems(); !slices.Equal(got, tt.problems) {
+				t.Errorf("problems %q; want %q\n%s", got, tt.problems, n.Format())
+			}
+			got := values(n)
+			for path, want := range tt.values {
+				if got[path] != want {
+					t.Errorf("%s = %q; want %q\n%s", path, got[path], want, n.Format())
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeByteOrders(t *testing.T) {
+	spec := mustParse(t, frame)
+	data := mustHex(t, "01 02  00 00 00 05  68 69 00  02 01 ff ff 02 10 00  de ad")
+	n, err := Decode(spec, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := n.Problems(); len(problems) != 0 {
+		t.Fatalf("problems %q\n%s", problems, n.Format())
+	}
+	want := map[string]string{
+		"frame.id":             "513 (0x0201)",
+		"frame.seq":            "5 (0x00000005)",
+		"frame.name":           `"hi"`,
+		"frame.items_count":    "2",
+		"frame.items[0].kind":  "1 (0x01)",
+		"frame.items[0].value": "-1 (0xffff)",
+		"frame.items[1].value": "16 (0x0010)",
+		"frame.rest":           "de ad",
+	}
+	got := values(n)
+	for path, value := range want {
+		if got[path] != value {
+			t.Errorf("%s = %q; want %q\n%s", path, got[path], value, n.Format())
+		}
+	}
+	if n.Length != len(data) {
+		t.Errorf("message of %d bytes; want %d", n.Length, len(data))
+	}
+}
+
+func TestHexRoundTrip(t *testing.T) {
+	want := []byte{0xde, 0xad, 0xbe, 0xef, 0x00, 0x0a}
+	for _, text := range []string{
+		"deadbeef000a",
+		"de ad be ef 00 0a",
+		"de:ad:be:ef:00:0a",
+		"de-ad-be-ef-00-0a",
+		"0xde, 0xad, 0xbe, 0xef, 0x00, 0x0a",
+		`"\xde\xad\xbe\xef\x00\x0a"`,
+		"DEAD BEEF 0 a",
+	} {
+		got, err := ParseHex(text)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("ParseHex(%q) = %x, %v; want %x", text, got, err, want)
+		}
+	}
+
+	data := make([]byte, 40)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	text := FormatHex(data)
+	if lines := strings.Count(text, "\n") + 1; lines != 3 {
+		t.Errorf("%d lines for 40 bytes; want 3", lines)
+	}
+	if got, err := ParseHex(text); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("ParseHex(FormatHex(data)) = %x, %v; want %x", got, err, data)
+	}
+
+	for _, text := range []string{"abc", "zz", "0xg1"} {
+		if _, err := ParseHex(text); err == nil {
+			t.Errorf("ParseHex(%q) succeeded", text)
+		}
+	}
+}
+
+func TestSizeRoundTrip(t *testing.T) {
+	// Sizes are written back as the parser reads them
+	for _, size := range []string{
+		"*",
+		"u16be",
+		"16",
+		"a",
+		"a - 2",
+		"a - b - c",
+		"a - (b - c)",
+		"(a + 1) * (b - 2)",
+		"a * b + c * 2",
+		"a + b * c",
+	} {
+		src := fmt.Sprintf("message m {\n a u8\n b u8\n c u8\n data bytes[%s]\n}\n", size)
+		spec, err := Parse(src)
+		if err != nil {
+			t.Errorf("%s: %v", size, err)
+			continue
+		}
+		got := spec.Root().Fields[3].Size.String()
+		if got != size {
+			t.Errorf("size %s written as %s", size, got)
+		}
+	}
+
+	for _, name := range []string{"u8", "i8", "u16", "i24le", "u32be", "i64"} {
+		typ, ok := parseIntType(name)
+		if !ok || typ.String() != name {
+			t.Errorf("integer type %s written as %s", name, typ)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src, err string
+	}{
+		{"message m {\n data bytes\n}", "line 2: bytes fields need a size"},
+		{"message m {\n data bytes[n]\n n u8\n}", `line 2: "n" is not an earlier field`},
+		{"message m {\n c u8 checksum md5\n}", `line 2: unknown checksum "md5"`},
+		{"message m {\n inner m\n}", "contains itself"},
+		{"message m {\n inner other\n}", "other"},
+		{"enum message u8 {\n A = 1\n}", `line 1: "message" is a keyword`},
+		{"message u16 {\n a u8\n}", `"u16" is an integer type`},
+		{"message m {\n a u8 = 1\n s string[a] = 2\n}", "line 3: only integer fields can have an expected value"},
+		{"endian middle", "line 1: expected big or little"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: error %v; want one containing %q", tt.src, err, tt.err)
+		}
+	}
+}
+
+func TestExportKaitai(t *testing.T) {
+	spec := mustParse(t, acme)
+	out, err := Export(spec, FormatKaitai, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ksy struct {
+		Meta struct {
+			ID     string `yaml:"id"`
+			Endian string `yaml:"endian"`
+		} `yaml:"meta"`
+		Seq   []map[string]interface{} `yaml:"seq"`
+		Types map[string]struct {
+			Seq []map[string]interface{} `yaml:"seq"`
+		} `yaml:"types"`
+		Enums map[string]map[int]string `yaml:"enums"`
+	}
+	if err := yaml.Unmarshal([]byte(out), &ksy); err != nil {
+		t.Fatalf("the specification is not YAML: %v\n%s", err, out)
+	}
+	if ksy.Meta.ID != "acme" || ksy.Meta.Endian != "be" {
+		t.Errorf("meta %+v; want id acme, endian be", ksy.Meta)
+	}
+	if len(ksy.Seq) != 1 || ksy.Seq[0]["type"] != "packet" {
+		t.Errorf("top-level seq %v; want the packet message", ksy.Seq)
+	}
+
+	// Every field of the message is read in order
+	var ids []string
+	for _, attr := range ksy.Types["packet"].Seq {
+		ids = append(ids, fmt.Sprint(attr["id"]))
+	}
+	var fields []string
+	for _, f := range spec.Root().Fields {
+		fields = append(fields, f.Name)
+	}
+	if !slices.Equal(ids, fields) {
+		t.Errorf("packet reads %v; want %v", ids, fields)
+	}
+	for _, name := range []string{"packet_options", "packet_options_mss", "packet_options_host"} {
+		if _, ok := ksy.Types[name]; !ok {
+			t.Errorf("no type %s", name)
+		}
+	}
+	if want := map[int]string{1: "hello", 2: "data"}; fmt.Sprint(ksy.Enums["msg_type"]) != fmt.Sprint(want) {
+		t.Errorf("enum msg_type %v; want %v", ksy.Enums["msg_type"], want)
+	}
+}
+
+func TestExportLua(t *testing.T) {
+	spec := mustParse(t, acme)
+	out, err := Export(spec, FormatLua, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every field is declared, and every declared field is used
+	declared := make(map[string]bool)
+	for _, m := range regexp.MustCompile(`(?m)^    (\w+) = ProtoField\.`).FindAllStringSubmatch(out, -1) {
+		declared[m[1]] = true
+	}
+	used := make(map[string]bool)
+	for _, m := range regexp.MustCompile(`hf\.(\w+)`).FindAllStringSubmatch(out, -1) {
+		used[m[1]] = true
+		if !declared[m[1]] {
+			t.Errorf("hf.%s is used but not declared", m[1])
+		}
+	}
+	for name := range declared {
+		if !used[name] {
+			t.Errorf("hf.%s is declared but not used", name)
+		}
+	}
+	for _, f := range spec.Root().Fields {
+		if f.Kind != KindTLV && !declared["packet_"+f.Name] {
+			t.Errorf("field %s is not declared", f.Name)
+		}
+	}
+	for _, text := range []string{`Proto("acme", "ACME telemetry")`, `[2] = "DATA"`, "0xa5a5a5a5", "crc16 from magic"} {
+		if !strings.Contains(out, text) {
+			t.Errorf("the dissector does not contain %s", text)
+		}
+	}
+}
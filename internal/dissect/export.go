@@ -0,0 +1,108 @@
+package dissect
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Export formats
+const (
+	// FormatLua is a Wireshark dissector plugin in Lua
+	FormatLua = "lua"
+
+	// FormatKaitai is a Kaitai Struct specification, which
+	// kaitai-struct-compiler turns into Python and other languages
+	FormatKaitai = "kaitai"
+)
+
+// ExportFormats lists the export formats
+var ExportFormats = []string{FormatLua, FormatKaitai}
+
+// FormatExtension returns the file extension for an export format
+func FormatExtension(format string) string {
+	if format == FormatKaitai {
+		return ".ksy"
+	}
+	return ".lua"
+}
+
+// Options are details of the protocol that definitions do not hold
+type Options struct {
+	// Transport and Port register a Wireshark dissector on a TCP or UDP
+	// port, such as "tcp" and 8443
+	Transport string
+	Port      uint16
+}
+
+// Export generates a dissector from a definition.
+//
+// Parameters:
+//   - spec: The protocol definition
+//   - format: One of ExportFormats
+//   - opts: Details of the protocol used by generated code
+//
+// Returns:
+//   - The generated source
+//   - An error if the format is unknown or the definition has no message
+func Export(spec *Spec, format string, opts Options) (string, error) {
+	if spec.Root() == nil {
+		return "", fmt.Errorf("the definition has no message")
+	}
+	switch format {
+	case FormatLua:
+		return exportLua(spec, opts), nil
+	case FormatKaitai:
+		return exportKaitai(spec), nil
+	}
+	return "", fmt.Errorf("unknown export format %q (supported: %s)", format, strings.Join(ExportFormats, ", "))
+}
+
+// nonIdentifier matches runs of characters that cannot appear in
+// identifiers of generated code
+var nonIdentifier = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// snake converts a name to lower snake case, such as "msgType" to
+// "msg_type", as Kaitai identifiers and Wireshark filter names require
+func snake(name string) string {
+	var b strings.Builder
+	for i, c := range name {
+		if c >= 'A' && c <= 'Z' {
+			if i > 0 && name[i-1] != '_' && !(name[i-1] >= 'A' && name[i-1] <= 'Z') {
+				b.WriteByte('_')
+			}
+			c += 'a' - 'A'
+		}
+		b.WriteRune(c)
+	}
+	s := strings.Trim(nonIdentifier.ReplaceAllString(b.String(), "_"), "_")
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		s = "f_" + s
+	}
+	return s
+}
+
+// protocolName returns the name generated code uses for the protocol
+func protocolName(spec *Spec) string {
+	if spec.Name == "" {
+		return "proto"
+	}
+	return snake(spec.Name)
+}
+
+// protocolTitle returns the protocol's display name
+func protocolTitle(spec *Spec) string {
+	if spec.Description != "" {
+		return spec.Description
+	}
+	return strings.ToUpper(protocolName(spec))
+}
+
+// hexLiteral writes an integer as a hex literal, with a minus sign for
+// negative values
+func hexLiteral(v int64) string {
+	if v < 0 {
+		return fmt.Sprintf("-0x%x", -v)
+	}
+	return fmt.Sprintf("0x%x", v)
+}
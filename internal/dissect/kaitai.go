@@ -0,0 +1,250 @@
+package dissect
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ksyWriter generates a Kaitai Struct specification. Messages become
+// types; TLV fields become a type per record with a type per case.
+type ksyWriter struct {
+	spec  *Spec
+	types strings.Builder
+
+	// regions are the repeated fields with a length in bytes; each is
+	// read through a type holding its elements
+	regions []region
+
+	// scope prefixes the fields expressions refer to, "_parent." while
+	// writing the type of a region
+	scope string
+}
+
+// region is a repeated message or TLV field with a length in bytes
+type region struct {
+	message *Message
+	field   *Field
+}
+
+// regionType names the type holding the elements of a region
+func (r region) regionType() string {
+	return snake(r.message.Name) + "_" + snake(r.field.Name) + "_region"
+}
+
+// exportKaitai generates a Kaitai Struct specification
+func exportKaitai(spec *Spec) string {
+	w := &ksyWriter{spec: spec}
+	for _, m := range spec.Messages {
+		w.typeHeader(snake(m.Name), "message "+m.Name)
+		for _, f := range m.Fields {
+			w.attribute(m, f)
+		}
+	}
+	for _, r := range w.regions {
+		w.typeHeader(r.regionType(), "the elements of "+r.field.Name)
+		elements := *r.field
+		elements.Length = nil
+		w.scope = "_parent."
+		w.attribute(r.message, &elements)
+		w.scope = ""
+	}
+	for _, m := range spec.Messages {
+		for _, f := range m.Fields {
+			if f.Kind == KindTLV {
+				w.tlv(snake(m.Name)+"_"+snake(f.Name), f)
+			}
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Kaitai Struct specification of %s, generated by RevEnGo.\n", protocolTitle(spec))
+	fmt.Fprintf(&b, "# Compile it to Python with: kaitai-struct-compiler -t python %s.ksy\n", protocolName(spec))
+	b.WriteString("meta:\n")
+	fmt.Fprintf(&b, "  id: %s\n", protocolName(spec))
+	fmt.Fprintf(&b, "  title: %s\n", strconv.Quote(protocolTitle(spec)))
+	if spec.BigEndian {
+		b.WriteString("  endian: be\n")
+	} else {
+		b.WriteString("  endian: le\n")
+	}
+	root := spec.Root()
+	b.WriteString("seq:\n")
+	fmt.Fprintf(&b, "  - id: %s\n", snake(root.Name))
+	fmt.Fprintf(&b, "    type: %s\n", snake(root.Name))
+	b.WriteString("types:\n")
+	b.WriteString(w.types.String())
+	if len(spec.Enums) > 0 {
+		b.WriteString("enums:\n")
+		for _, e := range spec.Enums {
+			fmt.Fprintf(&b, "  %s:\n", snake(e.Name))
+			for _, v := range e.Values {
+				fmt.Fprintf(&b, "    %d: %s\n", v.Value, snake(v.Name))
+			}
+		}
+	}
+	return b.String()
+}
+
+// typeHeader starts a type
+func (w *ksyWriter) typeHeader(name, doc string) {
+	fmt.Fprintf(&w.types, "  %s:\n", name)
+	fmt.Fprintf(&w.types, "    doc: %s\n", strconv.Quote(doc))
+	w.types.WriteString("    seq:\n")
+}
+
+// item writes one attribute of a type's seq from key, value pairs
+func (w *ksyWriter) item(pairs ...string) {
+	for i := 0; i < len(pairs); i += 2 {
+		lead := "        "
+		if i == 0 {
+			lead = "      - "
+		}
+		fmt.Fprintf(&w.types, "%s%s: %s\n", lead, pairs[i], pairs[i+1])
+	}
+}
+
+// intType names an integer type in Kaitai. Kaitai has no 24-bit integers,
+// so they are read as unsigned bit-sized integers.
+func (w *ksyWriter) intType(t IntType) string {
+	order := "le"
+	if t.bigEndian(w.spec) {
+		order = "be"
+	}
+	if t.Size == 3 {
+		return "b24" + order
+	}
+	kind := "u"
+	if t.Signed {
+		kind = "s"
+	}
+	if t.Size == 1 {
+		return fmt.Sprintf("%s1", kind)
+	}
+	return fmt.Sprintf("%s%d%s", kind, t.Size, order)
+}
+
+// ref names a field in an expression
+func (w *ksyWriter) ref(field string) string {
+	return w.scope + snake(field)
+}
+
+// attribute writes a message field; m is nil for TLV values
+func (w *ksyWriter) attribute(m *Message, f *Field) {
+	id := snake(f.Name)
+	var pairs []string
+
+	// A repeated field with a length in bytes is a single attribute of a
+	// type holding the elements, since Kaitai sizes apply to each element
+	var length string
+	if f.Length != nil {
+		length = id + "_length"
+		if f.Length.Prefix != nil {
+			w.item("id", length, "type", w.intType(*f.Length.Prefix))
+		} else {
+			length = f.Length.Expr.format(w.ref)
+		}
+		if f.Size != nil {
+			r := region{m, f}
+			w.regions = append(w.regions, r)
+			w.item("id", id, "type", r.regionType(), "size", length)
+			return
+		}
+	}
+
+	// sized adds the attributes giving a length or count, writing a
+	// length or count prefix as an attribute of its own
+	sized := func(lengthKey, suffix string) {
+		switch {
+		case f.Size == nil || f.Size.Rest:
+			if lengthKey == "size" {
+				pairs = append(pairs, "size-eos", "true")
+			} else {
+				pairs = append(pairs, "repeat", "eos")
+			}
+		case f.Size.Prefix != nil:
+			w.item("id", id+suffix, "type", w.intType(*f.Size.Prefix))
+			if lengthKey == "size" {
+				pairs = append(pairs, "size", id+suffix)
+			} else {
+				pairs = append(pairs, "repeat", "expr", "repeat-expr", id+suffix)
+			}
+		default:
+			expr := f.Size.Expr.format(w.ref)
+			if lengthKey == "size" {
+				pairs = append(pairs, "size", expr)
+			} else {
+				pairs = append(pairs, "repeat", "expr", "repeat-expr", expr)
+			}
+		}
+	}
+
+	switch f.Kind {
+	case KindInt:
+		pairs = append(pairs, "type", w.intType(f.Int))
+		if f.Enum != nil {
+			pairs = append(pairs, "enum", snake(f.Enum.Name))
+		} else if f.Expect != nil {
+			pairs = append(pairs, "valid", hexLiteral(*f.Expect))
+		}
+		if f.Checksum != "" {
+			from := "the start of " + m.Name
+			if f.ChecksumFrom != "" {
+				from = f.ChecksumFrom
+			}
+			pairs = append(pairs, "doc", strconv.Quote(fmt.Sprintf("%s of the bytes from %s up to this field", f.Checksum, from)))
+		}
+	case KindBytes:
+		sized("size", "_length")
+	case KindString:
+		pairs = append(pairs, "type", "str", "encoding", "UTF-8")
+		sized("size", "_length")
+	case KindCString:
+		pairs = append(pairs, "type", "strz", "encoding", "UTF-8")
+	case KindMessage:
+		pairs = append(pairs, "type", snake(f.Message.Name))
+		if f.Size != nil {
+			sized("repeat", "_count")
+		}
+	case KindTLV:
+		pairs = append(pairs, "type", snake(m.Name)+"_"+id)
+		if f.Size != nil {
+			sized("repeat", "_count")
+		}
+	}
+	if length != "" {
+		pairs = append(pairs, "size", length)
+	}
+	w.item(append([]string{"id", id}, pairs...)...)
+}
+
+// tlv writes the type of one record of a TLV field, and a type for the
+// value of each case
+func (w *ksyWriter) tlv(name string, f *Field) {
+	t := f.TLV
+	w.typeHeader(name, "one record of "+f.Name)
+	tag := []string{"id", "tag", "type", w.intType(t.Tag)}
+	switchOn := "tag"
+	if t.TagEnum != nil {
+		tag = append(tag, "enum", snake(t.TagEnum.Name))
+		switchOn = "tag.to_i"
+	}
+	w.item(tag...)
+	w.item("id", "length", "type", w.intType(t.Length))
+	w.item("id", "value", "size", "length")
+	if len(t.Cases) > 0 {
+		w.types.WriteString("        type:\n")
+		fmt.Fprintf(&w.types, "          switch-on: %s\n", switchOn)
+		w.types.WriteString("          cases:\n")
+		for _, c := range t.Cases {
+			fmt.Fprintf(&w.types, "            %d: %s_%s\n", c.Tag, name, snake(c.Field.Name))
+		}
+	}
+
+	// Each value is read from a substream of the record's length, so
+	// unsized values take all of it
+	for _, c := range t.Cases {
+		w.typeHeader(name+"_"+snake(c.Field.Name), fmt.Sprintf("value of %s records with tag %d", f.Name, c.Tag))
+		w.attribute(nil, c.Field)
+	}
+}
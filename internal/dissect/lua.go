@@ -0,0 +1,286 @@
+package dissect
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// luaWriter generates a Wireshark Lua dissector. Every message becomes a
+// function that adds a subtree and returns the offset after the message;
+// every TLV field becomes a function decoding one record.
+type luaWriter struct {
+	spec  *Spec
+	proto string
+	b     strings.Builder
+
+	// fields are the ProtoField declarations in order
+	fields []string
+}
+
+// exportLua generates a Wireshark dissector plugin
+func exportLua(spec *Spec, opts Options) string {
+	w := &luaWriter{spec: spec, proto: protocolName(spec)}
+	var body strings.Builder
+	var funcs []string
+	for _, m := range spec.Messages {
+		funcs = append(funcs, "dissect_"+snake(m.Name))
+		for _, f := range m.Fields {
+			if f.Kind == KindTLV {
+				funcs = append(funcs, "dissect_"+snake(m.Name)+"_"+snake(f.Name))
+			}
+		}
+	}
+	for _, m := range spec.Messages {
+		w.message(&body, m)
+	}
+
+	b := &w.b
+	fmt.Fprintf(b, "-- Wireshark dissector for %s, generated by RevEnGo.\n", protocolTitle(spec))
+	b.WriteString("-- Copy it to the Lua plugins folder shown in Help > About Wireshark > Folders.\n\n")
+	fmt.Fprintf(b, "local %s = Proto(%q, %q)\n\n", w.proto, w.proto, protocolTitle(spec))
+	for _, e := range spec.Enums {
+		fmt.Fprintf(b, "local %s_names = {\n", snake(e.Name))
+		for _, v := range e.Values {
+			fmt.Fprintf(b, "    [%d] = %q,\n", v.Value, v.Name)
+		}
+		b.WriteString("}\n\n")
+	}
+	b.WriteString("local hf = {\n")
+	for _, f := range w.fields {
+		b.WriteString("    " + f + ",\n")
+	}
+	b.WriteString("}\n")
+	fmt.Fprintf(b, "%s.fields = hf\n\n", w.proto)
+	fmt.Fprintf(b, "local %s\n\n", strings.Join(funcs, ", "))
+	b.WriteString(body.String())
+
+	root := spec.Root()
+	fmt.Fprintf(b, "function %s.dissector(buf, pinfo, tree)\n", w.proto)
+	fmt.Fprintf(b, "    pinfo.cols.protocol = %q\n", strings.ToUpper(w.proto))
+	fmt.Fprintf(b, "    local subtree = tree:add(%s, buf())\n", w.proto)
+	fmt.Fprintf(b, "    return dissect_%s(buf, pinfo, subtree, 0, buf:len(), %q)\n", snake(root.Name), root.Name)
+	b.WriteString("end\n\n")
+
+	transport := strings.ToLower(opts.Transport)
+	if (transport == "tcp" || transport == "udp") && opts.Port != 0 {
+		fmt.Fprintf(b, "DissectorTable.get(%q):add(%d, %s)\n", transport+".port", opts.Port, w.proto)
+	} else {
+		b.WriteString("-- Register the dissector on the protocol's port, for example:\n")
+		fmt.Fprintf(b, "-- DissectorTable.get(\"tcp.port\"):add(8443, %s)\n", w.proto)
+		fmt.Fprintf(b, "-- or use Decode As... with %s.\n", strings.ToUpper(w.proto))
+	}
+	return b.String()
+}
+
+// field declares a ProtoField and returns its key in the hf table
+func (w *luaWriter) field(key, name, kind, base string, enum *Enum) string {
+	abbrev := w.proto + "." + strings.ReplaceAll(key, "__", ".")
+	args := fmt.Sprintf("%q, %q", abbrev, name)
+	if base != "" {
+		args += ", " + base
+		if enum != nil {
+			args += ", " + snake(enum.Name) + "_names"
+		}
+	}
+	key = strings.ReplaceAll(key, "__", "_")
+	w.fields = append(w.fields, fmt.Sprintf("%s = ProtoField.%s(%s)", key, kind, args))
+	return "hf." + key
+}
+
+// intField declares the ProtoField of an integer
+func (w *luaWriter) intField(key, name string, t IntType, enum *Enum, hex bool) string {
+	kind := fmt.Sprintf("uint%d", t.Size*8)
+	if t.Signed {
+		kind = fmt.Sprintf("int%d", t.Size*8)
+	}
+	base := "base.DEC"
+	if hex && !t.Signed {
+		base = "base.HEX"
+	}
+	return w.field(key, name, kind, base, enum)
+}
+
+// read returns Lua code reading an integer at an offset
+func (w *luaWriter) read(t IntType, offset string) string {
+	method := "uint"
+	if t.Signed {
+		method = "int"
+	}
+	if t.Size == 8 {
+		method += "64"
+	}
+	if !t.bigEndian(w.spec) {
+		method = "le_" + method
+	}
+	s := fmt.Sprintf("buf(%s, %d):%s()", offset, t.Size, method)
+	if t.Size == 8 {
+		s += ":tonumber()"
+	}
+	return s
+}
+
+// add returns the TreeItem method adding an integer in its byte order
+func (w *luaWriter) add(t IntType) string {
+	if t.bigEndian(w.spec) {
+		return "add"
+	}
+	return "add_le"
+}
+
+// message writes the function dissecting a message
+func (w *luaWriter) message(b *strings.Builder, m *Message) {
+	name := snake(m.Name)
+	fmt.Fprintf(b, "-- message %s\n", m.Name)
+	fmt.Fprintf(b, "dissect_%s = function(buf, pinfo, tree, offset, limit, label)\n", name)
+	b.WriteString("    local start = offset\n")
+	b.WriteString("    local subtree = tree:add(buf(offset, limit - offset), label)\n")
+	b.WriteString("    local v = {}\n")
+	b.WriteString("    local item, len, count\n")
+	for _, f := range m.Fields {
+		w.writeField(b, m, name, f, "    ")
+	}
+	b.WriteString("    subtree:set_len(offset - start)\n")
+	b.WriteString("    return offset\n")
+	b.WriteString("end\n\n")
+
+	for _, f := range m.Fields {
+		if f.Kind == KindTLV {
+			w.tlv(b, name+"_"+snake(f.Name), f)
+		}
+	}
+}
+
+// writeField writes the code dissecting a field at offset, advancing it
+func (w *luaWriter) writeField(b *strings.Builder, m *Message, prefix string, f *Field, indent string) {
+	key := prefix + "__" + snake(f.Name)
+	line := func(format string, args ...interface{}) {
+		b.WriteString(indent + fmt.Sprintf(format, args...) + "\n")
+	}
+	value := func(field string) string { return "v." + snake(field) }
+
+	// size sets len or count from a size of the field
+	size := func(s *Size, variable string) {
+		switch {
+		case s == nil || s.Rest:
+			line("%s = limit - offset", variable)
+		case s.Prefix != nil:
+			suffix := "_length"
+			if variable == "count" {
+				suffix = "_count"
+			}
+			hf := w.intField(key+suffix, f.Name+suffix, *s.Prefix, nil, false)
+			line("subtree:%s(%s, buf(offset, %d))", w.add(*s.Prefix), hf, s.Prefix.Size)
+			line("%s = %s", variable, w.read(*s.Prefix, "offset"))
+			line("offset = offset + %d", s.Prefix.Size)
+		default:
+			line("%s = %s", variable, s.Expr.format(value))
+		}
+	}
+
+	switch f.Kind {
+	case KindInt:
+		hf := w.intField(key, f.Name, f.Int, f.Enum, f.Expect != nil || f.Checksum != "")
+		line("item = subtree:%s(%s, buf(offset, %d))", w.add(f.Int), hf, f.Int.Size)
+		line("%s = %s", value(f.Name), w.read(f.Int, "offset"))
+		if f.Expect != nil {
+			line("if %s ~= %s then", value(f.Name), hexLiteral(*f.Expect))
+			line("    item:add_expert_info(PI_MALFORMED, PI_ERROR, %q)", "expected "+hexLiteral(*f.Expect))
+			line("end")
+		}
+		if f.Checksum != "" {
+			from := "the start of " + m.Name
+			if f.ChecksumFrom != "" {
+				from = f.ChecksumFrom
+			}
+			line("item:append_text(%q)", fmt.Sprintf(" [%s from %s, not verified]", f.Checksum, from))
+		}
+		line("offset = offset + %d", f.Int.Size)
+
+	case KindBytes, KindString:
+		size(f.Size, "len")
+		kind := "bytes"
+		if f.Kind == KindString {
+			kind = "string"
+		}
+		line("subtree:add(%s, buf(offset, len))", w.field(key, f.Name, kind, "", nil))
+		line("offset = offset + len")
+
+	case KindCString:
+		line("len = buf(offset):strsize()")
+		line("subtree:add(%s, buf(offset, len))", w.field(key, f.Name, "stringz", "", nil))
+		line("offset = offset + len")
+
+	case KindMessage, KindTLV:
+		fn := "dissect_" + prefix + "_" + snake(f.Name)
+		if f.Kind == KindMessage {
+			fn = "dissect_" + snake(f.Message.Name)
+		}
+		call := fmt.Sprintf("offset = %s(buf, pinfo, subtree, offset, limit, %%s)", fn)
+		if f.Length != nil {
+			// The elements are read up to the end of the field's region
+			size(f.Length, "len")
+			line("do")
+			indent += "    "
+			line("local limit = offset + len")
+			defer func() {
+				line("offset = limit")
+				indent = indent[4:]
+				line("end")
+			}()
+		}
+		switch {
+		case f.Size == nil:
+			line(call, strconv.Quote(f.Name))
+		case f.Size.Rest:
+			line("count = 0")
+			line("while offset < limit do")
+			line("    local before = offset")
+			line("    "+call, strconv.Quote(f.Name+"[")+" .. count .. \"]\"")
+			line("    count = count + 1")
+			line("    if offset == before then break end")
+			line("end")
+		default:
+			size(f.Size, "count")
+			line("for i = 0, count - 1 do")
+			line("    "+call, strconv.Quote(f.Name+"[")+" .. i .. \"]\"")
+			line("end")
+		}
+	}
+}
+
+// tlv writes the function dissecting one record of a TLV field
+func (w *luaWriter) tlv(b *strings.Builder, name string, f *Field) {
+	t := f.TLV
+	header := t.Tag.Size + t.Length.Size
+	fmt.Fprintf(b, "-- one record of %s\n", f.Name)
+	fmt.Fprintf(b, "dissect_%s = function(buf, pinfo, tree, offset, limit, label)\n", name)
+	fmt.Fprintf(b, "    local tag = %s\n", w.read(t.Tag, "offset"))
+	fmt.Fprintf(b, "    local len = %s\n", w.read(t.Length, fmt.Sprintf("offset + %d", t.Tag.Size)))
+	fmt.Fprintf(b, "    local subtree = tree:add(buf(offset, %d + len), label)\n", header)
+	fmt.Fprintf(b, "    subtree:%s(%s, buf(offset, %d))\n", w.add(t.Tag), w.intField(name+"__tag", "tag", t.Tag, t.TagEnum, false), t.Tag.Size)
+	fmt.Fprintf(b, "    subtree:%s(%s, buf(offset + %d, %d))\n", w.add(t.Length), w.intField(name+"__length", "length", t.Length, nil, false), t.Tag.Size, t.Length.Size)
+	fmt.Fprintf(b, "    local finish = offset + %d + len\n", header)
+	fmt.Fprintf(b, "    offset = offset + %d\n", header)
+	b.WriteString("    limit = finish\n")
+	b.WriteString("    local v = {}\n")
+	b.WriteString("    local item, count\n")
+	for i, c := range t.Cases {
+		keyword := "elseif"
+		if i == 0 {
+			keyword = "if"
+		}
+		fmt.Fprintf(b, "    %s tag == %d then\n", keyword, c.Tag)
+		w.writeField(b, nil, name, c.Field, "        ")
+	}
+	value := w.field(name+"__value", "value", "bytes", "", nil)
+	if len(t.Cases) > 0 {
+		b.WriteString("    else\n")
+		fmt.Fprintf(b, "        subtree:add(%s, buf(offset, len))\n", value)
+		b.WriteString("    end\n")
+	} else {
+		fmt.Fprintf(b, "    subtree:add(%s, buf(offset, len))\n", value)
+	}
+	b.WriteString("    return finish\n")
+	b.WriteString("end\n\n")
+}
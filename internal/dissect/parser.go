@@ -0,0 +1,827 @@
+package dissect
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// tokenKind classifies a token
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNewline
+	tokIdent
+	tokNumber
+	tokString
+	tokPunct
+)
+
+// token is a lexical token of a definition
+type token struct {
+	kind  tokenKind
+	text  string
+	value int64
+	line  int
+}
+
+// Error is a syntax or semantic error in a definition
+type Error struct {
+	// Line is the line of the definition the error is on; 0 if unknown
+	Line int
+
+	Msg string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+	}
+	return e.Msg
+}
+
+// errorf returns an *Error for a line
+func errorf(line int, format string, args ...interface{}) *Error {
+	return &Error{Line: line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// lex splits a definition into tokens. Line breaks are tokens because
+// they end fields; "#" and "//" start comments.
+func lex(src string) ([]token, error) {
+	var toks []token
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n' || c == ';':
+			toks = append(toks, token{kind: tokNewline, text: string(c), line: line})
+			if c == '\n' {
+				line++
+			}
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#' || strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '_' || isLetter(c):
+			n := i + 1
+			for n < len(src) && (src[n] == '_' || isLetter(src[n]) || isDigit(src[n])) {
+				n++
+			}
+			toks = append(toks, token{kind: tokIdent, text: src[i:n], line: line})
+			i = n
+		case isDigit(c):
+			n := i + 1
+			for n < len(src) && (src[n] == '_' || isLetter(src[n]) || isDigit(src[n])) {
+				n++
+			}
+			v, err := strconv.ParseInt(src[i:n], 0, 64)
+			if err != nil {
+				u, uerr := strconv.ParseUint(src[i:n], 0, 64)
+				if uerr != nil {
+					return nil, errorf(line, "invalid number %q", src[i:n])
+				}
+				v = int64(u)
+			}
+			toks = append(toks, token{kind: tokNumber, text: src[i:n], value: v, line: line})
+			i = n
+		case c == '"':
+			n := i + 1
+			for n < len(src) && src[n] != '"' && src[n] != '\n' {
+				if src[n] == '\\' {
+					n++
+				}
+				n++
+			}
+			if n >= len(src) || src[n] != '"' {
+				return nil, errorf(line, "unterminated string")
+			}
+			text, err := strconv.Unquote(src[i : n+1])
+			if err != nil {
+				return nil, errorf(line, "invalid string %s", src[i:n+1])
+			}
+			toks = append(toks, token{kind: tokString, text: text, line: line})
+			i = n + 1
+		case strings.ContainsRune("{}[](),:=+-*", rune(c)):
+			toks = append(toks, token{kind: tokPunct, text: string(c), line: line})
+			i++
+		default:
+			return nil, errorf(line, "unexpected character %q", rune(c))
+		}
+	}
+	return append(toks, token{kind: tokEOF, line: line}), nil
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// intTypePattern matches integer type names such as u8, i32 and u16le
+var intTypePattern = regexp.MustCompile(`^([ui])(8|16|24|32|64)(le|be)?$`)
+
+// parseIntType parses an integer type name
+func parseIntType(name string) (IntType, bool) {
+	m := intTypePattern.FindStringSubmatch(name)
+	if m == nil {
+		return IntType{}, false
+	}
+	bits, _ := strconv.Atoi(m[2])
+	t := IntType{Size: bits / 8, Signed: m[1] == "i"}
+	switch m[3] {
+	case "le":
+		t.Order = OrderLittle
+	case "be":
+		t.Order = OrderBig
+	}
+	return t, true
+}
+
+// keywords cannot name enums, messages or enum constants
+var keywords = map[string]bool{
+	"protocol": true, "endian": true, "enum": true, "message": true,
+	"bytes": true, "string": true, "cstring": true, "tlv": true,
+	"checksum": true, "from": true, "size": true,
+}
+
+// parser builds a Spec from tokens
+type parser struct {
+	toks []token
+	pos  int
+	spec *Spec
+
+	enums    map[string]*Enum
+	messages map[string]*Message
+
+	// refs are nested message fields to resolve once every message is
+	// defined
+	refs []messageRef
+}
+
+// messageRef is a field whose type names a message
+type messageRef struct {
+	field *Field
+	name  string
+}
+
+// Parse parses a protocol definition.
+//
+// Parameters:
+//   - src: The definition
+//
+// Returns:
+//   - The parsed definition
+//   - An *Error giving the line of the first problem
+func Parse(src string) (*Spec, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{
+		toks:     toks,
+		spec:     &Spec{BigEndian: true},
+		enums:    make(map[string]*Enum),
+		messages: make(map[string]*Message),
+	}
+	if err := p.parseSpec(); err != nil {
+		return nil, err
+	}
+	if err := p.resolve(); err != nil {
+		return nil, err
+	}
+	return p.spec, nil
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes a punctuator or keyword if it is next
+func (p *parser) accept(text string) bool {
+	if t := p.peek(); (t.kind == tokPunct || t.kind == tokIdent) && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expect consumes a punctuator or keyword
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return errorf(p.peek().line, "expected %q, found %s", text, describe(p.peek()))
+	}
+	return nil
+}
+
+// ident consumes an identifier
+func (p *parser) ident(what string) (token, error) {
+	t := p.next()
+	if t.kind != tokIdent {
+		return t, errorf(t.line, "expected %s, found %s", what, describe(t))
+	}
+	return t, nil
+}
+
+// endOfLine consumes the line break ending a statement
+func (p *parser) endOfLine() error {
+	t := p.peek()
+	switch {
+	case t.kind == tokNewline:
+		p.skipNewlines()
+		return nil
+	case t.kind == tokEOF, t.kind == tokPunct && t.text == "}":
+		return nil
+	}
+	return errorf(t.line, "unexpected %s", describe(t))
+}
+
+func (p *parser) skipNewlines() {
+	for p.peek().kind == tokNewline {
+		p.pos++
+	}
+}
+
+// describe names a token in error messages
+func describe(t token) string {
+	switch t.kind {
+	case tokEOF:
+		return "end of definition"
+	case tokNewline:
+		return "end of line"
+	case tokString:
+		return strconv.Quote(t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// parseSpec parses the statements of a definition
+func (p *parser) parseSpec() error {
+	for {
+		p.skipNewlines()
+		t := p.next()
+		var err error
+		switch {
+		case t.kind == tokEOF:
+			return nil
+		case t.kind == tokIdent && t.text == "protocol":
+			err = p.parseProtocol()
+		case t.kind == tokIdent && t.text == "endian":
+			err = p.parseEndian()
+		case t.kind == tokIdent && t.text == "enum":
+			err = p.parseEnum(t.line)
+		case t.kind == tokIdent && t.text == "message":
+			err = p.parseMessage(t.line)
+		default:
+			err = errorf(t.line, "expected protocol, endian, enum or message, found %s", describe(t))
+		}
+		if err != nil {
+			return err
+		}
+		if err := p.endOfLine(); err != nil {
+			return err
+		}
+	}
+}
+
+// parseProtocol parses "protocol NAME ["Description"]"
+func (p *parser) parseProtocol() error {
+	name, err := p.ident("a protocol name")
+	if err != nil {
+		return err
+	}
+	p.spec.Name = name.text
+	if p.peek().kind == tokString {
+		p.spec.Description = p.next().text
+	}
+	return nil
+}
+
+// parseEndian parses "endian big" or "endian little"
+func (p *parser) parseEndian() error {
+	t, err := p.ident("big or little")
+	if err != nil {
+		return err
+	}
+	switch t.text {
+	case "big":
+		p.spec.BigEndian = true
+	case "little":
+		p.spec.BigEndian = false
+	default:
+		return errorf(t.line, "expected big or little, found %s", describe(t))
+	}
+	return nil
+}
+
+// declare checks that an enum or message name is free
+func (p *parser) declare(t token) error {
+	if keywords[t.text] {
+		return errorf(t.line, "%q is a keyword", t.text)
+	}
+	if _, ok := parseIntType(t.text); ok {
+		return errorf(t.line, "%q is an integer type", t.text)
+	}
+	if p.enums[t.text] != nil || p.messages[t.text] != nil {
+		return errorf(t.line, "%q is already defined", t.text)
+	}
+	return nil
+}
+
+// parseEnum parses "enum NAME TYPE { NAME = VALUE ... }"
+func (p *parser) parseEnum(line int) error {
+	name, err := p.ident("an enum name")
+	if err != nil {
+		return err
+	}
+	if err := p.declare(name); err != nil {
+		return err
+	}
+	typeTok, err := p.ident("an integer type")
+	if err != nil {
+		return err
+	}
+	t, ok := parseIntType(typeTok.text)
+	if !ok {
+		return errorf(typeTok.line, "expected an integer type such as u8, found %s", describe(typeTok))
+	}
+	e := &Enum{Name: name.text, Type: t, Line: line}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	next := int64(0)
+	for {
+		p.skipNewlines()
+		if p.accept("}") {
+			break
+		}
+		constant, err := p.ident("an enum constant")
+		if err != nil {
+			return err
+		}
+		if seen[constant.text] {
+			return errorf(constant.line, "duplicate enum constant %q", constant.text)
+		}
+		seen[constant.text] = true
+		if p.accept("=") {
+			if next, err = p.number(); err != nil {
+				return err
+			}
+		}
+		e.Values = append(e.Values, EnumValue{Name: constant.text, Value: next})
+		next++
+		p.accept(",")
+	}
+	p.enums[e.Name] = e
+	p.spec.Enums = append(p.spec.Enums, e)
+	return nil
+}
+
+// number parses an optionally negative number
+func (p *parser) number() (int64, error) {
+	negative := p.accept("-")
+	t := p.next()
+	if t.kind != tokNumber {
+		return 0, errorf(t.line, "expected a number, found %s", describe(t))
+	}
+	if negative {
+		return -t.value, nil
+	}
+	return t.value, nil
+}
+
+// parseMessage parses "message NAME { fields }"
+func (p *parser) parseMessage(line int) error {
+	name, err := p.ident("a message name")
+	if err != nil {
+		return err
+	}
+	if err := p.declare(name); err != nil {
+		return err
+	}
+	m := &Message{Name: name.text, Line: line}
+	// Register the message first so fields can refer to messages defined
+	// later; references are resolved once the definition is read
+	p.messages[m.Name] = m
+	p.spec.Messages = append(p.spec.Messages, m)
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+
+	fields := make(map[string]*Field)
+	for {
+		p.skipNewlines()
+		if p.accept("}") {
+			return nil
+		}
+		nameTok, err := p.ident("a field name")
+		if err != nil {
+			return err
+		}
+		if fields[nameTok.text] != nil {
+			return errorf(nameTok.line, "duplicate field %q", nameTok.text)
+		}
+		f, err := p.parseField(nameTok, fields)
+		if err != nil {
+			return err
+		}
+		fields[f.Name] = f
+		m.Fields = append(m.Fields, f)
+		if err := p.endOfLine(); err != nil {
+			return err
+		}
+	}
+}
+
+// parseField parses the type and modifiers of a field.
+//
+// Parameters:
+//   - name: The field name token
+//   - earlier: The fields before it in its message, which sizes and
+//     checksums may refer to; nil for TLV values, which have no sizes
+func (p *parser) parseField(name token, earlier map[string]*Field) (*Field, error) {
+	f := &Field{Name: name.text, Line: name.line}
+	typeTok, err := p.ident("a type")
+	if err != nil {
+		return nil, err
+	}
+
+	sized := false
+	switch typeTok.text {
+	case "bytes":
+		f.Kind, sized = KindBytes, true
+	case "string":
+		f.Kind, sized = KindString, true
+	case "cstring":
+		f.Kind = KindCString
+	case "tlv":
+		f.Kind = KindTLV
+		if f.TLV, err = p.parseTLVHeader(); err != nil {
+			return nil, err
+		}
+	default:
+		if t, ok := parseIntType(typeTok.text); ok {
+			f.Kind, f.Int = KindInt, t
+			break
+		}
+		f.Kind = KindMessage
+		p.refs = append(p.refs, messageRef{f, typeTok.text})
+	}
+
+	if p.accept("[") {
+		if f.Kind == KindInt || f.Kind == KindCString {
+			return nil, errorf(typeTok.line, "%s fields cannot have a size or count", typeTok.text)
+		}
+		if earlier == nil {
+			return nil, errorf(typeTok.line, "TLV values take their length from the record; remove the size")
+		}
+		if f.Size, err = p.parseSize(earlier); err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	} else if sized && earlier != nil {
+		return nil, errorf(typeTok.line, "%s fields need a size, such as %s[16], %s[length], %s[u16] or %s[*]",
+			typeTok.text, typeTok.text, typeTok.text, typeTok.text, typeTok.text)
+	}
+
+	if err := p.parseModifiers(f, earlier); err != nil {
+		return nil, err
+	}
+	if f.Kind == KindTLV && p.accept("{") {
+		if err := p.parseTLVCases(f.TLV); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// parseSize parses a size: the inside of "[...]", or the length after
+// the size modifier
+func (p *parser) parseSize(earlier map[string]*Field) (*Size, error) {
+	s := &Size{}
+	t := p.peek()
+	switch {
+	case p.accept("*"):
+		s.Rest = true
+	case t.kind == tokIdent && intTypePattern.MatchString(t.text):
+		p.next()
+		prefix, _ := parseIntType(t.text)
+		s.Prefix = &prefix
+	default:
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range expr.Fields() {
+			ref := earlier[name]
+			if ref == nil {
+				return nil, errorf(t.line, "%q is not an earlier field of the message", name)
+			}
+			if ref.Kind != KindInt {
+				return nil, errorf(t.line, "%q is not an integer field", name)
+			}
+		}
+		s.Expr = expr
+	}
+	return s, nil
+}
+
+// parseExpr parses a sum of products of numbers and field names
+func (p *parser) parseExpr() (*Expr, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokPunct || t.text != "+" && t.text != "-" {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &Expr{Op: t.text[0], Left: left, Right: right}
+	}
+}
+
+// parseTerm parses a product of operands
+func (p *parser) parseTerm() (*Expr, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("*") {
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		left = &Expr{Op: '*', Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// parseOperand parses a number, a field name or a parenthesized expression
+func (p *parser) parseOperand() (*Expr, error) {
+	t := p.next()
+	switch {
+	case t.kind == tokNumber:
+		return &Expr{Value: t.value}, nil
+	case t.kind == tokIdent:
+		return &Expr{Field: t.text}, nil
+	case t.kind == tokPunct && t.text == "(":
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expect(")")
+	}
+	return nil, errorf(t.line, "expected a number or field name, found %s", describe(t))
+}
+
+// parseTLVHeader parses "(TAG [enum E], LENGTH)" after "tlv"
+func (p *parser) parseTLVHeader() (*TLV, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	tlv := &TLV{}
+	tag, err := p.intType()
+	if err != nil {
+		return nil, err
+	}
+	tlv.Tag = tag
+	if p.accept("enum") {
+		if tlv.TagEnum, err = p.enumRef(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect(","); err != nil {
+		return nil, err
+	}
+	if tlv.Length, err = p.intType(); err != nil {
+		return nil, err
+	}
+	return tlv, p.expect(")")
+}
+
+// intType parses an integer type name
+func (p *parser) intType() (IntType, error) {
+	t := p.next()
+	if it, ok := parseIntType(t.text); ok && t.kind == tokIdent {
+		return it, nil
+	}
+	return IntType{}, errorf(t.line, "expected an integer type such as u8, found %s", describe(t))
+}
+
+// enumRef parses the name of a defined enum
+func (p *parser) enumRef() (*Enum, error) {
+	t, err := p.ident("an enum name")
+	if err != nil {
+		return nil, err
+	}
+	e := p.enums[t.text]
+	if e == nil {
+		return nil, errorf(t.line, "unknown enum %q; define enums before using them", t.text)
+	}
+	return e, nil
+}
+
+// parseTLVCases parses "TAG: NAME TYPE" lines up to the closing brace
+func (p *parser) parseTLVCases(tlv *TLV) error {
+	seen := make(map[int64]bool)
+	names := make(map[string]bool)
+	for {
+		p.skipNewlines()
+		if p.accept("}") {
+			return nil
+		}
+		t := p.peek()
+		var tag int64
+		var err error
+		if t.kind == tokIdent && tlv.TagEnum != nil {
+			p.next()
+			found := false
+			for _, v := range tlv.TagEnum.Values {
+				if v.Name == t.text {
+					tag, found = v.Value, true
+				}
+			}
+			if !found {
+				return errorf(t.line, "%q is not a constant of enum %s", t.text, tlv.TagEnum.Name)
+			}
+		} else if tag, err = p.number(); err != nil {
+			return err
+		}
+		if seen[tag] {
+			return errorf(t.line, "duplicate TLV tag %d", tag)
+		}
+		seen[tag] = true
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		name, err := p.ident("a field name")
+		if err != nil {
+			return err
+		}
+		if names[name.text] {
+			return errorf(name.line, "duplicate field %q", name.text)
+		}
+		names[name.text] = true
+		f, err := p.parseField(name, nil)
+		if err != nil {
+			return err
+		}
+		if f.Kind == KindTLV {
+			return errorf(f.Line, "TLV values cannot be TLVs; use a message holding a tlv field")
+		}
+		tlv.Cases = append(tlv.Cases, &TLVCase{Tag: tag, Field: f})
+		if err := p.endOfLine(); err != nil {
+			return err
+		}
+	}
+}
+
+// parseModifiers parses "= VALUE", "enum E" and "checksum ALG [from F]"
+func (p *parser) parseModifiers(f *Field, earlier map[string]*Field) error {
+	for {
+		t := p.peek()
+		switch {
+		case p.accept("="):
+			if f.Kind != KindInt {
+				return errorf(t.line, "only integer fields can have an expected value")
+			}
+			v, err := p.number()
+			if err != nil {
+				return err
+			}
+			f.Expect = &v
+		case p.accept("enum"):
+			if f.Kind != KindInt {
+				return errorf(t.line, "only integer fields can use an enum")
+			}
+			e, err := p.enumRef()
+			if err != nil {
+				return err
+			}
+			f.Enum = e
+		case p.accept("size"):
+			if f.Kind != KindMessage && f.Kind != KindTLV {
+				return errorf(t.line, "only message and TLV fields can have a size in bytes; use [...] for others")
+			}
+			if earlier == nil {
+				return errorf(t.line, "TLV values take their length from the record; remove the size")
+			}
+			s, err := p.parseSize(earlier)
+			if err != nil {
+				return err
+			}
+			if s.Rest {
+				return errorf(t.line, "fields take the rest of the message unless a size is given; remove \"size *\"")
+			}
+			f.Length = s
+		case p.accept("checksum"):
+			if f.Kind != KindInt {
+				return errorf(t.line, "only integer fields can be checksums")
+			}
+			if earlier == nil {
+				return errorf(t.line, "TLV values cannot be checksums")
+			}
+			alg, err := p.ident("a checksum algorithm")
+			if err != nil {
+				return err
+			}
+			if _, ok := checksums[alg.text]; !ok {
+				return errorf(alg.line, "unknown checksum %q (supported: %s)", alg.text, strings.Join(Checksums(), ", "))
+			}
+			f.Checksum = alg.text
+			if p.accept("from") {
+				from, err := p.ident("a field name")
+				if err != nil {
+					return err
+				}
+				if earlier[from.text] == nil {
+					return errorf(from.line, "%q is not an earlier field of the message", from.text)
+				}
+				f.ChecksumFrom = from.text
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// resolve links nested message fields to their messages and rejects
+// messages that contain themselves
+func (p *parser) resolve() error {
+	for _, ref := range p.refs {
+		m := p.messages[ref.name]
+		if m == nil {
+			if p.enums[ref.name] != nil {
+				return errorf(ref.field.Line, "%q is an enum; write an integer type followed by \"enum %s\"", ref.name, ref.name)
+			}
+			return errorf(ref.field.Line, "unknown type %q", ref.name)
+		}
+		ref.field.Message = m
+	}
+
+	state := make(map[*Message]int) // 1 while visiting, 2 when done
+	var visit func(m *Message) error
+	visit = func(m *Message) error {
+		switch state[m] {
+		case 1:
+			return errorf(m.Line, "message %s contains itself", m.Name)
+		case 2:
+			return nil
+		}
+		state[m] = 1
+		for _, f := range m.Fields {
+			for _, nested := range f.messages() {
+				if err := visit(nested); err != nil {
+					return err
+				}
+			}
+		}
+		state[m] = 2
+		return nil
+	}
+	for _, m := range p.spec.Messages {
+		if err := visit(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// messages returns the messages a field holds, directly or as TLV values
+func (f *Field) messages() []*Message {
+	var out []*Message
+	if f.Message != nil {
+		out = append(out, f.Message)
+	}
+	if f.TLV != nil {
+		for _, c := range f.TLV.Cases {
+			out = append(out, c.Field.messages()...)
+		}
+	}
+	return out
+}
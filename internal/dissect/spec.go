@@ -0,0 +1,283 @@
+// Package dissect implements a declarative language for describing the
+// messages of a binary protocol, decodes sample messages with it into a
+// field tree, and generates Wireshark Lua dissectors and Kaitai Struct
+// specifications from it.
+//
+// A definition lists enums and messages; the first message is the one
+// samples are decoded as:
+//
+//	protocol acme "ACME telemetry"
+//	endian big
+//
+//	enum msg_type u8 {
+//	    HELLO = 1
+//	    DATA = 2
+//	}
+//
+//	message packet {
+//	    magic   u32 = 0xa5a5a5a5
+//	    type    u8 enum msg_type
+//	    length  u16
+//	    payload bytes[length - 2]
+//	    olen    u8
+//	    options tlv(u8, u8)[*] size olen {
+//	        1: mss u16
+//	        2: host string
+//	    }
+//	    crc     u16 checksum crc16 from magic
+//	}
+package dissect
+
+import "fmt"
+
+// Order is the byte order of an integer
+type Order int
+
+// Byte orders; OrderDefault follows the definition's endian statement
+const (
+	OrderDefault Order = iota
+	OrderLittle
+	OrderBig
+)
+
+// IntType is an integer type such as u16 or i32le
+type IntType struct {
+	// Size is the size in bytes: 1, 2, 3, 4 or 8
+	Size int
+
+	Signed bool
+	Order  Order
+}
+
+// String returns the type as written in definitions
+func (t IntType) String() string {
+	s := fmt.Sprintf("u%d", t.Size*8)
+	if t.Signed {
+		s = fmt.Sprintf("i%d", t.Size*8)
+	}
+	switch t.Order {
+	case OrderLittle:
+		s += "le"
+	case OrderBig:
+		s += "be"
+	}
+	return s
+}
+
+// bigEndian reports whether the type is read most significant byte first
+func (t IntType) bigEndian(spec *Spec) bool {
+	if t.Order == OrderDefault {
+		return spec.BigEndian
+	}
+	return t.Order == OrderBig
+}
+
+// Kind classifies a field
+type Kind int
+
+// Kinds of fields
+const (
+	KindInt Kind = iota
+	KindBytes
+	KindString
+
+	// KindCString is a NUL-terminated string
+	KindCString
+
+	// KindMessage is a nested message
+	KindMessage
+
+	// KindTLV is a sequence of tag, length, value records
+	KindTLV
+)
+
+// Spec is a parsed protocol definition
+type Spec struct {
+	// Name names the protocol, such as "acme"; it prefixes the field
+	// names of generated dissectors
+	Name string
+
+	// Description is the protocol's display name
+	Description string
+
+	// BigEndian is the default byte order of integers; network protocols
+	// are big endian unless stated otherwise
+	BigEndian bool
+
+	Enums    []*Enum
+	Messages []*Message
+}
+
+// Root returns the message samples are decoded as: the first one defined
+func (s *Spec) Root() *Message {
+	if len(s.Messages) == 0 {
+		return nil
+	}
+	return s.Messages[0]
+}
+
+// Enum names the values of an integer
+type Enum struct {
+	Name   string
+	Type   IntType
+	Values []EnumValue
+	Line   int
+}
+
+// EnumValue is a named enum constant
+type EnumValue struct {
+	Name  string
+	Value int64
+}
+
+// ValueName returns the name of the constant with a value, or "" if none
+func (e *Enum) ValueName(value int64) string {
+	for _, v := range e.Values {
+		if v.Value == value {
+			return v.Name
+		}
+	}
+	return ""
+}
+
+// Message is a sequence of fields
+type Message struct {
+	Name   string
+	Fields []*Field
+	Line   int
+}
+
+// Field is a field of a message or the value of a TLV case
+type Field struct {
+	Name string
+	Kind Kind
+
+	// Int is the type of an integer field
+	Int IntType
+
+	// Message is the type of a nested message field
+	Message *Message
+
+	// Size is the length of bytes and string fields, or the number of
+	// elements of message and TLV fields; nil for a single element, or for
+	// TLV values, which take the length from the record
+	Size *Size
+
+	// Length is the number of bytes message and TLV fields occupy, for
+	// elements read from a region of known length; nil if they end where
+	// their last element ends
+	Length *Size
+
+	// Expect is the value an integer field must have, such as a magic
+	// number; nil if any value is valid
+	Expect *int64
+
+	// Enum names the values of an integer field
+	Enum *Enum
+
+	// Checksum is the algorithm an integer field is a checksum of, and
+	// ChecksumFrom the field the checksummed bytes start at; empty for the
+	// start of the message. The bytes end where the field starts.
+	Checksum     string
+	ChecksumFrom string
+
+	// TLV describes the records of a TLV field
+	TLV *TLV
+
+	Line int
+}
+
+// Size gives the length or count of a field
+type Size struct {
+	// Rest takes everything up to the end of the enclosing message
+	Rest bool
+
+	// Prefix is the type of a length or count read just before the field
+	Prefix *IntType
+
+	// Expr computes the length or count from numbers and earlier fields
+	Expr *Expr
+}
+
+// String returns the size as written in definitions, without brackets
+func (s *Size) String() string {
+	switch {
+	case s.Rest:
+		return "*"
+	case s.Prefix != nil:
+		return s.Prefix.String()
+	}
+	return s.Expr.String()
+}
+
+// Expr is an arithmetic expression of numbers and integer fields
+type Expr struct {
+	// Op is '+', '-' or '*' for binary expressions; 0 for a number or a
+	// field reference
+	Op byte
+
+	Left, Right *Expr
+
+	// Field names an integer field of the same message; empty for numbers
+	Field string
+
+	Value int64
+}
+
+// String returns the expression as written in definitions
+func (e *Expr) String() string {
+	return e.format(func(field string) string { return field })
+}
+
+// format writes the expression, naming fields with a function, with the
+// parentheses precedence needs
+func (e *Expr) format(name func(string) string) string {
+	switch {
+	case e.Op != 0:
+		left, right := e.Left.format(name), e.Right.format(name)
+		sum := func(x *Expr) bool { return x.Op == '+' || x.Op == '-' }
+		if e.Op == '*' && sum(e.Left) {
+			left = "(" + left + ")"
+		}
+		if e.Op == '*' && sum(e.Right) || e.Op == '-' && sum(e.Right) {
+			right = "(" + right + ")"
+		}
+		return fmt.Sprintf("%s %c %s", left, e.Op, right)
+	case e.Field != "":
+		return name(e.Field)
+	}
+	return fmt.Sprint(e.Value)
+}
+
+// Fields returns the fields an expression refers to
+func (e *Expr) Fields() []string {
+	if e == nil {
+		return nil
+	}
+	if e.Op != 0 {
+		return append(e.Left.Fields(), e.Right.Fields()...)
+	}
+	if e.Field != "" {
+		return []string{e.Field}
+	}
+	return nil
+}
+
+// TLV describes tag, length, value records
+type TLV struct {
+	// Tag and Length are the types of the record header
+	Tag    IntType
+	Length IntType
+
+	// TagEnum names the tags, if given
+	TagEnum *Enum
+
+	// Cases decode the values of known tags; other values are bytes
+	Cases []*TLVCase
+}
+
+// TLVCase decodes the value of records with a tag
+type TLVCase struct {
+	Tag   int64
+	Field *Field
+}
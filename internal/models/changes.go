@@ -0,0 +1,223 @@
+// Package models provides data models and storage functionality for the RevEnGo application.
+// This file contains the change feed that reports edits to stored notes and projects.
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Kinds of stored items reported in change events
+const (
+	ChangeNote    = "note"
+	ChangeProject = "project"
+)
+
+// Actions reported in change events
+const (
+	ChangeSaved   = "saved"
+	ChangeDeleted = "deleted"
+)
+
+// changeSettleDelay is how long a file must be quiet before its change is
+// reported. Saving a file produces several filesystem events in quick succession.
+const changeSettleDelay = 100 * time.Millisecond
+
+// ChangeEvent describes a note or project that was saved or deleted
+type ChangeEvent struct {
+	// Kind is ChangeNote or ChangeProject
+	Kind string `json:"kind"`
+
+	// Action is ChangeSaved or ChangeDeleted
+	Action string `json:"action"`
+
+	// ID is the ID of the changed item
+	ID string `json:"id"`
+
+	// Time is when the change was observed
+	Time time.Time `json:"time"`
+}
+
+// ChangeFeed reports changes to the file-based note and project stores.
+// It watches the storage directories rather than wrapping the stores, so
+// edits made by any process (the GUI, the command line or the HTTP API)
+// reach every subscriber.
+type ChangeFeed struct {
+	// dirs maps each watched directory to the kind of item stored in it
+	dirs map[string]string
+
+	mu      sync.Mutex
+	watcher *fsnotify.Watcher
+	subs    map[chan ChangeEvent]struct{}
+	pending map[string]*time.Timer
+}
+
+// NewChangeFeed creates a feed for the given note and project directories.
+// Watching starts when the first subscriber arrives.
+//
+// Parameters:
+//   - notesDir: The directory of a FileNoteStore
+//   - projectsDir: The directory of a FileProjectStore
+//
+// Returns:
+//   - A change feed for the two directories
+func NewChangeFeed(notesDir, projectsDir string) *ChangeFeed {
+	return &ChangeFeed{
+		dirs: map[string]string{
+			filepath.Clean(notesDir):    ChangeNote,
+			filepath.Clean(projectsDir): ChangeProject,
+		},
+		subs:    make(map[chan ChangeEvent]struct{}),
+		pending: make(map[string]*time.Timer),
+	}
+}
+
+// Subscribe registers for change events.
+// Slow subscribers miss events rather than blocking the feed.
+//
+// Returns:
+//   - A channel receiving change events
+//   - A function that cancels the subscription and closes the channel
+//   - An error if the directories cannot be watched
+func (f *ChangeFeed) Subscribe() (<-chan ChangeEvent, func(), error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.watcher == nil {
+		if err := f.start(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	ch := make(chan ChangeEvent, 64)
+	f.subs[ch] = struct{}{}
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if _, ok := f.subs[ch]; ok {
+				delete(f.subs, ch)
+				close(ch)
+			}
+		})
+	}
+	return ch, cancel, nil
+}
+
+// Close stops watching and closes every subscriber's channel
+func (f *ChangeFeed) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for path, timer := range f.pending {
+		timer.Stop()
+		delete(f.pending, path)
+	}
+	for ch := range f.subs {
+		delete(f.subs, ch)
+		close(ch)
+	}
+	if f.watcher == nil {
+		return nil
+	}
+	err := f.watcher.Close()
+	f.watcher = nil
+	return err
+}
+
+// start begins watching the storage directories; f.mu must be held
+func (f *ChangeFeed) start() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	for dir := range f.dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+	f.watcher = watcher
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				f.schedule(event.Name)
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// schedule reports a changed file once it has been quiet for changeSettleDelay
+func (f *ChangeFeed) schedule(path string) {
+	if filepath.Ext(path) != ".json" {
+		return
+	}
+	kind, ok := f.dirs[filepath.Dir(path)]
+	if !ok {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if timer, ok := f.pending[path]; ok && timer.Stop() {
+		// Still settling: start the wait over
+		timer.Reset(changeSettleDelay)
+		return
+	}
+
+	// A timer that has fired but not yet published is superseded by the
+	// new one, so each settle window is published once
+	var timer *time.Timer
+	timer = time.AfterFunc(changeSettleDelay, func() {
+		f.mu.Lock()
+		if f.pending[path] != timer {
+			f.mu.Unlock()
+			return
+		}
+		delete(f.pending, path)
+		f.mu.Unlock()
+
+		action := ChangeSaved
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			action = ChangeDeleted
+		}
+		f.Publish(ChangeEvent{
+			Kind:   kind,
+			Action: action,
+			ID:     strings.TrimSuffix(filepath.Base(path), ".json"),
+			Time:   time.Now(),
+		})
+	})
+	f.pending[path] = timer
+}
+
+// Publish delivers an event to every subscriber
+func (f *ChangeFeed) Publish(event ChangeEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch := range f.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,541 @@
+// Package models provides data models and storage functionality for the RevEnGo application.
+// This file contains the structured fields specific to each note type.
+package models
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leog/RevEnGo/internal/cstruct"
+	"github.com/leog/RevEnGo/internal/dissect"
+	"github.com/leog/RevEnGo/internal/vuln"
+)
+
+// Vulnerability statuses, in the order a finding moves through them
+const (
+	VulnStatusSuspected = "suspected"
+	VulnStatusConfirmed = "confirmed"
+	VulnStatusReported  = "reported"
+	VulnStatusFixed     = "fixed"
+	VulnStatusDisclosed = "disclosed"
+
+	// VulnStatusWontFix closes a finding the vendor declined to fix
+	VulnStatusWontFix = "wont_fix"
+)
+
+// VulnStatuses lists the vulnerability statuses in workflow order
+var VulnStatuses = []string{
+	VulnStatusSuspected,
+	VulnStatusConfirmed,
+	VulnStatusReported,
+	VulnStatusFixed,
+	VulnStatusDisclosed,
+	VulnStatusWontFix,
+}
+
+// vulnTransitions lists the statuses a finding may move to from each
+// status. A confirmed finding may be fixed without being reported, and a
+// reported one disclosed before it is fixed.
+var vulnTransitions = map[string][]string{
+	VulnStatusSuspected: {VulnStatusConfirmed},
+	VulnStatusConfirmed: {VulnStatusReported, VulnStatusFixed},
+	VulnStatusReported:  {VulnStatusFixed, VulnStatusWontFix, VulnStatusDisclosed},
+	VulnStatusFixed:     {VulnStatusDisclosed},
+	VulnStatusWontFix:   {VulnStatusDisclosed},
+}
+
+// CheckVulnTransition checks that a finding may move from one status to
+// another. A finding without a status may be given any status, so that
+// findings known for a while can be recorded, and any status may be kept.
+//
+// Parameters:
+//   - from: The finding's status, or "" for none
+//   - to: One of VulnStatuses
+//
+// Returns:
+//   - An error if the status is unknown or cannot be reached from the other
+func CheckVulnTransition(from, to string) error {
+	if !slices.Contains(VulnStatuses, to) {
+		return fmt.Errorf("unknown vulnerability status %q: use %s", to, strings.Join(VulnStatuses, ", "))
+	}
+	if from == "" || from == to || slices.Contains(vulnTransitions[from], to) {
+		return nil
+	}
+	next := vulnTransitions[from]
+	if len(next) == 0 {
+		return fmt.Errorf("a %s finding cannot change status", from)
+	}
+	return fmt.Errorf("a %s finding cannot become %s, only %s", from, to, strings.Join(next, " or "))
+}
+
+// VulnDateLayout is the layout of the dates of vulnerability statuses
+const VulnDateLayout = "2006-01-02"
+
+// IsOpenVulnStatus reports whether a finding with the status still needs
+// work: it is not fixed, disclosed or declined
+func IsOpenVulnStatus(status string) bool {
+	switch status {
+	case VulnStatusFixed, VulnStatusDisclosed, VulnStatusWontFix:
+		return false
+	}
+	return true
+}
+
+// FunctionDetails are the fields of a function analysis note
+type FunctionDetails struct {
+	// Prototype is the recovered C declaration, such as
+	// "int parse_header(const uint8_t *buf, size_t len)"
+	Prototype string `json:"prototype,omitempty"`
+
+	// CallingConvention is the convention the function follows, such as
+	// "cdecl", "stdcall" or "sysv"
+	CallingConvention string `json:"calling_convention,omitempty"`
+
+	// Arguments describe the parameters in order, such as "rdi: buf"
+	Arguments []string `json:"arguments,omitempty"`
+
+	// StackFrameSize is the size of the local stack frame in bytes
+	StackFrameSize uint64 `json:"stack_frame_size,omitempty"`
+}
+
+// IsZero reports whether no field is set
+func (d *FunctionDetails) IsZero() bool {
+	return d == nil || d.Prototype == "" && d.CallingConvention == "" &&
+		len(d.Arguments) == 0 && d.StackFrameSize == 0
+}
+
+// VulnerabilityDetails are the fields of a vulnerability note
+type VulnerabilityDetails struct {
+	// CWE is the weakness identifier, such as "CWE-787"
+	CWE string `json:"cwe,omitempty"`
+
+	// CVSSVector is the CVSS v3.0, v3.1 or v4.0 vector string, such as
+	// "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"
+	CVSSVector string `json:"cvss_vector,omitempty"`
+
+	// AffectedBinary is the binary the finding is in, when it is not the
+	// note's binary
+	AffectedBinary string `json:"affected_binary,omitempty"`
+
+	// AffectedVersions lists the affected versions or version ranges, in
+	// the syntax of vuln.ParseVersionRange
+	AffectedVersions []string `json:"affected_versions,omitempty"`
+
+	// Status is one of VulnStatuses
+	Status string `json:"status,omitempty"`
+
+	// StatusDates are the days the finding reached each status, in
+	// VulnDateLayout, by status
+	StatusDates map[string]string `json:"status_dates,omitempty"`
+}
+
+// IsZero reports whether no field is set
+func (d *VulnerabilityDetails) IsZero() bool {
+	return d == nil || d.CWE == "" && d.CVSSVector == "" && d.AffectedBinary == "" &&
+		len(d.AffectedVersions) == 0 && d.Status == "" && len(d.StatusDates) == 0
+}
+
+// Score computes the score of the CVSS vector.
+//
+// Returns:
+//   - The score; unscored without a vector
+//   - An error if the vector does not parse
+func (d *VulnerabilityDetails) Score() (vuln.Score, error) {
+	if d == nil || strings.TrimSpace(d.CVSSVector) == "" {
+		return vuln.Score{}, nil
+	}
+	return vuln.ScoreVector(d.CVSSVector)
+}
+
+// SetStatus moves the finding to a status, recording the day it was
+// reached unless a day is already recorded for it.
+//
+// Parameters:
+//   - status: One of VulnStatuses
+//   - day: The day the status was reached
+//
+// Returns:
+//   - An error if the status is unknown or cannot be reached from the
+//     finding's status; see CheckVulnTransition
+func (d *VulnerabilityDetails) SetStatus(status string, day time.Time) error {
+	if err := CheckVulnTransition(d.Status, status); err != nil {
+		return err
+	}
+	d.Status = status
+	if d.StatusDates[status] == "" {
+		if d.StatusDates == nil {
+			d.StatusDates = make(map[string]string)
+		}
+		d.StatusDates[status] = day.Format(VulnDateLayout)
+	}
+	return nil
+}
+
+// Timeline lists the recorded status dates in workflow order, as in
+// "suspected 2024-03-01, confirmed 2024-03-04"
+func (d *VulnerabilityDetails) Timeline() string {
+	var steps []string
+	for _, status := range VulnStatuses {
+		if date := d.StatusDates[status]; date != "" {
+			steps = append(steps, status+" "+date)
+		}
+	}
+	return strings.Join(steps, ", ")
+}
+
+// Affects reports whether any affected version range contains a version;
+// ranges that do not parse are skipped
+func (d *VulnerabilityDetails) Affects(version string) bool {
+	for _, text := range d.AffectedVersions {
+		if r, err := vuln.ParseVersionRange(text); err == nil && r.Contains(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the fields that have a syntax: the status, the CWE
+// identifier, the CVSS vector, the version ranges and the status dates.
+//
+// Returns:
+//   - An error describing the first invalid field, or nil
+func (d *VulnerabilityDetails) Validate() error {
+	if d == nil {
+		return nil
+	}
+	if d.Status != "" && !slices.Contains(VulnStatuses, d.Status) {
+		return fmt.Errorf("unknown vulnerability status %s", d.Status)
+	}
+	if d.CWE != "" {
+		if _, err := vuln.ParseCWE(d.CWE); err != nil {
+			return err
+		}
+	}
+	if _, err := d.Score(); err != nil {
+		return err
+	}
+	for _, text := range d.AffectedVersions {
+		if _, err := vuln.ParseVersionRange(text); err != nil {
+			return err
+		}
+	}
+	for status, date := range d.StatusDates {
+		if !slices.Contains(VulnStatuses, status) {
+			return fmt.Errorf("status date for unknown vulnerability status %s", status)
+		}
+		if _, err := time.Parse(VulnDateLayout, date); err != nil {
+			return fmt.Errorf("invalid date %q for status %s: expected YYYY-MM-DD", date, status)
+		}
+	}
+	return nil
+}
+
+// ProtocolDetails are the fields of a protocol analysis note
+type ProtocolDetails struct {
+	// Transport carries the protocol, such as "tcp", "udp" or "usb"
+	Transport string `json:"transport,omitempty"`
+
+	// Port is the port the protocol is served on; 0 if none or unknown
+	Port uint16 `json:"port,omitempty"`
+
+	// MessageTypes names the messages of the protocol
+	MessageTypes []string `json:"message_types,omitempty"`
+
+	// Dissector describes the messages in the dissect package's language
+	Dissector string `json:"dissector,omitempty"`
+
+	// Sample is a captured message in hex, decoded with the dissector
+	Sample string `json:"sample,omitempty"`
+
+	// Samples are messages pinned from packet captures
+	Samples []SampleMessage `json:"samples,omitempty"`
+}
+
+// SampleMessage is a message pinned from a packet capture
+type SampleMessage struct {
+	// Source tells where the message was captured, such as
+	// "dump.pcapng packet 42, TCP 10.0.0.5:51000 -> 10.0.0.1:443"
+	Source string `json:"source"`
+
+	// Hex is the message in hex
+	Hex string `json:"hex"`
+}
+
+// IsZero reports whether no field is set
+func (d *ProtocolDetails) IsZero() bool {
+	return d == nil || d.Transport == "" && d.Port == 0 && len(d.MessageTypes) == 0 &&
+		strings.TrimSpace(d.Dissector) == "" && strings.TrimSpace(d.Sample) == "" && len(d.Samples) == 0
+}
+
+// Decode parses the dissector and decodes a message with it.
+//
+// Parameters:
+//   - data: The message; nil decodes the sample
+//
+// Returns:
+//   - The parsed dissector
+//   - The field tree of the message
+//   - An error if the dissector or the sample is invalid
+func (d *ProtocolDetails) Decode(data []byte) (*dissect.Spec, *dissect.Node, error) {
+	spec, err := dissect.Parse(d.Dissector)
+	if err != nil {
+		return nil, nil, err
+	}
+	if data == nil {
+		if data, err = dissect.ParseHex(d.Sample); err != nil {
+			return spec, nil, fmt.Errorf("invalid sample: %w", err)
+		}
+	}
+	node, err := dissect.Decode(spec, data)
+	return spec, node, err
+}
+
+// ExportOptions returns the details of the protocol dissector exports use
+func (d *ProtocolDetails) ExportOptions() dissect.Options {
+	return dissect.Options{Transport: d.Transport, Port: d.Port}
+}
+
+// StructureDetails are the fields of a structure analysis note
+type StructureDetails struct {
+	// Definition is the C definition of the structures, such as
+	// "struct hdr { uint32_t magic; uint16_t version; };"
+	Definition string `json:"definition,omitempty"`
+
+	// ABI is the name of the ABI the layout is computed for; see
+	// cstruct.ABINames. Empty selects cstruct.DefaultABI.
+	ABI string `json:"abi,omitempty"`
+
+	// Binary is the path of a file to overlay a structure on, and Offset
+	// the file offset of the structure in it, in decimal or 0x hex
+	Binary string `json:"binary,omitempty"`
+	Offset string `json:"offset,omitempty"`
+
+	// Record names the struct or union to overlay; empty selects the
+	// first one defined
+	Record string `json:"record,omitempty"`
+
+	// BigEndian decodes the overlaid bytes most significant byte first
+	BigEndian bool `json:"big_endian,omitempty"`
+}
+
+// IsZero reports whether no field is set
+func (d *StructureDetails) IsZero() bool {
+	return d == nil || strings.TrimSpace(d.Definition) == "" && d.ABI == "" && d.Binary == "" && d.Offset == ""
+}
+
+// Layouts parses the definition and computes the layouts of its structs
+// and unions for the chosen ABI.
+//
+// Returns:
+//   - The layouts in definition order
+//   - An error if the ABI is unknown or the definition is invalid
+func (d *StructureDetails) Layouts() ([]*cstruct.Layout, error) {
+	abi, err := cstruct.LookupABI(d.ABI)
+	if err != nil {
+		return nil, err
+	}
+	file, err := cstruct.Parse(d.Definition)
+	if err != nil {
+		return nil, err
+	}
+	return file.Layouts(abi)
+}
+
+// Overlay decodes the bytes of the binary at the offset through the
+// chosen struct or union.
+//
+// Returns:
+//   - The layout of the record
+//   - Its members decoded from the binary; members past the end of the
+//     file are marked missing
+//   - The offset the record was read from
+//   - An error if the definition, offset or binary cannot be used
+func (d *StructureDetails) Overlay() (*cstruct.Layout, []cstruct.Value, int64, error) {
+	abi, err := cstruct.LookupABI(d.ABI)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	abi = abi.WithByteOrder(d.BigEndian)
+	file, err := cstruct.Parse(d.Definition)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	name := d.Record
+	if name == "" {
+		names := file.RecordNames()
+		if len(names) == 0 {
+			return nil, nil, 0, fmt.Errorf("the definition has no struct or union")
+		}
+		name = names[0]
+	}
+	layout, err := file.Layout(name, abi)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	var offset int64
+	if text := strings.TrimSpace(d.Offset); text != "" {
+		offset, err = strconv.ParseInt(text, 0, 64)
+		if err != nil || offset < 0 {
+			return nil, nil, 0, fmt.Errorf("invalid offset %q: use a decimal or 0x hex file offset", d.Offset)
+		}
+	}
+	if d.Binary == "" {
+		return nil, nil, 0, fmt.Errorf("no binary to overlay the structure on")
+	}
+	f, err := os.Open(d.Binary)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer f.Close()
+	data := make([]byte, layout.Size)
+	n, err := f.ReadAt(data, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, 0, fmt.Errorf("reading %s: %w", d.Binary, err)
+	}
+	if n == 0 {
+		return nil, nil, 0, fmt.Errorf("offset 0x%x is past the end of %s", offset, d.Binary)
+	}
+	return layout, layout.Decode(data[:n], abi), offset, nil
+}
+
+// DetailField is a labelled value of a note's structured fields
+type DetailField struct {
+	Label string
+	Value string
+}
+
+// DetailFields returns the structured fields of a note that are filled in,
+// in display order, for listing alongside its other metadata.
+//
+// Returns:
+//   - The labelled values; empty if the note has no structured fields
+func (n *Note) DetailFields() []DetailField {
+	var fields []DetailField
+	add := func(label, value string) {
+		if value != "" {
+			fields = append(fields, DetailField{Label: label, Value: value})
+		}
+	}
+
+	if f := n.Function; f != nil {
+		add("Prototype", f.Prototype)
+		add("Calling convention", f.CallingConvention)
+		add("Arguments", strings.Join(f.Arguments, "; "))
+		if f.StackFrameSize != 0 {
+			add("Stack frame", strconv.FormatUint(f.StackFrameSize, 10)+" bytes")
+		}
+	}
+	if v := n.Vulnerability; v != nil {
+		if cwe, err := vuln.ParseCWE(v.CWE); err == nil {
+			add("CWE", cwe.String())
+		} else {
+			add("CWE", v.CWE)
+		}
+		if score, err := v.Score(); err != nil {
+			add("CVSS", err.Error())
+		} else if score.Version != "" {
+			add("CVSS", score.String())
+		}
+		add("CVSS vector", v.CVSSVector)
+		add("Affected binary", v.AffectedBinary)
+		add("Affected versions", strings.Join(v.AffectedVersions, ", "))
+		add("Status", v.Status)
+		add("Status dates", v.Timeline())
+	}
+	if p := n.Protocol; p != nil {
+		add("Transport", p.Transport)
+		if p.Port != 0 {
+			add("Port", strconv.Itoa(int(p.Port)))
+		}
+		add("Message types", strings.Join(p.MessageTypes, ", "))
+		if len(p.Samples) > 0 {
+			var sources []string
+			for _, sample := range p.Samples {
+				sources = append(sources, sample.Source)
+			}
+			add("Pinned samples", strings.Join(sources, "; "))
+		}
+		if strings.TrimSpace(p.Dissector) != "" {
+			spec, node, err := p.Decode(nil)
+			switch {
+			case spec == nil:
+				add("Dissector", "invalid definition: "+err.Error())
+			case len(spec.Messages) == 0:
+				add("Dissector", "no messages")
+			case len(spec.Messages) == 1:
+				add("Dissector", "message "+spec.Root().Name)
+			default:
+				add("Dissector", fmt.Sprintf("%d messages, decoding %s", len(spec.Messages), spec.Root().Name))
+			}
+			if strings.TrimSpace(p.Sample) != "" && spec != nil {
+				switch {
+				case err != nil:
+					add("Sample", err.Error())
+				case len(node.Problems()) > 0:
+					add("Sample", node.Problems()[0])
+				default:
+					add("Sample", fmt.Sprintf("%d bytes decoded", node.Length))
+				}
+			}
+		}
+	}
+	if s := n.Structure; !s.IsZero() {
+		abi := s.ABI
+		if abi == "" {
+			abi = cstruct.DefaultABI
+		}
+		add("ABI", abi)
+		if layouts, err := s.Layouts(); err != nil {
+			add("Structures", "invalid definition: "+err.Error())
+		} else {
+			var sizes []string
+			for _, l := range layouts {
+				sizes = append(sizes, fmt.Sprintf("%s (%d bytes)", l.Name, l.Size))
+			}
+			add("Structures", strings.Join(sizes, ", "))
+		}
+		if s.Binary != "" {
+			offset := s.Offset
+			if offset == "" {
+				offset = "0"
+			}
+			overlay := s.Binary + " @ " + offset
+			if s.Record != "" {
+				overlay = s.Record + " in " + overlay
+			}
+			if s.BigEndian {
+				overlay += " (big endian)"
+			}
+			add("Overlay", overlay)
+		}
+	}
+
+	// Fields of configured types follow the type's field order; fields
+	// the type does not define, or of an unknown type, come last by name
+	defined := make(map[string]bool)
+	if t, ok := LookupNoteType(n.ReverseEngType); ok {
+		for _, f := range t.Fields {
+			defined[f.Name] = true
+			add(f.DisplayLabel(), strings.ReplaceAll(n.Fields[f.Name], "\n", ", "))
+		}
+	}
+	var rest []string
+	for name := range n.Fields {
+		if !defined[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	for _, name := range rest {
+		add(name, strings.ReplaceAll(n.Fields[name], "\n", ", "))
+	}
+	return fields
+}
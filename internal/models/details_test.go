@@ -0,0 +1,81 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckVulnTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		ok       bool
+	}{
+		{"", VulnStatusSuspected, true},
+		{"", VulnStatusReported, true},
+		{VulnStatusSuspected, VulnStatusSuspected, true},
+		{VulnStatusSuspected, VulnStatusConfirmed, true},
+		{VulnStatusSuspected, VulnStatusReported, false},
+		{VulnStatusConfirmed, VulnStatusReported, true},
+		{VulnStatusConfirmed, VulnStatusFixed, true},
+		{VulnStatusConfirmed, VulnStatusSuspected, false},
+		{VulnStatusConfirmed, VulnStatusWontFix, false},
+		{VulnStatusReported, VulnStatusFixed, true},
+		{VulnStatusReported, VulnStatusWontFix, true},
+		{VulnStatusReported, VulnStatusDisclosed, true},
+		{VulnStatusFixed, VulnStatusDisclosed, true},
+		{VulnStatusFixed, VulnStatusReported, false},
+		{VulnStatusWontFix, VulnStatusDisclosed, true},
+		{VulnStatusWontFix, VulnStatusFixed, false},
+		{VulnStatusDisclosed, VulnStatusFixed, false},
+		{VulnStatusDisclosed, VulnStatusDisclosed, true},
+		{"", "unconfirmed", false},
+		{VulnStatusSuspected, "", false},
+	}
+	for _, tt := range tests {
+		if err := CheckVulnTransition(tt.from, tt.to); (err == nil) != tt.ok {
+			t.Errorf("%q -> %q: error %v; want allowed %v", tt.from, tt.to, err, tt.ok)
+		}
+	}
+}
+
+func TestSetStatus(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 10, 0, 0, 0, time.UTC) }
+	var v VulnerabilityDetails
+	for i, status := range []string{VulnStatusSuspected, VulnStatusConfirmed, VulnStatusReported, VulnStatusFixed} {
+		if err := v.SetStatus(status, day(i+1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := v.SetStatus(VulnStatusConfirmed, day(9)); err == nil {
+		t.Errorf("a fixed finding became confirmed")
+	}
+	// Keeping a status keeps the day it was reached
+	if err := v.SetStatus(VulnStatusFixed, day(9)); err != nil {
+		t.Fatal(err)
+	}
+	if v.Status != VulnStatusFixed {
+		t.Errorf("status %s; want %s", v.Status, VulnStatusFixed)
+	}
+	want := "suspected 2024-03-01, confirmed 2024-03-02, reported 2024-03-03, fixed 2024-03-04"
+	if got := v.Timeline(); got != want {
+		t.Errorf("timeline %q; want %q", got, want)
+	}
+}
+
+func TestAffects(t *testing.T) {
+	v := VulnerabilityDetails{AffectedVersions: []string{">= 1.0, < 1.4.2", "not a range <", "2.0 - 2.1"}}
+	tests := map[string]bool{
+		"0.9":     false,
+		"1.0":     true,
+		"1.4.2":   false,
+		"1.4.2-1": false,
+		"1.4.2rc": true,
+		"2.0.5":   true,
+		"2.2":     false,
+	}
+	for version, want := range tests {
+		if got := v.Affects(version); got != want {
+			t.Errorf("Affects(%s) = %v; want %v", version, got, want)
+		}
+	}
+}
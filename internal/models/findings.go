@@ -0,0 +1,123 @@
+// Package models provides data models and storage functionality for the RevEnGo application.
+// This file contains the summary of the vulnerability findings of projects.
+package models
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/leog/RevEnGo/internal/vuln"
+)
+
+// Finding is a vulnerability note with its CVSS score
+type Finding struct {
+	Note *Note
+
+	// Score is the score of the note's CVSS vector; unscored without a
+	// vector or when the vector does not parse
+	Score vuln.Score
+
+	// Err reports a CVSS vector that does not parse
+	Err error
+}
+
+// Severity returns the severity of the finding's score
+func (f Finding) Severity() vuln.Severity {
+	return f.Score.Severity
+}
+
+// Status returns the finding's status; findings without one are suspected
+func (f Finding) Status() string {
+	if v := f.Note.Vulnerability; v != nil && v.Status != "" {
+		return v.Status
+	}
+	return VulnStatusSuspected
+}
+
+// Binary returns the binary the finding is in: the affected binary, or
+// the note's binary
+func (f Finding) Binary() string {
+	if v := f.Note.Vulnerability; v != nil && v.AffectedBinary != "" {
+		return v.AffectedBinary
+	}
+	return f.Note.BinaryName
+}
+
+// Findings scores the vulnerability notes among notes.
+//
+// Returns:
+//   - The findings, most severe first, then by score and title
+func Findings(notes []*Note) []Finding {
+	var findings []Finding
+	for _, note := range notes {
+		if note.ReverseEngType != RETypeVulnerability {
+			continue
+		}
+		finding := Finding{Note: note}
+		finding.Score, finding.Err = note.Vulnerability.Score()
+		findings = append(findings, finding)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity().Rank() != b.Severity().Rank() {
+			return a.Severity().Rank() > b.Severity().Rank()
+		}
+		if a.Score.Value != b.Score.Value {
+			return a.Score.Value > b.Score.Value
+		}
+		return strings.ToLower(a.Note.Title) < strings.ToLower(b.Note.Title)
+	})
+	return findings
+}
+
+// FindingSummary counts the findings of a project
+type FindingSummary struct {
+	// ProjectID is the project; empty for notes not filed under one
+	ProjectID string
+
+	// Findings are the project's findings, most severe first
+	Findings []Finding
+
+	// BySeverity and ByStatus count the findings
+	BySeverity map[vuln.Severity]int
+	ByStatus   map[string]int
+
+	// Open counts the findings still needing work, by severity
+	Open map[vuln.Severity]int
+}
+
+// SummarizeFindings groups the vulnerability notes among notes by project.
+//
+// Returns:
+//   - A summary per project with findings, ordered by project ID, with
+//     notes not filed under a project last
+func SummarizeFindings(notes []*Note) []*FindingSummary {
+	byProject := make(map[string]*FindingSummary)
+	var summaries []*FindingSummary
+	for _, finding := range Findings(notes) {
+		s := byProject[finding.Note.ProjectID]
+		if s == nil {
+			s = &FindingSummary{
+				ProjectID:  finding.Note.ProjectID,
+				BySeverity: make(map[vuln.Severity]int),
+				ByStatus:   make(map[string]int),
+				Open:       make(map[vuln.Severity]int),
+			}
+			byProject[s.ProjectID] = s
+			summaries = append(summaries, s)
+		}
+		s.Findings = append(s.Findings, finding)
+		s.BySeverity[finding.Severity()]++
+		s.ByStatus[finding.Status()]++
+		if IsOpenVulnStatus(finding.Status()) {
+			s.Open[finding.Severity()]++
+		}
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if (summaries[i].ProjectID == "") != (summaries[j].ProjectID == "") {
+			return summaries[j].ProjectID == ""
+		}
+		return summaries[i].ProjectID < summaries[j].ProjectID
+	})
+	return summaries
+}
@@ -0,0 +1,241 @@
+// Package models provides data models and storage functionality for the RevEnGo application.
+// This file contains helpers for wiki-style [[note links]] between notes.
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// wikiLinkPattern matches [[target]] and [[target|label]] links
+var wikiLinkPattern = regexp.MustCompile(`\[\[([^\[\]|\n]+)(\|[^\[\]\n]*)?\]\]`)
+
+// WikiLink is a [[target|label]] link found in note content
+type WikiLink struct {
+	// Target is the title or ID of the linked note
+	Target string
+
+	// Label is the optional display text after the "|"
+	Label string
+}
+
+// ParseWikiLinks finds all wiki-style links in note content.
+//
+// Parameters:
+//   - content: The note content to scan
+//
+// Returns:
+//   - The links in the order they appear
+func ParseWikiLinks(content string) []WikiLink {
+	matches := wikiLinkPattern.FindAllStringSubmatch(content, -1)
+	links := make([]WikiLink, 0, len(matches))
+	for _, m := range matches {
+		links = append(links, WikiLink{
+			Target: strings.TrimSpace(m[1]),
+			Label:  strings.TrimSpace(strings.TrimPrefix(m[2], "|")),
+		})
+	}
+	return links
+}
+
+// FindNoteByLink returns the note a link target refers to.
+// Targets match a note ID exactly, or a note title ignoring case.
+//
+// Parameters:
+//   - target: The link target
+//   - notes: The notes to search
+//
+// Returns:
+//   - The matching note, or nil if there is none
+func FindNoteByLink(target string, notes []*Note) *Note {
+	target = strings.TrimSpace(target)
+	for _, note := range notes {
+		if note.ID == target {
+			return note
+		}
+	}
+	for _, note := range notes {
+		if strings.EqualFold(note.Title, target) {
+			return note
+		}
+	}
+	return nil
+}
+
+// ResolveLinks returns the IDs of the notes linked from content.
+// Links to unknown notes are ignored and each ID appears once.
+//
+// Parameters:
+//   - content: The note content containing links
+//   - notes: All known notes
+//
+// Returns:
+//   - The IDs of linked notes, in order of first appearance
+func ResolveLinks(content string, notes []*Note) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, link := range ParseWikiLinks(content) {
+		note := FindNoteByLink(link.Target, notes)
+		if note == nil || seen[note.ID] {
+			continue
+		}
+		seen[note.ID] = true
+		ids = append(ids, note.ID)
+	}
+	return ids
+}
+
+// Backlinks returns the notes that reference the given note, either through
+// RelatedNotes or through a link in their content.
+//
+// Parameters:
+//   - noteID: The ID of the referenced note
+//   - notes: All known notes
+//
+// Returns:
+//   - The notes linking to noteID
+func Backlinks(noteID string, notes []*Note) []*Note {
+	var result []*Note
+	for _, note := range notes {
+		if note.ID == noteID {
+			continue
+		}
+		if containsString(note.RelatedNotes, noteID) || containsString(ResolveLinks(note.Content, notes), noteID) {
+			result = append(result, note)
+		}
+	}
+	return result
+}
+
+// ReplaceWikiLinks replaces every wiki-style link in content with the
+// text returned by replace.
+//
+// Parameters:
+//   - content: The text containing links
+//   - replace: Returns the replacement text for a link
+//
+// Returns:
+//   - The content with links replaced
+func ReplaceWikiLinks(content string, replace func(link WikiLink) string) string {
+	return wikiLinkPattern.ReplaceAllStringFunc(content, func(match string) string {
+		m := wikiLinkPattern.FindStringSubmatch(match)
+		return replace(WikiLink{
+			Target: strings.TrimSpace(m[1]),
+			Label:  strings.TrimSpace(strings.TrimPrefix(m[2], "|")),
+		})
+	})
+}
+
+// RenameLinks rewrites links pointing at oldTitle to point at newTitle.
+// Link labels are preserved, and links by ID are unaffected.
+//
+// Parameters:
+//   - content: The note content to rewrite
+//   - oldTitle: The previous title of the renamed note
+//   - newTitle: The new title of the renamed note
+//
+// Returns:
+//   - The rewritten content
+//   - Whether any link was changed
+func RenameLinks(content, oldTitle, newTitle string) (string, bool) {
+	changed := false
+	result := ReplaceWikiLinks(content, func(link WikiLink) string {
+		if !strings.EqualFold(link.Target, strings.TrimSpace(oldTitle)) {
+			return link.String()
+		}
+		changed = true
+		link.Target = newTitle
+		return link.String()
+	})
+	return result, changed
+}
+
+// String formats the link back into [[target|label]] syntax
+func (l WikiLink) String() string {
+	if l.Label != "" {
+		return "[[" + l.Target + "|" + l.Label + "]]"
+	}
+	return "[[" + l.Target + "]]"
+}
+
+// mergeLinks returns a note's related notes with the notes its content
+// links to added and the notes only its saved content linked to removed
+func mergeLinks(note *Note, notes []*Note) []string {
+	var removed []string
+	for _, saved := range notes {
+		if saved.ID == note.ID && note.ID != "" {
+			removed = ResolveLinks(saved.Content, notes)
+			break
+		}
+	}
+	linked := ResolveLinks(note.Content, notes)
+	var related []string
+	for _, id := range note.RelatedNotes {
+		if !containsString(related, id) && (containsString(linked, id) || !containsString(removed, id)) {
+			related = append(related, id)
+		}
+	}
+	for _, id := range linked {
+		if !containsString(related, id) {
+			related = append(related, id)
+		}
+	}
+	return related
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveLinkedNote saves a note and keeps links consistent across notes:
+// RelatedNotes gains the notes of the [[links]] in the note's content and
+// loses those whose links were removed from it, keeping the notes related
+// by hand, and when the title changed, links to the old title in other
+// notes are rewritten.
+//
+// Parameters:
+//   - store: The store to save to
+//   - note: The note to save
+//   - oldTitle: The note's previous title, or "" for a new note
+//
+// Returns:
+//   - The other notes whose links were rewritten and saved
+//   - An error if a note could not be listed or saved
+func SaveLinkedNote(store NoteStore, note *Note, oldTitle string) ([]*Note, error) {
+	notes, err := store.ListNotes()
+	if err != nil {
+		return nil, err
+	}
+	note.RelatedNotes = mergeLinks(note, notes)
+
+	if err := store.SaveNote(note); err != nil {
+		return nil, err
+	}
+
+	if oldTitle == "" || oldTitle == note.Title {
+		return nil, nil
+	}
+
+	var renamed []*Note
+	for _, other := range notes {
+		if other.ID == note.ID {
+			continue
+		}
+		content, changed := RenameLinks(other.Content, oldTitle, note.Title)
+		if !changed {
+			continue
+		}
+		other.Content = content
+		if err := store.SaveNote(other); err != nil {
+			return renamed, err
+		}
+		renamed = append(renamed, other)
+	}
+	return renamed, nil
+}
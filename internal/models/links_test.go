@@ -0,0 +1,84 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSaveLinkedNote(t *testing.T) {
+	store, err := NewFileNoteStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var others []*Note
+	for _, title := range []string{"Parser", "Reader", "Writer"} {
+		note := &Note{Title: title}
+		if err := store.SaveNote(note); err != nil {
+			t.Fatal(err)
+		}
+		others = append(others, note)
+	}
+	parser, reader, writer := others[0].ID, others[1].ID, others[2].ID
+
+	// Each step saves the note with a content and related notes, and
+	// checks the related notes saved
+	note := &Note{Title: "Overflow"}
+	steps := []struct {
+		content string
+		related []string
+		want    []string
+	}{
+		{"Calls [[Parser]].", []string{writer}, []string{writer, parser}},
+		{"Calls [[Parser]] and [[Reader]].", []string{writer, parser}, []string{writer, parser, reader}},
+		{"Calls [[Reader]].", []string{writer, parser, reader}, []string{writer, reader}},
+		{"Calls nothing.", []string{writer, reader}, []string{writer}},
+		{"Calls [[Writer]].", []string{writer}, []string{writer}},
+	}
+	for i, step := range steps {
+		note.Content, note.RelatedNotes = step.content, step.related
+		if _, err := SaveLinkedNote(store, note, ""); err != nil {
+			t.Fatal(err)
+		}
+		saved, err := store.GetNote(note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(saved.RelatedNotes, step.want) {
+			t.Errorf("step %d: related notes %q, want %q", i+1, saved.RelatedNotes, step.want)
+		}
+	}
+}
+
+func TestSaveLinkedNoteRename(t *testing.T) {
+	store, err := NewFileNoteStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := &Note{Title: "Parser"}
+	if err := store.SaveNote(target); err != nil {
+		t.Fatal(err)
+	}
+	linking := &Note{Title: "Overflow", Content: "See [[Parser|the parser]] and [[parser]]."}
+	if _, err := SaveLinkedNote(store, linking, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	target.Title = "Header parser"
+	renamed, err := SaveLinkedNote(store, target, "Parser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(renamed) != 1 || renamed[0].ID != linking.ID {
+		t.Fatalf("renamed %v, want the linking note", renamed)
+	}
+	saved, err := store.GetNote(linking.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "See [[Header parser|the parser]] and [[Header parser]]."; saved.Content != want {
+		t.Errorf("content %q, want %q", saved.Content, want)
+	}
+	if !slices.Equal(saved.RelatedNotes, []string{target.ID}) {
+		t.Errorf("related notes %q, want %q", saved.RelatedNotes, target.ID)
+	}
+}
@@ -4,12 +4,14 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"time"
 )
 
-// Reverse Engineering note types
+// Built-in reverse engineering note types; more can be registered with
+// RegisterNoteTypes
 const (
 	RETypeGeneral           = "general"
 	RETypeFunctionAnalysis  = "function_analysis"
@@ -51,6 +53,19 @@ type Note struct {
 	AddressRange   string   `json:"address_range,omitempty"`
 	RelatedNotes   []string `json:"related_notes,omitempty"`
 	ReverseEngType string   `json:"reverse_eng_type,omitempty"`
+
+	// Bookmarks are the 1-based content lines the user has bookmarked
+	Bookmarks []int `json:"bookmarks,omitempty"`
+
+	// Structured fields of the note's type; nil when not filled in
+	Function      *FunctionDetails      `json:"function,omitempty"`
+	Vulnerability *VulnerabilityDetails `json:"vulnerability,omitempty"`
+	Protocol      *ProtocolDetails      `json:"protocol,omitempty"`
+	Structure     *StructureDetails     `json:"structure,omitempty"`
+
+	// Fields are the structured fields of note types defined in the
+	// configuration, by field name
+	Fields map[string]string `json:"fields,omitempty"`
 }
 
 // NoteStore defines the interface for note storage operations.
@@ -115,7 +130,7 @@ func (s *FileNoteStore) SaveNote(note *Note) error {
 	// If it's a new note, generate an ID and set creation time
 	if note.ID == "" {
 		// Use a timestamp-based ID format (YYYYMMDhhmmss)
-		note.ID = time.Now().Format("20060102150405")
+		note.ID = newFileID(s.BasePath)
 		note.Created = time.Now()
 	}
 
@@ -215,3 +230,17 @@ func (s *FileNoteStore) DeleteNote(id string) error {
 	// Delete the file from the filesystem
 	return os.Remove(filename)
 }
+
+// newFileID generates a timestamp-based ID (YYYYMMDDhhmmss) that is not yet
+// used by a file in dir. Items created within the same second, as happens when
+// scripting the command-line interface, get a numeric suffix.
+func newFileID(dir string) string {
+	base := time.Now().Format("20060102150405")
+	id := base
+	for n := 2; ; n++ {
+		if _, err := os.Stat(filepath.Join(dir, id+".json")); os.IsNotExist(err) {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+}
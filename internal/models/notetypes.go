@@ -0,0 +1,217 @@
+// Package models provides data models and storage functionality for the RevEnGo application.
+// This file contains the registry of note types.
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// Kinds of custom note type fields
+const (
+	// FieldText is a single line of text
+	FieldText = "text"
+
+	// FieldList is a list entered one item per line
+	FieldList = "list"
+
+	// FieldNumber is an integer, written in decimal or as 0x hex
+	FieldNumber = "number"
+
+	// FieldChoice is one of a fixed set of choices
+	FieldChoice = "choice"
+)
+
+// NoteType describes a kind of note: how it is shown, the template new
+// notes of the type start from and the structured fields it carries.
+// The built-in types keep their structured fields in the typed Function,
+// Vulnerability and Protocol details of a note; types defined in the
+// configuration describe theirs with Fields and keep them in Note.Fields.
+type NoteType struct {
+	// Name identifies the type in notes, such as "malware_config"
+	Name string `toml:"name"`
+
+	// Label is the name shown to the user; the Name is shown if empty
+	Label string `toml:"label,omitempty"`
+
+	// Color is the type's color as "#rrggbb"; built-in types use theme colors
+	Color string `toml:"color,omitempty"`
+
+	// Icon names the icon shown for the type, such as "warning" or "storage"
+	Icon string `toml:"icon,omitempty"`
+
+	// Template is the Markdown template new notes of the type start from
+	Template string `toml:"template,omitempty"`
+
+	// Fields are the structured fields of the type
+	Fields []NoteField `toml:"fields,omitempty"`
+}
+
+// NoteField describes a structured field of a note type
+type NoteField struct {
+	// Name is the key the value is stored under in Note.Fields
+	Name string `toml:"name"`
+
+	// Label is shown next to the field; the Name is shown if empty
+	Label string `toml:"label,omitempty"`
+
+	// Kind is FieldText (the default), FieldList, FieldNumber or FieldChoice
+	Kind string `toml:"kind,omitempty"`
+
+	// Choices are the values offered by a FieldChoice field
+	Choices []string `toml:"choices,omitempty"`
+
+	// Placeholder is the hint shown in the empty field
+	Placeholder string `toml:"placeholder,omitempty"`
+}
+
+// DisplayLabel returns the label of the type, or its name if it has none
+func (t NoteType) DisplayLabel() string {
+	if t.Label != "" {
+		return t.Label
+	}
+	return t.Name
+}
+
+// DisplayLabel returns the label of the field, or its name if it has none
+func (f NoteField) DisplayLabel() string {
+	if f.Label != "" {
+		return f.Label
+	}
+	return f.Name
+}
+
+// builtinNoteTypes are the note types every installation has
+var builtinNoteTypes = []NoteType{
+	{Name: RETypeGeneral, Label: "General", Icon: "document", Template: builtinTemplates[RETypeGeneral]},
+	{Name: RETypeFunctionAnalysis, Label: "Function Analysis", Icon: "document", Template: builtinTemplates[RETypeFunctionAnalysis]},
+	{Name: RETypeStructureAnalysis, Label: "Structure Analysis", Icon: "storage", Template: builtinTemplates[RETypeStructureAnalysis]},
+	{Name: RETypeProtocolAnalysis, Label: "Protocol Analysis", Icon: "mail", Template: builtinTemplates[RETypeProtocolAnalysis]},
+	{Name: RETypeVulnerability, Label: "Vulnerability", Icon: "warning", Template: builtinTemplates[RETypeVulnerability]},
+}
+
+// noteTypes holds the registered note types: the built-in types followed
+// by those registered from the configuration
+var (
+	noteTypesMu sync.RWMutex
+	noteTypes   = append([]NoteType(nil), builtinNoteTypes...)
+)
+
+// Patterns that type and field names and colors must match
+var (
+	noteTypeNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	noteColorPattern    = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+)
+
+// RegisterNoteTypes adds note types to the registry, typically those
+// defined in the configuration. Invalid types are skipped.
+//
+// Parameters:
+//   - types: The note types to add
+//
+// Returns:
+//   - An error describing the types that were skipped
+func RegisterNoteTypes(types []NoteType) error {
+	noteTypesMu.Lock()
+	defer noteTypesMu.Unlock()
+
+	var errs []error
+	for _, t := range types {
+		if err := validateNoteType(t); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if _, ok := lookupNoteType(t.Name); ok {
+			errs = append(errs, fmt.Errorf("note type %q is already defined", t.Name))
+			continue
+		}
+		noteTypes = append(noteTypes, t)
+	}
+	return errors.Join(errs...)
+}
+
+// validateNoteType checks a note type defined by the user
+func validateNoteType(t NoteType) error {
+	if !noteTypeNamePattern.MatchString(t.Name) {
+		return fmt.Errorf("invalid note type name %q: use lowercase letters, digits, '-' and '_'", t.Name)
+	}
+	if t.Color != "" && !noteColorPattern.MatchString(t.Color) {
+		return fmt.Errorf("note type %q: invalid color %q: use #rrggbb", t.Name, t.Color)
+	}
+
+	seen := make(map[string]bool)
+	for _, f := range t.Fields {
+		if f.Name == "" {
+			return fmt.Errorf("note type %q: a field has no name", t.Name)
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("note type %q: field %q is defined twice", t.Name, f.Name)
+		}
+		seen[f.Name] = true
+
+		switch f.Kind {
+		case "", FieldText, FieldList, FieldNumber:
+		case FieldChoice:
+			if len(f.Choices) == 0 {
+				return fmt.Errorf("note type %q: choice field %q has no choices", t.Name, f.Name)
+			}
+		default:
+			return fmt.Errorf("note type %q: field %q has unknown kind %q", t.Name, f.Name, f.Kind)
+		}
+	}
+	return nil
+}
+
+// NoteTypes returns the registered note types in display order: the
+// built-in types followed by those defined in the configuration
+func NoteTypes() []NoteType {
+	noteTypesMu.RLock()
+	defer noteTypesMu.RUnlock()
+	return append([]NoteType(nil), noteTypes...)
+}
+
+// NoteTypeNames returns the names of the registered note types in display order
+func NoteTypeNames() []string {
+	noteTypesMu.RLock()
+	defer noteTypesMu.RUnlock()
+	names := make([]string, len(noteTypes))
+	for i, t := range noteTypes {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// LookupNoteType returns the registered note type with a name.
+// Notes may carry types that are not registered, for example after a
+// type was removed from the configuration; those are reported as unknown
+// and should be kept as they are.
+//
+// Parameters:
+//   - name: The type name
+//
+// Returns:
+//   - The note type
+//   - Whether the type is registered
+func LookupNoteType(name string) (NoteType, bool) {
+	noteTypesMu.RLock()
+	defer noteTypesMu.RUnlock()
+	return lookupNoteType(name)
+}
+
+// lookupNoteType finds a note type; the caller holds noteTypesMu
+func lookupNoteType(name string) (NoteType, bool) {
+	for _, t := range noteTypes {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return NoteType{}, false
+}
+
+// IsNoteType reports whether a note type is registered
+func IsNoteType(name string) bool {
+	_, ok := LookupNoteType(name)
+	return ok
+}
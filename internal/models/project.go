@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -28,6 +29,47 @@ type Project struct {
 
 	// Modified is the timestamp when the project was last edited
 	Modified time.Time `json:"modified"`
+
+	// Captures are the paths of the packet capture files attached to the
+	// project, in .pcap or .pcapng format
+	Captures []string `json:"captures,omitempty"`
+
+	// Binaries are the paths of the executables, libraries and firmware
+	// images attached to the project
+	Binaries []string `json:"binaries,omitempty"`
+}
+
+// FindBinary finds the attached file a note's binary name refers to.
+//
+// Parameters:
+//   - projects: The projects whose binaries are searched
+//   - projectID: The note's project, searched first; may be empty
+//   - name: The binary name, a file name or the path of an attached file
+//
+// Returns:
+//   - The path of the attached file; empty if no project has one by the name
+func FindBinary(projects []*Project, projectID, name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ""
+	}
+	find := func(own bool) string {
+		for _, project := range projects {
+			if (project.ID == projectID) != own {
+				continue
+			}
+			for _, path := range project.Binaries {
+				if path == name || strings.EqualFold(filepath.Base(path), filepath.Base(name)) {
+					return path
+				}
+			}
+		}
+		return ""
+	}
+	if path := find(true); path != "" {
+		return path
+	}
+	return find(false)
 }
 
 // ProjectStore defines the interface for project storage operations.
@@ -92,7 +134,7 @@ func (s *FileProjectStore) SaveProject(project *Project) error {
 	// If it's a new project, generate an ID and set creation time
 	if project.ID == "" {
 		// Use a timestamp-based ID format (YYYYMMDDhhmmss)
-		project.ID = time.Now().Format("20060102150405")
+		project.ID = newFileID(s.BasePath)
 		project.Created = time.Now()
 	}
 
@@ -0,0 +1,120 @@
+// Package models provides data models and storage functionality for the RevEnGo application.
+// This file contains note search and filtering helpers.
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// NoteFilter narrows a list of notes by project, tag and type.
+// Empty fields match every note.
+type NoteFilter struct {
+	ProjectID      string
+	Tag            string
+	ReverseEngType string
+}
+
+// Matches reports whether a note passes the filter
+func (f NoteFilter) Matches(note *Note) bool {
+	if f.ProjectID != "" && note.ProjectID != f.ProjectID {
+		return false
+	}
+	if f.ReverseEngType != "" && note.ReverseEngType != f.ReverseEngType {
+		return false
+	}
+	if f.Tag != "" {
+		found := false
+		for _, tag := range note.Tags {
+			if strings.EqualFold(tag, f.Tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterNotes returns the notes that pass the filter, in their original order.
+//
+// Parameters:
+//   - notes: The notes to filter
+//   - filter: The criteria to apply
+//
+// Returns:
+//   - The matching notes
+func FilterNotes(notes []*Note, filter NoteFilter) []*Note {
+	var result []*Note
+	for _, note := range notes {
+		if filter.Matches(note) {
+			result = append(result, note)
+		}
+	}
+	return result
+}
+
+// SearchNotes finds notes containing every word of the query, ignoring case.
+// Titles, content, tags, binary names, function references and address
+// ranges are searched. Notes matching in the title are ranked first.
+//
+// Parameters:
+//   - notes: The notes to search
+//   - query: Space separated search terms
+//
+// Returns:
+//   - The matching notes, best matches first
+func SearchNotes(notes []*Note, query string) []*Note {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	type hit struct {
+		note  *Note
+		score int
+	}
+	var hits []hit
+
+	for _, note := range notes {
+		title := strings.ToLower(note.Title)
+		body := strings.ToLower(strings.Join([]string{
+			note.Content,
+			strings.Join(note.Tags, " "),
+			note.BinaryName,
+			strings.Join(note.FunctionRefs, " "),
+			note.AddressRange,
+			note.ReverseEngType,
+		}, "\n"))
+
+		score := 0
+		for _, term := range terms {
+			switch {
+			case strings.Contains(title, term):
+				score += 10
+			case strings.Contains(body, term):
+				score++
+			default:
+				score = -1
+			}
+			if score < 0 {
+				break
+			}
+		}
+		if score > 0 {
+			hits = append(hits, hit{note: note, score: score})
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].score > hits[j].score
+	})
+
+	result := make([]*Note, len(hits))
+	for i, h := range hits {
+		result[i] = h.note
+	}
+	return result
+}
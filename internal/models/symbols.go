@@ -0,0 +1,169 @@
+// Package models provides data models and storage functionality for the RevEnGo application.
+// This file contains the symbol index used to resolve addresses and names to notes.
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SymbolIndex maps symbol names and addresses mentioned in notes back to the
+// notes that describe them. It is rebuilt whenever the note list changes.
+type SymbolIndex struct {
+	// symbols maps lower-case names (titles, IDs, function references) to note IDs
+	symbols map[string]string
+
+	// ranges lists the address ranges covered by notes
+	ranges []addressRange
+}
+
+// addressRange is an inclusive range of addresses described by a note
+type addressRange struct {
+	start, end uint64
+	noteID     string
+}
+
+// NewSymbolIndex builds an index over the names and address ranges of notes.
+//
+// Parameters:
+//   - notes: The notes to index
+//
+// Returns:
+//   - The populated index
+func NewSymbolIndex(notes []*Note) *SymbolIndex {
+	idx := &SymbolIndex{symbols: make(map[string]string)}
+
+	for _, note := range notes {
+		idx.addSymbol(note.ID, note.ID)
+		idx.addSymbol(note.Title, note.ID)
+
+		// Function references may carry extra text, e.g. "sub_401000 (decrypt)"
+		for _, ref := range note.FunctionRefs {
+			if fields := strings.Fields(ref); len(fields) > 0 {
+				idx.addSymbol(fields[0], note.ID)
+			}
+		}
+
+		if start, end, err := ParseAddressRange(note.AddressRange); err == nil {
+			idx.ranges = append(idx.ranges, addressRange{start: start, end: end, noteID: note.ID})
+		}
+	}
+
+	return idx
+}
+
+// addSymbol records a name for a note, keeping the first note to claim it
+func (idx *SymbolIndex) addSymbol(name, noteID string) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if key == "" {
+		return
+	}
+	if _, exists := idx.symbols[key]; !exists {
+		idx.symbols[key] = noteID
+	}
+}
+
+// Len returns the number of names and address ranges in the index
+func (idx *SymbolIndex) Len() int {
+	if idx == nil {
+		return 0
+	}
+	return len(idx.symbols) + len(idx.ranges)
+}
+
+// Lookup finds the note that describes a symbol name or address.
+//
+// Parameters:
+//   - word: A symbol name, note title or ID, or a hexadecimal address
+//
+// Returns:
+//   - The ID of the matching note
+//   - Whether a match was found
+func (idx *SymbolIndex) Lookup(word string) (string, bool) {
+	if idx == nil {
+		return "", false
+	}
+
+	word = strings.Trim(strings.TrimSpace(word), "<>")
+	if id, ok := idx.symbols[strings.ToLower(word)]; ok {
+		return id, true
+	}
+
+	// Fall back to the narrowest address range containing the address.
+	// Words without digits (such as "add") are never treated as addresses.
+	if !strings.ContainsAny(word, "0123456789") {
+		return "", false
+	}
+	addr, err := ParseAddress(word)
+	if err != nil {
+		return "", false
+	}
+	best, found := addressRange{}, false
+	for _, r := range idx.ranges {
+		if addr >= r.start && addr <= r.end && (!found || r.end-r.start < best.end-best.start) {
+			best, found = r, true
+		}
+	}
+	return best.noteID, found
+}
+
+// ParseAddress parses a hexadecimal address written as "0x401000",
+// "401000h" or a bare hex string such as "00401000".
+//
+// Parameters:
+//   - s: The address text
+//
+// Returns:
+//   - The address value
+//   - An error if the text is not a valid address
+func ParseAddress(s string) (uint64, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case strings.HasPrefix(s, "0x"):
+		s = s[2:]
+	case strings.HasSuffix(s, "h"):
+		s = s[:len(s)-1]
+	}
+	if s == "" {
+		return 0, fmt.Errorf("empty address")
+	}
+	return strconv.ParseUint(s, 16, 64)
+}
+
+// ParseAddressRange parses a note's address range, such as "0x1000-0x2000",
+// "0x1000..0x2000" or a single address "0x1000". The end is inclusive.
+//
+// Parameters:
+//   - s: The address range text
+//
+// Returns:
+//   - The start and end addresses
+//   - An error if the text is not a valid range
+func ParseAddressRange(s string) (uint64, uint64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, 0, fmt.Errorf("empty address range")
+	}
+
+	startText, endText := s, s
+	for _, sep := range []string{"..", "-", ":", " "} {
+		if before, after, found := strings.Cut(s, sep); found {
+			startText, endText = before, after
+			break
+		}
+	}
+
+	start, err := ParseAddress(startText)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid start address %q: %w", startText, err)
+	}
+	end, err := ParseAddress(endText)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid end address %q: %w", endText, err)
+	}
+	if end < start {
+		return 0, 0, fmt.Errorf("address range %q ends before it starts", s)
+	}
+	return start, end, nil
+}
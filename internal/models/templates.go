@@ -0,0 +1,266 @@
+// Package models provides data models and storage functionality for the RevEnGo application.
+// This file contains note templates and the placeholders filled into them.
+package models
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TemplatesDir is the directory in the data directory holding user templates.
+// Templates for one note type go in a subdirectory named after the type,
+// such as templates/vulnerability/heap-overflow.md; templates directly in
+// the directory are offered for every type.
+const TemplatesDir = "templates"
+
+// DefaultTemplateName is the name of the template applied when a note type
+// is picked. A user template with this name replaces the built-in one.
+const DefaultTemplateName = "default"
+
+// Template is a Markdown skeleton for the content of a note
+type Template struct {
+	// Name identifies the template among those of its note type
+	Name string
+
+	// NoteType is the note type the template is for; empty for every type
+	NoteType string
+
+	// Content is the Markdown text, which may contain placeholders
+	Content string
+
+	// Path is the file the template was read from; empty for built-in templates
+	Path string
+}
+
+// builtinTemplates are the default templates of the built-in note types
+var builtinTemplates = map[string]string{
+	RETypeGeneral: `## Summary
+
+## Details
+
+## References
+`,
+	RETypeFunctionAnalysis: `# Function at {{start}} in {{binary}}
+
+**Address range:** {{address_range}} ({{size}} bytes)
+
+## Purpose
+
+## Calling Convention
+
+## Arguments
+
+| # | Location | Type | Meaning |
+|---|----------|------|---------|
+| 1 |          |      |         |
+
+## Return Value
+
+## Side Effects
+
+## Callers and Callees
+`,
+	RETypeStructureAnalysis: `# Structure in {{binary}}
+
+**Address range:** {{address_range}} ({{size}} bytes)
+
+## Layout
+
+| Offset | Size | Type | Name | Notes |
+|--------|------|------|------|-------|
+| 0x00   |      |      |      |       |
+
+## Allocation and Lifetime
+
+## Used By
+`,
+	RETypeProtocolAnalysis: `# Protocol handled by {{binary}}
+
+**Parser:** {{address_range}}
+
+## Transport
+
+## Message Format
+
+| Offset | Size | Field | Notes |
+|--------|------|-------|-------|
+| 0      |      |       |       |
+
+## State Machine
+
+## Sample Exchanges
+`,
+	RETypeVulnerability: `# Vulnerability in {{binary}} at {{start}}
+
+**Affected code:** {{address_range}}
+**Found:** {{date}}
+
+## Root Cause
+
+## Trigger
+
+## Impact
+
+## Proof of Concept
+
+` + "```" + `
+` + "```" + `
+
+## Mitigation
+`,
+}
+
+// BuiltinTemplates returns the default template of every registered note
+// type that has one
+func BuiltinTemplates() []Template {
+	var templates []Template
+	for _, t := range NoteTypes() {
+		if t.Template == "" {
+			continue
+		}
+		templates = append(templates, Template{
+			Name:     DefaultTemplateName,
+			NoteType: t.Name,
+			Content:  t.Template,
+		})
+	}
+	return templates
+}
+
+// LoadTemplates returns the templates of the registered note types
+// together with the user templates in a directory. A user template with
+// the same note type and name as a note type's template replaces it.
+//
+// Parameters:
+//   - dir: The user template directory; it need not exist
+//
+// Returns:
+//   - The templates, sorted by note type and name
+//   - An error if some user templates cannot be read; the templates that
+//     could be read are still returned
+func LoadTemplates(dir string) ([]Template, error) {
+	byKey := make(map[string]Template)
+	for _, t := range BuiltinTemplates() {
+		byKey[t.NoteType+"/"+t.Name] = t
+	}
+
+	var errs []error
+	add := func(pattern, noteType string) {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		for _, match := range matches {
+			data, err := os.ReadFile(match)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			name := strings.TrimSuffix(filepath.Base(match), filepath.Ext(match))
+			byKey[noteType+"/"+name] = Template{Name: name, NoteType: noteType, Content: string(data), Path: match}
+		}
+	}
+
+	if dir != "" {
+		add(filepath.Join(dir, "*.md"), "")
+		entries, err := os.ReadDir(dir)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				add(filepath.Join(dir, entry.Name(), "*.md"), entry.Name())
+			}
+		}
+	}
+
+	templates := make([]Template, 0, len(byKey))
+	for _, t := range byKey {
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].NoteType != templates[j].NoteType {
+			return templates[i].NoteType < templates[j].NoteType
+		}
+		return templates[i].Name < templates[j].Name
+	})
+	return templates, errors.Join(errs...)
+}
+
+// TemplatesFor returns the templates offered for a note type: the type's
+// default template first, then its other templates, then the templates for
+// every type.
+//
+// Parameters:
+//   - templates: The templates to choose from
+//   - noteType: The note type
+//
+// Returns:
+//   - The matching templates
+func TemplatesFor(templates []Template, noteType string) []Template {
+	var defaults, own, shared []Template
+	for _, t := range templates {
+		switch {
+		case t.NoteType == noteType && t.Name == DefaultTemplateName:
+			defaults = append(defaults, t)
+		case t.NoteType == noteType:
+			own = append(own, t)
+		case t.NoteType == "":
+			shared = append(shared, t)
+		}
+	}
+	return append(append(defaults, own...), shared...)
+}
+
+// TemplateValues are the values filled into a template's placeholders
+type TemplateValues struct {
+	Title        string
+	BinaryName   string
+	AddressRange string
+	NoteType     string
+
+	// Date is the date written for {{date}}; the zero time leaves it unfilled
+	Date time.Time
+}
+
+// Expand fills the placeholders of a template. The placeholders are
+// {{title}}, {{binary}}, {{address_range}}, {{start}}, {{end}}, {{size}},
+// {{type}} and {{date}}. Placeholders whose value is not known yet, such as
+// {{start}} before an address range is entered, are left in place so they
+// can be filled in later.
+//
+// Parameters:
+//   - values: The values of the placeholders
+//
+// Returns:
+//   - The content with the known placeholders replaced
+func (t Template) Expand(values TemplateValues) string {
+	replacements := map[string]string{
+		"title":         values.Title,
+		"binary":        values.BinaryName,
+		"address_range": values.AddressRange,
+		"type":          values.NoteType,
+	}
+	if start, end, err := ParseAddressRange(values.AddressRange); err == nil {
+		replacements["start"] = fmt.Sprintf("0x%x", start)
+		replacements["end"] = fmt.Sprintf("0x%x", end)
+		replacements["size"] = fmt.Sprintf("%d", end-start+1)
+	}
+	if !values.Date.IsZero() {
+		replacements["date"] = values.Date.Format("2006-01-02")
+	}
+
+	var args []string
+	for name, value := range replacements {
+		if value = strings.TrimSpace(value); value != "" {
+			args = append(args, "{{"+name+"}}", value)
+		}
+	}
+	return strings.NewReplacer(args...).Replace(t.Content)
+}
@@ -0,0 +1,91 @@
+package pcap
+
+import (
+	"fmt"
+	"time"
+)
+
+// FlowPacket is a packet of a flow
+type FlowPacket struct {
+	*Packet
+	*Decoded
+
+	// FromClient reports a packet sent by the flow's client
+	FromClient bool
+}
+
+// Flow is the packets between two endpoints over one IP protocol: a
+// 5-tuple of protocol, addresses and ports, in both directions
+type Flow struct {
+	// Number is the flow's position in the capture, from 1, in the order
+	// of first packets
+	Number int
+
+	Proto uint8
+
+	// Client opened the flow: the sender of the first SYN for TCP, or of
+	// the first packet otherwise
+	Client, Server Endpoint
+
+	Packets []FlowPacket
+
+	// Bytes counts the payload bytes in both directions
+	Bytes int
+
+	First, Last time.Time
+}
+
+// String describes the flow, as in "TCP 10.0.0.5:51000 -> 10.0.0.1:443"
+func (f *Flow) String() string {
+	return fmt.Sprintf("%s %s -> %s", ProtoName(f.Proto), f.Client, f.Server)
+}
+
+// flowKey identifies a flow regardless of direction
+type flowKey struct {
+	proto uint8
+	a, b  Endpoint
+}
+
+// Flows groups the IP packets of a capture by 5-tuple.
+//
+// Returns:
+//   - The flows, in the order of their first packets
+//   - The number of packets that are not IP or whose headers are truncated
+func Flows(c *Capture) ([]*Flow, int) {
+	var flows []*Flow
+	byKey := make(map[flowKey]*Flow)
+	skipped := 0
+	for _, p := range c.Packets {
+		d, err := Decode(p)
+		if err != nil {
+			skipped++
+			continue
+		}
+		key := flowKey{d.Proto, d.Src, d.Dst}
+		if key.b.less(key.a) {
+			key.a, key.b = key.b, key.a
+		}
+		f := byKey[key]
+		if f == nil {
+			f = &Flow{Number: len(flows) + 1, Proto: d.Proto, Client: d.Src, Server: d.Dst, First: p.Time}
+			// A SYN-ACK seen first was sent by the server
+			if d.Proto == ProtoTCP && d.Flags&(FlagSYN|FlagACK) == FlagSYN|FlagACK {
+				f.Client, f.Server = d.Dst, d.Src
+			}
+			byKey[key] = f
+			flows = append(flows, f)
+		}
+		if d.Proto == ProtoTCP && d.Flags&(FlagSYN|FlagACK) == FlagSYN && d.Src != f.Client {
+			// The first packets were the server's; the SYN shows who
+			// opened the connection
+			f.Client, f.Server = f.Server, f.Client
+			for i := range f.Packets {
+				f.Packets[i].FromClient = !f.Packets[i].FromClient
+			}
+		}
+		f.Packets = append(f.Packets, FlowPacket{Packet: p, Decoded: d, FromClient: d.Src == f.Client})
+		f.Bytes += len(d.Payload)
+		f.Last = p.Time
+	}
+	return flows, skipped
+}
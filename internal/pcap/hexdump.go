@@ -0,0 +1,55 @@
+package pcap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Hexdump writes data as lines of 16 bytes in hex and ASCII, each led by
+// its offset.
+//
+// Parameters:
+//   - data: The bytes to show
+//   - base: The offset of the first byte
+//
+// Returns:
+//   - The dump, ending with a newline unless data is empty
+func Hexdump(data []byte, base int) string {
+	var b strings.Builder
+	for start := 0; start < len(data); start += 16 {
+		line := data[start:min(start+16, len(data))]
+		fmt.Fprintf(&b, "%08x  ", base+start)
+		for i := 0; i < 16; i++ {
+			switch {
+			case i < len(line):
+				fmt.Fprintf(&b, "%02x ", line[i])
+			default:
+				b.WriteString("   ")
+			}
+			if i == 7 {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(" |")
+		for _, c := range line {
+			if c < 0x20 || c > 0x7e {
+				c = '.'
+			}
+			b.WriteByte(c)
+		}
+		b.WriteString("|\n")
+	}
+	return b.String()
+}
+
+// PacketSource describes a packet of a flow, for labelling samples pinned
+// from it, as in "dump.pcapng packet 42, TCP 10.0.0.5:51000 -> 10.0.0.1:443"
+func PacketSource(capture string, f *Flow, packet int) string {
+	return fmt.Sprintf("%s packet %d, %s", capture, packet, f)
+}
+
+// RangeSource describes a range of a stream, for labelling samples pinned
+// from it, as in "dump.pcapng flow 3 client bytes 0-119, TCP ..."
+func RangeSource(capture string, f *Flow, fromClient bool, offset, length int) string {
+	return fmt.Sprintf("%s flow %d %s bytes %d-%d, %s", capture, f.Number, side(fromClient), offset, offset+length-1, f)
+}
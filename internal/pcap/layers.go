@@ -0,0 +1,256 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// IP protocol numbers
+const (
+	ProtoICMP   = 1
+	ProtoTCP    = 6
+	ProtoUDP    = 17
+	ProtoICMPv6 = 58
+)
+
+// ProtoName names an IP protocol, such as "TCP" for 6
+func ProtoName(proto uint8) string {
+	switch proto {
+	case ProtoICMP:
+		return "ICMP"
+	case ProtoTCP:
+		return "TCP"
+	case ProtoUDP:
+		return "UDP"
+	case ProtoICMPv6:
+		return "ICMPv6"
+	}
+	return fmt.Sprintf("IP proto %d", proto)
+}
+
+// Endpoint is an address and port; the port is 0 for protocols without
+// ports
+type Endpoint struct {
+	Addr netip.Addr
+	Port uint16
+}
+
+// String writes the endpoint as "10.0.0.1:443" or "[fe80::1]:443", or
+// the address alone without a port
+func (e Endpoint) String() string {
+	if e.Port == 0 {
+		return e.Addr.String()
+	}
+	return netip.AddrPortFrom(e.Addr, e.Port).String()
+}
+
+// less orders endpoints by address, then port
+func (e Endpoint) less(o Endpoint) bool {
+	if c := e.Addr.Compare(o.Addr); c != 0 {
+		return c < 0
+	}
+	return e.Port < o.Port
+}
+
+// TCPFlags are the control bits of a TCP segment
+type TCPFlags uint8
+
+// TCP control bits
+const (
+	FlagFIN TCPFlags = 1 << iota
+	FlagSYN
+	FlagRST
+	FlagPSH
+	FlagACK
+	FlagURG
+)
+
+// String writes the set flags, such as "SYN,ACK"
+func (f TCPFlags) String() string {
+	var names []string
+	for i, name := range []string{"FIN", "SYN", "RST", "PSH", "ACK", "URG"} {
+		if f&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Decoded are the headers of an IP packet
+type Decoded struct {
+	// Src and Dst are the addresses and, for TCP and UDP, the ports
+	Src, Dst Endpoint
+
+	// Proto is the IP protocol of the payload, such as ProtoTCP
+	Proto uint8
+
+	// Seq, Ack and Flags are the TCP header fields
+	Seq, Ack uint32
+	Flags    TCPFlags
+
+	// Payload is the TCP or UDP payload, or the IP payload of other
+	// protocols and of fragments
+	Payload []byte
+
+	// Fragment reports an IP fragment; fragments are not reassembled
+	Fragment bool
+}
+
+// Decode decodes the link, IP and transport headers of a packet.
+//
+// Returns:
+//   - The headers
+//   - An error if the packet is not IP or is truncated before its payload
+func Decode(p *Packet) (*Decoded, error) {
+	data := p.Data
+	switch p.Link {
+	case LinkEthernet:
+		if len(data) < 14 {
+			return nil, errTruncatedHeader("Ethernet")
+		}
+		etherType := binary.BigEndian.Uint16(data[12:])
+		data = data[14:]
+		// 802.1Q and 802.1ad VLAN tags
+		for etherType == 0x8100 || etherType == 0x88a8 {
+			if len(data) < 4 {
+				return nil, errTruncatedHeader("VLAN")
+			}
+			etherType = binary.BigEndian.Uint16(data[2:])
+			data = data[4:]
+		}
+		if etherType != 0x0800 && etherType != 0x86dd {
+			return nil, fmt.Errorf("not an IP packet (EtherType 0x%04x)", etherType)
+		}
+	case LinkLinuxSLL:
+		if len(data) < 16 {
+			return nil, errTruncatedHeader("Linux cooked")
+		}
+		data = data[16:]
+	case LinkLinuxSLL2:
+		if len(data) < 20 {
+			return nil, errTruncatedHeader("Linux cooked v2")
+		}
+		data = data[20:]
+	case LinkNull, LinkLoop:
+		if len(data) < 4 {
+			return nil, errTruncatedHeader("loopback")
+		}
+		data = data[4:]
+	case LinkRaw, LinkIPv4, LinkIPv6:
+	default:
+		return nil, fmt.Errorf("unsupported link type %d", p.Link)
+	}
+
+	if len(data) == 0 {
+		return nil, errTruncatedHeader("IP")
+	}
+	switch data[0] >> 4 {
+	case 4:
+		return decodeIPv4(data)
+	case 6:
+		return decodeIPv6(data)
+	}
+	return nil, fmt.Errorf("not an IP packet")
+}
+
+// errTruncatedHeader reports a packet that ends inside a header
+func errTruncatedHeader(layer string) error {
+	return fmt.Errorf("truncated %s header", layer)
+}
+
+// decodeIPv4 decodes an IPv4 packet
+func decodeIPv4(data []byte) (*Decoded, error) {
+	headerLength := int(data[0]&0x0f) * 4
+	if len(data) < 20 || headerLength < 20 || len(data) < headerLength {
+		return nil, errTruncatedHeader("IPv4")
+	}
+	// Frames may be padded past the IP packet, and captures may cut it
+	if total := int(binary.BigEndian.Uint16(data[2:])); total >= headerLength && total < len(data) {
+		data = data[:total]
+	}
+	d := &Decoded{Proto: data[9]}
+	d.Src.Addr, _ = netip.AddrFromSlice(data[12:16])
+	d.Dst.Addr, _ = netip.AddrFromSlice(data[16:20])
+	fragment := binary.BigEndian.Uint16(data[6:])
+	d.Fragment = fragment&0x1fff != 0 || fragment&0x2000 != 0
+	return d.transport(data[headerLength:])
+}
+
+// decodeIPv6 decodes an IPv6 packet, skipping extension headers
+func decodeIPv6(data []byte) (*Decoded, error) {
+	if len(data) < 40 {
+		return nil, errTruncatedHeader("IPv6")
+	}
+	if length := int(binary.BigEndian.Uint16(data[4:])); 40+length < len(data) {
+		data = data[:40+length]
+	}
+	d := &Decoded{}
+	d.Src.Addr, _ = netip.AddrFromSlice(data[8:24])
+	d.Dst.Addr, _ = netip.AddrFromSlice(data[24:40])
+	next, payload := data[6], data[40:]
+	for {
+		switch next {
+		case 0, 43, 60: // hop-by-hop, routing and destination options
+			if len(payload) < 8 || len(payload) < 8+int(payload[1])*8 {
+				return nil, errTruncatedHeader("IPv6 extension")
+			}
+			next, payload = payload[0], payload[8+int(payload[1])*8:]
+			continue
+		case 44: // fragment
+			if len(payload) < 8 {
+				return nil, errTruncatedHeader("IPv6 fragment")
+			}
+			offset := binary.BigEndian.Uint16(payload[2:])
+			d.Fragment = offset&0xfff8 != 0 || offset&1 != 0
+			next, payload = payload[0], payload[8:]
+			continue
+		case 51: // authentication header
+			if len(payload) < 8 || len(payload) < (int(payload[1])+2)*4 {
+				return nil, errTruncatedHeader("IPv6 authentication")
+			}
+			next, payload = payload[0], payload[(int(payload[1])+2)*4:]
+			continue
+		}
+		break
+	}
+	d.Proto = next
+	return d.transport(payload)
+}
+
+// transport decodes the TCP or UDP header of an IP payload
+func (d *Decoded) transport(data []byte) (*Decoded, error) {
+	d.Payload = data
+	if d.Fragment {
+		return d, nil
+	}
+	switch d.Proto {
+	case ProtoTCP:
+		if len(data) < 20 {
+			return nil, errTruncatedHeader("TCP")
+		}
+		offset := int(data[12]>>4) * 4
+		if offset < 20 || len(data) < offset {
+			return nil, errTruncatedHeader("TCP")
+		}
+		d.Src.Port = binary.BigEndian.Uint16(data)
+		d.Dst.Port = binary.BigEndian.Uint16(data[2:])
+		d.Seq = binary.BigEndian.Uint32(data[4:])
+		d.Ack = binary.BigEndian.Uint32(data[8:])
+		d.Flags = TCPFlags(data[13] & 0x3f)
+		d.Payload = data[offset:]
+	case ProtoUDP:
+		if len(data) < 8 {
+			return nil, errTruncatedHeader("UDP")
+		}
+		d.Src.Port = binary.BigEndian.Uint16(data)
+		d.Dst.Port = binary.BigEndian.Uint16(data[2:])
+		payload := data[8:]
+		if length := int(binary.BigEndian.Uint16(data[4:])); length >= 8 && length-8 < len(payload) {
+			payload = payload[:length-8]
+		}
+		d.Payload = payload
+	}
+	return d, nil
+}
@@ -0,0 +1,173 @@
+// Package pcap reads packet captures in the pcap and pcapng formats,
+// decodes the link, IP, TCP and UDP headers of their packets, groups the
+// packets into flows by 5-tuple and reassembles TCP streams.
+package pcap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// LinkType is the link-layer header type of a capture's packets
+type LinkType uint16
+
+// Link types the decoder understands; see https://www.tcpdump.org/linktypes.html
+const (
+	// LinkNull is BSD loopback, with a 4-byte address family in host order
+	LinkNull LinkType = 0
+
+	LinkEthernet LinkType = 1
+
+	// LinkRaw is IPv4 or IPv6 without a link-layer header
+	LinkRaw LinkType = 101
+
+	// LinkLoop is OpenBSD loopback, with a big endian address family
+	LinkLoop LinkType = 108
+
+	// LinkLinuxSLL and LinkLinuxSLL2 are the "any" device of Linux
+	LinkLinuxSLL  LinkType = 113
+	LinkLinuxSLL2 LinkType = 276
+
+	LinkIPv4 LinkType = 228
+	LinkIPv6 LinkType = 229
+)
+
+// maxPacketSize bounds the captured length of a packet, so a corrupt
+// length cannot make the reader allocate gigabytes
+const maxPacketSize = 1 << 20
+
+// Packet is a captured packet
+type Packet struct {
+	// Number is the packet's position in the capture, from 1 as in
+	// Wireshark
+	Number int
+
+	Time time.Time
+
+	// Length is the length of the packet on the wire; Data may be shorter
+	// if the capture was taken with a snapshot length
+	Length int
+
+	Data []byte
+	Link LinkType
+}
+
+// Capture is a parsed capture file
+type Capture struct {
+	// Format is "pcap" or "pcapng"
+	Format string
+
+	Packets []*Packet
+
+	// Truncated reports that the file ends inside a packet, as captures
+	// still being written do; the packets before it are kept
+	Truncated bool
+}
+
+// Open reads a capture file.
+//
+// Parameters:
+//   - path: The path of a .pcap or .pcapng file
+//
+// Returns:
+//   - The capture
+//   - An error if the file cannot be read or is not a capture
+func Open(path string) (*Capture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Read parses a capture in the pcap or pcapng format, detected from its
+// first bytes.
+//
+// Returns:
+//   - The capture
+//   - An error if the data is not a capture or is corrupt
+func Read(r io.Reader) (*Capture, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil {
+		return nil, errNotCapture
+	}
+	if binary.LittleEndian.Uint32(magic) == blockSectionHeader {
+		return readPcapng(br)
+	}
+	return readPcap(br)
+}
+
+// pcap file magic numbers, for microsecond and nanosecond timestamps
+const (
+	magicMicroseconds = 0xa1b2c3d4
+	magicNanoseconds  = 0xa1b23c4d
+)
+
+// readPcap reads the classic libpcap format
+func readPcap(r io.Reader) (*Capture, error) {
+	header := make([]byte, 24)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, errNotCapture
+	}
+	var order binary.ByteOrder
+	var nanoseconds bool
+	switch {
+	case binary.LittleEndian.Uint32(header) == magicMicroseconds:
+		order = binary.LittleEndian
+	case binary.BigEndian.Uint32(header) == magicMicroseconds:
+		order = binary.BigEndian
+	case binary.LittleEndian.Uint32(header) == magicNanoseconds:
+		order, nanoseconds = binary.LittleEndian, true
+	case binary.BigEndian.Uint32(header) == magicNanoseconds:
+		order, nanoseconds = binary.BigEndian, true
+	default:
+		return nil, errNotCapture
+	}
+	link := LinkType(order.Uint32(header[20:]) & 0xffff)
+
+	c := &Capture{Format: "pcap"}
+	record := make([]byte, 16)
+	for {
+		if _, err := io.ReadFull(r, record); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				c.Truncated = true
+			}
+			return c, nil
+		}
+		seconds := int64(order.Uint32(record))
+		fraction := int64(order.Uint32(record[4:]))
+		included := order.Uint32(record[8:])
+		if included > maxPacketSize {
+			return nil, fmt.Errorf("packet %d: invalid length %d", len(c.Packets)+1, included)
+		}
+		data := make([]byte, included)
+		if _, err := io.ReadFull(r, data); err != nil {
+			c.Truncated = true
+			return c, nil
+		}
+		if !nanoseconds {
+			fraction *= 1000
+		}
+		c.Packets = append(c.Packets, &Packet{
+			Number: len(c.Packets) + 1,
+			Time:   time.Unix(seconds, fraction).UTC(),
+			Length: int(order.Uint32(record[12:])),
+			Data:   data,
+			Link:   link,
+		})
+	}
+}
+
+// errNotCapture reports data that is not a capture
+var errNotCapture = errors.New("not a pcap or pcapng file")
@@ -0,0 +1,365 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	client = []byte{10, 0, 0, 5}
+	server = []byte{10, 0, 0, 1}
+)
+
+// tcpPacket builds an IPv4 TCP packet from port 51000 on client to port
+// 443 on server, or back when fromClient is false
+func tcpPacket(fromClient bool, seq uint32, flags TCPFlags, payload string) []byte {
+	src, dst := client, server
+	srcPort, dstPort := uint16(51000), uint16(443)
+	if !fromClient {
+		src, dst = dst, src
+		srcPort, dstPort = dstPort, srcPort
+	}
+	ip := make([]byte, 40, 40+len(payload))
+	ip[0] = 0x45
+	binary.BigEndian.PutUint16(ip[2:], uint16(40+len(payload)))
+	ip[8], ip[9] = 64, ProtoTCP
+	copy(ip[12:], src)
+	copy(ip[16:], dst)
+	tcp := ip[20:]
+	binary.BigEndian.PutUint16(tcp, srcPort)
+	binary.BigEndian.PutUint16(tcp[2:], dstPort)
+	binary.BigEndian.PutUint32(tcp[4:], seq)
+	tcp[12] = 5 << 4
+	tcp[13] = byte(flags)
+	return append(ip, payload...)
+}
+
+// ethernet wraps an IPv4 packet in an Ethernet frame
+func ethernet(packet []byte) []byte {
+	frame := make([]byte, 14, 14+len(packet))
+	binary.BigEndian.PutUint16(frame[12:], 0x0800)
+	return append(frame, packet...)
+}
+
+// start is the time of the first packet of the test captures
+var start = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// packetTime is the time of the i-th packet of the test captures, 1.5 ms
+// apart
+func packetTime(i int) time.Time {
+	return start.Add(time.Duration(i) * 1500 * time.Microsecond)
+}
+
+// writePcap builds a pcap file with microsecond or nanosecond timestamps
+func writePcap(order binary.ByteOrder, nanoseconds bool, link LinkType, packets [][]byte) []byte {
+	var b bytes.Buffer
+	magic := uint32(magicMicroseconds)
+	if nanoseconds {
+		magic = magicNanoseconds
+	}
+	header := make([]byte, 24)
+	order.PutUint32(header, magic)
+	order.PutUint16(header[4:], 2)
+	order.PutUint16(header[6:], 4)
+	order.PutUint32(header[16:], 65535)
+	order.PutUint32(header[20:], uint32(link))
+	b.Write(header)
+	for i, p := range packets {
+		t := packetTime(i)
+		fraction := uint32(t.Nanosecond() / 1000)
+		if nanoseconds {
+			fraction = uint32(t.Nanosecond())
+		}
+		record := make([]byte, 16)
+		order.PutUint32(record, uint32(t.Unix()))
+		order.PutUint32(record[4:], fraction)
+		order.PutUint32(record[8:], uint32(len(p)))
+		order.PutUint32(record[12:], uint32(len(p)))
+		b.Write(record)
+		b.Write(p)
+	}
+	return b.Bytes()
+}
+
+// ngBlock builds a pcapng block, padding its body to 32 bits
+func ngBlock(order binary.ByteOrder, blockType uint32, body []byte) []byte {
+	padded := append(body, make([]byte, (4-len(body)%4)%4)...)
+	length := uint32(12 + len(padded))
+	block := make([]byte, 8, length)
+	order.PutUint32(block, blockType)
+	order.PutUint32(block[4:], length)
+	block = append(block, padded...)
+	block = append(block, 0, 0, 0, 0)
+	order.PutUint32(block[len(block)-4:], length)
+	return block
+}
+
+// writePcapng builds a pcapng file with one interface. A resolution
+// other than 0 is written as the interface's if_tsresol option.
+func writePcapng(order binary.ByteOrder, link LinkType, resolution uint8, packets [][]byte) []byte {
+	var b bytes.Buffer
+	section := make([]byte, 16)
+	order.PutUint32(section, byteOrderMagic)
+	order.PutUint16(section[4:], 1)
+	binary.LittleEndian.PutUint64(section[8:], ^uint64(0))
+	b.Write(ngBlock(order, blockSectionHeader, section))
+
+	iface := make([]byte, 8)
+	order.PutUint16(iface, uint16(link))
+	order.PutUint32(iface[4:], 65535)
+	if resolution != 0 {
+		option := make([]byte, 8)
+		order.PutUint16(option, optionTimeResolution)
+		order.PutUint16(option[2:], 1)
+		option[4] = resolution
+		iface = append(iface, option...)
+		iface = append(iface, 0, 0, 0, 0)
+	}
+	b.Write(ngBlock(order, blockInterface, iface))
+
+	perSecond := uint64(1e6)
+	if resolution != 0 {
+		perSecond = 1
+		for range resolution {
+			perSecond *= 10
+		}
+	}
+	for i, p := range packets {
+		t := packetTime(i)
+		units := uint64(t.Unix())*perSecond + uint64(t.Nanosecond())*perSecond/1e9
+		body := make([]byte, 20, 20+len(p))
+		order.PutUint32(body[4:], uint32(units>>32))
+		order.PutUint32(body[8:], uint32(units))
+		order.PutUint32(body[12:], uint32(len(p)))
+		order.PutUint32(body[16:], uint32(len(p)))
+		b.Write(ngBlock(order, blockEnhancedPacket, append(body, p...)))
+	}
+	return b.Bytes()
+}
+
+// handshake is a connection that opens, exchanges a request and a reply,
+// and closes
+var handshake = [][]byte{
+	tcpPacket(true, 1000, FlagSYN, ""),
+	tcpPacket(false, 5000, FlagSYN|FlagACK, ""),
+	tcpPacket(true, 1001, FlagACK, ""),
+	tcpPacket(true, 1001, FlagACK|FlagPSH, "GET / HTTP/1.0\r\n\r\n"),
+	tcpPacket(false, 5001, FlagACK|FlagPSH, "HTTP/1.0 200 OK\r\n"),
+	tcpPacket(true, 1019, FlagACK|FlagFIN, ""),
+}
+
+func TestRead(t *testing.T) {
+	frames := make([][]byte, len(handshake))
+	for i, p := range handshake {
+		frames[i] = ethernet(p)
+	}
+	tests := []struct {
+		name   string
+		data   []byte
+		format string
+		link   LinkType
+
+		// precision is the timestamp unit when coarser than the packet times
+		precision time.Duration
+	}{
+		{"pcap little endian", writePcap(binary.LittleEndian, false, LinkEthernet, frames), "pcap", LinkEthernet, 0},
+		{"pcap big endian", writePcap(binary.BigEndian, false, LinkEthernet, frames), "pcap", LinkEthernet, 0},
+		{"pcap nanoseconds", writePcap(binary.LittleEndian, true, LinkRaw, handshake), "pcap", LinkRaw, 0},
+		{"pcap big endian nanoseconds", writePcap(binary.BigEndian, true, LinkRaw, handshake), "pcap", LinkRaw, 0},
+		{"pcapng little endian", writePcapng(binary.LittleEndian, LinkEthernet, 0, frames), "pcapng", LinkEthernet, 0},
+		{"pcapng big endian", writePcapng(binary.BigEndian, LinkEthernet, 0, frames), "pcapng", LinkEthernet, 0},
+		{"pcapng nanoseconds", writePcapng(binary.LittleEndian, LinkRaw, 9, handshake), "pcapng", LinkRaw, 0},
+		{"pcapng big endian milliseconds", writePcapng(binary.BigEndian, LinkRaw, 3, handshake), "pcapng", LinkRaw, time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Read(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Format != tt.format || c.Truncated {
+				t.Errorf("format %s, truncated %v; want %s, not truncated", c.Format, c.Truncated, tt.format)
+			}
+			if len(c.Packets) != len(handshake) {
+				t.Fatalf("%d packets; want %d", len(c.Packets), len(handshake))
+			}
+			for i, p := range c.Packets {
+				want := packetTime(i)
+				if tt.precision != 0 {
+					want = want.Truncate(tt.precision)
+				}
+				if p.Number != i+1 || !p.Time.Equal(want) || p.Link != tt.link || p.Length != len(p.Data) {
+					t.Errorf("packet %d: number %d, time %v, link %d, length %d", i+1, p.Number, p.Time, p.Link, p.Length)
+				}
+				d, err := Decode(p)
+				if err != nil {
+					t.Fatalf("packet %d: %v", i+1, err)
+				}
+				if want := handshake[i][40:]; !bytes.Equal(d.Payload, want) {
+					t.Errorf("packet %d: payload %q; want %q", i+1, d.Payload, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadTruncated(t *testing.T) {
+	packets := handshake[:4]
+	pcapFile := writePcap(binary.LittleEndian, false, LinkRaw, packets)
+	pcapngFile := writePcapng(binary.BigEndian, LinkRaw, 0, packets)
+	// The last packet's record starts after the file header and three
+	// records; its data after its own header
+	lastPcapRecord := len(pcapFile) - 16 - len(packets[3])
+	lastPcapngBlock := len(pcapngFile) - 32 - (len(packets[3])+3)&^3
+
+	tests := []struct {
+		name    string
+		data    []byte
+		packets int
+	}{
+		{"pcap inside a record header", pcapFile[:lastPcapRecord+10], 3},
+		{"pcap inside packet data", pcapFile[:len(pcapFile)-5], 3},
+		{"pcap after the file header", pcapFile[:30], 0},
+		{"pcapng inside a block header", pcapngFile[:lastPcapngBlock+6], 3},
+		{"pcapng inside a block", pcapngFile[:len(pcapngFile)-2], 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Read(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !c.Truncated || len(c.Packets) != tt.packets {
+				t.Errorf("truncated %v with %d packets; want truncated with %d", c.Truncated, len(c.Packets), tt.packets)
+			}
+		})
+	}
+
+	for name, data := range map[string][]byte{
+		"empty":            nil,
+		"short":            pcapFile[:3],
+		"pcap header only": pcapFile[:20],
+		"not a capture":    []byte("GIF89a, not a capture at all"),
+	} {
+		if _, err := Read(bytes.NewReader(data)); !errors.Is(err, errNotCapture) {
+			t.Errorf("%s: error %v; want %v", name, err, errNotCapture)
+		}
+	}
+}
+
+func TestReassemble(t *testing.T) {
+	syn := tcpPacket(true, 99, FlagSYN, "")
+	tests := []struct {
+		name            string
+		packets         [][]byte
+		want            string
+		retransmissions int
+		missing         int
+	}{
+		{
+			name:    "in order",
+			packets: [][]byte{syn, tcpPacket(true, 100, FlagACK, "abc"), tcpPacket(true, 103, FlagACK, "def")},
+			want:    "abcdef",
+		},
+		{
+			name:    "out of order",
+			packets: [][]byte{syn, tcpPacket(true, 103, FlagACK, "def"), tcpPacket(true, 106, FlagACK, "ghi"), tcpPacket(true, 100, FlagACK, "abc")},
+			want:    "abcdefghi",
+		},
+		{
+			name:            "retransmitted",
+			packets:         [][]byte{syn, tcpPacket(true, 100, FlagACK, "abc"), tcpPacket(true, 100, FlagACK, "abc"), tcpPacket(true, 103, FlagACK, "def")},
+			want:            "abcdef",
+			retransmissions: 1,
+		},
+		{
+			name:            "retransmitted while out of order",
+			packets:         [][]byte{syn, tcpPacket(true, 103, FlagACK, "def"), tcpPacket(true, 103, FlagACK, "def"), tcpPacket(true, 100, FlagACK, "abc")},
+			want:            "abcdef",
+			retransmissions: 1,
+		},
+		{
+			name:    "overlapping",
+			packets: [][]byte{syn, tcpPacket(true, 100, FlagACK, "abcd"), tcpPacket(true, 102, FlagACK, "cdef")},
+			want:    "abcdef",
+		},
+		{
+			name:    "gap",
+			packets: [][]byte{syn, tcpPacket(true, 100, FlagACK, "abc"), tcpPacket(true, 108, FlagACK, "ijk")},
+			want:    "abcijk",
+			missing: 5,
+		},
+		{
+			name:    "capture started after the handshake",
+			packets: [][]byte{tcpPacket(true, 500, FlagACK, "abc"), tcpPacket(true, 503, FlagACK, "def")},
+			want:    "abcdef",
+		},
+		{
+			name:    "sequence numbers wrapping",
+			packets: [][]byte{tcpPacket(true, 0xfffffffd, FlagACK, "abc"), tcpPacket(true, 3, FlagACK, "ghi"), tcpPacket(true, 0, FlagACK, "def")},
+			want:    "abcdefghi",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Read(bytes.NewReader(writePcap(binary.LittleEndian, false, LinkRaw, tt.packets)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			flows, skipped := Flows(c)
+			if len(flows) != 1 || skipped != 0 {
+				t.Fatalf("%d flows, %d skipped; want 1 flow", len(flows), skipped)
+			}
+			s := Reassemble(flows[0])
+			if string(s.Client) != tt.want || len(s.Server) != 0 {
+				t.Errorf("client sent %q, server %q; want %q from the client", s.Client, s.Server, tt.want)
+			}
+			if s.Retransmissions != tt.retransmissions || s.Missing != tt.missing {
+				t.Errorf("%d retransmissions, %d missing; want %d, %d", s.Retransmissions, s.Missing, tt.retransmissions, tt.missing)
+			}
+			offset := 0
+			for _, seg := range s.Segments {
+				if seg.Offset != offset {
+					t.Errorf("segment of packet %d at offset %d; want %d", seg.Packet, seg.Offset, offset)
+				}
+				offset += len(seg.Data)
+			}
+		})
+	}
+}
+
+func TestFlows(t *testing.T) {
+	tests := []struct {
+		name       string
+		packets    [][]byte
+		fromClient []bool
+	}{
+		{"handshake", handshake, []bool{true, false, true, true, false, true}},
+		{"SYN-ACK first", handshake[1:], []bool{false, true, true, false, true}},
+		{"server first", [][]byte{handshake[4], handshake[0]}, []bool{false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Read(bytes.NewReader(writePcapng(binary.LittleEndian, LinkRaw, 0, tt.packets)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			flows, _ := Flows(c)
+			if len(flows) != 1 {
+				t.Fatalf("%d flows; want 1", len(flows))
+			}
+			f := flows[0]
+			if got := f.String(); got != "TCP 10.0.0.5:51000 -> 10.0.0.1:443" {
+				t.Errorf("flow %s", got)
+			}
+			for i, p := range f.Packets {
+				if p.FromClient != tt.fromClient[i] {
+					t.Errorf("packet %d: from client %v; want %v", p.Number, p.FromClient, tt.fromClient[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// pcapng block types
+const (
+	blockSectionHeader   = 0x0a0d0d0a
+	blockInterface       = 0x00000001
+	blockPacket          = 0x00000002 // obsolete
+	blockSimplePacket    = 0x00000003
+	blockEnhancedPacket  = 0x00000006
+	byteOrderMagic       = 0x1a2b3c4d
+	optionEnd            = 0
+	optionTimeResolution = 9
+	optionTimeOffset     = 14
+)
+
+// maxBlockSize bounds the length of a pcapng block
+const maxBlockSize = maxPacketSize + 4096
+
+// ngInterface is an interface of a pcapng section
+type ngInterface struct {
+	link    LinkType
+	snaplen uint32
+
+	// resolution is the timestamp unit: 10^-n seconds, or 2^-n seconds
+	// if binary is set
+	resolution uint8
+	binary     bool
+
+	// offset is added to timestamps, in seconds
+	offset int64
+}
+
+// time converts a timestamp of the interface
+func (i *ngInterface) time(units uint64) time.Time {
+	var seconds, nanoseconds int64
+	switch {
+	case i.binary:
+		seconds = int64(units >> i.resolution)
+		fraction := units & (1<<i.resolution - 1)
+		nanoseconds = int64(float64(fraction) / math.Ldexp(1, int(i.resolution)) * 1e9)
+	case i.resolution <= 9:
+		scale := uint64(math.Pow10(int(9 - i.resolution)))
+		perSecond := uint64(math.Pow10(int(i.resolution)))
+		seconds = int64(units / perSecond)
+		nanoseconds = int64(units % perSecond * scale)
+	default:
+		perSecond := uint64(math.Pow10(int(i.resolution)))
+		scale := uint64(math.Pow10(int(i.resolution - 9)))
+		seconds = int64(units / perSecond)
+		nanoseconds = int64(units % perSecond / scale)
+	}
+	return time.Unix(seconds+i.offset, nanoseconds).UTC()
+}
+
+// readPcapng reads the pcapng format. Packets of every section and
+// interface are returned in file order; blocks other than interfaces and
+// packets are skipped.
+func readPcapng(r io.Reader) (*Capture, error) {
+	c := &Capture{Format: "pcapng"}
+	var order binary.ByteOrder = binary.LittleEndian
+	var interfaces []*ngInterface
+
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				c.Truncated = true
+			}
+			return c, nil
+		}
+		blockType := order.Uint32(header)
+		if binary.LittleEndian.Uint32(header) == blockSectionHeader {
+			blockType = blockSectionHeader
+		}
+
+		if blockType == blockSectionHeader {
+			// The byte order magic after the length sets the order of
+			// the section, including the length itself
+			magic := make([]byte, 4)
+			if _, err := io.ReadFull(r, magic); err != nil {
+				c.Truncated = true
+				return c, nil
+			}
+			switch {
+			case binary.LittleEndian.Uint32(magic) == byteOrderMagic:
+				order = binary.LittleEndian
+			case binary.BigEndian.Uint32(magic) == byteOrderMagic:
+				order = binary.BigEndian
+			default:
+				return nil, fmt.Errorf("invalid pcapng section header")
+			}
+			length := order.Uint32(header[4:])
+			if length < 28 || length > maxBlockSize || length%4 != 0 {
+				return nil, fmt.Errorf("invalid pcapng section header length %d", length)
+			}
+			if _, err := io.CopyN(io.Discard, r, int64(length-12)); err != nil {
+				c.Truncated = true
+				return c, nil
+			}
+			interfaces = nil
+			continue
+		}
+
+		length := order.Uint32(header[4:])
+		if length < 12 || length > maxBlockSize || length%4 != 0 {
+			return nil, fmt.Errorf("invalid pcapng block length %d after packet %d", length, len(c.Packets))
+		}
+		body := make([]byte, length-8)
+		if _, err := io.ReadFull(r, body); err != nil {
+			c.Truncated = true
+			return c, nil
+		}
+		body = body[:len(body)-4]
+
+		switch blockType {
+		case blockInterface:
+			if len(body) < 8 {
+				return nil, fmt.Errorf("invalid pcapng interface block")
+			}
+			iface := &ngInterface{
+				link:       LinkType(order.Uint16(body)),
+				snaplen:    order.Uint32(body[4:]),
+				resolution: 6,
+			}
+			readOptions(body[8:], order, func(code uint16, value []byte) {
+				switch {
+				case code == optionTimeResolution && len(value) == 1:
+					iface.resolution, iface.binary = value[0]&0x7f, value[0]&0x80 != 0
+				case code == optionTimeOffset && len(value) == 8:
+					iface.offset = int64(order.Uint64(value))
+				}
+			})
+			if !iface.binary && iface.resolution > 19 || iface.binary && iface.resolution > 63 {
+				return nil, fmt.Errorf("invalid pcapng timestamp resolution")
+			}
+			interfaces = append(interfaces, iface)
+
+		case blockEnhancedPacket, blockPacket:
+			if len(body) < 20 {
+				return nil, fmt.Errorf("invalid pcapng packet block after packet %d", len(c.Packets))
+			}
+			id := order.Uint32(body)
+			if blockType == blockPacket {
+				id = uint32(order.Uint16(body))
+			}
+			if int(id) >= len(interfaces) {
+				return nil, fmt.Errorf("packet %d: unknown interface %d", len(c.Packets)+1, id)
+			}
+			units := uint64(order.Uint32(body[4:]))<<32 | uint64(order.Uint32(body[8:]))
+			captured := order.Uint32(body[12:])
+			if int(captured) > len(body)-20 {
+				return nil, fmt.Errorf("packet %d: invalid length %d", len(c.Packets)+1, captured)
+			}
+			c.Packets = append(c.Packets, &Packet{
+				Number: len(c.Packets) + 1,
+				Time:   interfaces[id].time(units),
+				Length: int(order.Uint32(body[16:])),
+				Data:   body[20 : 20+captured],
+				Link:   interfaces[id].link,
+			})
+
+		case blockSimplePacket:
+			if len(body) < 4 || len(interfaces) == 0 {
+				return nil, fmt.Errorf("invalid pcapng simple packet block after packet %d", len(c.Packets))
+			}
+			length := order.Uint32(body)
+			captured := min(int(length), len(body)-4)
+			if snaplen := int(interfaces[0].snaplen); snaplen > 0 {
+				captured = min(captured, snaplen)
+			}
+			c.Packets = append(c.Packets, &Packet{
+				Number: len(c.Packets) + 1,
+				Length: int(length),
+				Data:   body[4 : 4+captured],
+				Link:   interfaces[0].link,
+			})
+		}
+	}
+}
+
+// readOptions calls visit with the code and value of each option of a
+// block, up to the end option
+func readOptions(data []byte, order binary.ByteOrder, visit func(code uint16, value []byte)) {
+	for len(data) >= 4 {
+		code, length := order.Uint16(data), int(order.Uint16(data[2:]))
+		if code == optionEnd || 4+length > len(data) {
+			return
+		}
+		visit(code, data[4:4+length])
+		data = data[min(4+(length+3)&^3, len(data)):]
+	}
+}
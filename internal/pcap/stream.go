@@ -0,0 +1,248 @@
+package pcap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Segment is data delivered in one direction of a stream
+type Segment struct {
+	FromClient bool
+
+	// Offset is the position of the data in its direction of the stream
+	Offset int
+
+	Data []byte
+
+	// Packet is the number of the packet that carried the data
+	Packet int
+
+	// Missing counts the bytes lost before the data, which the capture
+	// does not hold
+	Missing int
+}
+
+// Stream is the data of a flow in the order it was delivered to the
+// applications
+type Stream struct {
+	Flow *Flow
+
+	// Segments are the pieces of data in delivery order; consecutive
+	// segments in the same direction are kept apart so that each can be
+	// traced to its packet
+	Segments []Segment
+
+	// Client and Server hold all the data each side sent
+	Client, Server []byte
+
+	// Retransmissions counts TCP segments holding only data already
+	// delivered
+	Retransmissions int
+
+	// Missing counts the bytes lost to gaps in the capture
+	Missing int
+}
+
+// tcpDirection is the reassembly state of one direction of a connection
+type tcpDirection struct {
+	started bool
+
+	// next is the sequence number of the next byte to deliver
+	next uint32
+
+	// pending are segments received ahead of next
+	pending []pendingSegment
+}
+
+// pendingSegment is a segment waiting for the data before it
+type pendingSegment struct {
+	seq    uint32
+	data   []byte
+	packet int
+}
+
+// Reassemble orders the payloads of a flow into the data each side sent.
+// TCP segments are put in sequence order, with retransmitted and
+// overlapping data dropped; segments after a gap the capture missed are
+// delivered at the end with the gap counted. UDP and other flows deliver
+// each packet's payload in capture order.
+//
+// Parameters:
+//   - f: A flow from Flows
+//
+// Returns:
+//   - The reassembled stream
+func Reassemble(f *Flow) *Stream {
+	s := &Stream{Flow: f}
+	if f.Proto != ProtoTCP {
+		for _, p := range f.Packets {
+			if len(p.Payload) > 0 {
+				s.deliver(p.FromClient, p.Payload, p.Number, 0)
+			}
+		}
+		return s
+	}
+
+	directions := map[bool]*tcpDirection{true: {}, false: {}}
+	for _, p := range f.Packets {
+		dir := directions[p.FromClient]
+		if p.Flags&FlagSYN != 0 {
+			dir.started, dir.next = true, p.Seq+1
+			dir.pending = nil
+			continue
+		}
+		if len(p.Payload) == 0 {
+			continue
+		}
+		if !dir.started {
+			// The capture began after the handshake
+			dir.started, dir.next = true, p.Seq
+		}
+		s.receive(dir, p.FromClient, p.Seq, p.Payload, p.Number)
+	}
+
+	// Data after gaps is delivered in sequence order
+	for _, fromClient := range []bool{true, false} {
+		dir := directions[fromClient]
+		for len(dir.pending) > 0 {
+			sort.Slice(dir.pending, func(i, j int) bool {
+				return int32(dir.pending[i].seq-dir.next) < int32(dir.pending[j].seq-dir.next)
+			})
+			first := dir.pending[0]
+			missing := int(int32(first.seq - dir.next))
+			s.Missing += missing
+			dir.next = first.seq
+			s.flush(dir, fromClient, missing)
+		}
+	}
+	return s
+}
+
+// receive delivers a TCP segment if it is next in sequence, or keeps it
+// until the data before it arrives
+func (s *Stream) receive(dir *tcpDirection, fromClient bool, seq uint32, data []byte, packet int) {
+	ahead := int32(seq - dir.next)
+	if ahead > 0 {
+		dir.pending = append(dir.pending, pendingSegment{seq, data, packet})
+		return
+	}
+	if int(-ahead) >= len(data) {
+		s.Retransmissions++
+		return
+	}
+	s.deliver(fromClient, data[-ahead:], packet, 0)
+	dir.next += uint32(len(data)) + uint32(ahead)
+	s.flush(dir, fromClient, 0)
+}
+
+// flush delivers the pending segments that have become next in sequence.
+// The first one delivered is reported with missing bytes before it.
+func (s *Stream) flush(dir *tcpDirection, fromClient bool, missing int) {
+	for progress := true; progress; {
+		progress = false
+		for i := 0; i < len(dir.pending); i++ {
+			p := dir.pending[i]
+			ahead := int32(p.seq - dir.next)
+			if ahead > 0 {
+				continue
+			}
+			dir.pending = append(dir.pending[:i], dir.pending[i+1:]...)
+			i--
+			progress = true
+			if int(-ahead) >= len(p.data) {
+				s.Retransmissions++
+				continue
+			}
+			s.deliver(fromClient, p.data[-ahead:], p.packet, missing)
+			missing = 0
+			dir.next += uint32(len(p.data)) + uint32(ahead)
+		}
+	}
+}
+
+// deliver appends data sent by one side
+func (s *Stream) deliver(fromClient bool, data []byte, packet, missing int) {
+	buf := &s.Server
+	if fromClient {
+		buf = &s.Client
+	}
+	s.Segments = append(s.Segments, Segment{
+		FromClient: fromClient,
+		Offset:     len(*buf),
+		Data:       data,
+		Packet:     packet,
+		Missing:    missing,
+	})
+	*buf = append(*buf, data...)
+}
+
+// Data returns the data one side sent
+func (s *Stream) Data(fromClient bool) []byte {
+	if fromClient {
+		return s.Client
+	}
+	return s.Server
+}
+
+// Range returns a range of the data one side sent.
+//
+// Parameters:
+//   - fromClient: Selects the data the client sent, or the server's
+//   - offset: The offset of the range in that data
+//   - length: The length of the range; -1 for the rest of the data
+//
+// Returns:
+//   - The bytes
+//   - An error if the range is outside the data
+func (s *Stream) Range(fromClient bool, offset, length int) ([]byte, error) {
+	data := s.Data(fromClient)
+	if offset < 0 || offset > len(data) {
+		return nil, fmt.Errorf("offset %d is outside the %d bytes sent by the %s", offset, len(data), side(fromClient))
+	}
+	if length < 0 {
+		length = len(data) - offset
+	}
+	if offset+length > len(data) {
+		return nil, fmt.Errorf("bytes %d-%d are outside the %d bytes sent by the %s", offset, offset+length, len(data), side(fromClient))
+	}
+	return data[offset : offset+length], nil
+}
+
+// side names the sender of a direction
+func side(fromClient bool) string {
+	if fromClient {
+		return "client"
+	}
+	return "server"
+}
+
+// Format writes the stream as a conversation: a header per segment naming
+// its sender, offset and packet, followed by a hexdump of the data.
+//
+// Returns:
+//   - The conversation
+func (s *Stream) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: client sent %d bytes, server sent %d bytes", s.Flow, len(s.Client), len(s.Server))
+	if s.Retransmissions > 0 {
+		fmt.Fprintf(&b, ", %d retransmissions", s.Retransmissions)
+	}
+	if s.Missing > 0 {
+		fmt.Fprintf(&b, ", %d bytes missing from the capture", s.Missing)
+	}
+	b.WriteString("\n")
+	for _, seg := range s.Segments {
+		b.WriteString("\n")
+		if seg.Missing > 0 {
+			fmt.Fprintf(&b, "[%d bytes missing]\n", seg.Missing)
+		}
+		arrow := "server -> client"
+		if seg.FromClient {
+			arrow = "client -> server"
+		}
+		fmt.Fprintf(&b, "%s  %d bytes at offset %d  (packet %d)\n", arrow, len(seg.Data), seg.Offset, seg.Packet)
+		b.WriteString(Hexdump(seg.Data, seg.Offset))
+	}
+	return b.String()
+}
@@ -0,0 +1,361 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Typesetting of PDF reports, in millimetres and points
+const (
+	pdfMargin   = 20.0
+	pdfFontSize = 10.0
+	pdfLine     = 5.0
+	pdfCodeSize = 8.5
+	pdfCodeLine = 4.0
+	pdfIndent   = 6.0
+	pdfCellPad  = 1.5
+)
+
+// pdfHeadingSizes are the font sizes of headings by level
+var pdfHeadingSizes = [...]float64{0, 20, 15, 12.5, 11, 10, 10}
+
+// pdfWriter typesets Markdown into a PDF document with the standard fonts
+type pdfWriter struct {
+	pdf *gofpdf.Fpdf
+	src []byte
+
+	// tr converts UTF-8 to the Windows-1252 encoding of the standard fonts
+	tr func(string) string
+
+	// left is the left margin of the current block, indented by lists and
+	// block quotes
+	left float64
+
+	// style is the font style of the current inline text, such as "B"
+	style string
+	mono  bool
+}
+
+// typeset turns a Markdown report into a PDF document.
+//
+// Parameters:
+//   - title: The document title, also printed in the page footers
+//   - source: The Markdown report
+//
+// Returns:
+//   - The PDF document
+//   - An error if the document cannot be produced
+func typeset(title string, source []byte) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetCreator("RevEnGo", true)
+	pdf.SetTitle(title, true)
+	pdf.SetCreationDate(time.Now())
+	pdf.AliasNbPages("")
+	w := &pdfWriter{pdf: pdf, src: source, tr: pdf.UnicodeTranslatorFromDescriptor(""), left: pdfMargin}
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin + 5)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(0, 5, w.tr(title), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 5, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+
+	doc := markdown.Parser().Parse(text.NewReader(source))
+	w.blocks(doc)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("typesetting the PDF report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// font selects the body font in the current style
+func (w *pdfWriter) font(size float64) {
+	if w.mono {
+		w.pdf.SetFont("Courier", w.style, size)
+	} else {
+		w.pdf.SetFont("Helvetica", w.style, size)
+	}
+}
+
+// blocks typesets the block children of a node
+func (w *pdfWriter) blocks(n ast.Node) {
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		w.block(child)
+	}
+}
+
+// block typesets a block node
+func (w *pdfWriter) block(n ast.Node) {
+	pdf := w.pdf
+	pdf.SetLeftMargin(w.left)
+	pdf.SetX(w.left)
+	switch node := n.(type) {
+	case *ast.Heading:
+		level := min(node.Level, len(pdfHeadingSizes)-1)
+		pdf.Ln(pdfLine * 0.6)
+		// Keep a heading with the start of what follows it
+		if _, height := pdf.GetPageSize(); pdf.GetY() > height-pdfMargin-25 {
+			pdf.AddPage()
+		}
+		w.style = "B"
+		w.inline(node, pdfHeadingSizes[level]*0.5, pdfHeadingSizes[level])
+		w.style = ""
+		pdf.Ln(-1)
+		if level <= 2 {
+			width, _ := pdf.GetPageSize()
+			pdf.SetDrawColor(160, 160, 160)
+			pdf.Line(w.left, pdf.GetY()+0.5, width-pdfMargin, pdf.GetY()+0.5)
+		}
+		pdf.Ln(pdfLine * 0.5)
+	case *ast.Paragraph, *ast.TextBlock:
+		w.inline(node, pdfLine, pdfFontSize)
+		pdf.Ln(pdfLine)
+		if _, ok := node.(*ast.Paragraph); ok {
+			pdf.Ln(pdfLine * 0.4)
+		}
+	case *ast.List:
+		number := node.Start
+		for item := node.FirstChild(); item != nil; item = item.NextSibling() {
+			marker := "\x95"
+			if node.IsOrdered() {
+				marker = fmt.Sprintf("%d.", number)
+				number++
+			}
+			pdf.SetFont("Helvetica", "", pdfFontSize)
+			pdf.SetX(w.left)
+			pdf.CellFormat(pdfIndent, pdfLine, marker, "", 0, "L", false, 0, "")
+			w.left += pdfIndent
+			w.blocks(item)
+			w.left -= pdfIndent
+		}
+		pdf.SetLeftMargin(w.left)
+		pdf.Ln(pdfLine * 0.4)
+	case *ast.Blockquote:
+		top := pdf.GetY()
+		w.left += pdfIndent
+		pdf.SetTextColor(90, 90, 90)
+		w.blocks(node)
+		pdf.SetTextColor(0, 0, 0)
+		w.left -= pdfIndent
+		pdf.SetLeftMargin(w.left)
+		pdf.SetDrawColor(190, 190, 190)
+		pdf.SetLineWidth(0.8)
+		if bottom := pdf.GetY() - pdfLine*0.4; bottom > top {
+			pdf.Line(w.left+2, top, w.left+2, bottom)
+		}
+		pdf.SetLineWidth(0.2)
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		w.code(node)
+	case *ast.ThematicBreak:
+		width, _ := pdf.GetPageSize()
+		pdf.SetDrawColor(160, 160, 160)
+		pdf.Line(w.left, pdf.GetY()+2, width-pdfMargin, pdf.GetY()+2)
+		pdf.Ln(pdfLine)
+	case *extast.Table:
+		w.table(node)
+	case *ast.HTMLBlock:
+		// Raw HTML, such as internal markers, has no place in a PDF
+	default:
+		w.blocks(node)
+	}
+}
+
+// inline typesets the inline children of a node as wrapped text
+func (w *pdfWriter) inline(n ast.Node, lineHeight, size float64) {
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		switch node := child.(type) {
+		case *ast.Text:
+			w.font(size)
+			w.pdf.Write(lineHeight, w.tr(string(node.Segment.Value(w.src))))
+			switch {
+			case node.HardLineBreak():
+				w.pdf.Ln(lineHeight)
+			case node.SoftLineBreak():
+				w.pdf.Write(lineHeight, " ")
+			}
+		case *ast.String:
+			w.font(size)
+			w.pdf.Write(lineHeight, w.tr(string(node.Value)))
+		case *ast.CodeSpan:
+			mono := w.mono
+			w.mono = true
+			w.inline(node, lineHeight, size)
+			w.mono = mono
+		case *ast.Emphasis:
+			style := w.style
+			if node.Level >= 2 {
+				w.style = strings.ReplaceAll(w.style, "B", "") + "B"
+			} else {
+				w.style = strings.ReplaceAll(w.style, "I", "") + "I"
+			}
+			w.inline(node, lineHeight, size)
+			w.style = style
+		case *ast.Link:
+			w.pdf.SetTextColor(20, 80, 170)
+			w.inline(node, lineHeight, size)
+			w.pdf.SetTextColor(0, 0, 0)
+		case *ast.AutoLink:
+			w.font(size)
+			w.pdf.SetTextColor(20, 80, 170)
+			w.pdf.Write(lineHeight, w.tr(string(node.URL(w.src))))
+			w.pdf.SetTextColor(0, 0, 0)
+		case *ast.RawHTML:
+		default:
+			w.inline(node, lineHeight, size)
+		}
+	}
+}
+
+// plain returns the text of a node's inline children without formatting
+func (w *pdfWriter) plain(n ast.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		switch node := child.(type) {
+		case *ast.Text:
+			b.Write(node.Segment.Value(w.src))
+			if node.SoftLineBreak() || node.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(node.Value)
+		case *ast.AutoLink:
+			b.Write(node.URL(w.src))
+		default:
+			b.WriteString(w.plain(node))
+		}
+	}
+	return b.String()
+}
+
+// code typesets a code block on a shaded background, wrapping long lines
+func (w *pdfWriter) code(n ast.Node) {
+	pdf := w.pdf
+	lines := n.Lines()
+	width, _ := pdf.GetPageSize()
+	pdf.SetFont("Courier", "", pdfCodeSize)
+	pdf.SetFillColor(242, 242, 242)
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		line := strings.TrimRight(string(segment.Value(w.src)), "\r\n")
+		line = strings.ReplaceAll(line, "\t", "    ")
+		pdf.SetX(w.left)
+		pdf.MultiCell(width-pdfMargin-w.left, pdfCodeLine, w.tr(line), "", "L", true)
+	}
+	pdf.Ln(pdfLine * 0.6)
+}
+
+// table typesets a table with columns sized to their contents
+func (w *pdfWriter) table(n *extast.Table) {
+	pdf := w.pdf
+	var rows [][]string
+	for row := n.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			cells = append(cells, w.tr(w.plain(cell)))
+		}
+		rows = append(rows, cells)
+	}
+	if len(rows) == 0 {
+		return
+	}
+	columns := len(rows[0])
+	pageWidth, pageHeight := pdf.GetPageSize()
+	available := pageWidth - pdfMargin - w.left
+
+	// Share the width by the widest text of each column, giving no column
+	// more than it needs
+	natural := make([]float64, columns)
+	for i, cells := range rows {
+		if i == 0 {
+			pdf.SetFont("Helvetica", "B", pdfFontSize-1)
+		} else {
+			pdf.SetFont("Helvetica", "", pdfFontSize-1)
+		}
+		for c := 0; c < columns && c < len(cells); c++ {
+			natural[c] = max(natural[c], pdf.GetStringWidth(cells[c])+2*pdfCellPad+0.5)
+		}
+	}
+	total := 0.0
+	for _, width := range natural {
+		total += width
+	}
+	widths := natural
+	if total > available {
+		widths = make([]float64, columns)
+		for c := range widths {
+			widths[c] = max(available*natural[c]/total, 12)
+		}
+	}
+
+	lineHeight := pdfLine * 0.9
+	cellMargin := pdf.GetCellMargin()
+	pdf.SetCellMargin(pdfCellPad)
+	defer pdf.SetCellMargin(cellMargin)
+	pdf.SetDrawColor(190, 190, 190)
+	for i, cells := range rows {
+		style := ""
+		if i == 0 {
+			style = "B"
+		}
+		pdf.SetFont("Helvetica", style, pdfFontSize-1)
+		lines := make([][]string, columns)
+		height := 0.0
+		for c := 0; c < columns; c++ {
+			if c < len(cells) {
+				lines[c] = w.split(cells[c], widths[c])
+			}
+			height = max(height, float64(max(len(lines[c]), 1))*lineHeight+pdfCellPad)
+		}
+		if pdf.GetY()+height > pageHeight-pdfMargin {
+			pdf.AddPage()
+		}
+		x, y := w.left, pdf.GetY()
+		for c := 0; c < columns; c++ {
+			if i == 0 {
+				pdf.SetFillColor(235, 235, 235)
+				pdf.Rect(x, y, widths[c], height, "FD")
+			} else {
+				pdf.Rect(x, y, widths[c], height, "D")
+			}
+			for l, line := range lines[c] {
+				pdf.SetXY(x, y+pdfCellPad/2+float64(l)*lineHeight)
+				pdf.CellFormat(widths[c], lineHeight, line, "", 0, "L", false, 0, "")
+			}
+			x += widths[c]
+		}
+		pdf.SetXY(w.left, y+height)
+	}
+	pdf.Ln(pdfLine * 0.6)
+}
+
+// split wraps text encoded for the standard fonts into lines no wider than
+// width, less the cell margins
+func (w *pdfWriter) split(text string, width float64) []string {
+	// SplitText measures runes, so each encoded byte is passed as the rune
+	// of the same value
+	runes := make([]rune, len(text))
+	for i := 0; i < len(text); i++ {
+		runes[i] = rune(text[i])
+	}
+	var lines []string
+	for _, line := range w.pdf.SplitText(string(runes), width) {
+		encoded := make([]byte, 0, len(line))
+		for _, r := range line {
+			encoded = append(encoded, byte(r))
+		}
+		lines = append(lines, string(encoded))
+	}
+	return lines
+}
@@ -0,0 +1,180 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Report formats
+const (
+	// FormatMarkdown is a Markdown document
+	FormatMarkdown = "markdown"
+
+	// FormatHTML is a standalone HTML page
+	FormatHTML = "html"
+
+	// FormatPDF is a PDF document typeset from the Markdown report
+	FormatPDF = "pdf"
+)
+
+// Formats lists the report formats
+var Formats = []string{FormatMarkdown, FormatHTML, FormatPDF}
+
+// FormatExtension returns the file extension for a report format
+func FormatExtension(format string) string {
+	switch format {
+	case FormatHTML:
+		return ".html"
+	case FormatPDF:
+		return ".pdf"
+	}
+	return ".md"
+}
+
+// ParseFormat reads a report format, accepting "md" for Markdown
+func ParseFormat(text string) (string, error) {
+	switch format := strings.ToLower(strings.TrimSpace(text)); format {
+	case "md", "":
+		return FormatMarkdown, nil
+	case FormatMarkdown, FormatHTML, FormatPDF:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown report format %q (supported: %s)", text, strings.Join(Formats, ", "))
+}
+
+// TemplatesDir is the directory in the data directory holding user report
+// templates: <name>.md.tmpl for Markdown and PDF reports and
+// <name>.html.tmpl for HTML reports. Templates named "default" replace the
+// built-in ones.
+const TemplatesDir = "reports"
+
+// DefaultTemplateName is the name of the template used when none is chosen
+const DefaultTemplateName = "default"
+
+// templateExtension returns the file extension of the templates of a format;
+// PDF reports are typeset from the Markdown template
+func templateExtension(format string) string {
+	if format == FormatHTML {
+		return ".html.tmpl"
+	}
+	return ".md.tmpl"
+}
+
+// BuiltinTemplate returns the built-in template of a format, to start a
+// user template from
+func BuiltinTemplate(format string) string {
+	if format == FormatHTML {
+		return builtinHTML
+	}
+	return builtinMarkdown
+}
+
+// LoadTemplate finds the template a report is rendered with.
+//
+// Parameters:
+//   - dir: The user template directory; it need not exist
+//   - name: A template name in dir, or the path of a template file; empty
+//     selects the default template
+//   - format: The report format
+//
+// Returns:
+//   - The template text: the user template if there is one, otherwise the
+//     built-in template for the default name
+//   - An error if a named template does not exist or cannot be read
+func LoadTemplate(dir, name, format string) (string, error) {
+	if name == "" {
+		name = DefaultTemplateName
+	}
+	if strings.ContainsRune(name, filepath.Separator) || strings.HasSuffix(name, ".tmpl") {
+		data, err := os.ReadFile(name)
+		return string(data), err
+	}
+	if dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, name+templateExtension(format)))
+		switch {
+		case err == nil:
+			return string(data), nil
+		case !errors.Is(err, os.ErrNotExist):
+			return "", err
+		}
+	}
+	if name == DefaultTemplateName {
+		return BuiltinTemplate(format), nil
+	}
+	return "", fmt.Errorf("no report template %q: expected %s", name, filepath.Join(dir, name+templateExtension(format)))
+}
+
+// markdown converts the Markdown of note content to HTML; raw HTML in
+// notes is not passed through
+var markdown = goldmark.New(goldmark.WithExtensions(extension.Table, extension.Strikethrough, extension.Linkify))
+
+// templateFuncs are the functions report templates may call besides the
+// text/template built-ins
+var templateFuncs = map[string]any{
+	// inc turns a zero-based index into a number
+	"inc":   func(i int) int { return i + 1 },
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	// cell makes text safe in a Markdown table cell
+	"cell": func(text string) string {
+		return strings.NewReplacer("|", `\|`, "\r", "", "\n", " ").Replace(text)
+	},
+}
+
+// Render renders a report through a template.
+//
+// Parameters:
+//   - r: The report
+//   - format: One of Formats; PDF reports are typeset from the output of
+//     a Markdown template
+//   - text: The template, in text/template syntax, or html/template
+//     syntax for HTML reports, where {{markdown .Field}} converts Markdown
+//     fields to HTML
+//
+// Returns:
+//   - The rendered report
+//   - An error if the template is invalid or fails
+func Render(r *Report, format, text string) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case FormatHTML:
+		funcs := htmltemplate.FuncMap(maps.Clone(templateFuncs))
+		funcs["markdown"] = func(source string) (htmltemplate.HTML, error) {
+			var out bytes.Buffer
+			err := markdown.Convert([]byte(source), &out)
+			return htmltemplate.HTML(out.String()), err
+		}
+		t, err := htmltemplate.New("report").Funcs(funcs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid report template: %w", err)
+		}
+		if err := t.Execute(&buf, r); err != nil {
+			return nil, fmt.Errorf("rendering the report: %w", err)
+		}
+		return buf.Bytes(), nil
+	case FormatMarkdown, FormatPDF:
+		t, err := template.New("report").Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid report template: %w", err)
+		}
+		if err := t.Execute(&buf, r); err != nil {
+			return nil, fmt.Errorf("rendering the report: %w", err)
+		}
+		if format == FormatPDF {
+			return typeset(r.Title, buf.Bytes())
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unknown report format %q (supported: %s)", format, strings.Join(Formats, ", "))
+}
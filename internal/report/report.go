@@ -0,0 +1,658 @@
+// Package report builds disclosure reports from vulnerability notes and the
+// function and structure notes they link to, and renders them through
+// user-editable templates to Markdown, HTML and PDF.
+package report
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leog/RevEnGo/internal/models"
+	"github.com/leog/RevEnGo/internal/vuln"
+)
+
+// InternalTag marks notes kept out of reports: linked notes with the tag are
+// left out unless internal content is included
+const InternalTag = "internal"
+
+// Fields that can be marked internal, naming the note fields redacted from
+// reports
+const (
+	FieldBinary    = "binary"
+	FieldAddresses = "addresses"
+	FieldFunctions = "functions"
+	FieldVersions  = "versions"
+	FieldVector    = "cvss_vector"
+	FieldTimeline  = "timeline"
+	FieldRelated   = "related"
+)
+
+// RedactableFields lists the fields that can be marked internal
+var RedactableFields = []string{FieldBinary, FieldAddresses, FieldFunctions, FieldVersions, FieldVector, FieldTimeline, FieldRelated}
+
+// FieldLabels describe the fields that can be marked internal
+var FieldLabels = map[string]string{
+	FieldBinary:    "Binary names",
+	FieldAddresses: "Addresses",
+	FieldFunctions: "Function names",
+	FieldVersions:  "Affected versions",
+	FieldVector:    "CVSS vectors",
+	FieldTimeline:  "Timelines",
+	FieldRelated:   "Linked function and structure notes",
+}
+
+// Redacted replaces the value of a field marked internal
+const Redacted = "[REDACTED]"
+
+// Options choose what a report holds
+type Options struct {
+	// Title is the report title; empty uses the finding's title for a
+	// single finding and "Vulnerability Report" otherwise
+	Title string
+
+	// Project is the name of the project the findings are in, if any
+	Project string
+
+	// Date is the day the report is written; the zero time uses today
+	Date time.Time
+
+	// Redact names the fields marked internal, from RedactableFields
+	Redact []string
+
+	// Internal keeps internal content: fields marked internal, sections
+	// marked internal and linked notes tagged internal. It is meant for
+	// drafts shared within the team.
+	Internal bool
+}
+
+// Report is the data a report template is rendered with
+type Report struct {
+	Title   string
+	Project string
+	Date    string
+
+	// Internal reports that internal content was kept
+	Internal bool
+
+	// Findings are the vulnerabilities reported, most severe first
+	Findings []*Finding
+
+	// Severities count the findings of each severity, most severe first;
+	// severities without findings are left out
+	Severities []SeverityCount
+}
+
+// SeverityCount is the number of findings of a severity
+type SeverityCount struct {
+	Severity string
+	Count    int
+}
+
+// Finding is a vulnerability in a report
+type Finding struct {
+	ID    string
+	Title string
+
+	// Severity is the severity label, such as "Critical"; Score the
+	// numeric score such as "9.8", ScoreText the score with its severity
+	// and CVSS version, and Vector the CVSS vector
+	Severity  string
+	Score     string
+	ScoreText string
+	Vector    string
+
+	// CWE is the weakness identifier, such as "CWE-787", and CWEName its
+	// name in the bundled catalog
+	CWE     string
+	CWEName string
+
+	Binary   string
+	Versions []string
+	Status   string
+
+	// Summary is the executive summary, in Markdown: the note's summary
+	// section, or a sentence built from the fields followed by the impact
+	Summary string
+
+	// RootCause, Impact and Mitigation are the note's sections of those
+	// names, in Markdown
+	RootCause  string
+	Impact     string
+	Mitigation string
+
+	// Reproduction are the note's trigger, proof of concept and
+	// reproduction sections
+	Reproduction []Section
+
+	// Details are the note's other sections
+	Details []Section
+
+	// Addresses are the code locations of the finding and the notes it
+	// links to
+	Addresses []Address
+
+	// Timeline lists the days the finding was found and reached each status
+	Timeline []Event
+
+	// Functions and Structures are the linked function and structure notes
+	Functions  []*Related
+	Structures []*Related
+}
+
+// Section is a titled part of a note's content, in Markdown
+type Section struct {
+	Title string
+	Body  string
+}
+
+// Address is a code location affected by a finding
+type Address struct {
+	// Location is an address range or a function name
+	Location string
+	Binary   string
+
+	// Source is the title of the note the location comes from
+	Source string
+}
+
+// Event is a step of a finding's timeline
+type Event struct {
+	Date  string
+	Event string
+}
+
+// Related is a function or structure note linked to a finding
+type Related struct {
+	ID      string
+	Title   string
+	Binary  string
+	Address string
+
+	// Prototype is a function's recovered declaration
+	Prototype string
+
+	// Definition is a structure's C definition
+	Definition string
+
+	// Body is the note's content, in Markdown
+	Body string
+}
+
+// sectionKinds maps section titles, in lowercase, to the parts of a
+// finding they fill
+var sectionKinds = map[string]string{
+	"summary":             "summary",
+	"executive summary":   "summary",
+	"overview":            "summary",
+	"root cause":          "root cause",
+	"root cause analysis": "root cause",
+	"technical details":   "root cause",
+	"impact":              "impact",
+	"trigger":             "reproduction",
+	"proof of concept":    "reproduction",
+	"poc":                 "reproduction",
+	"reproduction":        "reproduction",
+	"reproduction steps":  "reproduction",
+	"steps to reproduce":  "reproduction",
+	"exploitation":        "reproduction",
+	"mitigation":          "mitigation",
+	"remediation":         "mitigation",
+	"recommendation":      "mitigation",
+	"recommendations":     "mitigation",
+	"workaround":          "mitigation",
+	"workarounds":         "mitigation",
+}
+
+// Build collects the findings of vulnerability notes into a report.
+//
+// Parameters:
+//   - findings: The vulnerability notes to report
+//   - notes: All known notes, to resolve the function and structure notes
+//     the findings link to
+//   - opts: What the report holds
+//
+// Returns:
+//   - The report, with findings most severe first
+//   - An error if a note is not a vulnerability note or no note is given
+func Build(findings []*models.Note, notes []*models.Note, opts Options) (*Report, error) {
+	if len(findings) == 0 {
+		return nil, fmt.Errorf("no vulnerability notes to report")
+	}
+	for _, note := range findings {
+		if note.ReverseEngType != models.RETypeVulnerability {
+			return nil, fmt.Errorf("note %q is not a vulnerability note", note.Title)
+		}
+	}
+	for _, field := range opts.Redact {
+		if !slices.Contains(RedactableFields, field) {
+			return nil, fmt.Errorf("unknown internal field %q: use %s", field, strings.Join(RedactableFields, ", "))
+		}
+	}
+	date := opts.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	r := &Report{
+		Title:    opts.Title,
+		Project:  opts.Project,
+		Date:     date.Format(models.VulnDateLayout),
+		Internal: opts.Internal,
+	}
+	counts := make(map[vuln.Severity]int)
+	for _, f := range models.Findings(findings) {
+		r.Findings = append(r.Findings, newFinding(f, notes, opts))
+		counts[f.Severity()]++
+	}
+	for _, severity := range vuln.Severities {
+		if counts[severity] > 0 {
+			r.Severities = append(r.Severities, SeverityCount{severity.Label(), counts[severity]})
+		}
+	}
+	if r.Title == "" {
+		r.Title = "Vulnerability Report"
+		if len(r.Findings) == 1 {
+			r.Title = r.Findings[0].Title
+		}
+	}
+	return r, nil
+}
+
+// newFinding fills a finding from a vulnerability note
+func newFinding(f models.Finding, notes []*models.Note, opts Options) *Finding {
+	redacted := func(field string) bool {
+		return !opts.Internal && slices.Contains(opts.Redact, field)
+	}
+	note := f.Note
+	details := note.Vulnerability
+	if details == nil {
+		details = &models.VulnerabilityDetails{}
+	}
+
+	finding := &Finding{
+		ID:       note.ID,
+		Title:    note.Title,
+		Severity: f.Severity().Label(),
+		Status:   statusLabel(f.Status()),
+		Binary:   f.Binary(),
+		Versions: details.AffectedVersions,
+		Vector:   details.CVSSVector,
+	}
+	if f.Score.Version != "" {
+		finding.Score = fmt.Sprintf("%.1f", f.Score.Value)
+		finding.ScoreText = f.Score.String()
+	}
+	if cwe, err := vuln.ParseCWE(details.CWE); err == nil {
+		finding.CWE, finding.CWEName = cwe.Ref(), cwe.Name
+	} else {
+		finding.CWE = details.CWE
+	}
+
+	// Links to the notes whose titles are redacted
+	var hidden []string
+	if redacted(FieldFunctions) || redacted(FieldRelated) {
+		hidden = append(hidden, models.RETypeFunctionAnalysis)
+	}
+	if redacted(FieldRelated) {
+		hidden = append(hidden, models.RETypeStructureAnalysis)
+	}
+	for _, s := range splitSections(contentOf(note, notes, opts.Internal, hidden), opts.Internal) {
+		switch sectionKinds[strings.ToLower(s.Title)] {
+		case "summary":
+			finding.Summary = joinBodies(finding.Summary, s.Body)
+		case "root cause":
+			finding.RootCause = joinBodies(finding.RootCause, s.Body)
+		case "impact":
+			finding.Impact = joinBodies(finding.Impact, s.Body)
+		case "mitigation":
+			finding.Mitigation = joinBodies(finding.Mitigation, s.Body)
+		case "reproduction":
+			finding.Reproduction = append(finding.Reproduction, s)
+		default:
+			if s.Title != "" || s.Body != "" {
+				finding.Details = append(finding.Details, s)
+			}
+		}
+	}
+	finding.Timeline = timeline(note, details)
+	for _, linked := range linkedNotes(note, notes, opts.Internal) {
+		related := &Related{
+			ID:      linked.ID,
+			Title:   linked.Title,
+			Binary:  linked.BinaryName,
+			Address: linked.AddressRange,
+			Body:    strings.Join(bodies(splitSections(contentOf(linked, notes, opts.Internal, hidden), opts.Internal)), "\n\n"),
+		}
+		switch linked.ReverseEngType {
+		case models.RETypeFunctionAnalysis:
+			if linked.Function != nil {
+				related.Prototype = linked.Function.Prototype
+			}
+			finding.Functions = append(finding.Functions, related)
+		case models.RETypeStructureAnalysis:
+			if linked.Structure != nil {
+				related.Definition = strings.TrimSpace(linked.Structure.Definition)
+			}
+			finding.Structures = append(finding.Structures, related)
+		}
+	}
+	finding.Addresses = addresses(note, finding)
+
+	// Redact the fields marked internal
+	if redacted(FieldBinary) {
+		finding.Binary = redact(finding.Binary)
+		for i := range finding.Addresses {
+			finding.Addresses[i].Binary = redact(finding.Addresses[i].Binary)
+		}
+		for _, related := range append(finding.Functions, finding.Structures...) {
+			related.Binary = redact(related.Binary)
+		}
+	}
+	if redacted(FieldAddresses) {
+		for i := range finding.Addresses {
+			finding.Addresses[i].Location = redact(finding.Addresses[i].Location)
+		}
+		for _, related := range append(finding.Functions, finding.Structures...) {
+			related.Address = redact(related.Address)
+		}
+	}
+	if redacted(FieldFunctions) {
+		finding.Addresses = slices.DeleteFunc(finding.Addresses, func(a Address) bool { return a.Source == "" })
+		for i, a := range finding.Addresses {
+			if slices.ContainsFunc(finding.Functions, func(related *Related) bool { return related.Title == a.Source }) {
+				finding.Addresses[i].Source = Redacted
+			}
+		}
+		for _, related := range finding.Functions {
+			related.Title, related.Prototype = Redacted, ""
+		}
+	}
+	if redacted(FieldVersions) && len(finding.Versions) > 0 {
+		finding.Versions = []string{Redacted}
+	}
+	if redacted(FieldVector) {
+		finding.Vector = redact(finding.Vector)
+	}
+	if redacted(FieldTimeline) {
+		finding.Timeline = nil
+	}
+	if redacted(FieldRelated) {
+		finding.Functions, finding.Structures = nil, nil
+		finding.Addresses = slices.DeleteFunc(finding.Addresses, func(a Address) bool { return a.Source != "" && a.Source != note.Title })
+	}
+
+	// The summary is written from the fields left after redaction
+	if finding.Summary == "" {
+		finding.Summary = joinBodies(summarize(finding), firstParagraph(finding.Impact))
+	}
+	return finding
+}
+
+// redact replaces a value that is set
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return Redacted
+}
+
+// summarize describes a finding in a sentence from its fields, leaving
+// out redacted ones
+func summarize(f *Finding) string {
+	var b strings.Builder
+	b.WriteString(f.Title)
+	switch {
+	case f.CWEName != "":
+		fmt.Fprintf(&b, " is a vulnerability classified as %s (%s)", f.CWEName, f.CWE)
+	case f.CWE != "":
+		fmt.Fprintf(&b, " is a vulnerability classified as %s", f.CWE)
+	default:
+		b.WriteString(" is a vulnerability")
+	}
+	if f.Binary != "" && f.Binary != Redacted {
+		fmt.Fprintf(&b, " in `%s`", f.Binary)
+	}
+	if len(f.Versions) > 0 && !slices.Contains(f.Versions, Redacted) {
+		fmt.Fprintf(&b, " affecting versions %s", strings.Join(f.Versions, "; "))
+	}
+	if f.Score != "" {
+		fmt.Fprintf(&b, ", rated %s with a CVSS score of %s", strings.ToLower(f.Severity), f.Score)
+	}
+	b.WriteString(".")
+	return b.String()
+}
+
+// timeline lists the day a finding's note was created and the days it
+// reached each status
+func timeline(note *models.Note, details *models.VulnerabilityDetails) []Event {
+	var events []Event
+	if !note.Created.IsZero() {
+		events = append(events, Event{note.Created.Format(models.VulnDateLayout), "Finding recorded"})
+	}
+	for _, status := range models.VulnStatuses {
+		if date := details.StatusDates[status]; date != "" {
+			events = append(events, Event{date, statusLabel(status)})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Date < events[j].Date })
+	return events
+}
+
+// statusLabel names a status in prose
+func statusLabel(status string) string {
+	if status == models.VulnStatusWontFix {
+		return "Won't fix"
+	}
+	if status == "" {
+		return ""
+	}
+	return strings.ToUpper(status[:1]) + strings.ReplaceAll(status[1:], "_", " ")
+}
+
+// addresses collects the code locations of a finding and its linked notes
+func addresses(note *models.Note, f *Finding) []Address {
+	var result []Address
+	seen := make(map[string]bool)
+	add := func(location, binary, source string) {
+		location = strings.TrimSpace(location)
+		if location == "" || seen[binary+"\x00"+location] {
+			return
+		}
+		seen[binary+"\x00"+location] = true
+		result = append(result, Address{Location: location, Binary: binary, Source: source})
+	}
+	add(note.AddressRange, f.Binary, note.Title)
+	for _, ref := range note.FunctionRefs {
+		add(ref, f.Binary, "")
+	}
+	for _, related := range append(f.Functions, f.Structures...) {
+		binary := related.Binary
+		if binary == "" {
+			binary = f.Binary
+		}
+		add(related.Address, binary, related.Title)
+	}
+	return result
+}
+
+// linkedNotes returns the function and structure notes a note links to
+// or that link to it, leaving out those tagged internal unless internal
+// content is kept
+func linkedNotes(note *models.Note, notes []*models.Note, internal bool) []*models.Note {
+	ids := append(slices.Clone(note.RelatedNotes), models.ResolveLinks(note.Content, notes)...)
+	for _, backlink := range models.Backlinks(note.ID, notes) {
+		ids = append(ids, backlink.ID)
+	}
+	var linked []*models.Note
+	seen := map[string]bool{note.ID: true}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		other := models.FindNoteByLink(id, notes)
+		if other == nil || other.ID != id {
+			continue
+		}
+		if other.ReverseEngType != models.RETypeFunctionAnalysis && other.ReverseEngType != models.RETypeStructureAnalysis {
+			continue
+		}
+		if !internal && slices.Contains(other.Tags, InternalTag) {
+			continue
+		}
+		linked = append(linked, other)
+	}
+	sort.SliceStable(linked, func(i, j int) bool { return linked[i].AddressRange < linked[j].AddressRange })
+	return linked
+}
+
+// Markers of internal content in notes
+var (
+	// internalBlock matches text between <!-- internal --> and
+	// <!-- /internal --> comments
+	internalBlock = regexp.MustCompile(`(?is)<!--\s*internal\s*-->.*?(<!--\s*/internal\s*-->|\z)`)
+
+	// internalHeading matches a section title marked "(internal)"
+	internalHeading = regexp.MustCompile(`(?i)\s*[\(\[]internal[\)\]]\s*`)
+)
+
+// contentOf returns a note's content as a report shows it: wiki links
+// become the titles of the notes they link to, or Redacted for notes of
+// the hidden types, and, unless internal content is kept, blocks between
+// <!-- internal --> and <!-- /internal --> are removed
+func contentOf(note *models.Note, notes []*models.Note, internal bool, hidden []string) string {
+	content := note.Content
+	if !internal {
+		content = internalBlock.ReplaceAllString(content, "")
+	}
+	return models.ReplaceWikiLinks(content, func(link models.WikiLink) string {
+		linked := models.FindNoteByLink(link.Target, notes)
+		switch {
+		case linked != nil && slices.Contains(hidden, linked.ReverseEngType):
+			return Redacted
+		case link.Label != "":
+			return link.Label
+		case linked != nil:
+			return linked.Title
+		}
+		return link.Target
+	})
+}
+
+// Headings of note content
+var (
+	// headingPattern matches a level 1 or 2 ATX heading
+	headingPattern = regexp.MustCompile(`^ {0,3}(#{1,2})[ \t]+(.*?)[ \t#]*$`)
+
+	// subheadingPattern matches a level 3 to 5 ATX heading
+	subheadingPattern = regexp.MustCompile(`^ {0,3}#{3,5}[ \t]`)
+
+	// fieldLine matches a line of a field's value, such as
+	// "**Affected code:** 0x401000"
+	fieldLine = regexp.MustCompile(`^\s*\*\*[^*]+:\*\*`)
+
+	// blankLines matches the blank lines left where internal blocks were
+	blankLines = regexp.MustCompile(`\n[ \t]*\n([ \t]*\n)+`)
+)
+
+// splitSections splits content at its level 1 and 2 headings. The level 1
+// heading naming the note is dropped, as are bold "**Label:** value"
+// lines before the first section, which repeat the note's fields, and
+// sections marked internal, such as "## Exploit chain (internal)", unless
+// internal content is kept. Deeper headings in a section are moved below
+// the levels the report templates use.
+//
+// Returns:
+//   - The sections in order; text before the first heading is a section
+//     without a title
+func splitSections(content string, keepInternal bool) []Section {
+	var sections []Section
+	current := Section{}
+	internal := false
+	var body []string
+	fence := ""
+	flush := func() {
+		current.Body = strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(body, "\n"), "\n\n"))
+		if (keepInternal || !internal) && (current.Title != "" || current.Body != "") {
+			sections = append(sections, current)
+		}
+		body = nil
+	}
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			body = append(body, line)
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			body = append(body, line)
+			continue
+		}
+		m := headingPattern.FindStringSubmatch(line)
+		if m == nil && current.Title == "" && fieldLine.MatchString(line) {
+			continue
+		}
+		if m == nil {
+			if subheadingPattern.MatchString(line) {
+				line = "#" + strings.TrimLeft(line, " ")
+			}
+			body = append(body, line)
+			continue
+		}
+		flush()
+		title := m[2]
+		internal = internalHeading.MatchString(title)
+		if len(m[1]) == 1 {
+			// The note's title heading; its text joins the untitled section
+			current = Section{}
+			continue
+		}
+		if !keepInternal {
+			title = internalHeading.ReplaceAllString(title, " ")
+		}
+		current = Section{Title: strings.TrimSpace(title)}
+	}
+	flush()
+	return sections
+}
+
+// bodies returns the content of sections with their titles as bold lines
+func bodies(sections []Section) []string {
+	var result []string
+	for _, s := range sections {
+		switch {
+		case s.Body == "":
+			continue
+		case s.Title == "":
+			result = append(result, s.Body)
+		default:
+			result = append(result, "**"+s.Title+"**\n\n"+s.Body)
+		}
+	}
+	return result
+}
+
+// joinBodies joins Markdown texts with a blank line, skipping empty ones
+func joinBodies(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	}
+	return a + "\n\n" + b
+}
+
+// firstParagraph returns the text up to the first blank line
+func firstParagraph(text string) string {
+	paragraph, _, _ := strings.Cut(strings.TrimSpace(text), "\n\n")
+	return paragraph
+}
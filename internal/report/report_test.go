@@ -0,0 +1,212 @@
+package report
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leog/RevEnGo/internal/models"
+)
+
+// Values of the test finding that each redactable field must hide
+var secrets = map[string][]string{
+	FieldBinary:    {"libacme.so.3"},
+	FieldAddresses: {"0x401000-0x4010ff", "0x402200"},
+	FieldFunctions: {"acme_parse_header", "Chunk reader", "read_chunk"},
+	FieldVersions:  {"2.4.0", "2.4.1"},
+	FieldVector:    {"AV:N/AC:L/PR:N/UI:N"},
+	FieldTimeline:  {"2026-03-14", "2026-04-02"},
+	FieldRelated:   {"Chunk reader", "read_chunk"},
+}
+
+// testNotes returns a vulnerability note with every redactable field set,
+// without a summary section so the report writes one, and the function
+// note it links to
+func testNotes() (*models.Note, []*models.Note) {
+	function := &models.Note{
+		ID:             "fn-1",
+		Title:          "Chunk reader",
+		Content:        "Reads one chunk without checking its length.",
+		BinaryName:     "libacme.so.3",
+		AddressRange:   "0x402200",
+		ReverseEngType: models.RETypeFunctionAnalysis,
+		Function:       &models.FunctionDetails{Prototype: "int read_chunk(struct acme_ctx *ctx)"},
+	}
+	finding := &models.Note{
+		ID:             "vuln-1",
+		Title:          "Heap overflow in chunk parsing",
+		Content:        "## Impact\n\nA crafted file overwrites heap memory.\n\n## Root Cause\n\n[[Chunk reader]] trusts the chunk length.\n\n## Mitigation\n\nUpgrade.",
+		Created:        time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC),
+		BinaryName:     "libacme.so.3",
+		AddressRange:   "0x401000-0x4010ff",
+		FunctionRefs:   []string{"acme_parse_header"},
+		RelatedNotes:   []string{function.ID},
+		ReverseEngType: models.RETypeVulnerability,
+		Vulnerability: &models.VulnerabilityDetails{
+			CWE:              "CWE-787",
+			CVSSVector:       "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+			AffectedVersions: []string{"2.4.0", "2.4.1"},
+			Status:           models.VulnStatusConfirmed,
+			StatusDates:      map[string]string{models.VulnStatusConfirmed: "2026-04-02"},
+		},
+	}
+	return finding, []*models.Note{finding, function}
+}
+
+// rendered returns the text of a report rendered with the built-in
+// template of a format; PDF content streams are inflated
+func rendered(t *testing.T, r *Report, format string) string {
+	t.Helper()
+	out, err := Render(r, format, BuiltinTemplate(format))
+	if err != nil {
+		t.Fatalf("Render(%s): %v", format, err)
+	}
+	if format != FormatPDF {
+		return string(out)
+	}
+	return pdfText(t, out)
+}
+
+// pdfStream matches the content streams of a PDF document
+var pdfStream = regexp.MustCompile(`(?s)stream\r?\n(.*?)\r?\nendstream`)
+
+// pdfText inflates the content streams of a PDF document
+func pdfText(t *testing.T, pdf []byte) string {
+	t.Helper()
+	var text strings.Builder
+	for _, m := range pdfStream.FindAllSubmatch(pdf, -1) {
+		zr, err := zlib.NewReader(bytes.NewReader(m[1]))
+		if err != nil {
+			text.Write(m[1])
+			continue
+		}
+		data, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("inflating a PDF stream: %v", err)
+		}
+		text.Write(data)
+	}
+	return text.String()
+}
+
+func TestRedaction(t *testing.T) {
+	finding, notes := testNotes()
+	date := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			// Without redaction every value is shown, so the checks below
+			// can find them
+			r, err := Build([]*models.Note{finding}, notes, Options{Date: date})
+			if err != nil {
+				t.Fatal(err)
+			}
+			text := rendered(t, r, format)
+			for field, values := range secrets {
+				for _, value := range values {
+					if !strings.Contains(text, value) {
+						t.Errorf("unredacted report lacks the %s value %q", field, value)
+					}
+				}
+			}
+
+			for _, field := range RedactableFields {
+				r, err := Build([]*models.Note{finding}, notes, Options{Date: date, Redact: []string{field}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				text := rendered(t, r, format)
+				for _, value := range secrets[field] {
+					if strings.Contains(text, value) {
+						t.Errorf("redacting %s: report shows %q", field, value)
+					}
+				}
+			}
+
+			// Internal reports keep the fields marked internal
+			r, err = Build([]*models.Note{finding}, notes, Options{Date: date, Redact: RedactableFields, Internal: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			text = rendered(t, r, format)
+			for field, values := range secrets {
+				for _, value := range values {
+					if !strings.Contains(text, value) {
+						t.Errorf("internal report lacks the %s value %q", field, value)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSummary(t *testing.T) {
+	finding, notes := testNotes()
+	tests := []struct {
+		redact []string
+		want   string
+	}{
+		{nil, "Heap overflow in chunk parsing is a vulnerability classified as Out-of-bounds Write (CWE-787) in `libacme.so.3` affecting versions 2.4.0; 2.4.1, rated critical with a CVSS score of 9.8."},
+		{[]string{FieldBinary}, "Heap overflow in chunk parsing is a vulnerability classified as Out-of-bounds Write (CWE-787) affecting versions 2.4.0; 2.4.1, rated critical with a CVSS score of 9.8."},
+		{[]string{FieldBinary, FieldVersions}, "Heap overflow in chunk parsing is a vulnerability classified as Out-of-bounds Write (CWE-787), rated critical with a CVSS score of 9.8."},
+	}
+	for _, tt := range tests {
+		r, err := Build([]*models.Note{finding}, notes, Options{Redact: tt.redact})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := tt.want + "\n\nA crafted file overwrites heap memory."
+		if got := r.Findings[0].Summary; got != want {
+			t.Errorf("redacting %v: summary\n%q\nwant\n%q", tt.redact, got, want)
+		}
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	finding, notes := testNotes()
+	if _, err := Build(nil, notes, Options{}); err == nil {
+		t.Error("Build without findings succeeded")
+	}
+	if _, err := Build([]*models.Note{notes[1]}, notes, Options{}); err == nil {
+		t.Error("Build of a function note succeeded")
+	}
+	if _, err := Build([]*models.Note{finding}, notes, Options{Redact: []string{"secrets"}}); err == nil {
+		t.Error("Build with an unknown internal field succeeded")
+	}
+}
+
+func TestRedactBinary(t *testing.T) {
+	finding, notes := testNotes()
+	tests := []struct {
+		redact []string
+		want   string
+	}{
+		{[]string{FieldAddresses}, "libacme.so.3"},
+		{[]string{FieldFunctions}, "libacme.so.3"},
+		{[]string{FieldBinary}, Redacted},
+	}
+	for _, tt := range tests {
+		r, err := Build([]*models.Note{finding}, notes, Options{Redact: tt.redact})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := r.Findings[0]
+		binaries := []string{f.Binary}
+		for _, a := range f.Addresses {
+			binaries = append(binaries, a.Binary)
+		}
+		for _, related := range f.Functions {
+			binaries = append(binaries, related.Binary)
+		}
+		for _, binary := range binaries {
+			if binary != tt.want {
+				t.Errorf("redacting %v: binaries %q, want all %q", tt.redact, binaries, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package report
+
+// builtinMarkdown is the default Markdown report template, also the source
+// of PDF reports
+const builtinMarkdown = `# {{.Title}}
+
+{{if .Project}}**Project:** {{.Project}}
+{{end}}**Date:** {{.Date}}
+**Findings:** {{len .Findings}}{{range .Severities}} · {{.Count}} {{.Severity}}{{end}}
+{{- if .Internal}}
+
+> **INTERNAL DRAFT.** This report includes content marked internal. Do not share it outside the team.
+{{- end}}
+
+## Executive Summary
+
+| # | Finding | Severity | CVSS | CWE | Status |
+|---|---------|----------|------|-----|--------|
+{{- range $i, $f := .Findings}}
+| {{inc $i}} | {{cell $f.Title}} | {{$f.Severity}} | {{or $f.Score "-"}} | {{or $f.CWE "-"}} | {{or $f.Status "-"}} |
+{{- end}}
+{{- range $i, $f := .Findings}}
+
+## {{inc $i}}. {{$f.Title}}
+
+{{$f.Summary}}
+
+| | |
+|---|---|
+| **Severity** | {{or $f.ScoreText $f.Severity}} |
+{{- if $f.Vector}}
+| **CVSS vector** | ` + "`{{$f.Vector}}`" + ` |
+{{- end}}
+{{- if $f.CWE}}
+| **Weakness** | {{$f.CWE}}{{if $f.CWEName}}: {{cell $f.CWEName}}{{end}} |
+{{- end}}
+{{- if $f.Binary}}
+| **Affected binary** | ` + "`{{$f.Binary}}`" + ` |
+{{- end}}
+{{- if $f.Versions}}
+| **Affected versions** | {{cell (join $f.Versions "; ")}} |
+{{- end}}
+{{- if $f.Status}}
+| **Status** | {{$f.Status}} |
+{{- end}}
+{{- if $f.RootCause}}
+
+### Root Cause
+
+{{$f.RootCause}}
+{{- end}}
+{{- if $f.Impact}}
+
+### Impact
+
+{{$f.Impact}}
+{{- end}}
+{{- if $f.Reproduction}}
+
+### Reproduction
+{{- range $f.Reproduction}}
+
+#### {{.Title}}
+
+{{.Body}}
+{{- end}}
+{{- end}}
+{{- if $f.Addresses}}
+
+### Affected Addresses
+
+| Location | Binary | Note |
+|----------|--------|------|
+{{- range $f.Addresses}}
+| ` + "`{{cell .Location}}`" + ` | {{or .Binary "-"}} | {{or (cell .Source) "-"}} |
+{{- end}}
+{{- end}}
+{{- range $f.Functions}}
+
+### Function: {{.Title}}
+{{- if .Address}}
+
+**Address:** ` + "`{{.Address}}`" + `{{if .Binary}} in ` + "`{{.Binary}}`" + `{{end}}
+{{- end}}
+{{- if .Prototype}}
+
+` + "```c" + `
+{{.Prototype}}
+` + "```" + `
+{{- end}}
+{{- if .Body}}
+
+{{.Body}}
+{{- end}}
+{{- end}}
+{{- range $f.Structures}}
+
+### Structure: {{.Title}}
+{{- if .Address}}
+
+**Address:** ` + "`{{.Address}}`" + `{{if .Binary}} in ` + "`{{.Binary}}`" + `{{end}}
+{{- end}}
+{{- if .Definition}}
+
+` + "```c" + `
+{{.Definition}}
+` + "```" + `
+{{- end}}
+{{- if .Body}}
+
+{{.Body}}
+{{- end}}
+{{- end}}
+{{- range $f.Details}}
+
+### {{or .Title "Details"}}
+
+{{.Body}}
+{{- end}}
+{{- if $f.Mitigation}}
+
+### Mitigation
+
+{{$f.Mitigation}}
+{{- end}}
+{{- if $f.Timeline}}
+
+### Timeline
+
+| Date | Event |
+|------|-------|
+{{- range $f.Timeline}}
+| {{.Date}} | {{.Event}} |
+{{- end}}
+{{- end}}
+{{- end}}
+`
+
+// builtinHTML is the default HTML report template
+const builtinHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 60em; margin: 2em auto; padding: 0 1em; color: #222; line-height: 1.5; }
+h1 { border-bottom: 3px solid #222; padding-bottom: .3em; }
+h2 { border-bottom: 1px solid #ccc; padding-bottom: .2em; margin-top: 2em; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: .3em .7em; text-align: left; vertical-align: top; }
+th { background: #f3f3f3; }
+code, pre { font-family: "SFMono-Regular", Consolas, "Liberation Mono", monospace; font-size: .9em; }
+pre { background: #f6f8fa; padding: .8em; overflow-x: auto; }
+.meta { color: #555; }
+.internal { background: #fff3cd; border: 1px solid #e0b100; padding: .6em 1em; }
+.severity { font-weight: bold; padding: .1em .5em; border-radius: .3em; color: #fff; background: #777; }
+.severity.critical { background: #7b1fa2; }
+.severity.high { background: #c62828; }
+.severity.medium { background: #ef6c00; }
+.severity.low { background: #2e7d32; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">{{if .Project}}Project: {{.Project}} · {{end}}Date: {{.Date}} · {{len .Findings}} findings{{range .Severities}} · {{.Count}} {{.Severity}}{{end}}</p>
+{{if .Internal}}<p class="internal"><strong>INTERNAL DRAFT.</strong> This report includes content marked internal. Do not share it outside the team.</p>
+{{end}}
+<h2>Executive Summary</h2>
+<table>
+<tr><th>#</th><th>Finding</th><th>Severity</th><th>CVSS</th><th>CWE</th><th>Status</th></tr>
+{{range $i, $f := .Findings}}<tr><td>{{inc $i}}</td><td><a href="#finding-{{inc $i}}">{{$f.Title}}</a></td><td><span class="severity {{lower $f.Severity}}">{{$f.Severity}}</span></td><td>{{or $f.Score "-"}}</td><td>{{or $f.CWE "-"}}</td><td>{{or $f.Status "-"}}</td></tr>
+{{end}}</table>
+{{range $i, $f := .Findings}}
+<h2 id="finding-{{inc $i}}">{{inc $i}}. {{$f.Title}}</h2>
+{{markdown $f.Summary}}
+<table>
+<tr><th>Severity</th><td><span class="severity {{lower $f.Severity}}">{{or $f.ScoreText $f.Severity}}</span></td></tr>
+{{if $f.Vector}}<tr><th>CVSS vector</th><td><code>{{$f.Vector}}</code></td></tr>
+{{end}}{{if $f.CWE}}<tr><th>Weakness</th><td>{{$f.CWE}}{{if $f.CWEName}}: {{$f.CWEName}}{{end}}</td></tr>
+{{end}}{{if $f.Binary}}<tr><th>Affected binary</th><td><code>{{$f.Binary}}</code></td></tr>
+{{end}}{{if $f.Versions}}<tr><th>Affected versions</th><td>{{join $f.Versions "; "}}</td></tr>
+{{end}}{{if $f.Status}}<tr><th>Status</th><td>{{$f.Status}}</td></tr>
+{{end}}</table>
+{{if $f.RootCause}}<h3>Root Cause</h3>
+{{markdown $f.RootCause}}
+{{end}}{{if $f.Impact}}<h3>Impact</h3>
+{{markdown $f.Impact}}
+{{end}}{{if $f.Reproduction}}<h3>Reproduction</h3>
+{{range $f.Reproduction}}<h4>{{.Title}}</h4>
+{{markdown .Body}}
+{{end}}{{end}}{{if $f.Addresses}}<h3>Affected Addresses</h3>
+<table>
+<tr><th>Location</th><th>Binary</th><th>Note</th></tr>
+{{range $f.Addresses}}<tr><td><code>{{.Location}}</code></td><td>{{or .Binary "-"}}</td><td>{{or .Source "-"}}</td></tr>
+{{end}}</table>
+{{end}}{{range $f.Functions}}<h3>Function: {{.Title}}</h3>
+{{if .Address}}<p><strong>Address:</strong> <code>{{.Address}}</code>{{if .Binary}} in <code>{{.Binary}}</code>{{end}}</p>
+{{end}}{{if .Prototype}}<pre><code>{{.Prototype}}</code></pre>
+{{end}}{{markdown .Body}}
+{{end}}{{range $f.Structures}}<h3>Structure: {{.Title}}</h3>
+{{if .Address}}<p><strong>Address:</strong> <code>{{.Address}}</code>{{if .Binary}} in <code>{{.Binary}}</code>{{end}}</p>
+{{end}}{{if .Definition}}<pre><code>{{.Definition}}</code></pre>
+{{end}}{{markdown .Body}}
+{{end}}{{range $f.Details}}<h3>{{or .Title "Details"}}</h3>
+{{markdown .Body}}
+{{end}}{{if $f.Mitigation}}<h3>Mitigation</h3>
+{{markdown $f.Mitigation}}
+{{end}}{{if $f.Timeline}}<h3>Timeline</h3>
+<table>
+<tr><th>Date</th><th>Event</th></tr>
+{{range $f.Timeline}}<tr><td>{{.Date}}</td><td>{{.Event}}</td></tr>
+{{end}}</table>
+{{end}}{{end}}
+</body>
+</html>
+`
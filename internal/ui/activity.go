@@ -0,0 +1,311 @@
+// Package ui provides user interface components and setup for the RevEnGo application.
+// This file contains the activity log and the feedback given after operations.
+package ui
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/leog/RevEnGo/internal/ui/widgets"
+)
+
+// ActivityFile is the name of the activity log file in the data directory
+const ActivityFile = "activity.jsonl"
+
+// maxActivityEntries is the number of entries the activity log keeps
+const maxActivityEntries = 500
+
+// ActivityKind classifies an activity log entry
+type ActivityKind string
+
+// Kinds of activity
+const (
+	ActivitySave   ActivityKind = "save"
+	ActivityDelete ActivityKind = "delete"
+	ActivityImport ActivityKind = "import"
+	ActivityExport ActivityKind = "export"
+	ActivityError  ActivityKind = "error"
+)
+
+// ActivityEntry is one line of the activity log
+type ActivityEntry struct {
+	Time    time.Time    `json:"time"`
+	Kind    ActivityKind `json:"kind"`
+	Message string       `json:"message"`
+
+	// Path is the file involved, if known
+	Path string `json:"path,omitempty"`
+}
+
+// ActivityLog records saves, deletes, imports and errors with timestamps.
+// Entries are appended to a JSON Lines file so the log survives restarts.
+// Entries may be added from any goroutine.
+type ActivityLog struct {
+	mu      sync.Mutex
+	entries []ActivityEntry
+
+	// path is the log file; the log is kept in memory only when empty
+	path string
+
+	// OnChanged is called after an entry is added or the log is cleared
+	OnChanged func()
+}
+
+// NewActivityLog opens an activity log, loading the most recent entries
+// from its file.
+//
+// Parameters:
+//   - path: The log file, created on the first entry; empty keeps the log
+//     in memory only
+//
+// Returns:
+//   - The activity log; an unreadable file starts an empty log
+func NewActivityLog(path string) *ActivityLog {
+	l := &ActivityLog{path: path}
+	if path == "" {
+		return l
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Warning: reading activity log: %v", err)
+		}
+		return l
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry ActivityEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			l.entries = append(l.entries, entry)
+		}
+	}
+	if len(l.entries) > maxActivityEntries {
+		l.entries = l.entries[len(l.entries)-maxActivityEntries:]
+		l.rewrite()
+	}
+	return l
+}
+
+// Add records an entry.
+//
+// Parameters:
+//   - kind: The kind of activity
+//   - message: What happened
+//   - path: The file involved, or "" if none
+func (l *ActivityLog) Add(kind ActivityKind, message, path string) {
+	entry := ActivityEntry{Time: time.Now(), Kind: kind, Message: message, Path: path}
+
+	l.mu.Lock()
+	l.entries = append(l.entries, entry)
+	if len(l.entries) > maxActivityEntries {
+		l.entries = l.entries[len(l.entries)-maxActivityEntries:]
+	}
+	l.append(entry)
+	l.mu.Unlock()
+
+	if l.OnChanged != nil {
+		l.OnChanged()
+	}
+}
+
+// Entries returns a copy of the entries, oldest first
+func (l *ActivityLog) Entries() []ActivityEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]ActivityEntry(nil), l.entries...)
+}
+
+// Clear removes every entry from the log and its file
+func (l *ActivityLog) Clear() {
+	l.mu.Lock()
+	l.entries = nil
+	l.rewrite()
+	l.mu.Unlock()
+
+	if l.OnChanged != nil {
+		l.OnChanged()
+	}
+}
+
+// append writes an entry to the end of the log file
+func (l *ActivityLog) append(entry ActivityEntry) {
+	if l.path == "" {
+		return
+	}
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("Warning: writing activity log: %v", err)
+		return
+	}
+	defer file.Close()
+
+	line, _ := json.Marshal(entry)
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		log.Printf("Warning: writing activity log: %v", err)
+	}
+}
+
+// rewrite replaces the log file with the entries in memory
+func (l *ActivityLog) rewrite() {
+	if l.path == "" {
+		return
+	}
+	var data []byte
+	for _, entry := range l.entries {
+		line, _ := json.Marshal(entry)
+		data = append(append(data, line...), '\n')
+	}
+	if err := os.WriteFile(l.path, data, 0644); err != nil {
+		log.Printf("Warning: writing activity log: %v", err)
+	}
+}
+
+// newActivityPanel creates a panel listing the activity log, newest first
+func newActivityPanel(activity *ActivityLog) fyne.CanvasObject {
+	entries := activity.Entries()
+
+	list := widget.NewList(
+		func() int {
+			return len(entries)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.TextStyle = fyne.TextStyle{Monospace: true}
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			entry := entries[len(entries)-1-id]
+			text := fmt.Sprintf("%s  %-6s  %s", entry.Time.Format("2006-01-02 15:04:05"), entry.Kind, entry.Message)
+			if entry.Path != "" {
+				text += "  [" + entry.Path + "]"
+			}
+			label := obj.(*widget.Label)
+			label.Importance = widget.MediumImportance
+			if entry.Kind == ActivityError {
+				label.Importance = widget.DangerImportance
+			}
+			label.SetText(text)
+		},
+	)
+	activity.OnChanged = func() {
+		entries = activity.Entries()
+		list.Refresh()
+	}
+
+	clearButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), activity.Clear)
+	clearButton.Importance = widget.LowImportance
+	title := widgets.DigitalText("ACTIVITY", 12, theme.ColorNamePrimary)
+
+	return container.NewBorder(
+		container.NewBorder(nil, nil, title, clearButton),
+		nil, nil, nil,
+		list,
+	)
+}
+
+// Feedback reports the outcome of operations: a toast and an activity
+// log entry for successes, and an activity log entry and an error dialog
+// for failures.
+type Feedback struct {
+	window fyne.Window
+	toasts *widgets.ToastLayer
+
+	// Activity is the log every outcome is recorded in
+	Activity *ActivityLog
+}
+
+// NewFeedback creates the feedback of a window.
+//
+// Parameters:
+//   - window: The window dialogs are shown over by default
+//   - toasts: The toast layer stacked over the window's content
+//   - activity: The activity log outcomes are recorded in
+func NewFeedback(window fyne.Window, toasts *widgets.ToastLayer, activity *ActivityLog) *Feedback {
+	return &Feedback{window: window, toasts: toasts, Activity: activity}
+}
+
+// Success records a successful operation and shows it as a toast
+func (f *Feedback) Success(kind ActivityKind, message string) {
+	f.Activity.Add(kind, message, "")
+	f.toasts.Notify(message, theme.ColorNameSuccess)
+}
+
+// Record records an operation in the activity log without notifying
+func (f *Feedback) Record(kind ActivityKind, message string) {
+	f.Activity.Add(kind, message, "")
+}
+
+// RecordError records a failed operation in the activity log without
+// interrupting, for failures of background work such as autosave
+func (f *Feedback) RecordError(title string, err error) {
+	f.Activity.Add(ActivityError, fmt.Sprintf("%s: %v", title, err), errorPath(err))
+}
+
+// Error records a failed operation and shows an error dialog naming the
+// file involved, if any, with the option to try again.
+//
+// Parameters:
+//   - parent: The window to show the dialog over; nil for the main window
+//   - title: Describes the failed operation, such as "Saving note"
+//   - err: The error
+//   - retry: Repeats the operation; nil offers no retry
+func (f *Feedback) Error(parent fyne.Window, title string, err error, retry func()) {
+	if parent == nil {
+		parent = f.window
+	}
+	path := errorPath(err)
+	f.Activity.Add(ActivityError, fmt.Sprintf("%s: %v", title, err), path)
+
+	message := widget.NewLabel(err.Error())
+	message.Wrapping = fyne.TextWrapWord
+	content := container.NewVBox(message)
+	if path != "" {
+		pathEntry := widget.NewEntry()
+		pathEntry.SetText(path)
+		pathEntry.TextStyle = fyne.TextStyle{Monospace: true}
+		content.Add(widget.NewForm(widget.NewFormItem("File", pathEntry)))
+	}
+
+	var d dialog.Dialog
+	if retry != nil {
+		d = dialog.NewCustomConfirm(title+" failed", "Retry", "Close", content, func(again bool) {
+			if again {
+				retry()
+			}
+		}, parent)
+	} else {
+		d = dialog.NewCustom(title+" failed", "Close", content, parent)
+	}
+	d.Resize(fyne.NewSize(480, 0))
+	d.Show()
+}
+
+// errorPath returns the file named by an error, or "" if it names none
+func errorPath(err error) string {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Path
+	}
+	var linkErr *os.LinkError
+	if errors.As(err, &linkErr) {
+		return linkErr.New
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+// Package ui provides user interface components and setup for the RevEnGo application.
+// This file contains the optional HTTP API server run inside the GUI.
+package ui
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/leog/RevEnGo/internal/api"
+)
+
+// APIServerController starts and stops the HTTP API from within the GUI,
+// so plugins and notebooks can push findings while the application is open
+type APIServerController struct {
+	config AppConfig
+	window fyne.Window
+
+	// srv is the running server, or nil when the API is stopped
+	srv *http.Server
+
+	// url is the base URL of the running server
+	url string
+
+	// token is the bearer token clients must present
+	token string
+}
+
+// NewAPIServerController creates a controller for the in-GUI API server
+func NewAPIServerController(config AppConfig, window fyne.Window) *APIServerController {
+	return &APIServerController{config: config, window: window}
+}
+
+// Toggle starts the server if it is stopped, or offers to stop it if it is running
+func (a *APIServerController) Toggle() {
+	if a.srv != nil {
+		a.showRunning()
+		return
+	}
+
+	token, err := api.LoadToken(a.config.DataDir)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("loading API token: %w", err), a.window)
+		return
+	}
+
+	server := api.NewServer(a.config.NoteStore, a.config.ProjectStore, a.config.Changes, token)
+	srv, addr, err := server.Start(api.DefaultAddr)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("starting API server: %w", err), a.window)
+		return
+	}
+
+	a.srv = srv
+	a.url = fmt.Sprintf("http://%s/api/", addr)
+	a.token = token
+	a.showRunning()
+}
+
+// Stop shuts the server down if it is running
+func (a *APIServerController) Stop() {
+	if a.srv == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	a.srv.Shutdown(ctx)
+	a.srv = nil
+}
+
+// showRunning shows the server's address and token, with an option to stop it
+func (a *APIServerController) showRunning() {
+	urlEntry := widget.NewEntry()
+	urlEntry.SetText(a.url)
+	urlEntry.Disable()
+
+	tokenEntry := widget.NewPasswordEntry()
+	tokenEntry.SetText(a.token)
+	tokenEntry.Disable()
+
+	copyToken := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), func() {
+		a.window.Clipboard().SetContent(a.token)
+	})
+
+	content := container.NewVBox(
+		widget.NewLabel("The RevEnGo API is listening on localhost."),
+		widget.NewForm(
+			widget.NewFormItem("URL", urlEntry),
+			widget.NewFormItem("Token", container.NewBorder(nil, nil, nil, copyToken, tokenEntry)),
+		),
+		widget.NewLabel(`Send the token as "Authorization: Bearer <token>".`),
+	)
+
+	dialog.ShowCustomConfirm("API Server", "Stop Server", "Close", content, func(stop bool) {
+		if stop {
+			a.Stop()
+		}
+	}, a.window)
+}
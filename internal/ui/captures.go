@@ -0,0 +1,373 @@
+// Package ui provides user interface components and setup for the RevEnGo application.
+// This file contains the browser of the packet captures attached to projects.
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/leog/RevEnGo/internal/dissect"
+	"github.com/leog/RevEnGo/internal/models"
+	"github.com/leog/RevEnGo/internal/pcap"
+)
+
+// Directions offered for pinning stream ranges
+const (
+	clientToServer = "Client -> server"
+	serverToClient = "Server -> client"
+)
+
+// captureBrowser is the window listing the flows of the packet captures
+// attached to a project. Packets and ranges of reassembled streams are
+// pinned from it to the protocol analysis note in the main window.
+type captureBrowser struct {
+	c      *NoteController
+	window fyne.Window
+
+	projects []*models.Project
+	project  *models.Project
+
+	// path is the capture shown, flows its flows and flow the selected one
+	path   string
+	flows  []*pcap.Flow
+	flow   *pcap.Flow
+	stream *pcap.Stream
+
+	// packet is the selected packet of the flow; -1 if none
+	packet int
+
+	projectSelect *widget.Select
+	captureSelect *widget.Select
+	status        *widget.Label
+
+	flowList   *widget.List
+	packetList *widget.List
+	packetDump *widget.Label
+	streamText *widget.Label
+
+	// Range of the stream to pin
+	direction *widget.RadioGroup
+	offset    *widget.Entry
+	length    *widget.Entry
+}
+
+// ShowCaptures opens the packet captures of the current note's project.
+// Only one capture browser is open at a time.
+func (c *NoteController) ShowCaptures() {
+	if c.captures != nil {
+		c.captures.window.RequestFocus()
+		return
+	}
+	if c.projectStore == nil {
+		return
+	}
+	projects, err := c.projectStore.ListProjects()
+	if err != nil {
+		c.feedback.Error(nil, "Listing projects", err, nil)
+		return
+	}
+	if len(projects) == 0 {
+		dialog.ShowInformation("No Projects", "Packet captures are attached to projects. Create a project first, for example with: revengo project add -name NAME", c.window)
+		return
+	}
+
+	b := &captureBrowser{c: c, projects: projects, packet: -1}
+	b.window = fyne.CurrentApp().NewWindow("RevEnGo - Packet Captures")
+	b.window.SetContent(b.build())
+	b.window.Resize(fyne.NewSize(1100, 720))
+	b.window.SetOnClosed(func() { c.captures = nil })
+	c.captures = b
+
+	// Start with the project of the note being edited
+	current := c.notepad.ProjectID()
+	if current == "" {
+		current = c.notepad.DefaultProjectID
+	}
+	index := slices.IndexFunc(projects, func(p *models.Project) bool { return p.ID == current })
+	b.projectSelect.SetSelectedIndex(max(index, 0))
+	b.window.Show()
+}
+
+// build creates the browser's layout
+func (b *captureBrowser) build() fyne.CanvasObject {
+	names := make([]string, len(b.projects))
+	for i, project := range b.projects {
+		names[i] = fmt.Sprintf("%s (%s)", project.Name, project.ID)
+	}
+	b.projectSelect = widget.NewSelect(names, func(string) {
+		b.project = b.projects[b.projectSelect.SelectedIndex()]
+		b.setCaptures("")
+	})
+	b.captureSelect = widget.NewSelect(nil, func(string) {
+		if i := b.captureSelect.SelectedIndex(); i >= 0 {
+			b.load(b.project.Captures[i])
+		}
+	})
+	b.captureSelect.PlaceHolder = "(no captures attached)"
+	attachButton := widget.NewButtonWithIcon("Attach...", theme.ContentAddIcon(), b.attach)
+	detachButton := widget.NewButtonWithIcon("Detach", theme.ContentRemoveIcon(), b.detach)
+	b.status = widget.NewLabel("")
+
+	b.flowList = widget.NewList(
+		func() int { return len(b.flows) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.TextStyle = fyne.TextStyle{Monospace: true}
+			return label
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			f := b.flows[id]
+			obj.(*widget.Label).SetText(fmt.Sprintf("%3d  %s  (%d packets, %d bytes)", f.Number, f, len(f.Packets), f.Bytes))
+		},
+	)
+	b.flowList.OnSelected = func(id widget.ListItemID) { b.selectFlow(b.flows[id]) }
+
+	b.packetList = widget.NewList(
+		func() int {
+			if b.flow == nil {
+				return 0
+			}
+			return len(b.flow.Packets)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.TextStyle = fyne.TextStyle{Monospace: true}
+			return label
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			p := b.flow.Packets[id]
+			arrow := "S->C"
+			if p.FromClient {
+				arrow = "C->S"
+			}
+			line := fmt.Sprintf("%6d  %+9.3fs  %s  %5d bytes", p.Number, p.Time.Sub(b.flow.First).Seconds(), arrow, len(p.Payload))
+			if b.flow.Proto == pcap.ProtoTCP {
+				line += "  " + p.Flags.String()
+			}
+			obj.(*widget.Label).SetText(line)
+		},
+	)
+	b.packetList.OnSelected = func(id widget.ListItemID) {
+		b.packet = id
+		p := b.flow.Packets[id]
+		dump := pcap.Hexdump(p.Payload, 0)
+		if dump == "" {
+			dump = "(no payload)"
+		}
+		b.packetDump.SetText(dump)
+	}
+	b.packetDump = widget.NewLabel("")
+	b.packetDump.TextStyle = fyne.TextStyle{Monospace: true}
+	pinPacketButton := widget.NewButtonWithIcon("Pin Packet", theme.ContentPasteIcon(), b.pinPacket)
+
+	b.streamText = widget.NewLabel("")
+	b.streamText.TextStyle = fyne.TextStyle{Monospace: true}
+	b.direction = widget.NewRadioGroup([]string{clientToServer, serverToClient}, nil)
+	b.direction.Horizontal = true
+	b.direction.Required = true
+	b.direction.SetSelected(clientToServer)
+	b.offset = widget.NewEntry()
+	b.offset.SetPlaceHolder("Offset (0)")
+	b.length = widget.NewEntry()
+	b.length.SetPlaceHolder("Length (rest)")
+	pinRangeButton := widget.NewButtonWithIcon("Pin Range", theme.ContentPasteIcon(), b.pinRange)
+
+	packetsTab := container.NewVSplit(
+		b.packetList,
+		container.NewBorder(nil, container.NewHBox(pinPacketButton), nil, nil, container.NewScroll(b.packetDump)),
+	)
+	streamControls := container.NewHBox(
+		b.direction,
+		container.NewGridWrap(fyne.NewSize(120, b.offset.MinSize().Height), b.offset),
+		container.NewGridWrap(fyne.NewSize(120, b.length.MinSize().Height), b.length),
+		pinRangeButton,
+	)
+	streamTab := container.NewBorder(nil, streamControls, nil, nil, container.NewScroll(b.streamText))
+	views := container.NewAppTabs(
+		container.NewTabItem("PACKETS", packetsTab),
+		container.NewTabItem("STREAM", streamTab),
+	)
+
+	toolbar := container.NewBorder(nil, nil,
+		container.NewHBox(widget.NewLabel("Project:"), b.projectSelect, widget.NewLabel("Capture:")),
+		container.NewHBox(attachButton, detachButton),
+		b.captureSelect,
+	)
+	split := container.NewHSplit(b.flowList, views)
+	split.Offset = 0.45
+	return container.NewBorder(container.NewVBox(toolbar, b.status), nil, nil, nil, split)
+}
+
+// setCaptures lists the captures of the selected project and shows one
+func (b *captureBrowser) setCaptures(show string) {
+	options := make([]string, len(b.project.Captures))
+	for i, path := range b.project.Captures {
+		options[i] = filepath.Base(path) + "  -  " + filepath.Dir(path)
+	}
+	b.captureSelect.Options = options
+	b.captureSelect.Selected = ""
+	b.captureSelect.Refresh()
+	b.showFlows("", nil)
+	if index := slices.Index(b.project.Captures, show); index >= 0 {
+		b.captureSelect.SetSelectedIndex(index)
+	} else if len(options) > 0 {
+		b.captureSelect.SetSelectedIndex(0)
+	} else {
+		b.status.SetText("Attach a .pcap or .pcapng file to list its flows")
+	}
+}
+
+// load reads a capture in the background and lists its flows
+func (b *captureBrowser) load(path string) {
+	b.showFlows("", nil)
+	b.status.SetText("Reading " + filepath.Base(path) + "...")
+	job := b.c.jobs.Start("Reading " + filepath.Base(path))
+	go func() {
+		defer job.Done()
+		capture, err := pcap.Open(path)
+		if err != nil {
+			b.status.SetText("ERROR: " + err.Error())
+			return
+		}
+		flows, skipped := pcap.Flows(capture)
+		status := fmt.Sprintf("%s: %s, %d packets, %d flows", filepath.Base(path), capture.Format, len(capture.Packets), len(flows))
+		if skipped > 0 {
+			status += fmt.Sprintf(", %d non-IP packets skipped", skipped)
+		}
+		if capture.Truncated {
+			status += ", file truncated"
+		}
+		b.status.SetText(status)
+		b.showFlows(path, flows)
+	}()
+}
+
+// showFlows lists the flows of a capture, selecting none
+func (b *captureBrowser) showFlows(path string, flows []*pcap.Flow) {
+	b.path, b.flows = path, flows
+	b.flowList.UnselectAll()
+	b.flowList.Refresh()
+	b.selectFlow(nil)
+}
+
+// selectFlow shows the packets and reassembled stream of a flow
+func (b *captureBrowser) selectFlow(f *pcap.Flow) {
+	b.flow, b.stream, b.packet = f, nil, -1
+	b.packetList.UnselectAll()
+	b.packetList.Refresh()
+	b.packetDump.SetText("")
+	if f == nil {
+		b.streamText.SetText("")
+		return
+	}
+	b.stream = pcap.Reassemble(f)
+	b.streamText.SetText(b.stream.Format())
+}
+
+// attach adds a capture file to the project
+func (b *captureBrowser) attach() {
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		reader.Close()
+		path := reader.URI().Path()
+		if !slices.Contains(b.project.Captures, path) {
+			b.project.Captures = append(b.project.Captures, path)
+			if err := b.c.projectStore.SaveProject(b.project); err != nil {
+				b.c.feedback.Error(b.window, "Attaching capture", err, nil)
+				return
+			}
+			b.c.feedback.Record(ActivityImport, fmt.Sprintf("Attached %s to project %q", filepath.Base(path), b.project.Name))
+		}
+		b.setCaptures(path)
+	}, b.window)
+}
+
+// detach removes the shown capture from the project; the file is kept
+func (b *captureBrowser) detach() {
+	index := b.captureSelect.SelectedIndex()
+	if index < 0 {
+		return
+	}
+	path := b.project.Captures[index]
+	b.project.Captures = slices.Delete(b.project.Captures, index, index+1)
+	if err := b.c.projectStore.SaveProject(b.project); err != nil {
+		b.c.feedback.Error(b.window, "Detaching capture", err, nil)
+		return
+	}
+	b.c.feedback.Record(ActivityDelete, fmt.Sprintf("Detached %s from project %q", filepath.Base(path), b.project.Name))
+	b.setCaptures("")
+}
+
+// pinPacket pins the payload of the selected packet to the note
+func (b *captureBrowser) pinPacket() {
+	if b.flow == nil || b.packet < 0 {
+		dialog.ShowInformation("No Packet", "Select a flow and one of its packets to pin.", b.window)
+		return
+	}
+	p := b.flow.Packets[b.packet]
+	if len(p.Payload) == 0 {
+		dialog.ShowInformation("No Payload", fmt.Sprintf("Packet %d carries no data.", p.Number), b.window)
+		return
+	}
+	b.pin(p.Payload, pcap.PacketSource(filepath.Base(b.path), b.flow, p.Number))
+}
+
+// pinRange pins a range of the data one side of the stream sent
+func (b *captureBrowser) pinRange() {
+	if b.stream == nil {
+		dialog.ShowInformation("No Stream", "Select a flow to pin a range of its stream.", b.window)
+		return
+	}
+	number := func(entry *widget.Entry, empty int) (int, error) {
+		text := strings.TrimSpace(entry.Text)
+		if text == "" {
+			return empty, nil
+		}
+		n, err := strconv.ParseInt(text, 0, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid number %q: use decimal or 0x hex", text)
+		}
+		return int(n), nil
+	}
+	offset, err := number(b.offset, 0)
+	if err != nil {
+		dialog.ShowError(err, b.window)
+		return
+	}
+	length, err := number(b.length, -1)
+	if err != nil {
+		dialog.ShowError(err, b.window)
+		return
+	}
+	fromClient := b.direction.Selected == clientToServer
+	data, err := b.stream.Range(fromClient, offset, length)
+	if err == nil && len(data) == 0 {
+		err = fmt.Errorf("the range is empty")
+	}
+	if err != nil {
+		dialog.ShowError(err, b.window)
+		return
+	}
+	b.pin(data, pcap.RangeSource(filepath.Base(b.path), b.flow, fromClient, offset, len(data)))
+}
+
+// pin adds a sample to the note in the main window
+func (b *captureBrowser) pin(data []byte, source string) {
+	sample := models.SampleMessage{Source: source, Hex: dissect.FormatHex(data)}
+	if err := b.c.notepad.PinSample(sample); err != nil {
+		dialog.ShowError(fmt.Errorf("%w; open a protocol analysis note in the main window first", err), b.window)
+		return
+	}
+	b.c.feedback.Success(ActivityImport, fmt.Sprintf("Pinned %d bytes to %q", len(data), b.c.notepad.TitleEntry.Text))
+}
@@ -0,0 +1,376 @@
+// Package components provides UI components for the RevEnGo application.
+// This file contains the forms for the structured fields of each note type.
+package components
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/leog/RevEnGo/internal/dissect"
+	"github.com/leog/RevEnGo/internal/models"
+	"github.com/leog/RevEnGo/internal/ui/widgets"
+)
+
+// detailForms holds the entries for the structured fields of each note
+// type. Only the form of the selected type is shown, but every form keeps
+// its values, so switching the type back and forth loses nothing.
+type detailForms struct {
+	// Function analysis fields
+	prototype         *widgets.ShortcutEntry
+	callingConvention *widgets.ShortcutEntry
+	arguments         *widgets.ShortcutEntry
+	stackFrameSize    *widgets.ShortcutEntry
+
+	// Vulnerability fields
+	vulnerability *vulnerabilityForm
+
+	// Protocol analysis fields
+	transport    *widgets.ShortcutEntry
+	port         *widgets.ShortcutEntry
+	messageTypes *widgets.ShortcutEntry
+	dissector    *dissectorForm
+
+	// Structure analysis fields
+	structure *structureForm
+
+	// custom are the fields of the note types defined in the
+	// configuration, by note type
+	custom map[string][]*customField
+
+	// fields are the configured-type fields of the loaded note, kept so
+	// that fields of types not being edited survive a save
+	fields map[string]string
+
+	// forms are the forms by note type; types without structured fields
+	// have none
+	forms map[string]fyne.CanvasObject
+
+	// area shows the form of the selected note type
+	area *fyne.Container
+}
+
+// newDetailForms creates the forms of the structured fields.
+//
+// Parameters:
+//   - changed: Called with the new text when the user edits a field
+//
+// Returns:
+//   - The forms, showing none until a note type is selected
+func newDetailForms(changed func(string)) *detailForms {
+	d := &detailForms{area: container.NewStack(), custom: make(map[string][]*customField)}
+
+	entry := func(placeHolder string) *widgets.ShortcutEntry {
+		e := widgets.NewShortcutEntry()
+		e.SetPlaceHolder(placeHolder)
+		e.TextStyle = fyne.TextStyle{Monospace: true}
+		e.OnChanged = changed
+		return e
+	}
+	list := func(placeHolder string) *widgets.ShortcutEntry {
+		e := widgets.NewMultiLineShortcutEntry()
+		e.SetPlaceHolder(placeHolder)
+		e.SetMinRowsVisible(3)
+		e.TextStyle = fyne.TextStyle{Monospace: true}
+		e.OnChanged = changed
+		return e
+	}
+	row := func(label string, field fyne.CanvasObject) fyne.CanvasObject {
+		return container.NewBorder(nil, nil, createTerminalLabel(label), nil, field)
+	}
+
+	d.prototype = entry("int parse_header(const uint8_t *buf, size_t len)")
+	d.callingConvention = entry("cdecl, stdcall, fastcall, thiscall, sysv, ms_x64, aapcs...")
+	d.arguments = list("Arguments, one per line (e.g., rdi: buf)")
+	d.stackFrameSize = entry("Stack frame size in bytes (e.g., 0x48)")
+	d.stackFrameSize.Validator = func(text string) error {
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		if _, err := strconv.ParseUint(strings.TrimSpace(text), 0, 64); err != nil {
+			return fmt.Errorf("expected a size in bytes such as 72 or 0x48")
+		}
+		return nil
+	}
+
+	d.vulnerability = newVulnerabilityForm(changed)
+
+	d.transport = entry("tcp, udp, usb, serial, ble...")
+	d.port = entry("Port (e.g., 8443)")
+	d.port.Validator = func(text string) error {
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		if _, err := strconv.ParseUint(strings.TrimSpace(text), 10, 16); err != nil {
+			return fmt.Errorf("expected a port from 0 to 65535")
+		}
+		return nil
+	}
+	d.messageTypes = list("Message types, one per line")
+	d.dissector = newDissectorForm(changed, func() dissect.Options {
+		return d.protocolDetails().ExportOptions()
+	})
+
+	d.structure = newStructureForm(changed)
+
+	d.forms = map[string]fyne.CanvasObject{
+		models.RETypeFunctionAnalysis: container.NewVBox(
+			row("PROTOTYPE:", d.prototype),
+			row("CALL_CONV:", d.callingConvention),
+			row("FRAME_SIZE:", d.stackFrameSize),
+			createTerminalLabel("ARGS:"),
+			d.arguments,
+		),
+		models.RETypeVulnerability: d.vulnerability.content,
+		models.RETypeProtocolAnalysis: container.NewBorder(
+			container.NewVBox(
+				container.NewGridWithColumns(2,
+					row("TRANSPORT:", d.transport),
+					row("PORT:", d.port),
+				),
+				createTerminalLabel("MESSAGES:"),
+				d.messageTypes,
+			),
+			nil, nil, nil,
+			d.dissector.content,
+		),
+		models.RETypeStructureAnalysis: d.structure.content,
+	}
+
+	// Types defined in the configuration get a form built from their fields
+	for _, t := range models.NoteTypes() {
+		if _, ok := d.forms[t.Name]; ok || len(t.Fields) == 0 {
+			continue
+		}
+		form := container.NewVBox()
+		for _, spec := range t.Fields {
+			field := newCustomField(spec, changed)
+			d.custom[t.Name] = append(d.custom[t.Name], field)
+			label := strings.ToUpper(strings.ReplaceAll(spec.DisplayLabel(), " ", "_")) + ":"
+			if spec.Kind == models.FieldList {
+				form.Add(createTerminalLabel(label))
+				form.Add(field.widget())
+			} else {
+				form.Add(row(label, field.widget()))
+			}
+		}
+		d.forms[t.Name] = form
+	}
+	return d
+}
+
+// show displays the form of a note type, or nothing if the type has no
+// structured fields
+func (d *detailForms) show(noteType string) {
+	d.area.Objects = nil
+	if form, ok := d.forms[noteType]; ok {
+		d.area.Objects = []fyne.CanvasObject{form}
+	}
+	d.area.Refresh()
+}
+
+// load fills the forms from a note's structured fields; nil fields clear
+// their form
+func (d *detailForms) load(data NotePadData) {
+	function := data.Function
+	if function == nil {
+		function = &models.FunctionDetails{}
+	}
+	d.prototype.SetText(function.Prototype)
+	d.callingConvention.SetText(function.CallingConvention)
+	d.arguments.SetText(strings.Join(function.Arguments, "\n"))
+	d.stackFrameSize.SetText("")
+	if function.StackFrameSize != 0 {
+		d.stackFrameSize.SetText(fmt.Sprintf("0x%x", function.StackFrameSize))
+	}
+
+	d.vulnerability.load(data.Vulnerability)
+
+	protocol := data.Protocol
+	if protocol == nil {
+		protocol = &models.ProtocolDetails{}
+	}
+	d.transport.SetText(protocol.Transport)
+	d.port.SetText("")
+	if protocol.Port != 0 {
+		d.port.SetText(strconv.Itoa(int(protocol.Port)))
+	}
+	d.messageTypes.SetText(strings.Join(protocol.MessageTypes, "\n"))
+	d.dissector.load(protocol)
+
+	d.structure.load(data.Structure)
+
+	d.fields = data.Fields
+	for _, fields := range d.custom {
+		for _, field := range fields {
+			field.setValue(data.Fields[field.spec.Name])
+		}
+	}
+}
+
+// collect copies the filled-in structured fields into note data. Forms
+// left empty give nil fields; numbers that do not parse are left out.
+func (d *detailForms) collect(data *NotePadData) {
+	function := &models.FunctionDetails{
+		Prototype:         strings.TrimSpace(d.prototype.Text),
+		CallingConvention: strings.TrimSpace(d.callingConvention.Text),
+		Arguments:         splitLines(d.arguments.Text),
+	}
+	function.StackFrameSize, _ = strconv.ParseUint(strings.TrimSpace(d.stackFrameSize.Text), 0, 64)
+	if !function.IsZero() {
+		data.Function = function
+	}
+
+	if vulnerability := d.vulnerability.details(); !vulnerability.IsZero() {
+		data.Vulnerability = vulnerability
+	}
+
+	if protocol := d.protocolDetails(); !protocol.IsZero() {
+		data.Protocol = protocol
+	}
+
+	if structure := d.structure.details(); strings.TrimSpace(structure.Definition) != "" {
+		data.Structure = structure
+	}
+
+	fields := make(map[string]string, len(d.fields))
+	for name, value := range d.fields {
+		fields[name] = value
+	}
+	for _, field := range d.custom[data.ReverseEngType] {
+		if value := field.value(); value != "" {
+			fields[field.spec.Name] = value
+		} else {
+			delete(fields, field.spec.Name)
+		}
+	}
+	if len(fields) > 0 {
+		data.Fields = fields
+	}
+}
+
+// protocolDetails returns the protocol analysis fields as a note stores them
+func (d *detailForms) protocolDetails() *models.ProtocolDetails {
+	protocol := &models.ProtocolDetails{
+		Transport:    strings.TrimSpace(d.transport.Text),
+		MessageTypes: splitLines(d.messageTypes.Text),
+		Dissector:    d.dissector.definition.Text,
+		Sample:       strings.TrimSpace(d.dissector.sample.Text),
+		Samples:      d.dissector.samples,
+	}
+	if port, err := strconv.ParseUint(strings.TrimSpace(d.port.Text), 10, 16); err == nil {
+		protocol.Port = uint16(port)
+	}
+	return protocol
+}
+
+// customField is the input of a field of a note type defined in the
+// configuration
+type customField struct {
+	spec models.NoteField
+
+	// entry is the input of text, list and number fields
+	entry *widgets.ShortcutEntry
+
+	// choice is the input of choice fields
+	choice *widget.Select
+}
+
+// newCustomField creates the input of a field.
+//
+// Parameters:
+//   - spec: The field's definition
+//   - changed: Called with the new text when the user edits the field
+func newCustomField(spec models.NoteField, changed func(string)) *customField {
+	f := &customField{spec: spec}
+	if spec.Kind == models.FieldChoice {
+		f.choice = widget.NewSelect(spec.Choices, changed)
+		f.choice.PlaceHolder = firstNonEmpty(spec.Placeholder, "("+spec.DisplayLabel()+")")
+		return f
+	}
+
+	if spec.Kind == models.FieldList {
+		f.entry = widgets.NewMultiLineShortcutEntry()
+		f.entry.SetMinRowsVisible(3)
+	} else {
+		f.entry = widgets.NewShortcutEntry()
+	}
+	f.entry.SetPlaceHolder(firstNonEmpty(spec.Placeholder, spec.DisplayLabel()))
+	f.entry.TextStyle = fyne.TextStyle{Monospace: true}
+	f.entry.OnChanged = changed
+	if spec.Kind == models.FieldNumber {
+		f.entry.Validator = func(text string) error {
+			if strings.TrimSpace(text) == "" {
+				return nil
+			}
+			if _, err := strconv.ParseInt(strings.TrimSpace(text), 0, 64); err != nil {
+				return fmt.Errorf("expected a number such as 42 or 0x2a")
+			}
+			return nil
+		}
+	}
+	return f
+}
+
+// widget returns the field's input
+func (f *customField) widget() fyne.CanvasObject {
+	if f.choice != nil {
+		return f.choice
+	}
+	return f.entry
+}
+
+// value returns the field's value as stored in Note.Fields. Lists are
+// stored one item per line.
+func (f *customField) value() string {
+	if f.choice != nil {
+		return f.choice.Selected
+	}
+	if f.spec.Kind == models.FieldList {
+		return strings.Join(splitLines(f.entry.Text), "\n")
+	}
+	return strings.TrimSpace(f.entry.Text)
+}
+
+// setValue shows a stored value in the field
+func (f *customField) setValue(value string) {
+	if f.choice == nil {
+		f.entry.SetText(value)
+		return
+	}
+	if value == "" {
+		f.choice.ClearSelected()
+		return
+	}
+	if !slices.Contains(f.choice.Options, value) {
+		// Keep values that are no longer among the choices
+		f.choice.Options = append(f.choice.Options, value)
+	}
+	f.choice.SetSelected(value)
+}
+
+// firstNonEmpty returns the first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// splitLines returns the non-blank lines of a text, trimmed
+func splitLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			lines = append(lines, trimmed)
+		}
+	}
+	return lines
+}
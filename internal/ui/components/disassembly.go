@@ -0,0 +1,146 @@
+// Package components provides UI components for the RevEnGo application.
+// This file contains the disassembly of a note's address range.
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/leog/RevEnGo/internal/disasm"
+	apptheme "github.com/leog/RevEnGo/internal/ui/theme"
+	"github.com/leog/RevEnGo/internal/ui/widgets"
+)
+
+// disassemblyPane shows the instructions of a note's address range in the
+// binary attached to its project, and copies them into the note as a
+// fenced code block
+type disassemblyPane struct {
+	// source returns the note's binary name, address range and project
+	source func() (binaryName, addressRange, projectID string)
+
+	// disassemble decodes the note's range
+	disassemble func(binaryName, addressRange, projectID string) (*disasm.Listing, error)
+
+	// insert adds a code block to the note's content
+	insert func(block string)
+
+	// listing is the range shown and binaryName the binary it is from
+	listing    *disasm.Listing
+	binaryName string
+
+	status    *widgets.ThemedText
+	text      *widget.Label
+	showBytes *widget.Check
+
+	content fyne.CanvasObject
+}
+
+// newDisassemblyPane creates the disassembly pane.
+//
+// Parameters:
+//   - source: Returns the binary name, address range and project of the note
+//   - disassemble: Decodes an address range of a binary attached to a project
+//   - insert: Adds a code block to the note's content
+func newDisassemblyPane(
+	source func() (string, string, string),
+	disassemble func(string, string, string) (*disasm.Listing, error),
+	insert func(string),
+) *disassemblyPane {
+	p := &disassemblyPane{source: source, disassemble: disassemble, insert: insert}
+
+	p.status = widgets.NewThemedText("", apptheme.ColorNameTerminalText)
+	p.status.TextStyle = fyne.TextStyle{Monospace: true}
+	p.status.TextSize = 12
+
+	p.text = widget.NewLabel("")
+	p.text.TextStyle = fyne.TextStyle{Monospace: true}
+
+	p.showBytes = widget.NewCheck("BYTES", func(bool) { p.show() })
+	p.showBytes.SetChecked(true)
+
+	refreshButton := widget.NewButtonWithIcon("Disassemble", theme.ViewRefreshIcon(), p.refresh)
+	copyButton := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), p.copy)
+	insertButton := widget.NewButtonWithIcon("Insert into Note", theme.ContentPasteIcon(), func() {
+		if p.listing != nil {
+			p.insert(p.listing.Fenced(p.binaryName))
+		}
+	})
+	for _, b := range []*widget.Button{copyButton, insertButton} {
+		b.Importance = widget.LowImportance
+	}
+
+	scroll := container.NewScroll(p.text)
+	scroll.SetMinSize(fyne.NewSize(0, 240))
+	p.content = container.NewBorder(
+		container.NewVBox(
+			container.NewHBox(createTerminalLabel("DISASM:"), refreshButton, p.showBytes, copyButton, insertButton),
+			p.status,
+		),
+		nil, nil, nil,
+		scroll,
+	)
+	p.clear()
+	return p
+}
+
+// clear forgets the shown listing
+func (p *disassemblyPane) clear() {
+	p.listing, p.binaryName = nil, ""
+	p.text.SetText("")
+	p.setStatus("Attach the binary to the project and set the note's binary name and address range to see its instructions", apptheme.ColorNameTerminalText)
+}
+
+// refresh disassembles the note's current address range
+func (p *disassemblyPane) refresh() {
+	binaryName, addressRange, projectID := p.source()
+	if strings.TrimSpace(binaryName) == "" || strings.TrimSpace(addressRange) == "" {
+		p.clear()
+		return
+	}
+	listing, err := p.disassemble(binaryName, addressRange, projectID)
+	if err != nil {
+		p.listing = nil
+		p.text.SetText("")
+		p.setStatus("ERROR: "+err.Error(), theme.ColorNameError)
+		return
+	}
+	p.listing, p.binaryName = listing, binaryName
+	status := fmt.Sprintf("%s %s  0x%x-0x%x  %s  %d instructions", binaryName, listing.Arch,
+		listing.Start, listing.End-1, listing.Section, len(listing.Instructions))
+	color := apptheme.ColorNameTerminalText
+	if listing.Truncated {
+		status += " (range cut short)"
+		color = theme.ColorNameWarning
+	}
+	p.setStatus(status, color)
+	p.show()
+}
+
+// show writes the listing, with or without instruction bytes
+func (p *disassemblyPane) show() {
+	if p.listing == nil {
+		return
+	}
+	p.text.SetText(strings.TrimRight(p.listing.Format(p.showBytes.Checked), "\n"))
+}
+
+// copy puts the listing on the clipboard as a fenced code block
+func (p *disassemblyPane) copy() {
+	win := windowFor(p.content)
+	if win == nil || p.listing == nil {
+		return
+	}
+	win.Clipboard().SetContent(p.listing.Fenced(p.binaryName))
+}
+
+// setStatus shows a status message in a color
+func (p *disassemblyPane) setStatus(text string, color fyne.ThemeColorName) {
+	p.status.Text = text
+	p.status.ColorName = color
+	p.status.Refresh()
+}
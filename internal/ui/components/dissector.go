@@ -0,0 +1,342 @@
+// Package components provides UI components for the RevEnGo application.
+// This file contains the dissector editor of protocol analysis notes.
+package components
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/leog/RevEnGo/internal/dissect"
+	"github.com/leog/RevEnGo/internal/models"
+	apptheme "github.com/leog/RevEnGo/internal/ui/theme"
+	"github.com/leog/RevEnGo/internal/ui/widgets"
+)
+
+// dissectorPlaceholder shows the syntax of dissector definitions
+const dissectorPlaceholder = `protocol acme "ACME telemetry"
+endian big
+
+enum msg_type u8 { HELLO = 1, DATA = 2 }
+
+message packet {
+    magic   u32 = 0xa5a5a5a5
+    type    u8 enum msg_type
+    length  u16
+    payload bytes[length]
+    crc     u16 checksum crc16
+}`
+
+// maxSampleFile is the number of bytes loaded from a sample file
+const maxSampleFile = 64 << 10
+
+// dissectorForm edits the dissector of a protocol analysis note and shows
+// the field tree of the sample message as the user types
+type dissectorForm struct {
+	definition *widgets.ShortcutEntry
+	sample     *widgets.ShortcutEntry
+
+	// status reports the decoded sample or the first error
+	status *widgets.ThemedText
+
+	// tree shows the decoded fields; nodes are keyed by their path of
+	// child indexes, such as "0.2.1"
+	tree  *widget.Tree
+	nodes map[string]*dissect.Node
+
+	// samples are the messages pinned from packet captures; choosing one
+	// in pinned decodes it
+	samples []models.SampleMessage
+	pinned  *widget.Select
+	unpin   *widget.Button
+
+	// options returns the transport and port exports register on
+	options func() dissect.Options
+
+	// changed reports edits to the notepad
+	changed func(string)
+
+	// OnShowCaptures opens the packet capture browser
+	OnShowCaptures func()
+
+	content fyne.CanvasObject
+}
+
+// newDissectorForm creates the dissector editor.
+//
+// Parameters:
+//   - changed: Called with the new text when the user edits the definition or sample
+//   - options: Returns the transport and port of the protocol
+func newDissectorForm(changed func(string), options func() dissect.Options) *dissectorForm {
+	s := &dissectorForm{options: options, changed: changed, nodes: make(map[string]*dissect.Node)}
+	edited := func(text string) {
+		s.update()
+		changed(text)
+	}
+
+	s.definition = widgets.NewMultiLineShortcutEntry()
+	s.definition.SetPlaceHolder(dissectorPlaceholder)
+	s.definition.SetMinRowsVisible(10)
+	s.definition.TextStyle = fyne.TextStyle{Monospace: true}
+	s.definition.Wrapping = fyne.TextWrapOff
+	s.definition.OnChanged = edited
+
+	s.sample = widgets.NewMultiLineShortcutEntry()
+	s.sample.SetPlaceHolder("Sample message in hex, e.g. a5 a5 a5 a5 02 00 03 de ad be ...")
+	s.sample.SetMinRowsVisible(3)
+	s.sample.TextStyle = fyne.TextStyle{Monospace: true}
+	s.sample.Wrapping = fyne.TextWrapWord
+	s.sample.OnChanged = edited
+
+	s.status = widgets.NewThemedText("", apptheme.ColorNameTerminalText)
+	s.status.TextStyle = fyne.TextStyle{Monospace: true}
+	s.status.TextSize = 12
+
+	s.tree = widget.NewTree(
+		func(id widget.TreeNodeID) []widget.TreeNodeID {
+			if id == "" {
+				if s.nodes["0"] == nil {
+					return nil
+				}
+				return []widget.TreeNodeID{"0"}
+			}
+			var ids []widget.TreeNodeID
+			if node := s.nodes[id]; node != nil {
+				for i := range node.Children {
+					ids = append(ids, id+"."+strconv.Itoa(i))
+				}
+			}
+			return ids
+		},
+		func(id widget.TreeNodeID) bool {
+			node := s.nodes[id]
+			return id == "" || node != nil && len(node.Children) > 0
+		},
+		func(bool) fyne.CanvasObject {
+			text := widgets.NewThemedText("", apptheme.ColorNameTerminalText)
+			text.TextStyle = fyne.TextStyle{Monospace: true}
+			return text
+		},
+		func(id widget.TreeNodeID, _ bool, obj fyne.CanvasObject) {
+			text := obj.(*widgets.ThemedText)
+			node := s.nodes[id]
+			if node == nil {
+				return
+			}
+			line := fmt.Sprintf("%04x  %s: %s", node.Offset, node.Name, node.Type)
+			if node.Value != "" {
+				line += " = " + node.Value
+			}
+			text.ColorName = apptheme.ColorNameTerminalText
+			if node.Error != "" {
+				line += "  [!] " + node.Error
+				text.ColorName = theme.ColorNameError
+			}
+			text.SetText(line)
+		},
+	)
+
+	s.pinned = widget.NewSelect(nil, func(string) {
+		if i := s.pinned.SelectedIndex(); i >= 0 && i < len(s.samples) {
+			s.sample.SetText(s.samples[i].Hex)
+		}
+	})
+	s.pinned.PlaceHolder = "(no samples pinned from captures)"
+	s.unpin = widget.NewButtonWithIcon("", theme.DeleteIcon(), s.unpinSample)
+	capturesButton := widget.NewButtonWithIcon("Captures", theme.SearchIcon(), func() {
+		if s.OnShowCaptures != nil {
+			s.OnShowCaptures()
+		}
+	})
+	capturesButton.Importance = widget.LowImportance
+
+	loadButton := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), s.loadSample)
+	exportButton := func(label, format string) *widget.Button {
+		button := widget.NewButtonWithIcon(label, theme.DownloadIcon(), func() {
+			s.export(format)
+		})
+		button.Importance = widget.LowImportance
+		return button
+	}
+	toolbar := container.NewHBox(
+		createTerminalLabel("DISSECTOR:"),
+		widget.NewSeparator(),
+		createTerminalLabel("EXPORT:"),
+		exportButton("Wireshark Lua", dissect.FormatLua),
+		exportButton("Kaitai", dissect.FormatKaitai),
+	)
+	sampleRow := container.NewVBox(
+		container.NewBorder(nil, nil, createTerminalLabel("SAMPLE:"), container.NewVBox(loadButton), s.sample),
+		container.NewBorder(nil, nil, createTerminalLabel("PINNED:"), container.NewHBox(s.unpin, capturesButton), s.pinned),
+	)
+
+	s.content = container.NewBorder(
+		container.NewVBox(toolbar, s.status), nil, nil, nil,
+		container.NewGridWithColumns(2,
+			s.definition,
+			container.NewBorder(sampleRow, nil, nil, nil, s.tree),
+		),
+	)
+	s.update()
+	return s
+}
+
+// load shows a note's dissector and sample
+func (s *dissectorForm) load(details *models.ProtocolDetails) {
+	if details == nil {
+		details = &models.ProtocolDetails{}
+	}
+	s.definition.SetText(details.Dissector)
+	s.sample.SetText(details.Sample)
+	s.samples = append([]models.SampleMessage(nil), details.Samples...)
+	s.setPinned()
+	s.update()
+}
+
+// setPinned lists the pinned samples, selecting none
+func (s *dissectorForm) setPinned() {
+	options := make([]string, len(s.samples))
+	for i, sample := range s.samples {
+		options[i] = fmt.Sprintf("%d. %s", i+1, sample.Source)
+	}
+	s.pinned.Options = options
+	s.pinned.Selected = ""
+	s.pinned.Refresh()
+	if len(s.samples) == 0 {
+		s.unpin.Disable()
+	} else {
+		s.unpin.Enable()
+	}
+}
+
+// pin adds a sample pinned from a capture and decodes it
+func (s *dissectorForm) pin(sample models.SampleMessage) {
+	s.samples = append(s.samples, sample)
+	s.setPinned()
+	s.pinned.SetSelectedIndex(len(s.samples) - 1)
+	s.changed(sample.Hex)
+}
+
+// unpinSample removes the chosen pinned sample
+func (s *dissectorForm) unpinSample() {
+	i := s.pinned.SelectedIndex()
+	if i < 0 || i >= len(s.samples) {
+		return
+	}
+	s.samples = append(s.samples[:i:i], s.samples[i+1:]...)
+	s.setPinned()
+	s.changed("")
+}
+
+// update decodes the sample with the definition and shows the field tree
+func (s *dissectorForm) update() {
+	if s.tree == nil {
+		return
+	}
+	s.nodes = make(map[string]*dissect.Node)
+	defer func() {
+		s.tree.Refresh()
+		s.tree.OpenAllBranches()
+	}()
+
+	if strings.TrimSpace(s.definition.Text) == "" {
+		s.setStatus("Describe the protocol's messages to decode samples and export dissectors", apptheme.ColorNameTerminalText)
+		return
+	}
+	details := &models.ProtocolDetails{Dissector: s.definition.Text, Sample: s.sample.Text}
+	spec, node, err := details.Decode(nil)
+	if spec == nil {
+		s.setStatus("ERROR: "+err.Error(), theme.ColorNameError)
+		return
+	}
+	if strings.TrimSpace(s.sample.Text) == "" {
+		s.setStatus(fmt.Sprintf("Definition OK: %d messages; paste or load a sample to decode it as %s",
+			len(spec.Messages), spec.Root().Name), apptheme.ColorNameTerminalText)
+		return
+	}
+	if err != nil {
+		s.setStatus("ERROR: "+err.Error(), theme.ColorNameError)
+		return
+	}
+
+	var index func(n *dissect.Node, id string)
+	index = func(n *dissect.Node, id string) {
+		s.nodes[id] = n
+		for i, child := range n.Children {
+			index(child, id+"."+strconv.Itoa(i))
+		}
+	}
+	index(node, "0")
+	if problems := node.Problems(); len(problems) > 0 {
+		noun := "problems"
+		if len(problems) == 1 {
+			noun = "problem"
+		}
+		s.setStatus(fmt.Sprintf("%d %s: %s", len(problems), noun, problems[0]), theme.ColorNameError)
+		return
+	}
+	s.setStatus(fmt.Sprintf("Decoded %d bytes as %s", node.Length, spec.Root().Name), apptheme.ColorNameTerminalText)
+}
+
+// loadSample reads a sample message from a file, such as a payload saved
+// from Wireshark with Export Packet Bytes
+func (s *dissectorForm) loadSample() {
+	win := windowFor(s.content)
+	if win == nil {
+		return
+	}
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		defer reader.Close()
+		data, err := io.ReadAll(io.LimitReader(reader, maxSampleFile))
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("reading %s: %w", reader.URI().Name(), err), win)
+			return
+		}
+		s.sample.SetText(dissect.FormatHex(data))
+	}, win)
+}
+
+// setStatus shows a status message in a color
+func (s *dissectorForm) setStatus(text string, color fyne.ThemeColorName) {
+	s.status.Text = text
+	s.status.ColorName = color
+	s.status.Refresh()
+}
+
+// export shows the dissector generated in a format, with buttons to copy
+// it or save it to a file
+func (s *dissectorForm) export(format string) {
+	win := windowFor(s.content)
+	if win == nil {
+		return
+	}
+	spec, err := dissect.Parse(s.definition.Text)
+	if err == nil {
+		var source string
+		if source, err = dissect.Export(spec, format, s.options()); err == nil {
+			name := spec.Name
+			if name == "" {
+				name = "dissector"
+			}
+			showExportDialog(win, "Export "+dissectorExportTitles[format], name+dissect.FormatExtension(format), source)
+			return
+		}
+	}
+	dialog.ShowError(fmt.Errorf("cannot export the dissector: %w", err), win)
+}
+
+// dissectorExportTitles name the dissector export formats in dialog titles
+var dissectorExportTitles = map[string]string{
+	dissect.FormatLua:    "Wireshark Lua Dissector",
+	dissect.FormatKaitai: "Kaitai Struct",
+}
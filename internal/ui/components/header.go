@@ -3,55 +3,101 @@
 package components
 
 import (
-	"image/color"
+	"fmt"
+	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
+	apptheme "github.com/leog/RevEnGo/internal/ui/theme"
 	"github.com/leog/RevEnGo/internal/ui/widgets"
 )
 
-// Color constants for the header
-var (
-	headerStartColor = color.NRGBA{R: 15, G: 23, B: 42, A: 255}   // Darker blue
-	headerEndColor   = color.NRGBA{R: 30, G: 41, B: 59, A: 255}   // Slightly lighter blue
-	accentColor      = color.NRGBA{R: 0, G: 174, B: 239, A: 255}  // Cyber blue for accents
-	glowColor        = color.NRGBA{R: 61, G: 134, B: 247, A: 100} // Glowing effect color
-)
+// maxStatusErrorLength limits how much of a store error the status area shows
+const maxStatusErrorLength = 60
+
+// Header is the banner at the top of the main window, holding the main
+// actions and a status area describing the state of the workspace
+type Header struct {
+	widget.BaseWidget
+
+	// content is the assembled header layout
+	content fyne.CanvasObject
+
+	// statusLight, statusLabel and progress make up the status area
+	statusLight *widgets.StatusLight
+	statusLabel *widgets.ThemedText
+	progress    *widget.ProgressBar
+}
+
+// HeaderStatus is the state shown in the header's status area
+type HeaderStatus struct {
+	// StoreError is the error of the last failed store operation; nil
+	// while the note store is healthy
+	StoreError error
+
+	// Unsaved is the number of open notes with unsaved edits
+	Unsaved int
+
+	// LastAutosave is when autosave last saved a note; zero if it has not
+	LastAutosave time.Time
+
+	// IndexedNotes and IndexedSymbols describe the symbol index that links
+	// addresses and names to notes
+	IndexedNotes, IndexedSymbols int
+
+	// Job names the running background job; empty when idle
+	Job string
+
+	// JobProgress is the job's progress from 0 to 1, or negative when unknown
+	JobProgress float64
+
+	// Jobs is the number of running background jobs
+	Jobs int
+}
 
 // NewHeader creates a new header component for the application.
 // The header includes:
 // - Application title/logo
 // - Main navigation buttons
-// - User actions (settings, help, etc.)
+// - A status area showing the state of the workspace
 //
-// Returns a canvas object that can be placed in a container.
-func NewHeader() fyne.CanvasObject {
+// Parameters:
+//   - onNew: Called when the New button is tapped
+//   - onOpen: Called when the Open button is tapped
+//   - onSave: Called when the Save button is tapped
+//
+// Returns the header, whose status is updated with SetStatus.
+func NewHeader(onNew, onOpen, onSave func()) *Header {
+	h := &Header{}
+
 	// Create a gradient background for the header
-	gradient := widgets.GradientBackground(headerStartColor, headerEndColor)
+	gradient := widgets.GradientBackground(apptheme.ColorNameHeaderStart, apptheme.ColorNameHeaderEnd)
 
 	// Create the application title with digital styling
-	appTitle := widgets.DigitalText("RevEnGo", 22, accentColor)
+	appTitle := widgets.DigitalText("RevEnGo", 22, apptheme.ColorNameAccent)
 
 	// Create action buttons with cyber styling
-	newButton := widgets.CyberButton("New", theme.DocumentCreateIcon(), nil)
-	openButton := widgets.CyberButton("Open", theme.FolderOpenIcon(), nil)
-	saveButton := widgets.CyberButton("Save", theme.DocumentSaveIcon(), nil)
+	newButton := widgets.CyberButton("New", theme.DocumentCreateIcon(), onNew)
+	openButton := widgets.CyberButton("Open", theme.FolderOpenIcon(), onOpen)
+	saveButton := widgets.CyberButton("Save", theme.DocumentSaveIcon(), onSave)
 
 	// Add subtle glow effects to the buttons
-	newButtonWithGlow := widgets.GlowEffect(newButton, glowColor)
-	openButtonWithGlow := widgets.GlowEffect(openButton, glowColor)
-	saveButtonWithGlow := widgets.GlowEffect(saveButton, glowColor)
+	newButtonWithGlow := widgets.GlowEffect(newButton, apptheme.ColorNameGlow)
+	openButtonWithGlow := widgets.GlowEffect(openButton, apptheme.ColorNameGlow)
+	saveButtonWithGlow := widgets.GlowEffect(saveButton, apptheme.ColorNameGlow)
 
-	// Create status indicator for system health
-	statusIndicator := widgets.StatusIndicator("secure")
-	statusLabel := canvas.NewText("System Status: Secure", color.NRGBA{R: 60, G: 220, B: 100, A: 255})
-	statusLabel.TextSize = 12
-	statusContainer := container.NewHBox(statusIndicator, statusLabel)
+	// Create the status area: a light, a summary and background job progress
+	h.statusLight = widgets.StatusIndicator("info")
+	h.statusLabel = widgets.NewThemedText("", widgets.StatusColorName("info"))
+	h.statusLabel.TextSize = 12
+	h.progress = widget.NewProgressBar()
+	h.progress.Hide()
+	statusContainer := container.NewHBox(h.statusLight, h.statusLabel, h.progress)
 
 	// Create a toolbar with the action buttons
 	toolbar := container.NewHBox(
@@ -64,7 +110,7 @@ func NewHeader() fyne.CanvasObject {
 
 	// Add cybersecurity-themed decorative elements
 	// Binary pattern as decoration
-	binaryPattern := canvas.NewText("01001010 10101", color.NRGBA{R: 100, G: 150, B: 200, A: 100})
+	binaryPattern := widgets.NewThemedText("01001010 10101", apptheme.ColorNameDecoration)
 	binaryPattern.TextSize = 10
 	binaryPattern.TextStyle = fyne.TextStyle{Monospace: true}
 
@@ -88,8 +134,68 @@ func NewHeader() fyne.CanvasObject {
 	)
 
 	// Combine the gradient and content
-	return container.NewStack(
+	h.content = container.NewStack(
 		gradient,
 		container.NewPadded(content),
 	)
+
+	h.SetStatus(HeaderStatus{JobProgress: -1})
+	h.ExtendBaseWidget(h)
+	return h
+}
+
+// CreateRenderer implements fyne.Widget by rendering the assembled layout.
+func (h *Header) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(h.content)
+}
+
+// SetStatus updates the status area. The light is red while the store is
+// failing, amber while there are unsaved edits and green otherwise.
+func (h *Header) SetStatus(status HeaderStatus) {
+	light := "secure"
+	var parts []string
+
+	if status.StoreError != nil {
+		light = "vulnerable"
+		message := status.StoreError.Error()
+		if len(message) > maxStatusErrorLength {
+			message = message[:maxStatusErrorLength] + "..."
+		}
+		parts = append(parts, "Store error: "+message)
+	} else {
+		parts = append(parts, "Store OK")
+	}
+
+	if status.Unsaved > 0 {
+		if light == "secure" {
+			light = "warning"
+		}
+		parts = append(parts, fmt.Sprintf("%d unsaved", status.Unsaved))
+	} else {
+		parts = append(parts, "All saved")
+	}
+
+	if !status.LastAutosave.IsZero() {
+		parts = append(parts, "Autosaved "+status.LastAutosave.Format("15:04:05"))
+	}
+
+	parts = append(parts, fmt.Sprintf("Index: %d notes, %d symbols", status.IndexedNotes, status.IndexedSymbols))
+
+	if status.Job != "" {
+		job := status.Job
+		if status.Jobs > 1 {
+			job += fmt.Sprintf(" (+%d more)", status.Jobs-1)
+		}
+		parts = append(parts, job)
+	}
+	if status.Job != "" && status.JobProgress >= 0 {
+		h.progress.SetValue(status.JobProgress)
+		h.progress.Show()
+	} else {
+		h.progress.Hide()
+	}
+
+	h.statusLight.SetStatus(light)
+	h.statusLabel.ColorName = widgets.StatusColorName(light)
+	h.statusLabel.SetText(strings.Join(parts, " | "))
 }
@@ -3,26 +3,42 @@
 package components
 
 import (
+	"fmt"
 	"image/color"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
+	"github.com/leog/RevEnGo/internal/disasm"
 	"github.com/leog/RevEnGo/internal/models"
+	"github.com/leog/RevEnGo/internal/ui/highlight"
+	"github.com/leog/RevEnGo/internal/ui/markdown"
+	apptheme "github.com/leog/RevEnGo/internal/ui/theme"
 	"github.com/leog/RevEnGo/internal/ui/widgets"
 )
 
-// Color constants for the notepad
-var (
-	terminalBgColor    = color.NRGBA{R: 8, G: 14, B: 21, A: 255}     // Dark terminal background
-	terminalTextColor  = color.NRGBA{R: 180, G: 255, B: 180, A: 255} // Terminal green text
-	codeBlockBgColor   = color.NRGBA{R: 15, G: 25, B: 35, A: 255}    // Slightly lighter for code blocks
-	tabActiveBgColor   = color.NRGBA{R: 30, G: 50, B: 70, A: 255}    // Active tab background
-	tabInactiveBgColor = color.NRGBA{R: 15, G: 30, B: 50, A: 255}    // Inactive tab background
-	accentBlue         = color.NRGBA{R: 0, G: 174, B: 239, A: 255}   // Cyber blue accent
+// ViewMode selects how the note content is presented
+type ViewMode string
+
+// Content view modes
+const (
+	// ViewEdit shows only the Markdown source editor
+	ViewEdit ViewMode = "EDIT"
+
+	// ViewPreview shows only the rendered Markdown
+	ViewPreview ViewMode = "PREVIEW"
+
+	// ViewSplit shows the editor and the rendered Markdown side by side
+	ViewSplit ViewMode = "SPLIT"
 )
 
 // NotePadData represents the data for a note in the application.
@@ -44,97 +60,226 @@ type NotePadData struct {
 	AddressRange   string
 	RelatedNotes   []string
 	ReverseEngType string
+
+	// Bookmarks are the bookmarked content lines (1-based)
+	Bookmarks []int
+
+	// ProjectID is the project the note is filed under
+	ProjectID string
+
+	// Structured fields of the note types; nil when not filled in
+	Function      *models.FunctionDetails
+	Vulnerability *models.VulnerabilityDetails
+	Protocol      *models.ProtocolDetails
+	Structure     *models.StructureDetails
+
+	// Fields are the structured fields of note types defined in the configuration
+	Fields map[string]string
 }
 
-// NotePadComponents groups all the interactive components of the notepad
-// This makes it easier to access them for setting and getting data
-type NotePadComponents struct {
-	TitleEntry        *widget.Entry
-	ContentEntry      *widget.Entry
-	TagsEntry         *widget.Entry
+// NotePad is the note editing widget.
+// Each NotePad carries its own entry widgets, so several notepads can be
+// open at once (for example a note popped out into a separate window)
+// without interfering with each other.
+type NotePad struct {
+	widget.BaseWidget
+
+	TitleEntry        *widgets.ShortcutEntry
+	ContentEditor     *widgets.CodeEditor
+	ContentEntry      *widgets.ShortcutEntry
+	TagsEntry         *widgets.ShortcutEntry
 	NoteTypeSelect    *widget.Select
-	BinaryNameEntry   *widget.Entry
-	AddressRangeEntry *widget.Entry
-	FunctionRefsEntry *widget.Entry
+	BinaryNameEntry   *widgets.ShortcutEntry
+	AddressRangeEntry *widgets.ShortcutEntry
+	FunctionRefsEntry *widgets.ShortcutEntry
 	Tabs              *container.AppTabs
+
+	// Preview shows the content rendered as Markdown
+	Preview *widget.RichText
+
+	// Markdown renders the content for the preview.
+	// Its link and highlighting hooks may be customized by the owner.
+	Markdown *markdown.Renderer
+
+	// ModeSelect switches between edit, preview and split view
+	ModeSelect *widget.RadioGroup
+
+	// OnChanged is called whenever the user edits any field of the notepad.
+	// It is not called while data is being loaded programmatically.
+	OnChanged func()
+
+	// LinkCandidates returns note titles matching a partially typed
+	// [[note link]], used for autocompletion
+	LinkCandidates func(query string) []string
+
+	// OnOpenNote is called with a note ID when a backlink is tapped
+	OnOpenNote func(noteID string)
+
+	// Templates returns the content templates offered for a note type,
+	// the one applied when the type is picked first
+	Templates func(noteType string) []models.Template
+
+	// OnShowCaptures opens the packet captures of the note's project, from
+	// which messages can be pinned to protocol analysis notes
+	OnShowCaptures func()
+
+	// Disassemble decodes the note's address range of a binary attached to
+	// its project for the disassembly tab
+	Disassemble func(binaryName, addressRange, projectID string) (*disasm.Listing, error)
+
+	// DefaultNoteType is the note type selected when the notepad is cleared
+	DefaultNoteType string
+
+	// DefaultProjectID is the project new notes are filed under
+	DefaultProjectID string
+
+	// content is the fully assembled layout rendered by the widget
+	content fyne.CanvasObject
+
+	// loading suppresses OnChanged while fields are set from code
+	loading bool
+
+	// viewMode is the current content view mode
+	viewMode ViewMode
+
+	// editorArea holds the editor and/or preview depending on the view mode
+	editorArea *fyne.Container
+
+	// editor is the content editor wrapped in its text size override
+	editor *container.ThemeOverride
+
+	// editorTheme sets the text size of the content editor
+	editorTheme *editorTheme
+
+	// previewScroll makes long rendered notes scrollable
+	previewScroll *container.Scroll
+
+	// relatedNotes carries the loaded note's related note IDs through edits
+	relatedNotes []string
+
+	// projectID carries the loaded note's project through edits
+	projectID string
+
+	// suggestions shows link completions while a [[link is being typed
+	suggestions *fyne.Container
+
+	// backlinks lists the notes that link to the current note
+	backlinks *fyne.Container
+
+	// details holds the structured fields of the note types
+	details *detailForms
+
+	// disassembly shows the instructions of the note's address range
+	disassembly *disassemblyPane
+
+	// template is the template the content was created from; its
+	// placeholders follow the title, binary and address range until the
+	// content is edited. Nil when the content did not come from a template.
+	template *models.Template
+
+	// templateText is the content as last filled in from template
+	templateText string
 }
 
-// Global reference to the current notepad components
-// This approach avoids the need to store components in the container
-var currentComponents *NotePadComponents
+// maxLinkSuggestions limits how many completions are offered at once
+const maxLinkSuggestions = 8
 
-// NewNotePad creates a new notepad component for editing and viewing notes.
+// NewNotePad creates a new notepad widget for editing and viewing notes.
 // The notepad provides:
 // - A title field for naming the note
 // - A large content area for the main note text
 // - A tags field for categorization
 // - RE-specific fields for specialized analysis
-//
-// Returns a canvas object that can be placed in a container.
-func NewNotePad() fyne.CanvasObject {
-	components := &NotePadComponents{}
-	currentComponents = components // Store the reference globally
+func NewNotePad() *NotePad {
+	np := &NotePad{}
 
 	// Create the background
-	background := canvas.NewRectangle(terminalBgColor)
+	background := widgets.NewThemedRectangle(apptheme.ColorNameTerminalBackground)
 
 	// Create the title entry field with terminal styling
-	components.TitleEntry = widget.NewEntry()
-	components.TitleEntry.SetPlaceHolder("Note Title")
-	components.TitleEntry.TextStyle = fyne.TextStyle{Monospace: true, Bold: true}
+	np.TitleEntry = widgets.NewShortcutEntry()
+	np.TitleEntry.SetPlaceHolder("Note Title")
+	np.TitleEntry.TextStyle = fyne.TextStyle{Monospace: true, Bold: true}
 
-	// Create the main content entry field with terminal styling
-	components.ContentEntry = widgets.TerminalEntry()
-	components.ContentEntry.SetPlaceHolder("Write your analysis notes here...")
-	components.ContentEntry.SetMinRowsVisible(20)
+	// Create the main content editor with line numbers and terminal styling
+	np.ContentEditor = widgets.NewCodeEditor()
+	np.ContentEntry = np.ContentEditor.Entry
+	np.ContentEntry.SetPlaceHolder("Write your analysis notes here...")
+	np.editorTheme = &editorTheme{}
+	np.editor = container.NewThemeOverride(np.ContentEditor, np.editorTheme)
 
 	// Create the tags entry field with terminal styling
-	components.TagsEntry = widget.NewEntry()
-	components.TagsEntry.SetPlaceHolder("Tags (comma separated)")
-	components.TagsEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	np.TagsEntry = widgets.NewShortcutEntry()
+	np.TagsEntry.SetPlaceHolder("Tags (comma separated)")
+	np.TagsEntry.TextStyle = fyne.TextStyle{Monospace: true}
 
 	// Create a label for the tags field with distinctive styling
-	tagsLabel := canvas.NewText("TAGS:", color.NRGBA{R: 0, G: 200, B: 170, A: 255})
+	tagsLabel := widgets.NewThemedText("TAGS:", apptheme.ColorNameTagLabel)
 	tagsLabel.TextStyle = fyne.TextStyle{Monospace: true, Bold: true}
 	tagsLabel.TextSize = 12
 
 	// Create RE-specific fields with terminal styling
 	// Note type selector with distinctive styling
-	components.NoteTypeSelect = widget.NewSelect([]string{
-		models.RETypeGeneral,
-		models.RETypeFunctionAnalysis,
-		models.RETypeStructureAnalysis,
-		models.RETypeProtocolAnalysis,
-		models.RETypeVulnerability,
-	}, nil)
-	components.NoteTypeSelect.SetSelected(models.RETypeGeneral)
+	np.NoteTypeSelect = widget.NewSelect(models.NoteTypeNames(), nil)
+	np.NoteTypeSelect.SetSelected(models.RETypeGeneral)
 
 	// Binary name entry with terminal styling
-	components.BinaryNameEntry = widget.NewEntry()
-	components.BinaryNameEntry.SetPlaceHolder("Binary Name (optional)")
-	components.BinaryNameEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	np.BinaryNameEntry = widgets.NewShortcutEntry()
+	np.BinaryNameEntry.SetPlaceHolder("Binary Name (optional)")
+	np.BinaryNameEntry.TextStyle = fyne.TextStyle{Monospace: true}
 
 	// Address range entry with terminal styling
-	components.AddressRangeEntry = widget.NewEntry()
-	components.AddressRangeEntry.SetPlaceHolder("Address Range (e.g., 0x1000-0x2000)")
-	components.AddressRangeEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	np.AddressRangeEntry = widgets.NewShortcutEntry()
+	np.AddressRangeEntry.SetPlaceHolder("Address Range (e.g., 0x1000-0x2000)")
+	np.AddressRangeEntry.TextStyle = fyne.TextStyle{Monospace: true}
 
 	// Function references entry with terminal styling
-	components.FunctionRefsEntry = widget.NewMultiLineEntry()
-	components.FunctionRefsEntry.SetPlaceHolder("Function references (one per line)")
-	components.FunctionRefsEntry.SetMinRowsVisible(3)
-	components.FunctionRefsEntry.TextStyle = fyne.TextStyle{Monospace: true}
+	np.FunctionRefsEntry = widgets.NewMultiLineShortcutEntry()
+	np.FunctionRefsEntry.SetPlaceHolder("Function references (one per line)")
+	np.FunctionRefsEntry.SetMinRowsVisible(3)
+	np.FunctionRefsEntry.TextStyle = fyne.TextStyle{Monospace: true}
+
+	// Structured fields of the selected note type
+	np.details = newDetailForms(np.fieldChanged)
+	np.details.show(np.NoteTypeSelect.Selected)
+	np.details.dissector.OnShowCaptures = func() {
+		if np.OnShowCaptures != nil {
+			np.OnShowCaptures()
+		}
+	}
+
+	// Report user edits from every field through a single callback
+	np.TitleEntry.OnChanged = np.placeholderChanged
+	np.ContentEditor.OnChanged = func(text string) {
+		np.RefreshPreview()
+		np.fieldChanged(text)
+	}
+	np.ContentEditor.OnBookmarksChanged = func() {
+		np.fieldChanged("")
+	}
+	np.ContentEditor.OnCursorChanged = np.updateLinkSuggestions
+	np.TagsEntry.OnChanged = np.fieldChanged
+	np.NoteTypeSelect.OnChanged = func(noteType string) {
+		np.details.show(noteType)
+		if !np.loading && np.contentFromTemplate() {
+			np.applyDefaultTemplate()
+		}
+		np.fieldChanged(noteType)
+	}
+	np.BinaryNameEntry.OnChanged = np.placeholderChanged
+	np.AddressRangeEntry.OnChanged = np.placeholderChanged
+	np.FunctionRefsEntry.OnChanged = np.fieldChanged
 
 	// Create title container with prompt-like styling
-	titlePrompt := canvas.NewText(">> ", accentBlue)
+	titlePrompt := widgets.NewThemedText(">> ", apptheme.ColorNameAccent)
 	titlePrompt.TextStyle = fyne.TextStyle{Monospace: true, Bold: true}
 	titlePrompt.TextSize = 16
 
 	titleContainer := container.NewBorder(
-		nil, nil, titlePrompt, nil, components.TitleEntry)
+		nil, nil, titlePrompt, nil, np.TitleEntry)
 
 	// Arrange the tags label and entry field in a horizontal layout
-	tagsContainer := container.NewBorder(nil, nil, tagsLabel, nil, components.TagsEntry)
+	tagsContainer := container.NewBorder(nil, nil, tagsLabel, nil, np.TagsEntry)
 
 	// Create styled labels for RE fields
 	typeLabel := createTerminalLabel("TYPE:")
@@ -144,55 +289,125 @@ func NewNotePad() fyne.CanvasObject {
 
 	// Create container for RE-specific fields with terminal styling
 	reFieldsContainer := container.NewVBox(
-		container.NewBorder(nil, nil, typeLabel, nil, components.NoteTypeSelect),
-		container.NewBorder(nil, nil, binaryLabel, nil, components.BinaryNameEntry),
-		container.NewBorder(nil, nil, addressLabel, nil, components.AddressRangeEntry),
+		container.NewBorder(nil, nil, typeLabel, nil, np.NoteTypeSelect),
+		container.NewBorder(nil, nil, binaryLabel, nil, np.BinaryNameEntry),
+		container.NewBorder(nil, nil, addressLabel, nil, np.AddressRangeEntry),
 		funcRefsLabel,
-		components.FunctionRefsEntry,
+		np.FunctionRefsEntry,
+		np.details.area,
 	)
 
 	// Create a code block background for the RE fields
-	reBackground := canvas.NewRectangle(codeBlockBgColor)
+	reBackground := widgets.NewThemedRectangle(apptheme.ColorNameCodeBlockBackground)
 	reContainer := container.NewStack(
 		reBackground,
 		container.NewPadded(reFieldsContainer),
 	)
 
+	// Create the backlinks panel listing notes that link here
+	np.backlinks = container.NewVBox()
+	backlinksContainer := container.NewVBox(
+		createTerminalLabel("BACKLINKS:"),
+		container.NewVScroll(np.backlinks),
+	)
+
+	// Create the disassembly of the note's address range
+	np.disassembly = newDisassemblyPane(
+		func() (string, string, string) {
+			return np.BinaryNameEntry.Text, np.AddressRangeEntry.Text, np.projectID
+		},
+		func(binaryName, addressRange, projectID string) (*disasm.Listing, error) {
+			if np.Disassemble == nil {
+				return nil, fmt.Errorf("disassembly is not available")
+			}
+			return np.Disassemble(binaryName, addressRange, projectID)
+		},
+		np.appendContent,
+	)
+
 	// Create tabs for regular note fields and RE-specific fields with hex addresses
-	components.Tabs = container.NewAppTabs(
+	np.Tabs = container.NewAppTabs(
 		widgets.HexTabItem("01", container.NewVBox(
 			titleContainer,
 			tagsContainer,
 		)),
 		widgets.HexTabItem("02", reContainer),
+		widgets.HexTabItem("03", backlinksContainer),
+		widgets.HexTabItem("04", np.disassembly.content),
 	)
 
-	// Style the tabs to look like memory addresses
-	components.Tabs.OnSelected = func(tab *container.TabItem) {
-		// We could add more custom tab styling logic here
+	// The disassembly follows the note when its tab is shown
+	np.Tabs.OnSelected = func(tab *container.TabItem) {
+		if tab.Content == np.disassembly.content {
+			np.disassembly.refresh()
+		}
 	}
 
 	// Add decorative elements to make it look like a terminal
 	// Create a terminal-style prompt for the content area
-	contentPrompt := canvas.NewText("$>", accentBlue)
+	contentPrompt := widgets.NewThemedText("$>", apptheme.ColorNameAccent)
 	contentPrompt.TextStyle = fyne.TextStyle{Monospace: true, Bold: true}
 	contentPrompt.TextSize = 14
 
 	// Add hex address indicators to simulate memory view
 	addrIndicator := createHexAddressLabel()
 
+	// Create the Markdown preview shown in preview and split modes
+	np.Markdown = &markdown.Renderer{Highlight: (&highlight.Highlighter{}).Highlight}
+	np.Preview = widget.NewRichText()
+	np.Preview.Wrapping = fyne.TextWrapWord
+	np.previewScroll = container.NewScroll(np.Preview)
+	np.editorArea = container.NewStack()
+
+	// Create the view mode switch
+	np.ModeSelect = widget.NewRadioGroup([]string{
+		string(ViewEdit),
+		string(ViewPreview),
+		string(ViewSplit),
+	}, func(selected string) {
+		if selected != "" {
+			np.SetViewMode(ViewMode(selected))
+		}
+	})
+	np.ModeSelect.Horizontal = true
+	np.ModeSelect.Required = true
+	np.ModeSelect.SetSelected(string(ViewEdit))
+
+	// Create the code pane controls: line numbers, go-to-line and bookmarks
+	lineNumbersCheck := widget.NewCheck("LINES", func(show bool) {
+		np.ContentEditor.SetLineNumbers(show)
+	})
+	lineNumbersCheck.SetChecked(true)
+	goToButton := widget.NewButtonWithIcon("", theme.SearchIcon(), np.ShowGoToLine)
+	bookmarkButton := widget.NewButtonWithIcon("", theme.ContentAddIcon(), func() {
+		np.ContentEditor.ToggleBookmark(np.ContentEditor.CurrentLine())
+	})
+	nextBookmarkButton := widget.NewButtonWithIcon("", theme.MoveDownIcon(), np.ContentEditor.NextBookmark)
+	var templateButton *widget.Button
+	templateButton = widget.NewButtonWithIcon("", theme.DocumentIcon(), func() {
+		np.showTemplateMenu(templateButton)
+	})
+	for _, b := range []*widget.Button{goToButton, bookmarkButton, nextBookmarkButton, templateButton} {
+		b.Importance = widget.LowImportance
+	}
+
+	// Create the link completion bar, hidden until a [[link is typed
+	np.suggestions = container.NewHBox()
+	np.suggestions.Hide()
+
 	// Create the content area with decorative elements
 	contentContainer := container.NewBorder(
-		container.NewHBox(contentPrompt, addrIndicator),
-		nil,
+		container.NewHBox(contentPrompt, addrIndicator, layout.NewSpacer(),
+			lineNumbersCheck, goToButton, bookmarkButton, nextBookmarkButton, templateButton, np.ModeSelect),
+		np.suggestions,
 		nil,
 		nil,
-		components.ContentEntry,
+		np.editorArea,
 	)
 
 	// Create the overall notepad layout
 	noteContainer := container.NewBorder(
-		components.Tabs,  // Top component (tabs)
+		np.Tabs,          // Top component (tabs)
 		nil,              // No bottom component
 		nil,              // No left component
 		nil,              // No right component
@@ -200,79 +415,423 @@ func NewNotePad() fyne.CanvasObject {
 	)
 
 	// Stack the background and content
-	return container.NewStack(
+	np.content = container.NewStack(
 		background,
 		container.NewPadded(noteContainer),
 	)
+
+	np.SetBacklinks(nil)
+	np.ExtendBaseWidget(np)
+	return np
+}
+
+// CreateRenderer implements fyne.Widget by rendering the assembled layout.
+func (np *NotePad) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(np.content)
+}
+
+// SetViewMode switches the content area between editing, preview and split view
+func (np *NotePad) SetViewMode(mode ViewMode) {
+	np.viewMode = mode
+
+	switch mode {
+	case ViewPreview:
+		np.editorArea.Objects = []fyne.CanvasObject{np.previewScroll}
+	case ViewSplit:
+		np.editorArea.Objects = []fyne.CanvasObject{container.NewHSplit(np.editor, np.previewScroll)}
+	default:
+		np.viewMode = ViewEdit
+		np.editorArea.Objects = []fyne.CanvasObject{np.editor}
+	}
+
+	if np.ModeSelect.Selected != string(np.viewMode) {
+		np.ModeSelect.SetSelected(string(np.viewMode))
+	}
+	np.RefreshPreview()
+	np.editorArea.Refresh()
+}
+
+// ViewMode returns the current content view mode
+func (np *NotePad) ViewMode() ViewMode {
+	return np.viewMode
+}
+
+// TogglePreview switches between editing the content and its Markdown
+// preview. Split view switches to editing.
+func (np *NotePad) TogglePreview() {
+	if np.viewMode == ViewEdit {
+		np.SetViewMode(ViewPreview)
+		return
+	}
+	np.SetViewMode(ViewEdit)
+}
+
+// NextTab selects the next field tab, wrapping around after the last
+func (np *NotePad) NextTab() {
+	if len(np.Tabs.Items) == 0 {
+		return
+	}
+	np.Tabs.SelectIndex((np.Tabs.SelectedIndex() + 1) % len(np.Tabs.Items))
+}
+
+// PinSample adds a message from a packet capture to the samples of the
+// note's dissector and decodes it.
+//
+// Parameters:
+//   - sample: The message and where it was captured
+//
+// Returns:
+//   - An error if the note is not a protocol analysis
+func (np *NotePad) PinSample(sample models.SampleMessage) error {
+	if np.NoteTypeSelect.Selected != models.RETypeProtocolAnalysis {
+		return fmt.Errorf("samples can only be pinned to protocol analysis notes")
+	}
+	np.details.dissector.pin(sample)
+	return nil
+}
+
+// ProjectID returns the project the note is filed under
+func (np *NotePad) ProjectID() string {
+	return np.projectID
+}
+
+// SetProjectID files the note under a project
+func (np *NotePad) SetProjectID(id string) {
+	np.projectID = id
+}
+
+// SetEditorTextSize changes the text size of the content editor.
+// A size of 0 uses the text size of the application theme.
+func (np *NotePad) SetEditorTextSize(size float32) {
+	if np.editorTheme.textSize == size {
+		return
+	}
+	np.editorTheme.textSize = size
+	np.editor.Refresh()
+}
+
+// SetBacklinks shows the notes that link to the current note
+func (np *NotePad) SetBacklinks(notes []*models.Note) {
+	np.backlinks.Objects = nil
+	if len(notes) == 0 {
+		empty := widget.NewLabel("No notes link here yet. Link with [[Note Title]].")
+		empty.TextStyle = fyne.TextStyle{Monospace: true, Italic: true}
+		np.backlinks.Add(empty)
+		return
+	}
+
+	for _, note := range notes {
+		id := note.ID
+		link := widget.NewButtonWithIcon(note.Title, theme.NavigateBackIcon(), func() {
+			if np.OnOpenNote != nil {
+				np.OnOpenNote(id)
+			}
+		})
+		link.Alignment = widget.ButtonAlignLeading
+		link.Importance = widget.LowImportance
+		np.backlinks.Add(link)
+	}
+	np.backlinks.Refresh()
+}
+
+// updateLinkSuggestions offers note titles while an unclosed [[link is
+// being typed on the current line
+func (np *NotePad) updateLinkSuggestions() {
+	np.suggestions.Objects = nil
+	defer np.suggestions.Refresh()
+
+	line := np.ContentEditor.CurrentLineText()
+	start := strings.LastIndex(line, "[[")
+	if start < 0 || np.LinkCandidates == nil || strings.ContainsAny(line[start:], "]|") {
+		np.suggestions.Hide()
+		return
+	}
+
+	query := line[start+2:]
+	candidates := np.LinkCandidates(query)
+	if len(candidates) == 0 {
+		np.suggestions.Hide()
+		return
+	}
+
+	np.suggestions.Add(createTerminalLabel("LINK:"))
+	lineNumber := np.ContentEditor.CurrentLine()
+	for i, title := range candidates {
+		if i == maxLinkSuggestions {
+			break
+		}
+		completed := line[:start] + "[[" + title + "]]"
+		choice := widget.NewButton(title, func() {
+			np.ContentEditor.ReplaceLine(lineNumber, completed)
+			np.suggestions.Hide()
+		})
+		choice.Importance = widget.LowImportance
+		np.suggestions.Add(choice)
+	}
+	np.suggestions.Show()
+}
+
+// ShowGoToLine asks for a line number and moves the cursor to that line
+func (np *NotePad) ShowGoToLine() {
+	win := windowFor(np)
+	if win == nil {
+		return
+	}
+
+	lineEntry := widget.NewEntry()
+	lineEntry.SetPlaceHolder(fmt.Sprintf("1-%d", np.ContentEditor.LineCount()))
+	lineEntry.Validator = func(text string) error {
+		_, err := strconv.Atoi(strings.TrimSpace(text))
+		return err
+	}
+
+	dialog.ShowForm("Go to Line", "Go", "Cancel",
+		[]*widget.FormItem{widget.NewFormItem("Line", lineEntry)},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			line, _ := strconv.Atoi(strings.TrimSpace(lineEntry.Text))
+			np.ContentEditor.GoToLine(line)
+		}, win)
+}
+
+// RefreshPreview re-renders the Markdown preview when it is visible
+func (np *NotePad) RefreshPreview() {
+	if np.viewMode == ViewEdit || np.Preview == nil {
+		return
+	}
+	np.Preview.Segments = np.Markdown.Render(np.ContentEntry.Text)
+	np.Preview.Refresh()
+}
+
+// ApplyTemplate fills in a template with the note's fields. The template
+// replaces content that is empty or still as a template left it, and is
+// appended to other content.
+//
+// Parameters:
+//   - t: The template to apply
+func (np *NotePad) ApplyTemplate(t models.Template) {
+	text := t.Expand(np.templateValues())
+	if !np.contentFromTemplate() {
+		np.ContentEditor.SetText(strings.TrimRight(np.ContentEntry.Text, "\n") + "\n\n" + text)
+		np.template = nil
+		return
+	}
+	np.template = &t
+	np.templateText = text
+	np.ContentEditor.SetText(text)
+}
+
+// appendContent adds a block of text after the note's content
+func (np *NotePad) appendContent(block string) {
+	content := strings.TrimRight(np.ContentEntry.Text, "\n")
+	if content == "" {
+		np.ContentEditor.SetText(block)
+		return
+	}
+	np.ContentEditor.SetText(content + "\n\n" + block)
+}
+
+// applyDefaultTemplate applies the first template of the selected note
+// type, or empties the content if the type has none
+func (np *NotePad) applyDefaultTemplate() {
+	var templates []models.Template
+	if np.Templates != nil {
+		templates = np.Templates(np.NoteTypeSelect.Selected)
+	}
+	if len(templates) == 0 {
+		np.template = nil
+		np.templateText = ""
+		np.ContentEditor.SetText("")
+		return
+	}
+	np.ApplyTemplate(templates[0])
+}
+
+// contentFromTemplate reports whether the content is empty or unchanged
+// since a template filled it in
+func (np *NotePad) contentFromTemplate() bool {
+	text := np.ContentEntry.Text
+	return text == "" || np.template != nil && text == np.templateText
+}
+
+// placeholderChanged refills the template placeholders after an edit of a
+// field they are taken from, then reports the edit
+func (np *NotePad) placeholderChanged(text string) {
+	if !np.loading && np.template != nil && np.ContentEntry.Text == np.templateText {
+		if filled := np.template.Expand(np.templateValues()); filled != np.templateText {
+			np.templateText = filled
+			np.ContentEditor.SetText(filled)
+		}
+	}
+	np.fieldChanged(text)
+}
+
+// templateValues returns the note's fields as template placeholder values
+func (np *NotePad) templateValues() models.TemplateValues {
+	return models.TemplateValues{
+		Title:        np.TitleEntry.Text,
+		BinaryName:   np.BinaryNameEntry.Text,
+		AddressRange: np.AddressRangeEntry.Text,
+		NoteType:     np.NoteTypeSelect.Selected,
+		Date:         time.Now(),
+	}
+}
+
+// showTemplateMenu offers the templates of the selected note type below
+// the button that opened it
+func (np *NotePad) showTemplateMenu(button fyne.CanvasObject) {
+	var templates []models.Template
+	if np.Templates != nil {
+		templates = np.Templates(np.NoteTypeSelect.Selected)
+	}
+
+	var items []*fyne.MenuItem
+	for _, t := range templates {
+		label := t.Name
+		if t.NoteType == "" {
+			label += " (all types)"
+		}
+		items = append(items, fyne.NewMenuItem(label, func() {
+			np.ApplyTemplate(t)
+		}))
+	}
+	if len(items) == 0 {
+		none := fyne.NewMenuItem("No templates for this note type", nil)
+		none.Disabled = true
+		items = append(items, none)
+	}
+
+	c := fyne.CurrentApp().Driver().CanvasForObject(button)
+	if c == nil {
+		return
+	}
+	position := fyne.CurrentApp().Driver().AbsolutePositionForObject(button)
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", items...), c,
+		position.Add(fyne.NewPos(0, button.Size().Height)))
+}
+
+// fieldChanged forwards a user edit to OnChanged unless data is being loaded
+func (np *NotePad) fieldChanged(string) {
+	if np.loading || np.OnChanged == nil {
+		return
+	}
+	np.OnChanged()
 }
 
 // createTerminalLabel creates a terminal-styled label
-func createTerminalLabel(text string) *canvas.Text {
-	label := canvas.NewText(text, accentBlue)
+func createTerminalLabel(text string) *widgets.ThemedText {
+	label := widgets.NewThemedText(text, apptheme.ColorNameAccent)
 	label.TextStyle = fyne.TextStyle{Monospace: true, Bold: true}
 	label.TextSize = 12
 	return label
 }
 
 // createHexAddressLabel creates a label with hexadecimal address styling
-func createHexAddressLabel() *canvas.Text {
-	label := canvas.NewText("0x00c0ffee:", terminalTextColor)
+func createHexAddressLabel() *widgets.ThemedText {
+	label := widgets.NewThemedText("0x00c0ffee:", apptheme.ColorNameTerminalText)
 	label.TextStyle = fyne.TextStyle{Monospace: true}
 	label.TextSize = 12
 	return label
 }
 
-// getComponents returns the current components
-func getComponents() *NotePadComponents {
-	return currentComponents
-}
-
-// LoadNoteData loads data into the notepad component.
-// This function populates the notepad with existing note data
+// LoadNoteData loads data into the notepad.
+// This populates the notepad with existing note data
 // when a user selects a note to view or edit.
 //
 // Parameters:
-//   - notepad: The notepad container to load data into
 //   - data: The note data to load
-func LoadNoteData(notepad *fyne.Container, data NotePadData) {
-	components := getComponents()
+func (np *NotePad) LoadNoteData(data NotePadData) {
+	np.loading = true
+	defer func() { np.loading = false }()
 
 	// Set basic note data
-	components.TitleEntry.SetText(data.Title)
-	components.ContentEntry.SetText(data.Content)
-	components.TagsEntry.SetText(strings.Join(data.Tags, ", "))
+	np.template = nil
+	np.relatedNotes = data.RelatedNotes
+	np.projectID = data.ProjectID
+	np.TitleEntry.SetText(data.Title)
+	np.ContentEditor.SetText(data.Content)
+	np.ContentEditor.SetBookmarks(data.Bookmarks)
+	np.TagsEntry.SetText(strings.Join(data.Tags, ", "))
 
 	// Set RE-specific data
-	components.NoteTypeSelect.SetSelected(data.ReverseEngType)
-	components.BinaryNameEntry.SetText(data.BinaryName)
-	components.AddressRangeEntry.SetText(data.AddressRange)
-	components.FunctionRefsEntry.SetText(strings.Join(data.FunctionRefs, "\n"))
+	np.setNoteTypeOptions(data.ReverseEngType)
+	np.NoteTypeSelect.SetSelected(data.ReverseEngType)
+	np.BinaryNameEntry.SetText(data.BinaryName)
+	np.AddressRangeEntry.SetText(data.AddressRange)
+	np.FunctionRefsEntry.SetText(strings.Join(data.FunctionRefs, "\n"))
+	np.details.load(data)
+	np.disassembly.clear()
+	if np.Tabs.Selected() != nil && np.Tabs.Selected().Content == np.disassembly.content {
+		np.disassembly.refresh()
+	}
 }
 
-// GetNoteData retrieves data from the notepad component.
-// This function extracts the current note data from the UI
-// when a user wants to save a note.
+// SyncNoteData brings the notepad in step with edits made to the same note
+// in another notepad. Unlike LoadNoteData it only sets the fields that
+// differ, so the cursor, selection and undo history of the others are
+// kept.
 //
 // Parameters:
-//   - notepad: The notepad container to extract data from
+//   - data: The note data of the notepad that was edited
+func (np *NotePad) SyncNoteData(data NotePadData) {
+	np.loading = true
+	defer func() { np.loading = false }()
+
+	np.relatedNotes = data.RelatedNotes
+	np.projectID = data.ProjectID
+	syncText(np.TitleEntry, data.Title)
+	if np.ContentEntry.Text != data.Content {
+		np.ContentEditor.SetText(data.Content)
+	}
+	if !slices.Equal(np.ContentEditor.Bookmarks(), data.Bookmarks) {
+		np.ContentEditor.SetBookmarks(data.Bookmarks)
+	}
+	syncText(np.TagsEntry, strings.Join(data.Tags, ", "))
+	if np.NoteTypeSelect.Selected != data.ReverseEngType {
+		np.setNoteTypeOptions(data.ReverseEngType)
+		np.NoteTypeSelect.SetSelected(data.ReverseEngType)
+	}
+	syncText(np.BinaryNameEntry, data.BinaryName)
+	syncText(np.AddressRangeEntry, data.AddressRange)
+	syncText(np.FunctionRefsEntry, strings.Join(data.FunctionRefs, "\n"))
+
+	var current NotePadData
+	np.details.collect(&current)
+	if !reflect.DeepEqual([]any{current.Function, current.Vulnerability, current.Protocol, current.Structure, current.Fields},
+		[]any{data.Function, data.Vulnerability, data.Protocol, data.Structure, data.Fields}) {
+		np.details.load(data)
+	}
+}
+
+// syncText sets the text of an entry unless it already holds it, as
+// setting the text clears the entry's undo history
+func syncText(entry *widgets.ShortcutEntry, text string) {
+	if entry.Text != text {
+		entry.SetText(text)
+	}
+}
+
+// GetNoteData retrieves data from the notepad.
+// This extracts the current note data from the UI
+// when a user wants to save a note.
 //
 // Returns:
 //   - The note data extracted from the notepad
-func GetNoteData(notepad *fyne.Container) NotePadData {
-	components := getComponents()
-
+func (np *NotePad) GetNoteData() NotePadData {
 	// Extract tags from comma-separated list
 	tags := []string{}
-	if components.TagsEntry.Text != "" {
-		for _, tag := range strings.Split(components.TagsEntry.Text, ",") {
+	if np.TagsEntry.Text != "" {
+		for _, tag := range strings.Split(np.TagsEntry.Text, ",") {
 			tags = append(tags, strings.TrimSpace(tag))
 		}
 	}
 
 	// Extract function references from newline-separated list
 	functionRefs := []string{}
-	if components.FunctionRefsEntry.Text != "" {
-		for _, ref := range strings.Split(components.FunctionRefsEntry.Text, "\n") {
+	if np.FunctionRefsEntry.Text != "" {
+		for _, ref := range strings.Split(np.FunctionRefsEntry.Text, "\n") {
 			if trimmed := strings.TrimSpace(ref); trimmed != "" {
 				functionRefs = append(functionRefs, trimmed)
 			}
@@ -280,34 +839,56 @@ func GetNoteData(notepad *fyne.Container) NotePadData {
 	}
 
 	// Compile the data
-	return NotePadData{
-		Title:          components.TitleEntry.Text,
-		Content:        components.ContentEntry.Text,
+	data := NotePadData{
+		Title:          np.TitleEntry.Text,
+		Content:        np.ContentEntry.Text,
 		Tags:           tags,
-		BinaryName:     components.BinaryNameEntry.Text,
+		BinaryName:     np.BinaryNameEntry.Text,
 		FunctionRefs:   functionRefs,
-		AddressRange:   components.AddressRangeEntry.Text,
-		ReverseEngType: components.NoteTypeSelect.Selected,
+		AddressRange:   np.AddressRangeEntry.Text,
+		RelatedNotes:   np.relatedNotes,
+		ReverseEngType: np.NoteTypeSelect.Selected,
+		Bookmarks:      np.ContentEditor.Bookmarks(),
+		ProjectID:      np.projectID,
 	}
+	np.details.collect(&data)
+	return data
 }
 
-// ClearNotepad resets all fields in the notepad
-func ClearNotepad(notepad *fyne.Container) {
-	components := getComponents()
+// Clear resets all fields in the notepad
+func (np *NotePad) Clear() {
+	np.loading = true
+	defer func() { np.loading = false }()
 
 	// Clear basic note data
-	components.TitleEntry.SetText("")
-	components.ContentEntry.SetText("")
-	components.TagsEntry.SetText("")
+	np.relatedNotes = nil
+	np.projectID = np.DefaultProjectID
+	np.TitleEntry.SetText("")
+	np.ContentEditor.SetText("")
+	np.ContentEditor.SetBookmarks(nil)
+	np.TagsEntry.SetText("")
 
 	// Clear RE-specific data
-	components.NoteTypeSelect.SetSelected(models.RETypeGeneral)
-	components.BinaryNameEntry.SetText("")
-	components.AddressRangeEntry.SetText("")
-	components.FunctionRefsEntry.SetText("")
-
-	// Reset to first tab
-	components.Tabs.SelectIndex(0)
+	noteType := np.DefaultNoteType
+	if noteType == "" {
+		noteType = models.RETypeGeneral
+	}
+	np.setNoteTypeOptions(noteType)
+	np.NoteTypeSelect.SetSelected(noteType)
+	np.BinaryNameEntry.SetText("")
+	np.AddressRangeEntry.SetText("")
+	np.FunctionRefsEntry.SetText("")
+	np.details.load(NotePadData{})
+	np.disassembly.clear()
+
+	// Start the content from the note type's template
+	np.template = nil
+	np.applyDefaultTemplate()
+
+	// Reset links and go back to the first tab
+	np.SetBacklinks(nil)
+	np.suggestions.Hide()
+	np.Tabs.SelectIndex(0)
 }
 
 // ConvertToNote converts NotePadData to a models.Note.
@@ -323,6 +904,13 @@ func ConvertToNote(data NotePadData, existingID string) *models.Note {
 		AddressRange:   data.AddressRange,
 		RelatedNotes:   data.RelatedNotes,
 		ReverseEngType: data.ReverseEngType,
+		Bookmarks:      data.Bookmarks,
+		ProjectID:      data.ProjectID,
+		Function:       data.Function,
+		Vulnerability:  data.Vulnerability,
+		Protocol:       data.Protocol,
+		Structure:      data.Structure,
+		Fields:         data.Fields,
 	}
 	return note
 }
@@ -339,5 +927,63 @@ func ConvertFromNote(note *models.Note) NotePadData {
 		AddressRange:   note.AddressRange,
 		RelatedNotes:   note.RelatedNotes,
 		ReverseEngType: note.ReverseEngType,
+		Bookmarks:      note.Bookmarks,
+		ProjectID:      note.ProjectID,
+		Function:       note.Function,
+		Vulnerability:  note.Vulnerability,
+		Protocol:       note.Protocol,
+		Structure:      note.Structure,
+		Fields:         note.Fields,
+	}
+}
+
+// setNoteTypeOptions offers the registered note types, plus a note's own
+// type if it is not registered, so that notes of unknown types keep them
+func (np *NotePad) setNoteTypeOptions(noteType string) {
+	options := models.NoteTypeNames()
+	if noteType != "" && !models.IsNoteType(noteType) {
+		options = append(options, noteType)
+	}
+	np.NoteTypeSelect.SetOptions(options)
+}
+
+// editorTheme is the application theme with the content editor's own
+// text size. It follows the application theme as that changes.
+type editorTheme struct {
+	// textSize replaces the theme's text size when it is not 0
+	textSize float32
+}
+
+// Color implements fyne.Theme
+func (t *editorTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	return theme.Current().Color(name, variant)
+}
+
+// Font implements fyne.Theme
+func (t *editorTheme) Font(style fyne.TextStyle) fyne.Resource {
+	return theme.Current().Font(style)
+}
+
+// Icon implements fyne.Theme
+func (t *editorTheme) Icon(name fyne.ThemeIconName) fyne.Resource {
+	return theme.Current().Icon(name)
+}
+
+// Size implements fyne.Theme
+func (t *editorTheme) Size(name fyne.ThemeSizeName) float32 {
+	if name == theme.SizeNameText && t.textSize > 0 {
+		return t.textSize
+	}
+	return theme.Current().Size(name)
+}
+
+// windowFor finds the window that displays a canvas object
+func windowFor(obj fyne.CanvasObject) fyne.Window {
+	c := fyne.CurrentApp().Driver().CanvasForObject(obj)
+	for _, w := range fyne.CurrentApp().Driver().AllWindows() {
+		if w.Canvas() == c {
+			return w
+		}
 	}
+	return nil
 }
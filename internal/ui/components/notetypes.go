@@ -0,0 +1,118 @@
+// Package components provides UI components for the RevEnGo application.
+// This file contains the colors and icons that identify note types.
+package components
+
+import (
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/leog/RevEnGo/internal/models"
+	apptheme "github.com/leog/RevEnGo/internal/ui/theme"
+	"github.com/leog/RevEnGo/internal/ui/widgets"
+)
+
+// builtinNoteTypeColors are the theme colors of the built-in note types
+var builtinNoteTypeColors = map[string]fyne.ThemeColorName{
+	models.RETypeGeneral:           apptheme.ColorNameGeneralNote,
+	models.RETypeFunctionAnalysis:  apptheme.ColorNameFunctionAnalysis,
+	models.RETypeStructureAnalysis: apptheme.ColorNameStructureAnalysis,
+	models.RETypeProtocolAnalysis:  apptheme.ColorNameProtocolAnalysis,
+	models.RETypeVulnerability:     apptheme.ColorNameVulnerability,
+}
+
+// noteTypeIcons maps the icon names note types may use to theme icons
+var noteTypeIcons = map[string]func() fyne.Resource{
+	"account":     theme.AccountIcon,
+	"application": theme.FileApplicationIcon,
+	"computer":    theme.ComputerIcon,
+	"document":    theme.DocumentIcon,
+	"download":    theme.DownloadIcon,
+	"error":       theme.ErrorIcon,
+	"file":        theme.FileIcon,
+	"folder":      theme.FolderIcon,
+	"grid":        theme.GridIcon,
+	"help":        theme.HelpIcon,
+	"history":     theme.HistoryIcon,
+	"home":        theme.HomeIcon,
+	"info":        theme.InfoIcon,
+	"list":        theme.ListIcon,
+	"mail":        theme.MailComposeIcon,
+	"media":       theme.MediaPlayIcon,
+	"question":    theme.QuestionIcon,
+	"search":      theme.SearchIcon,
+	"settings":    theme.SettingsIcon,
+	"storage":     theme.StorageIcon,
+	"upload":      theme.UploadIcon,
+	"visibility":  theme.VisibilityIcon,
+	"warning":     theme.WarningIcon,
+}
+
+// NoteTypeColorName returns the theme color identifying a note type.
+// Types defined in the configuration use the color name "note:<type>",
+// which RegisterNoteTypeColors fills in and theme files may override.
+// Unknown types use the color of general notes.
+func NoteTypeColorName(noteType string) fyne.ThemeColorName {
+	if name, ok := builtinNoteTypeColors[noteType]; ok {
+		return name
+	}
+	if t, ok := models.LookupNoteType(noteType); ok && t.Color != "" {
+		return fyne.ThemeColorName("note:" + t.Name)
+	}
+	return apptheme.ColorNameGeneralNote
+}
+
+// NoteTypeIcon returns the icon identifying a note type; types without a
+// known icon use the document icon
+func NoteTypeIcon(noteType string) fyne.Resource {
+	if t, ok := models.LookupNoteType(noteType); ok {
+		if icon, ok := noteTypeIcons[t.Icon]; ok {
+			return icon()
+		}
+	}
+	return theme.DocumentIcon()
+}
+
+// RegisterNoteTypeColors adds the colors of the note types defined in the
+// configuration to the themes
+func RegisterNoteTypeColors() {
+	for _, t := range models.NoteTypes() {
+		if t.Color == "" {
+			continue
+		}
+		c, err := apptheme.ParseColor(t.Color)
+		if err != nil {
+			log.Printf("Warning: note type %s: %v", t.Name, err)
+			continue
+		}
+		apptheme.RegisterColor(fyne.ThemeColorName("note:"+t.Name), c)
+	}
+}
+
+// NewNoteListItem creates a note list row: a color bar and icon showing
+// the note's type next to its title. Fill it in with SetNoteListItem.
+func NewNoteListItem() fyne.CanvasObject {
+	indicator := widgets.NewThemedRectangle(apptheme.ColorNameGeneralNote)
+	indicator.SetMinSize(fyne.NewSize(4, 20))
+	icon := widget.NewIcon(theme.DocumentIcon())
+	label := widget.NewLabel("")
+	label.Truncation = fyne.TextTruncateEllipsis
+	return container.NewBorder(nil, nil, container.NewHBox(indicator, icon), nil, label)
+}
+
+// SetNoteListItem shows a note in a row made by NewNoteListItem
+func SetNoteListItem(item fyne.CanvasObject, note *models.Note) {
+	row := item.(*fyne.Container)
+	label := row.Objects[0].(*widget.Label)
+	marker := row.Objects[1].(*fyne.Container)
+	indicator := marker.Objects[0].(*widgets.ThemedRectangle)
+	icon := marker.Objects[1].(*widget.Icon)
+
+	indicator.ColorName = NoteTypeColorName(note.ReverseEngType)
+	indicator.Refresh()
+	icon.SetResource(NoteTypeIcon(note.ReverseEngType))
+	label.SetText(note.Title)
+}
@@ -3,26 +3,15 @@
 package components
 
 import (
-	"image/color"
-
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
-	"github.com/leog/RevEnGo/internal/models"
+	apptheme "github.com/leog/RevEnGo/internal/ui/theme"
 	"github.com/leog/RevEnGo/internal/ui/widgets"
 )
 
-// Sidebar color constants
-var (
-	sidebarBgColor = color.NRGBA{R: 12, G: 17, B: 27, A: 255}   // Dark blue-black
-	sidebarAccent  = color.NRGBA{R: 0, G: 174, B: 239, A: 255}  // Cyber blue accent
-	highlightColor = color.NRGBA{R: 30, G: 60, B: 110, A: 255}  // Selection highlight
-	terminalGreen  = color.NRGBA{R: 35, G: 209, B: 139, A: 255} // Terminal green
-)
-
 // SidebarSection represents a section in the sidebar navigation.
 // Each section contains a title, icon, and a list of items that can be selected.
 // Sections help organize related items into collapsible groups.
@@ -45,28 +34,31 @@ type SidebarSection struct {
 // - Projects for organizing related notes
 // - Tags for filtering notes by keywords
 //
+// Parameters:
+//   - onSettings: Called when the settings button is tapped
+//
 // Returns a canvas object that can be placed in a container.
-func NewSidebar() fyne.CanvasObject {
+func NewSidebar(onSettings func()) fyne.CanvasObject {
 	// Create background panel
-	background := canvas.NewRectangle(sidebarBgColor)
+	background := widgets.NewThemedRectangle(apptheme.ColorNameSidebarBackground)
 
 	// Create the sidebar title with digital styling
-	sidebarTitle := widgets.DigitalText("OPERATIONS", 16, sidebarAccent)
+	sidebarTitle := widgets.DigitalText("OPERATIONS", 16, apptheme.ColorNameAccent)
 
 	// Create binary decoration
-	binaryDecoration := canvas.NewText("01001100 01001111 01000111", color.NRGBA{R: 40, G: 80, B: 120, A: 120})
+	binaryDecoration := widgets.NewThemedText("01001100 01001111 01000111", apptheme.ColorNameDecoration)
 	binaryDecoration.TextSize = 9
 	binaryDecoration.TextStyle = fyne.TextStyle{Monospace: true}
 
 	// Create a separator with circuit-inspired styling
-	circuitLine := canvas.NewLine(sidebarAccent)
-	circuitLine.StrokeWidth = 1
+	circuitLine := widgets.NewThemedRectangle(apptheme.ColorNameAccent)
+	circuitLine.SetMinSize(fyne.NewSize(0, 1))
 
 	// Create hexagonal navigation buttons with icons
 	notesButton := widgets.HexagonalButton(theme.DocumentIcon(), nil)
 	projectsButton := widgets.HexagonalButton(theme.FolderIcon(), nil)
 	analysisButton := widgets.HexagonalButton(theme.ViewRestoreIcon(), nil)
-	settingsButton := widgets.HexagonalButton(theme.SettingsIcon(), nil)
+	settingsButton := widgets.HexagonalButton(theme.SettingsIcon(), onSettings)
 
 	// Create a toolbar with the hexagonal buttons
 	hexButtonsContainer := container.NewHBox(
@@ -235,33 +227,15 @@ func createSidebarTree(sections []SidebarSection) *widget.Tree {
 
 // createNoteTypeIndicator creates a list item with an indicator showing the type of note
 func createNoteTypeIndicator(noteType string, title string) fyne.CanvasObject {
-	var indicatorColor color.Color
-	var iconRes fyne.Resource
-
 	// Choose color and icon based on note type
-	switch noteType {
-	case models.RETypeFunctionAnalysis:
-		indicatorColor = color.NRGBA{R: 0, G: 180, B: 255, A: 255} // Blue
-		iconRes = theme.DocumentIcon()
-	case models.RETypeVulnerability:
-		indicatorColor = color.NRGBA{R: 255, G: 70, B: 70, A: 255} // Red
-		iconRes = theme.WarningIcon()
-	case models.RETypeStructureAnalysis:
-		indicatorColor = color.NRGBA{R: 180, G: 120, B: 255, A: 255} // Purple
-		iconRes = theme.StorageIcon()
-	case models.RETypeProtocolAnalysis:
-		indicatorColor = color.NRGBA{R: 255, G: 180, B: 0, A: 255} // Amber
-		iconRes = theme.MailComposeIcon()
-	default:
-		indicatorColor = color.NRGBA{R: 120, G: 120, B: 120, A: 255} // Gray
-		iconRes = theme.DocumentIcon()
-	}
+	indicatorColor := NoteTypeColorName(noteType)
+	iconRes := NoteTypeIcon(noteType)
 
 	// Create an icon with the appropriate color
 	icon := widget.NewIcon(iconRes)
 
 	// Create a color indicator
-	indicator := canvas.NewRectangle(indicatorColor)
+	indicator := widgets.NewThemedRectangle(indicatorColor)
 	indicator.SetMinSize(fyne.NewSize(4, 20))
 
 	// Create the title label with monospaced font
@@ -277,12 +251,9 @@ func createNoteTypeIndicator(noteType string, title string) fyne.CanvasObject {
 		label,
 	)
 
-	// Create the hoverable container
-	hoverRect := canvas.NewRectangle(color.NRGBA{R: 0, G: 0, B: 0, A: 0})
-	item := container.NewStack(hoverRect, itemContent)
-
 	// Make the item visually distinct
-	hoverRect.FillColor = color.NRGBA{R: 15, G: 30, B: 55, A: 100}
+	itemBackground := widgets.NewThemedRectangle(apptheme.ColorNameSidebarItem)
+	item := container.NewStack(itemBackground, itemContent)
 
 	return container.NewPadded(item)
 }
@@ -0,0 +1,338 @@
+// Package components provides UI components for the RevEnGo application.
+// This file contains the C structure editor of structure analysis notes.
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/leog/RevEnGo/internal/cstruct"
+	"github.com/leog/RevEnGo/internal/models"
+	apptheme "github.com/leog/RevEnGo/internal/ui/theme"
+	"github.com/leog/RevEnGo/internal/ui/widgets"
+)
+
+// structurePlaceholder shows the syntax of structure definitions
+const structurePlaceholder = `struct packet_header {
+    uint32_t magic;
+    uint16_t version;
+    uint16_t flags : 4;
+    char name[16];
+    struct packet_header *next;
+};`
+
+// structureForm edits the C definition of a structure analysis note and
+// shows the layout computed from it as the user types
+type structureForm struct {
+	definition *widgets.ShortcutEntry
+	abi        *widget.Select
+
+	// status reports the structures found or the first error
+	status *widgets.ThemedText
+
+	// layout shows the offsets, sizes and types of every member
+	layout *widget.Label
+
+	// Overlay of a struct on the bytes of a binary
+	binary  *widgets.ShortcutEntry
+	offset  *widgets.ShortcutEntry
+	record  *widget.Select
+	endian  *widget.RadioGroup
+	overlay *widget.Label
+
+	content fyne.CanvasObject
+}
+
+// Byte orders offered for overlays
+const (
+	littleEndian = "Little endian"
+	bigEndian    = "Big endian"
+)
+
+// newStructureForm creates the structure editor.
+//
+// Parameters:
+//   - changed: Called with the new text when the user edits the definition or ABI
+func newStructureForm(changed func(string)) *structureForm {
+	s := &structureForm{}
+	s.definition = widgets.NewMultiLineShortcutEntry()
+	s.definition.SetPlaceHolder(structurePlaceholder)
+	s.definition.SetMinRowsVisible(10)
+	s.definition.TextStyle = fyne.TextStyle{Monospace: true}
+	s.definition.Wrapping = fyne.TextWrapOff
+	s.definition.OnChanged = func(text string) {
+		s.update()
+		changed(text)
+	}
+
+	s.status = widgets.NewThemedText("", apptheme.ColorNameTerminalText)
+	s.status.TextStyle = fyne.TextStyle{Monospace: true}
+	s.status.TextSize = 12
+
+	s.layout = widget.NewLabel("")
+	s.layout.TextStyle = fyne.TextStyle{Monospace: true}
+
+	s.abi = widget.NewSelect(cstruct.ABINames(), func(abi string) {
+		s.update()
+		changed(abi)
+	})
+	s.abi.SetSelected(cstruct.DefaultABI)
+
+	s.overlay = widget.NewLabel("")
+	s.overlay.TextStyle = fyne.TextStyle{Monospace: true}
+	overlayChanged := func(text string) {
+		s.updateOverlay()
+		changed(text)
+	}
+	s.binary = widgets.NewShortcutEntry()
+	s.binary.SetPlaceHolder("Path of the binary")
+	s.binary.TextStyle = fyne.TextStyle{Monospace: true}
+	s.binary.OnChanged = overlayChanged
+	s.offset = widgets.NewShortcutEntry()
+	s.offset.SetPlaceHolder("File offset, e.g. 0x1a40")
+	s.offset.TextStyle = fyne.TextStyle{Monospace: true}
+	s.offset.OnChanged = overlayChanged
+	s.record = widget.NewSelect(nil, overlayChanged)
+	s.record.PlaceHolder = "(first struct)"
+	s.endian = widget.NewRadioGroup([]string{littleEndian, bigEndian}, overlayChanged)
+	s.endian.Horizontal = true
+	s.endian.Required = true
+	s.endian.SetSelected(littleEndian)
+	browseButton := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), s.browseBinary)
+
+	overlayControls := container.NewVBox(
+		container.NewBorder(nil, nil, createTerminalLabel("BINARY:"), browseButton, s.binary),
+		container.NewGridWithColumns(2,
+			container.NewBorder(nil, nil, createTerminalLabel("OFFSET:"), nil, s.offset),
+			container.NewBorder(nil, nil, createTerminalLabel("STRUCT:"), nil, s.record),
+		),
+		s.endian,
+	)
+	views := container.NewAppTabs(
+		container.NewTabItem("LAYOUT", container.NewScroll(s.layout)),
+		container.NewTabItem("OVERLAY", container.NewBorder(overlayControls, nil, nil, nil, container.NewScroll(s.overlay))),
+	)
+
+	exportButton := func(label, format string) *widget.Button {
+		button := widget.NewButtonWithIcon(label, theme.DownloadIcon(), func() {
+			s.export(format)
+		})
+		button.Importance = widget.LowImportance
+		return button
+	}
+	toolbar := container.NewHBox(
+		createTerminalLabel("ABI:"),
+		s.abi,
+		widget.NewSeparator(),
+		createTerminalLabel("EXPORT:"),
+		exportButton("C", cstruct.FormatC),
+		exportButton("Ghidra", cstruct.FormatGhidra),
+		exportButton("ctypes", cstruct.FormatCtypes),
+	)
+
+	s.content = container.NewBorder(
+		container.NewVBox(toolbar, s.status), nil, nil, nil,
+		container.NewGridWithColumns(2, s.definition, views),
+	)
+	s.update()
+	return s
+}
+
+// load shows a note's structure definition
+func (s *structureForm) load(details *models.StructureDetails) {
+	if details == nil {
+		details = &models.StructureDetails{}
+	}
+	s.definition.SetText(details.Definition)
+	s.abi.SetSelected(firstNonEmpty(details.ABI, cstruct.DefaultABI))
+	s.binary.SetText(details.Binary)
+	s.offset.SetText(details.Offset)
+	s.record.ClearSelected()
+	s.update()
+	s.record.SetSelected(details.Record)
+	if details.BigEndian {
+		s.endian.SetSelected(bigEndian)
+	} else {
+		s.endian.SetSelected(littleEndian)
+	}
+	s.updateOverlay()
+}
+
+// details returns the structure definition as a note stores it
+func (s *structureForm) details() *models.StructureDetails {
+	return &models.StructureDetails{
+		Definition: s.definition.Text,
+		ABI:        s.abi.Selected,
+		Binary:     strings.TrimSpace(s.binary.Text),
+		Offset:     strings.TrimSpace(s.offset.Text),
+		Record:     s.record.Selected,
+		BigEndian:  s.endian.Selected == bigEndian,
+	}
+}
+
+// update lays out the definition and shows the result
+func (s *structureForm) update() {
+	defer s.updateOverlay()
+	if strings.TrimSpace(s.definition.Text) == "" {
+		s.setStatus("Define structs and unions in C to see their layout", apptheme.ColorNameTerminalText)
+		s.layout.SetText("")
+		return
+	}
+	layouts, err := s.details().Layouts()
+	if err != nil {
+		s.setStatus("ERROR: "+err.Error(), theme.ColorNameError)
+		return
+	}
+
+	var tables []string
+	var names []string
+	for _, l := range layouts {
+		tables = append(tables, l.Format())
+		if l.Name != "" {
+			names = append(names, l.Name)
+		}
+	}
+	s.setRecords(names)
+	s.layout.SetText(strings.Join(tables, "\n"))
+	noun := "structures"
+	if len(layouts) == 1 {
+		noun = "structure"
+	}
+	s.setStatus(fmt.Sprintf("%d %s laid out for %s", len(layouts), noun, s.abi.Selected), apptheme.ColorNameTerminalText)
+}
+
+// setRecords offers the structs and unions that can be overlaid, keeping
+// the selection if it is still defined
+func (s *structureForm) setRecords(names []string) {
+	s.record.Options = names
+	found := false
+	for _, name := range names {
+		found = found || name == s.record.Selected
+	}
+	if !found {
+		// Cleared without OnChanged: the definition change is already reported
+		s.record.Selected = ""
+	}
+	s.record.Refresh()
+}
+
+// updateOverlay decodes the bytes at the binary's offset through the
+// chosen struct
+func (s *structureForm) updateOverlay() {
+	if s.overlay == nil {
+		return
+	}
+	details := s.details()
+	if strings.TrimSpace(details.Definition) == "" || details.Binary == "" {
+		s.overlay.SetText("Choose a binary and a file offset to decode the bytes there through a struct")
+		return
+	}
+	layout, values, base, err := details.Overlay()
+	if err != nil {
+		s.overlay.SetText("ERROR: " + err.Error())
+		return
+	}
+	s.overlay.SetText(cstruct.FormatValues(layout, values, base))
+}
+
+// browseBinary picks the binary to overlay structures on
+func (s *structureForm) browseBinary() {
+	win := windowFor(s.content)
+	if win == nil {
+		return
+	}
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		reader.Close()
+		s.binary.SetText(reader.URI().Path())
+	}, win)
+}
+
+// setStatus shows a status message in a color
+func (s *structureForm) setStatus(text string, color fyne.ThemeColorName) {
+	s.status.Text = text
+	s.status.ColorName = color
+	s.status.Refresh()
+}
+
+// export shows the definition in an export format, with buttons to copy
+// it or save it to a file
+func (s *structureForm) export(format string) {
+	win := windowFor(s.content)
+	if win == nil {
+		return
+	}
+	abi, err := cstruct.LookupABI(s.abi.Selected)
+	if err == nil {
+		var file *cstruct.File
+		if file, err = cstruct.Parse(s.definition.Text); err == nil {
+			var source string
+			if source, err = cstruct.Export(file, abi, format); err == nil {
+				s.showExport(win, format, source, file)
+				return
+			}
+		}
+	}
+	dialog.ShowError(fmt.Errorf("cannot export the structures: %w", err), win)
+}
+
+// showExport shows exported source in a dialog
+func (s *structureForm) showExport(win fyne.Window, format, source string, file *cstruct.File) {
+	name := "structures"
+	if names := file.RecordNames(); len(names) > 0 && names[0] != "" {
+		name = names[0]
+	}
+	showExportDialog(win, "Export "+exportTitles[format], name+cstruct.FormatExtension(format), source)
+}
+
+// showExportDialog shows generated source in a dialog with buttons to copy
+// it or save it to a file.
+//
+// Parameters:
+//   - win: The window to show the dialog in
+//   - title: The dialog title
+//   - fileName: The file name offered when saving
+//   - source: The generated source
+func showExportDialog(win fyne.Window, title, fileName, source string) {
+	text := widget.NewMultiLineEntry()
+	text.SetText(source)
+	text.TextStyle = fyne.TextStyle{Monospace: true}
+	text.Wrapping = fyne.TextWrapOff
+
+	copyButton := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), func() {
+		win.Clipboard().SetContent(text.Text)
+	})
+	saveButton := widget.NewButtonWithIcon("Save As...", theme.DocumentSaveIcon(), func() {
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			defer writer.Close()
+			if _, err := writer.Write([]byte(text.Text)); err != nil {
+				dialog.ShowError(fmt.Errorf("saving %s: %w", writer.URI().Name(), err), win)
+			}
+		}, win)
+		save.SetFileName(fileName)
+		save.Show()
+	})
+
+	content := container.NewBorder(nil, container.NewHBox(copyButton, saveButton), nil, nil, text)
+	d := dialog.NewCustom(title, "Close", content, win)
+	d.Resize(fyne.NewSize(720, 520))
+	d.Show()
+}
+
+// exportTitles name the export formats in dialog titles
+var exportTitles = map[string]string{
+	cstruct.FormatC:      "C Header",
+	cstruct.FormatGhidra: "C for Ghidra",
+	cstruct.FormatCtypes: "Python ctypes",
+}
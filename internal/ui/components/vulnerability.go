@@ -0,0 +1,296 @@
+// Package components provides UI components for the RevEnGo application.
+// This file contains the fields of vulnerability notes: classification,
+// CVSS score, affected versions and the status workflow.
+package components
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/leog/RevEnGo/internal/models"
+	apptheme "github.com/leog/RevEnGo/internal/ui/theme"
+	"github.com/leog/RevEnGo/internal/ui/widgets"
+	"github.com/leog/RevEnGo/internal/vuln"
+)
+
+// vulnerabilityForm edits the fields of a vulnerability note, naming the
+// weakness and scoring the CVSS vector as the user types
+type vulnerabilityForm struct {
+	cwe     *widgets.ShortcutEntry
+	cweName *widgets.ThemedText
+
+	cvssVector *widgets.ShortcutEntry
+	score      *widgets.ThemedText
+
+	affectedBinary   *widgets.ShortcutEntry
+	affectedVersions *widgets.ShortcutEntry
+
+	status *widget.Select
+
+	// current is the status selected last, which a new selection must be
+	// reachable from
+	current string
+
+	// dates are the days each status was reached, by status
+	dates map[string]*widgets.ShortcutEntry
+
+	// loading is set while a note is loaded, so that selecting its status
+	// records no date
+	loading bool
+
+	content fyne.CanvasObject
+}
+
+// SeverityColor returns the theme color that shows a severity
+func SeverityColor(severity vuln.Severity) fyne.ThemeColorName {
+	switch severity {
+	case vuln.SeverityCritical, vuln.SeverityHigh:
+		return theme.ColorNameError
+	case vuln.SeverityMedium:
+		return theme.ColorNameWarning
+	case vuln.SeverityLow:
+		return apptheme.ColorNameTerminalText
+	}
+	return theme.ColorNamePlaceHolder
+}
+
+// newVulnerabilityForm creates the vulnerability fields.
+//
+// Parameters:
+//   - changed: Called with the new text when the user edits a field
+func newVulnerabilityForm(changed func(string)) *vulnerabilityForm {
+	v := &vulnerabilityForm{dates: make(map[string]*widgets.ShortcutEntry)}
+	entry := func(placeHolder string, update func()) *widgets.ShortcutEntry {
+		e := widgets.NewShortcutEntry()
+		e.SetPlaceHolder(placeHolder)
+		e.TextStyle = fyne.TextStyle{Monospace: true}
+		e.OnChanged = func(text string) {
+			if update != nil {
+				update()
+			}
+			changed(text)
+		}
+		return e
+	}
+	status := func() *widgets.ThemedText {
+		t := widgets.NewThemedText("", apptheme.ColorNameTerminalText)
+		t.TextStyle = fyne.TextStyle{Monospace: true}
+		t.TextSize = 12
+		return t
+	}
+
+	v.cwe = entry("CWE-787", v.updateCWE)
+	v.cwe.Validator = func(text string) error {
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		_, err := vuln.ParseCWE(text)
+		return err
+	}
+	v.cweName = status()
+	cweButton := widget.NewButtonWithIcon("", theme.SearchIcon(), v.pickCWE)
+
+	v.cvssVector = entry("CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H or CVSS:4.0/...", v.updateScore)
+	v.score = status()
+
+	v.affectedBinary = entry("Affected binary, if not the note's binary", nil)
+	v.affectedVersions = widgets.NewMultiLineShortcutEntry()
+	v.affectedVersions.SetPlaceHolder("Affected versions, one range per line (e.g., >= 2.0, < 2.4.1 or 1.0 - 1.3)")
+	v.affectedVersions.SetMinRowsVisible(3)
+	v.affectedVersions.TextStyle = fyne.TextStyle{Monospace: true}
+	v.affectedVersions.OnChanged = changed
+	v.affectedVersions.Validator = func(text string) error {
+		for _, line := range splitLines(text) {
+			if _, err := vuln.ParseVersionRange(line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	dates := container.NewGridWithColumns(3)
+	for _, s := range models.VulnStatuses {
+		date := entry(models.VulnDateLayout, nil)
+		date.Validator = func(text string) error {
+			if text = strings.TrimSpace(text); text == "" {
+				return nil
+			}
+			if _, err := time.Parse(models.VulnDateLayout, text); err != nil {
+				return fmt.Errorf("expected a date such as 2024-03-01")
+			}
+			return nil
+		}
+		v.dates[s] = date
+		label := strings.ToUpper(strings.ReplaceAll(s, "_", " ")) + ":"
+		dates.Add(container.NewBorder(nil, nil, createTerminalLabel(label), nil, date))
+	}
+
+	v.status = widget.NewSelect(models.VulnStatuses, func(status string) {
+		if !v.loading {
+			if err := models.CheckVulnTransition(v.current, status); err != nil {
+				v.selectStatus(v.current)
+				if win := windowFor(v.content); win != nil {
+					dialog.ShowError(err, win)
+				}
+				return
+			}
+			// Reaching a status records the day, unless one is set already
+			if date := v.dates[status]; date != nil && strings.TrimSpace(date.Text) == "" {
+				date.SetText(time.Now().Format(models.VulnDateLayout))
+			}
+		}
+		v.current = status
+		changed(status)
+	})
+	v.status.PlaceHolder = "(status)"
+
+	row := func(label string, field fyne.CanvasObject) fyne.CanvasObject {
+		return container.NewBorder(nil, nil, createTerminalLabel(label), nil, field)
+	}
+	v.content = container.NewVBox(
+		container.NewBorder(nil, nil, createTerminalLabel("CWE:"), cweButton, v.cwe),
+		v.cweName,
+		row("CVSS:", v.cvssVector),
+		v.score,
+		container.NewGridWithColumns(2,
+			row("STATUS:", v.status),
+			row("BINARY:", v.affectedBinary),
+		),
+		dates,
+		createTerminalLabel("AFFECTED:"),
+		v.affectedVersions,
+	)
+	v.updateCWE()
+	v.updateScore()
+	return v
+}
+
+// load shows a note's vulnerability fields
+func (v *vulnerabilityForm) load(details *models.VulnerabilityDetails) {
+	if details == nil {
+		details = &models.VulnerabilityDetails{}
+	}
+	v.loading = true
+	defer func() { v.loading = false }()
+
+	v.cwe.SetText(details.CWE)
+	v.cvssVector.SetText(details.CVSSVector)
+	v.affectedBinary.SetText(details.AffectedBinary)
+	v.affectedVersions.SetText(strings.Join(details.AffectedVersions, "\n"))
+	for status, date := range v.dates {
+		date.SetText(details.StatusDates[status])
+	}
+	v.selectStatus(details.Status)
+}
+
+// selectStatus shows a status without checking it or recording its day
+func (v *vulnerabilityForm) selectStatus(status string) {
+	loading := v.loading
+	v.loading = true
+	defer func() { v.loading = loading }()
+
+	if status == "" {
+		v.status.ClearSelected()
+	} else {
+		v.status.SetSelected(status)
+	}
+	v.current = status
+}
+
+// details returns the vulnerability fields as a note stores them
+func (v *vulnerabilityForm) details() *models.VulnerabilityDetails {
+	details := &models.VulnerabilityDetails{
+		CWE:              strings.ToUpper(strings.TrimSpace(v.cwe.Text)),
+		CVSSVector:       strings.TrimSpace(v.cvssVector.Text),
+		AffectedBinary:   strings.TrimSpace(v.affectedBinary.Text),
+		AffectedVersions: splitLines(v.affectedVersions.Text),
+		Status:           v.status.Selected,
+	}
+	for status, date := range v.dates {
+		if text := strings.TrimSpace(date.Text); text != "" {
+			if details.StatusDates == nil {
+				details.StatusDates = make(map[string]string)
+			}
+			details.StatusDates[status] = text
+		}
+	}
+	return details
+}
+
+// updateCWE names the weakness entered
+func (v *vulnerabilityForm) updateCWE() {
+	text := strings.TrimSpace(v.cwe.Text)
+	if text == "" {
+		setThemedText(v.cweName, "Enter a CWE identifier or search the catalog", apptheme.ColorNameTerminalText)
+		return
+	}
+	cwe, err := vuln.ParseCWE(text)
+	switch {
+	case err != nil:
+		setThemedText(v.cweName, "ERROR: "+err.Error(), theme.ColorNameError)
+	case cwe.Name == "":
+		setThemedText(v.cweName, cwe.Ref()+" is not in the bundled catalog", apptheme.ColorNameTerminalText)
+	default:
+		setThemedText(v.cweName, cwe.Name, apptheme.ColorNameTerminalText)
+	}
+}
+
+// updateScore scores the CVSS vector entered
+func (v *vulnerabilityForm) updateScore() {
+	if strings.TrimSpace(v.cvssVector.Text) == "" {
+		setThemedText(v.score, "Enter a CVSS v3.1 or v4.0 vector to score the finding", apptheme.ColorNameTerminalText)
+		return
+	}
+	score, err := vuln.ScoreVector(v.cvssVector.Text)
+	if err != nil {
+		setThemedText(v.score, "ERROR: "+err.Error(), theme.ColorNameError)
+		return
+	}
+	setThemedText(v.score, "SCORE: "+score.String(), SeverityColor(score.Severity))
+}
+
+// pickCWE searches the bundled CWE catalog and fills in the weakness chosen
+func (v *vulnerabilityForm) pickCWE() {
+	win := windowFor(v.content)
+	if win == nil {
+		return
+	}
+	results := vuln.CWECatalog()
+	search := widgets.NewShortcutEntry()
+	search.SetPlaceHolder("Search by ID or name, e.g. 787 or use after free")
+	var picker dialog.Dialog
+	list := widget.NewList(
+		func() int { return len(results) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(results[id].String())
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		v.cwe.SetText(results[id].Ref())
+		picker.Hide()
+	}
+	search.OnChanged = func(query string) {
+		results = vuln.SearchCWE(query)
+		list.UnselectAll()
+		list.Refresh()
+	}
+	picker = dialog.NewCustom("CWE Catalog", "Cancel", container.NewBorder(search, nil, nil, nil, list), win)
+	picker.Resize(fyne.NewSize(720, 480))
+	picker.Show()
+	win.Canvas().Focus(search)
+}
+
+// setThemedText shows text in a color
+func setThemedText(t *widgets.ThemedText, text string, color fyne.ThemeColorName) {
+	t.Text = text
+	t.ColorName = color
+	t.Refresh()
+}
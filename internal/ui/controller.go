@@ -3,76 +3,615 @@
 package ui
 
 import (
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
+	"github.com/leog/RevEnGo/internal/config"
 	"github.com/leog/RevEnGo/internal/models"
 	"github.com/leog/RevEnGo/internal/ui/components"
+	"github.com/leog/RevEnGo/internal/ui/highlight"
+	"github.com/leog/RevEnGo/internal/ui/markdown"
 )
 
 // NoteController manages operations related to notes
 type NoteController struct {
 	noteStore models.NoteStore
 	window    fyne.Window
-	notepad   fyne.CanvasObject
+	notepad   *components.NotePad
 	sidebar   fyne.CanvasObject
 
+	// projectStore lists the projects offered by quick-open; may be nil
+	projectStore models.ProjectStore
+
+	// jobs tracks background work such as autosave
+	jobs *Jobs
+
+	// feedback reports the outcome of saves, deletes and failures
+	feedback *Feedback
+
+	// OnStatusChanged is called when state shown in the status area changes
+	OnStatusChanged func()
+
+	// TemplateDir holds the user's note templates; empty offers only the
+	// built-in ones
+	TemplateDir string
+
+	// ReportDir holds the user's report templates; empty offers only the
+	// built-in ones
+	ReportDir string
+
+	// reportRedact names the fields marked internal, which reports redact
+	reportRedact []string
+
+	// captures is the open packet capture browser, if any
+	captures *captureBrowser
+
+	// binaries is the open browser of the strings of binaries, if any
+	binaries *stringsBrowser
+
+	// editorTextSize is the content text size of new pop-outs; 0 uses the theme's
+	editorTextSize float32
+
+	// stopAutosave stops the autosave timer; nil when autosave is off
+	stopAutosave chan struct{}
+
+	// switchMu is held while the main notepad changes to another note, and
+	// while a notepad's note and contents are read to be saved, so the
+	// contents of one note are never saved under the ID of another
+	switchMu sync.Mutex
+
+	// mu guards the fields below, which the main window and pop-outs share
+	// while each handles its events on its own goroutine. It is not held
+	// while widgets are updated, as their callbacks come back to the
+	// controller.
+	mu sync.Mutex
+
 	// Currently loaded note ID (empty if creating a new note)
 	currentNoteID string
+
+	// popouts are the notes currently open in separate windows
+	popouts []*noteWindow
+
+	// findings is the open vulnerability dashboard, if any
+	findings *findingsDashboard
+
+	// notes is the most recently loaded list of notes
+	notes []*models.Note
+
+	// notesList shows notes in the sidebar; nil while there are no notes
+	notesList *widget.List
+
+	// symbols resolves addresses and names in code blocks to notes
+	symbols *models.SymbolIndex
+
+	// loaded records the modification time of the stored version of each
+	// open note, so changes made elsewhere can be told apart from our own
+	loaded map[string]time.Time
+
+	// edited records open notes with unsaved edits
+	edited map[string]bool
+
+	// lastAutosave is when autosave last saved a note
+	lastAutosave time.Time
+
+	// storeErr is the error of the last failed store operation; nil while
+	// the store is healthy
+	storeErr error
+}
+
+// noteWindow is a note popped out of the main window into its own window
+type noteWindow struct {
+	window  fyne.Window
+	notepad *components.NotePad
+	noteID  string
+}
+
+// NewNoteController creates a new controller for note operations.
+//
+// Parameters:
+//   - noteStore: The store holding the notes
+//   - projectStore: The store holding the projects; may be nil
+//   - jobs: Tracks background work done by the controller
+//   - feedback: Reports the outcome of operations
+//   - window: The main window
+//   - notepad: The notepad of the main window
+//   - sidebar: The sidebar listing the notes
+func NewNoteController(noteStore models.NoteStore, projectStore models.ProjectStore, jobs *Jobs, feedback *Feedback, window fyne.Window, notepad *components.NotePad, sidebar fyne.CanvasObject) *NoteController {
+	c := &NoteController{
+		noteStore:    noteStore,
+		projectStore: projectStore,
+		jobs:         jobs,
+		feedback:     feedback,
+		window:       window,
+		notepad:      notepad,
+		sidebar:      sidebar,
+		loaded:       make(map[string]time.Time),
+		edited:       make(map[string]bool),
+	}
+
+	// Propagate edits in the main notepad to any pop-out showing the same note
+	notepad.OnChanged = func() {
+		noteID := c.current()
+		c.markEdited(noteID)
+		c.syncFrom(notepad, noteID)
+		c.statusChanged()
+	}
+	c.configureNotePad(notepad)
+
+	return c
+}
+
+// configureNotePad connects a notepad's rendering hooks to the controller,
+// so that known addresses and symbols in code blocks open their notes
+func (c *NoteController) configureNotePad(notepad *components.NotePad) {
+	highlighter := &highlight.Highlighter{
+		Resolve: func(word string) bool {
+			_, ok := c.symbolIndex().Lookup(word)
+			return ok
+		},
+		OnTapped: func(word string) {
+			if id, ok := c.symbolIndex().Lookup(word); ok {
+				c.LoadNote(id)
+			}
+		},
+	}
+	notepad.Markdown.Highlight = highlighter.Highlight
+	notepad.Markdown.OnLinkTapped = c.openLink
+	notepad.LinkCandidates = c.linkCandidates
+	notepad.OnOpenNote = func(noteID string) {
+		c.LoadNote(noteID)
+	}
+	notepad.Templates = c.templatesFor
+	notepad.OnShowCaptures = c.ShowCaptures
+	notepad.Disassemble = c.disassemble
 }
 
-// NewNoteController creates a new controller for note operations
-func NewNoteController(noteStore models.NoteStore, window fyne.Window, notepad fyne.CanvasObject, sidebar fyne.CanvasObject) *NoteController {
-	return &NoteController{
-		noteStore: noteStore,
-		window:    window,
-		notepad:   notepad,
-		sidebar:   sidebar,
+// templatesFor returns the templates of a note type. User templates are
+// read on every call so that new template files are picked up at once.
+func (c *NoteController) templatesFor(noteType string) []models.Template {
+	templates, err := models.LoadTemplates(c.TemplateDir)
+	if err != nil {
+		log.Printf("Warning: reading note templates: %v", err)
 	}
+	return models.TemplatesFor(templates, noteType)
+}
+
+// openLink follows a link tapped in a rendered note.
+// Note links open the linked note; other links open in the browser.
+func (c *NoteController) openLink(destination string) {
+	if !strings.HasPrefix(destination, markdown.NoteLinkScheme) {
+		if u, err := url.Parse(destination); err == nil {
+			fyne.CurrentApp().OpenURL(u)
+		}
+		return
+	}
+
+	target := strings.TrimPrefix(destination, markdown.NoteLinkScheme)
+	if note := models.FindNoteByLink(target, c.Notes()); note != nil {
+		c.LoadNote(note.ID)
+		return
+	}
+
+	// Offer to create notes for links that do not resolve yet
+	dialog.ShowConfirm("Create Note", fmt.Sprintf("No note named %q exists. Create it?", target), func(confirmed bool) {
+		if confirmed {
+			c.CreateNewNote()
+			c.notepad.TitleEntry.SetText(target)
+		}
+	}, c.window)
+}
+
+// linkCandidates returns note titles matching a partially typed link.
+// Titles starting with the query are listed before other matches.
+func (c *NoteController) linkCandidates(query string) []string {
+	query = strings.ToLower(strings.TrimSpace(query))
+	var prefixed, contained []string
+	for _, note := range c.Notes() {
+		title := strings.ToLower(note.Title)
+		switch {
+		case strings.HasPrefix(title, query):
+			prefixed = append(prefixed, note.Title)
+		case strings.Contains(title, query):
+			contained = append(contained, note.Title)
+		}
+	}
+	sort.Strings(prefixed)
+	sort.Strings(contained)
+	return append(prefixed, contained...)
 }
 
 // CreateNewNote initializes the notepad for creating a new note
 func (c *NoteController) CreateNewNote() {
+	c.switchMu.Lock()
+
 	// Clear the current note ID
+	c.mu.Lock()
 	c.currentNoteID = ""
+	delete(c.edited, "")
+	c.mu.Unlock()
 
 	// Clear the notepad
-	components.ClearNotepad(c.notepad.(*fyne.Container))
+	c.notepad.Clear()
+	c.switchMu.Unlock()
+	c.statusChanged()
+}
+
+// Status describes the state of the workspace for the status area
+func (c *NoteController) Status() components.HeaderStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	unsaved := 0
+	for id, edited := range c.edited {
+		if edited && c.isOpenLocked(id) {
+			unsaved++
+		}
+	}
+	return components.HeaderStatus{
+		StoreError:     c.storeErr,
+		Unsaved:        unsaved,
+		LastAutosave:   c.lastAutosave,
+		IndexedNotes:   len(c.notes),
+		IndexedSymbols: c.symbols.Len(),
+		JobProgress:    -1,
+	}
+}
+
+// statusChanged reports a change of status to OnStatusChanged
+func (c *NoteController) statusChanged() {
+	if c.OnStatusChanged != nil {
+		c.OnStatusChanged()
+	}
+}
+
+// ApplySettings applies the user's preferences to every open notepad
+// and restarts autosave with the configured interval.
+//
+// Parameters:
+//   - settings: The preferences to apply
+//   - defaultProject: The ID of the project new notes are filed under
+func (c *NoteController) ApplySettings(settings config.Settings, defaultProject string) {
+	c.editorTextSize = settings.EditorFontSize
+	c.reportRedact = settings.ReportRedact
+	c.notepad.DefaultNoteType = settings.DefaultNoteType
+	c.notepad.DefaultProjectID = defaultProject
+	c.notepad.SetEditorTextSize(c.editorTextSize)
+	for _, pw := range c.openPopOuts() {
+		pw.notepad.SetEditorTextSize(c.editorTextSize)
+	}
+	c.setAutosave(settings.AutosaveInterval())
+}
+
+// setAutosave saves edited notes periodically, replacing any previous
+// autosave timer. An interval of 0 turns autosave off.
+func (c *NoteController) setAutosave(interval time.Duration) {
+	if c.stopAutosave != nil {
+		close(c.stopAutosave)
+		c.stopAutosave = nil
+	}
+	if interval <= 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	c.stopAutosave = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				// Saving reads and reloads notepads, so it runs with the
+				// window's events rather than on the ticker's goroutine
+				runOnUI(c.window, func() {
+					select {
+					case <-stop:
+						// Turned off while queued
+					default:
+						c.autosave()
+					}
+				})
+			}
+		}
+	}()
+}
+
+// autosave quietly saves every open note with unsaved edits.
+// New notes are saved once they have a title.
+func (c *NoteController) autosave() {
+	c.mu.Lock()
+	var pending []*noteWindow
+	if c.edited[c.currentNoteID] {
+		pending = append(pending, &noteWindow{notepad: c.notepad, noteID: c.currentNoteID})
+	}
+	for _, pw := range c.popouts {
+		if c.edited[pw.noteID] {
+			pending = append(pending, pw)
+		}
+	}
+	c.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	job := c.jobs.Start("Autosaving")
+	defer job.Done()
+	for i, target := range pending {
+		noteID, data := c.contents(target.notepad, target.noteID)
+		if !c.isEdited(noteID) {
+			// Saved or reloaded since the tick
+			continue
+		}
+		id, err := c.storeNote(data, noteID)
+		switch {
+		case err != nil:
+			log.Printf("Warning: autosave failed: %v", err)
+			c.feedback.RecordError("Autosaving note", err)
+		case id != "":
+			c.mu.Lock()
+			c.lastAutosave = time.Now()
+			c.mu.Unlock()
+			c.feedback.Record(ActivitySave, fmt.Sprintf("Autosaved %q", data.Title))
+			if target.notepad == c.notepad {
+				c.savedCurrent(noteID, id)
+			}
+		}
+		job.SetProgress(float64(i+1) / float64(len(pending)))
+	}
+	c.statusChanged()
+}
+
+// Close stops autosave and closes every pop-out window
+func (c *NoteController) Close() {
+	c.setAutosave(0)
+	c.CloseAllPopOuts()
 }
 
 // SaveCurrentNote saves the current content of the notepad
 func (c *NoteController) SaveCurrentNote() error {
-	// Extract data from the notepad
-	data := components.GetNoteData(c.notepad.(*fyne.Container))
+	_, err := c.saveFrom(c.notepad, "", c.window)
+	return err
+}
+
+// saveFrom saves the contents of a notepad under the given note ID; the
+// main notepad is saved under the note it shows. It returns the ID of the
+// saved note, or an empty ID if nothing was saved.
+func (c *NoteController) saveFrom(notepad *components.NotePad, noteID string, parent fyne.Window) (string, error) {
+	noteID, data := c.contents(notepad, noteID)
 
 	// Validate data
 	if data.Title == "" {
-		dialog.ShowInformation("Missing Information", "Please provide a title for your note.", c.window)
-		return nil
+		dialog.ShowInformation("Missing Information", "Please provide a title for your note.", parent)
+		return "", nil
 	}
 
-	// Convert to a Note model
-	note := components.ConvertToNote(data, c.currentNoteID)
-
-	// Save the note
-	err := c.noteStore.SaveNote(note)
+	id, err := c.storeNote(data, noteID)
 	if err != nil {
-		dialog.ShowError(err, c.window)
-		return err
+		c.feedback.Error(parent, "Saving note", err, func() {
+			if notepad == c.notepad {
+				c.SaveCurrentNote()
+			} else {
+				c.saveFrom(notepad, noteID, parent)
+			}
+		})
+		return "", err
 	}
 
 	// Update current note ID
-	c.currentNoteID = note.ID
+	if notepad == c.notepad {
+		c.savedCurrent(noteID, id)
+	}
+
+	c.feedback.Success(ActivitySave, fmt.Sprintf("Saved %q", data.Title))
+
+	return id, nil
+}
+
+// contents reads the note a notepad shows and its contents together.
+//
+// Parameters:
+//   - notepad: The notepad
+//   - noteID: The note of a pop-out; ignored for the main notepad, which
+//     shows the current note
+//
+// Returns:
+//   - The ID of the note; empty for a new note
+//   - The contents of the notepad
+func (c *NoteController) contents(notepad *components.NotePad, noteID string) (string, components.NotePadData) {
+	c.switchMu.Lock()
+	defer c.switchMu.Unlock()
+	if notepad == c.notepad {
+		noteID = c.current()
+	}
+	return noteID, notepad.GetNoteData()
+}
+
+// savedCurrent records the ID the note of the main notepad was saved
+// under, unless another note was opened while it was being saved
+func (c *NoteController) savedCurrent(noteID, id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.currentNoteID == noteID {
+		c.currentNoteID = id
+	}
+}
+
+// storeNote saves the contents of a notepad without showing any dialogs.
+// It returns the ID of the saved note, or an empty ID if the note has no
+// title and was not saved.
+func (c *NoteController) storeNote(data components.NotePadData, noteID string) (string, error) {
+	if data.Title == "" {
+		return "", nil
+	}
+
+	// Convert to a Note model
+	note := components.ConvertToNote(data, noteID)
+
+	// Remember the previous title so links to a renamed note can be updated,
+	// and keep the original creation time
+	oldTitle := ""
+	if noteID != "" {
+		if previous, err := c.noteStore.GetNote(noteID); err == nil {
+			oldTitle = previous.Title
+			note.Created = previous.Created
+		}
+	}
+
+	// Save the note, keeping [[links]] consistent with other notes
+	renamed, err := models.SaveLinkedNote(c.noteStore, note, oldTitle)
+	for _, other := range renamed {
+		c.reloadOpenNote(other)
+	}
+	if err != nil {
+		c.mu.Lock()
+		c.storeErr = err
+		c.mu.Unlock()
+		c.statusChanged()
+		return "", err
+	}
+	c.mu.Lock()
+	c.loaded[note.ID] = note.Modified
+	delete(c.edited, noteID)
+	c.mu.Unlock()
 
 	// Refresh the sidebar
 	c.RefreshNoteList()
 
-	// Show success message
-	dialog.ShowInformation("Note Saved", "Your note has been saved successfully.", c.window)
+	return note.ID, nil
+}
 
-	return nil
+// reloadOpenNote refreshes any notepad showing a note changed behind its back
+func (c *NoteController) reloadOpenNote(note *models.Note) {
+	c.switchMu.Lock()
+	defer c.switchMu.Unlock()
+	c.mu.Lock()
+	c.loaded[note.ID] = note.Modified
+	delete(c.edited, note.ID)
+	current := c.currentNoteID == note.ID
+	c.mu.Unlock()
+
+	data := components.ConvertFromNote(note)
+	if current {
+		c.notepad.LoadNoteData(data)
+	}
+	for _, pw := range c.openPopOuts() {
+		if pw.noteID == note.ID {
+			pw.notepad.LoadNoteData(data)
+		}
+	}
+}
+
+// PopOutNote opens the current note in a separate window.
+// Edits made in either window are synchronized through the controller.
+func (c *NoteController) PopOutNote() {
+	noteID := c.current()
+	if noteID == "" {
+		dialog.ShowInformation("Note Not Saved", "Please save the note before opening it in a new window.", c.window)
+		return
+	}
+
+	pw := &noteWindow{
+		window:  fyne.CurrentApp().NewWindow("RevEnGo - " + c.notepad.TitleEntry.Text),
+		notepad: components.NewNotePad(),
+		noteID:  noteID,
+	}
+
+	// Start from the main notepad so unsaved edits carry over
+	c.configureNotePad(pw.notepad)
+	pw.notepad.SetEditorTextSize(c.editorTextSize)
+	pw.notepad.LoadNoteData(c.notepad.GetNoteData())
+	pw.notepad.SetBacklinks(models.Backlinks(pw.noteID, c.Notes()))
+	pw.notepad.OnChanged = func() {
+		c.markEdited(pw.noteID)
+		c.syncFrom(pw.notepad, pw.noteID)
+		pw.window.SetTitle("RevEnGo - " + pw.notepad.TitleEntry.Text)
+		c.statusChanged()
+	}
+
+	toolbar := widget.NewToolbar(
+		widget.NewToolbarAction(theme.DocumentSaveIcon(), func() {
+			c.saveFrom(pw.notepad, pw.noteID, pw.window)
+		}),
+	)
+
+	pw.window.SetContent(container.NewBorder(toolbar, nil, nil, nil, pw.notepad))
+	pw.window.Resize(fyne.NewSize(800, 700))
+	pw.window.SetOnClosed(func() {
+		c.removePopOut(pw)
+	})
+
+	c.mu.Lock()
+	c.popouts = append(c.popouts, pw)
+	c.mu.Unlock()
+	pw.window.Show()
+}
+
+// syncFrom copies the contents of the notepad that was edited into every
+// other notepad showing the same note. Each copy is made with the events of
+// the other notepad's window and only sets the fields that differ, so the
+// cursor and selection there are kept. Notepads being synced do not report
+// the change as an edit, so copies do not bounce back.
+func (c *NoteController) syncFrom(source *components.NotePad, noteID string) {
+	if noteID == "" {
+		return
+	}
+	data := source.GetNoteData()
+	if source != c.notepad {
+		runOnUI(c.window, func() {
+			// The main notepad may have moved to another note meanwhile
+			if c.current() == noteID {
+				c.notepad.SyncNoteData(data)
+			}
+		})
+	}
+	for _, pw := range c.openPopOuts() {
+		if pw.notepad != source && pw.noteID == noteID {
+			runOnUI(pw.window, func() {
+				pw.notepad.SyncNoteData(data)
+			})
+		}
+	}
+}
+
+// removePopOut forgets a pop-out window once it has been closed
+func (c *NoteController) removePopOut(target *noteWindow) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, pw := range c.popouts {
+		if pw == target {
+			c.popouts = append(c.popouts[:i], c.popouts[i+1:]...)
+			return
+		}
+	}
+}
+
+// closePopOuts closes every pop-out window showing the given note
+func (c *NoteController) closePopOuts(noteID string) {
+	for _, pw := range c.openPopOuts() {
+		if pw.noteID == noteID {
+			pw.window.Close()
+		}
+	}
+}
+
+// CloseAllPopOuts closes every pop-out window
+func (c *NoteController) CloseAllPopOuts() {
+	for _, pw := range c.openPopOuts() {
+		pw.window.Close()
+	}
 }
 
 // LoadNote loads a note into the notepad
@@ -80,51 +619,349 @@ func (c *NoteController) LoadNote(noteID string) error {
 	// Load the note from storage
 	note, err := c.noteStore.GetNote(noteID)
 	if err != nil {
-		dialog.ShowError(err, c.window)
+		c.feedback.Error(nil, "Opening note", err, func() {
+			c.LoadNote(noteID)
+		})
 		return err
 	}
 
 	// Convert to NotePadData
 	data := components.ConvertFromNote(note)
 
-	// Load data into the notepad
-	components.LoadNoteData(c.notepad.(*fyne.Container), data)
-
-	// Update current note ID
+	// Load data into the notepad and update current note ID
+	c.switchMu.Lock()
+	c.mu.Lock()
 	c.currentNoteID = noteID
+	c.loaded[noteID] = note.Modified
+	delete(c.edited, noteID)
+	c.mu.Unlock()
+	c.notepad.LoadNoteData(data)
+	c.switchMu.Unlock()
+	c.notepad.SetBacklinks(models.Backlinks(noteID, c.Notes()))
+	c.statusChanged()
 
 	return nil
 }
 
 // DeleteNote deletes the current note
 func (c *NoteController) DeleteNote() error {
-	if c.currentNoteID == "" {
+	noteID, data := c.contents(c.notepad, "")
+	if noteID == "" {
 		dialog.ShowInformation("No Note Selected", "Please select a note to delete.", c.window)
 		return nil
 	}
 
 	// Confirm deletion
+	title := data.Title
 	dialog.ShowConfirm("Delete Note", "Are you sure you want to delete this note?", func(confirmed bool) {
 		if confirmed {
-			// Delete the note
-			err := c.noteStore.DeleteNote(c.currentNoteID)
-			if err != nil {
-				dialog.ShowError(err, c.window)
+			c.removeNote(noteID, title)
+		}
+	}, c.window)
+
+	return nil
+}
+
+// removeNote deletes a note from the store and closes it wherever it is open
+func (c *NoteController) removeNote(noteID, title string) {
+	// Delete the note
+	err := c.noteStore.DeleteNote(noteID)
+	if err != nil {
+		c.feedback.Error(nil, "Deleting note", err, func() {
+			c.removeNote(noteID, title)
+		})
+		return
+	}
+
+	// Close any windows still showing the deleted note
+	c.closePopOuts(noteID)
+
+	// Clear the notepad
+	if c.current() == noteID {
+		c.CreateNewNote()
+	}
+
+	// Refresh the sidebar
+	c.RefreshNoteList()
+
+	c.feedback.Success(ActivityDelete, fmt.Sprintf("Deleted %q", title))
+}
+
+// Notes returns the most recently loaded list of notes
+func (c *NoteController) Notes() []*models.Note {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.notes
+}
+
+// ShowNote loads a note into the notepad and selects it in the sidebar
+func (c *NoteController) ShowNote(noteID string) {
+	if c.LoadNote(noteID) != nil {
+		return
+	}
+	c.mu.Lock()
+	notes, notesList := c.notes, c.notesList
+	c.mu.Unlock()
+	for i, note := range notes {
+		if note.ID == noteID && notesList != nil {
+			notesList.Select(i)
+		}
+	}
+}
+
+// ShowAdjacentNote opens the note after or before the current one in the
+// sidebar, wrapping around at the ends. Without a current note the first
+// or last note is opened.
+//
+// Parameters:
+//   - step: 1 for the next note, -1 for the previous one
+func (c *NoteController) ShowAdjacentNote(step int) {
+	notes, current := c.Notes(), c.current()
+	if len(notes) == 0 {
+		return
+	}
+
+	next := -1
+	for i, note := range notes {
+		if note.ID == current {
+			next = (i + step + len(notes)) % len(notes)
+		}
+	}
+	if next < 0 {
+		next = 0
+		if step < 0 {
+			next = len(notes) - 1
+		}
+	}
+	c.ShowNote(notes[next].ID)
+}
+
+// ShowSearch opens a search over the titles, content and fields of every note
+func (c *NoteController) ShowSearch() {
+	showPicker(c.window, "Search Notes", "Search titles, content, tags, addresses...", func(query string) []paletteItem {
+		return c.noteItems(models.SearchNotes(c.Notes(), query), "")
+	})
+}
+
+// ShowQuickOpen opens a picker across notes, projects and binaries.
+// Picking a project or binary lists the notes filed under it.
+func (c *NoteController) ShowQuickOpen() {
+	allNotes := c.Notes()
+	items := c.noteItems(allNotes, "Note: ")
+
+	if c.projectStore != nil {
+		projects, err := c.projectStore.ListProjects()
+		if err != nil {
+			log.Printf("Warning: listing projects for quick-open: %v", err)
+		}
+		for _, project := range projects {
+			notes := models.FilterNotes(allNotes, models.NoteFilter{ProjectID: project.ID})
+			title := "Project: " + project.Name
+			items = append(items, paletteItem{
+				Label:  title,
+				Detail: fmt.Sprintf("%d notes", len(notes)),
+				Run:    func() { c.pickNote(title, notes) },
+			})
+		}
+	}
+
+	binaries := make(map[string][]*models.Note)
+	var names []string
+	for _, note := range allNotes {
+		if note.BinaryName == "" {
+			continue
+		}
+		if binaries[note.BinaryName] == nil {
+			names = append(names, note.BinaryName)
+		}
+		binaries[note.BinaryName] = append(binaries[note.BinaryName], note)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		notes := binaries[name]
+		title := "Binary: " + name
+		items = append(items, paletteItem{
+			Label:  title,
+			Detail: fmt.Sprintf("%d notes", len(notes)),
+			Run:    func() { c.pickNote(title, notes) },
+		})
+	}
+
+	showPicker(c.window, "Open", "Type a note, project or binary name", func(query string) []paletteItem {
+		return fuzzyFilter(items, query)
+	})
+}
+
+// pickNote opens a picker over some notes
+func (c *NoteController) pickNote(title string, notes []*models.Note) {
+	items := c.noteItems(notes, "")
+	showPicker(c.window, title, "Type a note title", func(query string) []paletteItem {
+		return fuzzyFilter(items, query)
+	})
+}
+
+// noteItems makes picker choices that open notes.
+//
+// Parameters:
+//   - notes: The notes offered
+//   - prefix: Text put before each note title
+func (c *NoteController) noteItems(notes []*models.Note, prefix string) []paletteItem {
+	items := make([]paletteItem, 0, len(notes))
+	for _, note := range notes {
+		id := note.ID
+		items = append(items, paletteItem{
+			Label:  prefix + note.Title,
+			Detail: firstNonEmpty(note.BinaryName, note.ReverseEngType),
+			Run:    func() { c.ShowNote(id) },
+		})
+	}
+	return items
+}
+
+// ShowGoToAddress asks for an address or symbol and opens the note that
+// describes it: the note with the narrowest address range containing the
+// address, or the note naming the symbol.
+func (c *NoteController) ShowGoToAddress() {
+	addressEntry := widget.NewEntry()
+	addressEntry.SetPlaceHolder("0x401000 or symbol")
+
+	form := dialog.NewForm("Go to Address", "Go", "Cancel",
+		[]*widget.FormItem{widget.NewFormItem("Address", addressEntry)},
+		func(confirmed bool) {
+			target := strings.TrimSpace(addressEntry.Text)
+			if !confirmed || target == "" {
+				return
+			}
+			if id, ok := c.symbolIndex().Lookup(target); ok {
+				c.ShowNote(id)
 				return
 			}
+			dialog.ShowInformation("Go to Address", fmt.Sprintf("No note covers %s.", target), c.window)
+		}, c.window)
+	addressEntry.OnSubmitted = func(string) {
+		form.Submit()
+	}
+	form.Resize(fyne.NewSize(360, 0))
+	form.Show()
+	c.window.Canvas().Focus(addressEntry)
+}
 
-			// Clear the notepad
+// WatchChanges keeps the window in step with notes changed elsewhere, for
+// example through the HTTP API, the command line or another RevEnGo window.
+//
+// Parameters:
+//   - changes: The feed reporting store changes
+//
+// Returns:
+//   - An error if the feed cannot be subscribed to
+func (c *NoteController) WatchChanges(changes *models.ChangeFeed) error {
+	events, _, err := changes.Subscribe()
+	if err != nil {
+		return err
+	}
+	go func() {
+		for event := range events {
+			if event.Kind == models.ChangeNote {
+				runOnUI(c.window, func() { c.applyChange(event) })
+			}
+		}
+	}()
+	return nil
+}
+
+// applyChange refreshes the note list after a note changed in storage, and
+// reloads the note if it is open. Open notes with unsaved edits are only
+// reloaded after confirmation.
+func (c *NoteController) applyChange(event models.ChangeEvent) {
+	c.recordImport(event)
+	c.RefreshNoteList()
+	if !c.isOpen(event.ID) {
+		return
+	}
+
+	if event.Action == models.ChangeDeleted {
+		if c.isEdited(event.ID) {
+			// Keep unsaved work; saving will restore the note
+			return
+		}
+		c.closePopOuts(event.ID)
+		if c.current() == event.ID {
 			c.CreateNewNote()
+		}
+		return
+	}
 
-			// Refresh the sidebar
-			c.RefreshNoteList()
+	note, err := c.noteStore.GetNote(event.ID)
+	if err != nil {
+		// Unreadable mid-write
+		return
+	}
+	c.mu.Lock()
+	ours := note.Modified.Equal(c.loaded[event.ID])
+	edited := c.edited[event.ID]
+	if !ours && edited {
+		// Ask once per external version
+		c.loaded[event.ID] = note.Modified
+	}
+	c.mu.Unlock()
+	if ours {
+		// Our own save
+		return
+	}
+	if !edited {
+		c.reloadOpenNote(note)
+		return
+	}
 
-			// Show success message
-			dialog.ShowInformation("Note Deleted", "The note has been deleted successfully.", c.window)
+	message := fmt.Sprintf("%q was changed outside this window. Reload it and discard your unsaved edits?", note.Title)
+	dialog.ShowConfirm("Note Changed", message, func(reload bool) {
+		if reload {
+			c.reloadOpenNote(note)
 		}
 	}, c.window)
+}
 
-	return nil
+// recordImport records notes created outside this window, for example
+// through the HTTP API or the command line, in the activity log
+func (c *NoteController) recordImport(event models.ChangeEvent) {
+	if event.Kind != models.ChangeNote || event.Action != models.ChangeSaved {
+		return
+	}
+	c.mu.Lock()
+	_, ours := c.loaded[event.ID]
+	notes := c.notes
+	c.mu.Unlock()
+	if ours {
+		return
+	}
+	for _, note := range notes {
+		if note.ID == event.ID {
+			return
+		}
+	}
+	if note, err := c.noteStore.GetNote(event.ID); err == nil {
+		c.feedback.Record(ActivityImport, fmt.Sprintf("Imported %q", note.Title))
+	}
+}
+
+// isOpen reports whether a note is shown in the main window or a pop-out
+func (c *NoteController) isOpen(noteID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.isOpenLocked(noteID)
+}
+
+// isOpenLocked is isOpen for callers holding mu
+func (c *NoteController) isOpenLocked(noteID string) bool {
+	if c.currentNoteID == noteID {
+		return true
+	}
+	for _, pw := range c.popouts {
+		if pw.noteID == noteID {
+			return true
+		}
+	}
+	return false
 }
 
 // RefreshNoteList updates the sidebar with the current list of notes
@@ -132,9 +969,35 @@ func (c *NoteController) RefreshNoteList() error {
 	// Get all notes
 	notes, err := c.noteStore.ListNotes()
 	if err != nil {
-		dialog.ShowError(err, c.window)
+		c.mu.Lock()
+		c.storeErr = err
+		c.mu.Unlock()
+		c.statusChanged()
+		c.feedback.Error(nil, "Loading notes", err, func() {
+			c.RefreshNoteList()
+		})
 		return err
 	}
+	// Rebuild the symbol index so code blocks link to the latest notes
+	c.mu.Lock()
+	c.storeErr = nil
+	c.notes = notes
+	c.symbols = models.NewSymbolIndex(notes)
+	c.notesList = nil
+	current, popouts, findings := c.currentNoteID, slices.Clone(c.popouts), c.findings
+	c.mu.Unlock()
+
+	c.notepad.RefreshPreview()
+	if current != "" {
+		c.notepad.SetBacklinks(models.Backlinks(current, notes))
+	}
+	for _, pw := range popouts {
+		pw.notepad.RefreshPreview()
+		pw.notepad.SetBacklinks(models.Backlinks(pw.noteID, notes))
+	}
+	if findings != nil {
+		findings.refresh()
+	}
 
 	var content fyne.CanvasObject
 
@@ -150,20 +1013,21 @@ func (c *NoteController) RefreshNoteList() error {
 			func() int {
 				return len(notes)
 			},
-			func() fyne.CanvasObject {
-				return widget.NewLabel("")
-			},
+			components.NewNoteListItem,
 			func(id widget.ListItemID, obj fyne.CanvasObject) {
-				obj.(*widget.Label).SetText(notes[id].Title)
+				components.SetNoteListItem(obj, notes[id])
 			},
 		)
 
 		// Set up on-selected handler
 		notesList.OnSelected = func(id widget.ListItemID) {
-			if id < len(notes) {
+			if id < len(notes) && notes[id].ID != c.current() {
 				c.LoadNote(notes[id].ID)
 			}
 		}
+		c.mu.Lock()
+		c.notesList = notesList
+		c.mu.Unlock()
 
 		// Wrap in a container with header
 		content = container.NewBorder(
@@ -177,6 +1041,60 @@ func (c *NoteController) RefreshNoteList() error {
 
 	// Update the sidebar using the component's function
 	components.UpdateNotesList(c.sidebar.(*fyne.Container), content)
+	c.statusChanged()
 
 	return nil
 }
+
+// current returns the ID of the note in the main notepad; empty for a new
+// note
+func (c *NoteController) current() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.currentNoteID
+}
+
+// openPopOuts returns the pop-out windows currently open
+func (c *NoteController) openPopOuts() []*noteWindow {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.popouts)
+}
+
+// markEdited records unsaved edits of an open note
+func (c *NoteController) markEdited(noteID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.edited[noteID] = true
+}
+
+// isEdited reports whether an open note has unsaved edits
+func (c *NoteController) isEdited(noteID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.edited[noteID]
+}
+
+// symbolIndex returns the index of the most recently loaded notes
+func (c *NoteController) symbolIndex() *models.SymbolIndex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.symbols
+}
+
+// eventQueue is implemented by the windows of Fyne's drivers, which run
+// queued functions in order with the window's input events
+type eventQueue interface {
+	QueueEvent(fn func())
+}
+
+// runOnUI runs work started by a goroutine with the events of a window,
+// so that widgets are not updated while the user is editing them. Windows
+// without an event queue run fn at once.
+func runOnUI(window fyne.Window, fn func()) {
+	if queue, ok := window.(eventQueue); ok {
+		queue.QueueEvent(fn)
+		return
+	}
+	fn()
+}
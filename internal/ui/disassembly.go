@@ -0,0 +1,46 @@
+// Package ui provides user interface components and setup for the RevEnGo application.
+// This file contains the disassembly of the code ranges notes refer to.
+package ui
+
+import (
+	"fmt"
+
+	"github.com/leog/RevEnGo/internal/binfile"
+	"github.com/leog/RevEnGo/internal/disasm"
+	"github.com/leog/RevEnGo/internal/models"
+)
+
+// disassemble decodes a note's address range of the binary attached to a
+// project by the note's binary name.
+//
+// Parameters:
+//   - binaryName: The note's binary name
+//   - addressRange: The note's address range
+//   - projectID: The note's project, whose binaries are searched first
+//
+// Returns:
+//   - The listing
+//   - An error if no project has the binary attached or the range cannot
+//     be decoded
+func (c *NoteController) disassemble(binaryName, addressRange, projectID string) (*disasm.Listing, error) {
+	if c.projectStore == nil {
+		return nil, fmt.Errorf("no project store")
+	}
+	projects, err := c.projectStore.ListProjects()
+	if err != nil {
+		return nil, fmt.Errorf("listing projects: %w", err)
+	}
+	path := models.FindBinary(projects, projectID, binaryName)
+	if path == "" {
+		return nil, fmt.Errorf("%s is not attached to a project; attach it in Binary Strings or with: revengo binary attach PROJECT FILE", binaryName)
+	}
+	start, end, err := models.ParseAddressRange(addressRange)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address range %q: %w", addressRange, err)
+	}
+	file, err := binfile.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return disasm.Disassemble(file, start, end)
+}
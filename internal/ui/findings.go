@@ -0,0 +1,314 @@
+// Package ui provides user interface components and setup for the RevEnGo application.
+// This file contains the dashboard of the vulnerability findings of projects.
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/leog/RevEnGo/internal/models"
+	"github.com/leog/RevEnGo/internal/report"
+	"github.com/leog/RevEnGo/internal/ui/components"
+	apptheme "github.com/leog/RevEnGo/internal/ui/theme"
+	"github.com/leog/RevEnGo/internal/ui/widgets"
+	"github.com/leog/RevEnGo/internal/vuln"
+)
+
+// allProjects is the project filter choice that shows every project
+const allProjects = "All projects"
+
+// findingsDashboard is the window counting the vulnerability findings of
+// each project by severity and status, and listing them most severe
+// first. Choosing a finding opens its note in the main window.
+type findingsDashboard struct {
+	c      *NoteController
+	window fyne.Window
+
+	project *widget.Select
+
+	// projectIDs are the IDs of the project filter choices after the first
+	projectIDs []string
+
+	// summary holds a row of counts per project shown
+	summary *fyne.Container
+
+	findings []models.Finding
+	list     *widget.List
+}
+
+// ShowFindings opens the dashboard of vulnerability findings. Only one
+// dashboard is open at a time; it follows changes to the notes.
+func (c *NoteController) ShowFindings() {
+	c.mu.Lock()
+	open := c.findings
+	c.mu.Unlock()
+	if open != nil {
+		open.window.RequestFocus()
+		return
+	}
+	d := &findingsDashboard{c: c}
+	d.window = fyne.CurrentApp().NewWindow("RevEnGo - Vulnerability Findings")
+	d.window.SetContent(d.build())
+	d.window.Resize(fyne.NewSize(1000, 680))
+	d.window.SetOnClosed(func() {
+		c.mu.Lock()
+		c.findings = nil
+		c.mu.Unlock()
+	})
+	c.mu.Lock()
+	c.findings = d
+	c.mu.Unlock()
+
+	// Start with the project of the note being edited
+	d.project.SetSelected(allProjects)
+	for i, id := range d.projectIDs {
+		if id != "" && id == c.notepad.ProjectID() {
+			d.project.SetSelectedIndex(i + 1)
+		}
+	}
+	d.window.Show()
+}
+
+// build creates the dashboard's layout
+func (d *findingsDashboard) build() fyne.CanvasObject {
+	d.project = widget.NewSelect(nil, func(string) { d.refresh() })
+	d.setProjects()
+	d.summary = container.NewVBox()
+
+	d.list = widget.NewList(
+		func() int { return len(d.findings) },
+		func() fyne.CanvasObject {
+			severity := widgets.NewThemedText("", apptheme.ColorNameTerminalText)
+			severity.TextStyle = fyne.TextStyle{Monospace: true, Bold: true}
+			label := widget.NewLabel("")
+			label.TextStyle = fyne.TextStyle{Monospace: true}
+			label.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, container.NewGridWrap(fyne.NewSize(150, severity.MinSize().Height), severity), nil, label)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			f := d.findings[id]
+			row := obj.(*fyne.Container)
+			severity := row.Objects[1].(*fyne.Container).Objects[0].(*widgets.ThemedText)
+			severity.Text = strings.ToUpper(f.Severity().Label())
+			if f.Score.Version != "" {
+				severity.Text += fmt.Sprintf(" %4.1f", f.Score.Value)
+			}
+			severity.ColorName = components.SeverityColor(f.Severity())
+			severity.Refresh()
+
+			cwe := "-"
+			if v := f.Note.Vulnerability; v != nil && v.CWE != "" {
+				cwe = v.CWE
+			}
+			line := fmt.Sprintf("%-10s %-9s %s", f.Status(), cwe, f.Note.Title)
+			if binary := f.Binary(); binary != "" {
+				line += "  [" + binary + "]"
+			}
+			if f.Err != nil {
+				line += "  (invalid CVSS vector)"
+			}
+			row.Objects[0].(*widget.Label).SetText(line)
+		},
+	)
+	d.list.OnSelected = func(id widget.ListItemID) {
+		d.c.ShowNote(d.findings[id].Note.ID)
+		d.c.window.RequestFocus()
+		d.list.UnselectAll()
+	}
+
+	header := widget.NewLabel(fmt.Sprintf("%-15s %-10s %-9s %s", "SEVERITY", "STATUS", "CWE", "TITLE"))
+	header.TextStyle = fyne.TextStyle{Monospace: true, Bold: true}
+	reportButton := widget.NewButtonWithIcon("Report...", theme.DocumentIcon(), d.showReport)
+	toolbar := container.NewBorder(nil, nil, widget.NewLabel("Project:"), reportButton, d.project)
+	return container.NewBorder(
+		container.NewVBox(toolbar, d.summary, widget.NewSeparator(), header),
+		nil, nil, nil,
+		d.list,
+	)
+}
+
+// setProjects offers the projects as filter choices
+func (d *findingsDashboard) setProjects() {
+	options := []string{allProjects}
+	d.projectIDs = nil
+	if d.c.projectStore != nil {
+		if projects, err := d.c.projectStore.ListProjects(); err == nil {
+			for _, project := range projects {
+				options = append(options, fmt.Sprintf("%s (%s)", project.Name, project.ID))
+				d.projectIDs = append(d.projectIDs, project.ID)
+			}
+		}
+	}
+	d.project.Options = options
+	d.project.Refresh()
+}
+
+// refresh recounts the findings of the chosen projects
+func (d *findingsDashboard) refresh() {
+	projectID := ""
+	if i := d.project.SelectedIndex(); i > 0 {
+		projectID = d.projectIDs[i-1]
+	}
+	names := make(map[string]string)
+	for i, id := range d.projectIDs {
+		names[id] = d.project.Options[i+1]
+	}
+
+	d.summary.RemoveAll()
+	d.findings = nil
+	for _, s := range models.SummarizeFindings(d.c.Notes()) {
+		if projectID != "" && s.ProjectID != projectID {
+			continue
+		}
+		d.findings = append(d.findings, s.Findings...)
+		d.summary.Add(d.summaryRow(s, names))
+	}
+	if len(d.summary.Objects) == 0 {
+		d.summary.Add(widget.NewLabel("No vulnerability notes yet. Set a note's type to vulnerability to track it here."))
+	}
+	// Findings of several projects are merged most severe first
+	d.findings = models.Findings(notesOf(d.findings))
+	d.list.Refresh()
+}
+
+// summaryRow shows a project's findings by severity, with the open ones,
+// and by status
+func (d *findingsDashboard) summaryRow(s *models.FindingSummary, names map[string]string) fyne.CanvasObject {
+	name := names[s.ProjectID]
+	if s.ProjectID == "" {
+		name = "No project"
+	} else if name == "" {
+		name = s.ProjectID
+	}
+	title := widget.NewLabel(fmt.Sprintf("%s: %d findings", name, len(s.Findings)))
+	title.TextStyle = fyne.TextStyle{Bold: true}
+
+	counts := container.NewHBox()
+	for _, severity := range vuln.Severities {
+		if s.BySeverity[severity] == 0 {
+			continue
+		}
+		count := widgets.NewThemedText(fmt.Sprintf("%s %d (%d open)", strings.ToUpper(severity.Label()), s.BySeverity[severity], s.Open[severity]), components.SeverityColor(severity))
+		count.TextStyle = fyne.TextStyle{Monospace: true, Bold: true}
+		counts.Add(count)
+		counts.Add(widget.NewSeparator())
+	}
+	var statuses []string
+	for _, status := range models.VulnStatuses {
+		if n := s.ByStatus[status]; n > 0 {
+			statuses = append(statuses, fmt.Sprintf("%d %s", n, status))
+		}
+	}
+	statusText := widgets.NewThemedText(strings.Join(statuses, ", "), apptheme.ColorNameTerminalText)
+	statusText.TextStyle = fyne.TextStyle{Monospace: true}
+	return container.NewVBox(title, counts, statusText)
+}
+
+// reportFormatLabels name the report formats in the report dialog
+var reportFormatLabels = map[string]string{
+	report.FormatMarkdown: "Markdown",
+	report.FormatHTML:     "HTML",
+	report.FormatPDF:      "PDF",
+}
+
+// showReport asks how to report the findings shown and saves the report
+// to a file
+func (d *findingsDashboard) showReport() {
+	if len(d.findings) == 0 {
+		dialog.ShowInformation("Vulnerability Report", "There are no findings to report.", d.window)
+		return
+	}
+	title := widget.NewEntry()
+	title.SetPlaceHolder("Vulnerability Report")
+	var formatLabels []string
+	for _, format := range report.Formats {
+		formatLabels = append(formatLabels, reportFormatLabels[format])
+	}
+	format := widget.NewSelect(formatLabels, nil)
+	format.SetSelectedIndex(0)
+	templateName := widget.NewEntry()
+	templateName.SetPlaceHolder(report.DefaultTemplateName)
+	internal := widget.NewCheck("Keep internal content (draft for the team)", nil)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Title", title),
+		widget.NewFormItem("Format", format),
+		widget.NewFormItem("Template", templateName),
+		widget.NewFormItem("", internal),
+	}
+	dialog.ShowForm("Vulnerability Report", "Save As...", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		opts := report.Options{
+			Title:    strings.TrimSpace(title.Text),
+			Redact:   d.c.reportRedact,
+			Internal: internal.Checked,
+		}
+		if i := d.project.SelectedIndex(); i > 0 {
+			opts.Project, _, _ = strings.Cut(d.project.Selected, " (")
+		}
+		chosen := report.Formats[format.SelectedIndex()]
+		data, err := d.render(opts, chosen, strings.TrimSpace(templateName.Text))
+		if err != nil {
+			dialog.ShowError(err, d.window)
+			return
+		}
+		d.save(data, opts.Title, chosen)
+	}, d.window)
+}
+
+// render builds a report of the findings shown
+func (d *findingsDashboard) render(opts report.Options, format, templateName string) ([]byte, error) {
+	r, err := report.Build(notesOf(d.findings), d.c.Notes(), opts)
+	if err != nil {
+		return nil, err
+	}
+	text, err := report.LoadTemplate(d.c.ReportDir, templateName, format)
+	if err != nil {
+		return nil, err
+	}
+	return report.Render(r, format, text)
+}
+
+// save asks where to write a report and writes it
+func (d *findingsDashboard) save(data []byte, title, format string) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		defer writer.Close()
+		if _, err := writer.Write(data); err != nil {
+			d.c.feedback.Error(d.window, "Saving report", err, nil)
+			return
+		}
+		d.c.feedback.Success(ActivityExport, fmt.Sprintf("Wrote report %s", writer.URI().Name()))
+	}, d.window)
+	name := strings.Trim(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, title), "-")
+	if name == "" {
+		name = "vulnerability-report"
+	}
+	save.SetFileName(name + report.FormatExtension(format))
+	save.Show()
+}
+
+// notesOf returns the notes of findings
+func notesOf(findings []models.Finding) []*models.Note {
+	notes := make([]*models.Note, len(findings))
+	for i, f := range findings {
+		notes[i] = f.Note
+	}
+	return notes
+}
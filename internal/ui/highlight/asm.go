@@ -0,0 +1,234 @@
+package highlight
+
+import (
+	"regexp"
+	"strings"
+)
+
+// asmSyntax describes the lexical differences between assembly dialects
+type asmSyntax struct {
+	// comments are the prefixes that start a comment running to end of line
+	comments []string
+
+	// isRegister reports whether a lower-case word names a register
+	isRegister func(word string) bool
+
+	// immediate is the prefix marking immediate operands, if any
+	immediate rune
+
+	// registerSigil is the prefix marking register operands, if any
+	registerSigil rune
+}
+
+// Register patterns for each architecture
+var (
+	x86RegisterPattern = regexp.MustCompile(`^(` +
+		`[re]?[abcd]x|[abcd][lh]|[re]?(si|di|sp|bp|ip)|(si|di|sp|bp)l|` +
+		`r([89]|1[0-5])[dwb]?|` +
+		`[xyz]mm([0-9]|[12][0-9]|3[01])|k[0-7]|` +
+		`[cdefgs]s|cr[0-8]|dr[0-7]|st(\([0-7]\))?|mm[0-7]|[re]?flags)$`)
+
+	armRegisterPattern = regexp.MustCompile(`^(` +
+		`r([0-9]|1[0-5])|[xw]([0-9]|[12][0-9]|30)|[xw]zr|w?sp|lr|pc|fp|ip|sb|sl|` +
+		`[vqdshb]([0-9]|[12][0-9]|3[01])|` +
+		`cpsr|spsr|apsr|fpscr|nzcv)$`)
+
+	mipsRegisterPattern = regexp.MustCompile(`^\$?(` +
+		`zero|at|v[01]|a[0-3]|t[0-9]|s[0-8]|k[01]|gp|sp|fp|ra|` +
+		`[0-9]|[12][0-9]|3[01]|f([0-9]|[12][0-9]|3[01])|hi|lo)$`)
+)
+
+// sizeKeywords are operand size and distance qualifiers in Intel syntax
+var sizeKeywords = map[string]bool{
+	"byte": true, "word": true, "dword": true, "qword": true, "tbyte": true,
+	"xmmword": true, "ymmword": true, "zmmword": true, "ptr": true,
+	"short": true, "near": true, "far": true, "offset": true,
+}
+
+// Assembly dialects
+var (
+	x86Syntax = asmSyntax{
+		comments:      []string{";", "#", "//"},
+		isRegister:    x86RegisterPattern.MatchString,
+		immediate:     '$',
+		registerSigil: '%',
+	}
+
+	armSyntax = asmSyntax{
+		comments:   []string{"//", "@", ";"},
+		isRegister: armRegisterPattern.MatchString,
+		immediate:  '#',
+	}
+
+	mipsSyntax = asmSyntax{
+		comments:      []string{"#", ";", "//"},
+		isRegister:    mipsRegisterPattern.MatchString,
+		registerSigil: '$',
+	}
+)
+
+func init() {
+	register(x86Syntax.lex, "x86", "x64", "x86_64", "x86-64", "amd64", "i386", "asm", "nasm", "masm", "intel", "gas")
+	register(armSyntax.lex, "arm", "arm32", "armv7", "thumb", "arm64", "aarch64", "armasm")
+	register(mipsSyntax.lex, "mips", "mips32", "mips64", "mipsel")
+}
+
+// lex tokenizes assembly. It copes with raw listings as well as disassembler
+// output that prefixes each line with an address and instruction bytes.
+func (a asmSyntax) lex(code string) []Token {
+	s := newScanner(code)
+	line := asmLine{start: true}
+
+	for !s.done() {
+		start := s.pos
+		r := s.peek(0)
+
+		switch {
+		case r == '\n':
+			s.pos++
+			s.emit(KindText, start)
+			line = asmLine{start: true}
+			continue
+
+		case r == ' ' || r == '\t' || r == '\r':
+			for !s.done() && (s.peek(0) == ' ' || s.peek(0) == '\t' || s.peek(0) == '\r') {
+				s.pos++
+			}
+			s.emit(KindText, start)
+			continue
+
+		case a.isComment(s):
+			s.untilEOL()
+			s.emit(KindComment, start)
+			continue
+
+		case r == '"' || r == '\'':
+			s.quoted()
+			s.emit(KindString, start)
+
+		case r == '<':
+			// Symbolic operands such as <main+0x10> in objdump output
+			s.until(">")
+			s.emit(KindSymbol, start)
+
+		case r == '.' && isIdent(s.peek(1)) && !line.mnemonic:
+			s.pos++
+			s.word(".")
+			s.emit(KindDirective, start)
+			line.mnemonic = true
+
+		case a.immediate != 0 && r == a.immediate && a.registerSigil != r:
+			s.pos++
+			s.word("")
+			s.emit(KindNumber, start)
+
+		case a.registerSigil != 0 && r == a.registerSigil:
+			s.pos++
+			word := s.word("")
+			if a.isRegister(strings.ToLower(word)) || a.isRegister(strings.ToLower(string(r)+word)) {
+				s.emit(KindRegister, start)
+			} else {
+				s.emit(KindText, start)
+			}
+
+		case r == '-' && isDigit(s.peek(1)):
+			s.pos++
+			s.word("")
+			s.emit(KindNumber, start)
+
+		case isIdent(r) || r == '?' || r == '@':
+			word := s.word(".?@$")
+			a.classify(s, word, start, &line)
+
+		default:
+			s.pos++
+			s.emit(KindPunctuation, start)
+		}
+
+		line.start = false
+	}
+	return s.tokens
+}
+
+// asmLine tracks where the lexer is within the current line
+type asmLine struct {
+	// start is set until the first token of the line has been read
+	start bool
+
+	// address is set once a leading address column has been read
+	address bool
+
+	// mnemonic is set once the instruction or directive has been read
+	mnemonic bool
+}
+
+// classify emits a token for a word based on its position and spelling
+func (a asmSyntax) classify(s *scanner, word string, start int, line *asmLine) {
+	lower := strings.ToLower(word)
+
+	// Labels end with a colon; a leading hex value followed by a colon is an address
+	if s.peek(0) == ':' {
+		s.pos++
+		if kind, ok := classifyNumber(word); ok || (line.start && isHexWord(word)) {
+			if !ok || kind == KindNumber {
+				kind = KindAddress
+			}
+			line.address = true
+			s.emit(kind, start)
+			return
+		}
+		s.emit(KindLabel, start)
+		return
+	}
+
+	// Disassembler listings lead with an address column followed by the raw
+	// instruction bytes. Mnemonics such as "add" are valid hex too, so bytes
+	// must be digit pairs, or contain a digit, to be recognized.
+	if !line.mnemonic && isHexWord(word) {
+		hasDigit := strings.ContainsAny(word, "0123456789")
+		switch {
+		case line.start && (len(word) >= 6 || (len(word) >= 4 && hasDigit)):
+			line.address = true
+			s.emit(KindAddress, start)
+			return
+		case line.address && len(word)%2 == 0 && (len(word) == 2 || hasDigit):
+			s.emit(KindComment, start)
+			return
+		}
+	}
+
+	if kind, ok := classifyNumber(word); ok {
+		if line.start && kind == KindAddress {
+			line.address = true
+		}
+		s.emit(kind, start)
+		return
+	}
+
+	switch {
+	case a.isRegister(lower):
+		s.emit(KindRegister, start)
+	case sizeKeywords[lower]:
+		s.emit(KindKeyword, start)
+	case !line.mnemonic:
+		line.mnemonic = true
+		s.emit(KindMnemonic, start)
+	default:
+		s.emit(KindSymbol, start)
+	}
+}
+
+// isComment reports whether a comment starts at the cursor
+func (a asmSyntax) isComment(s *scanner) bool {
+	for _, prefix := range a.comments {
+		if s.hasPrefix(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDigit reports whether r is an ASCII digit
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
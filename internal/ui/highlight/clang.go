@@ -0,0 +1,125 @@
+package highlight
+
+import (
+	"regexp"
+	"strings"
+)
+
+// cKeywords are C and C++ keywords found in decompiler output
+var cKeywords = wordSet(`
+	if else for while do switch case default break continue return goto
+	sizeof typedef struct union enum static const volatile extern register
+	inline restrict auto class namespace template typename public private
+	protected virtual new delete this nullptr true false NULL
+	__fastcall __cdecl __stdcall __thiscall __usercall __noreturn __packed
+`)
+
+// cTypes are primitive and common decompiler type names
+var cTypes = wordSet(`
+	void char short int long float double signed unsigned bool _Bool
+	int8_t int16_t int32_t int64_t uint8_t uint16_t uint32_t uint64_t
+	size_t ssize_t uintptr_t intptr_t ptrdiff_t wchar_t
+	__int8 __int16 __int32 __int64 _BYTE _WORD _DWORD _QWORD _OWORD _BOOL1 _BOOL4
+	BYTE WORD DWORD QWORD BOOL HANDLE LPVOID PVOID LPSTR LPCSTR LPWSTR LPCWSTR
+	undefined undefined1 undefined2 undefined4 undefined8 byte ushort uint ulong
+	longlong ulonglong code pointer
+`)
+
+// decompilerSymbol matches auto-generated names from IDA and Ghidra
+var decompilerSymbol = regexp.MustCompile(`^(sub|loc|off|unk|byte|word|dword|qword|stru|asc|FUN|DAT|LAB|PTR|thunk_FUN)_[0-9A-Fa-f]+$`)
+
+func init() {
+	register(lexC, "c", "cpp", "c++", "h", "pseudo", "pseudocode", "decompiled", "hexrays", "ghidra")
+}
+
+// lexC tokenizes C-like pseudocode as produced by decompilers
+func lexC(code string) []Token {
+	s := newScanner(code)
+	lineStart := true
+
+	for !s.done() {
+		start := s.pos
+		r := s.peek(0)
+
+		switch {
+		case r == '\n':
+			s.pos++
+			s.emit(KindText, start)
+			lineStart = true
+			continue
+
+		case r == ' ' || r == '\t' || r == '\r':
+			s.pos++
+			s.emit(KindText, start)
+			continue
+
+		case s.hasPrefix("//"):
+			s.untilEOL()
+			s.emit(KindComment, start)
+
+		case s.hasPrefix("/*"):
+			s.pos += 2
+			s.until("*/")
+			s.emit(KindComment, start)
+
+		case r == '#' && lineStart:
+			s.untilEOL()
+			s.emit(KindDirective, start)
+
+		case r == '"' || r == '\'':
+			s.quoted()
+			s.emit(KindString, start)
+
+		case isDigit(r):
+			word := s.word("")
+			if kind, ok := classifyNumber(strings.TrimRight(word, "uUlL")); ok {
+				s.emit(kind, start)
+			} else {
+				s.emit(KindNumber, start)
+			}
+
+		case isIdent(r):
+			word := s.word("")
+			s.emit(classifyC(s, word), start)
+
+		default:
+			s.pos++
+			s.emit(KindPunctuation, start)
+		}
+
+		lineStart = false
+	}
+	return s.tokens
+}
+
+// classifyC decides the kind of a C identifier
+func classifyC(s *scanner, word string) Kind {
+	switch {
+	case cKeywords[word]:
+		return KindKeyword
+	case cTypes[word]:
+		return KindType
+	case decompilerSymbol.MatchString(word):
+		return KindSymbol
+	}
+
+	// Identifiers followed by a call are function names
+	for i := 0; ; i++ {
+		switch s.peek(i) {
+		case ' ', '\t':
+			continue
+		case '(':
+			return KindSymbol
+		}
+		return KindText
+	}
+}
+
+// wordSet builds a lookup set from whitespace separated words
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
@@ -0,0 +1,91 @@
+package highlight
+
+import (
+	"strings"
+)
+
+func init() {
+	register(lexHexdump, "hex", "hexdump", "xxd", "hexview")
+}
+
+// lexHexdump tokenizes hexdump output such as that of xxd or hexdump -C.
+// Each line has an offset column, a run of hex byte groups and an optional
+// ASCII column.
+func lexHexdump(code string) []Token {
+	var tokens []Token
+	lines := strings.SplitAfter(code, "\n")
+	for _, line := range lines {
+		tokens = append(tokens, lexHexdumpLine(line)...)
+	}
+	return mergeTokens(tokens)
+}
+
+// lexHexdumpLine tokenizes a single hexdump line including its newline
+func lexHexdumpLine(line string) []Token {
+	var tokens []Token
+	rest := line
+
+	// take removes the first n bytes of rest as a token
+	take := func(kind Kind, n int) {
+		if n > 0 {
+			tokens = append(tokens, Token{Kind: kind, Text: rest[:n]})
+			rest = rest[n:]
+		}
+	}
+	spaces := func() {
+		n := len(rest) - len(strings.TrimLeft(rest, " \t"))
+		take(KindText, n)
+	}
+
+	// Offset column, optionally followed by a colon
+	spaces()
+	offset := hexPrefixLen(rest)
+	if offset == 0 {
+		return []Token{{Kind: KindText, Text: line}}
+	}
+	if offset < len(rest) && rest[offset] == ':' {
+		offset++
+	}
+	take(KindAddress, offset)
+
+	// Byte groups continue until something that is not a hex group appears
+	for {
+		spaces()
+		if rest == "" || rest == "\n" || rest == "\r\n" {
+			break
+		}
+		n := hexPrefixLen(rest)
+		if n == 0 || n%2 != 0 || (n < len(rest) && !strings.ContainsRune(" \t\r\n", rune(rest[n]))) {
+			break
+		}
+		take(KindNumber, n)
+	}
+
+	// Whatever is left on the line is the ASCII column
+	body := strings.TrimRight(rest, "\r\n")
+	take(KindString, len(body))
+	take(KindText, len(rest))
+	return tokens
+}
+
+// hexPrefixLen returns the number of leading hex digits in s
+func hexPrefixLen(s string) int {
+	n := 0
+	for n < len(s) && strings.IndexByte("0123456789abcdefABCDEF", s[n]) >= 0 {
+		n++
+	}
+	return n
+}
+
+// mergeTokens joins adjacent tokens of the same kind
+func mergeTokens(tokens []Token) []Token {
+	merged := make([]Token, 0, len(tokens))
+	for _, tok := range tokens {
+		if n := len(merged); n > 0 && merged[n-1].Kind == tok.Kind {
+			merged[n-1].Text += tok.Text
+			continue
+		}
+		merged = append(merged, tok)
+	}
+	return merged
+}
@@ -0,0 +1,164 @@
+// Package highlight provides syntax highlighting for code blocks in notes.
+// It understands the languages that dominate reverse engineering notes:
+// x86/x64, ARM/AArch64 and MIPS assembly, C-like decompiler output,
+// hexdumps and YARA rules.
+package highlight
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Kind classifies a token for colouring
+type Kind int
+
+// Token kinds
+const (
+	KindText Kind = iota
+	KindComment
+	KindKeyword
+	KindMnemonic
+	KindRegister
+	KindNumber
+	KindAddress
+	KindString
+	KindSymbol
+	KindLabel
+	KindDirective
+	KindType
+	KindPunctuation
+)
+
+// Token is a piece of highlighted source text.
+// Concatenating the text of all tokens reproduces the original source.
+type Token struct {
+	Kind Kind
+	Text string
+}
+
+// lexer splits source code into tokens
+type lexer func(code string) []Token
+
+// languages maps fenced code block language hints to lexers
+var languages = map[string]lexer{}
+
+// register makes a lexer available under one or more language hints
+func register(lex lexer, names ...string) {
+	for _, name := range names {
+		languages[name] = lex
+	}
+}
+
+// Supported reports whether a language hint has a highlighter
+func Supported(language string) bool {
+	_, ok := languages[normalize(language)]
+	return ok
+}
+
+// Tokenize splits code into tokens using the lexer for the language hint.
+// It returns false if the language is not supported.
+func Tokenize(language, code string) ([]Token, bool) {
+	lex, ok := languages[normalize(language)]
+	if !ok {
+		return nil, false
+	}
+	return lex(code), true
+}
+
+// normalize reduces a language hint to its lookup key
+func normalize(language string) string {
+	return strings.ToLower(strings.TrimSpace(language))
+}
+
+// Highlighter renders code blocks into coloured rich text segments.
+// Addresses and symbol names can be made clickable by providing Resolve.
+type Highlighter struct {
+	// Resolve reports whether an address or symbol name refers to something
+	// known, such as a note. Tokens it accepts are rendered as links.
+	Resolve func(word string) bool
+
+	// OnTapped is called with the word of a resolved token when it is tapped
+	OnTapped func(word string)
+}
+
+// Highlight renders a code block; it matches markdown.Highlighter.
+// It returns nil for unsupported languages.
+func (h *Highlighter) Highlight(language, code string) []widget.RichTextSegment {
+	tokens, ok := Tokenize(language, code)
+	if !ok {
+		return nil
+	}
+
+	segs := make([]widget.RichTextSegment, 0, len(tokens)+1)
+	for _, tok := range tokens {
+		if (tok.Kind == KindAddress || tok.Kind == KindSymbol || tok.Kind == KindLabel) && h.resolves(tok.Text) {
+			word := strings.TrimSuffix(tok.Text, ":")
+			segs = append(segs, &widget.HyperlinkSegment{
+				Alignment: fyne.TextAlignLeading,
+				Text:      tok.Text,
+				OnTapped: func() {
+					if h.OnTapped != nil {
+						h.OnTapped(word)
+					}
+				},
+			})
+			continue
+		}
+
+		segs = append(segs, &widget.TextSegment{Style: styleFor(tok.Kind), Text: tok.Text})
+	}
+
+	// Terminate the block so following content starts on a new line
+	end := widget.RichTextStyleCodeBlock
+	return append(segs, &widget.TextSegment{Style: end})
+}
+
+// resolves reports whether a token should be rendered as a link
+func (h *Highlighter) resolves(word string) bool {
+	if h.Resolve == nil {
+		return false
+	}
+	return h.Resolve(strings.TrimSuffix(word, ":"))
+}
+
+// styleFor returns the inline monospace style used for a token kind
+func styleFor(kind Kind) widget.RichTextStyle {
+	style := widget.RichTextStyle{
+		Inline:    true,
+		SizeName:  theme.SizeNameText,
+		TextStyle: fyne.TextStyle{Monospace: true},
+		ColorName: ColorName(kind),
+	}
+	switch kind {
+	case KindComment:
+		style.TextStyle.Italic = true
+	case KindMnemonic, KindKeyword, KindLabel:
+		style.TextStyle.Bold = true
+	}
+	return style
+}
+
+// ColorName returns the theme colour used for a token kind
+func ColorName(kind Kind) fyne.ThemeColorName {
+	switch kind {
+	case KindComment:
+		return theme.ColorNamePlaceHolder
+	case KindKeyword, KindMnemonic:
+		return theme.ColorNamePrimary
+	case KindRegister, KindType:
+		return theme.ColorNameSuccess
+	case KindNumber:
+		return theme.ColorNameWarning
+	case KindAddress, KindLabel, KindSymbol:
+		return theme.ColorNameHyperlink
+	case KindString:
+		return theme.ColorNameError
+	case KindDirective:
+		return theme.ColorNameFocus
+	default:
+		return theme.ColorNameForeground
+	}
+}
@@ -0,0 +1,139 @@
+package highlight
+
+import (
+	"strings"
+	"unicode"
+)
+
+// scanner is a small cursor over source text shared by the lexers
+type scanner struct {
+	src    []rune
+	pos    int
+	tokens []Token
+}
+
+// newScanner creates a scanner over code
+func newScanner(code string) *scanner {
+	return &scanner{src: []rune(code)}
+}
+
+// done reports whether the whole source has been consumed
+func (s *scanner) done() bool {
+	return s.pos >= len(s.src)
+}
+
+// peek returns the rune at an offset from the cursor, or 0 past the end
+func (s *scanner) peek(offset int) rune {
+	if i := s.pos + offset; i >= 0 && i < len(s.src) {
+		return s.src[i]
+	}
+	return 0
+}
+
+// hasPrefix reports whether the text at the cursor starts with prefix
+func (s *scanner) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(string(s.src[s.pos:min(len(s.src), s.pos+len(prefix))]), prefix)
+}
+
+// emit appends the text from start to the cursor as a token.
+// Adjacent tokens of the same kind are merged.
+func (s *scanner) emit(kind Kind, start int) {
+	if start >= s.pos {
+		return
+	}
+	text := string(s.src[start:s.pos])
+	if n := len(s.tokens); n > 0 && s.tokens[n-1].Kind == kind {
+		s.tokens[n-1].Text += text
+		return
+	}
+	s.tokens = append(s.tokens, Token{Kind: kind, Text: text})
+}
+
+// until advances up to (but not including) the next newline
+func (s *scanner) untilEOL() {
+	for !s.done() && s.src[s.pos] != '\n' {
+		s.pos++
+	}
+}
+
+// until advances past the first occurrence of end, or to the end of input
+func (s *scanner) until(end string) {
+	for !s.done() {
+		if s.hasPrefix(end) {
+			s.pos += len([]rune(end))
+			return
+		}
+		s.pos++
+	}
+}
+
+// quoted advances over a quoted string starting at the cursor,
+// honouring backslash escapes and stopping at the end of the line
+func (s *scanner) quoted() {
+	quote := s.src[s.pos]
+	s.pos++
+	for !s.done() && s.src[s.pos] != '\n' {
+		switch s.src[s.pos] {
+		case '\\':
+			s.pos += 2
+			continue
+		case quote:
+			s.pos++
+			return
+		}
+		s.pos++
+	}
+	s.pos = min(s.pos, len(s.src))
+}
+
+// word advances over a run of identifier characters and returns it
+func (s *scanner) word(extra string) string {
+	start := s.pos
+	for !s.done() && (isIdent(s.src[s.pos]) || strings.ContainsRune(extra, s.src[s.pos])) {
+		s.pos++
+	}
+	return string(s.src[start:s.pos])
+}
+
+// space advances over horizontal and vertical whitespace
+func (s *scanner) space() {
+	for !s.done() && unicode.IsSpace(s.src[s.pos]) {
+		s.pos++
+	}
+}
+
+// isIdent reports whether r can appear in an identifier
+func isIdent(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isHexWord reports whether word consists only of hexadecimal digits
+func isHexWord(word string) bool {
+	if word == "" {
+		return false
+	}
+	for _, r := range word {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// classifyNumber decides whether a numeric word is a plain number or an address.
+// Hex values of six or more digits are treated as addresses.
+func classifyNumber(word string) (Kind, bool) {
+	lower := strings.ToLower(word)
+	switch {
+	case strings.HasPrefix(lower, "0x") && isHexWord(lower[2:]):
+		if len(lower)-2 >= 6 {
+			return KindAddress, true
+		}
+		return KindNumber, true
+	case strings.HasSuffix(lower, "h") && len(lower) > 1 && isHexWord(lower[:len(lower)-1]) && unicode.IsDigit(rune(lower[0])):
+		return KindNumber, true
+	case lower != "" && strings.Trim(lower, "0123456789") == "":
+		return KindNumber, true
+	}
+	return KindText, false
+}
@@ -0,0 +1,120 @@
+package highlight
+
+// yaraKeywords are the reserved words of the YARA rule language
+var yaraKeywords = wordSet(`
+	rule private global import include meta strings condition
+	and or not any all none of them for in at filesize entrypoint
+	true false defined matches contains icontains startswith istartswith
+	endswith iendswith iequals
+	nocase wide ascii fullword xor base64 base64wide
+	int8 int16 int32 uint8 uint16 uint32 int8be int16be int32be
+	uint8be uint16be uint32be
+`)
+
+func init() {
+	register(lexYara, "yara", "yar")
+}
+
+// lexYara tokenizes YARA rules
+func lexYara(code string) []Token {
+	s := newScanner(code)
+	// inHex is set while inside a { ... } hex string in the strings section
+	inHex := false
+	// afterAssign is set between a string identifier's "=" and its value
+	afterAssign := false
+
+	for !s.done() {
+		start := s.pos
+		r := s.peek(0)
+
+		switch {
+		case r == '\n' || r == ' ' || r == '\t' || r == '\r':
+			s.pos++
+			s.emit(KindText, start)
+			continue
+
+		case s.hasPrefix("//"):
+			s.untilEOL()
+			s.emit(KindComment, start)
+
+		case s.hasPrefix("/*"):
+			s.pos += 2
+			s.until("*/")
+			s.emit(KindComment, start)
+
+		case inHex:
+			if r == '}' {
+				inHex = false
+				s.pos++
+				s.emit(KindPunctuation, start)
+				continue
+			}
+			s.pos++
+			s.emit(KindNumber, start)
+
+		case r == '"':
+			s.quoted()
+			s.emit(KindString, start)
+
+		case r == '/' && afterAssign:
+			// Regular expression strings
+			s.pos++
+			for !s.done() && s.peek(0) != '/' && s.peek(0) != '\n' {
+				if s.peek(0) == '\\' {
+					s.pos++
+				}
+				s.pos++
+			}
+			if s.peek(0) == '/' {
+				s.pos++
+			}
+			s.word("")
+			s.emit(KindString, start)
+
+		case r == '{' && afterAssign:
+			inHex = true
+			s.pos++
+			s.emit(KindPunctuation, start)
+
+		case r == '$' || r == '#' || r == '@' || r == '!':
+			// String identifiers, counts, offsets and lengths
+			s.pos++
+			s.word("*")
+			s.emit(KindRegister, start)
+			afterAssign = false
+			continue
+
+		case r == '=':
+			s.pos++
+			s.emit(KindPunctuation, start)
+			afterAssign = true
+			continue
+
+		case isDigit(r):
+			word := s.word("")
+			if kind, ok := classifyNumber(word); ok {
+				s.emit(kind, start)
+			} else {
+				s.emit(KindNumber, start)
+			}
+
+		case isIdent(r):
+			word := s.word(".")
+			switch {
+			case yaraKeywords[word]:
+				s.emit(KindKeyword, start)
+			case s.peek(0) == ':':
+				s.emit(KindLabel, start)
+			default:
+				s.emit(KindSymbol, start)
+			}
+
+		default:
+			s.pos++
+			s.emit(KindPunctuation, start)
+		}
+
+		afterAssign = false
+	}
+	return s.tokens
+}
@@ -0,0 +1,87 @@
+// Package ui provides user interface components and setup for the RevEnGo application.
+// This file contains the tracker for background jobs shown in the status area.
+package ui
+
+import "sync"
+
+// Jobs tracks work running in the background, such as autosave, so its
+// progress can be shown in the header's status area. Jobs may be started
+// and updated from any goroutine.
+type Jobs struct {
+	mu      sync.Mutex
+	running []*Job
+
+	// OnChanged is called when a job starts, progresses or finishes
+	OnChanged func()
+}
+
+// Job is a unit of background work tracked by Jobs
+type Job struct {
+	jobs *Jobs
+
+	// name describes the job to the user
+	name string
+
+	// progress is the fraction done from 0 to 1, or negative when unknown
+	progress float64
+}
+
+// Start records a new running job with unknown progress.
+//
+// Parameters:
+//   - name: Describes the job to the user, such as "Autosaving"
+//
+// Returns:
+//   - The job, to be updated and finished by the caller
+func (j *Jobs) Start(name string) *Job {
+	job := &Job{jobs: j, name: name, progress: -1}
+	j.mu.Lock()
+	j.running = append(j.running, job)
+	j.mu.Unlock()
+	j.changed()
+	return job
+}
+
+// Current describes the oldest running job.
+//
+// Returns:
+//   - The job's name, or "" when no job is running
+//   - The job's progress from 0 to 1, or negative when unknown
+//   - The number of running jobs
+func (j *Jobs) Current() (string, float64, int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.running) == 0 {
+		return "", -1, 0
+	}
+	return j.running[0].name, j.running[0].progress, len(j.running)
+}
+
+// changed reports a change to OnChanged
+func (j *Jobs) changed() {
+	if j.OnChanged != nil {
+		j.OnChanged()
+	}
+}
+
+// SetProgress records how much of the job is done, from 0 to 1
+func (job *Job) SetProgress(progress float64) {
+	job.jobs.mu.Lock()
+	job.progress = progress
+	job.jobs.mu.Unlock()
+	job.jobs.changed()
+}
+
+// Done removes the finished job
+func (job *Job) Done() {
+	j := job.jobs
+	j.mu.Lock()
+	for i, running := range j.running {
+		if running == job {
+			j.running = append(j.running[:i], j.running[i+1:]...)
+			break
+		}
+	}
+	j.mu.Unlock()
+	j.changed()
+}
@@ -0,0 +1,377 @@
+// Package markdown renders Markdown note content into Fyne rich text.
+// It supports headings, emphasis, lists, block quotes, tables,
+// fenced code blocks with language hints, clickable links and
+// wiki-style [[note links]].
+package markdown
+
+import (
+	"net/url"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+
+	"github.com/leog/RevEnGo/internal/models"
+)
+
+// NoteLinkScheme prefixes the destination of wiki-style [[note links]]
+// passed to OnLinkTapped, e.g. "note:Main Loop".
+const NoteLinkScheme = "note:"
+
+// Highlighter converts the contents of a fenced code block into styled segments.
+// It returns nil if it does not support the given language, in which case
+// the block is rendered as plain monospace text.
+type Highlighter func(language, code string) []widget.RichTextSegment
+
+// Renderer converts Markdown source into rich text segments.
+// The zero value is ready to use.
+type Renderer struct {
+	// OnLinkTapped is called with the link destination when a link is tapped.
+	// If nil, links are opened with the system browser.
+	OnLinkTapped func(destination string)
+
+	// Highlight is an optional syntax highlighter for fenced code blocks
+	Highlight Highlighter
+}
+
+// parser is shared by all renderers; goldmark parsers are safe for reuse
+var parser = goldmark.New(
+	goldmark.WithExtensions(
+		extension.Table,
+		extension.Strikethrough,
+		extension.Linkify,
+		extension.TaskList,
+	),
+).Parser()
+
+// Render parses Markdown source and returns the segments to display.
+func (r *Renderer) Render(source string) []widget.RichTextSegment {
+	src := []byte(rewriteWikiLinks(source))
+	doc := parser.Parse(text.NewReader(src))
+	return r.renderBlocks(src, doc, false)
+}
+
+// NewRichText creates a read-only rich text widget showing the rendered source.
+func (r *Renderer) NewRichText(source string) *widget.RichText {
+	rt := widget.NewRichText(r.Render(source)...)
+	rt.Wrapping = fyne.TextWrapWord
+	return rt
+}
+
+// renderBlocks renders each block-level child of a node
+func (r *Renderer) renderBlocks(src []byte, n ast.Node, quoted bool) []widget.RichTextSegment {
+	var segs []widget.RichTextSegment
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		segs = append(segs, r.renderBlock(src, child, quoted)...)
+	}
+	return segs
+}
+
+// renderBlock renders a single block-level node
+func (r *Renderer) renderBlock(src []byte, n ast.Node, quoted bool) []widget.RichTextSegment {
+	switch node := n.(type) {
+	case *ast.Heading:
+		style := widget.RichTextStyleParagraph
+		switch node.Level {
+		case 1:
+			style = widget.RichTextStyleHeading
+		case 2:
+			style = widget.RichTextStyleSubHeading
+		default:
+			style.TextStyle.Bold = true
+		}
+		return []widget.RichTextSegment{&widget.TextSegment{Style: style, Text: plainText(src, node)}}
+
+	case *ast.Paragraph, *ast.TextBlock:
+		base := widget.RichTextStyleInline
+		if quoted {
+			base = widget.RichTextStyleBlockquote
+			base.Inline = true
+		}
+		segs := r.renderInlines(src, n, base)
+		if _, ok := n.(*ast.Paragraph); ok {
+			segs = append(segs, &widget.TextSegment{Style: widget.RichTextStyleParagraph})
+		}
+		return segs
+
+	case *ast.Blockquote:
+		return r.renderBlocks(src, node, true)
+
+	case *ast.List:
+		items := make([]widget.RichTextSegment, 0, node.ChildCount())
+		for item := node.FirstChild(); item != nil; item = item.NextSibling() {
+			items = append(items, &widget.ParagraphSegment{Texts: r.renderBlocks(src, item, quoted)})
+		}
+		return []widget.RichTextSegment{&widget.ListSegment{Items: items, Ordered: node.IsOrdered()}}
+
+	case *ast.ThematicBreak:
+		return []widget.RichTextSegment{&widget.SeparatorSegment{}}
+
+	case *ast.FencedCodeBlock:
+		return r.renderCode(string(node.Language(src)), blockText(src, node))
+
+	case *ast.CodeBlock:
+		return r.renderCode("", blockText(src, node))
+
+	case *ast.HTMLBlock:
+		return r.renderCode("", blockText(src, node))
+
+	case *extast.Table:
+		return []widget.RichTextSegment{
+			&widget.TextSegment{Style: widget.RichTextStyleCodeBlock, Text: renderTable(src, node)},
+		}
+	}
+
+	// Unknown containers still show their children
+	return r.renderBlocks(src, n, quoted)
+}
+
+// renderCode renders a fenced or indented code block.
+// A language hint is shown as a caption above the block.
+func (r *Renderer) renderCode(language, code string) []widget.RichTextSegment {
+	code = strings.TrimSuffix(code, "\n")
+	var segs []widget.RichTextSegment
+
+	if language != "" {
+		caption := widget.RichTextStyleParagraph
+		caption.SizeName = theme.SizeNameCaptionText
+		caption.ColorName = theme.ColorNamePlaceHolder
+		caption.TextStyle = fyne.TextStyle{Monospace: true, Italic: true}
+		segs = append(segs, &widget.TextSegment{Style: caption, Text: language})
+	}
+
+	if r.Highlight != nil {
+		if highlighted := r.Highlight(language, code); highlighted != nil {
+			return append(segs, highlighted...)
+		}
+	}
+
+	return append(segs, &widget.TextSegment{Style: widget.RichTextStyleCodeBlock, Text: code})
+}
+
+// renderInlines renders the inline children of a node using the given base style
+func (r *Renderer) renderInlines(src []byte, n ast.Node, style widget.RichTextStyle) []widget.RichTextSegment {
+	var segs []widget.RichTextSegment
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		segs = append(segs, r.renderInline(src, child, style)...)
+	}
+	return segs
+}
+
+// renderInline renders a single inline node
+func (r *Renderer) renderInline(src []byte, n ast.Node, style widget.RichTextStyle) []widget.RichTextSegment {
+	switch node := n.(type) {
+	case *ast.Text:
+		value := string(node.Segment.Value(src))
+		if node.SoftLineBreak() || node.HardLineBreak() {
+			value += " "
+		}
+		if value == "" {
+			return nil
+		}
+		return []widget.RichTextSegment{&widget.TextSegment{Style: style, Text: value}}
+
+	case *ast.String:
+		return []widget.RichTextSegment{&widget.TextSegment{Style: style, Text: string(node.Value)}}
+
+	case *ast.Emphasis:
+		if node.Level >= 2 {
+			style.TextStyle.Bold = true
+		} else {
+			style.TextStyle.Italic = true
+		}
+		return r.renderInlines(src, node, style)
+
+	case *extast.Strikethrough:
+		style.ColorName = theme.ColorNamePlaceHolder
+		return r.renderInlines(src, node, style)
+
+	case *ast.CodeSpan:
+		code := widget.RichTextStyleCodeInline
+		return []widget.RichTextSegment{&widget.TextSegment{Style: code, Text: plainText(src, node)}}
+
+	case *ast.Link:
+		return []widget.RichTextSegment{r.link(plainText(src, node), string(node.Destination))}
+
+	case *ast.AutoLink:
+		return []widget.RichTextSegment{r.link(string(node.Label(src)), string(node.URL(src)))}
+
+	case *extast.TaskCheckBox:
+		mark := "[ ] "
+		if node.IsChecked {
+			mark = "[x] "
+		}
+		mono := style
+		mono.TextStyle.Monospace = true
+		return []widget.RichTextSegment{&widget.TextSegment{Style: mono, Text: mark}}
+
+	case *ast.Image:
+		return []widget.RichTextSegment{r.link(plainText(src, node), string(node.Destination))}
+
+	case *ast.RawHTML:
+		return nil
+	}
+
+	return r.renderInlines(src, n, style)
+}
+
+// link creates a hyperlink segment that reports taps through OnLinkTapped
+func (r *Renderer) link(label, destination string) widget.RichTextSegment {
+	if label == "" {
+		label = destination
+	}
+	u, _ := url.Parse(destination)
+	seg := &widget.HyperlinkSegment{Alignment: fyne.TextAlignLeading, Text: label, URL: u}
+	if r.OnLinkTapped != nil {
+		seg.OnTapped = func() {
+			r.OnLinkTapped(destination)
+		}
+	}
+	return seg
+}
+
+// renderTable lays a table out as aligned monospace text,
+// as RichText has no native table segment
+func renderTable(src []byte, table *extast.Table) string {
+	var rows [][]string
+	for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			cells = append(cells, plainText(src, cell))
+		}
+		rows = append(rows, cells)
+	}
+
+	// Work out the width of every column
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if l := len([]rune(cell)); l > widths[i] {
+				widths[i] = l
+			}
+		}
+	}
+
+	var b strings.Builder
+	for r, row := range rows {
+		for i, cell := range row {
+			b.WriteString("| ")
+			b.WriteString(pad(cell, widths[i], alignment(table, i)))
+			b.WriteString(" ")
+		}
+		b.WriteString("|\n")
+
+		// Separate the header row from the body
+		if r == 0 {
+			for _, w := range widths {
+				b.WriteString("|" + strings.Repeat("-", w+2))
+			}
+			b.WriteString("|\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// alignment returns the alignment of a table column
+func alignment(table *extast.Table, column int) extast.Alignment {
+	if column < len(table.Alignments) {
+		return table.Alignments[column]
+	}
+	return extast.AlignNone
+}
+
+// pad pads a cell to the column width honouring its alignment
+func pad(cell string, width int, align extast.Alignment) string {
+	gap := width - len([]rune(cell))
+	if gap <= 0 {
+		return cell
+	}
+	switch align {
+	case extast.AlignRight:
+		return strings.Repeat(" ", gap) + cell
+	case extast.AlignCenter:
+		left := gap / 2
+		return strings.Repeat(" ", left) + cell + strings.Repeat(" ", gap-left)
+	default:
+		return cell + strings.Repeat(" ", gap)
+	}
+}
+
+// rewriteWikiLinks turns [[note links]] into Markdown links using NoteLinkScheme.
+// Links inside fenced code blocks and code spans are left alone.
+func rewriteWikiLinks(source string) string {
+	if !strings.Contains(source, "[[") {
+		return source
+	}
+
+	lines := strings.SplitAfter(source, "\n")
+	inFence := false
+	for i, line := range lines {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		// Even-numbered pieces between backticks are outside code spans
+		pieces := strings.Split(line, "`")
+		for j := 0; j < len(pieces); j += 2 {
+			pieces[j] = models.ReplaceWikiLinks(pieces[j], func(link models.WikiLink) string {
+				label := link.Label
+				if label == "" {
+					label = link.Target
+				}
+				return "[" + label + "](<" + NoteLinkScheme + link.Target + ">)"
+			})
+		}
+		lines[i] = strings.Join(pieces, "`")
+	}
+	return strings.Join(lines, "")
+}
+
+// plainText collects the text of a node and all of its descendants
+func plainText(src []byte, n ast.Node) string {
+	var b strings.Builder
+	_ = ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := child.(type) {
+		case *ast.Text:
+			b.Write(t.Segment.Value(src))
+			if t.SoftLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(t.Value)
+		case *ast.AutoLink:
+			b.Write(t.Label(src))
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
+
+// blockText returns the raw lines of a block node such as a code block
+func blockText(src []byte, n ast.Node) string {
+	var b strings.Builder
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		b.Write(line.Value(src))
+	}
+	return b.String()
+}
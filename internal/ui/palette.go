@@ -0,0 +1,226 @@
+// Package ui provides user interface components and setup for the RevEnGo application.
+// This file contains the command palette and the keyboard-driven picker it is built on.
+package ui
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/leog/RevEnGo/internal/models"
+)
+
+// paletteItem is a choice offered by a picker
+type paletteItem struct {
+	// Label is the main text of the choice
+	Label string
+
+	// Detail is shown to the right of the label, such as a shortcut
+	Detail string
+
+	// Run is called when the choice is picked
+	Run func()
+}
+
+// ShowCommandPalette opens a palette listing every action and note.
+// Typing filters the list by fuzzy matching; Up and Down move the
+// selection and Enter runs it.
+//
+// Parameters:
+//   - w: The parent window
+//   - actions: The actions offered
+//   - keys: The shortcuts bound to the actions, by action name
+//   - notes: The notes offered
+//   - openNote: Called with the ID of a picked note
+func ShowCommandPalette(w fyne.Window, actions []Action, keys map[string]string, notes []*models.Note, openNote func(noteID string)) {
+	var all []paletteItem
+	for _, action := range actions {
+		if action.Name == ActionCommandPalette {
+			continue
+		}
+		all = append(all, paletteItem{Label: action.Label, Detail: keys[action.Name], Run: action.Run})
+	}
+	for _, note := range notes {
+		id := note.ID
+		all = append(all, paletteItem{
+			Label:  "Note: " + note.Title,
+			Detail: note.ReverseEngType,
+			Run:    func() { openNote(id) },
+		})
+	}
+
+	showPicker(w, "Command Palette", "Type a command or note title", func(query string) []paletteItem {
+		return fuzzyFilter(all, query)
+	})
+}
+
+// fuzzyFilter returns the items whose labels fuzzily match a query, best
+// matches first. An empty query returns every item in order.
+func fuzzyFilter(items []paletteItem, query string) []paletteItem {
+	if strings.TrimSpace(query) == "" {
+		return items
+	}
+
+	type hit struct {
+		item  paletteItem
+		score int
+	}
+	var hits []hit
+	for _, item := range items {
+		if score, ok := fuzzyScore(query, item.Label); ok {
+			hits = append(hits, hit{item: item, score: score})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].score > hits[j].score
+	})
+
+	result := make([]paletteItem, len(hits))
+	for i, h := range hits {
+		result[i] = h.item
+	}
+	return result
+}
+
+// fuzzyScore reports whether the characters of a query appear in order in
+// a text, ignoring case and spaces, and how well they match. Consecutive
+// characters and characters starting a word score higher.
+//
+// Parameters:
+//   - query: The typed query
+//   - text: The text to match
+//
+// Returns:
+//   - The match score; higher is better
+//   - Whether the text matches at all
+func fuzzyScore(query, text string) (int, bool) {
+	var pattern []rune
+	for _, r := range strings.ToLower(query) {
+		if !unicode.IsSpace(r) {
+			pattern = append(pattern, r)
+		}
+	}
+	runes := []rune(strings.ToLower(text))
+
+	score, matched, previous := 0, 0, -2
+	for i := 0; i < len(runes) && matched < len(pattern); i++ {
+		if runes[i] != pattern[matched] {
+			continue
+		}
+		score++
+		if i == previous+1 {
+			score += 5
+		}
+		if i == 0 || !unicode.IsLetter(runes[i-1]) && !unicode.IsDigit(runes[i-1]) {
+			score += 3
+		}
+		previous = i
+		matched++
+	}
+	if matched < len(pattern) {
+		return 0, false
+	}
+
+	// Prefer shorter texts among equally good matches
+	return score*100 - len(runes), true
+}
+
+// showPicker opens a dialog with a query entry above a list of choices.
+//
+// Parameters:
+//   - w: The parent window
+//   - title: The dialog title
+//   - placeHolder: The hint shown in the empty entry
+//   - search: Returns the choices for a query
+func showPicker(w fyne.Window, title, placeHolder string, search func(query string) []paletteItem) {
+	items := search("")
+	selected := 0
+
+	var picker dialog.Dialog
+	run := func(i int) {
+		if i < 0 || i >= len(items) {
+			return
+		}
+		picker.Hide()
+		items[i].Run()
+	}
+
+	list := widget.NewList(
+		func() int {
+			return len(items)
+		},
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, nil, widget.NewLabel(""), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			row := obj.(*fyne.Container)
+			label := row.Objects[0].(*widget.Label)
+			label.Importance = widget.MediumImportance
+			if id == selected {
+				label.Importance = widget.HighImportance
+			}
+			label.SetText(items[id].Label)
+			row.Objects[1].(*widget.Label).SetText(items[id].Detail)
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		run(id)
+	}
+
+	entry := &pickerEntry{}
+	entry.ExtendBaseWidget(entry)
+	entry.SetPlaceHolder(placeHolder)
+	entry.OnChanged = func(query string) {
+		items = search(query)
+		selected = 0
+		list.Refresh()
+		list.ScrollToTop()
+	}
+	entry.OnSubmitted = func(string) {
+		run(selected)
+	}
+	entry.onMove = func(step int) {
+		if len(items) == 0 {
+			return
+		}
+		selected = min(max(selected+step, 0), len(items)-1)
+		list.Refresh()
+		list.ScrollTo(selected)
+	}
+	entry.onEscape = func() {
+		picker.Hide()
+	}
+
+	picker = dialog.NewCustom(title, "Close", container.NewBorder(entry, nil, nil, nil, list), w)
+	picker.Resize(fyne.NewSize(560, 420))
+	picker.Show()
+	w.Canvas().Focus(entry)
+}
+
+// pickerEntry is the query entry of a picker. Up and Down move the
+// selection in the list below it and Escape closes the picker.
+type pickerEntry struct {
+	widget.Entry
+
+	onMove   func(step int)
+	onEscape func()
+}
+
+// TypedKey implements fyne.Focusable
+func (e *pickerEntry) TypedKey(key *fyne.KeyEvent) {
+	switch key.Name {
+	case fyne.KeyUp:
+		e.onMove(-1)
+	case fyne.KeyDown:
+		e.onMove(1)
+	case fyne.KeyEscape:
+		e.onEscape()
+	default:
+		e.Entry.TypedKey(key)
+	}
+}
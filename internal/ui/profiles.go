@@ -0,0 +1,135 @@
+// Package ui provides user interface components and setup for the RevEnGo application.
+// This file contains the profile switcher.
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/leog/RevEnGo/internal/config"
+)
+
+// ShowProfileSwitcher lets the user pick or create a profile.
+// The chosen profile becomes the default for the next start.
+//
+// Parameters:
+//   - w: The parent window
+//   - current: The configuration of the open profile
+//   - onSwitch: Called with the configuration of the newly opened profile
+func ShowProfileSwitcher(w fyne.Window, current AppConfig, onSwitch func(next AppConfig)) {
+	settings := current.Settings
+	if settings == nil {
+		dialog.ShowInformation("Profiles", "Profiles are not available.", w)
+		return
+	}
+
+	dirLabel := widget.NewLabel("")
+	dirLabel.Wrapping = fyne.TextWrapBreak
+
+	profiles := widget.NewSelect(settings.ProfileNames(), func(name string) {
+		if dir, err := settings.ProfileDataDir(name); err == nil {
+			dirLabel.SetText(dir)
+		}
+	})
+	profiles.SetSelected(current.Profile)
+
+	// The open data directory may differ from the profile's own when it was
+	// given with -data-dir or REVENGO_DATA_DIR
+	dirLabel.SetText(current.DataDir)
+
+	newProfile := widget.NewButtonWithIcon("New Profile", theme.ContentAddIcon(), func() {
+		showNewProfileForm(w, settings, func(name string) {
+			profiles.Options = settings.ProfileNames()
+			profiles.SetSelected(name)
+		})
+	})
+
+	content := container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("Profile", profiles),
+			widget.NewFormItem("Data", dirLabel),
+		),
+		container.NewHBox(newProfile),
+	)
+
+	switcher := dialog.NewCustomConfirm("Switch Profile", "Switch", "Cancel", content, func(confirmed bool) {
+		name := profiles.Selected
+		if !confirmed || name == "" {
+			return
+		}
+
+		dataDir, err := settings.ProfileDataDir(name)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		if name == current.Profile && dataDir == current.DataDir {
+			return
+		}
+		workspace, err := config.Open(name, dataDir)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("opening profile %q: %w", name, err), w)
+			return
+		}
+
+		// Remember the choice for the next start
+		settings.DefaultProfile = name
+		if err := settings.Save(); err != nil {
+			dialog.ShowError(fmt.Errorf("saving configuration: %w", err), w)
+		}
+
+		onSwitch(NewAppConfig(settings, workspace))
+	}, w)
+	switcher.Resize(fyne.NewSize(480, 240))
+	switcher.Show()
+}
+
+// showNewProfileForm asks for the name and data directory of a new profile
+// and saves it to the configuration
+func showNewProfileForm(w fyne.Window, settings *config.Config, onCreated func(name string)) {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("client-engagement")
+	nameEntry.Validator = config.ValidateProfileName
+
+	dirEntry := widget.NewEntry()
+	dirEntry.SetPlaceHolder("Default location")
+	browse := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+			if err == nil && dir != nil {
+				dirEntry.SetText(dir.Path())
+			}
+		}, w)
+	})
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Data Directory", container.NewBorder(nil, nil, nil, browse, dirEntry)),
+	}
+
+	form := dialog.NewForm("New Profile", "Create", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		name := nameEntry.Text
+		if settings.HasProfile(name) {
+			dialog.ShowError(fmt.Errorf("a profile named %q already exists", name), w)
+			return
+		}
+		if err := settings.AddProfile(name, dirEntry.Text); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		if err := settings.Save(); err != nil {
+			dialog.ShowError(fmt.Errorf("saving configuration: %w", err), w)
+			return
+		}
+		onCreated(name)
+	}, w)
+	form.Resize(fyne.NewSize(480, 200))
+	form.Show()
+}
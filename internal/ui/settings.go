@@ -0,0 +1,320 @@
+// Package ui provides user interface components and setup for the RevEnGo application.
+// This file contains the settings dialog.
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/leog/RevEnGo/internal/config"
+	"github.com/leog/RevEnGo/internal/models"
+	"github.com/leog/RevEnGo/internal/report"
+	apptheme "github.com/leog/RevEnGo/internal/ui/theme"
+)
+
+// noProject is the choice for filing new notes under no project
+const noProject = "(none)"
+
+// ShowSettings opens the settings dialog. Confirmed settings are saved to
+// the configuration file and take effect immediately.
+//
+// Parameters:
+//   - w: The parent window
+//   - current: The configuration of the open profile
+//   - actions: The actions whose shortcuts can be changed
+//   - onApply: Called after the settings have been saved
+//   - onSwitch: Called instead of onApply with the reopened workspace when
+//     the data directory was changed
+func ShowSettings(w fyne.Window, current AppConfig, actions []Action, onApply func(), onSwitch func(next AppConfig)) {
+	cfg := current.Settings
+	if cfg == nil {
+		dialog.ShowInformation("Settings", "Settings are not available.", w)
+		return
+	}
+	prefs := cfg.Settings
+
+	// General: autosave, default note type and default project
+	autosaveEntry := widget.NewEntry()
+	autosaveEntry.SetPlaceHolder("Off")
+	if prefs.AutosaveSeconds > 0 {
+		autosaveEntry.SetText(strconv.Itoa(prefs.AutosaveSeconds))
+	}
+	autosaveEntry.Validator = func(text string) error {
+		_, err := parseAutosave(text)
+		return err
+	}
+
+	noteTypeSelect := widget.NewSelect(models.NoteTypeNames(), nil)
+	noteTypeSelect.SetSelected(firstNonEmpty(prefs.DefaultNoteType, models.RETypeGeneral))
+
+	projectIDs, projectNames := projectChoices(current.ProjectStore)
+	projectSelect := widget.NewSelect(projectNames, nil)
+	projectSelect.SetSelectedIndex(0)
+	defaultProject := cfg.DefaultProject(current.Profile)
+	for i, id := range projectIDs {
+		if id == defaultProject {
+			projectSelect.SetSelectedIndex(i)
+		}
+	}
+
+	general := widget.NewForm(
+		widget.NewFormItem("Autosave (seconds)", autosaveEntry),
+		widget.NewFormItem("Default Note Type", noteTypeSelect),
+		widget.NewFormItem("Default Project", projectSelect),
+	)
+
+	// Appearance: theme and editor font size. Theme files are reloaded so
+	// new and edited themes can be picked without a restart.
+	themeErrors := widget.NewLabel("")
+	themeErrors.Wrapping = fyne.TextWrapWord
+	themeErrors.Importance = widget.DangerImportance
+	themeErrors.Hide()
+	if _, err := apptheme.LoadDir(cfg.ThemesDir()); err != nil {
+		themeErrors.SetText(err.Error())
+		themeErrors.Show()
+	}
+	themeSelect := widget.NewSelect(apptheme.Names(), nil)
+	themeSelect.SetSelected(firstNonEmpty(prefs.Theme, apptheme.Dark))
+
+	fontSizeEntry := widget.NewEntry()
+	fontSizeEntry.SetPlaceHolder("Theme default")
+	if prefs.EditorFontSize > 0 {
+		fontSizeEntry.SetText(strconv.FormatFloat(float64(prefs.EditorFontSize), 'g', -1, 32))
+	}
+	fontSizeEntry.Validator = func(text string) error {
+		_, err := parseFontSize(text)
+		return err
+	}
+
+	appearance := container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("Theme", themeSelect),
+			widget.NewFormItem("Editor Font Size", fontSizeEntry),
+		),
+		widget.NewLabel("Theme files (TOML or JSON) are read from "+cfg.ThemesDir()),
+		themeErrors,
+	)
+
+	// Data: the open profile's data directory
+	dataDirEntry := widget.NewEntry()
+	dataDirEntry.SetText(current.DataDir)
+	browse := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+			if err == nil && dir != nil {
+				dataDirEntry.SetText(dir.Path())
+			}
+		}, w)
+	})
+	data := widget.NewForm(
+		widget.NewFormItem("Profile", widget.NewLabel(current.Profile)),
+		widget.NewFormItem("Data Directory", container.NewBorder(nil, nil, nil, browse, dataDirEntry)),
+	)
+
+	// Keys: one shortcut per action
+	keys := widget.NewForm()
+	keyEntries := make(map[string]*widget.Entry)
+	for _, action := range actions {
+		entry := widget.NewEntry()
+		entry.SetPlaceHolder("Unbound")
+		entry.SetText(keybinding(action.Name, prefs.Keybindings))
+		entry.Validator = func(text string) error {
+			if strings.TrimSpace(text) == "" {
+				return nil
+			}
+			_, err := ParseShortcut(text)
+			return err
+		}
+		keyEntries[action.Name] = entry
+		keys.Append(action.Label, entry)
+	}
+
+	// Reports: the fields marked internal, which reports redact
+	var redactLabels []string
+	for _, field := range report.RedactableFields {
+		redactLabels = append(redactLabels, report.FieldLabels[field])
+	}
+	redactGroup := widget.NewCheckGroup(redactLabels, nil)
+	for _, field := range prefs.ReportRedact {
+		redactGroup.Selected = append(redactGroup.Selected, report.FieldLabels[field])
+	}
+	reports := container.NewVBox(
+		widget.NewLabel("Fields marked internal are redacted from vulnerability reports:"),
+		redactGroup,
+		widget.NewLabel("Report templates (<name>.md.tmpl, <name>.html.tmpl) are read from "+filepath.Join(current.DataDir, report.TemplatesDir)),
+	)
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("General", general),
+		container.NewTabItem("Appearance", appearance),
+		container.NewTabItem("Data", data),
+		container.NewTabItem("Keys", container.NewVScroll(keys)),
+		container.NewTabItem("Reports", reports),
+	)
+
+	settingsDialog := dialog.NewCustomConfirm("Settings", "Apply", "Cancel", tabs, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		autosave, err := parseAutosave(autosaveEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		fontSize, err := parseFontSize(fontSizeEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+
+		// Only keep bindings that differ from the defaults
+		bindings := make(map[string]string)
+		for _, action := range actions {
+			binding := strings.TrimSpace(keyEntries[action.Name].Text)
+			if binding != defaultKeybindings[action.Name] {
+				bindings[action.Name] = binding
+			}
+		}
+		if err := checkKeybindings(actions, bindings); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+
+		var redact []string
+		for _, field := range report.RedactableFields {
+			if slices.Contains(redactGroup.Selected, report.FieldLabels[field]) {
+				redact = append(redact, field)
+			}
+		}
+
+		next := config.Settings{
+			AutosaveSeconds: autosave,
+			DefaultNoteType: noteTypeSelect.Selected,
+			EditorFontSize:  fontSize,
+			Theme:           themeSelect.Selected,
+			Keybindings:     bindings,
+			ReportRedact:    redact,
+		}
+		if err := next.Validate(); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		// The configuration keeps its previous values unless everything
+		// below succeeds, so a failed change is neither applied nor saved
+		// later along with other changes
+		previousSettings := cfg.Settings
+		previousProfile, hadProfile := cfg.Profiles[current.Profile]
+		restore := func() {
+			cfg.Settings = previousSettings
+			if hadProfile {
+				cfg.Profiles[current.Profile] = previousProfile
+			} else {
+				delete(cfg.Profiles, current.Profile)
+			}
+		}
+
+		cfg.Settings = next
+		if i := projectSelect.SelectedIndex(); i >= 0 {
+			cfg.SetDefaultProject(current.Profile, projectIDs[i])
+		}
+
+		// A new data directory reopens the profile from there
+		var workspace *config.Workspace
+		if dataDir := strings.TrimSpace(dataDirEntry.Text); dataDir != "" && dataDir != current.DataDir {
+			if err := cfg.AddProfile(current.Profile, dataDir); err != nil {
+				restore()
+				dialog.ShowError(err, w)
+				return
+			}
+			resolved, err := cfg.ProfileDataDir(current.Profile)
+			if err == nil {
+				workspace, err = config.Open(current.Profile, resolved)
+			}
+			if err != nil {
+				restore()
+				dialog.ShowError(fmt.Errorf("opening data directory %s: %w", dataDir, err), w)
+				return
+			}
+		}
+
+		if err := cfg.Save(); err != nil {
+			restore()
+			if workspace != nil {
+				workspace.Close()
+			}
+			dialog.ShowError(fmt.Errorf("saving configuration: %w", err), w)
+			return
+		}
+
+		if workspace != nil {
+			onSwitch(NewAppConfig(cfg, workspace))
+			return
+		}
+		onApply()
+	}, w)
+	settingsDialog.Resize(fyne.NewSize(560, 420))
+	settingsDialog.Show()
+}
+
+// projectChoices lists the projects new notes can be filed under, starting
+// with the choice of no project
+func projectChoices(store models.ProjectStore) (ids, names []string) {
+	ids, names = []string{""}, []string{noProject}
+	if store == nil {
+		return ids, names
+	}
+	projects, err := store.ListProjects()
+	if err != nil {
+		return ids, names
+	}
+	for _, project := range projects {
+		ids = append(ids, project.ID)
+		names = append(names, fmt.Sprintf("%s (%s)", project.Name, project.ID))
+	}
+	return ids, names
+}
+
+// parseAutosave parses the autosave interval in seconds; empty means off
+func parseAutosave(text string) (int, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, nil
+	}
+	seconds, err := strconv.Atoi(text)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("invalid autosave interval %q: enter a number of seconds, or nothing to disable autosave", text)
+	}
+	return seconds, nil
+}
+
+// parseFontSize parses the editor font size; empty means the theme's size
+func parseFontSize(text string) (float32, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, nil
+	}
+	size, err := strconv.ParseFloat(text, 32)
+	if err != nil || size < config.MinEditorFontSize || size > config.MaxEditorFontSize {
+		return 0, fmt.Errorf("invalid font size %q: enter %d to %d", text, config.MinEditorFontSize, config.MaxEditorFontSize)
+	}
+	return float32(size), nil
+}
+
+// firstNonEmpty returns the first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
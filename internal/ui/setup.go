@@ -2,29 +2,84 @@
 package ui
 
 import (
+	"log"
+	"path/filepath"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
+	"github.com/leog/RevEnGo/internal/config"
 	"github.com/leog/RevEnGo/internal/models"
+	"github.com/leog/RevEnGo/internal/report"
 	"github.com/leog/RevEnGo/internal/ui/components"
+	apptheme "github.com/leog/RevEnGo/internal/ui/theme"
+	"github.com/leog/RevEnGo/internal/ui/widgets"
 )
 
 // AppConfig holds the application configuration and dependencies
 type AppConfig struct {
 	NoteStore    models.NoteStore
 	ProjectStore models.ProjectStore
+
+	// Changes reports edits made outside the GUI; may be nil
+	Changes *models.ChangeFeed
+
+	// DataDir is the application data directory
+	DataDir string
+
+	// Settings is the persistent configuration holding the profiles;
+	// the profile switcher is unavailable when it is nil
+	Settings *config.Config
+
+	// Profile is the name of the open profile
+	Profile string
+
+	// workspace is the open workspace, closed when switching profiles
+	workspace *config.Workspace
+}
+
+// NewAppConfig builds the UI configuration for an opened workspace.
+//
+// Parameters:
+//   - settings: The persistent configuration
+//   - workspace: The workspace to show
+//
+// Returns:
+//   - The configuration for SetupMainWindow
+func NewAppConfig(settings *config.Config, workspace *config.Workspace) AppConfig {
+	return AppConfig{
+		NoteStore:    workspace.Notes,
+		ProjectStore: workspace.Projects,
+		Changes:      workspace.Changes,
+		DataDir:      workspace.DataDir,
+		Settings:     settings,
+		Profile:      workspace.Profile,
+		workspace:    workspace,
+	}
 }
 
 // SetupMainWindow configures the main application window and its components
-func SetupMainWindow(w fyne.Window, config AppConfig) {
+func SetupMainWindow(w fyne.Window, appConfig AppConfig) {
 	// Set window size
 	w.Resize(fyne.NewSize(1200, 800))
 
+	// Show the profile in the title when it is not the default one
+	if appConfig.Profile != "" && appConfig.Profile != config.DefaultProfile {
+		w.SetTitle("RevEnGo - " + appConfig.Profile)
+	} else {
+		w.SetTitle("RevEnGo")
+	}
+
+	// showSettings opens the settings dialog; it is assigned once the
+	// components it configures exist
+	var showSettings func()
+
 	// Create the main UI components
-	header := components.NewHeader()
-	sidebar := components.NewSidebar()
+	sidebar := components.NewSidebar(func() {
+		showSettings()
+	})
 	notepad := components.NewNotePad()
 
 	// Create the content layout
@@ -34,17 +89,113 @@ func SetupMainWindow(w fyne.Window, config AppConfig) {
 	)
 	content.Offset = 0.2
 
-	// Create the main layout
-	mainLayout := container.NewBorder(
-		header,  // top component
-		nil,     // bottom component (none)
-		nil,     // left component (none)
-		nil,     // right component (none)
-		content, // center component
-	)
+	// Report outcomes with toasts and the activity log, which is kept in
+	// the data directory
+	activityPath := ""
+	if appConfig.DataDir != "" {
+		activityPath = filepath.Join(appConfig.DataDir, ActivityFile)
+	}
+	toasts := widgets.NewToastLayer()
+	feedback := NewFeedback(w, toasts, NewActivityLog(activityPath))
 
 	// Create note controller
-	noteController := NewNoteController(config.NoteStore, w, notepad, sidebar)
+	jobs := &Jobs{}
+	noteController := NewNoteController(appConfig.NoteStore, appConfig.ProjectStore, jobs, feedback, w, notepad, sidebar)
+	if appConfig.DataDir != "" {
+		noteController.TemplateDir = filepath.Join(appConfig.DataDir, models.TemplatesDir)
+		noteController.ReportDir = filepath.Join(appConfig.DataDir, report.TemplatesDir)
+	}
+	apiServer := NewAPIServerController(appConfig, w)
+
+	// Create the header, whose status area follows the controller and the
+	// background jobs
+	header := components.NewHeader(
+		noteController.CreateNewNote,
+		noteController.ShowQuickOpen,
+		func() { noteController.SaveCurrentNote() },
+	)
+	updateStatus := func() {
+		status := noteController.Status()
+		status.Job, status.JobProgress, status.Jobs = jobs.Current()
+		header.SetStatus(status)
+	}
+	noteController.OnStatusChanged = updateStatus
+	jobs.OnChanged = updateStatus
+
+	// shortcuts binds the keyboard shortcuts of the actions defined below
+	shortcuts := &shortcutBinder{canvas: w.Canvas()}
+
+	// closeWorkspace releases everything tied to the open profile
+	closeWorkspace := func() {
+		shortcuts.unbind()
+		apiServer.Stop()
+		noteController.Close()
+		if appConfig.workspace != nil {
+			appConfig.workspace.Close()
+		}
+	}
+
+	// switchWorkspace replaces the window contents with another workspace
+	switchWorkspace := func(next AppConfig) {
+		closeWorkspace()
+		SetupMainWindow(w, next)
+	}
+
+	// The activity log panel is shown below the content on demand
+	activityPanel := newActivityPanel(feedback.Activity)
+	workArea := container.NewStack(content)
+	toggleActivity := func() {
+		if len(workArea.Objects) == 1 && workArea.Objects[0] == content {
+			split := container.NewVSplit(content, activityPanel)
+			split.Offset = 0.75
+			workArea.Objects = []fyne.CanvasObject{split}
+		} else {
+			workArea.Objects = []fyne.CanvasObject{content}
+		}
+		workArea.Refresh()
+	}
+
+	// Actions that can be bound to keyboard shortcuts
+	shortcuts.actions = []Action{
+		{Name: ActionNewNote, Label: "New Note", Run: noteController.CreateNewNote},
+		{Name: ActionSaveNote, Label: "Save Note", Run: func() { noteController.SaveCurrentNote() }},
+		{Name: ActionDeleteNote, Label: "Delete Note", Run: func() { noteController.DeleteNote() }},
+		{Name: ActionQuickOpen, Label: "Open Note, Project or Binary", Run: noteController.ShowQuickOpen},
+		{Name: ActionSearch, Label: "Search Notes", Run: noteController.ShowSearch},
+		{Name: ActionNextNote, Label: "Next Note", Run: func() { noteController.ShowAdjacentNote(1) }},
+		{Name: ActionPreviousNote, Label: "Previous Note", Run: func() { noteController.ShowAdjacentNote(-1) }},
+		{Name: ActionNextTab, Label: "Next Field Tab", Run: notepad.NextTab},
+		{Name: ActionGoToAddress, Label: "Go to Address", Run: noteController.ShowGoToAddress},
+		{Name: ActionTogglePreview, Label: "Toggle Preview", Run: notepad.TogglePreview},
+		{Name: ActionCommandPalette, Label: "Command Palette", Run: func() {
+			ShowCommandPalette(w, shortcuts.actions, shortcuts.keys, noteController.Notes(), noteController.ShowNote)
+		}},
+		{Name: ActionPopOut, Label: "Open in New Window", Run: noteController.PopOutNote},
+		{Name: ActionCaptures, Label: "Packet Captures", Run: noteController.ShowCaptures},
+		{Name: ActionStrings, Label: "Binary Strings", Run: noteController.ShowStrings},
+		{Name: ActionFindings, Label: "Vulnerability Findings", Run: noteController.ShowFindings},
+		{Name: ActionActivityLog, Label: "Show or Hide Activity Log", Run: toggleActivity},
+		{Name: ActionSettings, Label: "Settings", Run: func() { showSettings() }},
+		{Name: ActionSwitchProfile, Label: "Switch Profile", Run: func() {
+			ShowProfileSwitcher(w, appConfig, switchWorkspace)
+		}},
+	}
+
+	// applySettings puts the saved preferences into effect
+	applySettings := func() {
+		var prefs config.Settings
+		defaultProject := ""
+		if appConfig.Settings != nil {
+			prefs = appConfig.Settings.Settings
+			defaultProject = appConfig.Settings.DefaultProject(appConfig.Profile)
+		}
+		fyne.CurrentApp().Settings().SetTheme(apptheme.Named(prefs.Theme))
+		noteController.ApplySettings(prefs, defaultProject)
+		shortcuts.bind(prefs.Keybindings)
+	}
+	showSettings = func() {
+		ShowSettings(w, appConfig, shortcuts.actions, applySettings, switchWorkspace)
+	}
 
 	// Set up toolbar actions
 	toolbar := widget.NewToolbar(
@@ -58,6 +209,18 @@ func SetupMainWindow(w fyne.Window, config AppConfig) {
 		widget.NewToolbarAction(theme.DeleteIcon(), func() {
 			noteController.DeleteNote()
 		}),
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarAction(theme.ViewFullScreenIcon(), func() {
+			noteController.PopOutNote()
+		}),
+		widget.NewToolbarSpacer(),
+		widget.NewToolbarAction(theme.HistoryIcon(), toggleActivity),
+		widget.NewToolbarAction(theme.AccountIcon(), func() {
+			ShowProfileSwitcher(w, appConfig, switchWorkspace)
+		}),
+		widget.NewToolbarAction(theme.ComputerIcon(), func() {
+			apiServer.Toggle()
+		}),
 	)
 
 	// Add toolbar to the header
@@ -69,23 +232,35 @@ func SetupMainWindow(w fyne.Window, config AppConfig) {
 		header,
 	)
 
-	// Update the main layout with the new header
-	mainLayout = container.NewBorder(
+	// Create the main layout with the header above the content
+	mainLayout := container.NewBorder(
 		headerContainer,
 		nil,
 		nil,
 		nil,
-		content,
+		workArea,
 	)
 
-	// Set the window content
-	w.SetContent(mainLayout)
+	// Set the window content, with toasts shown over it
+	w.SetContent(container.NewStack(mainLayout, toasts))
 
 	// Set up window close handler
 	w.SetOnClosed(func() {
 		// TODO: Implement saving of unsaved data before closing
+		closeWorkspace()
 	})
 
+	// Apply the saved preferences and start on an empty note
+	applySettings()
+	noteController.CreateNewNote()
+
 	// Load initial note list
 	noteController.RefreshNoteList()
+
+	// Follow edits made through the API or the command line
+	if appConfig.Changes != nil {
+		if err := noteController.WatchChanges(appConfig.Changes); err != nil {
+			log.Printf("Warning: not watching for note changes: %v", err)
+		}
+	}
 }
@@ -0,0 +1,244 @@
+// Package ui provides user interface components and setup for the RevEnGo application.
+// This file contains the keyboard shortcuts of the main window.
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// Names of the actions that can be bound to shortcuts.
+// They are the keys of the [settings.keybindings] table in the configuration.
+const (
+	ActionNewNote        = "new_note"
+	ActionSaveNote       = "save_note"
+	ActionDeleteNote     = "delete_note"
+	ActionQuickOpen      = "quick_open"
+	ActionSearch         = "search"
+	ActionNextNote       = "next_note"
+	ActionPreviousNote   = "previous_note"
+	ActionNextTab        = "next_tab"
+	ActionGoToAddress    = "go_to_address"
+	ActionTogglePreview  = "toggle_preview"
+	ActionCommandPalette = "command_palette"
+	ActionPopOut         = "pop_out"
+	ActionActivityLog    = "activity_log"
+	ActionSettings       = "settings"
+	ActionSwitchProfile  = "switch_profile"
+	ActionCaptures       = "captures"
+	ActionStrings        = "strings"
+	ActionFindings       = "findings"
+)
+
+// defaultKeybindings are the shortcuts used for actions the configuration
+// does not rebind
+var defaultKeybindings = map[string]string{
+	ActionNewNote:        "CmdOrCtrl+N",
+	ActionSaveNote:       "CmdOrCtrl+S",
+	ActionDeleteNote:     "CmdOrCtrl+Shift+Delete",
+	ActionQuickOpen:      "CmdOrCtrl+O",
+	ActionSearch:         "CmdOrCtrl+F",
+	ActionNextNote:       "CmdOrCtrl+PageDown",
+	ActionPreviousNote:   "CmdOrCtrl+PageUp",
+	ActionNextTab:        "CmdOrCtrl+T",
+	ActionGoToAddress:    "CmdOrCtrl+G",
+	ActionTogglePreview:  "CmdOrCtrl+E",
+	ActionCommandPalette: "CmdOrCtrl+Shift+P",
+	ActionPopOut:         "CmdOrCtrl+Shift+O",
+	ActionActivityLog:    "CmdOrCtrl+Shift+L",
+	ActionSettings:       "CmdOrCtrl+Comma",
+	ActionSwitchProfile:  "CmdOrCtrl+Shift+U",
+	ActionCaptures:       "CmdOrCtrl+Shift+K",
+	ActionStrings:        "CmdOrCtrl+Shift+B",
+	ActionFindings:       "CmdOrCtrl+Shift+V",
+}
+
+// Action is a command of the main window that can be bound to a shortcut
+type Action struct {
+	// Name identifies the action in the configuration
+	Name string
+
+	// Label describes the action to the user
+	Label string
+
+	// Run performs the action
+	Run func()
+}
+
+// keyNames maps the names of keys without a single-character name to
+// the keys Fyne reports
+var keyNames = map[string]fyne.KeyName{
+	"Backspace": fyne.KeyBackspace,
+	"Comma":     fyne.KeyComma,
+	"Delete":    fyne.KeyDelete,
+	"Down":      fyne.KeyDown,
+	"End":       fyne.KeyEnd,
+	"Enter":     fyne.KeyReturn,
+	"Equal":     fyne.KeyEqual,
+	"Escape":    fyne.KeyEscape,
+	"Home":      fyne.KeyHome,
+	"Insert":    fyne.KeyInsert,
+	"Left":      fyne.KeyLeft,
+	"Minus":     fyne.KeyMinus,
+	"PageDown":  fyne.KeyPageDown,
+	"PageUp":    fyne.KeyPageUp,
+	"Period":    fyne.KeyPeriod,
+	"Return":    fyne.KeyReturn,
+	"Right":     fyne.KeyRight,
+	"Slash":     fyne.KeySlash,
+	"Space":     fyne.KeySpace,
+	"Tab":       fyne.KeyTab,
+	"Up":        fyne.KeyUp,
+}
+
+// ParseShortcut parses a shortcut such as "CmdOrCtrl+Shift+P".
+// Modifiers are CmdOrCtrl (Cmd on macOS, Ctrl elsewhere), Ctrl, Alt,
+// Shift and Super. The key is a letter, a digit, F1 to F12 or a key name
+// such as Delete, Comma or PageUp. Case is ignored.
+//
+// Parameters:
+//   - text: The shortcut to parse
+//
+// Returns:
+//   - The shortcut
+//   - An error if the shortcut is malformed or has no modifier besides Shift
+func ParseShortcut(text string) (*desktop.CustomShortcut, error) {
+	parts := strings.Split(strings.TrimSpace(text), "+")
+	shortcut := &desktop.CustomShortcut{}
+
+	for _, part := range parts[:len(parts)-1] {
+		switch strings.ToLower(strings.TrimSpace(part)) {
+		case "cmdorctrl":
+			shortcut.Modifier |= fyne.KeyModifierShortcutDefault
+		case "ctrl", "control":
+			shortcut.Modifier |= fyne.KeyModifierControl
+		case "alt", "option":
+			shortcut.Modifier |= fyne.KeyModifierAlt
+		case "shift":
+			shortcut.Modifier |= fyne.KeyModifierShift
+		case "super", "cmd", "command":
+			shortcut.Modifier |= fyne.KeyModifierSuper
+		default:
+			return nil, fmt.Errorf("unknown modifier %q in shortcut %q", part, text)
+		}
+	}
+
+	key, err := parseKey(strings.TrimSpace(parts[len(parts)-1]))
+	if err != nil {
+		return nil, fmt.Errorf("%w in shortcut %q", err, text)
+	}
+	shortcut.KeyName = key
+
+	// Keys pressed with Shift alone or no modifier are typed as text
+	if shortcut.Modifier&^fyne.KeyModifierShift == 0 {
+		return nil, fmt.Errorf("shortcut %q needs a modifier such as CmdOrCtrl or Alt", text)
+	}
+	return shortcut, nil
+}
+
+// parseKey parses the key of a shortcut
+func parseKey(name string) (fyne.KeyName, error) {
+	if name == "" {
+		return "", errors.New("missing key")
+	}
+	if len(name) == 1 {
+		c := strings.ToUpper(name)[0]
+		if c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			return fyne.KeyName(string(c)), nil
+		}
+	}
+	for n := 1; n <= 12; n++ {
+		if strings.EqualFold(name, fmt.Sprintf("F%d", n)) {
+			return fyne.KeyName(fmt.Sprintf("F%d", n)), nil
+		}
+	}
+	for keyName, key := range keyNames {
+		if strings.EqualFold(name, keyName) {
+			return key, nil
+		}
+	}
+	return "", fmt.Errorf("unknown key %q", name)
+}
+
+// keybinding returns the shortcut bound to an action: the configured one
+// if there is one, otherwise the default
+func keybinding(action string, configured map[string]string) string {
+	if binding, ok := configured[action]; ok {
+		return binding
+	}
+	return defaultKeybindings[action]
+}
+
+// checkKeybindings validates the shortcuts of every action and reports
+// shortcuts bound to more than one action
+func checkKeybindings(actions []Action, configured map[string]string) error {
+	used := make(map[string]string)
+	for _, action := range actions {
+		binding := keybinding(action.Name, configured)
+		if binding == "" {
+			continue
+		}
+		shortcut, err := ParseShortcut(binding)
+		if err != nil {
+			return fmt.Errorf("%s: %w", action.Label, err)
+		}
+		if other, ok := used[shortcut.ShortcutName()]; ok {
+			return fmt.Errorf("%s is bound to both %s and %s", binding, other, action.Label)
+		}
+		used[shortcut.ShortcutName()] = action.Label
+	}
+	return nil
+}
+
+// shortcutBinder registers the actions' shortcuts on a window canvas and
+// replaces them when the keybindings change
+type shortcutBinder struct {
+	canvas  fyne.Canvas
+	actions []Action
+
+	// bound are the shortcuts currently registered
+	bound []fyne.Shortcut
+
+	// keys maps action names to their registered shortcuts as written in
+	// the configuration, for showing in the command palette
+	keys map[string]string
+}
+
+// bind registers the actions' shortcuts, replacing those bound before.
+// Invalid shortcuts are skipped with a warning.
+func (b *shortcutBinder) bind(configured map[string]string) {
+	b.unbind()
+
+	b.keys = make(map[string]string)
+	for _, action := range b.actions {
+		binding := keybinding(action.Name, configured)
+		if binding == "" {
+			continue
+		}
+		shortcut, err := ParseShortcut(binding)
+		if err != nil {
+			log.Printf("Warning: not binding %s: %v", action.Name, err)
+			continue
+		}
+		run := action.Run
+		b.canvas.AddShortcut(shortcut, func(fyne.Shortcut) {
+			run()
+		})
+		b.bound = append(b.bound, shortcut)
+		b.keys[action.Name] = binding
+	}
+}
+
+// unbind removes the registered shortcuts from the canvas
+func (b *shortcutBinder) unbind() {
+	for _, shortcut := range b.bound {
+		b.canvas.RemoveShortcut(shortcut)
+	}
+	b.bound = nil
+	b.keys = nil
+}
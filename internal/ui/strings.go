@@ -0,0 +1,358 @@
+// Package ui provides user interface components and setup for the RevEnGo application.
+// This file contains the browser of the strings in the binaries attached to projects.
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/leog/RevEnGo/internal/binfile"
+	"github.com/leog/RevEnGo/internal/models"
+)
+
+// Choices of the filters that select nothing out
+const (
+	allEncodings = "All encodings"
+	allSections  = "All sections"
+)
+
+// maxStringShown bounds the characters of a string shown in the list
+const maxStringShown = 200
+
+// stringsBrowser is the window listing the strings of the binaries
+// attached to a project. A string becomes a new note in the main window
+// with its address and the binary filled in.
+type stringsBrowser struct {
+	c      *NoteController
+	window fyne.Window
+
+	projects []*models.Project
+	project  *models.Project
+
+	// file is the binary shown, found its strings and shown those matching
+	// the search
+	file  *binfile.File
+	found []binfile.String
+	shown []binfile.String
+
+	// selected is the index in shown of the selected string; -1 if none
+	selected int
+
+	projectSelect  *widget.Select
+	binarySelect   *widget.Select
+	minLength      *widget.Entry
+	encodingSelect *widget.Select
+	sectionSelect  *widget.Select
+	search         *widget.Entry
+	status         *widget.Label
+	list           *widget.List
+}
+
+// ShowStrings opens the strings of the binaries of the current note's
+// project. Only one strings browser is open at a time.
+func (c *NoteController) ShowStrings() {
+	if c.binaries != nil {
+		c.binaries.window.RequestFocus()
+		return
+	}
+	if c.projectStore == nil {
+		return
+	}
+	projects, err := c.projectStore.ListProjects()
+	if err != nil {
+		c.feedback.Error(nil, "Listing projects", err, nil)
+		return
+	}
+	if len(projects) == 0 {
+		dialog.ShowInformation("No Projects", "Binaries are attached to projects. Create a project first, for example with: revengo project add -name NAME", c.window)
+		return
+	}
+
+	b := &stringsBrowser{c: c, projects: projects, selected: -1}
+	b.window = fyne.CurrentApp().NewWindow("RevEnGo - Binary Strings")
+	b.window.SetContent(b.build())
+	b.window.Resize(fyne.NewSize(1100, 720))
+	b.window.SetOnClosed(func() { c.binaries = nil })
+	c.binaries = b
+
+	// Start with the project of the note being edited
+	current := c.notepad.ProjectID()
+	if current == "" {
+		current = c.notepad.DefaultProjectID
+	}
+	index := slices.IndexFunc(projects, func(p *models.Project) bool { return p.ID == current })
+	b.projectSelect.SetSelectedIndex(max(index, 0))
+	b.window.Show()
+}
+
+// build creates the browser's layout
+func (b *stringsBrowser) build() fyne.CanvasObject {
+	names := make([]string, len(b.projects))
+	for i, project := range b.projects {
+		names[i] = fmt.Sprintf("%s (%s)", project.Name, project.ID)
+	}
+	b.projectSelect = widget.NewSelect(names, func(string) {
+		b.project = b.projects[b.projectSelect.SelectedIndex()]
+		b.setBinaries("")
+	})
+	b.binarySelect = widget.NewSelect(nil, func(string) {
+		if i := b.binarySelect.SelectedIndex(); i >= 0 {
+			b.load(b.project.Binaries[i])
+		}
+	})
+	b.binarySelect.PlaceHolder = "(no binaries attached)"
+	attachButton := widget.NewButtonWithIcon("Attach...", theme.ContentAddIcon(), b.attach)
+	detachButton := widget.NewButtonWithIcon("Detach", theme.ContentRemoveIcon(), b.detach)
+	b.status = widget.NewLabel("")
+
+	b.minLength = widget.NewEntry()
+	b.minLength.SetText(strconv.Itoa(binfile.DefaultMinLength))
+	b.minLength.OnChanged = func(string) {
+		if _, err := b.minimum(); err == nil {
+			b.extract()
+		}
+	}
+	b.encodingSelect = widget.NewSelect(append([]string{allEncodings}, binfile.Encodings...), func(string) { b.extract() })
+	b.encodingSelect.Selected = allEncodings
+	b.sectionSelect = widget.NewSelect([]string{allSections}, func(string) { b.extract() })
+	b.sectionSelect.Selected = allSections
+	b.search = widget.NewEntry()
+	b.search.SetPlaceHolder("Search strings")
+	b.search.OnChanged = func(string) { b.filter() }
+
+	b.list = widget.NewList(
+		func() int { return len(b.shown) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.TextStyle = fyne.TextStyle{Monospace: true}
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(formatString(b.shown[id]))
+		},
+	)
+	b.list.OnSelected = func(id widget.ListItemID) { b.selected = id }
+	b.list.OnUnselected = func(widget.ListItemID) { b.selected = -1 }
+	header := widget.NewLabel(fmt.Sprintf("%-10s  %-18s  %-18s  %-8s  %s", "OFFSET", "VA", "SECTION", "ENCODING", "STRING"))
+	header.TextStyle = fyne.TextStyle{Monospace: true, Bold: true}
+	noteButton := widget.NewButtonWithIcon("New Note", theme.DocumentCreateIcon(), b.newNote)
+	copyButton := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), b.copy)
+
+	toolbar := container.NewBorder(nil, nil,
+		container.NewHBox(widget.NewLabel("Project:"), b.projectSelect, widget.NewLabel("Binary:")),
+		container.NewHBox(attachButton, detachButton),
+		b.binarySelect,
+	)
+	filters := container.NewBorder(nil, nil,
+		container.NewHBox(
+			widget.NewLabel("Min length:"),
+			container.NewGridWrap(fyne.NewSize(60, b.minLength.MinSize().Height), b.minLength),
+			b.encodingSelect,
+			b.sectionSelect,
+		),
+		nil,
+		b.search,
+	)
+	return container.NewBorder(
+		container.NewVBox(toolbar, filters, b.status, header),
+		container.NewHBox(noteButton, copyButton),
+		nil, nil,
+		b.list,
+	)
+}
+
+// formatString formats a string as a line of the list
+func formatString(s binfile.String) string {
+	va := "-"
+	if s.Mapped {
+		va = fmt.Sprintf("0x%x", s.VA)
+	}
+	text := strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(s.Text)
+	if runes := []rune(text); len(runes) > maxStringShown {
+		text = string(runes[:maxStringShown]) + "..."
+	}
+	return fmt.Sprintf("0x%08x  %-18s  %-18s  %-8s  %s", s.Offset, va, s.Section, s.Encoding, text)
+}
+
+// setBinaries lists the binaries of the selected project and shows one
+func (b *stringsBrowser) setBinaries(show string) {
+	options := make([]string, len(b.project.Binaries))
+	for i, path := range b.project.Binaries {
+		options[i] = filepath.Base(path) + "  -  " + filepath.Dir(path)
+	}
+	b.binarySelect.Options = options
+	b.binarySelect.Selected = ""
+	b.binarySelect.Refresh()
+	b.showFile(nil)
+	if index := slices.Index(b.project.Binaries, show); index >= 0 {
+		b.binarySelect.SetSelectedIndex(index)
+	} else if len(options) > 0 {
+		b.binarySelect.SetSelectedIndex(0)
+	} else {
+		b.status.SetText("Attach an executable, library or firmware image to list its strings")
+	}
+}
+
+// load reads a binary in the background and lists its strings
+func (b *stringsBrowser) load(path string) {
+	b.showFile(nil)
+	b.status.SetText("Reading " + filepath.Base(path) + "...")
+	job := b.c.jobs.Start("Reading " + filepath.Base(path))
+	go func() {
+		defer job.Done()
+		file, err := binfile.Open(path)
+		if err != nil {
+			b.status.SetText("ERROR: " + err.Error())
+			return
+		}
+		b.showFile(file)
+	}()
+}
+
+// showFile offers the sections of a binary and extracts its strings
+func (b *stringsBrowser) showFile(file *binfile.File) {
+	b.file = file
+	sections := []string{allSections}
+	if file != nil {
+		sections = append(sections, file.SectionNames()...)
+	}
+	b.sectionSelect.Options = sections
+	b.sectionSelect.Selected = allSections
+	b.sectionSelect.Refresh()
+	b.extract()
+}
+
+// minimum reads the minimum string length
+func (b *stringsBrowser) minimum() (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(b.minLength.Text))
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid minimum length %q", b.minLength.Text)
+	}
+	return n, nil
+}
+
+// extract finds the strings of the binary that pass the filters
+func (b *stringsBrowser) extract() {
+	b.found = nil
+	if b.file == nil {
+		b.filter()
+		return
+	}
+	opts := binfile.StringOptions{MinLength: binfile.DefaultMinLength}
+	if n, err := b.minimum(); err == nil {
+		opts.MinLength = n
+	}
+	if encoding := b.encodingSelect.Selected; encoding != allEncodings {
+		opts.Encodings = []string{encoding}
+	}
+	if section := b.sectionSelect.Selected; section != allSections {
+		opts.Sections = []string{section}
+	}
+	found, err := b.file.Strings(opts)
+	if err != nil {
+		b.status.SetText("ERROR: " + err.Error())
+		b.filter()
+		return
+	}
+	b.found = found
+	b.filter()
+}
+
+// filter shows the strings matching the search
+func (b *stringsBrowser) filter() {
+	b.shown = binfile.FilterStrings(b.found, b.search.Text)
+	b.selected = -1
+	b.list.UnselectAll()
+	b.list.Refresh()
+	if b.file == nil {
+		return
+	}
+	status := fmt.Sprintf("%s: %s", filepath.Base(b.file.Path), b.file.Format)
+	if b.file.Arch != "" {
+		status += " " + b.file.Arch
+	}
+	status += fmt.Sprintf(", %d sections, %d strings", len(b.file.Sections), len(b.found))
+	if len(b.shown) != len(b.found) {
+		status += fmt.Sprintf(", %d matching", len(b.shown))
+	}
+	b.status.SetText(status)
+}
+
+// attach adds a binary to the project
+func (b *stringsBrowser) attach() {
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		reader.Close()
+		path := reader.URI().Path()
+		if !slices.Contains(b.project.Binaries, path) {
+			b.project.Binaries = append(b.project.Binaries, path)
+			if err := b.c.projectStore.SaveProject(b.project); err != nil {
+				b.c.feedback.Error(b.window, "Attaching binary", err, nil)
+				return
+			}
+			b.c.feedback.Record(ActivityImport, fmt.Sprintf("Attached %s to project %q", filepath.Base(path), b.project.Name))
+		}
+		b.setBinaries(path)
+	}, b.window)
+}
+
+// detach removes the shown binary from the project; the file is kept
+func (b *stringsBrowser) detach() {
+	index := b.binarySelect.SelectedIndex()
+	if index < 0 {
+		return
+	}
+	path := b.project.Binaries[index]
+	b.project.Binaries = slices.Delete(b.project.Binaries, index, index+1)
+	if err := b.c.projectStore.SaveProject(b.project); err != nil {
+		b.c.feedback.Error(b.window, "Detaching binary", err, nil)
+		return
+	}
+	b.c.feedback.Record(ActivityDelete, fmt.Sprintf("Detached %s from project %q", filepath.Base(path), b.project.Name))
+	b.setBinaries("")
+}
+
+// selectedString returns the selected string, telling the user if there is
+// none
+func (b *stringsBrowser) selectedString() (binfile.String, bool) {
+	if b.selected < 0 || b.selected >= len(b.shown) {
+		dialog.ShowInformation("No String", "Select a string first.", b.window)
+		return binfile.String{}, false
+	}
+	return b.shown[b.selected], true
+}
+
+// copy puts the selected string on the clipboard
+func (b *stringsBrowser) copy() {
+	if s, ok := b.selectedString(); ok {
+		b.window.Clipboard().SetContent(s.Text)
+	}
+}
+
+// newNote starts a note in the main window about the selected string,
+// filed under the project with the string's address and the binary's name
+func (b *stringsBrowser) newNote() {
+	s, ok := b.selectedString()
+	if !ok {
+		return
+	}
+	c := b.c
+	c.CreateNewNote()
+	c.notepad.SetProjectID(b.project.ID)
+	c.notepad.TitleEntry.SetText(s.NoteTitle())
+	c.notepad.BinaryNameEntry.SetText(filepath.Base(b.file.Path))
+	c.notepad.AddressRangeEntry.SetText(s.AddressRange())
+	c.window.RequestFocus()
+}
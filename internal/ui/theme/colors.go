@@ -0,0 +1,47 @@
+package theme
+
+import "fyne.io/fyne/v2"
+
+// Colour names for RevEnGo's own components. Every built-in theme defines
+// them, and theme files can override them like Fyne's colour names.
+const (
+	// ColorNameAccent is used for titles, prompts and field labels
+	ColorNameAccent fyne.ThemeColorName = "accent"
+
+	// ColorNameGlow is the glow behind the header buttons
+	ColorNameGlow fyne.ThemeColorName = "glow"
+
+	// ColorNameDecoration is the colour of decorative binary patterns
+	ColorNameDecoration fyne.ThemeColorName = "decoration"
+
+	// ColorNameHeaderStart and ColorNameHeaderEnd are the ends of the
+	// header's gradient
+	ColorNameHeaderStart fyne.ThemeColorName = "headerStart"
+	ColorNameHeaderEnd   fyne.ThemeColorName = "headerEnd"
+
+	// ColorNameSidebarBackground is the background of the sidebar
+	ColorNameSidebarBackground fyne.ThemeColorName = "sidebarBackground"
+
+	// ColorNameSidebarItem is the background of sidebar list items
+	ColorNameSidebarItem fyne.ThemeColorName = "sidebarItem"
+
+	// ColorNameTerminalBackground is the background of the notepad
+	ColorNameTerminalBackground fyne.ThemeColorName = "terminalBackground"
+
+	// ColorNameTerminalText is the colour of the notepad's address text
+	ColorNameTerminalText fyne.ThemeColorName = "terminalText"
+
+	// ColorNameCodeBlockBackground is the background of the notepad's
+	// reverse engineering fields
+	ColorNameCodeBlockBackground fyne.ThemeColorName = "codeBlockBackground"
+
+	// ColorNameTagLabel is the colour of the notepad's tags label
+	ColorNameTagLabel fyne.ThemeColorName = "tagLabel"
+
+	// Colours identifying the note types
+	ColorNameGeneralNote       fyne.ThemeColorName = "noteGeneral"
+	ColorNameFunctionAnalysis  fyne.ThemeColorName = "noteFunctionAnalysis"
+	ColorNameStructureAnalysis fyne.ThemeColorName = "noteStructureAnalysis"
+	ColorNameProtocolAnalysis  fyne.ThemeColorName = "noteProtocolAnalysis"
+	ColorNameVulnerability     fyne.ThemeColorName = "noteVulnerability"
+)
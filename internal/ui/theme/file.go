@@ -0,0 +1,259 @@
+package theme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"github.com/BurntSushi/toml"
+)
+
+// File is the contents of a user theme file, written in TOML or JSON.
+// A theme starts from one of the built-in themes and overrides any of its
+// colors, sizes and fonts. Color and size names are Fyne's theme names,
+// such as "background", "primary", "text" or "padding", or the names of
+// RevEnGo's component colors, such as "headerStart" or "terminalText".
+//
+//	name = "solarized"
+//	base = "dark"
+//
+//	[colors]
+//	background = "#002b36"
+//	foreground = "#eee8d5"
+//	primary = "#268bd2"
+//
+//	[sizes]
+//	text = 14
+//
+//	[fonts]
+//	monospace = "fonts/Hack-Regular.ttf"
+type File struct {
+	// Name identifies the theme in the settings; the file name is used if empty
+	Name string `toml:"name" json:"name"`
+
+	// Base is the built-in theme extended: dark (the default), light,
+	// high-contrast or system. Color overrides of a system theme apply to
+	// both its dark and light palettes.
+	Base string `toml:"base" json:"base"`
+
+	// Colors maps color names to "#rgb", "#rrggbb" or "#rrggbbaa" colors
+	Colors map[string]string `toml:"colors" json:"colors"`
+
+	// Sizes maps size names to sizes in points
+	Sizes map[string]float32 `toml:"sizes" json:"sizes"`
+
+	// Fonts are TrueType font files, relative to the theme file
+	Fonts FontFiles `toml:"fonts" json:"fonts"`
+}
+
+// FontFiles names the font files of a theme. Bold and italic styles
+// without a file of their own use the regular file of the same family.
+type FontFiles struct {
+	Regular    string `toml:"regular" json:"regular"`
+	Bold       string `toml:"bold" json:"bold"`
+	Italic     string `toml:"italic" json:"italic"`
+	BoldItalic string `toml:"bold_italic" json:"bold_italic"`
+
+	Monospace           string `toml:"monospace" json:"monospace"`
+	MonospaceBold       string `toml:"monospace_bold" json:"monospace_bold"`
+	MonospaceItalic     string `toml:"monospace_italic" json:"monospace_italic"`
+	MonospaceBoldItalic string `toml:"monospace_bold_italic" json:"monospace_bold_italic"`
+}
+
+// LoadDir loads every theme file (*.toml and *.json) in a directory,
+// replacing the user themes loaded before. A missing directory holds no
+// themes. Files that cannot be loaded are skipped and reported in the
+// returned error; the other themes are still loaded.
+//
+// Parameters:
+//   - dir: The directory holding the theme files
+//
+// Returns:
+//   - The names of the loaded themes
+//   - An error describing every file that could not be loaded
+func LoadDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	loaded := make(map[string]*RevEnGoTheme)
+	var names []string
+	var errs []error
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || ext != ".toml" && ext != ".json" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		name, t, err := LoadFile(path)
+		if err == nil && isBuiltIn(name) {
+			err = fmt.Errorf("%q is the name of a built-in theme", name)
+		}
+		if err == nil && loaded[name] != nil {
+			err = fmt.Errorf("another theme is named %q", name)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		loaded[name] = t
+		names = append(names, name)
+	}
+
+	userThemesMu.Lock()
+	userThemes = loaded
+	userThemesMu.Unlock()
+	return names, errors.Join(errs...)
+}
+
+// LoadFile loads a theme file.
+//
+// Parameters:
+//   - path: The TOML or JSON theme file
+//
+// Returns:
+//   - The theme's name
+//   - The theme
+//   - An error if the file cannot be read or describes an invalid theme
+func LoadFile(path string) (string, *RevEnGoTheme, error) {
+	var file File
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", nil, err
+		}
+		if err := json.Unmarshal(data, &file); err != nil {
+			return "", nil, err
+		}
+	default:
+		if _, err := toml.DecodeFile(path, &file); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if file.Name == "" {
+		file.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	t, err := file.theme(filepath.Dir(path))
+	return file.Name, t, err
+}
+
+// theme builds the theme a file describes.
+//
+// Parameters:
+//   - dir: The directory font paths are relative to
+func (f *File) theme(dir string) (*RevEnGoTheme, error) {
+	var t *RevEnGoTheme
+	switch f.Base {
+	case "", Dark:
+		t = New().(*RevEnGoTheme)
+	case Light:
+		t = NewLight().(*RevEnGoTheme)
+	case HighContrast:
+		t = NewHighContrast().(*RevEnGoTheme)
+	case System:
+		t = NewSystem().(*RevEnGoTheme)
+	default:
+		return nil, fmt.Errorf("unknown base theme %q", f.Base)
+	}
+
+	// Copy the palettes so the built-in ones are left untouched
+	for _, p := range []**palette{&t.dark, &t.light} {
+		if *p != nil {
+			*p = &palette{variant: (*p).variant, colors: maps.Clone((*p).colors)}
+		}
+	}
+	for name, value := range f.Colors {
+		c, err := ParseColor(value)
+		if err != nil {
+			return nil, fmt.Errorf("color %s: %w", name, err)
+		}
+		for _, p := range []*palette{t.dark, t.light} {
+			if p != nil {
+				p.colors[fyne.ThemeColorName(name)] = c
+			}
+		}
+	}
+
+	for name, size := range f.Sizes {
+		if size < 0 {
+			return nil, fmt.Errorf("size %s: must not be negative", name)
+		}
+		t.sizes[fyne.ThemeSizeName(name)] = size
+	}
+
+	return t, f.Fonts.load(dir, &t.fonts)
+}
+
+// load reads the named font files into a font set
+func (f FontFiles) load(dir string, fonts *fontSet) error {
+	families := []struct {
+		files []string
+		fonts []*fyne.Resource
+	}{
+		{
+			files: []string{f.Regular, f.Bold, f.Italic, f.BoldItalic},
+			fonts: []*fyne.Resource{&fonts.regular, &fonts.bold, &fonts.italic, &fonts.boldItalic},
+		},
+		{
+			files: []string{f.Monospace, f.MonospaceBold, f.MonospaceItalic, f.MonospaceBoldItalic},
+			fonts: []*fyne.Resource{&fonts.monospace, &fonts.monospaceBold, &fonts.monospaceItalic, &fonts.monospaceBoldItalic},
+		},
+	}
+
+	for _, family := range families {
+		if family.files[0] == "" {
+			continue
+		}
+		for i, file := range family.files {
+			if file == "" {
+				// Styles without a file use the family's regular font
+				*family.fonts[i] = *family.fonts[0]
+				continue
+			}
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(dir, file)
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("font: %w", err)
+			}
+			*family.fonts[i] = fyne.NewStaticResource(filepath.Base(file), data)
+		}
+	}
+	return nil
+}
+
+// ParseColor parses a "#rgb", "#rrggbb" or "#rrggbbaa" color
+func ParseColor(text string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(text), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 8 || err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q: use #rrggbb or #rrggbbaa", text)
+	}
+	return color.NRGBA{R: uint8(value >> 24), G: uint8(value >> 16), B: uint8(value >> 8), A: uint8(value)}, nil
+}
+
+// isBuiltIn reports whether a name belongs to a built-in theme
+func isBuiltIn(name string) bool {
+	switch name {
+	case Dark, Light, HighContrast, System:
+		return true
+	}
+	return false
+}
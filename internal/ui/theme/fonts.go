@@ -0,0 +1,63 @@
+package theme
+
+import (
+	"fyne.io/fyne/v2"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/gomonobolditalic"
+	"golang.org/x/image/font/gofont/gomonoitalic"
+)
+
+// Go Mono, bundled as the monospace font for code and hex dumps. Its
+// glyphs for 0/O and 1/l/I are distinct, which matters when reading bytes.
+var (
+	goMono           = fyne.NewStaticResource("Go-Mono.ttf", gomono.TTF)
+	goMonoBold       = fyne.NewStaticResource("Go-Mono-Bold.ttf", gomonobold.TTF)
+	goMonoItalic     = fyne.NewStaticResource("Go-Mono-Italic.ttf", gomonoitalic.TTF)
+	goMonoBoldItalic = fyne.NewStaticResource("Go-Mono-Bold-Italic.ttf", gomonobolditalic.TTF)
+)
+
+// fontSet holds the fonts of a theme. Nil fonts fall back to Fyne's defaults.
+type fontSet struct {
+	regular, bold, italic, boldItalic fyne.Resource
+
+	monospace, monospaceBold, monospaceItalic, monospaceBoldItalic fyne.Resource
+}
+
+// bundledFonts returns the fonts of the built-in themes: Fyne's text fonts
+// and the bundled Go Mono
+func bundledFonts() fontSet {
+	return fontSet{
+		monospace:           goMono,
+		monospaceBold:       goMonoBold,
+		monospaceItalic:     goMonoItalic,
+		monospaceBoldItalic: goMonoBoldItalic,
+	}
+}
+
+// font returns the font for a text style, or nil to use the default
+func (f fontSet) font(style fyne.TextStyle) fyne.Resource {
+	if style.Symbol {
+		return nil
+	}
+	if style.Monospace {
+		switch {
+		case style.Bold && style.Italic:
+			return f.monospaceBoldItalic
+		case style.Bold:
+			return f.monospaceBold
+		case style.Italic:
+			return f.monospaceItalic
+		}
+		return f.monospace
+	}
+	switch {
+	case style.Bold && style.Italic:
+		return f.boldItalic
+	case style.Bold:
+		return f.bold
+	case style.Italic:
+		return f.italic
+	}
+	return f.regular
+}
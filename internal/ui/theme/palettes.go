@@ -0,0 +1,122 @@
+package theme
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+)
+
+// darkPalette is the dark cyber palette
+var darkPalette = palette{
+	variant: theme.VariantDark,
+	colors: map[fyne.ThemeColorName]color.Color{
+		theme.ColorNameBackground:      colorBackground,
+		theme.ColorNameForeground:      color.White,
+		theme.ColorNamePrimary:         colorPrimary,
+		theme.ColorNameButton:          colorButton,
+		theme.ColorNameScrollBar:       color.NRGBA{R: 40, G: 50, B: 70, A: 200},
+		theme.ColorNameDisabledButton:  color.NRGBA{R: 30, G: 40, B: 50, A: 120},
+		theme.ColorNameInputBackground: colorInputBackground,
+		theme.ColorNamePlaceHolder:     color.NRGBA{R: 100, G: 120, B: 140, A: 200},
+		theme.ColorNameHover:           color.NRGBA{R: 60, G: 80, B: 120, A: 30},
+		theme.ColorNameSelection:       color.NRGBA{R: 10, G: 120, B: 200, A: 60},
+		theme.ColorNamePressed:         color.NRGBA{R: 30, G: 150, B: 220, A: 60},
+
+		ColorNameAccent:              colorPrimary,
+		ColorNameGlow:                color.NRGBA{R: 61, G: 134, B: 247, A: 100},
+		ColorNameDecoration:          color.NRGBA{R: 70, G: 115, B: 160, A: 110},
+		ColorNameHeaderStart:         color.NRGBA{R: 15, G: 23, B: 42, A: 255},
+		ColorNameHeaderEnd:           color.NRGBA{R: 30, G: 41, B: 59, A: 255},
+		ColorNameSidebarBackground:   color.NRGBA{R: 12, G: 17, B: 27, A: 255},
+		ColorNameSidebarItem:         color.NRGBA{R: 15, G: 30, B: 55, A: 100},
+		ColorNameTerminalBackground:  color.NRGBA{R: 8, G: 14, B: 21, A: 255},
+		ColorNameTerminalText:        color.NRGBA{R: 180, G: 255, B: 180, A: 255},
+		ColorNameCodeBlockBackground: color.NRGBA{R: 15, G: 25, B: 35, A: 255},
+		ColorNameTagLabel:            color.NRGBA{R: 0, G: 200, B: 170, A: 255},
+		ColorNameGeneralNote:         color.NRGBA{R: 120, G: 120, B: 120, A: 255},
+		ColorNameFunctionAnalysis:    color.NRGBA{R: 0, G: 180, B: 255, A: 255},
+		ColorNameStructureAnalysis:   color.NRGBA{R: 180, G: 120, B: 255, A: 255},
+		ColorNameProtocolAnalysis:    color.NRGBA{R: 255, G: 180, B: 0, A: 255},
+		ColorNameVulnerability:       color.NRGBA{R: 255, G: 70, B: 70, A: 255},
+	},
+}
+
+// lightPalette keeps the cyber blue accents on a pale blue-grey background
+var lightPalette = palette{
+	variant: theme.VariantLight,
+	colors: map[fyne.ThemeColorName]color.Color{
+		theme.ColorNameBackground:      color.NRGBA{R: 236, G: 240, B: 245, A: 255},
+		theme.ColorNameForeground:      color.NRGBA{R: 20, G: 28, B: 40, A: 255},
+		theme.ColorNamePrimary:         color.NRGBA{R: 0, G: 120, B: 190, A: 255},
+		theme.ColorNameButton:          color.NRGBA{R: 214, G: 222, B: 232, A: 255},
+		theme.ColorNameScrollBar:       color.NRGBA{R: 120, G: 135, B: 155, A: 160},
+		theme.ColorNameDisabledButton:  color.NRGBA{R: 220, G: 225, B: 232, A: 160},
+		theme.ColorNameInputBackground: color.NRGBA{R: 252, G: 253, B: 255, A: 255},
+		theme.ColorNamePlaceHolder:     color.NRGBA{R: 110, G: 120, B: 135, A: 255},
+		theme.ColorNameHover:           color.NRGBA{R: 0, G: 120, B: 190, A: 25},
+		theme.ColorNameSelection:       color.NRGBA{R: 0, G: 120, B: 190, A: 60},
+		theme.ColorNamePressed:         color.NRGBA{R: 0, G: 120, B: 190, A: 70},
+
+		ColorNameAccent:              color.NRGBA{R: 0, G: 120, B: 190, A: 255},
+		ColorNameGlow:                color.NRGBA{R: 0, G: 120, B: 190, A: 40},
+		ColorNameDecoration:          color.NRGBA{R: 90, G: 120, B: 150, A: 110},
+		ColorNameHeaderStart:         color.NRGBA{R: 214, G: 224, B: 236, A: 255},
+		ColorNameHeaderEnd:           color.NRGBA{R: 236, G: 241, B: 247, A: 255},
+		ColorNameSidebarBackground:   color.NRGBA{R: 226, G: 232, B: 240, A: 255},
+		ColorNameSidebarItem:         color.NRGBA{R: 0, G: 120, B: 190, A: 25},
+		ColorNameTerminalBackground:  color.NRGBA{R: 246, G: 248, B: 251, A: 255},
+		ColorNameTerminalText:        color.NRGBA{R: 20, G: 120, B: 60, A: 255},
+		ColorNameCodeBlockBackground: color.NRGBA{R: 232, G: 238, B: 245, A: 255},
+		ColorNameTagLabel:            color.NRGBA{R: 0, G: 130, B: 110, A: 255},
+		ColorNameGeneralNote:         color.NRGBA{R: 110, G: 110, B: 110, A: 255},
+		ColorNameFunctionAnalysis:    color.NRGBA{R: 0, G: 110, B: 200, A: 255},
+		ColorNameStructureAnalysis:   color.NRGBA{R: 120, G: 70, B: 200, A: 255},
+		ColorNameProtocolAnalysis:    color.NRGBA{R: 190, G: 120, B: 0, A: 255},
+		ColorNameVulnerability:       color.NRGBA{R: 200, G: 40, B: 40, A: 255},
+	},
+}
+
+// highContrastPalette uses pure black and white with saturated accents,
+// and opaque hover and selection colors that stay visible
+var highContrastPalette = palette{
+	variant: theme.VariantDark,
+	colors: map[fyne.ThemeColorName]color.Color{
+		theme.ColorNameBackground:      color.Black,
+		theme.ColorNameForeground:      color.White,
+		theme.ColorNamePrimary:         color.NRGBA{R: 255, G: 230, B: 0, A: 255},
+		theme.ColorNameFocus:           color.NRGBA{R: 255, G: 230, B: 0, A: 255},
+		theme.ColorNameButton:          color.Black,
+		theme.ColorNameInputBorder:     color.White,
+		theme.ColorNameScrollBar:       color.White,
+		theme.ColorNameDisabled:        color.NRGBA{R: 170, G: 170, B: 170, A: 255},
+		theme.ColorNameDisabledButton:  color.NRGBA{R: 40, G: 40, B: 40, A: 255},
+		theme.ColorNameInputBackground: color.Black,
+		theme.ColorNamePlaceHolder:     color.NRGBA{R: 200, G: 200, B: 200, A: 255},
+		theme.ColorNameHover:           color.NRGBA{R: 0, G: 90, B: 160, A: 255},
+		theme.ColorNameSelection:       color.NRGBA{R: 0, G: 120, B: 215, A: 255},
+		theme.ColorNamePressed:         color.NRGBA{R: 255, G: 230, B: 0, A: 120},
+		theme.ColorNameHyperlink:       color.NRGBA{R: 0, G: 255, B: 255, A: 255},
+		theme.ColorNameError:           color.NRGBA{R: 255, G: 80, B: 80, A: 255},
+		theme.ColorNameSuccess:         color.NRGBA{R: 0, G: 255, B: 0, A: 255},
+		theme.ColorNameWarning:         colorWarning,
+		theme.ColorNameSeparator:       color.White,
+
+		ColorNameAccent:              color.NRGBA{R: 255, G: 230, B: 0, A: 255},
+		ColorNameGlow:                color.Transparent,
+		ColorNameDecoration:          color.NRGBA{R: 200, G: 200, B: 200, A: 255},
+		ColorNameHeaderStart:         color.Black,
+		ColorNameHeaderEnd:           color.Black,
+		ColorNameSidebarBackground:   color.Black,
+		ColorNameSidebarItem:         color.NRGBA{R: 40, G: 40, B: 40, A: 255},
+		ColorNameTerminalBackground:  color.Black,
+		ColorNameTerminalText:        color.White,
+		ColorNameCodeBlockBackground: color.NRGBA{R: 20, G: 20, B: 20, A: 255},
+		ColorNameTagLabel:            color.NRGBA{R: 0, G: 255, B: 255, A: 255},
+		ColorNameGeneralNote:         color.White,
+		ColorNameFunctionAnalysis:    color.NRGBA{R: 0, G: 200, B: 255, A: 255},
+		ColorNameStructureAnalysis:   color.NRGBA{R: 220, G: 160, B: 255, A: 255},
+		ColorNameProtocolAnalysis:    color.NRGBA{R: 255, G: 200, B: 0, A: 255},
+		ColorNameVulnerability:       color.NRGBA{R: 255, G: 80, B: 80, A: 255},
+	},
+}
@@ -3,11 +3,30 @@ package theme
 
 import (
 	"image/color"
+	"sort"
+	"sync"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/theme"
 )
 
+// Names of the built-in themes
+const (
+	// Dark is the dark cyber theme
+	Dark = "dark"
+
+	// Light is a light theme with the same accents
+	Light = "light"
+
+	// HighContrast is a black and white theme with strong accents for
+	// low-vision users
+	HighContrast = "high-contrast"
+
+	// System follows the operating system, using the dark theme in dark
+	// mode and the light theme otherwise
+	System = "system"
+)
+
 // Custom colors for the RevEnGo theme
 var (
 	// Main background color - deep charcoal with slight blue tint
@@ -38,49 +57,169 @@ var (
 	colorInputBackground = color.NRGBA{R: 12, G: 15, B: 22, A: 255}
 )
 
+// baseSizes are the sizes shared by the built-in themes
+var baseSizes = map[fyne.ThemeSizeName]float32{
+	theme.SizeNamePadding:        6,
+	theme.SizeNameInnerPadding:   4,
+	theme.SizeNameText:           13,
+	theme.SizeNameHeadingText:    18,
+	theme.SizeNameSubHeadingText: 15,
+	theme.SizeNameCaptionText:    11,
+	theme.SizeNameInlineIcon:     20,
+}
+
+// palette is a set of colors for one theme variant
+type palette struct {
+	// variant is the Fyne variant used for colors the palette does not set
+	variant fyne.ThemeVariant
+
+	// colors maps color names to colors
+	colors map[fyne.ThemeColorName]color.Color
+}
+
 // RevEnGoTheme is a custom theme for the RevEnGo application
-type RevEnGoTheme struct{}
+type RevEnGoTheme struct {
+	// dark and light are the palettes used for the dark and light system
+	// variants. A theme with only one of them always uses that one.
+	dark, light *palette
+
+	// sizes override Fyne's default sizes
+	sizes map[fyne.ThemeSizeName]float32
+
+	// fonts holds the fonts to use instead of the defaults
+	fonts fontSet
+}
 
 // New creates a new instance of the RevEnGoTheme
 func New() fyne.Theme {
-	return &RevEnGoTheme{}
+	return newTheme(&darkPalette, nil)
 }
 
-// Color returns the color for the specified ColorName and theme
-func (t *RevEnGoTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+// NewLight creates the light variant of the RevEnGoTheme
+func NewLight() fyne.Theme {
+	return newTheme(nil, &lightPalette)
+}
+
+// NewHighContrast creates the high-contrast variant of the RevEnGoTheme
+func NewHighContrast() fyne.Theme {
+	t := newTheme(&highContrastPalette, nil)
+	t.sizes[theme.SizeNameText] = 15
+	t.sizes[theme.SizeNameInputBorder] = 2
+	t.sizes[theme.SizeNameSelectionRadius] = 0
+	return t
+}
+
+// NewSystem creates a RevEnGoTheme that follows the system's dark or light mode
+func NewSystem() fyne.Theme {
+	return newTheme(&darkPalette, &lightPalette)
+}
+
+// newTheme creates a theme with the given palettes and the default sizes
+func newTheme(dark, light *palette) *RevEnGoTheme {
+	t := &RevEnGoTheme{
+		dark:  dark,
+		light: light,
+		sizes: make(map[fyne.ThemeSizeName]float32, len(baseSizes)),
+		fonts: bundledFonts(),
+	}
+	for name, size := range baseSizes {
+		t.sizes[name] = size
+	}
+	return t
+}
+
+// userThemes holds the themes loaded from theme files, by name
+var (
+	userThemesMu sync.RWMutex
+	userThemes   = map[string]*RevEnGoTheme{}
+)
+
+// Names returns the names of the themes that can be selected: the
+// built-in themes followed by the loaded user themes
+func Names() []string {
+	names := []string{Dark, Light, HighContrast, System}
+
+	userThemesMu.RLock()
+	defer userThemesMu.RUnlock()
+	var user []string
+	for name := range userThemes {
+		user = append(user, name)
+	}
+	sort.Strings(user)
+	return append(names, user...)
+}
+
+// Named returns the theme with the given name.
+// Unknown names and the empty name select the dark theme.
+func Named(name string) fyne.Theme {
 	switch name {
-	case theme.ColorNameBackground:
-		return colorBackground
-	case theme.ColorNameForeground:
-		return color.White
-	case theme.ColorNamePrimary:
-		return colorPrimary
-	case theme.ColorNameButton:
-		return colorButton
-	case theme.ColorNameScrollBar:
-		return color.NRGBA{R: 40, G: 50, B: 70, A: 200}
-	case theme.ColorNameDisabledButton:
-		return color.NRGBA{R: 30, G: 40, B: 50, A: 120}
-	case theme.ColorNameInputBackground:
-		return colorInputBackground
-	case theme.ColorNamePlaceHolder:
-		return color.NRGBA{R: 100, G: 120, B: 140, A: 200}
-	case theme.ColorNameHover:
-		return color.NRGBA{R: 60, G: 80, B: 120, A: 30}
-	case theme.ColorNameSelection:
-		return color.NRGBA{R: 10, G: 120, B: 200, A: 60}
-	case theme.ColorNamePressed:
-		return color.NRGBA{R: 30, G: 150, B: 220, A: 60}
-	default:
-		return theme.DefaultTheme().Color(name, variant)
+	case Light:
+		return NewLight()
+	case HighContrast:
+		return NewHighContrast()
+	case System:
+		return NewSystem()
+	}
+
+	userThemesMu.RLock()
+	defer userThemesMu.RUnlock()
+	if t, ok := userThemes[name]; ok {
+		return t
+	}
+	return New()
+}
+
+// extraColors holds colors registered by name for every theme, such as
+// the colors of note types defined in the configuration
+var (
+	extraColorsMu sync.RWMutex
+	extraColors   = map[fyne.ThemeColorName]color.Color{}
+)
+
+// RegisterColor adds a named color to every theme. A theme's own palette
+// takes precedence over registered colors, so theme files may restyle them.
+//
+// Parameters:
+//   - name: The color name
+//   - c: The color
+func RegisterColor(name fyne.ThemeColorName, c color.Color) {
+	extraColorsMu.Lock()
+	defer extraColorsMu.Unlock()
+	extraColors[name] = c
+}
+
+// Color returns the color for the specified ColorName and theme.
+// Themes with both a dark and a light palette follow the variant; other
+// themes always use their one palette.
+func (t *RevEnGoTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	p := t.palette(variant)
+	if c, ok := p.colors[name]; ok {
+		return c
 	}
+
+	extraColorsMu.RLock()
+	c, ok := extraColors[name]
+	extraColorsMu.RUnlock()
+	if ok {
+		return c
+	}
+	return theme.DefaultTheme().Color(name, p.variant)
+}
+
+// palette returns the palette to use for a system variant
+func (t *RevEnGoTheme) palette(variant fyne.ThemeVariant) *palette {
+	if variant == theme.VariantLight && t.light != nil || t.dark == nil {
+		return t.light
+	}
+	return t.dark
 }
 
-// Font returns the font resource for the specified TextStyle and theme
+// Font returns the font resource for the specified TextStyle and theme.
+// Monospace text, used for code and hex, uses the bundled Go Mono font
+// unless the theme sets its own.
 func (t *RevEnGoTheme) Font(style fyne.TextStyle) fyne.Resource {
-	if style.Monospace {
-		// Use a more distinct monospace font for code
-		return theme.DefaultTheme().Font(style)
+	if font := t.fonts.font(style); font != nil {
+		return font
 	}
 	return theme.DefaultTheme().Font(style)
 }
@@ -94,22 +233,8 @@ func (t *RevEnGoTheme) Icon(name fyne.ThemeIconName) fyne.Resource {
 
 // Size returns the size for the specified SizeName and theme
 func (t *RevEnGoTheme) Size(name fyne.ThemeSizeName) float32 {
-	switch name {
-	case theme.SizeNamePadding:
-		return 6
-	case theme.SizeNameInnerPadding:
-		return 4
-	case theme.SizeNameText:
-		return 13
-	case theme.SizeNameHeadingText:
-		return 18
-	case theme.SizeNameSubHeadingText:
-		return 15
-	case theme.SizeNameCaptionText:
-		return 11
-	case theme.SizeNameInlineIcon:
-		return 20
-	default:
-		return theme.DefaultTheme().Size(name)
+	if size, ok := t.sizes[name]; ok {
+		return size
 	}
+	return theme.DefaultTheme().Size(name)
 }
@@ -0,0 +1,587 @@
+// Package widgets provides custom UI widgets for the RevEnGo application.
+// This file contains the line-numbered code editor used for note content.
+package widgets
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// defaultEditorRows is the visible height of the editor without line numbers
+const defaultEditorRows = 20
+
+// CodeEditor is a multi-line monospace editor with a line number gutter.
+// The gutter follows scrolling and word wrapping, highlights the current
+// line and lets lines be bookmarked by tapping their number.
+type CodeEditor struct {
+	widget.BaseWidget
+
+	// Entry is the underlying text entry
+	Entry *ShortcutEntry
+
+	// OnChanged is called when the user edits the text
+	OnChanged func(string)
+
+	// OnBookmarksChanged is called when bookmarks are added or removed
+	OnBookmarksChanged func()
+
+	// OnCursorChanged is called when the cursor moves
+	OnCursorChanged func()
+
+	// body holds the editor layout for the current mode
+	body *fyne.Container
+
+	// scroll scrolls the gutter and text together in line number mode
+	scroll *container.Scroll
+
+	// gutter draws the line numbers and bookmark markers
+	gutter *lineGutter
+
+	// currentLine marks the row(s) of the line holding the cursor
+	currentLine *canvas.Rectangle
+
+	// lineNumbers is set when the gutter is shown
+	lineNumbers bool
+
+	// bookmarks is the set of bookmarked lines (1-based)
+	bookmarks map[int]bool
+
+	// lineStarts holds the first visual row of each logical line
+	lineStarts []int
+
+	// visualRows is the number of rows after wrapping
+	visualRows int
+
+	// cursorLine is the logical line (1-based) holding the cursor
+	cursorLine int
+
+	// text is the text as of the last change, used to locate edits
+	text string
+}
+
+// NewCodeEditor creates a line-numbered editor with word wrapping enabled.
+func NewCodeEditor() *CodeEditor {
+	e := &CodeEditor{
+		Entry:      TerminalEntry(),
+		bookmarks:  make(map[int]bool),
+		cursorLine: 1,
+	}
+	e.Entry.Wrapping = fyne.TextWrapWord
+	e.Entry.OnChanged = e.textChanged
+	e.Entry.OnCursorChanged = e.cursorMoved
+
+	e.gutter = newLineGutter(e)
+	e.currentLine = canvas.NewRectangle(theme.Color(theme.ColorNameHover))
+	overlay := container.NewWithoutLayout(e.currentLine)
+
+	e.scroll = container.NewVScroll(container.NewBorder(
+		nil, nil, e.gutter, nil,
+		container.NewStack(e.Entry, overlay),
+	))
+	e.body = container.NewStack()
+
+	e.ExtendBaseWidget(e)
+	e.SetLineNumbers(true)
+	return e
+}
+
+// CreateRenderer implements fyne.Widget
+func (e *CodeEditor) CreateRenderer() fyne.WidgetRenderer {
+	return &codeEditorRenderer{editor: e}
+}
+
+// SetLineNumbers shows or hides the line number gutter.
+// Without line numbers the editor behaves like a plain terminal entry.
+func (e *CodeEditor) SetLineNumbers(show bool) {
+	e.lineNumbers = show
+	if show {
+		e.body.Objects = []fyne.CanvasObject{e.scroll}
+		e.reflow()
+	} else {
+		e.body.Objects = []fyne.CanvasObject{e.Entry}
+		e.Entry.SetMinRowsVisible(defaultEditorRows)
+	}
+	e.body.Refresh()
+}
+
+// LineNumbers reports whether the gutter is shown
+func (e *CodeEditor) LineNumbers() bool {
+	return e.lineNumbers
+}
+
+// SetText replaces the editor text
+func (e *CodeEditor) SetText(text string) {
+	e.text = text
+	e.Entry.SetText(text)
+	e.reflow()
+}
+
+// CurrentLine returns the 1-based line holding the cursor
+func (e *CodeEditor) CurrentLine() int {
+	return e.cursorLine
+}
+
+// LineCount returns the number of lines in the text
+func (e *CodeEditor) LineCount() int {
+	return strings.Count(e.Entry.Text, "\n") + 1
+}
+
+// GoToLine moves the cursor to the start of a 1-based line and scrolls it into view
+func (e *CodeEditor) GoToLine(line int) {
+	e.MoveCursor(line, 0)
+}
+
+// MoveCursor moves the cursor to a 1-based line and 0-based column within
+// that line, focuses the editor and scrolls the cursor into view
+func (e *CodeEditor) MoveCursor(line, column int) {
+	e.reflow()
+	lines := strings.Split(e.Entry.Text, "\n")
+	line = max(1, min(line, len(lines)))
+	text := []rune(lines[line-1])
+	column = max(0, min(column, len(text)))
+
+	// Convert the column within the line into a wrapped row and column
+	row, col := line-1, column
+	if line-1 < len(e.lineStarts) {
+		starts := wrapLine(text, e.columns())
+		i := sort.Search(len(starts), func(i int) bool { return starts[i] > column }) - 1
+		row, col = e.lineStarts[line-1]+i, column-starts[i]
+	}
+	e.Entry.CursorRow = row
+	e.Entry.CursorColumn = col
+	e.Entry.Refresh()
+
+	if c := fyne.CurrentApp().Driver().CanvasForObject(e.Entry); c != nil {
+		c.Focus(e.Entry)
+	}
+	e.setCursorLine(line)
+	e.ensureVisible(row)
+}
+
+// CurrentLineText returns the text of the line holding the cursor
+func (e *CodeEditor) CurrentLineText() string {
+	lines := strings.Split(e.Entry.Text, "\n")
+	if e.cursorLine-1 < len(lines) {
+		return lines[e.cursorLine-1]
+	}
+	return ""
+}
+
+// ReplaceLine replaces the text of a 1-based line and places the cursor at its end
+func (e *CodeEditor) ReplaceLine(line int, text string) {
+	lines := strings.Split(e.Entry.Text, "\n")
+	if line < 1 || line > len(lines) {
+		return
+	}
+	lines[line-1] = text
+	e.Entry.SetText(strings.Join(lines, "\n"))
+	e.MoveCursor(line, len([]rune(text)))
+}
+
+// ToggleBookmark adds or removes a bookmark on a 1-based line
+func (e *CodeEditor) ToggleBookmark(line int) {
+	if line < 1 || line > e.LineCount() {
+		return
+	}
+	if e.bookmarks[line] {
+		delete(e.bookmarks, line)
+	} else {
+		e.bookmarks[line] = true
+	}
+	e.gutter.Refresh()
+	e.bookmarksChanged()
+}
+
+// Bookmarks returns the bookmarked lines in ascending order
+func (e *CodeEditor) Bookmarks() []int {
+	lines := make([]int, 0, len(e.bookmarks))
+	for line := range e.bookmarks {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// SetBookmarks replaces the bookmarked lines without notifying listeners
+func (e *CodeEditor) SetBookmarks(lines []int) {
+	e.bookmarks = make(map[int]bool, len(lines))
+	for _, line := range lines {
+		if line >= 1 {
+			e.bookmarks[line] = true
+		}
+	}
+	e.gutter.Refresh()
+}
+
+// NextBookmark moves the cursor to the next bookmark, wrapping around
+func (e *CodeEditor) NextBookmark() {
+	lines := e.Bookmarks()
+	if len(lines) == 0 {
+		return
+	}
+	for _, line := range lines {
+		if line > e.cursorLine {
+			e.GoToLine(line)
+			return
+		}
+	}
+	e.GoToLine(lines[0])
+}
+
+// PreviousBookmark moves the cursor to the previous bookmark, wrapping around
+func (e *CodeEditor) PreviousBookmark() {
+	lines := e.Bookmarks()
+	if len(lines) == 0 {
+		return
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		if lines[i] < e.cursorLine {
+			e.GoToLine(lines[i])
+			return
+		}
+	}
+	e.GoToLine(lines[len(lines)-1])
+}
+
+// textChanged keeps bookmarks attached to their lines and refreshes the gutter
+func (e *CodeEditor) textChanged(text string) {
+	e.shiftBookmarks(e.text, text)
+	e.text = text
+	e.reflow()
+	if e.OnChanged != nil {
+		e.OnChanged(text)
+	}
+}
+
+// shiftBookmarks keeps bookmarks on the same text when lines are added or removed
+func (e *CodeEditor) shiftBookmarks(before, after string) {
+	delta := strings.Count(after, "\n") - strings.Count(before, "\n")
+	if delta == 0 || len(e.bookmarks) == 0 {
+		return
+	}
+
+	// Find the line where the edit starts. An edit at the very start of a
+	// line moves that line too, otherwise only the lines after it move.
+	common := 0
+	for common < len(before) && common < len(after) && before[common] == after[common] {
+		common++
+	}
+	editLine := strings.Count(before[:common], "\n") + 1
+	firstMoved := editLine + 1
+	if common == 0 || before[common-1] == '\n' {
+		firstMoved = editLine
+	}
+
+	shifted := make(map[int]bool, len(e.bookmarks))
+	for line := range e.bookmarks {
+		switch {
+		case line < firstMoved:
+			shifted[line] = true
+		case delta < 0 && line < firstMoved-delta:
+			// The bookmarked line was deleted
+		default:
+			shifted[line+delta] = true
+		}
+	}
+	e.bookmarks = shifted
+	e.bookmarksChanged()
+}
+
+// bookmarksChanged notifies the listener that bookmarks changed
+func (e *CodeEditor) bookmarksChanged() {
+	if e.OnBookmarksChanged != nil {
+		e.OnBookmarksChanged()
+	}
+}
+
+// cursorMoved tracks the logical line holding the cursor
+func (e *CodeEditor) cursorMoved() {
+	e.setCursorLine(e.lineAtRow(e.Entry.CursorRow))
+	e.ensureVisible(e.Entry.CursorRow)
+	if e.OnCursorChanged != nil {
+		e.OnCursorChanged()
+	}
+}
+
+// setCursorLine updates the current line highlight
+func (e *CodeEditor) setCursorLine(line int) {
+	if line == e.cursorLine {
+		return
+	}
+	e.cursorLine = line
+	e.placeCurrentLine()
+	e.gutter.Refresh()
+}
+
+// lineAtRow maps a visual row to its 1-based logical line
+func (e *CodeEditor) lineAtRow(row int) int {
+	i := sort.Search(len(e.lineStarts), func(i int) bool {
+		return e.lineStarts[i] > row
+	})
+	return max(1, i)
+}
+
+// reflow recomputes how logical lines wrap onto visual rows and grows the
+// entry to fit them, so the shared scroll container scrolls text and gutter
+// together
+func (e *CodeEditor) reflow() {
+	lines := strings.Split(e.Entry.Text, "\n")
+	columns := e.columns()
+
+	e.lineStarts = e.lineStarts[:0]
+	rows := 0
+	for _, line := range lines {
+		e.lineStarts = append(e.lineStarts, rows)
+		rows += len(wrapLine([]rune(line), columns))
+	}
+
+	if !e.lineNumbers {
+		return
+	}
+	resized := rows != e.visualRows
+	e.visualRows = rows
+	e.placeCurrentLine()
+	e.gutter.Refresh()
+	if resized {
+		// Resize the entry and gutter, then let the scroll container re-measure them
+		e.Entry.SetMinRowsVisible(max(rows, defaultEditorRows))
+		e.scroll.Refresh()
+	}
+}
+
+// columns returns how many monospace characters fit on one row of the entry
+func (e *CodeEditor) columns() int {
+	th := e.Entry.Theme()
+	width := e.Entry.Size().Width - 2*th.Size(theme.SizeNameInnerPadding)
+	char := fyne.MeasureText("M", th.Size(theme.SizeNameText), e.Entry.TextStyle).Width
+	if width <= 0 || char <= 0 {
+		return 0
+	}
+	return int(width / char)
+}
+
+// wrapLine returns the offset at which each visual row of a line starts
+// when it is word wrapped to the given number of columns
+func wrapLine(text []rune, columns int) []int {
+	starts := []int{0}
+	if columns <= 0 {
+		return starts
+	}
+
+	low := 0
+	for len(text)-low > columns {
+		cut := low + columns
+		// Break after the last space that fits, or mid-word if there is none
+		space := -1
+		for i := cut; i > low; i-- {
+			if text[i] == ' ' {
+				space = i
+				break
+			}
+		}
+		if space > low {
+			low = space + 1
+		} else {
+			low = cut
+		}
+		starts = append(starts, low)
+	}
+	return starts
+}
+
+// rowHeight returns the height of one row of text in the entry
+func (e *CodeEditor) rowHeight() float32 {
+	th := e.Entry.Theme()
+	text := fyne.MeasureText("M", th.Size(theme.SizeNameText), e.Entry.TextStyle)
+	return text.Height + th.Size(theme.SizeNameLineSpacing)
+}
+
+// rowOffset returns the vertical position of a visual row within the entry
+func (e *CodeEditor) rowOffset(row int) float32 {
+	return e.Entry.Theme().Size(theme.SizeNameInnerPadding) + float32(row)*e.rowHeight()
+}
+
+// placeCurrentLine positions the highlight over the rows of the cursor line
+func (e *CodeEditor) placeCurrentLine() {
+	if e.cursorLine-1 >= len(e.lineStarts) {
+		e.currentLine.Hide()
+		return
+	}
+	first := e.lineStarts[e.cursorLine-1]
+	last := e.visualRows
+	if e.cursorLine < len(e.lineStarts) {
+		last = e.lineStarts[e.cursorLine]
+	}
+
+	e.currentLine.FillColor = e.Theme().Color(theme.ColorNameHover, fyne.CurrentApp().Settings().ThemeVariant())
+	e.currentLine.Move(fyne.NewPos(0, e.rowOffset(first)))
+	e.currentLine.Resize(fyne.NewSize(e.Entry.Size().Width, float32(max(1, last-first))*e.rowHeight()))
+	e.currentLine.Show()
+	e.currentLine.Refresh()
+}
+
+// ensureVisible scrolls so that a visual row is inside the viewport
+func (e *CodeEditor) ensureVisible(row int) {
+	if !e.lineNumbers {
+		return
+	}
+	top := e.rowOffset(row)
+	bottom := top + e.rowHeight()
+	view := e.scroll.Size().Height
+
+	switch {
+	case top < e.scroll.Offset.Y:
+		e.scroll.Offset.Y = top
+	case bottom > e.scroll.Offset.Y+view:
+		e.scroll.Offset.Y = bottom - view
+	default:
+		return
+	}
+	e.scroll.Refresh()
+}
+
+// codeEditorRenderer lays out the editor body and reflows text on resize
+type codeEditorRenderer struct {
+	editor *CodeEditor
+}
+
+func (r *codeEditorRenderer) Layout(size fyne.Size) {
+	r.editor.body.Resize(size)
+	r.editor.reflow()
+}
+
+func (r *codeEditorRenderer) MinSize() fyne.Size {
+	return r.editor.body.MinSize()
+}
+
+func (r *codeEditorRenderer) Refresh() {
+	r.editor.body.Refresh()
+}
+
+func (r *codeEditorRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.editor.body}
+}
+
+func (r *codeEditorRenderer) Destroy() {}
+
+// lineGutter draws line numbers beside the editor text.
+// Tapping a line number toggles a bookmark on that line.
+type lineGutter struct {
+	widget.BaseWidget
+	editor *CodeEditor
+}
+
+// newLineGutter creates the gutter for an editor
+func newLineGutter(editor *CodeEditor) *lineGutter {
+	g := &lineGutter{editor: editor}
+	g.ExtendBaseWidget(g)
+	return g
+}
+
+// Tapped toggles the bookmark on the tapped line
+func (g *lineGutter) Tapped(ev *fyne.PointEvent) {
+	e := g.editor
+	row := int((ev.Position.Y - e.rowOffset(0)) / e.rowHeight())
+	if row < 0 || row >= max(e.visualRows, 1) {
+		return
+	}
+	e.ToggleBookmark(e.lineAtRow(row))
+}
+
+// CreateRenderer implements fyne.Widget
+func (g *lineGutter) CreateRenderer() fyne.WidgetRenderer {
+	r := &lineGutterRenderer{gutter: g, background: canvas.NewRectangle(theme.Color(theme.ColorNameInputBackground))}
+	r.Refresh()
+	return r
+}
+
+// lineGutterRenderer draws one number per logical line at its first visual row
+type lineGutterRenderer struct {
+	gutter     *lineGutter
+	background *canvas.Rectangle
+	labels     []*canvas.Text
+	objects    []fyne.CanvasObject
+}
+
+// markerWidth is the number of characters reserved for the bookmark marker
+const markerWidth = 2
+
+func (r *lineGutterRenderer) Layout(size fyne.Size) {
+	r.background.Resize(size)
+	e := r.gutter.editor
+	pad := e.Entry.Theme().Size(theme.SizeNameInnerPadding)
+	for i, label := range r.labels {
+		if i >= len(e.lineStarts) {
+			label.Hide()
+			continue
+		}
+		label.Move(fyne.NewPos(pad, e.rowOffset(e.lineStarts[i])))
+		label.Resize(fyne.NewSize(size.Width-2*pad, e.rowHeight()))
+		label.Show()
+	}
+}
+
+func (r *lineGutterRenderer) MinSize() fyne.Size {
+	e := r.gutter.editor
+	th := e.Entry.Theme()
+	digits := len(strconv.Itoa(max(len(e.lineStarts), 1)))
+	char := fyne.MeasureText("0", th.Size(theme.SizeNameText), fyne.TextStyle{Monospace: true})
+	height := e.rowOffset(max(e.visualRows, 1))
+	return fyne.NewSize(float32(digits+markerWidth)*char.Width+2*th.Size(theme.SizeNameInnerPadding), height)
+}
+
+func (r *lineGutterRenderer) Refresh() {
+	e := r.gutter.editor
+	th := e.Entry.Theme()
+	variant := fyne.CurrentApp().Settings().ThemeVariant()
+	r.background.FillColor = th.Color(theme.ColorNameInputBackground, variant)
+
+	// Grow the pool of labels to one per line
+	for len(r.labels) < len(e.lineStarts) {
+		label := canvas.NewText("", nil)
+		label.TextStyle = fyne.TextStyle{Monospace: true}
+		label.Alignment = fyne.TextAlignTrailing
+		r.labels = append(r.labels, label)
+	}
+
+	for i, label := range r.labels {
+		line := i + 1
+		marker := "  "
+		if e.bookmarks[line] {
+			marker = "● "
+		}
+		label.Text = marker + strconv.Itoa(line)
+		label.TextSize = th.Size(theme.SizeNameText)
+		label.TextStyle.Bold = line == e.cursorLine
+		switch {
+		case line == e.cursorLine:
+			label.Color = th.Color(theme.ColorNamePrimary, variant)
+		case e.bookmarks[line]:
+			label.Color = th.Color(theme.ColorNameWarning, variant)
+		default:
+			label.Color = th.Color(theme.ColorNamePlaceHolder, variant)
+		}
+	}
+
+	r.objects = r.objects[:0]
+	r.objects = append(r.objects, r.background)
+	for _, label := range r.labels {
+		r.objects = append(r.objects, label)
+	}
+
+	r.Layout(r.gutter.Size())
+	canvas.Refresh(r.gutter)
+}
+
+func (r *lineGutterRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *lineGutterRenderer) Destroy() {}
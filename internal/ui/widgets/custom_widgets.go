@@ -2,11 +2,9 @@
 package widgets
 
 import (
-	"image/color"
-
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
 
@@ -22,8 +20,8 @@ func CyberButton(text string, icon fyne.Resource, onTapped func()) fyne.CanvasOb
 }
 
 // TerminalEntry creates a text entry field styled like a terminal
-func TerminalEntry() *widget.Entry {
-	entry := widget.NewMultiLineEntry()
+func TerminalEntry() *ShortcutEntry {
+	entry := NewMultiLineShortcutEntry()
 	entry.TextStyle = fyne.TextStyle{Monospace: true}
 
 	return entry
@@ -37,16 +35,15 @@ func HexTabItem(title string, content fyne.CanvasObject) *container.TabItem {
 	return container.NewTabItem(hexTitle, content)
 }
 
-// GradientBackground creates a gradient background with specified colors
-func GradientBackground(startColor, endColor color.Color) *canvas.LinearGradient {
-	gradient := canvas.NewLinearGradient(startColor, endColor, 0)
-	return gradient
+// GradientBackground creates a gradient background between two theme colors
+func GradientBackground(startColor, endColor fyne.ThemeColorName) *ThemedGradient {
+	return NewThemedGradient(startColor, endColor)
 }
 
-// GlowEffect adds a subtle glow effect to a canvas object
-func GlowEffect(obj fyne.CanvasObject, glowColor color.Color) fyne.CanvasObject {
+// GlowEffect adds a subtle glow effect in a theme color to a canvas object
+func GlowEffect(obj fyne.CanvasObject, glowColor fyne.ThemeColorName) fyne.CanvasObject {
 	// Create a rectangle with the glow color
-	glowRect := canvas.NewRectangle(glowColor)
+	glowRect := NewThemedRectangle(glowColor)
 
 	// Overlay the original object on top of the glow
 	return container.NewStack(glowRect, obj)
@@ -63,58 +60,56 @@ func HexagonalButton(icon fyne.Resource, onTapped func()) fyne.CanvasObject {
 	return btn
 }
 
-// DigitalText creates text with a digital/circuit styling
-func DigitalText(text string, size float32, textColor color.Color) fyne.CanvasObject {
-	label := canvas.NewText(text, textColor)
+// DigitalText creates text in a theme color with a digital/circuit styling
+func DigitalText(text string, size float32, textColor fyne.ThemeColorName) *ThemedText {
+	label := NewThemedText(text, textColor)
 	label.TextSize = size
 	label.TextStyle = fyne.TextStyle{Monospace: true, Bold: true}
 
 	return label
 }
 
-// StatusIndicator creates a colored circle indicator for status
-func StatusIndicator(status string) fyne.CanvasObject {
-	var statusColor color.Color
+// StatusLight is a colored circle indicating a status
+type StatusLight struct {
+	*fyne.Container
 
-	// Choose color based on status
-	switch status {
-	case "vulnerable":
-		statusColor = color.NRGBA{R: 255, G: 60, B: 60, A: 255} // Red
-	case "secure":
-		statusColor = color.NRGBA{R: 60, G: 220, B: 100, A: 255} // Green
-	case "warning":
-		statusColor = color.NRGBA{R: 255, G: 180, B: 50, A: 255} // Yellow
-	case "info":
-		statusColor = color.NRGBA{R: 80, G: 170, B: 255, A: 255} // Blue
-	default:
-		statusColor = color.Gray{Y: 150} // Gray for unknown
-	}
+	circle *ThemedRectangle
+}
 
+// StatusIndicator creates a colored circle indicator for status
+func StatusIndicator(status string) *StatusLight {
 	// Create a circle with the status color
-	circle := canvas.NewCircle(statusColor)
+	circle := NewThemedRectangle(StatusColorName(status))
+	circle.StrokeColorName = theme.ColorNameForeground
 	circle.StrokeWidth = 1
-	circle.StrokeColor = color.White
+	circle.CornerRadius = 6
 
 	// Set a fixed size for the circle
-	circleContainer := container.NewWithoutLayout(circle)
-	circleContainer.Resize(fyne.NewSize(12, 12))
+	circle.SetMinSize(fyne.NewSize(12, 12))
 
-	return circleContainer
+	return &StatusLight{Container: container.NewCenter(circle), circle: circle}
 }
 
-// NotePadWithLineNumbers creates a notepad with line numbers for code
-func NotePadWithLineNumbers() fyne.CanvasObject {
-	// Create the main text area
-	codeArea := widget.NewMultiLineEntry()
-	codeArea.TextStyle = fyne.TextStyle{Monospace: true}
-
-	// Create line numbers (simplified version, not dynamic)
-	lineNumbers := widget.NewLabel("1\n2\n3\n4\n5\n6\n7\n8\n9\n10")
-	lineNumbers.TextStyle = fyne.TextStyle{Monospace: true}
-	lineNumbers.Alignment = fyne.TextAlignTrailing
+// SetStatus changes the status the light shows
+func (l *StatusLight) SetStatus(status string) {
+	l.circle.ColorName = StatusColorName(status)
+	l.circle.Refresh()
+}
 
-	// Combine them in a horizontal container
-	return container.NewBorder(nil, nil, lineNumbers, nil, codeArea)
+// StatusColorName returns the theme color used for a status
+func StatusColorName(status string) fyne.ThemeColorName {
+	switch status {
+	case "vulnerable":
+		return theme.ColorNameError
+	case "secure":
+		return theme.ColorNameSuccess
+	case "warning":
+		return theme.ColorNameWarning
+	case "info":
+		return theme.ColorNamePrimary
+	default:
+		return theme.ColorNameDisabled
+	}
 }
 
 // HexDumpView creates a view that mimics a hex editor
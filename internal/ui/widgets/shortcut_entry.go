@@ -0,0 +1,60 @@
+// Package widgets provides custom UI widgets for the RevEnGo application.
+// This file contains an entry that lets window shortcuts through.
+package widgets
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ShortcutEntry is a text entry that passes keyboard shortcuts it has no
+// use for on to its window. A focused widget.Entry swallows every
+// shortcut, so without this the application's shortcuts would stop
+// working as soon as the user starts typing.
+type ShortcutEntry struct {
+	widget.Entry
+}
+
+// NewShortcutEntry creates a single-line entry
+func NewShortcutEntry() *ShortcutEntry {
+	e := &ShortcutEntry{}
+	e.Wrapping = fyne.TextWrap(fyne.TextTruncateClip)
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+// NewMultiLineShortcutEntry creates a multi-line entry
+func NewMultiLineShortcutEntry() *ShortcutEntry {
+	e := NewShortcutEntry()
+	e.MultiLine = true
+	return e
+}
+
+// TypedShortcut implements fyne.Shortcutable. Clipboard, undo and cursor
+// movement shortcuts stay with the entry; other custom shortcuts go to
+// the window canvas.
+func (e *ShortcutEntry) TypedShortcut(shortcut fyne.Shortcut) {
+	custom, ok := shortcut.(*desktop.CustomShortcut)
+	if !ok || editingShortcut(custom) {
+		e.Entry.TypedShortcut(shortcut)
+		return
+	}
+
+	c := fyne.CurrentApp().Driver().CanvasForObject(e)
+	if target, ok := c.(fyne.Shortcutable); ok {
+		target.TypedShortcut(shortcut)
+	}
+}
+
+// editingShortcut reports whether a shortcut moves the cursor or deletes
+// a word, which the entry handles itself
+func editingShortcut(shortcut *desktop.CustomShortcut) bool {
+	switch shortcut.KeyName {
+	case fyne.KeyLeft, fyne.KeyRight, fyne.KeyUp, fyne.KeyDown, fyne.KeyHome, fyne.KeyEnd:
+		return true
+	case fyne.KeyBackspace, fyne.KeyDelete:
+		return shortcut.Modifier&fyne.KeyModifierShift == 0
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+// Package widgets provides custom UI widgets for the RevEnGo application.
+// This file contains canvas primitives coloured by name from the theme.
+package widgets
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// themeColor looks up a named colour in the theme of a widget
+func themeColor(w interface{ Theme() fyne.Theme }, name fyne.ThemeColorName) color.Color {
+	if name == "" {
+		return color.Transparent
+	}
+	return w.Theme().Color(name, fyne.CurrentApp().Settings().ThemeVariant())
+}
+
+// ThemedRectangle is a rectangle whose colours are named theme colours.
+// Unlike a canvas.Rectangle it is recoloured when the theme changes.
+type ThemedRectangle struct {
+	widget.BaseWidget
+
+	// ColorName names the fill colour
+	ColorName fyne.ThemeColorName
+
+	// StrokeColorName names the outline colour; there is no outline if empty
+	StrokeColorName fyne.ThemeColorName
+
+	// StrokeWidth is the width of the outline
+	StrokeWidth float32
+
+	// CornerRadius rounds the corners
+	CornerRadius float32
+
+	// minSize is the smallest size the rectangle is laid out at
+	minSize fyne.Size
+}
+
+// NewThemedRectangle creates a rectangle filled with a named theme colour
+func NewThemedRectangle(name fyne.ThemeColorName) *ThemedRectangle {
+	r := &ThemedRectangle{ColorName: name}
+	r.ExtendBaseWidget(r)
+	return r
+}
+
+// SetMinSize sets the smallest size the rectangle is laid out at
+func (r *ThemedRectangle) SetMinSize(size fyne.Size) {
+	r.minSize = size
+	r.Refresh()
+}
+
+// MinSize implements fyne.Widget
+func (r *ThemedRectangle) MinSize() fyne.Size {
+	return r.minSize
+}
+
+// CreateRenderer implements fyne.Widget
+func (r *ThemedRectangle) CreateRenderer() fyne.WidgetRenderer {
+	rect := canvas.NewRectangle(color.Transparent)
+	return newThemedRenderer(rect, func() {
+		rect.FillColor = themeColor(r, r.ColorName)
+		rect.StrokeColor = themeColor(r, r.StrokeColorName)
+		rect.StrokeWidth = r.StrokeWidth
+		rect.CornerRadius = r.CornerRadius
+	}, r.MinSize)
+}
+
+// ThemedText is a line of text in a named theme colour.
+// Unlike a canvas.Text it is recoloured when the theme changes.
+type ThemedText struct {
+	widget.BaseWidget
+
+	// Text is the text shown
+	Text string
+
+	// ColorName names the text colour
+	ColorName fyne.ThemeColorName
+
+	// TextSize is the size of the text; 0 uses the theme's text size
+	TextSize float32
+
+	// TextStyle is the style of the text
+	TextStyle fyne.TextStyle
+}
+
+// NewThemedText creates text in a named theme colour
+func NewThemedText(text string, name fyne.ThemeColorName) *ThemedText {
+	t := &ThemedText{Text: text, ColorName: name}
+	t.ExtendBaseWidget(t)
+	return t
+}
+
+// SetText changes the text shown
+func (t *ThemedText) SetText(text string) {
+	t.Text = text
+	t.Refresh()
+}
+
+// CreateRenderer implements fyne.Widget
+func (t *ThemedText) CreateRenderer() fyne.WidgetRenderer {
+	text := canvas.NewText(t.Text, color.Transparent)
+	return newThemedRenderer(text, func() {
+		text.Text = t.Text
+		text.Color = themeColor(t, t.ColorName)
+		text.TextSize = t.TextSize
+		if text.TextSize == 0 {
+			text.TextSize = t.Theme().Size(theme.SizeNameText)
+		}
+		text.TextStyle = t.TextStyle
+	}, text.MinSize)
+}
+
+// ThemedGradient is a horizontal gradient between two named theme colours.
+// Unlike a canvas.LinearGradient it is recoloured when the theme changes.
+type ThemedGradient struct {
+	widget.BaseWidget
+
+	// StartColorName and EndColorName name the colours at the left and right edges
+	StartColorName, EndColorName fyne.ThemeColorName
+}
+
+// NewThemedGradient creates a horizontal gradient between two theme colours
+func NewThemedGradient(start, end fyne.ThemeColorName) *ThemedGradient {
+	g := &ThemedGradient{StartColorName: start, EndColorName: end}
+	g.ExtendBaseWidget(g)
+	return g
+}
+
+// CreateRenderer implements fyne.Widget
+func (g *ThemedGradient) CreateRenderer() fyne.WidgetRenderer {
+	gradient := canvas.NewLinearGradient(color.Transparent, color.Transparent, 0)
+	return newThemedRenderer(gradient, func() {
+		gradient.StartColor = themeColor(g, g.StartColorName)
+		gradient.EndColor = themeColor(g, g.EndColorName)
+	}, func() fyne.Size {
+		return fyne.Size{}
+	})
+}
+
+// themedRenderer renders a single canvas primitive that fills the widget,
+// re-reading its theme colours on every refresh
+type themedRenderer struct {
+	objects []fyne.CanvasObject
+	refresh func()
+	minSize func() fyne.Size
+}
+
+// newThemedRenderer creates a renderer for a primitive and applies the
+// theme colours to it.
+//
+// Parameters:
+//   - object: The primitive drawn
+//   - refresh: Copies the widget's properties and theme colours to the primitive
+//   - minSize: Returns the widget's minimum size
+func newThemedRenderer(object fyne.CanvasObject, refresh func(), minSize func() fyne.Size) *themedRenderer {
+	refresh()
+	return &themedRenderer{
+		objects: []fyne.CanvasObject{object},
+		refresh: refresh,
+		minSize: minSize,
+	}
+}
+
+func (r *themedRenderer) Layout(size fyne.Size) {
+	for _, o := range r.objects {
+		o.Resize(size)
+	}
+}
+
+func (r *themedRenderer) MinSize() fyne.Size {
+	return r.minSize()
+}
+
+func (r *themedRenderer) Refresh() {
+	r.refresh()
+	for _, o := range r.objects {
+		o.Refresh()
+	}
+}
+
+func (r *themedRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *themedRenderer) Destroy() {}
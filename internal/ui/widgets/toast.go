@@ -0,0 +1,85 @@
+// Package widgets provides custom UI widgets for the RevEnGo application.
+// This file contains toast notifications.
+package widgets
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+)
+
+// Toast timing and layout
+const (
+	// ToastDuration is how long a toast stays visible
+	ToastDuration = 3 * time.Second
+
+	// maxToasts is the number of toasts shown at once; older ones are
+	// dropped when more arrive
+	maxToasts = 4
+)
+
+// ToastLayer shows short notifications in the bottom right corner of the
+// content it is stacked over. Toasts disappear on their own, cannot be
+// tapped and never take the keyboard focus, so they do not interrupt typing.
+type ToastLayer struct {
+	*fyne.Container
+
+	// toasts holds the visible toasts, oldest first
+	toasts *fyne.Container
+}
+
+// NewToastLayer creates an empty toast layer to stack over a window's content
+func NewToastLayer() *ToastLayer {
+	toasts := container.NewVBox()
+	return &ToastLayer{
+		Container: container.New(&toastLayout{}, toasts),
+		toasts:    toasts,
+	}
+}
+
+// Notify displays a toast for ToastDuration.
+//
+// Parameters:
+//   - message: The text shown
+//   - colorName: The theme color of the toast's outline, such as
+//     theme.ColorNameSuccess or theme.ColorNameError
+func (l *ToastLayer) Notify(message string, colorName fyne.ThemeColorName) {
+	background := NewThemedRectangle(theme.ColorNameOverlayBackground)
+	background.StrokeColorName = colorName
+	background.StrokeWidth = 2
+	background.CornerRadius = 4
+
+	text := NewThemedText(message, theme.ColorNameForeground)
+	text.TextStyle = fyne.TextStyle{Monospace: true}
+	toast := container.NewStack(background, container.NewPadded(text))
+
+	if len(l.toasts.Objects) == maxToasts {
+		l.toasts.Remove(l.toasts.Objects[0])
+	}
+	l.toasts.Add(toast)
+	l.Refresh()
+
+	time.AfterFunc(ToastDuration, func() {
+		l.toasts.Remove(toast)
+		l.Refresh()
+	})
+}
+
+// toastLayout places the toasts at their minimum size in the bottom right
+// corner, inset by the theme padding
+type toastLayout struct{}
+
+func (t *toastLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	padding := theme.Padding() * 2
+	for _, o := range objects {
+		min := o.MinSize()
+		o.Resize(min)
+		o.Move(fyne.NewPos(size.Width-min.Width-padding, size.Height-min.Height-padding))
+	}
+}
+
+func (t *toastLayout) MinSize([]fyne.CanvasObject) fyne.Size {
+	return fyne.Size{}
+}
@@ -0,0 +1,228 @@
+// Package vuln scores and classifies vulnerabilities: CVSS vectors, the
+// CWE catalog and the version ranges a finding affects.
+package vuln
+
+import (
+	"fmt"
+	"strings"
+
+	gocvss30 "github.com/pandatix/go-cvss/30"
+	gocvss31 "github.com/pandatix/go-cvss/31"
+	gocvss40 "github.com/pandatix/go-cvss/40"
+)
+
+// Severity is the qualitative rating of a CVSS score
+type Severity string
+
+// Severities, from the CVSS v3.1 and v4.0 rating scales; SeverityUnscored
+// is a finding without a CVSS vector
+const (
+	SeverityUnscored Severity = ""
+	SeverityNone     Severity = "none"
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+// Severities lists the severities from most to least severe, ending with
+// findings not yet scored
+var Severities = []Severity{
+	SeverityCritical,
+	SeverityHigh,
+	SeverityMedium,
+	SeverityLow,
+	SeverityNone,
+	SeverityUnscored,
+}
+
+// Label names the severity for display, such as "Critical"
+func (s Severity) Label() string {
+	if s == SeverityUnscored {
+		return "Unscored"
+	}
+	return strings.ToUpper(string(s[:1])) + string(s[1:])
+}
+
+// Rank orders severities: higher is more severe, and unscored findings
+// rank lowest
+func (s Severity) Rank() int {
+	for i, severity := range Severities {
+		if severity == s {
+			return len(Severities) - i
+		}
+	}
+	return 0
+}
+
+// ParseSeverity reads a severity name in any case, such as "High"
+func ParseSeverity(name string) (Severity, error) {
+	for _, s := range Severities {
+		if strings.EqualFold(name, string(s)) || strings.EqualFold(name, s.Label()) {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unknown severity %q: use critical, high, medium, low, none or unscored", name)
+}
+
+// Rate gives the severity of a score on the CVSS rating scale
+func Rate(score float64) Severity {
+	switch {
+	case score >= 9.0:
+		return SeverityCritical
+	case score >= 7.0:
+		return SeverityHigh
+	case score >= 4.0:
+		return SeverityMedium
+	case score >= 0.1:
+		return SeverityLow
+	}
+	return SeverityNone
+}
+
+// Score is the result of scoring a CVSS vector
+type Score struct {
+	// Version is the CVSS version of the vector: "3.0", "3.1" or "4.0"
+	Version string
+
+	// Base is the score of the base metrics alone
+	Base float64
+
+	// Value is the score that applies: for CVSS v3, the environmental or
+	// temporal score when the vector sets those metrics, otherwise the
+	// base score; for CVSS v4.0, the score of all the metrics set
+	Value float64
+
+	// Nomenclature names the metric groups scored, such as "CVSS-B" for
+	// the base metrics alone or "CVSS-BTE" with threat and environmental
+	// metrics
+	Nomenclature string
+
+	Severity Severity
+}
+
+// String describes the score, as in "9.8 Critical (CVSS 3.1)" or
+// "7.2 High (CVSS 3.1 environmental, base 9.8)"
+func (s Score) String() string {
+	if s.Version == "" {
+		return "unscored"
+	}
+	detail := "CVSS " + s.Version
+	switch s.Nomenclature {
+	case "CVSS-B":
+	case "CVSS-BT":
+		detail += " temporal"
+		if s.Version == "4.0" {
+			detail = "CVSS-BT 4.0"
+		}
+	default:
+		detail += " environmental"
+		if s.Version == "4.0" {
+			detail = s.Nomenclature + " 4.0"
+		}
+	}
+	if s.Version != "4.0" && s.Value != s.Base {
+		detail += fmt.Sprintf(", base %.1f", s.Base)
+	}
+	return fmt.Sprintf("%.1f %s (%s)", s.Value, s.Severity.Label(), detail)
+}
+
+// ScoreVector computes the score of a CVSS v3.0, v3.1 or v4.0 vector
+// string, following the FIRST specifications; v4.0 uses the macrovector
+// lookup and interpolation of the specification's reference calculator.
+//
+// Parameters:
+//   - vector: A vector such as "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"
+//
+// Returns:
+//   - The score
+//   - An error if the vector is not a valid CVSS v3 or v4.0 vector
+func ScoreVector(vector string) (Score, error) {
+	vector = strings.TrimSpace(vector)
+	version, _, _ := strings.Cut(strings.TrimPrefix(vector, "CVSS:"), "/")
+	switch {
+	case !strings.HasPrefix(vector, "CVSS:"):
+		return Score{}, fmt.Errorf("invalid CVSS vector: expected it to start with CVSS:3.1/, CVSS:3.0/ or CVSS:4.0/")
+	case version == "3.0":
+		v, err := gocvss30.ParseVector(vector)
+		if err != nil {
+			return Score{}, vectorError(err)
+		}
+		return scoreV3("3.0", vector, v.BaseScore(), v.TemporalScore(), v.EnvironmentalScore()), nil
+	case version == "3.1":
+		v, err := gocvss31.ParseVector(vector)
+		if err != nil {
+			return Score{}, vectorError(err)
+		}
+		return scoreV3("3.1", vector, v.BaseScore(), v.TemporalScore(), v.EnvironmentalScore()), nil
+	case version == "4.0":
+		v, err := gocvss40.ParseVector(vector)
+		if err != nil {
+			return Score{}, vectorError(err)
+		}
+		score := Score{Version: "4.0", Value: v.Score(), Nomenclature: v.Nomenclature()}
+		score.Base = score.Value
+		if score.Nomenclature != "CVSS-B" {
+			// The base score is the score of the base metrics alone
+			base, err := gocvss40.ParseVector(baseMetrics(vector, cvss40Base))
+			if err == nil {
+				score.Base = base.Score()
+			}
+		}
+		score.Severity = Rate(score.Value)
+		return score, nil
+	}
+	return Score{}, fmt.Errorf("unsupported CVSS version %q: use 3.0, 3.1 or 4.0", version)
+}
+
+// scoreV3 picks the score that applies to a CVSS v3 vector
+func scoreV3(version, vector string, base, temporal, environmental float64) Score {
+	score := Score{Version: version, Base: base, Value: base, Nomenclature: "CVSS-B"}
+	metrics := setMetrics(vector)
+	if metrics["E"] || metrics["RL"] || metrics["RC"] {
+		score.Value, score.Nomenclature = temporal, "CVSS-BT"
+	}
+	for _, name := range []string{"CR", "IR", "AR", "MAV", "MAC", "MPR", "MUI", "MS", "MC", "MI", "MA"} {
+		if metrics[name] {
+			score.Value, score.Nomenclature = environmental, "CVSS-BTE"
+			break
+		}
+	}
+	score.Severity = Rate(score.Value)
+	return score
+}
+
+// cvss40Base lists the base metrics of CVSS v4.0
+var cvss40Base = []string{"AV", "AC", "AT", "PR", "UI", "VC", "VI", "VA", "SC", "SI", "SA"}
+
+// setMetrics returns the metrics a vector sets to a value other than X
+// (not defined)
+func setMetrics(vector string) map[string]bool {
+	metrics := make(map[string]bool)
+	for _, part := range strings.Split(vector, "/")[1:] {
+		if name, value, ok := strings.Cut(part, ":"); ok && value != "X" {
+			metrics[name] = true
+		}
+	}
+	return metrics
+}
+
+// baseMetrics keeps the CVSS header and the given metrics of a vector
+func baseMetrics(vector string, names []string) string {
+	parts := strings.Split(vector, "/")
+	kept := parts[:1]
+	for _, part := range parts[1:] {
+		name, _, _ := strings.Cut(part, ":")
+		for _, base := range names {
+			if name == base {
+				kept = append(kept, part)
+			}
+		}
+	}
+	return strings.Join(kept, "/")
+}
+
+// vectorError words the errors of the CVSS parsers
+func vectorError(err error) error {
+	return fmt.Errorf("invalid CVSS vector: %v", err)
+}
@@ -0,0 +1,115 @@
+package vuln
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScoreVector(t *testing.T) {
+	// Scores from the FIRST calculators and specification examples
+	tests := []struct {
+		vector       string
+		value, base  float64
+		severity     Severity
+		nomenclature string
+		text         string
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8, 9.8, SeverityCritical, "CVSS-B", "9.8 Critical (CVSS 3.1)"},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", 10.0, 10.0, SeverityCritical, "CVSS-B", "10.0 Critical (CVSS 3.1)"},
+		{"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", 7.8, 7.8, SeverityHigh, "CVSS-B", "7.8 High (CVSS 3.1)"},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1, 6.1, SeverityMedium, "CVSS-B", "6.1 Medium (CVSS 3.1)"},
+		{"CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:N/A:N", 5.9, 5.9, SeverityMedium, "CVSS-B", "5.9 Medium (CVSS 3.1)"},
+		{"CVSS:3.1/AV:P/AC:H/PR:H/UI:R/S:U/C:L/I:N/A:N", 1.6, 1.6, SeverityLow, "CVSS-B", "1.6 Low (CVSS 3.1)"},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", 0.0, 0.0, SeverityNone, "CVSS-B", "0.0 None (CVSS 3.1)"},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:P/RL:O/RC:C", 8.8, 9.8, SeverityHigh, "CVSS-BT", "8.8 High (CVSS 3.1 temporal, base 9.8)"},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/MAV:L", 8.4, 9.8, SeverityHigh, "CVSS-BTE", "8.4 High (CVSS 3.1 environmental, base 9.8)"},
+		// Metrics set to X are not defined and leave the base score
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:X/RL:X/RC:X/MAV:X", 9.8, 9.8, SeverityCritical, "CVSS-B", "9.8 Critical (CVSS 3.1)"},
+		{"CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8, 9.8, SeverityCritical, "CVSS-B", "9.8 Critical (CVSS 3.0)"},
+		{"  CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H\n", 7.8, 7.8, SeverityHigh, "CVSS-B", "7.8 High (CVSS 3.1)"},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", 9.3, 9.3, SeverityCritical, "CVSS-B", "9.3 Critical (CVSS 4.0)"},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:H/SI:H/SA:H", 10.0, 10.0, SeverityCritical, "CVSS-B", "10.0 Critical (CVSS 4.0)"},
+		{"CVSS:4.0/AV:L/AC:L/AT:N/PR:L/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", 8.5, 8.5, SeverityHigh, "CVSS-B", "8.5 High (CVSS 4.0)"},
+		{"CVSS:4.0/AV:L/AC:L/AT:N/PR:N/UI:N/VC:N/VI:N/VA:N/SC:N/SI:N/SA:N", 0.0, 0.0, SeverityNone, "CVSS-B", "0.0 None (CVSS 4.0)"},
+	}
+	for _, tt := range tests {
+		score, err := ScoreVector(tt.vector)
+		if err != nil {
+			t.Errorf("%s: %v", tt.vector, err)
+			continue
+		}
+		if score.Value != tt.value || score.Base != tt.base || score.Severity != tt.severity || score.Nomenclature != tt.nomenclature {
+			t.Errorf("%s: %+v; want %.1f (base %.1f) %s %s", tt.vector, score, tt.value, tt.base, tt.severity, tt.nomenclature)
+		}
+		if got := score.String(); got != tt.text {
+			t.Errorf("%s: %q; want %q", tt.vector, got, tt.text)
+		}
+	}
+}
+
+func TestScoreVectorV4Supplemental(t *testing.T) {
+	// Threat and environmental metrics lower the score of the base vector,
+	// which stays the base score
+	base := "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N"
+	tests := []struct {
+		metrics      string
+		nomenclature string
+	}{
+		{"/E:U", "CVSS-BT"},
+		{"/CR:L/IR:L/AR:L", "CVSS-BE"},
+		{"/E:P/CR:L/IR:L/AR:L", "CVSS-BTE"},
+	}
+	for _, tt := range tests {
+		score, err := ScoreVector(base + tt.metrics)
+		if err != nil {
+			t.Errorf("%s: %v", tt.metrics, err)
+			continue
+		}
+		if score.Nomenclature != tt.nomenclature || score.Base != 9.3 || score.Value >= score.Base {
+			t.Errorf("%s: %+v; want %s below base 9.3", tt.metrics, score, tt.nomenclature)
+		}
+		if want := tt.nomenclature + " 4.0)"; !strings.HasSuffix(score.String(), want) {
+			t.Errorf("%s: %q; want it to end with %q", tt.metrics, score.String(), want)
+		}
+	}
+}
+
+func TestScoreVectorInvalid(t *testing.T) {
+	tests := []struct {
+		vector, err string
+	}{
+		{"", "expected it to start with CVSS:"},
+		{"AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "expected it to start with CVSS:"},
+		{"CVSS:2.0/AV:N/AC:L/Au:N/C:C/I:C/A:C", `unsupported CVSS version "2.0"`},
+		{"CVSS:3.1/AV:N/AC:L", "invalid CVSS vector"},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:Q", "invalid CVSS vector"},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H", "invalid CVSS vector"},
+	}
+	for _, tt := range tests {
+		if _, err := ScoreVector(tt.vector); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: error %v; want one containing %q", tt.vector, err, tt.err)
+		}
+	}
+}
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  Severity
+	}{
+		{0, SeverityNone},
+		{0.1, SeverityLow},
+		{3.9, SeverityLow},
+		{4.0, SeverityMedium},
+		{6.9, SeverityMedium},
+		{7.0, SeverityHigh},
+		{8.9, SeverityHigh},
+		{9.0, SeverityCritical},
+		{10, SeverityCritical},
+	}
+	for _, tt := range tests {
+		if got := Rate(tt.score); got != tt.want {
+			t.Errorf("Rate(%.1f) = %s; want %s", tt.score, got, tt.want)
+		}
+	}
+}
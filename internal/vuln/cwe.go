@@ -0,0 +1,259 @@
+package vuln
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CWE is a weakness of the Common Weakness Enumeration
+type CWE struct {
+	ID   int
+	Name string
+}
+
+// Ref writes the identifier, such as "CWE-787"
+func (c CWE) Ref() string {
+	return "CWE-" + strconv.Itoa(c.ID)
+}
+
+// String writes the identifier and name, as in "CWE-787: Out-of-bounds Write"
+func (c CWE) String() string {
+	if c.Name == "" {
+		return c.Ref()
+	}
+	return c.Ref() + ": " + c.Name
+}
+
+// cweRefPattern matches a weakness reference such as "CWE-787" or "787"
+var cweRefPattern = regexp.MustCompile(`^(?i:CWE-?)?([0-9]+)$`)
+
+// ParseCWE reads a weakness reference, such as "CWE-787", "cwe-787" or
+// "787", naming it from the catalog when it is listed there.
+//
+// Returns:
+//   - The weakness; its name is empty if the catalog does not list it
+//   - An error if the reference is not a CWE identifier
+func ParseCWE(ref string) (CWE, error) {
+	match := cweRefPattern.FindStringSubmatch(strings.TrimSpace(ref))
+	if match == nil {
+		return CWE{}, fmt.Errorf("invalid CWE %q: expected an identifier such as CWE-787", ref)
+	}
+	id, err := strconv.Atoi(match[1])
+	if err != nil || id == 0 {
+		return CWE{}, fmt.Errorf("invalid CWE %q: expected an identifier such as CWE-787", ref)
+	}
+	if c, ok := catalogByID[id]; ok {
+		return c, nil
+	}
+	return CWE{ID: id}, nil
+}
+
+// CWECatalog returns the weaknesses of the bundled catalog, ordered by ID
+func CWECatalog() []CWE {
+	return append([]CWE(nil), catalog...)
+}
+
+// SearchCWE finds weaknesses in the bundled catalog. A query of digits,
+// with or without the "CWE-" prefix, matches IDs starting with them; other
+// queries match names containing every word of the query.
+//
+// Returns:
+//   - The matching weaknesses, ordered by ID; the whole catalog for an
+//     empty query
+func SearchCWE(query string) []CWE {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return CWECatalog()
+	}
+	digits := strings.TrimPrefix(strings.TrimPrefix(query, "cwe"), "-")
+	if _, err := strconv.Atoi(digits); err == nil {
+		var found []CWE
+		for _, c := range catalog {
+			if strings.HasPrefix(strconv.Itoa(c.ID), digits) {
+				found = append(found, c)
+			}
+		}
+		return found
+	}
+
+	words := strings.Fields(query)
+	var found []CWE
+	for _, c := range catalog {
+		name := strings.ToLower(c.Name)
+		matches := true
+		for _, word := range words {
+			if !strings.Contains(name, word) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+// catalogByID indexes the catalog
+var catalogByID = func() map[int]CWE {
+	sort.Slice(catalog, func(i, j int) bool { return catalog[i].ID < catalog[j].ID })
+	byID := make(map[int]CWE, len(catalog))
+	for _, c := range catalog {
+		byID[c.ID] = c
+	}
+	return byID
+}()
+
+// catalog holds the weaknesses met most often when reversing binaries,
+// firmware and protocols: the CWE Top 25, memory safety, numeric errors,
+// cryptography, authentication and hardware debug interfaces. Other IDs
+// are accepted without a name.
+var catalog = []CWE{
+	{20, "Improper Input Validation"},
+	{22, "Improper Limitation of a Pathname to a Restricted Directory ('Path Traversal')"},
+	{59, "Improper Link Resolution Before File Access ('Link Following')"},
+	{73, "External Control of File Name or Path"},
+	{77, "Improper Neutralization of Special Elements used in a Command ('Command Injection')"},
+	{78, "Improper Neutralization of Special Elements used in an OS Command ('OS Command Injection')"},
+	{79, "Improper Neutralization of Input During Web Page Generation ('Cross-site Scripting')"},
+	{88, "Improper Neutralization of Argument Delimiters in a Command ('Argument Injection')"},
+	{89, "Improper Neutralization of Special Elements used in an SQL Command ('SQL Injection')"},
+	{94, "Improper Control of Generation of Code ('Code Injection')"},
+	{95, "Improper Neutralization of Directives in Dynamically Evaluated Code ('Eval Injection')"},
+	{116, "Improper Encoding or Escaping of Output"},
+	{119, "Improper Restriction of Operations within the Bounds of a Memory Buffer"},
+	{120, "Buffer Copy without Checking Size of Input ('Classic Buffer Overflow')"},
+	{121, "Stack-based Buffer Overflow"},
+	{122, "Heap-based Buffer Overflow"},
+	{123, "Write-what-where Condition"},
+	{124, "Buffer Underwrite ('Buffer Underflow')"},
+	{125, "Out-of-bounds Read"},
+	{126, "Buffer Over-read"},
+	{127, "Buffer Under-read"},
+	{129, "Improper Validation of Array Index"},
+	{130, "Improper Handling of Length Parameter Inconsistency"},
+	{131, "Incorrect Calculation of Buffer Size"},
+	{134, "Use of Externally-Controlled Format String"},
+	{170, "Improper Null Termination"},
+	{190, "Integer Overflow or Wraparound"},
+	{191, "Integer Underflow (Wrap or Wraparound)"},
+	{193, "Off-by-one Error"},
+	{194, "Unexpected Sign Extension"},
+	{195, "Signed to Unsigned Conversion Error"},
+	{197, "Numeric Truncation Error"},
+	{200, "Exposure of Sensitive Information to an Unauthorized Actor"},
+	{203, "Observable Discrepancy"},
+	{208, "Observable Timing Discrepancy"},
+	{209, "Generation of Error Message Containing Sensitive Information"},
+	{215, "Insertion of Sensitive Information Into Debugging Code"},
+	{250, "Execution with Unnecessary Privileges"},
+	{252, "Unchecked Return Value"},
+	{256, "Plaintext Storage of a Password"},
+	{259, "Use of Hard-coded Password"},
+	{269, "Improper Privilege Management"},
+	{276, "Incorrect Default Permissions"},
+	{284, "Improper Access Control"},
+	{285, "Improper Authorization"},
+	{287, "Improper Authentication"},
+	{288, "Authentication Bypass Using an Alternate Path or Channel"},
+	{290, "Authentication Bypass by Spoofing"},
+	{294, "Authentication Bypass by Capture-replay"},
+	{295, "Improper Certificate Validation"},
+	{306, "Missing Authentication for Critical Function"},
+	{307, "Improper Restriction of Excessive Authentication Attempts"},
+	{311, "Missing Encryption of Sensitive Data"},
+	{312, "Cleartext Storage of Sensitive Information"},
+	{319, "Cleartext Transmission of Sensitive Information"},
+	{321, "Use of Hard-coded Cryptographic Key"},
+	{322, "Key Exchange without Entity Authentication"},
+	{323, "Reusing a Nonce, Key Pair in Encryption"},
+	{325, "Missing Cryptographic Step"},
+	{326, "Inadequate Encryption Strength"},
+	{327, "Use of a Broken or Risky Cryptographic Algorithm"},
+	{328, "Use of Weak Hash"},
+	{329, "Generation of Predictable IV with CBC Mode"},
+	{330, "Use of Insufficiently Random Values"},
+	{331, "Insufficient Entropy"},
+	{335, "Incorrect Usage of Seeds in Pseudo-Random Number Generator (PRNG)"},
+	{338, "Use of Cryptographically Weak Pseudo-Random Number Generator (PRNG)"},
+	{345, "Insufficient Verification of Data Authenticity"},
+	{347, "Improper Verification of Cryptographic Signature"},
+	{352, "Cross-Site Request Forgery (CSRF)"},
+	{362, "Concurrent Execution using Shared Resource with Improper Synchronization ('Race Condition')"},
+	{367, "Time-of-check Time-of-use (TOCTOU) Race Condition"},
+	{369, "Divide By Zero"},
+	{377, "Insecure Temporary File"},
+	{400, "Uncontrolled Resource Consumption"},
+	{401, "Missing Release of Memory after Effective Lifetime"},
+	{404, "Improper Resource Shutdown or Release"},
+	{415, "Double Free"},
+	{416, "Use After Free"},
+	{426, "Untrusted Search Path"},
+	{427, "Uncontrolled Search Path Element"},
+	{428, "Unquoted Search Path or Element"},
+	{434, "Unrestricted Upload of File with Dangerous Type"},
+	{457, "Use of Uninitialized Variable"},
+	{459, "Incomplete Cleanup"},
+	{476, "NULL Pointer Dereference"},
+	{489, "Active Debug Code"},
+	{494, "Download of Code Without Integrity Check"},
+	{502, "Deserialization of Untrusted Data"},
+	{521, "Weak Password Requirements"},
+	{522, "Insufficiently Protected Credentials"},
+	{532, "Insertion of Sensitive Information into Log File"},
+	{552, "Files or Directories Accessible to External Parties"},
+	{590, "Free of Memory not on the Heap"},
+	{601, "URL Redirection to Untrusted Site ('Open Redirect')"},
+	{611, "Improper Restriction of XML External Entity Reference"},
+	{617, "Reachable Assertion"},
+	{639, "Authorization Bypass Through User-Controlled Key"},
+	{662, "Improper Synchronization"},
+	{665, "Improper Initialization"},
+	{667, "Improper Locking"},
+	{672, "Operation on a Resource after Expiration or Release"},
+	{674, "Uncontrolled Recursion"},
+	{681, "Incorrect Conversion between Numeric Types"},
+	{682, "Incorrect Calculation"},
+	{690, "Unchecked Return Value to NULL Pointer Dereference"},
+	{754, "Improper Check for Unusual or Exceptional Conditions"},
+	{755, "Improper Handling of Exceptional Conditions"},
+	{759, "Use of a One-Way Hash without a Salt"},
+	{760, "Use of a One-Way Hash with a Predictable Salt"},
+	{763, "Release of Invalid Pointer or Reference"},
+	{770, "Allocation of Resources Without Limits or Throttling"},
+	{772, "Missing Release of Resource after Effective Lifetime"},
+	{787, "Out-of-bounds Write"},
+	{798, "Use of Hard-coded Credentials"},
+	{805, "Buffer Access with Incorrect Length Value"},
+	{822, "Untrusted Pointer Dereference"},
+	{823, "Use of Out-of-range Pointer Offset"},
+	{824, "Access of Uninitialized Pointer"},
+	{825, "Expired Pointer Dereference"},
+	{826, "Premature Release of Resource During Expected Lifetime"},
+	{833, "Deadlock"},
+	{834, "Excessive Iteration"},
+	{835, "Loop with Unreachable Exit Condition ('Infinite Loop')"},
+	{843, "Access of Resource Using Incompatible Type ('Type Confusion')"},
+	{862, "Missing Authorization"},
+	{863, "Incorrect Authorization"},
+	{908, "Use of Uninitialized Resource"},
+	{909, "Missing Initialization of Resource"},
+	{911, "Improper Update of Reference Count"},
+	{912, "Hidden Functionality"},
+	{913, "Improper Control of Dynamically-Managed Code Resources"},
+	{918, "Server-Side Request Forgery (SSRF)"},
+	{922, "Insecure Storage of Sensitive Information"},
+	{1021, "Improper Restriction of Rendered UI Layers or Frames"},
+	{1188, "Initialization of a Resource with an Insecure Default"},
+	{1191, "On-Chip Debug and Test Interface With Improper Access Control"},
+	{1240, "Use of a Cryptographic Primitive with a Risky Implementation"},
+	{1241, "Use of Predictable Algorithm in Random Number Generator"},
+	{1242, "Inclusion of Undocumented Features or Chicken Bits"},
+	{1256, "Improper Restriction of Software Interfaces to Hardware Features"},
+	{1326, "Missing Immutable Root of Trust in Hardware"},
+	{1333, "Inefficient Regular Expression Complexity"},
+	{1341, "Multiple Releases of Same Resource or Handle"},
+}
@@ -0,0 +1,224 @@
+package vuln
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// VersionRange is a set of versions a finding affects, such as
+// ">= 1.2, < 2.4.1"
+type VersionRange struct {
+	text string
+
+	// all reports the range of every version
+	all bool
+
+	// constraints must all hold for a version in the range
+	constraints []versionConstraint
+}
+
+// versionConstraint compares versions with a bound, such as "< 2.4.1"
+type versionConstraint struct {
+	op      string
+	version string
+}
+
+// ParseVersionRange reads a version range. A range is "*" for every
+// version, a version alone, "1.0 - 1.3" for the versions between two
+// versions inclusive, or comparisons with <, <=, >, >= or = separated by
+// commas or spaces, such as ">= 1.2, < 2.4.1".
+//
+// Versions are compared a component at a time, splitting them at dots,
+// dashes and changes between digits and letters: numbers compare
+// numerically, "1.0" equals "1.0.0", and a component of letters marks a
+// pre-release, so "1.0-rc1" comes before "1.0". A leading "v" is ignored.
+//
+// Returns:
+//   - The range
+//   - An error if the range is empty or a comparison has no version
+func ParseVersionRange(text string) (*VersionRange, error) {
+	r := &VersionRange{text: strings.TrimSpace(text)}
+	switch {
+	case r.text == "":
+		return nil, fmt.Errorf("empty version range")
+	case r.text == "*" || strings.EqualFold(r.text, "all"):
+		r.all = true
+		return r, nil
+	}
+	if low, high, ok := strings.Cut(r.text, " - "); ok {
+		low, high = strings.TrimSpace(low), strings.TrimSpace(high)
+		if !isVersion(low) || !isVersion(high) || strings.ContainsAny(low+high, "<>=, ") {
+			return nil, fmt.Errorf("invalid version range %q: expected a range such as 1.0 - 1.3", text)
+		}
+		r.constraints = []versionConstraint{{">=", low}, {"<=", high}}
+		return r, nil
+	}
+
+	// Operators may be written apart from their versions, as in "< 2.4"
+	var op string
+	for _, field := range strings.FieldsFunc(r.text, func(c rune) bool { return c == ',' || unicode.IsSpace(c) }) {
+		fieldOp := field[:len(field)-len(strings.TrimLeft(field, "<>="))]
+		version := field[len(fieldOp):]
+		switch {
+		case fieldOp != "" && op != "":
+			return nil, fmt.Errorf("invalid version range %q: %s has no version", text, op)
+		case fieldOp != "":
+			op = fieldOp
+		}
+		if version == "" {
+			continue
+		}
+		if !isVersion(version) {
+			return nil, fmt.Errorf("invalid version range %q: %q is not a version", text, version)
+		}
+		if op == "" {
+			op = "="
+		}
+		switch op {
+		case "<", "<=", ">", ">=", "=", "==":
+		default:
+			return nil, fmt.Errorf("invalid version range %q: unknown comparison %s", text, op)
+		}
+		r.constraints = append(r.constraints, versionConstraint{op, version})
+		op = ""
+	}
+	if op != "" {
+		return nil, fmt.Errorf("invalid version range %q: %s has no version", text, op)
+	}
+	return r, nil
+}
+
+// String returns the range as it was written
+func (r *VersionRange) String() string {
+	return r.text
+}
+
+// Contains reports whether a version is in the range
+func (r *VersionRange) Contains(version string) bool {
+	if r.all {
+		return true
+	}
+	for _, c := range r.constraints {
+		order := CompareVersions(version, c.version)
+		var holds bool
+		switch c.op {
+		case "<":
+			holds = order < 0
+		case "<=":
+			holds = order <= 0
+		case ">":
+			holds = order > 0
+		case ">=":
+			holds = order >= 0
+		default:
+			holds = order == 0
+		}
+		if !holds {
+			return false
+		}
+	}
+	return true
+}
+
+// CompareVersions orders two versions as ParseVersionRange describes.
+//
+// Returns:
+//   - -1 if a comes before b, 0 if they are equal and 1 if a comes after b
+func CompareVersions(a, b string) int {
+	ca, cb := releaseTrimmed(versionComponents(a)), releaseTrimmed(versionComponents(b))
+	for i := 0; i < max(len(ca), len(cb)); i++ {
+		switch {
+		case i >= len(ca):
+			if order := componentAfterEnd(cb[i]); order != 0 {
+				return -order
+			}
+			continue
+		case i >= len(cb):
+			if order := componentAfterEnd(ca[i]); order != 0 {
+				return order
+			}
+			continue
+		}
+		na, errA := strconv.ParseUint(ca[i], 10, 64)
+		nb, errB := strconv.ParseUint(cb[i], 10, 64)
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				return compare(na < nb)
+			}
+		case errA == nil:
+			// A release number comes after a pre-release tag
+			return 1
+		case errB == nil:
+			return -1
+		case ca[i] != cb[i]:
+			return compare(ca[i] < cb[i])
+		}
+	}
+	return 0
+}
+
+// componentAfterEnd orders a component against the end of a shorter
+// version: zeros are equal to nothing, other numbers come after it and
+// pre-release tags before it
+func componentAfterEnd(c string) int {
+	n, err := strconv.ParseUint(c, 10, 64)
+	switch {
+	case err != nil:
+		return -1
+	case n == 0:
+		return 0
+	}
+	return 1
+}
+
+// releaseTrimmed drops the zeros that end the release numbers of a
+// version, so that "1.0.0-rc1" compares like "1.0-rc1" and "1-rc1"
+func releaseTrimmed(components []string) []string {
+	release := len(components)
+	for i, c := range components {
+		if _, err := strconv.ParseUint(c, 10, 64); err != nil {
+			release = i
+			break
+		}
+	}
+	end := release
+	for end > 0 && componentAfterEnd(components[end-1]) == 0 {
+		end--
+	}
+	return append(components[:end:end], components[release:]...)
+}
+
+// compare turns a less-than result into -1 or 1
+func compare(less bool) int {
+	if less {
+		return -1
+	}
+	return 1
+}
+
+// isVersion reports whether text has a number or tag to compare
+func isVersion(text string) bool {
+	return len(versionComponents(text)) > 0
+}
+
+// versionComponents splits a version into numbers and lowercase tags
+func versionComponents(version string) []string {
+	version = strings.ToLower(strings.TrimSpace(version))
+	version = strings.TrimPrefix(version, "v")
+	var components []string
+	start := -1
+	for i, c := range version + "." {
+		separator := !unicode.IsLetter(c) && !unicode.IsDigit(c)
+		if start >= 0 && (separator || unicode.IsDigit(c) != unicode.IsDigit(rune(version[start]))) {
+			components = append(components, version[start:i])
+			start = -1
+		}
+		if !separator && start < 0 {
+			start = i
+		}
+	}
+	return components
+}
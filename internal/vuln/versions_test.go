@@ -0,0 +1,93 @@
+package vuln
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "1.0.0", 0},
+		{"1.0.0.0", "1", 0},
+		{"v2.1", "2.1", 0},
+		{"V2.1", "2.1", 0},
+		{"1.9", "1.10", -1},
+		{"1.0.1", "1.0", 1},
+		{"2", "10", -1},
+		{"1.0-rc1", "1.0", -1},
+		{"1.0rc1", "1.0", -1},
+		{"1.0-RC1", "1.0-rc1", 0},
+		{"1.0-rc2", "1.0-rc10", -1},
+		{"1.0-alpha", "1.0-beta", -1},
+		{"1.0-beta", "1.0-rc1", -1},
+		{"1.0-rc1", "1.0.1", -1},
+		{"1.0.0-rc1", "1.0-rc1", 0},
+		{"18446744073709551615", "18446744073709551614", 1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d; want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := CompareVersions(tt.b, tt.a); got != -tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d; want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestVersionRange(t *testing.T) {
+	tests := []struct {
+		text     string
+		contains []string
+		excludes []string
+	}{
+		{"*", []string{"0", "1.0", "99.9-rc1"}, nil},
+		{"all", []string{"1.0"}, nil},
+		{"1.2.3", []string{"1.2.3", "v1.2.3", "1.2.3.0"}, []string{"1.2.4", "1.2.3-rc1", "1.2"}},
+		{"= 1.2", []string{"1.2", "1.2.0"}, []string{"1.2.1"}},
+		{"== 1.2", []string{"1.2"}, []string{"1.3"}},
+		{">= 2.0, < 2.4.1", []string{"2.0", "2.0.0", "2.4", "2.4.0", "2.4.1-rc1"}, []string{"1.9.9", "2.0-rc1", "2.4.1", "2.10"}},
+		{">=2.0 <2.4.1", []string{"2.3"}, []string{"2.4.1"}},
+		{"> 1.0 <= 1.4", []string{"1.0.1", "1.4", "1.4.0"}, []string{"1.0", "1.4.1"}},
+		{"1.0 - 1.3", []string{"1.0", "1.1.7", "1.3", "1.3.0"}, []string{"0.9", "1.0-rc1", "1.3.1"}},
+		{"v1.0 - v1.3-rc2", []string{"1.3-rc1", "1.3-rc2"}, []string{"1.3"}},
+		{"  < 3  ", []string{"2.99", "3-rc1"}, []string{"3", "3.0.0", "4"}},
+	}
+	for _, tt := range tests {
+		r, err := ParseVersionRange(tt.text)
+		if err != nil {
+			t.Errorf("%q: %v", tt.text, err)
+			continue
+		}
+		for _, version := range tt.contains {
+			if !r.Contains(version) {
+				t.Errorf("%q does not contain %s", tt.text, version)
+			}
+		}
+		for _, version := range tt.excludes {
+			if r.Contains(version) {
+				t.Errorf("%q contains %s", tt.text, version)
+			}
+		}
+	}
+}
+
+func TestParseVersionRangeInvalid(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"   ",
+		">=",
+		">= 1.0, <",
+		">= <= 1.0",
+		"=> 1.0",
+		"!= 1.0",
+		"1.0 - ",
+		"1.0 - < 2.0",
+		">= 1.0 - 2.0",
+		"!!",
+	} {
+		if r, err := ParseVersionRange(text); err == nil {
+			t.Errorf("%q parsed as %v; want an error", text, r)
+		}
+	}
+}
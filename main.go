@@ -1,65 +1,127 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"os"
-	"path/filepath"
+	"strings"
 
 	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/dialog"
 
+	"github.com/leog/RevEnGo/internal/cli"
+	"github.com/leog/RevEnGo/internal/config"
 	"github.com/leog/RevEnGo/internal/models"
 	"github.com/leog/RevEnGo/internal/ui"
+	"github.com/leog/RevEnGo/internal/ui/components"
 	"github.com/leog/RevEnGo/internal/ui/theme"
 )
 
 func main() {
-	// This is the root object that manages the application lifecycle
-	a := app.New()
+	// Global options such as -data-dir and -profile come before any command
+	opts, args, err := config.ParseArgs(os.Args[1:], os.Stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	}
 
-	// Set up custom RevEnGo theme
-	a.Settings().SetTheme(theme.New())
+	// Load the persistent configuration, which defines the profiles
+	configPath, err := config.Path()
+	if err != nil {
+		fail(err)
+	}
+	settings, err := config.Load(configPath)
+	if err != nil {
+		fail(err)
+	}
 
-	// Create the main application window with a title
-	w := a.NewWindow("RevEnGo")
+	// Add the note types defined in the configuration to the built-in ones
+	if err := models.RegisterNoteTypes(settings.NoteTypes); err != nil {
+		log.Printf("Warning: %v", err)
+	}
 
-	homeDir, err := os.UserHomeDir() // The application will store all data in the user's home directory
+	// Open the data directory of the selected profile
+	profile, dataDir, err := settings.Resolve(opts)
 	if err != nil {
-		// If we can't access the home directory, the application cannot function
-		log.Fatalf("Error getting home directory: %v", err)
+		fail(err)
+	}
+	workspace, err := config.Open(profile, dataDir)
+	if err != nil {
+		fail(fmt.Errorf("opening data directory %s: %w", dataDir, err))
 	}
 
-	// Paths for storing application data
-	appDir := filepath.Join(homeDir, ".revengo")
-	notesDir := filepath.Join(appDir, "notes")       // For storing note files
-	projectsDir := filepath.Join(appDir, "projects") // For storing project files
-
-	// Ensure program flow directory exists (used for storing program flow diagrams)
-	programFlowDir := filepath.Join(homeDir, "Program Flow")
-	if err := os.MkdirAll(programFlowDir, 0755); err != nil {
-		log.Printf("Warning: Failed to create program flow directory: %v", err)
+	// Any arguments select a command-line subcommand; the GUI is never started
+	if len(args) > 0 {
+		commands := cli.New(workspace.Notes, workspace.Projects)
+		commands.DataDir = workspace.DataDir
+		commands.Changes = workspace.Changes
+		commands.Settings = settings
+		commands.Profile = profile
+		err := commands.Run(args)
+		workspace.Close()
+		if errors.Is(err, cli.ErrUsage) {
+			os.Exit(2)
+		}
+		if err != nil {
+			fail(err)
+		}
+		return
 	}
 
-	// Initialize the note storage system
-	noteStore, err := models.NewFileNoteStore(notesDir)
-	if err != nil {
-		log.Fatalf("Error initializing note store: %v", err)
+	// This is the root object that manages the application lifecycle
+	a := app.New()
+
+	// Set up the RevEnGo theme chosen in the settings, which may be one of
+	// the user's theme files
+	if _, err := theme.LoadDir(settings.ThemesDir()); err != nil {
+		log.Printf("Warning: %v", err)
 	}
+	components.RegisterNoteTypeColors()
+	a.Settings().SetTheme(theme.Named(settings.Settings.Theme))
 
-	// Initialize the project storage system
-	projectStore, err := models.NewFileProjectStore(projectsDir)
-	if err != nil {
-		log.Fatalf("Error initializing project store: %v", err)
+	// Create the main application window with a title
+	w := a.NewWindow("RevEnGo")
+
+	// Ensure program flow directory exists (used for storing program flow diagrams)
+	if err := os.MkdirAll(workspace.ProgramFlowDir(), 0755); err != nil {
+		log.Printf("Warning: Failed to create program flow directory: %v", err)
 	}
 
-	// Create config for UI setup
-	config := ui.AppConfig{
-		NoteStore:    noteStore,
-		ProjectStore: projectStore,
+	// Program flow diagrams were kept in ~/Program Flow before profiles
+	// existed; the default profile in the default data directory, which
+	// holds the data of that time, takes them over. Another -data-dir
+	// leaves them alone.
+	var migration string
+	if legacyDir, err := config.LegacyProgramFlowDir(); err == nil && profile == config.DefaultProfile && workspace.InDefaultDataDir() {
+		moved, kept, err := workspace.MigrateProgramFlow(legacyDir)
+		switch {
+		case err != nil:
+			log.Printf("Warning: moving program flow diagrams from %s: %v", legacyDir, err)
+			migration = fmt.Sprintf("Program flow diagrams are now kept in %s, but those in %s could not be moved: %v", workspace.ProgramFlowDir(), legacyDir, err)
+		case len(kept) > 0:
+			migration = fmt.Sprintf("Program flow diagrams are now kept in %s. %d were moved there from %s; %d were left there because the name was taken or the move failed: %s",
+				workspace.ProgramFlowDir(), len(moved), legacyDir, len(kept), strings.Join(kept, ", "))
+		case len(moved) > 0:
+			migration = fmt.Sprintf("Program flow diagrams are now kept in %s. %d were moved there from %s.", workspace.ProgramFlowDir(), len(moved), legacyDir)
+		}
 	}
 
 	// Set up the main window with the configuration
-	ui.SetupMainWindow(w, config)
+	ui.SetupMainWindow(w, ui.NewAppConfig(settings, workspace))
+	if migration != "" {
+		dialog.ShowInformation("Program Flow Moved", migration, w)
+	}
 
 	// Start the application
 	w.ShowAndRun()
 }
+
+// fail reports an error that prevents RevEnGo from starting and exits
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "revengo: %v\n", err)
+	os.Exit(1)
+}